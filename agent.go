@@ -2,8 +2,16 @@ package blades
 
 import (
 	"context"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// defaultMaxIterations bounds the tool loop when MaxIterations is not set.
+const defaultMaxIterations = 10
+
 var (
 	_ Runner = (*Agent)(nil)
 )
@@ -39,6 +47,16 @@ func WithTools(tools ...*Tool) Option {
 	}
 }
 
+// WithParallelTools runs up to limit of the tool calls requested by the model in
+// one turn concurrently, instead of one after the other. Calls are approved in
+// order before any of them runs, and their results are reported in the order of
+// the calls whatever order they complete in.
+func WithParallelTools(limit int) Option {
+	return func(a *Agent) {
+		a.toolConcurrency = limit
+	}
+}
+
 // WithMemory sets the memory for the Agent.
 func WithMemory(m Memory) Option {
 	return func(a *Agent) {
@@ -53,15 +71,85 @@ func WithMiddleware(m Middleware) Option {
 	}
 }
 
+// WithPromptRegistry resolves the Agent instructions from the active version of the
+// named prompt on every run, so pinning or rolling back takes effect immediately.
+// The version used is recorded in the metadata of the generated messages.
+func WithPromptRegistry(registry *PromptRegistry, name string) Option {
+	return func(a *Agent) {
+		a.prompts = registry
+		a.promptName = name
+	}
+}
+
+// WithEnvironment appends the current date, time, timezone, locale and custom
+// facts to the Agent instructions on every run.
+func WithEnvironment(opts ...EnvironmentOption) Option {
+	return func(a *Agent) {
+		a.environment = NewEnvironment(opts...)
+	}
+}
+
+// WithPricing sets the pricing used to report the cost of streamed generations.
+func WithPricing(pricing Pricing) Option {
+	return func(a *Agent) {
+		a.pricing = pricing
+	}
+}
+
+// WithRetry retries transient provider failures of the Agent with the policy.
+func WithRetry(policy RetryPolicy) Option {
+	return func(a *Agent) {
+		a.retry = &policy
+	}
+}
+
+// WithModelOptions sets model options applied to every run of the Agent before
+// the options of the run.
+func WithModelOptions(opts ...ModelOption) Option {
+	return func(a *Agent) {
+		a.modelOptions = opts
+	}
+}
+
+// WithGrounding grounds the responses of the Agent in web search results, e.g.
+// Google Search for Gemini, surfacing the sources on the Generation.
+func WithGrounding() Option {
+	return func(a *Agent) {
+		a.modelOptions = append(slices.Clip(a.modelOptions), WebSearch())
+	}
+}
+
 // Agent is a struct that represents an AI agent.
+//
+// An Agent is safe for concurrent Run and RunStream calls: its configuration
+// is not modified after NewAgent, and the state of a run is kept per call.
+// Servers should create agents, and their providers, once and share them, so
+// that requests reuse the pooled connections of the provider.
 type Agent struct {
-	name         string
-	model        string
-	instructions string
-	middleware   Middleware
-	provider     ModelProvider
-	memory       Memory
-	tools        []*Tool
+	name             string
+	description      string
+	model            string
+	instructions     string
+	middleware       Middleware
+	provider         ModelProvider
+	memory           Memory
+	tools            []*Tool
+	toolConcurrency  int
+	prompts          *PromptRegistry
+	promptName       string
+	environment      *Environment
+	pricing          Pricing
+	retry            *RetryPolicy
+	modelOptions     []ModelOption
+	autonomy         *autonomy
+	approval         *toolApproval
+	handoffs         []*Agent
+	guardrails       []Guardrail
+	outputGuardrails []OutputGuardrail
+	repairs          int
+	provenance       *provenance
+	spillover        *spillover
+	logger           *slog.Logger
 }
 
 // NewAgent creates a new Agent with the given name and options.
@@ -76,19 +164,56 @@ func NewAgent(name string, opts ...Option) *Agent {
 	return a
 }
 
-func (a *Agent) buildContext(ctx context.Context) context.Context {
-	return NewContext(ctx, &AgentContext{
-		Model:        a.model,
-		Instructions: a.instructions,
-	})
+// Clone returns a copy of the Agent with opts applied on top of its configuration.
+func (a *Agent) Clone(opts ...Option) *Agent {
+	c := *a
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &c
+}
+
+// resolveInstructions returns the instructions for a run and, when a prompt
+// registry is configured, the prompt version they come from.
+func (a *Agent) resolveInstructions() (string, *PromptVersion, error) {
+	var (
+		instructions = a.instructions
+		version      *PromptVersion
+	)
+	if a.prompts != nil {
+		var err error
+		if version, err = a.prompts.Active(a.promptName); err != nil {
+			return "", nil, err
+		}
+		instructions = version.Template
+	}
+	if a.environment != nil {
+		instructions = strings.TrimSpace(instructions + "\n\n" + a.environment.String())
+	}
+	return instructions, version, nil
+}
+
+func (a *Agent) buildContext(ctx context.Context, prompt *Prompt, instructions string, version *PromptVersion) context.Context {
+	agent := &AgentContext{
+		Name:           a.name,
+		Model:          a.model,
+		Instructions:   instructions,
+		ConversationID: prompt.ConversationID,
+		PromptVersion:  version,
+	}
+	claimMemory(ctx, agent)
+	return NewContext(ctx, agent)
 }
 
 // buildRequest builds the request for the Agent by combining system instructions and user messages.
-func (a *Agent) buildRequest(ctx context.Context, prompt *Prompt) (*ModelRequest, error) {
+func (a *Agent) buildRequest(ctx context.Context, prompt *Prompt, instructions string) (*ModelRequest, error) {
 	req := ModelRequest{Model: a.model, Tools: a.tools}
+	if len(a.handoffs) > 0 {
+		req.Tools = append(slices.Clip(a.tools), a.handoffTools()...)
+	}
 	// system messages
-	if a.instructions != "" {
-		req.Messages = append(req.Messages, SystemMessage(a.instructions))
+	if instructions != "" {
+		req.Messages = append(req.Messages, SystemMessage(instructions))
 	}
 	// memory messages
 	if a.memory != nil {
@@ -105,11 +230,25 @@ func (a *Agent) buildRequest(ctx context.Context, prompt *Prompt) (*ModelRequest
 	return &req, nil
 }
 
-func (a *Agent) addMemory(ctx context.Context, prompt *Prompt, res *ModelResponse) error {
+// addMemory records the prompt and the produced messages to the memory of the
+// Agent, or defers it when the run may be retried.
+func (a *Agent) addMemory(ctx context.Context, prompt *Prompt, produced []*Message) error {
+	if d, ok := ctx.Value(ctxMemoryKey{}).(*deferredMemory); ok {
+		if agent, _ := FromContext(ctx); agent == d.agent {
+			d.record = func(prompt *Prompt) error {
+				return a.storeMemory(ctx, prompt, produced)
+			}
+			return nil
+		}
+	}
+	return a.storeMemory(ctx, prompt, produced)
+}
+
+func (a *Agent) storeMemory(ctx context.Context, prompt *Prompt, produced []*Message) error {
 	if a.memory != nil {
-		messages := make([]*Message, 0, len(prompt.Messages)+1)
+		messages := make([]*Message, 0, len(prompt.Messages)+len(produced))
 		messages = append(messages, prompt.Messages...)
-		messages = append(messages, res.Messages...)
+		messages = append(messages, produced...)
 		if err := a.memory.AddMessages(ctx, prompt.ConversationID, messages); err != nil {
 			return err
 		}
@@ -117,61 +256,319 @@ func (a *Agent) addMemory(ctx context.Context, prompt *Prompt, res *ModelRespons
 	return nil
 }
 
+// modelProvider returns the provider of the Agent, wrapped with its logger and
+// retry policy.
+func (a *Agent) modelProvider() ModelProvider {
+	provider := a.provider
+	if a.logger != nil {
+		provider = NewLoggingProvider(provider, a.logger)
+	}
+	if a.retry != nil {
+		provider = NewRetryProvider(provider, *a.retry)
+	}
+	return provider
+}
+
+func (a *Agent) maxIterations(opts []ModelOption) int {
+	opt := ModelOptions{MaxIterations: defaultMaxIterations}
+	for _, apply := range opts {
+		apply(&opt)
+	}
+	return opt.MaxIterations
+}
+
+// generate calls the model and executes the tools it requests until it answers
+// without tool calls. It returns the final generation, carrying the trace of tool
+// invocations and the total usage, and every message produced along the way.
+// When the model hands the conversation off, the generation so far is returned
+// along with the peer.
+func (a *Agent) generate(ctx context.Context, req *ModelRequest, opts ...ModelOption) (*Generation, []*Message, *Agent, error) {
+	var (
+		usage    *Usage
+		trace    []*ToolInvocation
+		warnings []Warning
+		produced []*Message
+		messages = slices.Clone(req.Messages)
+		provider = a.modelProvider()
+		guard    = a.guard()
+	)
+	for range a.maxIterations(opts) {
+		res, err := provider.Generate(ctx, &ModelRequest{Model: req.Model, Tools: req.Tools, Messages: messages}, opts...)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if res.Usage != nil {
+			if usage == nil {
+				usage = &Usage{}
+			}
+			usage.Add(res.Usage)
+		}
+		warnings = append(warnings, responseWarnings(res)...)
+		produced = append(produced, res.Messages...)
+		calls := pendingToolCalls(res.Messages)
+		if len(calls) == 0 {
+			if err := a.spillover.spillAnswers(ctx, res.Messages); err != nil {
+				return nil, nil, nil, err
+			}
+			return &Generation{Messages: res.Messages, ToolTrace: trace, Usage: usage, Grounding: res.Grounding, Warnings: warnings}, produced, nil, nil
+		}
+		if peer := a.handoffTarget(calls); peer != nil {
+			return &Generation{ToolTrace: trace, Usage: usage, Warnings: warnings}, produced, peer, nil
+		}
+		result, invocations, err := callTools(ctx, a.tools, calls, guard, a.toolConcurrency)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if err := a.spillover.spillResults(ctx, result); err != nil {
+			return nil, nil, nil, err
+		}
+		logToolCalls(ctx, a.logger, invocations)
+		trace = append(trace, invocations...)
+		produced = append(produced, result)
+		messages = append(messages, res.Messages...)
+		messages = append(messages, result)
+	}
+	return nil, nil, nil, ErrMaxIterations
+}
+
+// stream is the streaming counterpart of generate. Every chunk is forwarded to
+// the returned stream, followed by a tool message and the trace of its invocations
+// whenever tools are executed;
+// once the model completes a turn without tool calls the produced messages are
+// passed to done and a terminal generation carrying the Finish summary is sent.
+// When the model hands the conversation off, the stream of the peer opened by
+// handoff is forwarded instead.
+func (a *Agent) stream(ctx context.Context, req *ModelRequest, version *PromptVersion, done func([]*Message) error, handoff func(*Agent) (Streamer[*Generation], error), opts ...ModelOption) (Streamer[*Generation], error) {
+	started := Now()
+	iterations := a.maxIterations(opts)
+	if iterations < 1 {
+		return nil, ErrMaxIterations
+	}
+	provider := a.modelProvider()
+	stream, err := provider.NewStream(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	pipe := NewStreamPipe[*Generation]()
+	pipe.Go(func() error {
+		var (
+			usage    *Usage
+			warnings []Warning
+			produced []*Message
+			messages = slices.Clone(req.Messages)
+			guard    = a.guard()
+		)
+		for i := 1; ; i++ {
+			var completed []*Message
+			for stream.Next() {
+				res, err := stream.Current()
+				if err != nil {
+					drain(stream)
+					return err
+				}
+				stampPromptVersion(res.Messages, version)
+				if res.Usage != nil {
+					if usage == nil {
+						usage = &Usage{}
+					}
+					usage.Add(res.Usage)
+				}
+				for _, msg := range res.Messages {
+					if msg.Status == StatusCompleted {
+						completed = append(completed, msg)
+						if err := a.spillover.spillAnswers(ctx, []*Message{msg}); err != nil {
+							drain(stream)
+							return err
+						}
+					}
+				}
+				chunkWarnings := responseWarnings(res)
+				warnings = append(warnings, chunkWarnings...)
+				pipe.Send(&Generation{Messages: res.Messages, Usage: res.Usage, Grounding: res.Grounding, Warnings: chunkWarnings})
+			}
+			if err := stream.Close(); err != nil {
+				return err
+			}
+			produced = append(produced, completed...)
+			calls := pendingToolCalls(completed)
+			if len(calls) == 0 {
+				if err := done(produced); err != nil {
+					return err
+				}
+				pipe.Send(&Generation{Finish: a.finish(req.Model, completed, usage, started), Warnings: warnings})
+				return nil
+			}
+			if peer := a.handoffTarget(calls); peer != nil {
+				return forwardHandoff(pipe, peer, handoff)
+			}
+			result, trace, err := callTools(ctx, a.tools, calls, guard, a.toolConcurrency)
+			if err != nil {
+				return err
+			}
+			if err := a.spillover.spillResults(ctx, result); err != nil {
+				return err
+			}
+			logToolCalls(ctx, a.logger, trace)
+			pipe.Send(&Generation{Messages: []*Message{result}, ToolTrace: trace})
+			produced = append(produced, result)
+			messages = append(messages, completed...)
+			messages = append(messages, result)
+			// the budget is spent: do not pay for a turn that cannot be used
+			if i >= iterations {
+				return ErrMaxIterations
+			}
+			if stream, err = provider.NewStream(ctx, &ModelRequest{Model: req.Model, Tools: req.Tools, Messages: messages}, opts...); err != nil {
+				return err
+			}
+		}
+	})
+	return pipe, nil
+}
+
+// finish summarizes a stream that ended with the completed messages.
+func (a *Agent) finish(model string, completed []*Message, usage *Usage, started time.Time) *Finish {
+	f := &Finish{Reason: "stop", Usage: usage, Elapsed: Since(started)}
+	for _, msg := range completed {
+		if reason := msg.Metadata["finish_reason"]; reason != "" {
+			f.Reason = reason
+		}
+	}
+	if a.pricing != nil && usage != nil {
+		f.Cost = a.pricing.Cost(model, usage)
+	}
+	return f
+}
+
+// options returns the model options of the Agent followed by opts.
+func (a *Agent) options(opts []ModelOption) []ModelOption {
+	if len(a.modelOptions) == 0 {
+		return opts
+	}
+	return append(slices.Clip(a.modelOptions), opts...)
+}
+
 // Run runs the agent with the given prompt and options, returning the response message.
 func (a *Agent) Run(ctx context.Context, prompt *Prompt, opts ...ModelOption) (*Generation, error) {
-	req, err := a.buildRequest(ctx, prompt)
+	instructions, version, err := a.resolveInstructions()
 	if err != nil {
 		return nil, err
 	}
-	ctx = a.buildContext(ctx)
-	handler := a.middleware(a.handler(req))
-	return handler.Run(ctx, prompt, opts...)
+	ctx = a.buildContext(ctx, prompt, instructions, version)
+	if a.logger != nil || a.provenance != nil {
+		ctx, _ = ensureRunID(ctx)
+	}
+	if prompt, err = a.checkInput(ctx, prompt); err != nil {
+		return nil, err
+	}
+	handler := a.middleware(a.handler(instructions, version, opts))
+	res, err := a.runValidated(ctx, handler, prompt, a.options(opts)...)
+	if err == nil {
+		err = a.stampProvenance(ctx, res)
+	}
+	if err != nil && a.logger != nil {
+		a.logger.LogAttrs(ctx, slog.LevelError, "agent run failed", append(runAttrs(ctx), slog.Any("error", err))...)
+	}
+	return res, err
 }
 
 // RunStream runs the agent with the given prompt and options, returning a streamable response.
 func (a *Agent) RunStream(ctx context.Context, prompt *Prompt, opts ...ModelOption) (Streamer[*Generation], error) {
-	req, err := a.buildRequest(ctx, prompt)
+	instructions, version, err := a.resolveInstructions()
+	if err != nil {
+		return nil, err
+	}
+	ctx = a.buildContext(ctx, prompt, instructions, version)
+	if a.logger != nil || a.provenance != nil {
+		ctx, _ = ensureRunID(ctx)
+	}
+	if prompt, err = a.checkInput(ctx, prompt); err != nil {
+		return nil, err
+	}
+	handler := a.middleware(a.handler(instructions, version, opts))
+	stream, err := handler.Stream(ctx, prompt, a.options(opts)...)
 	if err != nil {
 		return nil, err
 	}
-	ctx = a.buildContext(ctx)
-	handler := a.middleware(a.handler(req))
-	return handler.Stream(ctx, prompt, opts...)
+	return a.streamProvenance(ctx, a.streamValidated(ctx, stream)), nil
 }
 
 // handler constructs the default handlers for Run and Stream using the provider.
-func (a *Agent) handler(req *ModelRequest) Handler {
+// The request is built from the prompt the handler receives, so middlewares may
+// rewrite the prompt.
+func (a *Agent) handler(instructions string, version *PromptVersion, runOpts []ModelOption) Handler {
 	return Handler{
 		Run: func(ctx context.Context, p *Prompt, opts ...ModelOption) (*Generation, error) {
-			res, err := a.provider.Generate(ctx, req, opts...)
+			req, err := a.buildRequest(ctx, p, instructions)
 			if err != nil {
 				return nil, err
 			}
-			if err := a.addMemory(ctx, p, res); err != nil {
+			res, produced, peer, err := a.generate(ctx, req, opts...)
+			if err != nil {
 				return nil, err
 			}
-			return &Generation{res.Messages}, nil
+			if peer != nil {
+				handed, err := handOff(ctx, peer, p, runOpts)
+				if err != nil {
+					return nil, err
+				}
+				if res.Usage != nil {
+					if handed.Usage == nil {
+						handed.Usage = &Usage{}
+					}
+					handed.Usage.Add(res.Usage)
+				}
+				handed.ToolTrace = append(res.ToolTrace, handed.ToolTrace...)
+				return handed, nil
+			}
+			stampPromptVersion(produced, version)
+			if err := a.addMemory(ctx, p, produced); err != nil {
+				return nil, err
+			}
+			return res, nil
 		},
 		Stream: func(ctx context.Context, p *Prompt, opts ...ModelOption) (Streamer[*Generation], error) {
-			stream, err := a.provider.NewStream(ctx, req, opts...)
+			req, err := a.buildRequest(ctx, p, instructions)
 			if err != nil {
 				return nil, err
 			}
-			return NewMappedStream[*ModelResponse, *Generation](stream, func(m *ModelResponse) (*Generation, error) {
-				if err := a.addMemory(ctx, p, m); err != nil {
-					return nil, err
-				}
-				return &Generation{m.Messages}, nil
-			}), nil
+			return a.stream(ctx, req, version, func(produced []*Message) error {
+				return a.addMemory(ctx, p, produced)
+			}, func(peer *Agent) (Streamer[*Generation], error) {
+				return handOffStream(ctx, peer, p, runOpts)
+			}, opts...)
 		},
 	}
 }
 
+// stampPromptVersion records the prompt version in the metadata of messages.
+func stampPromptVersion(messages []*Message, version *PromptVersion) {
+	if version == nil {
+		return
+	}
+	for _, msg := range messages {
+		if msg.Metadata == nil {
+			msg.Metadata = make(map[string]string)
+		}
+		msg.Metadata["prompt_name"] = version.Name
+		msg.Metadata["prompt_version"] = strconv.Itoa(version.Version)
+	}
+}
+
 // Name returns the agent's name
 func (a *Agent) Name() string {
 	return a.name
 }
 
+// Description returns the agent's description
+func (a *Agent) Description() string {
+	return a.description
+}
+
+// Model returns the agent's model
+func (a *Agent) Model() string {
+	return a.model
+}
+
 // Instructions returns the agent's instructions
 func (a *Agent) Instructions() string {
 	return a.instructions
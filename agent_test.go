@@ -0,0 +1,206 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAgent_ToolLoop(t *testing.T) {
+	provider := weatherProvider()
+	agent := NewAgent("test", WithProvider(provider), WithTools(weatherTool()))
+	res, err := agent.Run(context.Background(), NewPrompt(UserMessage("weather in Paris?")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Text(); got != "weather is sunny" || provider.Calls() != 2 {
+		t.Fatalf("unexpected result %q after %d calls", got, provider.Calls())
+	}
+	if len(res.ToolTrace) != 1 || res.ToolTrace[0].Name != "weather" || res.ToolTrace[0].Result != "sunny" {
+		t.Fatalf("unexpected tool trace %+v", res.ToolTrace)
+	}
+	if res.Usage == nil || res.Usage.TotalTokens != 20 {
+		t.Fatalf("expected usage summed over both calls, got %+v", res.Usage)
+	}
+
+	stream, err := agent.RunStream(context.Background(), NewPrompt(UserMessage("weather in Paris?")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last, finish *Generation
+	for stream.Next() {
+		g, err := stream.Current()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.Finish != nil {
+			finish = g
+		} else {
+			last = g
+		}
+	}
+	if last == nil || last.Text() != "weather is sunny" {
+		t.Fatalf("unexpected stream result %+v", last)
+	}
+	if finish == nil || finish.Finish.Reason != "stop" || finish.Finish.Usage.TotalTokens != 20 {
+		t.Fatalf("unexpected finish event %+v", finish)
+	}
+}
+
+func TestAgent_ConcurrentRuns(t *testing.T) {
+	provider := weatherProvider()
+	agent := NewAgent("test", WithProvider(provider), WithTools(weatherTool()), WithMiddleware(NewCostTracker(DefaultPriceTable()).Middleware()))
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := agent.Run(context.Background(), NewPrompt(UserMessage("weather in Paris?")))
+			if err != nil || res.Text() != "weather is sunny" {
+				t.Errorf("unexpected result %v: %v", res, err)
+			}
+			stream, err := agent.RunStream(context.Background(), NewPrompt(UserMessage("weather in Paris?")))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			for stream.Next() {
+			}
+			if err := stream.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := provider.Calls(); got != 16*4 {
+		t.Fatalf("expected %d provider calls, got %d", 16*4, got)
+	}
+}
+
+func TestAgent_ToolFailureIsReported(t *testing.T) {
+	failing := &Tool{
+		Name: "weather",
+		Handle: func(ctx context.Context, args string) (string, error) {
+			return "", errors.New("service unavailable")
+		},
+	}
+	agent := NewAgent("test", WithProvider(weatherProvider()), WithTools(failing))
+	res, err := agent.Run(context.Background(), NewPrompt(UserMessage("weather in Paris?")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.ToolTrace) != 1 || res.ToolTrace[0].Error != "service unavailable" {
+		t.Fatalf("unexpected tool trace %+v", res.ToolTrace)
+	}
+	if got := res.Text(); got != `weather is {"error":"tool_failed","message":"service unavailable"}` {
+		t.Fatalf("unexpected result %q", got)
+	}
+}
+
+func TestAgent_ToolErrors(t *testing.T) {
+	agent := NewAgent("test", WithProvider(weatherProvider()))
+	res, err := agent.Run(context.Background(), NewPrompt(UserMessage("hi")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.ToolTrace) != 1 || res.ToolTrace[0].Error != "tool not found: weather" {
+		t.Fatalf("unexpected tool trace %+v", res.ToolTrace)
+	}
+	if got := res.Text(); got != `weather is {"error":"tool_not_found","message":"tool not found: weather"}` {
+		t.Fatalf("expected the unknown tool to be reported to the model, got %q", got)
+	}
+	agent = NewAgent("test", WithProvider(weatherProvider()), WithTools(weatherTool()))
+	_, err = agent.Run(context.Background(), NewPrompt(UserMessage("hi")), MaxIterations(1))
+	if !errors.Is(err, ErrMaxIterations) {
+		t.Fatalf("expected ErrMaxIterations, got %v", err)
+	}
+}
+
+func TestAgent_StreamMaxIterations(t *testing.T) {
+	provider := weatherProvider()
+	agent := NewAgent("test", WithProvider(provider), WithTools(weatherTool()))
+	stream, err := agent.RunStream(context.Background(), NewPrompt(UserMessage("hi")), MaxIterations(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tools int
+	for stream.Next() {
+		// the error of the run may be reported along with the last chunks
+		g, _ := stream.Current()
+		tools += len(g.ToolTrace)
+	}
+	if err := stream.Close(); !errors.Is(err, ErrMaxIterations) {
+		t.Fatalf("expected ErrMaxIterations, got %v", err)
+	}
+	if tools != 1 || provider.Calls() != 1 {
+		t.Fatalf("expected the tools of the only turn to run after 1 call, got %d tools after %d calls", tools, provider.Calls())
+	}
+}
+
+func TestAgent_Grounding(t *testing.T) {
+	agent := NewAgent("test", WithProvider(&fakeProvider{reply: func(req *ModelRequest, opts ModelOptions) *ModelResponse {
+		res := textResponse("ok")
+		if opts.Grounding.WebSearch {
+			res.Grounding = &Grounding{Sources: []GroundingSource{{Title: "Kratos", URI: "https://go-kratos.dev"}}}
+		}
+		return res
+	}}), WithGrounding())
+	res, err := agent.Run(context.Background(), NewPrompt(UserMessage("what is kratos?")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Grounding == nil || len(res.Grounding.Sources) != 1 {
+		t.Fatalf("expected grounding sources, got %+v", res.Grounding)
+	}
+}
+
+func TestAgent_AutonomyDeclinesDestructiveCall(t *testing.T) {
+	tool := weatherTool()
+	tool.Destructive = true
+	var checkpoints []Checkpoint
+	supervisor := SupervisorFunc(func(ctx context.Context, c Checkpoint) (bool, error) {
+		checkpoints = append(checkpoints, c)
+		return false, nil
+	})
+	agent := NewAgent("test", WithProvider(weatherProvider()), WithTools(tool), WithAutonomy(supervisor))
+	res, err := agent.Run(context.Background(), NewPrompt(UserMessage("weather in Paris?")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checkpoints) != 1 || !checkpoints[0].Destructive || checkpoints[0].Call.Name != "weather" {
+		t.Fatalf("unexpected checkpoints %+v", checkpoints)
+	}
+	if len(res.ToolTrace) != 1 || res.ToolTrace[0].Error != ErrActionDeclined.Error() {
+		t.Fatalf("expected the declined call in the trace, got %+v", res.ToolTrace)
+	}
+}
+
+func TestAgent_ToolApproval(t *testing.T) {
+	edit := ApproverFunc(func(ctx context.Context, call *ToolCall) (Approval, error) {
+		return EditArguments(`{"city":"Lyon"}`), nil
+	})
+	agent := NewAgent("test", WithProvider(weatherProvider()), WithTools(weatherTool()), WithToolApproval(edit, RequireApprovalFor("weather")))
+	res, err := agent.Run(context.Background(), NewPrompt(UserMessage("weather in Paris?")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.ToolTrace) != 1 || res.ToolTrace[0].Arguments != `{"city":"Lyon"}` || res.ToolTrace[0].Result != "sunny" {
+		t.Fatalf("expected the edited call in the trace, got %+v", res.ToolTrace)
+	}
+
+	requests := make(chan *ApprovalRequest)
+	go func() {
+		req := <-requests
+		req.Respond(Deny("not today"))
+	}()
+	agent = NewAgent("test", WithProvider(weatherProvider()), WithTools(weatherTool()), WithToolApproval(ChannelApprover(requests), nil))
+	res, err = agent.Run(context.Background(), NewPrompt(UserMessage("weather in Paris?")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.ToolTrace) != 1 || !strings.Contains(res.ToolTrace[0].Error, "not today") {
+		t.Fatalf("expected the denied call in the trace, got %+v", res.ToolTrace)
+	}
+}
@@ -0,0 +1,146 @@
+package blades
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// ApprovalOutcome is the decision of a human about a tool call.
+type ApprovalOutcome int
+
+const (
+	// Approved runs the tool call as requested.
+	Approved ApprovalOutcome = iota
+	// Denied skips the tool call and reports the refusal to the model.
+	Denied
+	// Edited runs the tool call with the arguments of the approval.
+	Edited
+)
+
+// Approval is the answer to a tool call awaiting approval.
+type Approval struct {
+	Outcome ApprovalOutcome
+	// Arguments replace the arguments of an Edited call.
+	Arguments string
+	// Reason explains a denial to the model.
+	Reason string
+}
+
+// Approve approves a tool call.
+func Approve() Approval {
+	return Approval{Outcome: Approved}
+}
+
+// Deny denies a tool call for reason.
+func Deny(reason string) Approval {
+	return Approval{Outcome: Denied, Reason: reason}
+}
+
+// EditArguments approves a tool call with other arguments.
+func EditArguments(arguments string) Approval {
+	return Approval{Outcome: Edited, Arguments: arguments}
+}
+
+// Approver asks a human to confirm tool calls.
+type Approver interface {
+	ApproveTool(ctx context.Context, call *ToolCall) (Approval, error)
+}
+
+// ApproverFunc adapts a function to an Approver.
+type ApproverFunc func(ctx context.Context, call *ToolCall) (Approval, error)
+
+// ApproveTool calls f(ctx, call).
+func (f ApproverFunc) ApproveTool(ctx context.Context, call *ToolCall) (Approval, error) {
+	return f(ctx, call)
+}
+
+// ApprovalRequest is a tool call awaiting approval on the channel of a ChannelApprover.
+type ApprovalRequest struct {
+	Call  *ToolCall
+	reply chan Approval
+}
+
+// Respond answers the request. It must be called exactly once.
+func (r *ApprovalRequest) Respond(approval Approval) {
+	r.reply <- approval
+}
+
+// ChannelApprover returns an Approver that sends the tool calls awaiting
+// approval on requests and blocks the run until they are answered with Respond,
+// or the context of the run is done.
+func ChannelApprover(requests chan<- *ApprovalRequest) Approver {
+	return ApproverFunc(func(ctx context.Context, call *ToolCall) (Approval, error) {
+		req := &ApprovalRequest{Call: call, reply: make(chan Approval, 1)}
+		select {
+		case requests <- req:
+		case <-ctx.Done():
+			return Approval{}, ctx.Err()
+		}
+		select {
+		case approval := <-req.reply:
+			return approval, nil
+		case <-ctx.Done():
+			return Approval{}, ctx.Err()
+		}
+	})
+}
+
+// ApprovalPolicy reports whether a tool call requires approval.
+type ApprovalPolicy func(tool *Tool, call *ToolCall) bool
+
+// RequireApprovalFor requires approval for the calls of the named tools.
+func RequireApprovalFor(names ...string) ApprovalPolicy {
+	return func(tool *Tool, call *ToolCall) bool {
+		return slices.Contains(names, tool.Name)
+	}
+}
+
+// RequireApprovalForDestructive requires approval for the calls of tools tagged
+// as destructive.
+func RequireApprovalForDestructive() ApprovalPolicy {
+	return func(tool *Tool, call *ToolCall) bool {
+		return tool.Destructive
+	}
+}
+
+// WithToolApproval pauses the runs of the Agent on the tool calls matching
+// policy, or on every call when policy is nil, until the approver answers. A
+// denied call is skipped and the refusal reported to the model, which may
+// continue; an edited call runs with the new arguments, which are validated
+// and recorded in the tool trace.
+func WithToolApproval(approver Approver, policy ApprovalPolicy) Option {
+	return func(a *Agent) {
+		a.approval = &toolApproval{approver: approver, policy: policy}
+	}
+}
+
+type toolApproval struct {
+	approver Approver
+	policy   ApprovalPolicy
+}
+
+// approve asks the approver about the call when the policy matches it and
+// returns the arguments to call the tool with.
+func (a *toolApproval) approve(ctx context.Context, tool *Tool, call *ToolCall) (string, error) {
+	if a == nil || (a.policy != nil && !a.policy(tool, call)) {
+		return call.Arguments, nil
+	}
+	approval, err := a.approver.ApproveTool(ctx, call)
+	if err != nil {
+		return "", err
+	}
+	switch approval.Outcome {
+	case Approved:
+		return call.Arguments, nil
+	case Edited:
+		return approval.Arguments, nil
+	case Denied:
+		if approval.Reason != "" {
+			return "", fmt.Errorf("%w: %s", ErrActionDeclined, approval.Reason)
+		}
+		return "", ErrActionDeclined
+	default:
+		return "", fmt.Errorf("unknown approval outcome %d", approval.Outcome)
+	}
+}
@@ -0,0 +1,90 @@
+package blades
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+var (
+	_ ArtifactStore = (*FileArtifactStore)(nil)
+)
+
+// ArtifactStore stores binary content produced during runs, such as generated
+// images, and returns a file part referencing it.
+type ArtifactStore interface {
+	Save(ctx context.Context, data DataPart) (FilePart, error)
+}
+
+// FileArtifactStore is an ArtifactStore that writes artifacts to a directory.
+// Artifacts are named after the hash of their content, so saving the same
+// content twice yields the same file.
+type FileArtifactStore struct {
+	dir string
+}
+
+// NewFileArtifactStore creates a FileArtifactStore writing to dir, which is
+// created if needed.
+func NewFileArtifactStore(dir string) (*FileArtifactStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("artifact store: %w", err)
+	}
+	return &FileArtifactStore{dir: dir}, nil
+}
+
+// Save writes the data to the directory and returns a file:// part for it.
+func (s *FileArtifactStore) Save(ctx context.Context, data DataPart) (FilePart, error) {
+	mimeType := data.MimeType
+	if mimeType == "" {
+		mimeType = DetectMimeType(data.Name, data.Bytes)
+	}
+	sum := sha256.Sum256(data.Bytes)
+	name := hex.EncodeToString(sum[:16])
+	if exts, _ := mime.ExtensionsByType(string(mimeType)); len(exts) > 0 {
+		name += exts[0]
+	}
+	path, err := filepath.Abs(filepath.Join(s.dir, name))
+	if err != nil {
+		return FilePart{}, fmt.Errorf("artifact store: %w", err)
+	}
+	if err := os.WriteFile(path, data.Bytes, 0o644); err != nil {
+		return FilePart{}, fmt.Errorf("artifact store: %w", err)
+	}
+	return FilePart{
+		Name:     data.Name,
+		URI:      (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String(),
+		MimeType: mimeType,
+	}, nil
+}
+
+// Images returns the image data parts of the generation.
+func (g *Generation) Images() []DataPart {
+	var images []DataPart
+	for _, msg := range g.Messages {
+		for _, part := range msg.Parts {
+			if data, ok := part.(DataPart); ok && data.MimeType.Type() == "image" {
+				images = append(images, data)
+			}
+		}
+	}
+	return images
+}
+
+// SaveImages saves the images of the generation to the store and returns the
+// file parts referencing them.
+func SaveImages(ctx context.Context, store ArtifactStore, g *Generation) ([]FilePart, error) {
+	var files []FilePart
+	for _, image := range g.Images() {
+		file, err := store.Save(ctx, image)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
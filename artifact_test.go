@@ -0,0 +1,34 @@
+package blades
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func TestSaveImages(t *testing.T) {
+	store, err := NewFileArtifactStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	png := []byte("\x89PNG\r\n\x1a\n")
+	g := &Generation{Messages: []*Message{{
+		Role:  RoleAssistant,
+		Parts: []Part{TextPart{Text: "here you go"}, DataPart{Bytes: png, MimeType: MimeImagePNG}},
+	}}}
+	files, err := SaveImages(context.Background(), store, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].MimeType != MimeImagePNG {
+		t.Fatalf("unexpected files %+v", files)
+	}
+	u, err := url.Parse(files[0].URI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(u.Path); err != nil || string(b) != string(png) {
+		t.Fatalf("unexpected artifact content %q: %v", b, err)
+	}
+}
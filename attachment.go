@@ -0,0 +1,242 @@
+package blades
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+
+	_ "image/gif"
+)
+
+var (
+	_ ModelProvider = (*AttachmentProvider)(nil)
+)
+
+var (
+	// ErrAttachmentTooLarge indicates an attachment exceeds the size limit and could not be converted to fit.
+	ErrAttachmentTooLarge = errors.New("attachment too large")
+)
+
+// Converter converts a data part so that it fits within maxBytes, e.g. by
+// resizing an image or transcoding audio to a compressed format.
+type Converter interface {
+	Convert(ctx context.Context, part DataPart, maxBytes int) (DataPart, error)
+}
+
+// ConverterFunc adapts a function to a Converter.
+type ConverterFunc func(ctx context.Context, part DataPart, maxBytes int) (DataPart, error)
+
+// Convert calls f(ctx, part, maxBytes).
+func (f ConverterFunc) Convert(ctx context.Context, part DataPart, maxBytes int) (DataPart, error) {
+	return f(ctx, part, maxBytes)
+}
+
+// AttachmentOption configures an AttachmentProvider.
+type AttachmentOption func(*AttachmentProvider)
+
+// AttachmentMaxBytes sets the maximum size of a data part sent to the provider.
+func AttachmentMaxBytes(n int) AttachmentOption {
+	return func(p *AttachmentProvider) {
+		p.maxBytes = n
+	}
+}
+
+// AttachmentMaxImageSize sets the maximum width and height of images; larger
+// images are scaled down.
+func AttachmentMaxImageSize(pixels int) AttachmentOption {
+	return func(p *AttachmentProvider) {
+		p.maxImageSize = pixels
+	}
+}
+
+// AttachmentConverter registers the converter used for oversized parts whose
+// media type starts with prefix, e.g. "audio/" with an ffmpeg based transcoder.
+func AttachmentConverter(prefix string, converter Converter) AttachmentOption {
+	return func(p *AttachmentProvider) {
+		p.converters[prefix] = converter
+	}
+}
+
+// AttachmentProvider prepares the attachments of requests for providers with
+// strict limits: the media type of files and data parts is detected when
+// missing, images are scaled down, and data parts over the size limit are
+// converted or rejected with ErrAttachmentTooLarge.
+type AttachmentProvider struct {
+	provider     ModelProvider
+	maxBytes     int
+	maxImageSize int
+	converters   map[string]Converter
+}
+
+// NewAttachmentProvider wraps provider with attachment preparation. Images are
+// converted by a built-in resizer; other media need an AttachmentConverter.
+func NewAttachmentProvider(provider ModelProvider, opts ...AttachmentOption) *AttachmentProvider {
+	p := &AttachmentProvider{
+		provider:   provider,
+		converters: map[string]Converter{"image/": ConverterFunc(resizeImage)},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Generate prepares the attachments and executes the request.
+func (p *AttachmentProvider) Generate(ctx context.Context, req *ModelRequest, opts ...ModelOption) (*ModelResponse, error) {
+	req, err := p.prepare(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return p.provider.Generate(ctx, req, opts...)
+}
+
+// NewStream prepares the attachments and executes the streaming request.
+func (p *AttachmentProvider) NewStream(ctx context.Context, req *ModelRequest, opts ...ModelOption) (Streamer[*ModelResponse], error) {
+	req, err := p.prepare(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return p.provider.NewStream(ctx, req, opts...)
+}
+
+// prepare returns a copy of req with the attachments prepared.
+func (p *AttachmentProvider) prepare(ctx context.Context, req *ModelRequest) (*ModelRequest, error) {
+	out := *req
+	out.Messages = make([]*Message, 0, len(req.Messages))
+	for _, msg := range req.Messages {
+		m := *msg
+		m.Parts = make([]Part, 0, len(msg.Parts))
+		for _, part := range msg.Parts {
+			switch v := part.(type) {
+			case FilePart:
+				if v.MimeType == "" {
+					v.MimeType = DetectMimeType(v.URI, nil)
+				}
+				part = v
+			case DataPart:
+				prepared, err := p.prepareData(ctx, v)
+				if err != nil {
+					return nil, err
+				}
+				part = prepared
+			}
+			m.Parts = append(m.Parts, part)
+		}
+		out.Messages = append(out.Messages, &m)
+	}
+	return &out, nil
+}
+
+func (p *AttachmentProvider) prepareData(ctx context.Context, part DataPart) (DataPart, error) {
+	if part.MimeType == "" {
+		part.MimeType = DetectMimeType(part.Name, part.Bytes)
+	}
+	if part.MimeType.Type() == "image" && p.maxImageSize > 0 {
+		resized, err := fitImage(part, p.maxImageSize)
+		if err != nil {
+			return DataPart{}, err
+		}
+		part = resized
+	}
+	if p.maxBytes <= 0 || len(part.Bytes) <= p.maxBytes {
+		return part, nil
+	}
+	for prefix, converter := range p.converters {
+		if !strings.HasPrefix(string(part.MimeType), prefix) {
+			continue
+		}
+		converted, err := converter.Convert(ctx, part, p.maxBytes)
+		if err != nil {
+			return DataPart{}, fmt.Errorf("attachment %s: %w", part.Name, err)
+		}
+		if len(converted.Bytes) <= p.maxBytes {
+			return converted, nil
+		}
+	}
+	return DataPart{}, fmt.Errorf("%w: %s is %d bytes, limit is %d", ErrAttachmentTooLarge, part.Name, len(part.Bytes), p.maxBytes)
+}
+
+// fitImage scales an image down so that neither side exceeds maxSize pixels.
+// Images that are small enough or cannot be decoded are returned unchanged.
+func fitImage(part DataPart, maxSize int) (DataPart, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(part.Bytes))
+	if err != nil || (cfg.Width <= maxSize && cfg.Height <= maxSize) {
+		return part, nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(part.Bytes))
+	if err != nil {
+		return part, nil
+	}
+	return encodeImage(part, scaleImage(img, maxSize))
+}
+
+// resizeImage halves the resolution of an image until it fits within maxBytes.
+func resizeImage(ctx context.Context, part DataPart, maxBytes int) (DataPart, error) {
+	img, _, err := image.Decode(bytes.NewReader(part.Bytes))
+	if err != nil {
+		return DataPart{}, err
+	}
+	for size := max(img.Bounds().Dx(), img.Bounds().Dy()); size > 16; size /= 2 {
+		if err := ctx.Err(); err != nil {
+			return DataPart{}, err
+		}
+		resized, err := encodeImage(part, scaleImage(img, size))
+		if err != nil {
+			return DataPart{}, err
+		}
+		if len(resized.Bytes) <= maxBytes {
+			return resized, nil
+		}
+	}
+	return part, nil
+}
+
+// encodeImage encodes img as PNG if the part is a PNG and as JPEG otherwise.
+func encodeImage(part DataPart, img image.Image) (DataPart, error) {
+	var buf bytes.Buffer
+	if part.MimeType == MimeImagePNG {
+		if err := png.Encode(&buf, img); err != nil {
+			return DataPart{}, err
+		}
+	} else {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+			return DataPart{}, err
+		}
+		part.MimeType = MimeImageJPEG
+	}
+	part.Bytes = buf.Bytes()
+	return part, nil
+}
+
+// scaleImage scales img down with a box filter so that its longest side is maxSize.
+func scaleImage(img image.Image, maxSize int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSize && h <= maxSize {
+		return img
+	}
+	scale := float64(maxSize) / float64(max(w, h))
+	dw, dh := max(1, int(float64(w)*scale)), max(1, int(float64(h)*scale))
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := range dh {
+		y0, y1 := b.Min.Y+y*h/dh, b.Min.Y+max((y+1)*h/dh, y*h/dh+1)
+		for x := range dw {
+			x0, x1 := b.Min.X+x*w/dw, b.Min.X+max((x+1)*w/dw, x*w/dw+1)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBA64Model.Convert(img.At(sx, sy)).(color.NRGBA64)
+					r, g, bl, a, n = r+uint64(c.R), g+uint64(c.G), bl+uint64(c.B), a+uint64(c.A), n+1
+				}
+			}
+			dst.Set(x, y, color.NRGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
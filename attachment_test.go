@@ -0,0 +1,36 @@
+package blades
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func TestAttachmentProvider(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 64, 32))); err != nil {
+		t.Fatal(err)
+	}
+	recorder := textProvider()
+	provider := NewAttachmentProvider(recorder, AttachmentMaxImageSize(16), AttachmentMaxBytes(1024))
+	req := &ModelRequest{Messages: []*Message{{Role: RoleUser, Parts: []Part{DataPart{Name: "chart", Bytes: buf.Bytes()}}}}}
+	if _, err := provider.Generate(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	data := recorder.lastRequest().Messages[0].Data()
+	if data.MimeType != MimeImagePNG {
+		t.Fatalf("expected detected png, got %q", data.MimeType)
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(data.Bytes))
+	if err != nil || cfg.Width != 16 || cfg.Height != 8 {
+		t.Fatalf("expected 16x8 image, got %+v %v", cfg, err)
+	}
+
+	req = &ModelRequest{Messages: []*Message{{Role: RoleUser, Parts: []Part{DataPart{Name: "talk.wav", Bytes: make([]byte, 2048)}}}}}
+	if _, err := provider.Generate(context.Background(), req); !errors.Is(err, ErrAttachmentTooLarge) {
+		t.Fatalf("expected ErrAttachmentTooLarge, got %v", err)
+	}
+}
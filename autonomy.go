@@ -0,0 +1,115 @@
+package blades
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrRunHalted indicates the supervisor stopped a run at a check-in.
+	ErrRunHalted = errors.New("run halted by supervisor")
+	// ErrActionDeclined indicates the supervisor or approver declined a tool call.
+	ErrActionDeclined = errors.New("action declined by supervisor")
+)
+
+// Checkpoint describes a tool call awaiting the supervisor's approval.
+type Checkpoint struct {
+	// Step is the number of the tool call in the run, starting at 1.
+	Step int
+	// Call is the tool call about to be executed.
+	Call *ToolCall
+	// Destructive reports whether the tool is tagged as destructive.
+	Destructive bool
+}
+
+// Supervisor approves tool calls of autonomous runs, typically by asking the user.
+type Supervisor interface {
+	Approve(ctx context.Context, checkpoint Checkpoint) (bool, error)
+}
+
+// SupervisorFunc adapts a function to a Supervisor.
+type SupervisorFunc func(ctx context.Context, checkpoint Checkpoint) (bool, error)
+
+// Approve calls f(ctx, checkpoint).
+func (f SupervisorFunc) Approve(ctx context.Context, checkpoint Checkpoint) (bool, error) {
+	return f(ctx, checkpoint)
+}
+
+// AutonomyOption configures the autonomy of an Agent.
+type AutonomyOption func(*autonomy)
+
+// CheckInEvery pauses for the supervisor's approval after every n tool calls.
+func CheckInEvery(n int) AutonomyOption {
+	return func(a *autonomy) {
+		a.every = n
+	}
+}
+
+// WithAutonomy lets the Agent run its tool loop autonomously under supervision:
+// the supervisor is asked before every destructive tool call and at every
+// check-in. Declining a destructive call skips it and reports the refusal to the
+// model, which may continue; declining a check-in halts the run with ErrRunHalted.
+func WithAutonomy(supervisor Supervisor, opts ...AutonomyOption) Option {
+	return func(a *Agent) {
+		a.autonomy = &autonomy{supervisor: supervisor}
+		for _, opt := range opts {
+			opt(a.autonomy)
+		}
+	}
+}
+
+type autonomy struct {
+	supervisor Supervisor
+	every      int
+}
+
+// guard returns the state of a single run, nil when the agent is neither
+// supervised nor requires tool approvals.
+func (a *Agent) guard() *autonomyGuard {
+	if a.autonomy == nil && a.approval == nil {
+		return nil
+	}
+	return &autonomyGuard{autonomy: a.autonomy, approval: a.approval}
+}
+
+type autonomyGuard struct {
+	autonomy *autonomy
+	approval *toolApproval
+	steps    int
+}
+
+// approve asks the supervisor about the call when it is destructive or due for
+// a check-in, then the approver when the approval policy matches the call. It
+// returns the arguments to call the tool with, which the approver may have edited.
+func (g *autonomyGuard) approve(ctx context.Context, tool *Tool, call *ToolCall) (string, error) {
+	if g == nil {
+		return call.Arguments, nil
+	}
+	if err := g.supervise(ctx, tool, call); err != nil {
+		return "", err
+	}
+	return g.approval.approve(ctx, tool, call)
+}
+
+// supervise asks the supervisor about the call when it is destructive or due for a check-in.
+func (g *autonomyGuard) supervise(ctx context.Context, tool *Tool, call *ToolCall) error {
+	if g.autonomy == nil {
+		return nil
+	}
+	g.steps++
+	checkIn := g.autonomy.every > 0 && g.steps > 1 && (g.steps-1)%g.autonomy.every == 0
+	if !tool.Destructive && !checkIn {
+		return nil
+	}
+	ok, err := g.autonomy.supervisor.Approve(ctx, Checkpoint{Step: g.steps, Call: call, Destructive: tool.Destructive})
+	switch {
+	case err != nil:
+		return err
+	case ok:
+		return nil
+	case tool.Destructive:
+		return ErrActionDeclined
+	default:
+		return ErrRunHalted
+	}
+}
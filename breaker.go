@@ -0,0 +1,237 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	_ ModelProvider = (*CircuitBreakerProvider)(nil)
+)
+
+var (
+	// ErrCircuitOpen matches every CircuitOpenError with errors.Is.
+	ErrCircuitOpen = errors.New("circuit open")
+)
+
+// CircuitOpenError is returned for calls short-circuited by an open breaker.
+type CircuitOpenError struct {
+	// Failures is the number of consecutive failures that opened the breaker.
+	Failures int
+	// RetryAfter is the remaining cool-down.
+	RetryAfter time.Duration
+}
+
+// Error returns the error message.
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit open after %d consecutive failures, retry after %s", e.Failures, e.RetryAfter)
+}
+
+// Is reports whether target is ErrCircuitOpen.
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets calls through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen short-circuits calls until the cool-down has elapsed.
+	CircuitOpen
+	// CircuitHalfOpen lets a single trial call through after the cool-down.
+	CircuitHalfOpen
+)
+
+// String returns the name of the state.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerOption configures a CircuitBreakerProvider.
+type BreakerOption func(*CircuitBreakerProvider)
+
+// BreakerThreshold sets the number of consecutive failures that open the breaker.
+func BreakerThreshold(n int) BreakerOption {
+	return func(p *CircuitBreakerProvider) {
+		p.threshold = n
+	}
+}
+
+// BreakerCooldown sets how long the breaker stays open before a trial call.
+func BreakerCooldown(d time.Duration) BreakerOption {
+	return func(p *CircuitBreakerProvider) {
+		p.cooldown = d
+	}
+}
+
+// BreakerFailure sets which errors count as failures of the backend.
+func BreakerFailure(fn func(error) bool) BreakerOption {
+	return func(p *CircuitBreakerProvider) {
+		p.failure = fn
+	}
+}
+
+// BreakerObserver sets a function called whenever the breaker changes state.
+// It is called with the breaker locked and must not call back into it.
+func BreakerObserver(fn func(from, to CircuitState)) BreakerOption {
+	return func(p *CircuitBreakerProvider) {
+		p.observer = fn
+	}
+}
+
+// CircuitBreakerProvider opens after consecutive provider failures and then
+// fails calls fast with a CircuitOpenError for a cool-down period, so that long
+// chains stop hammering a backend that is down. After the cool-down a single
+// trial call is let through: its success closes the breaker, its failure opens
+// it again. Streams count as failed when they fail to open or end with an error.
+type CircuitBreakerProvider struct {
+	provider  ModelProvider
+	threshold int
+	cooldown  time.Duration
+	failure   func(error) bool
+	observer  func(from, to CircuitState)
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// NewCircuitBreakerProvider wraps provider with a breaker opening after 5
+// consecutive failures for 30 seconds. Cancellations are not failures.
+func NewCircuitBreakerProvider(provider ModelProvider, opts ...BreakerOption) *CircuitBreakerProvider {
+	p := &CircuitBreakerProvider{
+		provider:  provider,
+		threshold: 5,
+		cooldown:  30 * time.Second,
+		failure: func(err error) bool {
+			return !errors.Is(err, context.Canceled)
+		},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// State returns the current state of the breaker.
+func (p *CircuitBreakerProvider) State() CircuitState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == CircuitOpen && Since(p.openedAt) >= p.cooldown {
+		return CircuitHalfOpen
+	}
+	return p.state
+}
+
+// Generate executes the request unless the breaker is open.
+func (p *CircuitBreakerProvider) Generate(ctx context.Context, req *ModelRequest, opts ...ModelOption) (*ModelResponse, error) {
+	if err := p.allow(); err != nil {
+		return nil, err
+	}
+	res, err := p.provider.Generate(ctx, req, opts...)
+	p.record(err)
+	return res, err
+}
+
+// NewStream opens the stream unless the breaker is open.
+func (p *CircuitBreakerProvider) NewStream(ctx context.Context, req *ModelRequest, opts ...ModelOption) (Streamer[*ModelResponse], error) {
+	if err := p.allow(); err != nil {
+		return nil, err
+	}
+	stream, err := peekStream(p.provider.NewStream(ctx, req, opts...))
+	if err != nil {
+		p.record(err)
+		return nil, err
+	}
+	pipe := NewStreamPipe[*ModelResponse]()
+	pipe.Go(func() error {
+		var err error
+		for stream.Next() {
+			var res *ModelResponse
+			if res, err = stream.Current(); err != nil {
+				break
+			}
+			pipe.Send(res)
+		}
+		if cerr := stream.Close(); err == nil {
+			err = cerr
+		}
+		p.record(err)
+		return err
+	})
+	return pipe, nil
+}
+
+// allow admits a call, moving an open breaker to half-open after the cool-down.
+func (p *CircuitBreakerProvider) allow() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch p.state {
+	case CircuitOpen:
+		if elapsed := Since(p.openedAt); elapsed < p.cooldown {
+			return &CircuitOpenError{Failures: p.failures, RetryAfter: p.cooldown - elapsed}
+		}
+		p.transition(CircuitHalfOpen)
+		p.trial = true
+	case CircuitHalfOpen:
+		if p.trial {
+			return &CircuitOpenError{Failures: p.failures}
+		}
+		p.trial = true
+	}
+	return nil
+}
+
+// record updates the breaker with the outcome of a call.
+func (p *CircuitBreakerProvider) record(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	failed := err != nil && p.failure(err)
+	if p.state == CircuitHalfOpen {
+		p.trial = false
+		if failed {
+			p.failures++
+			p.open()
+		} else if err == nil {
+			p.failures = 0
+			p.transition(CircuitClosed)
+		}
+		return
+	}
+	switch {
+	case failed:
+		p.failures++
+		if p.state == CircuitClosed && p.failures >= p.threshold {
+			p.open()
+		}
+	case err == nil:
+		p.failures = 0
+	}
+}
+
+func (p *CircuitBreakerProvider) open() {
+	p.openedAt = Now()
+	p.transition(CircuitOpen)
+}
+
+func (p *CircuitBreakerProvider) transition(to CircuitState) {
+	from := p.state
+	p.state = to
+	if p.observer != nil && from != to {
+		p.observer(from, to)
+	}
+}
@@ -0,0 +1,64 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerProvider(t *testing.T) {
+	backend := &fakeProvider{replies: []string{"ok"}, err: errors.New("down"), failures: 2}
+	p := NewCircuitBreakerProvider(backend, BreakerThreshold(2), BreakerCooldown(20*time.Millisecond))
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := p.Generate(ctx, &ModelRequest{}); err == nil {
+			t.Fatal("expected the backend error")
+		}
+	}
+	_, err := p.Generate(ctx, &ModelRequest{})
+	var open *CircuitOpenError
+	if !errors.As(err, &open) || !errors.Is(err, ErrCircuitOpen) || open.Failures != 2 {
+		t.Fatalf("expected a circuit open error, got %v", err)
+	}
+	if backend.Calls() != 2 {
+		t.Fatalf("open breaker should not call the backend, got %d calls", backend.Calls())
+	}
+	time.Sleep(25 * time.Millisecond)
+	if p.State() != CircuitHalfOpen {
+		t.Fatalf("expected half-open after the cool-down, got %s", p.State())
+	}
+	if _, err := p.Generate(ctx, &ModelRequest{}); err != nil {
+		t.Fatalf("trial call failed: %v", err)
+	}
+	if p.State() != CircuitClosed {
+		t.Fatalf("expected closed after a successful trial, got %s", p.State())
+	}
+}
+
+func TestCircuitBreakerProvider_Stream(t *testing.T) {
+	tests := []struct {
+		name string
+		lazy bool
+	}{
+		{"counts failures to open", false},
+		{"counts failures on the first read", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &fakeProvider{replies: []string{"ok"}, err: errors.New("down"), failures: 2, lazy: tt.lazy}
+			p := NewCircuitBreakerProvider(backend, BreakerThreshold(2), BreakerCooldown(time.Minute))
+			for i := 0; i < 2; i++ {
+				if _, err := p.NewStream(context.Background(), &ModelRequest{}); err == nil {
+					t.Fatal("expected the backend error when opening the stream")
+				}
+			}
+			if _, err := p.NewStream(context.Background(), &ModelRequest{}); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("expected a circuit open error, got %v", err)
+			}
+			if p.State() != CircuitOpen || backend.Calls() != 2 {
+				t.Fatalf("got %s after %d calls", p.State(), backend.Calls())
+			}
+		})
+	}
+}
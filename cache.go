@@ -0,0 +1,286 @@
+package blades
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	_ ModelProvider = (*CachingProvider)(nil)
+	_ ResponseCache = (*LRUCache)(nil)
+)
+
+// ResponseCache stores encoded responses by key.
+type ResponseCache interface {
+	// Get returns the entry for key and whether it exists and has not expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores an entry expiring after ttl, or never when ttl is 0.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// LRUCache is an in-memory ResponseCache evicting the least recently used entries.
+type LRUCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewLRUCache creates an LRUCache holding at most size entries.
+func NewLRUCache(size int) *LRUCache {
+	return &LRUCache{size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// Get returns the entry for key unless it has expired.
+func (c *LRUCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := elem.Value.(*lruEntry)
+	if !entry.expires.IsZero() && Now().After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, true, nil
+}
+
+// Set stores the entry, evicting the least recently used one when full.
+func (c *LRUCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &lruEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expires = Now().Add(ttl)
+	}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return nil
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+	return nil
+}
+
+// CacheOption configures a CachingProvider.
+type CacheOption func(*CachingProvider)
+
+// CacheTTL sets how long responses are cached, forever by default.
+func CacheTTL(ttl time.Duration) CacheOption {
+	return func(p *CachingProvider) {
+		p.ttl = ttl
+	}
+}
+
+// CacheWhen sets which requests are cached, e.g. only those with temperature 0.
+func CacheWhen(fn func(req *ModelRequest, opts ModelOptions) bool) CacheOption {
+	return func(p *CachingProvider) {
+		p.when = fn
+	}
+}
+
+// CachingProvider serves repeated requests from a ResponseCache, so that
+// deterministic prompts are paid for once. Requests are keyed by a canonical
+// hash of the model, tools, message contents and model options. Cached
+// responses carry "cached" = "true" in their message metadata, a WarningCacheHit
+// and no usage, as they consume no tokens. Cache errors are ignored and the provider is called.
+type CachingProvider struct {
+	provider ModelProvider
+	cache    ResponseCache
+	ttl      time.Duration
+	when     func(*ModelRequest, ModelOptions) bool
+}
+
+// NewCachingProvider wraps provider with cache.
+func NewCachingProvider(provider ModelProvider, cache ResponseCache, opts ...CacheOption) *CachingProvider {
+	p := &CachingProvider{provider: provider, cache: cache}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// CacheKey returns the canonical hash of a request and its options. Message IDs
+// and metadata do not affect it.
+func CacheKey(req *ModelRequest, opts ...ModelOption) (string, error) {
+	var opt ModelOptions
+	for _, o := range opts {
+		o(&opt)
+	}
+	digests := make([]string, len(req.Messages))
+	for i, msg := range req.Messages {
+		digests[i] = messageDigest(msg)
+	}
+	b, err := json.Marshal(struct {
+		Model    string       `json:"model"`
+		Tools    []*Tool      `json:"tools"`
+		Messages []string     `json:"messages"`
+		Options  ModelOptions `json:"options"`
+	}{req.Model, req.Tools, digests, opt})
+	if err != nil {
+		return "", fmt.Errorf("cache key: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Generate returns the cached response or generates and caches one.
+func (p *CachingProvider) Generate(ctx context.Context, req *ModelRequest, opts ...ModelOption) (*ModelResponse, error) {
+	key, ok := p.lookupKey(req, opts)
+	if !ok {
+		return p.provider.Generate(ctx, req, opts...)
+	}
+	if res, ok := p.get(ctx, key); ok {
+		return res, nil
+	}
+	res, err := p.provider.Generate(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	p.set(ctx, key, res)
+	return res, nil
+}
+
+// NewStream replays the cached response as a single-item stream, or streams
+// the provider and caches the completed response.
+func (p *CachingProvider) NewStream(ctx context.Context, req *ModelRequest, opts ...ModelOption) (Streamer[*ModelResponse], error) {
+	key, ok := p.lookupKey(req, opts)
+	if !ok {
+		return p.provider.NewStream(ctx, req, opts...)
+	}
+	if res, ok := p.get(ctx, key); ok {
+		pipe := NewStreamPipe[*ModelResponse]()
+		pipe.Go(func() error {
+			pipe.Send(res)
+			return nil
+		})
+		return pipe, nil
+	}
+	stream, err := p.provider.NewStream(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	pipe := NewStreamPipe[*ModelResponse]()
+	pipe.Go(func() error {
+		defer stream.Close()
+		completed := &ModelResponse{}
+		for stream.Next() {
+			res, err := stream.Current()
+			if err != nil {
+				return err
+			}
+			c := completedMessages(res)
+			completed.Messages = append(completed.Messages, c.Messages...)
+			if c.Usage != nil {
+				completed.Usage = c.Usage
+			}
+			if c.Grounding != nil {
+				completed.Grounding = c.Grounding
+			}
+			pipe.Send(res)
+		}
+		if err := stream.Close(); err != nil {
+			return err
+		}
+		if len(completed.Messages) > 0 {
+			p.set(ctx, key, completed)
+		}
+		return nil
+	})
+	return pipe, nil
+}
+
+func (p *CachingProvider) lookupKey(req *ModelRequest, opts []ModelOption) (string, bool) {
+	if p.when != nil {
+		var opt ModelOptions
+		for _, o := range opts {
+			o(&opt)
+		}
+		if !p.when(req, opt) {
+			return "", false
+		}
+	}
+	key, err := CacheKey(req, opts...)
+	return key, err == nil
+}
+
+func (p *CachingProvider) get(ctx context.Context, key string) (*ModelResponse, bool) {
+	data, ok, err := p.cache.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, false
+	}
+	res, err := UnmarshalResponse(data)
+	if err != nil {
+		return nil, false
+	}
+	res.Usage = nil
+	res.Warnings = append(res.Warnings, Warning{Code: WarningCacheHit})
+	for _, msg := range res.Messages {
+		if msg.Metadata == nil {
+			msg.Metadata = make(map[string]string)
+		}
+		msg.Metadata["cached"] = "true"
+	}
+	return res, true
+}
+
+func (p *CachingProvider) set(ctx context.Context, key string, res *ModelResponse) {
+	data, err := MarshalResponse(res)
+	if err != nil {
+		return
+	}
+	_ = p.cache.Set(ctx, key, data, p.ttl)
+}
+
+type encodedResponse struct {
+	Messages  []encodedMessage `json:"messages"`
+	Usage     *Usage           `json:"usage,omitempty"`
+	Grounding *Grounding       `json:"grounding,omitempty"`
+}
+
+// MarshalResponse encodes a response as JSON, preserving the types of its parts.
+func MarshalResponse(res *ModelResponse) ([]byte, error) {
+	enc := encodedResponse{Usage: res.Usage, Grounding: res.Grounding}
+	for _, msg := range res.Messages {
+		enc.Messages = append(enc.Messages, encodeMessage(msg))
+	}
+	return json.Marshal(enc)
+}
+
+// UnmarshalResponse decodes a response encoded by MarshalResponse.
+func UnmarshalResponse(data []byte) (*ModelResponse, error) {
+	var enc encodedResponse
+	if err := json.Unmarshal(data, &enc); err != nil {
+		return nil, err
+	}
+	res := &ModelResponse{Usage: enc.Usage, Grounding: enc.Grounding}
+	for _, m := range enc.Messages {
+		msg, err := m.decode()
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal response: %w", err)
+		}
+		res.Messages = append(res.Messages, msg)
+	}
+	return res, nil
+}
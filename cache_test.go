@@ -0,0 +1,50 @@
+package blades
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCachingProvider(t *testing.T) {
+	backend := &fakeProvider{replies: []string{"cached answer"}}
+	p := NewCachingProvider(backend, NewLRUCache(8))
+	ctx := context.Background()
+
+	first, err := p.Generate(ctx, &ModelRequest{Model: "m", Messages: []*Message{UserMessage("hi")}}, Temperature(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a new message ID must not change the key
+	second, err := p.Generate(ctx, &ModelRequest{Model: "m", Messages: []*Message{UserMessage("hi")}}, Temperature(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backend.Calls() != 1 {
+		t.Fatalf("expected a single provider call, got %d", backend.Calls())
+	}
+	if second.Messages[0].Text() != first.Messages[0].Text() || second.Messages[0].Metadata["cached"] != "true" {
+		t.Fatalf("unexpected cached response: %+v", second.Messages[0])
+	}
+
+	if _, err := p.Generate(ctx, &ModelRequest{Model: "m", Messages: []*Message{UserMessage("hi")}}, Temperature(0.7)); err != nil {
+		t.Fatal(err)
+	}
+	if backend.Calls() != 2 {
+		t.Fatalf("different options must miss the cache, got %d calls", backend.Calls())
+	}
+}
+
+func TestLRUCache_Evicts(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRUCache(2)
+	_ = c.Set(ctx, "a", []byte("1"), 0)
+	_ = c.Set(ctx, "b", []byte("2"), 0)
+	_, _, _ = c.Get(ctx, "a")
+	_ = c.Set(ctx, "c", []byte("3"), 0)
+	if _, ok, _ := c.Get(ctx, "b"); ok {
+		t.Fatal("least recently used entry should be evicted")
+	}
+	if _, ok, _ := c.Get(ctx, "a"); !ok {
+		t.Fatal("recently used entry should be kept")
+	}
+}
@@ -0,0 +1,188 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+var (
+	_ ModelProvider = (*ChaosProvider)(nil)
+)
+
+var (
+	// ErrInjectedFault is the error returned by a ChaosProvider when it injects a failure.
+	ErrInjectedFault = errors.New("injected fault")
+	// ErrInjectedDisconnect is the error returned by a ChaosProvider when it cuts a stream.
+	ErrInjectedDisconnect = errors.New("injected stream disconnect")
+)
+
+// ChaosOption configures a ChaosProvider.
+type ChaosOption func(*ChaosProvider)
+
+// ChaosLatency delays calls by up to latency with probability p.
+func ChaosLatency(p float64, latency time.Duration) ChaosOption {
+	return func(c *ChaosProvider) {
+		c.latencyRate = p
+		c.latency = latency
+	}
+}
+
+// ChaosErrors fails calls with err with probability p. A nil err defaults to ErrInjectedFault.
+func ChaosErrors(p float64, err error) ChaosOption {
+	return func(c *ChaosProvider) {
+		c.errorRate = p
+		if err != nil {
+			c.err = err
+		}
+	}
+}
+
+// ChaosMalformed truncates the text of responses with probability p, which
+// breaks structured outputs such as JSON.
+func ChaosMalformed(p float64) ChaosOption {
+	return func(c *ChaosProvider) {
+		c.malformedRate = p
+	}
+}
+
+// ChaosDisconnect ends streams with ErrInjectedDisconnect after a chunk with probability p.
+func ChaosDisconnect(p float64) ChaosOption {
+	return func(c *ChaosProvider) {
+		c.disconnectRate = p
+	}
+}
+
+// ChaosSeed makes the injected faults reproducible.
+func ChaosSeed(seed uint64) ChaosOption {
+	return func(c *ChaosProvider) {
+		c.rand = rand.New(rand.NewPCG(seed, seed))
+	}
+}
+
+// ChaosProvider injects latency, errors, malformed responses and mid-stream
+// disconnects into a provider with the configured probabilities, so retry,
+// fallback and guardrail configurations can be exercised before production.
+type ChaosProvider struct {
+	provider       ModelProvider
+	latencyRate    float64
+	latency        time.Duration
+	errorRate      float64
+	err            error
+	malformedRate  float64
+	disconnectRate float64
+	mu             sync.Mutex
+	rand           *rand.Rand
+}
+
+// NewChaosProvider wraps provider with fault injection.
+func NewChaosProvider(provider ModelProvider, opts ...ChaosOption) *ChaosProvider {
+	c := &ChaosProvider{
+		provider: provider,
+		err:      ErrInjectedFault,
+		rand:     rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Generate executes the request with fault injection.
+func (c *ChaosProvider) Generate(ctx context.Context, req *ModelRequest, opts ...ModelOption) (*ModelResponse, error) {
+	if err := c.inject(ctx); err != nil {
+		return nil, err
+	}
+	res, err := c.provider.Generate(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if c.chance(c.malformedRate) {
+		res = malformed(res)
+	}
+	return res, nil
+}
+
+// NewStream executes the streaming request with fault injection.
+func (c *ChaosProvider) NewStream(ctx context.Context, req *ModelRequest, opts ...ModelOption) (Streamer[*ModelResponse], error) {
+	if err := c.inject(ctx); err != nil {
+		return nil, err
+	}
+	stream, err := c.provider.NewStream(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		disconnect = c.chance(c.disconnectRate)
+		malform    = c.chance(c.malformedRate)
+	)
+	if !disconnect && !malform {
+		return stream, nil
+	}
+	pipe := NewStreamPipe[*ModelResponse]()
+	pipe.Go(func() error {
+		defer stream.Close()
+		for stream.Next() {
+			res, err := stream.Current()
+			if err != nil {
+				return err
+			}
+			if malform {
+				res = malformed(res)
+			}
+			pipe.Send(res)
+			if disconnect {
+				return ErrInjectedDisconnect
+			}
+		}
+		return stream.Close()
+	})
+	return pipe, nil
+}
+
+// inject waits for the injected latency and returns the injected error, if any.
+func (c *ChaosProvider) inject(ctx context.Context) error {
+	if c.chance(c.latencyRate) {
+		c.mu.Lock()
+		delay := time.Duration(c.rand.Int64N(int64(c.latency) + 1))
+		c.mu.Unlock()
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if c.chance(c.errorRate) {
+		return c.err
+	}
+	return nil
+}
+
+func (c *ChaosProvider) chance(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rand.Float64() < p
+}
+
+// malformed returns a copy of res with the text parts cut in half.
+func malformed(res *ModelResponse) *ModelResponse {
+	out := &ModelResponse{Usage: res.Usage, Grounding: res.Grounding}
+	for _, msg := range res.Messages {
+		m := *msg
+		m.Parts = make([]Part, 0, len(msg.Parts))
+		for _, part := range msg.Parts {
+			if text, ok := part.(TextPart); ok {
+				runes := []rune(text.Text)
+				part = TextPart{Text: string(runes[:len(runes)/2])}
+			}
+			m.Parts = append(m.Parts, part)
+		}
+		out.Messages = append(out.Messages, &m)
+	}
+	return out
+}
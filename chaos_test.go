@@ -0,0 +1,59 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestChaosProvider(t *testing.T) {
+	provider := NewChaosProvider(textProvider("Canberra"), ChaosErrors(1, nil))
+	if _, err := provider.Generate(context.Background(), &ModelRequest{}); !errors.Is(err, ErrInjectedFault) {
+		t.Fatalf("expected ErrInjectedFault, got %v", err)
+	}
+}
+
+func TestChaosProvider_Stream(t *testing.T) {
+	errUpstream := errors.New("upstream")
+	tests := []struct {
+		name     string
+		provider *fakeProvider
+		opts     []ChaosOption
+		chunks   []string
+		err      error
+	}{
+		{
+			name:     "disconnect",
+			provider: textProvider("Canberra"),
+			opts:     []ChaosOption{ChaosMalformed(1), ChaosDisconnect(1), ChaosSeed(1)},
+			chunks:   []string{"Canb"},
+			err:      ErrInjectedDisconnect,
+		},
+		{
+			name:     "upstream error",
+			provider: &fakeProvider{replies: []string{"Canberra"}, trailing: errUpstream},
+			opts:     []ChaosOption{ChaosMalformed(1), ChaosSeed(1)},
+			chunks:   []string{"Canb"},
+			err:      errUpstream,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := NewChaosProvider(tt.provider, tt.opts...).NewStream(context.Background(), &ModelRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var chunks []string
+			for stream.Next() {
+				res, _ := stream.Current()
+				chunks = append(chunks, res.Messages[0].Text())
+			}
+			if err := stream.Close(); !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+			if len(chunks) != len(tt.chunks) || chunks[0] != tt.chunks[0] {
+				t.Fatalf("unexpected chunks %q", chunks)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package blades
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Clock tells the time recorded by runs, e.g. in tool traces, provenance
+// records and dead letters, and used to expire cache entries and cool down
+// circuit breakers. Pacing and rate limiting always use the wall clock, as
+// they wait in real time.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to a Clock.
+type ClockFunc func() time.Time
+
+// Now calls f().
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// IDGenerator generates the identifiers of runs and messages.
+type IDGenerator interface {
+	NewID() string
+}
+
+// IDGeneratorFunc adapts a function to an IDGenerator.
+type IDGeneratorFunc func() string
+
+// NewID calls f().
+func (f IDGeneratorFunc) NewID() string {
+	return f()
+}
+
+type clockHolder struct{ Clock }
+
+type idHolder struct{ IDGenerator }
+
+var (
+	clock = func() *atomic.Pointer[clockHolder] {
+		var p atomic.Pointer[clockHolder]
+		p.Store(&clockHolder{ClockFunc(time.Now)})
+		return &p
+	}()
+	ids = func() *atomic.Pointer[idHolder] {
+		var p atomic.Pointer[idHolder]
+		p.Store(&idHolder{IDGeneratorFunc(uuid.NewString)})
+		return &p
+	}()
+)
+
+// SetClock replaces the clock of the package, the wall clock by default, and
+// returns a function restoring the previous one. It is meant for tests and
+// replay tooling producing deterministic artifacts.
+func SetClock(c Clock) (restore func()) {
+	prev := clock.Swap(&clockHolder{c})
+	return func() { clock.Store(prev) }
+}
+
+// SetIDGenerator replaces the generator of run and message identifiers, random
+// UUIDs by default, and returns a function restoring the previous one.
+func SetIDGenerator(g IDGenerator) (restore func()) {
+	prev := ids.Swap(&idHolder{g})
+	return func() { ids.Store(prev) }
+}
+
+// Now returns the current time of the package clock.
+func Now() time.Time {
+	return clock.Load().Now()
+}
+
+// Since returns the time elapsed since t on the package clock.
+func Since(t time.Time) time.Duration {
+	return Now().Sub(t)
+}
+
+// NewID generates an identifier with the package generator.
+func NewID() string {
+	return ids.Load().NewID()
+}
+
+// SequentialIDs returns an IDGenerator yielding prefix-1, prefix-2 and so on.
+func SequentialIDs(prefix string) IDGenerator {
+	var n atomic.Int64
+	return IDGeneratorFunc(func() string {
+		return prefix + "-" + strconv.FormatInt(n.Add(1), 10)
+	})
+}
+
+// ManualClock is a Clock that only moves when told to.
+type ManualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewManualClock creates a ManualClock set to now.
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+// Now returns the time of the clock.
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
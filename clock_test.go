@@ -0,0 +1,38 @@
+package blades
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDeterministicRuns(t *testing.T) {
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	defer SetClock(NewManualClock(start))()
+	defer SetIDGenerator(SequentialIDs("id"))()
+
+	agent := NewAgent("test", WithProvider(weatherProvider()), WithTools(weatherTool()), WithProvenance())
+	res, err := agent.Run(context.Background(), NewPrompt(UserMessage("weather in Paris?")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.ToolTrace[0].StartedAt; !got.Equal(start) || res.ToolTrace[0].Duration != 0 {
+		t.Fatalf("unexpected tool trace time %v after %v", got, res.ToolTrace[0].Duration)
+	}
+	if p := res.Provenance; p.RunID != "id-2" || !p.Timestamp.Equal(start) {
+		t.Fatalf("unexpected provenance %+v", p)
+	}
+	if got := NewMessageID(); got != "id-4" {
+		t.Fatalf("expected sequential IDs, got %s", got)
+	}
+}
+
+func TestManualClock(t *testing.T) {
+	c := NewManualClock(time.Unix(0, 0))
+	defer SetClock(c)()
+	started := Now()
+	c.Advance(time.Minute)
+	if Since(started) != time.Minute {
+		t.Fatalf("expected a minute to elapse, got %v", Since(started))
+	}
+}
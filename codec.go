@@ -0,0 +1,73 @@
+package blades
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// encodedPart is a Part tagged with its type.
+type encodedPart struct {
+	Type     string   `json:"type"`
+	Text     string   `json:"text,omitempty"`
+	Name     string   `json:"name,omitempty"`
+	URI      string   `json:"uri,omitempty"`
+	Bytes    []byte   `json:"bytes,omitempty"`
+	MimeType MimeType `json:"mimeType,omitempty"`
+}
+
+type encodedMessage struct {
+	Message
+	Parts []encodedPart `json:"parts"`
+}
+
+func encodeMessage(msg *Message) encodedMessage {
+	m := encodedMessage{Message: *msg}
+	for _, part := range msg.Parts {
+		switch v := part.(type) {
+		case TextPart:
+			m.Parts = append(m.Parts, encodedPart{Type: "text", Text: v.Text})
+		case FilePart:
+			m.Parts = append(m.Parts, encodedPart{Type: "file", Name: v.Name, URI: v.URI, MimeType: v.MimeType})
+		case DataPart:
+			m.Parts = append(m.Parts, encodedPart{Type: "data", Name: v.Name, Bytes: v.Bytes, MimeType: v.MimeType})
+		}
+	}
+	return m
+}
+
+func (m encodedMessage) decode() (*Message, error) {
+	msg := m.Message
+	msg.Parts = nil
+	for _, part := range m.Parts {
+		switch part.Type {
+		case "text":
+			msg.Parts = append(msg.Parts, TextPart{Text: part.Text})
+		case "file":
+			msg.Parts = append(msg.Parts, FilePart{Name: part.Name, URI: part.URI, MimeType: part.MimeType})
+		case "data":
+			msg.Parts = append(msg.Parts, DataPart{Name: part.Name, Bytes: part.Bytes, MimeType: part.MimeType})
+		default:
+			return nil, fmt.Errorf("unknown part type %q", part.Type)
+		}
+	}
+	return &msg, nil
+}
+
+// MarshalMessage encodes a message as JSON, preserving the types of its parts,
+// e.g. to persist conversation history.
+func MarshalMessage(msg *Message) ([]byte, error) {
+	return json.Marshal(encodeMessage(msg))
+}
+
+// UnmarshalMessage decodes a message encoded by MarshalMessage.
+func UnmarshalMessage(data []byte) (*Message, error) {
+	var m encodedMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	msg, err := m.decode()
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal message: %w", err)
+	}
+	return msg, nil
+}
@@ -0,0 +1,155 @@
+// Package config builds agents and model aliases from declarative
+// configuration and rebuilds them when the configuration changes.
+package config
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/go-kratos/blades"
+)
+
+var (
+	// ErrInvalidConfig indicates a configuration referring to unknown models, providers or tools.
+	ErrInvalidConfig = errors.New("config: invalid configuration")
+)
+
+// Config declares model aliases and agents, with per-environment profiles
+// overlaying them.
+type Config struct {
+	Models map[string]ModelConfig `json:"models,omitempty"`
+	Agents map[string]AgentConfig `json:"agents,omitempty"`
+	// Budget is the spend, priced with the pricing of the Registry, at which
+	// runs of the agents are aborted. It is reset when a configuration is applied.
+	Budget float64 `json:"budget,omitempty"`
+	// Verbose logs the provider and tool calls of the agents to the logger of the Registry.
+	Verbose *bool `json:"verbose,omitempty"`
+	// Profiles are overlays of the configuration per environment, e.g. "dev",
+	// "staging" and "prod". See Profile.
+	Profiles map[string]*Config `json:"profiles,omitempty"`
+}
+
+// ModelConfig maps a model alias to a provider and model, with default options.
+type ModelConfig struct {
+	// Provider is the name of a provider registered with the Registry.
+	Provider string `json:"provider"`
+	// Model is the model name sent to the provider.
+	Model           string   `json:"model"`
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *float64 `json:"top_p,omitempty"`
+	MaxOutputTokens int64    `json:"max_output_tokens,omitempty"`
+}
+
+// AgentConfig declares an agent.
+type AgentConfig struct {
+	// Model is a model alias of the configuration.
+	Model        string `json:"model"`
+	Instructions string `json:"instructions,omitempty"`
+	// Tools are names of tools registered with the Registry.
+	Tools           []string `json:"tools,omitempty"`
+	Temperature     *float64 `json:"temperature,omitempty"`
+	MaxOutputTokens int64    `json:"max_output_tokens,omitempty"`
+	MaxIterations   int      `json:"max_iterations,omitempty"`
+}
+
+// Profile returns the configuration with the overlay of the named profile
+// applied, or the configuration itself when name is empty. Overlay models and
+// agents are merged field by field into those of the same name, so a profile
+// only declares what differs, e.g. another provider or a lower budget.
+func (c *Config) Profile(name string) (*Config, error) {
+	resolved := &Config{
+		Models:  maps.Clone(c.Models),
+		Agents:  maps.Clone(c.Agents),
+		Budget:  c.Budget,
+		Verbose: c.Verbose,
+	}
+	if name == "" {
+		return resolved, nil
+	}
+	overlay, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown profile %q", ErrInvalidConfig, name)
+	}
+	if resolved.Models == nil {
+		resolved.Models = make(map[string]ModelConfig)
+	}
+	for alias, m := range overlay.Models {
+		resolved.Models[alias] = resolved.Models[alias].merge(m)
+	}
+	if resolved.Agents == nil {
+		resolved.Agents = make(map[string]AgentConfig)
+	}
+	for name, a := range overlay.Agents {
+		resolved.Agents[name] = resolved.Agents[name].merge(a)
+	}
+	if overlay.Budget != 0 {
+		resolved.Budget = overlay.Budget
+	}
+	if overlay.Verbose != nil {
+		resolved.Verbose = overlay.Verbose
+	}
+	return resolved, nil
+}
+
+func (m ModelConfig) merge(overlay ModelConfig) ModelConfig {
+	m.Provider = cmp.Or(overlay.Provider, m.Provider)
+	m.Model = cmp.Or(overlay.Model, m.Model)
+	m.Temperature = cmp.Or(overlay.Temperature, m.Temperature)
+	m.TopP = cmp.Or(overlay.TopP, m.TopP)
+	m.MaxOutputTokens = cmp.Or(overlay.MaxOutputTokens, m.MaxOutputTokens)
+	return m
+}
+
+func (a AgentConfig) merge(overlay AgentConfig) AgentConfig {
+	a.Model = cmp.Or(overlay.Model, a.Model)
+	a.Instructions = cmp.Or(overlay.Instructions, a.Instructions)
+	if overlay.Tools != nil {
+		a.Tools = overlay.Tools
+	}
+	a.Temperature = cmp.Or(overlay.Temperature, a.Temperature)
+	a.MaxOutputTokens = cmp.Or(overlay.MaxOutputTokens, a.MaxOutputTokens)
+	a.MaxIterations = cmp.Or(overlay.MaxIterations, a.MaxIterations)
+	return a
+}
+
+// Parse decodes a JSON configuration.
+func Parse(data []byte) (*Config, error) {
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	return &c, nil
+}
+
+// options returns the model options of the alias.
+func (m ModelConfig) options() []blades.ModelOption {
+	var opts []blades.ModelOption
+	if m.Temperature != nil {
+		opts = append(opts, blades.Temperature(*m.Temperature))
+	}
+	if m.TopP != nil {
+		opts = append(opts, blades.TopP(*m.TopP))
+	}
+	if m.MaxOutputTokens > 0 {
+		opts = append(opts, blades.MaxOutputTokens(m.MaxOutputTokens))
+	}
+	return opts
+}
+
+// options returns the model options of the agent, applied after those of its model.
+func (a AgentConfig) options() []blades.ModelOption {
+	var opts []blades.ModelOption
+	if a.Temperature != nil {
+		opts = append(opts, blades.Temperature(*a.Temperature))
+	}
+	if a.MaxOutputTokens > 0 {
+		opts = append(opts, blades.MaxOutputTokens(a.MaxOutputTokens))
+	}
+	if a.MaxIterations > 0 {
+		opts = append(opts, blades.MaxIterations(a.MaxIterations))
+	}
+	return opts
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	c, err := Parse([]byte(`{
+		"models": {"default": {"provider": "openai", "model": "gpt-4o", "temperature": 0.2}},
+		"agents": {"support": {"model": "default", "instructions": "Be kind.", "tools": ["search"]}},
+		"budget": 100,
+		"profiles": {
+			"dev": {
+				"models": {"default": {"provider": "ollama", "model": "llama3"}},
+				"agents": {"support": {"tools": []}},
+				"budget": 1,
+				"verbose": true
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev, err := c.Profile("dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	model := dev.Models["default"]
+	if model.Provider != "ollama" || model.Model != "llama3" || *model.Temperature != 0.2 {
+		t.Fatalf("unexpected dev model: %+v", model)
+	}
+	agent := dev.Agents["support"]
+	if agent.Instructions != "Be kind." || len(agent.Tools) != 0 {
+		t.Fatalf("unexpected dev agent: %+v", agent)
+	}
+	if dev.Budget != 1 || dev.Verbose == nil || !*dev.Verbose || dev.Profiles != nil {
+		t.Fatalf("unexpected dev settings: %+v", dev)
+	}
+	if c.Models["default"].Provider != "openai" {
+		t.Fatalf("profile modified the base configuration")
+	}
+	if _, err := c.Profile("qa"); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("expected unknown profile error, got %v", err)
+	}
+}
@@ -0,0 +1,336 @@
+package config
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-kratos/blades"
+)
+
+var (
+	// ErrUnsupportedPrompt indicates an imported prompt uses features without a blades equivalent.
+	ErrUnsupportedPrompt = errors.New("config: unsupported prompt")
+)
+
+// PromptMessage is a message template in Go text/template syntax.
+type PromptMessage struct {
+	Role     blades.Role
+	Template string
+}
+
+// PromptPack is a prompt imported from another framework.
+type PromptPack struct {
+	// Variables are the input variables of the prompt.
+	Variables []string
+	Messages  []PromptMessage
+}
+
+// Template returns a PromptTemplate rendering the messages with params.
+func (p *PromptPack) Template(params map[string]any) *blades.PromptTemplate {
+	t := blades.NewPromptTemplate()
+	for _, msg := range p.Messages {
+		switch msg.Role {
+		case blades.RoleSystem:
+			t.System(msg.Template, params)
+		case blades.RoleDeveloper:
+			t.Developer(msg.Template, params)
+		case blades.RoleAssistant:
+			t.Assistant(msg.Template, params)
+		default:
+			t.User(msg.Template, params)
+		}
+	}
+	return t
+}
+
+// langchainPrompt is a LangChain prompt saved with prompt.save(), or a message
+// of a chat prompt.
+type langchainPrompt struct {
+	Type           string            `json:"_type"`
+	InputVariables []string          `json:"input_variables"`
+	Template       string            `json:"template"`
+	TemplateFormat string            `json:"template_format"`
+	Messages       []json.RawMessage `json:"messages"`
+}
+
+// langchainSerialized is an object in the LangChain serialization format of dumpd.
+type langchainSerialized struct {
+	LC     int                        `json:"lc"`
+	ID     []string                   `json:"id"`
+	Kwargs map[string]json.RawMessage `json:"kwargs"`
+}
+
+// ImportLangChainPrompt converts a LangChain PromptTemplate or ChatPromptTemplate,
+// either saved with prompt.save() or serialized with dumpd, into a PromptPack.
+// f-string, mustache and jinja2 variables are converted; mustache sections,
+// jinja2 statements and message placeholders fail with ErrUnsupportedPrompt.
+func ImportLangChainPrompt(data []byte) (*PromptPack, error) {
+	var probe langchainSerialized
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	pack := &PromptPack{}
+	if probe.LC > 0 {
+		if err := pack.addSerialized(probe); err != nil {
+			return nil, err
+		}
+		return pack, nil
+	}
+	var prompt langchainPrompt
+	if err := json.Unmarshal(data, &prompt); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	if len(prompt.Messages) == 0 {
+		return pack, pack.add(blades.RoleUser, prompt.Template, prompt.TemplateFormat, prompt.InputVariables)
+	}
+	for _, raw := range prompt.Messages {
+		var msg langchainSerialized
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+		if err := pack.addSerialized(msg); err != nil {
+			return nil, err
+		}
+	}
+	return pack, nil
+}
+
+// addSerialized adds the messages of a serialized prompt or message template.
+func (p *PromptPack) addSerialized(obj langchainSerialized) error {
+	kind := ""
+	if len(obj.ID) > 0 {
+		kind = obj.ID[len(obj.ID)-1]
+	}
+	switch kind {
+	case "PromptTemplate":
+		var prompt langchainPrompt
+		if err := unmarshalKwargs(obj.Kwargs, &prompt); err != nil {
+			return err
+		}
+		return p.add(blades.RoleUser, prompt.Template, prompt.TemplateFormat, prompt.InputVariables)
+	case "ChatPromptTemplate":
+		var messages []langchainSerialized
+		if err := json.Unmarshal(obj.Kwargs["messages"], &messages); err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+		for _, msg := range messages {
+			if err := p.addSerialized(msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "SystemMessagePromptTemplate", "HumanMessagePromptTemplate", "AIMessagePromptTemplate":
+		var prompt langchainSerialized
+		if err := json.Unmarshal(obj.Kwargs["prompt"], &prompt); err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+		var tmpl langchainPrompt
+		if err := unmarshalKwargs(prompt.Kwargs, &tmpl); err != nil {
+			return err
+		}
+		role := blades.RoleUser
+		switch kind {
+		case "SystemMessagePromptTemplate":
+			role = blades.RoleSystem
+		case "AIMessagePromptTemplate":
+			role = blades.RoleAssistant
+		}
+		return p.add(role, tmpl.Template, tmpl.TemplateFormat, tmpl.InputVariables)
+	case "SystemMessage", "HumanMessage", "AIMessage":
+		var content string
+		if err := json.Unmarshal(obj.Kwargs["content"], &content); err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+		role := map[string]blades.Role{"SystemMessage": blades.RoleSystem, "HumanMessage": blades.RoleUser, "AIMessage": blades.RoleAssistant}[kind]
+		p.Messages = append(p.Messages, PromptMessage{Role: role, Template: escapeTemplate(content)})
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedPrompt, cmp.Or(kind, "unknown object"))
+	}
+}
+
+// unmarshalKwargs decodes the keyword arguments of a serialized object into v.
+func unmarshalKwargs(kwargs map[string]json.RawMessage, v any) error {
+	b, err := json.Marshal(kwargs)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	return nil
+}
+
+// add converts the template and appends it as a message of role.
+func (p *PromptPack) add(role blades.Role, template, format string, variables []string) error {
+	var (
+		converted string
+		err       error
+	)
+	switch format {
+	case "", "f-string":
+		converted, err = convertFString(template)
+	case "mustache":
+		converted, err = convertDelimited(template, mustacheTag, "{{#", "{{^", "{{/", "{{>")
+	case "jinja2":
+		converted, err = convertDelimited(template, jinjaTag, "{%")
+	default:
+		err = fmt.Errorf("%w: template format %q", ErrUnsupportedPrompt, format)
+	}
+	if err != nil {
+		return err
+	}
+	p.Messages = append(p.Messages, PromptMessage{Role: role, Template: converted})
+	for _, v := range variables {
+		if !slices.Contains(p.Variables, v) {
+			p.Variables = append(p.Variables, v)
+		}
+	}
+	return nil
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// variable returns the Go template action printing the variable name.
+func variable(name string) string {
+	if identifier.MatchString(name) {
+		return "{{." + name + "}}"
+	}
+	return fmt.Sprintf("{{index . %q}}", name)
+}
+
+// escapeTemplate returns text as a Go template printing it verbatim.
+func escapeTemplate(text string) string {
+	return strings.ReplaceAll(text, "{{", `{{"{{"}}`)
+}
+
+// convertFString converts a Python f-string template, where {name} is a
+// variable and {{ and }} are literal braces.
+func convertFString(template string) (string, error) {
+	var buf strings.Builder
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		switch {
+		case c == '{' && strings.HasPrefix(template[i:], "{{"):
+			buf.WriteString(`{{"{"}}`)
+			i++
+		case c == '}' && strings.HasPrefix(template[i:], "}}"):
+			buf.WriteByte('}')
+			i++
+		case c == '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("%w: unclosed brace in %q", ErrUnsupportedPrompt, template)
+			}
+			name, _, _ := strings.Cut(template[i+1:i+end], ":")
+			buf.WriteString(variable(strings.TrimSpace(name)))
+			i += end
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String(), nil
+}
+
+var (
+	mustacheTag = regexp.MustCompile(`\{\{\{?\s*([^{}#^/>!\s]+)\s*\}?\}\}`)
+	jinjaTag    = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+)
+
+// convertDelimited converts the {{name}} variables of a mustache or jinja2
+// template, rejecting the unsupported constructs.
+func convertDelimited(template string, tag *regexp.Regexp, unsupported ...string) (string, error) {
+	for _, construct := range unsupported {
+		if strings.Contains(template, construct) {
+			return "", fmt.Errorf("%w: %q in %q", ErrUnsupportedPrompt, construct, template)
+		}
+	}
+	var (
+		buf  strings.Builder
+		last int
+	)
+	for _, m := range tag.FindAllStringSubmatchIndex(template, -1) {
+		buf.WriteString(escapeTemplate(template[last:m[0]]))
+		buf.WriteString(variable(template[m[2]:m[3]]))
+		last = m[1]
+	}
+	buf.WriteString(escapeTemplate(template[last:]))
+	return buf.String(), nil
+}
+
+// openAIAssistant is an OpenAI assistant, as returned by the Assistants API,
+// or the configuration of a GPT.
+type openAIAssistant struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Model        string   `json:"model"`
+	Instructions string   `json:"instructions"`
+	Temperature  *float64 `json:"temperature"`
+	Tools        []struct {
+		Type     string `json:"type"`
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	} `json:"tools"`
+}
+
+// ImportOpenAIAssistants converts OpenAI Assistants API objects, or GPT
+// configurations, into a Config with an agent per assistant and a model alias
+// per model served by provider. The data is a single assistant, an array of
+// them or a list response. Agents are named after the assistants, and their
+// function tools are referenced by name, so they must be registered with the
+// Registry. Settings without an agent equivalent, such as top_p and built-in
+// tools like file_search, are dropped. GPTs without a model use gpt-4o.
+func ImportOpenAIAssistants(data []byte, provider string) (*Config, error) {
+	var assistants []openAIAssistant
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(data, []byte("[")):
+		if err := json.Unmarshal(data, &assistants); err != nil {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+	default:
+		var list struct {
+			Data *[]openAIAssistant `json:"data"`
+		}
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+		if list.Data != nil {
+			assistants = *list.Data
+			break
+		}
+		var assistant openAIAssistant
+		if err := json.Unmarshal(data, &assistant); err != nil {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+		assistants = append(assistants, assistant)
+	}
+	c := &Config{Models: make(map[string]ModelConfig), Agents: make(map[string]AgentConfig)}
+	for i, a := range assistants {
+		name := cmp.Or(a.Name, a.ID, fmt.Sprintf("assistant-%d", i+1))
+		if _, ok := c.Agents[name]; ok {
+			return nil, fmt.Errorf("%w: duplicate assistant %q", ErrInvalidConfig, name)
+		}
+		model := cmp.Or(a.Model, "gpt-4o")
+		c.Models[model] = ModelConfig{Provider: provider, Model: model}
+		agent := AgentConfig{
+			Model:        model,
+			Instructions: a.Instructions,
+			Temperature:  a.Temperature,
+		}
+		for _, tool := range a.Tools {
+			if tool.Type == "function" && tool.Function.Name != "" {
+				agent.Tools = append(agent.Tools, tool.Function.Name)
+			}
+		}
+		c.Agents[name] = agent
+	}
+	return c, nil
+}
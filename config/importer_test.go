@@ -0,0 +1,66 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestImportLangChainPrompt(t *testing.T) {
+	pack, err := ImportLangChainPrompt([]byte(`{
+		"lc": 1, "type": "constructor", "id": ["langchain", "prompts", "chat", "ChatPromptTemplate"],
+		"kwargs": {"input_variables": ["topic"], "messages": [
+			{"lc": 1, "type": "constructor", "id": ["langchain", "prompts", "chat", "SystemMessagePromptTemplate"],
+			 "kwargs": {"prompt": {"lc": 1, "type": "constructor", "id": ["langchain", "prompts", "prompt", "PromptTemplate"],
+				"kwargs": {"input_variables": [], "template": "Answer in JSON like {{\"a\": 1}}.", "template_format": "f-string"}}}},
+			{"lc": 1, "type": "constructor", "id": ["langchain", "prompts", "chat", "HumanMessagePromptTemplate"],
+			 "kwargs": {"prompt": {"lc": 1, "type": "constructor", "id": ["langchain", "prompts", "prompt", "PromptTemplate"],
+				"kwargs": {"input_variables": ["topic"], "template": "Tell me about {topic}.", "template_format": "f-string"}}}}
+		]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompt, err := pack.Template(map[string]any{"topic": "kratos"}).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pack.Variables) != 1 || len(prompt.Messages) != 2 {
+		t.Fatalf("unexpected pack %+v", pack)
+	}
+	if got := prompt.Messages[0].Text(); got != `Answer in JSON like {"a": 1}.` {
+		t.Fatalf("unexpected system message %q", got)
+	}
+	if got := prompt.Messages[1].Text(); got != "Tell me about kratos." {
+		t.Fatalf("unexpected user message %q", got)
+	}
+
+	pack, err = ImportLangChainPrompt([]byte(`{"_type": "prompt", "input_variables": ["user-name"], "template": "Hi {{user-name}}!", "template_format": "mustache"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prompt, err = pack.Template(map[string]any{"user-name": "Ada"}).Build(); err != nil || prompt.Messages[0].Text() != "Hi Ada!" {
+		t.Fatalf("unexpected mustache prompt %v: %v", prompt, err)
+	}
+	_, err = ImportLangChainPrompt([]byte(`{"_type": "prompt", "template": "{% for x in xs %}{{x}}{% endfor %}", "template_format": "jinja2"}`))
+	if !errors.Is(err, ErrUnsupportedPrompt) {
+		t.Fatalf("expected ErrUnsupportedPrompt, got %v", err)
+	}
+}
+
+func TestImportOpenAIAssistants(t *testing.T) {
+	c, err := ImportOpenAIAssistants([]byte(`{"object": "list", "data": [
+		{"id": "asst_1", "object": "assistant", "name": "math", "model": "gpt-4o-mini", "instructions": "Solve it.", "temperature": 0.1,
+		 "tools": [{"type": "code_interpreter"}, {"type": "function", "function": {"name": "calculator"}}]},
+		{"name": "writer", "instructions": "Write well."}
+	]}`), "openai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	math := c.Agents["math"]
+	if math.Model != "gpt-4o-mini" || math.Instructions != "Solve it." || *math.Temperature != 0.1 || len(math.Tools) != 1 || math.Tools[0] != "calculator" {
+		t.Fatalf("unexpected agent %+v", math)
+	}
+	if c.Agents["writer"].Model != "gpt-4o" || c.Models["gpt-4o"].Provider != "openai" {
+		t.Fatalf("unexpected config %+v", c)
+	}
+}
@@ -0,0 +1,227 @@
+package config
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-kratos/blades"
+)
+
+var (
+	_ blades.Runner = (*agentRunner)(nil)
+)
+
+// Model is a resolved model alias.
+type Model struct {
+	Name     string
+	Provider blades.ModelProvider
+	Options  []blades.ModelOption
+}
+
+// snapshot is an immutable set of agents and models built from a Config.
+type snapshot struct {
+	config *Config
+	models map[string]*Model
+	agents map[string]*blades.Agent
+}
+
+// Option configures a Registry.
+type Option func(*Registry)
+
+// WithAgentOptions applies opts to every agent, e.g. to add a memory that the
+// configuration does not declare. Use WithMiddleware for middlewares.
+func WithAgentOptions(opts ...blades.Option) Option {
+	return func(r *Registry) {
+		r.agentOpts = append(r.agentOpts, opts...)
+	}
+}
+
+// WithMiddleware installs middlewares on every agent, inside the budget
+// enforcement of the configuration.
+func WithMiddleware(mws ...blades.Middleware) Option {
+	return func(r *Registry) {
+		r.middlewares = append(r.middlewares, mws...)
+	}
+}
+
+// WithProfile selects the profile applied over the configurations, e.g. the
+// environment the service runs in.
+func WithProfile(name string) Option {
+	return func(r *Registry) {
+		r.profile = name
+	}
+}
+
+// WithLogger sets the logger of the agents of verbose configurations.
+func WithLogger(logger *slog.Logger) Option {
+	return func(r *Registry) {
+		r.logger = logger
+	}
+}
+
+// WithPricing sets the pricing enforcing budgets, blades.DefaultPriceTable by default.
+func WithPricing(pricing blades.Pricing) Option {
+	return func(r *Registry) {
+		r.pricing = pricing
+	}
+}
+
+// Registry builds agents and model aliases from a Config using the providers
+// and tools registered in code. Applying a new configuration rebuilds them and
+// swaps them in atomically, so runs in flight keep the agents they started with.
+type Registry struct {
+	mu          sync.RWMutex
+	providers   map[string]blades.ModelProvider
+	tools       map[string]*blades.Tool
+	agentOpts   []blades.Option
+	middlewares []blades.Middleware
+	profile     string
+	logger      *slog.Logger
+	pricing     blades.Pricing
+	current     atomic.Pointer[snapshot]
+}
+
+// NewRegistry creates a new Registry.
+func NewRegistry(opts ...Option) *Registry {
+	r := &Registry{
+		providers: make(map[string]blades.ModelProvider),
+		tools:     make(map[string]*blades.Tool),
+		pricing:   blades.DefaultPriceTable(),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.current.Store(&snapshot{config: &Config{}})
+	return r
+}
+
+// RegisterProvider makes a provider available to model aliases under name.
+func (r *Registry) RegisterProvider(name string, provider blades.ModelProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[name] = provider
+}
+
+// RegisterTool makes a tool available to agents under its name.
+func (r *Registry) RegisterTool(tool *blades.Tool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tools[tool.Name] = tool
+}
+
+// Apply builds the agents and models of the configuration, with the profile
+// of the Registry applied, and swaps them in. An invalid configuration is
+// rejected and the current one is kept.
+func (r *Registry) Apply(c *Config) error {
+	c, err := c.Profile(r.profile)
+	if err != nil {
+		return err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var (
+		shared      []blades.Option
+		middlewares = slices.Clip(r.middlewares)
+	)
+	if c.Budget > 0 {
+		tracker := blades.NewCostTracker(r.pricing, blades.CostBudget(c.Budget))
+		middlewares = append([]blades.Middleware{tracker.Middleware()}, middlewares...)
+	}
+	if len(middlewares) > 0 {
+		shared = append(shared, blades.WithMiddleware(blades.ChainMiddlewares(middlewares...)))
+	}
+	if c.Verbose != nil && *c.Verbose && r.logger != nil {
+		shared = append(shared, blades.WithLogger(r.logger))
+	}
+	s := &snapshot{config: c, models: make(map[string]*Model), agents: make(map[string]*blades.Agent)}
+	for alias, m := range c.Models {
+		provider, ok := r.providers[m.Provider]
+		if !ok {
+			return fmt.Errorf("%w: model %s uses unknown provider %q", ErrInvalidConfig, alias, m.Provider)
+		}
+		s.models[alias] = &Model{Name: cmp.Or(m.Model, alias), Provider: provider, Options: m.options()}
+	}
+	for name, a := range c.Agents {
+		model, ok := s.models[a.Model]
+		if !ok {
+			return fmt.Errorf("%w: agent %s uses unknown model %q", ErrInvalidConfig, name, a.Model)
+		}
+		tools := make([]*blades.Tool, 0, len(a.Tools))
+		for _, toolName := range a.Tools {
+			tool, ok := r.tools[toolName]
+			if !ok {
+				return fmt.Errorf("%w: agent %s uses unknown tool %q", ErrInvalidConfig, name, toolName)
+			}
+			tools = append(tools, tool)
+		}
+		opts := append(append(slices.Clip(r.agentOpts), shared...),
+			blades.WithModel(model.Name),
+			blades.WithProvider(model.Provider),
+			blades.WithInstructions(a.Instructions),
+			blades.WithTools(tools...),
+			blades.WithModelOptions(append(slices.Clip(model.Options), a.options()...)...),
+		)
+		s.agents[name] = blades.NewAgent(name, opts...)
+	}
+	r.current.Store(s)
+	return nil
+}
+
+// Config returns the configuration currently applied, with its profile applied.
+func (r *Registry) Config() *Config {
+	return r.current.Load().config
+}
+
+// Agent returns the current agent named name.
+func (r *Registry) Agent(name string) (*blades.Agent, bool) {
+	agent, ok := r.current.Load().agents[name]
+	return agent, ok
+}
+
+// Model returns the current model of an alias.
+func (r *Registry) Model(alias string) (*Model, bool) {
+	model, ok := r.current.Load().models[alias]
+	return model, ok
+}
+
+// Runner returns a runner that runs the current agent named name, so that
+// flows built once pick up configuration changes on their next run.
+func (r *Registry) Runner(name string) blades.Runner {
+	return &agentRunner{registry: r, name: name}
+}
+
+type agentRunner struct {
+	registry *Registry
+	name     string
+}
+
+func (a *agentRunner) agent() (*blades.Agent, error) {
+	agent, ok := a.registry.Agent(a.name)
+	if !ok {
+		return nil, fmt.Errorf("config: agent %q is not configured", a.name)
+	}
+	return agent, nil
+}
+
+// Run runs the current agent.
+func (a *agentRunner) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	agent, err := a.agent()
+	if err != nil {
+		return nil, err
+	}
+	return agent.Run(ctx, prompt, opts...)
+}
+
+// RunStream streams the current agent.
+func (a *agentRunner) RunStream(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (blades.Streamer[*blades.Generation], error) {
+	agent, err := a.agent()
+	if err != nil {
+		return nil, err
+	}
+	return agent.RunStream(ctx, prompt, opts...)
+}
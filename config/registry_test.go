@@ -0,0 +1,72 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/blades"
+)
+
+type echoProvider struct{}
+
+func (echoProvider) Generate(ctx context.Context, req *blades.ModelRequest, opts ...blades.ModelOption) (*blades.ModelResponse, error) {
+	return &blades.ModelResponse{Messages: []*blades.Message{blades.AssistantMessage(req.Model + ": " + req.Messages[0].Text())}}, nil
+}
+
+func (echoProvider) NewStream(ctx context.Context, req *blades.ModelRequest, opts ...blades.ModelOption) (blades.Streamer[*blades.ModelResponse], error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestRegistryWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agents.json")
+	write := func(instructions string) {
+		data := `{"models":{"fast":{"provider":"echo","model":"mini"}},"agents":{"support":{"model":"fast","instructions":"` + instructions + `"}}}`
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("v1")
+	registry := NewRegistry()
+	registry.RegisterProvider("echo", echoProvider{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := make(chan error, 1)
+	go func() { errs <- registry.Watch(ctx, NewFileSource(path, 5*time.Millisecond), nil) }()
+
+	runner := registry.Runner("support")
+	for deadline := time.Now().Add(time.Second); ; {
+		g, err := runner.Run(ctx, blades.NewPrompt(blades.UserMessage("hi")))
+		if err == nil && g.Text() == "mini: v1" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("agent not built: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	write("v2")
+	for deadline := time.Now().Add(time.Second); ; {
+		g, err := runner.Run(ctx, blades.NewPrompt(blades.UserMessage("hi")))
+		if err == nil && g.Text() == "mini: v2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("agent not rebuilt")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := registry.Apply(&Config{Agents: map[string]AgentConfig{"x": {Model: "missing"}}}); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("expected invalid config, got %v", err)
+	}
+	if _, ok := registry.Agent("support"); !ok {
+		t.Fatalf("invalid config replaced the current one")
+	}
+	cancel()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected watch error: %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"time"
+)
+
+// Source loads configurations.
+type Source interface {
+	Load(ctx context.Context) (*Config, error)
+}
+
+// Watcher is a Source that notifies changes. Watch blocks until ctx is done,
+// calling onChange after each change of the configuration.
+type Watcher interface {
+	Source
+	Watch(ctx context.Context, onChange func()) error
+}
+
+// FileSource loads a JSON configuration file and watches it by polling.
+type FileSource struct {
+	path     string
+	interval time.Duration
+}
+
+// NewFileSource creates a new FileSource for path, checked for changes every
+// interval, or every 5 seconds when interval is 0.
+func NewFileSource(path string, interval time.Duration) *FileSource {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	return &FileSource{path: path, interval: interval}
+}
+
+// Load reads and parses the file.
+func (s *FileSource) Load(ctx context.Context) (*Config, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Watch polls the file, calling onChange when its content changes.
+func (s *FileSource) Watch(ctx context.Context, onChange func()) error {
+	last, _ := os.ReadFile(s.path)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			data, err := os.ReadFile(s.path)
+			if err != nil || bytes.Equal(data, last) {
+				continue
+			}
+			last = data
+			onChange()
+		}
+	}
+}
+
+// Watch applies the configuration of source, then reapplies it on every change
+// when source is a Watcher, until ctx is done. Failures to load or apply a
+// changed configuration are passed to onError and the current one is kept.
+func (r *Registry) Watch(ctx context.Context, source Source, onError func(error)) error {
+	reload := func() error {
+		c, err := source.Load(ctx)
+		if err != nil {
+			return err
+		}
+		return r.Apply(c)
+	}
+	if err := reload(); err != nil {
+		return err
+	}
+	watcher, ok := source.(Watcher)
+	if !ok {
+		return nil
+	}
+	return watcher.Watch(ctx, func() {
+		if err := reload(); err != nil && onError != nil {
+			onError(err)
+		}
+	})
+}
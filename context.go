@@ -6,8 +6,13 @@ type ctxAgentKey struct{}
 
 // AgentContext holds information about the agent handling the request.
 type AgentContext struct {
+	Name         string
 	Model        string
 	Instructions string
+	// ConversationID is the conversation of the prompt being run, if any.
+	ConversationID string
+	// PromptVersion is the registered prompt the instructions come from, if any.
+	PromptVersion *PromptVersion
 }
 
 // NewContext returns a new context with the given AgentContext.
@@ -0,0 +1,127 @@
+package cohere
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/rag"
+)
+
+var (
+	_ rag.Reranker = (*Reranker)(nil)
+)
+
+// Config configures a Cohere Reranker.
+type Config struct {
+	// APIKey authenticates the requests. It is required.
+	APIKey string
+	// Model is the rerank model, "rerank-v3.5" by default.
+	Model string
+	// BaseURL is the API endpoint, "https://api.cohere.com" by default.
+	BaseURL string
+	// MaxTokensPerDoc truncates long documents, 4096 tokens by default.
+	MaxTokensPerDoc int
+	// HTTPClient sends the requests. When nil a client on blades.SharedTransport
+	// with a 30 seconds timeout is used.
+	HTTPClient *http.Client
+}
+
+// Reranker implements rag.Reranker with the Cohere Rerank API.
+type Reranker struct {
+	client          *http.Client
+	apiKey          string
+	model           string
+	baseURL         string
+	maxTokensPerDoc int
+}
+
+// NewReranker creates a new Reranker from the config.
+func NewReranker(config Config) (*Reranker, error) {
+	if config.APIKey == "" {
+		return nil, errors.New("cohere: API key is required")
+	}
+	client := config.HTTPClient
+	if client == nil {
+		client = blades.NewHTTPClient(blades.HTTPTimeout(30 * time.Second))
+	}
+	return &Reranker{
+		client:          client,
+		apiKey:          config.APIKey,
+		model:           cmp.Or(config.Model, "rerank-v3.5"),
+		baseURL:         strings.TrimSuffix(cmp.Or(config.BaseURL, "https://api.cohere.com"), "/"),
+		maxTokensPerDoc: config.MaxTokensPerDoc,
+	}, nil
+}
+
+// Rerank scores the chunks against query and returns the topN most relevant,
+// with their relevance scores between 0 and 1.
+func (r *Reranker) Rerank(ctx context.Context, query string, chunks []rag.Chunk, topN int) ([]rag.Chunk, error) {
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+	documents := make([]string, len(chunks))
+	for i, c := range chunks {
+		documents[i] = c.Document.Content
+	}
+	body := map[string]any{
+		"model":     r.model,
+		"query":     query,
+		"documents": documents,
+	}
+	if topN > 0 {
+		body["top_n"] = topN
+	}
+	if r.maxTokensPerDoc > 0 {
+		body["max_tokens_per_doc"] = r.maxTokensPerDoc
+	}
+	var res struct {
+		Results []struct {
+			Index          int     `json:"index"`
+			RelevanceScore float64 `json:"relevance_score"`
+		} `json:"results"`
+	}
+	if err := r.do(ctx, "/v2/rerank", body, &res); err != nil {
+		return nil, err
+	}
+	// results are sorted by decreasing relevance
+	ranked := make([]rag.Chunk, 0, len(res.Results))
+	for _, result := range res.Results {
+		if result.Index < 0 || result.Index >= len(chunks) {
+			return nil, fmt.Errorf("cohere: rerank: result index %d out of range", result.Index)
+		}
+		ranked = append(ranked, rag.Chunk{Document: chunks[result.Index].Document, Score: result.RelevanceScore})
+	}
+	return ranked, nil
+}
+
+func (r *Reranker) do(ctx context.Context, path string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	res, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cohere: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 4<<10))
+		return fmt.Errorf("cohere: %s %s: %s", path, res.Status, bytes.TrimSpace(msg))
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
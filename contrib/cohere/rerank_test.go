@@ -0,0 +1,121 @@
+package cohere
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-kratos/blades/document"
+	"github.com/go-kratos/blades/rag"
+)
+
+// fakeCohere serves the Rerank API with the given response, recording the
+// request headers and bodies.
+type fakeCohere struct {
+	status   int
+	response string
+
+	mu      sync.Mutex
+	headers []http.Header
+	bodies  []string
+}
+
+func (f *fakeCohere) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if r.Method != http.MethodPost || r.URL.Path != "/v2/rerank" {
+		http.NotFound(w, r)
+		return
+	}
+	f.mu.Lock()
+	f.headers = append(f.headers, r.Header.Clone())
+	f.bodies = append(f.bodies, string(body))
+	f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+		io.WriteString(w, `{"message": "invalid api token"}`)
+		return
+	}
+	io.WriteString(w, f.response)
+}
+
+func newTestReranker(t *testing.T, cfg Config, fake *fakeCohere) *Reranker {
+	t.Helper()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	cfg.APIKey, cfg.BaseURL = "secret", srv.URL+"/"
+	r, err := NewReranker(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func chunks(contents ...string) []rag.Chunk {
+	out := make([]rag.Chunk, len(contents))
+	for i, content := range contents {
+		out[i] = rag.Chunk{Document: &document.Document{ID: content, Content: content}, Score: 0.1}
+	}
+	return out
+}
+
+func TestReranker_Rerank(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		topN int
+		body string
+	}{
+		{name: "defaults", body: `{"documents":["a","b","c"],"model":"rerank-v3.5","query":"q"}`},
+		{
+			name: "options",
+			cfg:  Config{Model: "rerank-multilingual-v3.0", MaxTokensPerDoc: 512},
+			topN: 2,
+			body: `{"documents":["a","b","c"],"max_tokens_per_doc":512,"model":"rerank-multilingual-v3.0","query":"q","top_n":2}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeCohere{response: `{"results": [{"index": 2, "relevance_score": 0.9}, {"index": 0, "relevance_score": 0.3}]}`}
+			ranked, err := newTestReranker(t, tt.cfg, fake).Rerank(context.Background(), "q", chunks("a", "b", "c"), tt.topN)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fake.headers[0].Get("Authorization") != "Bearer secret" {
+				t.Fatalf("expected the API key, got %v", fake.headers[0])
+			}
+			var body map[string]any
+			json.Unmarshal([]byte(fake.bodies[0]), &body)
+			if got, _ := json.Marshal(body); string(got) != tt.body {
+				t.Fatalf("expected %s, got %s", tt.body, got)
+			}
+			if len(ranked) != 2 || ranked[0].Document.ID != "c" || ranked[0].Score != 0.9 || ranked[1].Document.ID != "a" || ranked[1].Score != 0.3 {
+				t.Fatalf("expected the chunks in the order of the results, got %+v", ranked)
+			}
+		})
+	}
+}
+
+func TestReranker_Errors(t *testing.T) {
+	if _, err := NewReranker(Config{}); err == nil {
+		t.Fatal("expected the API key to be required")
+	}
+	ctx := context.Background()
+	fake := &fakeCohere{}
+	if ranked, err := newTestReranker(t, Config{}, fake).Rerank(ctx, "q", nil, 3); err != nil || ranked != nil || len(fake.bodies) != 0 {
+		t.Fatalf("expected no request without chunks, got %v, %v", ranked, err)
+	}
+	r := newTestReranker(t, Config{}, &fakeCohere{status: http.StatusUnauthorized})
+	if _, err := r.Rerank(ctx, "q", chunks("a"), 1); err == nil || !strings.HasPrefix(err.Error(), "cohere: /v2/rerank 401 Unauthorized:") || !strings.Contains(err.Error(), "invalid api token") {
+		t.Fatalf("expected the status and message to be reported, got %v", err)
+	}
+	r = newTestReranker(t, Config{}, &fakeCohere{response: `{"results": [{"index": 3, "relevance_score": 0.9}]}`})
+	if _, err := r.Rerank(ctx, "q", chunks("a"), 1); err == nil || err.Error() != "cohere: rerank: result index 3 out of range" {
+		t.Fatalf("expected an out of range error, got %v", err)
+	}
+}
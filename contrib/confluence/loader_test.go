@@ -0,0 +1,132 @@
+package confluence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeConfluence serves two pages of content search results, recording the
+// CQL queries.
+type fakeConfluence struct {
+	auth func(r *http.Request) bool
+
+	mu      sync.Mutex
+	queries []string
+}
+
+func (f *fakeConfluence) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/wiki/rest/api/content/search" {
+		http.NotFound(w, r)
+		return
+	}
+	if !f.auth(r) {
+		http.Error(w, `{"message": "unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	q := r.URL.Query()
+	f.mu.Lock()
+	f.queries = append(f.queries, q.Get("cql"))
+	f.mu.Unlock()
+	if q.Get("expand") != "body.storage,version,space" || q.Get("limit") != "50" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	page := func(id, when string) string {
+		return fmt.Sprintf(`{"id": %q, "title": "Page %s", "space": {"key": "ENG"}, "version": {"number": 2, "when": %q}, "body": {"storage": {"value": "<h1>Page %s</h1><p>Hello <b>world</b></p>"}}, "_links": {"webui": "/spaces/ENG/pages/%s"}}`, id, id, when, id, id)
+	}
+	switch q.Get("start") {
+	case "0":
+		fmt.Fprintf(w, `{"results": [%s], "_links": {"next": "/rest/api/content/search?start=1"}}`, page("1", "2025-01-01T10:00:00Z"))
+	default:
+		fmt.Fprintf(w, `{"results": [%s], "_links": {}}`, page("2", "2025-01-02T10:00:00Z"))
+	}
+}
+
+func newTestLoader(t *testing.T, cfg Config, auth func(r *http.Request) bool) (*Loader, *fakeConfluence) {
+	t.Helper()
+	fake := &fakeConfluence{auth: auth}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	cfg.BaseURL = srv.URL + "/wiki/"
+	loader, err := NewLoader(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loader, fake
+}
+
+func TestLoader_Sync(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    Config
+		auth   func(r *http.Request) bool
+		cursor string
+		cql    string
+	}{
+		{
+			name: "cloud",
+			cfg:  Config{Email: "me@example.com", Token: "secret"},
+			auth: func(r *http.Request) bool {
+				user, pass, ok := r.BasicAuth()
+				return ok && user == "me@example.com" && pass == "secret"
+			},
+			cql: "type = page order by lastmodified asc",
+		},
+		{
+			name: "data center",
+			cfg:  Config{Token: "secret", SpaceKeys: []string{"ENG", "OPS"}},
+			auth: func(r *http.Request) bool {
+				return r.Header.Get("Authorization") == "Bearer secret"
+			},
+			cursor: "2025-01-01T09:30:45Z",
+			cql:    `type = page and space in ("ENG","OPS") and lastmodified >= "2025/01/01 09:30" order by lastmodified asc`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader, fake := newTestLoader(t, tt.cfg, tt.auth)
+			docs, next, err := loader.Sync(context.Background(), tt.cursor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(fake.queries) != 2 || fake.queries[0] != tt.cql {
+				t.Fatalf("expected 2 searches with %q, got %q", tt.cql, fake.queries)
+			}
+			if len(docs) != 2 || docs[0].ID != "1" || docs[1].ID != "2" {
+				t.Fatalf("expected the pages of both result pages, got %d documents", len(docs))
+			}
+			if next != "2025-01-02T10:00:00Z" {
+				t.Fatalf("expected the last modification time as the cursor, got %q", next)
+			}
+			doc := docs[0]
+			if !strings.Contains(doc.Content, "Page 1") || !strings.Contains(doc.Content, "Hello world") || strings.Contains(doc.Content, "<") {
+				t.Fatalf("expected the page text without markup, got %q", doc.Content)
+			}
+			if doc.Metadata["source"] != loader.cfg.BaseURL+"/spaces/ENG/pages/1" || doc.Metadata["space"] != "ENG" || doc.Metadata["version"] != 2 {
+				t.Fatalf("unexpected metadata %v", doc.Metadata)
+			}
+		})
+	}
+}
+
+func TestLoader_Errors(t *testing.T) {
+	if _, err := NewLoader(Config{Token: "secret"}); !errors.Is(err, ErrBaseURLRequired) {
+		t.Fatalf("expected ErrBaseURLRequired, got %v", err)
+	}
+	if _, err := NewLoader(Config{BaseURL: "https://example.atlassian.net/wiki"}); !errors.Is(err, ErrTokenRequired) {
+		t.Fatalf("expected ErrTokenRequired, got %v", err)
+	}
+	loader, _ := newTestLoader(t, Config{Token: "wrong"}, func(r *http.Request) bool { return false })
+	if _, err := loader.Load(context.Background()); err == nil || !strings.Contains(err.Error(), "confluence: GET /rest/api/content/search: status 401") {
+		t.Fatalf("expected the status to be reported, got %v", err)
+	}
+	if _, _, err := loader.Sync(context.Background(), "yesterday"); err == nil || !strings.Contains(err.Error(), `confluence: invalid cursor "yesterday"`) {
+		t.Fatalf("expected an invalid cursor error, got %v", err)
+	}
+}
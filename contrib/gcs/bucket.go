@@ -0,0 +1,59 @@
+package gcs
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"cloud.google.com/go/storage"
+	"github.com/go-kratos/blades/document"
+	"google.golang.org/api/iterator"
+)
+
+var (
+	_ document.Bucket = (*Bucket)(nil)
+)
+
+// Bucket adapts a Google Cloud Storage bucket to document.Bucket.
+type Bucket struct {
+	handle *storage.BucketHandle
+}
+
+// NewBucket creates a new Bucket for the named GCS bucket.
+func NewBucket(client *storage.Client, name string) *Bucket {
+	return &Bucket{handle: client.Bucket(name)}
+}
+
+// List lists objects under prefix after startAfter.
+func (b *Bucket) List(ctx context.Context, prefix, startAfter string, limit int) ([]document.Object, error) {
+	query := &storage.Query{Prefix: prefix, StartOffset: startAfter}
+	if err := query.SetAttrSelection([]string{"Name", "Size", "ContentType", "Updated"}); err != nil {
+		return nil, err
+	}
+	it := b.handle.Objects(ctx, query)
+	var objects []document.Object
+	for limit <= 0 || len(objects) < limit {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if attrs.Name == startAfter { // StartOffset is inclusive
+			continue
+		}
+		objects = append(objects, document.Object{
+			Key:         attrs.Name,
+			Size:        attrs.Size,
+			ContentType: attrs.ContentType,
+			ModTime:     attrs.Updated,
+		})
+	}
+	return objects, nil
+}
+
+// Open streams the object content.
+func (b *Bucket) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return b.handle.Object(key).NewReader(ctx)
+}
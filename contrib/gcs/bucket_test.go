@@ -0,0 +1,110 @@
+package gcs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/option"
+)
+
+// fakeGCS serves the object listing of the JSON API and the object media of
+// the bucket "docs", recording the list queries.
+type fakeGCS struct {
+	mu      sync.Mutex
+	queries []url.Values
+}
+
+func (f *fakeGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/storage/v1/b/docs/o":
+		f.mu.Lock()
+		f.queries = append(f.queries, r.URL.Query())
+		f.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"items": [
+			{"name": "guides/a.md", "size": "5", "contentType": "text/markdown", "updated": "2025-01-01T10:00:00Z"},
+			{"name": "guides/b.md", "size": "7", "contentType": "text/markdown", "updated": "2025-01-02T10:00:00Z"},
+			{"name": "guides/c.md", "size": "9", "contentType": "text/markdown", "updated": "2025-01-03T10:00:00Z"}
+		]}`)
+	case r.URL.Path == "/docs/guides/a.md":
+		w.Header().Set("Content-Type", "text/markdown")
+		io.WriteString(w, "hello")
+	default:
+		http.Error(w, `{"error": {"code": 404, "message": "No such object"}}`, http.StatusNotFound)
+	}
+}
+
+func newTestBucket(t *testing.T) (*Bucket, *fakeGCS) {
+	t.Helper()
+	fake := &fakeGCS{}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	t.Setenv("STORAGE_EMULATOR_HOST", strings.TrimPrefix(srv.URL, "http://"))
+	client, err := storage.NewClient(context.Background(), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return NewBucket(client, "docs"), fake
+}
+
+func TestBucket_List(t *testing.T) {
+	tests := []struct {
+		name       string
+		startAfter string
+		limit      int
+		keys       []string
+	}{
+		{name: "every object", keys: []string{"guides/a.md", "guides/b.md", "guides/c.md"}},
+		{name: "limited", limit: 2, keys: []string{"guides/a.md", "guides/b.md"}},
+		{name: "after a key", startAfter: "guides/a.md", keys: []string{"guides/b.md", "guides/c.md"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket, fake := newTestBucket(t)
+			objects, err := bucket.List(context.Background(), "guides/", tt.startAfter, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			query := fake.queries[0]
+			if query.Get("prefix") != "guides/" || query.Get("startOffset") != tt.startAfter {
+				t.Fatalf("expected the prefix and start offset in the query, got %v", query)
+			}
+			var keys []string
+			for _, obj := range objects {
+				keys = append(keys, obj.Key)
+			}
+			if strings.Join(keys, ",") != strings.Join(tt.keys, ",") {
+				t.Fatalf("expected %v, got %v", tt.keys, keys)
+			}
+			first := objects[0]
+			if first.Size == 0 || first.ContentType != "text/markdown" || first.ModTime.Before(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Fatalf("unexpected object %+v", first)
+			}
+		})
+	}
+}
+
+func TestBucket_Open(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	r, err := bucket.Open(context.Background(), "guides/a.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if b, _ := io.ReadAll(r); string(b) != "hello" {
+		t.Fatalf("expected the object content, got %q", b)
+	}
+	if _, err := bucket.Open(context.Background(), "guides/missing.md"); !errors.Is(err, storage.ErrObjectNotExist) {
+		t.Fatalf("expected storage.ErrObjectNotExist, got %v", err)
+	}
+}
@@ -1,21 +1,26 @@
 package gemini
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/go-kratos/blades"
-	"github.com/google/generative-ai-go/genai"
-	"google.golang.org/api/option"
+	"github.com/google/jsonschema-go/jsonschema"
+	"google.golang.org/genai"
 )
 
 var (
 	// ErrEmptyResponse indicates the provider returned no candidates.
 	ErrEmptyResponse = errors.New("empty completion response")
-	// ErrToolNotFound indicates a tool call was made to an unknown tool.
-	ErrToolNotFound = errors.New("tool not found")
 )
 
 // ChatProvider implements blades.ModelProvider for Gemini models.
@@ -23,72 +28,97 @@ type ChatProvider struct {
 	client *genai.Client
 }
 
+// Config configures a Gemini provider.
+type Config struct {
+	// APIKey authenticates the requests. It is required.
+	APIKey string
+	// BaseURL overrides the API endpoint.
+	BaseURL string
+	// HTTPClient sends the requests; the API key is added to each of them.
+	// When nil a client on blades.SharedTransport is used.
+	HTTPClient *http.Client
+	// Timeout bounds each request when set.
+	Timeout time.Duration
+	// Hooks set custom headers on the requests and inspect their raw bodies.
+	Hooks *blades.HTTPHooks
+}
+
 // NewChatProvider constructs a Gemini provider. The API key is read from
-// the API_KEY environment variable.
+// the API_KEY environment variable. It exits if the provider cannot be
+// created; use NewChatProviderWithConfig to handle configuration errors.
 func NewChatProvider() blades.ModelProvider {
-	apiKey := os.Getenv("API_KEY")
-	if apiKey == "" {
-		log.Fatal("API_KEY environment variable is required for Gemini provider")
-	}
-
-	ctx := context.Background()
-	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+	provider, err := NewChatProviderWithConfig(Config{APIKey: os.Getenv("API_KEY")})
 	if err != nil {
 		log.Fatalf("Failed to create Gemini client: %v", err)
 	}
+	return provider
+}
 
-	return &ChatProvider{client: client}
+// NewChatProviderWithConfig constructs a Gemini provider from the config.
+func NewChatProviderWithConfig(config Config) (blades.ModelProvider, error) {
+	if config.APIKey == "" {
+		return nil, errors.New("gemini: API key is required")
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = blades.NewHTTPClient()
+	}
+	if config.Hooks != nil {
+		config.HTTPClient = config.Hooks.Client(config.HTTPClient)
+	}
+	clientConfig := &genai.ClientConfig{
+		APIKey:      config.APIKey,
+		Backend:     genai.BackendGeminiAPI,
+		HTTPClient:  config.HTTPClient,
+		HTTPOptions: genai.HTTPOptions{BaseURL: config.BaseURL},
+	}
+	if config.Timeout > 0 {
+		clientConfig.HTTPOptions.Timeout = &config.Timeout
+	}
+	client, err := genai.NewClient(context.Background(), clientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("gemini: %w", err)
+	}
+	return &ChatProvider{client: client}, nil
 }
 
 // Generate executes a non-streaming chat completion request.
 func (p *ChatProvider) Generate(ctx context.Context, req *blades.ModelRequest, opts ...blades.ModelOption) (*blades.ModelResponse, error) {
-	model := p.client.GenerativeModel(req.Model)
-
-	// Convert messages to Gemini format
-	var parts []genai.Part
-	for _, msg := range req.Messages {
-		for _, part := range msg.Parts {
-			switch v := part.(type) {
-			case blades.TextPart:
-				parts = append(parts, genai.Text(v.Text))
-			case blades.FilePart:
-				// Handle file parts - for now, just add as text
-				parts = append(parts, genai.Text("File: "+v.URI))
-			case blades.DataPart:
-				// Handle data parts - for now, just add as text
-				parts = append(parts, genai.Text("Data: "+string(v.Bytes)))
-			}
-		}
+	config, err := toConfig(req.Tools, opts)
+	if err != nil {
+		return nil, err
 	}
-
-	// Generate content
-	resp, err := model.GenerateContent(ctx, parts...)
+	system, contents, err := toContents(req.Messages)
 	if err != nil {
 		return nil, err
 	}
+	config.SystemInstruction = system
+
+	resp, err := p.client.Models.GenerateContent(ctx, req.Model, contents, config)
+	if err != nil {
+		return nil, toProviderError(err)
+	}
 
 	if len(resp.Candidates) == 0 {
 		return nil, ErrEmptyResponse
 	}
 
-	// Convert response to Blades format
-	var responseText string
-	for _, part := range resp.Candidates[0].Content.Parts {
-		if text, ok := part.(genai.Text); ok {
-			responseText += string(text)
-		}
+	parts := candidateParts(resp)
+	calls, err := toToolCalls(parts)
+	if err != nil {
+		return nil, err
 	}
-
 	response := &blades.ModelResponse{
 		Messages: []*blades.Message{
 			{
-				Role:   blades.RoleAssistant,
-				Status: blades.StatusCompleted,
-				Parts: []blades.Part{
-					blades.TextPart{Text: responseText},
-				},
+				Role:      blades.RoleAssistant,
+				Status:    blades.StatusCompleted,
+				Parts:     fromParts(parts),
+				ToolCalls: calls,
+				Metadata:  finishMetadata(resp.Candidates[0].FinishReason),
 			},
 		},
+		Usage:     toUsage(resp.UsageMetadata),
+		Grounding: toGrounding(resp.Candidates[0].GroundingMetadata),
 	}
 
 	return response, nil
@@ -96,58 +126,454 @@ func (p *ChatProvider) Generate(ctx context.Context, req *blades.ModelRequest, o
 
 // NewStream executes a streaming chat completion request.
 func (p *ChatProvider) NewStream(ctx context.Context, req *blades.ModelRequest, opts ...blades.ModelOption) (blades.Streamer[*blades.ModelResponse], error) {
-	model := p.client.GenerativeModel(req.Model)
-
-	// Convert messages to Gemini format
-	var parts []genai.Part
-	for _, msg := range req.Messages {
-		for _, part := range msg.Parts {
-			switch v := part.(type) {
-			case blades.TextPart:
-				parts = append(parts, genai.Text(v.Text))
-			case blades.FilePart:
-				parts = append(parts, genai.Text("File: "+v.URI))
-			case blades.DataPart:
-				parts = append(parts, genai.Text("Data: "+string(v.Bytes)))
-			}
-		}
+	config, err := toConfig(req.Tools, opts)
+	if err != nil {
+		return nil, err
 	}
-
-	// Generate content with streaming
-	iter := model.GenerateContentStream(ctx, parts...)
+	system, contents, err := toContents(req.Messages)
+	if err != nil {
+		return nil, err
+	}
+	config.SystemInstruction = system
 
 	pipe := blades.NewStreamPipe[*blades.ModelResponse]()
 	pipe.Go(func() error {
-		var fullText string
-		for {
-			resp, err := iter.Next()
+		var (
+			fullText  string
+			media     []blades.Part
+			calls     []*blades.ToolCall
+			reason    genai.FinishReason
+			usage     *blades.Usage
+			grounding *blades.Grounding
+		)
+		for resp, err := range p.client.Models.GenerateContentStream(ctx, req.Model, contents, config) {
+			if err != nil {
+				return toProviderError(err)
+			}
+			// Usage metadata is cumulative, the last chunk has the totals.
+			if resp.UsageMetadata != nil {
+				usage = toUsage(resp.UsageMetadata)
+			}
+			if len(resp.Candidates) > 0 && resp.Candidates[0].GroundingMetadata != nil {
+				grounding = toGrounding(resp.Candidates[0].GroundingMetadata)
+			}
+			if len(resp.Candidates) > 0 && resp.Candidates[0].FinishReason != "" {
+				reason = resp.Candidates[0].FinishReason
+			}
+			// Function calls are sent whole, on the completed message.
+			chunkCalls, err := toToolCalls(candidateParts(resp))
 			if err != nil {
 				return err
 			}
+			calls = append(calls, chunkCalls...)
 
-			if len(resp.Candidates) > 0 {
-				for _, part := range resp.Candidates[0].Content.Parts {
-					if text, ok := part.(genai.Text); ok {
-						fullText += string(text)
-
-						// Send incremental response
-						response := &blades.ModelResponse{
-							Messages: []*blades.Message{
-								{
-									Role:   blades.RoleAssistant,
-									Status: blades.StatusIncomplete,
-									Parts: []blades.Part{
-										blades.TextPart{Text: string(text)},
-									},
-								},
-							},
-						}
-						pipe.Send(response)
-					}
+			parts := fromParts(candidateParts(resp))
+			if len(parts) == 0 {
+				continue
+			}
+			for _, part := range parts {
+				if text, ok := part.(blades.TextPart); ok {
+					fullText += text.Text
+				} else {
+					media = append(media, part)
 				}
 			}
+			// Send incremental response
+			pipe.Send(&blades.ModelResponse{
+				Messages: []*blades.Message{
+					{
+						Role:   blades.RoleAssistant,
+						Status: blades.StatusIncomplete,
+						Parts:  parts,
+					},
+				},
+			})
 		}
+		pipe.Send(&blades.ModelResponse{
+			Messages: []*blades.Message{
+				{
+					Role:      blades.RoleAssistant,
+					Status:    blades.StatusCompleted,
+					Parts:     append([]blades.Part{blades.TextPart{Text: fullText}}, media...),
+					ToolCalls: calls,
+					Metadata:  finishMetadata(reason),
+				},
+			},
+			Usage:     usage,
+			Grounding: grounding,
+		})
+		return nil
 	})
 
 	return pipe, nil
 }
+
+// toProviderError converts API errors to blades.ProviderError, so that
+// transient failures can be retried.
+func toProviderError(err error) error {
+	var apiErr genai.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	return &blades.ProviderError{
+		Provider:   "gemini",
+		StatusCode: apiErr.Code,
+		RetryAfter: retryDelay(apiErr.Details),
+		Err:        err,
+	}
+}
+
+// retryDelay returns the delay of the RetryInfo detail of an API error, which
+// Gemini sends instead of a Retry-After header when rate limiting.
+func retryDelay(details []map[string]any) time.Duration {
+	for _, detail := range details {
+		if detail["@type"] != "type.googleapis.com/google.rpc.RetryInfo" {
+			continue
+		}
+		delay, _ := detail["retryDelay"].(string)
+		if d, err := time.ParseDuration(delay); err == nil && d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// candidateParts returns the parts of the first candidate of the response.
+func candidateParts(resp *genai.GenerateContentResponse) []*genai.Part {
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return nil
+	}
+	return resp.Candidates[0].Content.Parts
+}
+
+// fromParts converts Gemini response parts to message parts. Text is joined into
+// a single text part, and generated images and other inline data are kept as
+// data parts instead of being dropped.
+func fromParts(parts []*genai.Part) []blades.Part {
+	var (
+		text  strings.Builder
+		media []blades.Part
+	)
+	for _, part := range parts {
+		switch {
+		case part.Thought:
+		case part.Text != "":
+			text.WriteString(part.Text)
+		case part.InlineData != nil:
+			media = append(media, blades.DataPart{Bytes: part.InlineData.Data, MimeType: blades.MimeType(part.InlineData.MIMEType)})
+		case part.FileData != nil:
+			media = append(media, blades.FilePart{URI: part.FileData.FileURI, MimeType: blades.MimeType(part.FileData.MIMEType)})
+		}
+	}
+	if text.Len() == 0 {
+		return media
+	}
+	return append([]blades.Part{blades.TextPart{Text: text.String()}}, media...)
+}
+
+// toToolCalls converts the function calls of Gemini response parts to tool
+// calls, generating the IDs Gemini leaves empty.
+func toToolCalls(parts []*genai.Part) ([]*blades.ToolCall, error) {
+	var calls []*blades.ToolCall
+	for _, part := range parts {
+		if part.FunctionCall == nil {
+			continue
+		}
+		args, err := json.Marshal(part.FunctionCall.Args)
+		if err != nil {
+			return nil, fmt.Errorf("gemini: function call %s: %w", part.FunctionCall.Name, err)
+		}
+		calls = append(calls, &blades.ToolCall{
+			ID:        cmp.Or(part.FunctionCall.ID, blades.NewID()),
+			Name:      part.FunctionCall.Name,
+			Arguments: string(args),
+		})
+	}
+	return calls, nil
+}
+
+// finishMetadata records the finish reason of a candidate using the names of
+// the other providers, e.g. "length" when the output token limit is reached.
+func finishMetadata(reason genai.FinishReason) map[string]string {
+	switch reason {
+	case "", genai.FinishReasonUnspecified:
+		return nil
+	case genai.FinishReasonStop:
+		return map[string]string{"finish_reason": "stop"}
+	case genai.FinishReasonMaxTokens:
+		return map[string]string{"finish_reason": "length"}
+	case genai.FinishReasonSafety, genai.FinishReasonRecitation, genai.FinishReasonBlocklist,
+		genai.FinishReasonProhibitedContent, genai.FinishReasonSPII, genai.FinishReasonImageSafety,
+		genai.FinishReasonImageProhibitedContent, genai.FinishReasonImageRecitation:
+		return map[string]string{"finish_reason": "content_filter"}
+	}
+	return map[string]string{"finish_reason": strings.ToLower(string(reason))}
+}
+
+// toUsage converts the Gemini usage metadata.
+func toUsage(metadata *genai.GenerateContentResponseUsageMetadata) *blades.Usage {
+	if metadata == nil {
+		return nil
+	}
+	return &blades.Usage{
+		PromptTokens:     int64(metadata.PromptTokenCount),
+		CompletionTokens: int64(metadata.CandidatesTokenCount),
+		TotalTokens:      int64(metadata.TotalTokenCount),
+	}
+}
+
+// toGrounding converts the Google Search grounding metadata.
+func toGrounding(metadata *genai.GroundingMetadata) *blades.Grounding {
+	if metadata == nil {
+		return nil
+	}
+	grounding := &blades.Grounding{Queries: metadata.WebSearchQueries}
+	for _, chunk := range metadata.GroundingChunks {
+		var source blades.GroundingSource
+		if chunk.Web != nil {
+			source = blades.GroundingSource{Title: chunk.Web.Title, URI: chunk.Web.URI}
+		}
+		grounding.Sources = append(grounding.Sources, source)
+	}
+	for _, support := range metadata.GroundingSupports {
+		if support.Segment == nil {
+			continue
+		}
+		s := blades.GroundingSupport{
+			Text:  support.Segment.Text,
+			Start: int(support.Segment.StartIndex),
+			End:   int(support.Segment.EndIndex),
+		}
+		for _, i := range support.GroundingChunkIndices {
+			s.Sources = append(s.Sources, int(i))
+		}
+		for _, score := range support.ConfidenceScores {
+			s.Scores = append(s.Scores, float64(score))
+		}
+		grounding.Supports = append(grounding.Supports, s)
+	}
+	return grounding
+}
+
+// toContents converts messages to a Gemini system instruction, gathering the
+// system and developer messages, and a history of user and model turns.
+// Consecutive messages with the same role are merged, as Gemini expects the
+// roles to alternate.
+func toContents(messages []*blades.Message) (*genai.Content, []*genai.Content, error) {
+	var (
+		system   *genai.Content
+		contents []*genai.Content
+	)
+	for _, msg := range messages {
+		parts, err := toParts(msg)
+		if err != nil {
+			return nil, nil, err
+		}
+		if msg.Role.IsInstruction() {
+			if system == nil {
+				system = &genai.Content{}
+			}
+			system.Parts = append(system.Parts, parts...)
+			continue
+		}
+		role := genai.RoleUser
+		if msg.Role == blades.RoleAssistant {
+			role = genai.RoleModel
+		}
+		if len(parts) == 0 {
+			continue
+		}
+		if n := len(contents); n > 0 && contents[n-1].Role == role {
+			contents[n-1].Parts = append(contents[n-1].Parts, parts...)
+			continue
+		}
+		contents = append(contents, &genai.Content{Role: role, Parts: parts})
+	}
+	return system, contents, nil
+}
+
+// toParts converts message parts to Gemini parts. Inline data becomes a Blob
+// and file URIs (e.g. gs:// or Files API URIs) become FileData, so that images,
+// audio and PDFs reach the model. Tool calls become function calls, and their
+// results function responses.
+func toParts(msg *blades.Message) ([]*genai.Part, error) {
+	var parts []*genai.Part
+	for _, part := range msg.Parts {
+		switch v := part.(type) {
+		case blades.TextPart:
+			parts = append(parts, &genai.Part{Text: v.Text})
+		case blades.FilePart:
+			parts = append(parts, &genai.Part{FileData: &genai.FileData{
+				MIMEType: string(cmp.Or(v.MimeType, blades.DetectMimeType(v.URI, nil))),
+				FileURI:  v.URI,
+			}})
+		case blades.DataPart:
+			parts = append(parts, &genai.Part{InlineData: &genai.Blob{
+				MIMEType: string(cmp.Or(v.MimeType, blades.DetectMimeType(v.Name, v.Bytes))),
+				Data:     v.Bytes,
+			}})
+		}
+	}
+	for _, call := range msg.ToolCalls {
+		if msg.Role == blades.RoleTool {
+			parts = append(parts, &genai.Part{FunctionResponse: &genai.FunctionResponse{
+				ID:       call.ID,
+				Name:     call.Name,
+				Response: map[string]any{"output": call.Result},
+			}})
+			continue
+		}
+		var args map[string]any
+		if strings.TrimSpace(call.Arguments) != "" {
+			if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
+				return nil, fmt.Errorf("gemini: arguments of tool call %s: %w", call.Name, err)
+			}
+		}
+		parts = append(parts, &genai.Part{FunctionCall: &genai.FunctionCall{ID: call.ID, Name: call.Name, Args: args}})
+	}
+	return parts, nil
+}
+
+// toConfig converts the tools, generation config, safety settings, response
+// format and grounding tools of the request.
+func toConfig(tools []*blades.Tool, opts []blades.ModelOption) (*genai.GenerateContentConfig, error) {
+	var opt blades.ModelOptions
+	for _, apply := range opts {
+		apply(&opt)
+	}
+	config := &genai.GenerateContentConfig{}
+	if len(tools) > 0 {
+		declarations := make([]*genai.FunctionDeclaration, 0, len(tools))
+		for _, tool := range tools {
+			declaration := &genai.FunctionDeclaration{Name: tool.Name, Description: tool.Description}
+			if tool.InputSchema != nil {
+				declaration.ParametersJsonSchema = tool.InputSchema
+			}
+			declarations = append(declarations, declaration)
+		}
+		config.Tools = append(config.Tools, &genai.Tool{FunctionDeclarations: declarations})
+	}
+	if opt.Temperature != nil {
+		config.Temperature = genai.Ptr(float32(*opt.Temperature))
+	}
+	if opt.TopP > 0 {
+		config.TopP = genai.Ptr(float32(opt.TopP))
+	}
+	if opt.TopK > 0 {
+		config.TopK = genai.Ptr(float32(opt.TopK))
+	}
+	if opt.MaxOutputTokens > 0 {
+		config.MaxOutputTokens = int32(opt.MaxOutputTokens)
+	}
+	if len(opt.StopSequences) > 0 {
+		config.StopSequences = opt.StopSequences
+	}
+	for _, setting := range opt.SafetySettings {
+		s, err := toSafetySetting(setting)
+		if err != nil {
+			return nil, err
+		}
+		config.SafetySettings = append(config.SafetySettings, s)
+	}
+	switch format := opt.ResponseFormat; format.Type {
+	case blades.ResponseFormatJSONObject:
+		config.ResponseMIMEType = "application/json"
+	case blades.ResponseFormatJSONSchema:
+		schema, err := toSchema(format.Schema)
+		if err != nil {
+			return nil, err
+		}
+		config.ResponseMIMEType = "application/json"
+		config.ResponseSchema = schema
+	}
+	if opt.Grounding.WebSearch {
+		config.Tools = append(config.Tools, &genai.Tool{GoogleSearch: &genai.GoogleSearch{}})
+	}
+	if opt.Grounding.URLContext {
+		config.Tools = append(config.Tools, &genai.Tool{URLContext: &genai.URLContext{}})
+	}
+	return config, nil
+}
+
+var (
+	harmCategories = []genai.HarmCategory{
+		genai.HarmCategoryHarassment,
+		genai.HarmCategoryHateSpeech,
+		genai.HarmCategorySexuallyExplicit,
+		genai.HarmCategoryDangerousContent,
+		genai.HarmCategoryCivicIntegrity,
+	}
+	harmBlockThresholds = []genai.HarmBlockThreshold{
+		genai.HarmBlockThresholdBlockLowAndAbove,
+		genai.HarmBlockThresholdBlockMediumAndAbove,
+		genai.HarmBlockThresholdBlockOnlyHigh,
+		genai.HarmBlockThresholdBlockNone,
+		genai.HarmBlockThresholdOff,
+	}
+)
+
+// toSafetySetting converts a safety setting using the Gemini enum names; the
+// HARM_CATEGORY_ and BLOCK_ prefixes are optional.
+func toSafetySetting(s blades.SafetySetting) (*genai.SafetySetting, error) {
+	category := genai.HarmCategory("HARM_CATEGORY_" + strings.TrimPrefix(strings.ToUpper(s.Category), "HARM_CATEGORY_"))
+	if !slices.Contains(harmCategories, category) {
+		return nil, fmt.Errorf("gemini: unknown harm category %q", s.Category)
+	}
+	threshold := genai.HarmBlockThreshold(strings.ToUpper(s.Threshold))
+	if !slices.Contains(harmBlockThresholds, threshold) {
+		threshold = "BLOCK_" + threshold
+	}
+	if !slices.Contains(harmBlockThresholds, threshold) {
+		return nil, fmt.Errorf("gemini: unknown harm block threshold %q", s.Threshold)
+	}
+	return &genai.SafetySetting{Category: category, Threshold: threshold}, nil
+}
+
+// toSchema converts a JSON schema to the OpenAPI subset supported by Gemini.
+func toSchema(s *jsonschema.Schema) (*genai.Schema, error) {
+	if s == nil {
+		return nil, nil
+	}
+	out := &genai.Schema{Description: s.Description, Format: s.Format, Required: s.Required}
+	typ := s.Type
+	for _, t := range s.Types {
+		if t == "null" {
+			out.Nullable = genai.Ptr(true)
+		} else {
+			typ = t
+		}
+	}
+	switch typ {
+	case "string":
+		out.Type = genai.TypeString
+	case "number":
+		out.Type = genai.TypeNumber
+	case "integer":
+		out.Type = genai.TypeInteger
+	case "boolean":
+		out.Type = genai.TypeBoolean
+	case "array":
+		out.Type = genai.TypeArray
+		items, err := toSchema(s.Items)
+		if err != nil {
+			return nil, err
+		}
+		out.Items = items
+	case "object":
+		out.Type = genai.TypeObject
+		out.Properties = make(map[string]*genai.Schema, len(s.Properties))
+		for name, prop := range s.Properties {
+			schema, err := toSchema(prop)
+			if err != nil {
+				return nil, err
+			}
+			out.Properties[name] = schema
+		}
+	default:
+		return nil, &blades.UnsupportedError{Provider: "gemini", Option: fmt.Sprintf("response schema type %q", typ)}
+	}
+	for _, v := range s.Enum {
+		out.Enum = append(out.Enum, fmt.Sprint(v))
+	}
+	return out, nil
+}
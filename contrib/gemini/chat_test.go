@@ -0,0 +1,171 @@
+package gemini
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/blades"
+	"github.com/google/jsonschema-go/jsonschema"
+	"google.golang.org/genai"
+)
+
+func TestToProviderError(t *testing.T) {
+	retryInfo := map[string]any{"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "30s"}
+	tests := []struct {
+		name       string
+		err        error
+		status     int
+		retryAfter time.Duration
+	}{
+		{
+			name:       "rate limited with a retry delay",
+			err:        genai.APIError{Code: http.StatusTooManyRequests, Details: []map[string]any{{"@type": "type.googleapis.com/google.rpc.QuotaFailure"}, retryInfo}},
+			status:     http.StatusTooManyRequests,
+			retryAfter: 30 * time.Second,
+		},
+		{
+			name:   "unavailable without details",
+			err:    genai.APIError{Code: http.StatusServiceUnavailable},
+			status: http.StatusServiceUnavailable,
+		},
+		{
+			name: "not an API error",
+			err:  errors.New("connection reset"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := toProviderError(tt.err)
+			var perr *blades.ProviderError
+			if !errors.As(err, &perr) {
+				if tt.status != 0 {
+					t.Fatalf("expected a provider error, got %v", err)
+				}
+				return
+			}
+			if perr.StatusCode != tt.status || perr.RetryAfter != tt.retryAfter {
+				t.Fatalf("got status %d retry after %s", perr.StatusCode, perr.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestToSchema(t *testing.T) {
+	tests := []struct {
+		name        string
+		schema      *jsonschema.Schema
+		typ         genai.Type
+		unsupported bool
+	}{
+		{
+			name: "object",
+			schema: &jsonschema.Schema{Type: "object", Required: []string{"name"}, Properties: map[string]*jsonschema.Schema{
+				"name": {Type: "string", Enum: []any{"Paris", "Rome"}},
+				"tags": {Type: "array", Items: &jsonschema.Schema{Types: []string{"null", "string"}}},
+			}},
+			typ: genai.TypeObject,
+		},
+		{
+			name:        "untyped",
+			schema:      &jsonschema.Schema{},
+			unsupported: true,
+		},
+		{
+			name:        "untyped property",
+			schema:      &jsonschema.Schema{Type: "object", Properties: map[string]*jsonschema.Schema{"any": {}}},
+			unsupported: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := toSchema(tt.schema)
+			if tt.unsupported {
+				var unsupported *blades.UnsupportedError
+				if !errors.As(err, &unsupported) || !errors.Is(err, blades.ErrUnsupported) {
+					t.Fatalf("expected an UnsupportedError, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if schema.Type != tt.typ {
+				t.Fatalf("got type %s, want %s", schema.Type, tt.typ)
+			}
+			if name := schema.Properties["name"]; name.Type != genai.TypeString || len(name.Enum) != 2 {
+				t.Fatalf("unexpected name schema %+v", name)
+			}
+			if items := schema.Properties["tags"].Items; items.Type != genai.TypeString || items.Nullable == nil || !*items.Nullable {
+				t.Fatalf("unexpected tags items schema %+v", items)
+			}
+		})
+	}
+}
+
+func TestToConfig(t *testing.T) {
+	tool := &blades.Tool{Name: "weather", Description: "Get the weather", InputSchema: &jsonschema.Schema{Type: "object"}}
+	config, err := toConfig([]*blades.Tool{tool}, []blades.ModelOption{blades.Temperature(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Tools) != 1 || len(config.Tools[0].FunctionDeclarations) != 1 || config.Tools[0].FunctionDeclarations[0].Name != "weather" {
+		t.Fatalf("expected the tool to be declared, got %+v", config.Tools)
+	}
+	if config.Temperature == nil || *config.Temperature != 0 {
+		t.Fatalf("expected a temperature of 0 to be sent, got %v", config.Temperature)
+	}
+	if config, _ := toConfig(nil, nil); config.Temperature != nil || config.Tools != nil {
+		t.Fatalf("expected no temperature nor tools, got %+v", config)
+	}
+}
+
+func TestToolCalls(t *testing.T) {
+	calls, err := toToolCalls([]*genai.Part{
+		{Text: "checking"},
+		{FunctionCall: &genai.FunctionCall{Name: "weather", Args: map[string]any{"city": "Paris"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 1 || calls[0].Name != "weather" || calls[0].Arguments != `{"city":"Paris"}` || calls[0].ID == "" {
+		t.Fatalf("unexpected tool calls %+v", calls)
+	}
+	calls[0].Result = "sunny"
+	_, contents, err := toContents([]*blades.Message{
+		blades.UserMessage("weather in Paris?"),
+		{Role: blades.RoleAssistant, ToolCalls: calls},
+		{Role: blades.RoleTool, ToolCalls: calls},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contents) != 3 {
+		t.Fatalf("expected 3 turns, got %d", len(contents))
+	}
+	if call := contents[1].Parts[0].FunctionCall; contents[1].Role != genai.RoleModel || call == nil || call.Args["city"] != "Paris" {
+		t.Fatalf("unexpected function call turn %+v", contents[1])
+	}
+	if res := contents[2].Parts[0].FunctionResponse; contents[2].Role != genai.RoleUser || res == nil || res.ID != calls[0].ID || res.Response["output"] != "sunny" {
+		t.Fatalf("unexpected function response turn %+v", contents[2])
+	}
+}
+
+func TestFinishMetadata(t *testing.T) {
+	tests := []struct {
+		reason genai.FinishReason
+		want   string
+	}{
+		{"", ""},
+		{genai.FinishReasonStop, "stop"},
+		{genai.FinishReasonMaxTokens, "length"},
+		{genai.FinishReasonSafety, "content_filter"},
+		{genai.FinishReasonMalformedFunctionCall, "malformed_function_call"},
+	}
+	for _, tt := range tests {
+		if got := finishMetadata(tt.reason)["finish_reason"]; got != tt.want {
+			t.Errorf("finishMetadata(%q) = %q, want %q", tt.reason, got, tt.want)
+		}
+	}
+}
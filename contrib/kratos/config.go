@@ -0,0 +1,59 @@
+package kratos
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kratos/blades/config"
+	kconfig "github.com/go-kratos/kratos/v2/config"
+)
+
+var (
+	_ config.Watcher = (*Source)(nil)
+)
+
+// Source adapts a Kratos config, backed by files, etcd, Consul, Nacos or any
+// other Kratos config source, to a blades config.Watcher. The agents and models
+// are read from the "agents" and "models" keys of the configuration root, or of
+// the key set with NewSource.
+type Source struct {
+	config kconfig.Config
+	key    string
+}
+
+// NewSource creates a new Source reading the blades configuration under key,
+// or at the root when key is empty. The Kratos config must be loaded.
+func NewSource(c kconfig.Config, key string) *Source {
+	return &Source{config: c, key: key}
+}
+
+// Load scans the configuration.
+func (s *Source) Load(ctx context.Context) (*config.Config, error) {
+	var c config.Config
+	if s.key == "" {
+		if err := s.config.Scan(&c); err != nil {
+			return nil, err
+		}
+		return &c, nil
+	}
+	if err := s.config.Value(s.key).Scan(&c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Watch observes the agents and models keys until ctx is done. Keys missing
+// from the configuration are not observed.
+func (s *Source) Watch(ctx context.Context, onChange func()) error {
+	for _, key := range []string{"agents", "models"} {
+		if s.key != "" {
+			key = s.key + "." + key
+		}
+		err := s.config.Watch(key, func(string, kconfig.Value) { onChange() })
+		if err != nil && !errors.Is(err, kconfig.ErrNotFound) {
+			return err
+		}
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
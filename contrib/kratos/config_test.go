@@ -0,0 +1,147 @@
+package kratos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	kconfig "github.com/go-kratos/kratos/v2/config"
+)
+
+// fakeSource is a Kratos config source serving JSON documents, the later ones
+// through its watcher.
+type fakeSource struct {
+	data    string
+	changes chan string
+}
+
+func (s *fakeSource) Load() ([]*kconfig.KeyValue, error) {
+	return []*kconfig.KeyValue{{Key: "blades.json", Value: []byte(s.data), Format: "json"}}, nil
+}
+
+func (s *fakeSource) Watch() (kconfig.Watcher, error) {
+	return &fakeWatcher{changes: s.changes, done: make(chan struct{})}, nil
+}
+
+type fakeWatcher struct {
+	changes chan string
+	done    chan struct{}
+}
+
+func (w *fakeWatcher) Next() ([]*kconfig.KeyValue, error) {
+	select {
+	case data := <-w.changes:
+		return []*kconfig.KeyValue{{Key: "blades.json", Value: []byte(data), Format: "json"}}, nil
+	case <-w.done:
+		return nil, context.Canceled
+	}
+}
+
+func (w *fakeWatcher) Stop() error {
+	close(w.done)
+	return nil
+}
+
+func newTestConfig(t *testing.T, source *fakeSource) kconfig.Config {
+	t.Helper()
+	c := kconfig.New(kconfig.WithSource(source))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+const document = `{"models": {"fast": {"provider": "openai", "model": "gpt-4o-mini"}}, "agents": {"helper": {"model": "fast", "instructions": "%s"}}}`
+
+func TestSource_Load(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		key  string
+	}{
+		{name: "root", data: fmt.Sprintf(document, "be brief")},
+		{name: "key", data: fmt.Sprintf(`{"ai": {"blades": %s}}`, fmt.Sprintf(document, "be brief")), key: "ai.blades"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := NewSource(newTestConfig(t, &fakeSource{data: tt.data}), tt.key)
+			c, err := source.Load(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Models["fast"].Model != "gpt-4o-mini" || c.Agents["helper"].Model != "fast" || c.Agents["helper"].Instructions != "be brief" {
+				t.Fatalf("unexpected configuration %+v", c)
+			}
+		})
+	}
+}
+
+func TestSource_LoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		key  string
+	}{
+		{name: "invalid", data: `{"agents": "helper"}`},
+		{name: "missing key", data: fmt.Sprintf(document, "be brief"), key: "ai.blades"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := NewSource(newTestConfig(t, &fakeSource{data: tt.data}), tt.key)
+			if _, err := source.Load(context.Background()); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestSource_Watch(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		key  string
+	}{
+		{name: "root", data: document},
+		{name: "key", data: `{"ai": ` + document + `}`, key: "ai"},
+		{name: "missing models", data: `{"agents": {"helper": {"model": "fast", "instructions": "%s"}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &fakeSource{data: fmt.Sprintf(tt.data, "v0"), changes: make(chan string)}
+			s := NewSource(newTestConfig(t, source), tt.key)
+			ctx, cancel := context.WithCancel(context.Background())
+			changed := make(chan struct{}, 1)
+			done := make(chan error, 1)
+			go func() {
+				done <- s.Watch(ctx, func() {
+					select {
+					case changed <- struct{}{}:
+					default:
+					}
+				})
+			}()
+			// the observers are registered asynchronously, so keep changing
+			// the configuration until one of them is notified
+			deadline := time.After(5 * time.Second)
+			for i, observed := 1, false; !observed; i++ {
+				select {
+				case source.changes <- fmt.Sprintf(tt.data, fmt.Sprint("v", i)):
+				case <-deadline:
+					t.Fatal("expected the change to be observed")
+				}
+				select {
+				case <-changed:
+					observed = true
+				case <-time.After(10 * time.Millisecond):
+				}
+			}
+			cancel()
+			if err := <-done; !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected the watch to end with the context, got %v", err)
+			}
+		})
+	}
+}
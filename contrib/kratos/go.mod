@@ -0,0 +1,19 @@
+module github.com/go-kratos/blades/contrib/kratos
+
+go 1.24
+
+require (
+	github.com/go-kratos/blades v0.0.0
+	github.com/go-kratos/kratos/v2 v2.8.4
+)
+
+require (
+	dario.cat/mergo v1.0.0 // indirect
+	github.com/google/jsonschema-go v0.2.3 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	google.golang.org/protobuf v1.33.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/go-kratos/blades => ../../
@@ -0,0 +1,110 @@
+package litellm
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/contrib/openai"
+	"github.com/openai/openai-go/v2/option"
+)
+
+// DefaultBaseURL is the address of a LiteLLM proxy running locally.
+const DefaultBaseURL = "http://localhost:4000"
+
+// Config holds the configuration of a LiteLLM proxy provider.
+type Config struct {
+	// APIKey is the LiteLLM virtual key or master key.
+	APIKey string
+	// BaseURL overrides DefaultBaseURL.
+	BaseURL string
+	// ModelPrefix is prepended to request models without a provider prefix, e.g.
+	// "azure/" routes "gpt-4o" to the "azure/gpt-4o" deployment.
+	ModelPrefix string
+	// Tags are sent as x-litellm-tags for tag-based routing and spend tracking.
+	Tags []string
+}
+
+type callKey struct{}
+
+// call records the response headers LiteLLM reports for a call.
+type call struct {
+	header http.Header
+}
+
+// ChatProvider implements blades.ModelProvider for a LiteLLM proxy. It reports
+// the cost, model ID and call ID surfaced by the proxy for each non-streaming
+// response in the "cost", "model_id" and "call_id" message metadata.
+type ChatProvider struct {
+	chat   blades.ModelProvider
+	prefix string
+}
+
+// NewChatProvider constructs a LiteLLM proxy provider. Additional request
+// options are passed through to the underlying OpenAI-compatible client.
+func NewChatProvider(cfg Config, opts ...option.RequestOption) blades.ModelProvider {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	reqOpts := []option.RequestOption{
+		option.WithBaseURL(baseURL),
+		option.WithMiddleware(headerMiddleware),
+	}
+	if cfg.APIKey != "" {
+		reqOpts = append(reqOpts, option.WithAPIKey(cfg.APIKey))
+	}
+	if len(cfg.Tags) > 0 {
+		reqOpts = append(reqOpts, option.WithHeader("x-litellm-tags", strings.Join(cfg.Tags, ",")))
+	}
+	return &ChatProvider{chat: openai.NewChatProvider(append(reqOpts, opts...)...), prefix: cfg.ModelPrefix}
+}
+
+// Generate executes a non-streaming chat completion request through the proxy.
+func (p *ChatProvider) Generate(ctx context.Context, req *blades.ModelRequest, opts ...blades.ModelOption) (*blades.ModelResponse, error) {
+	c := &call{}
+	res, err := p.chat.Generate(context.WithValue(ctx, callKey{}, c), p.route(req), opts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range res.Messages {
+		if msg.Metadata == nil {
+			msg.Metadata = make(map[string]string)
+		}
+		for key, header := range map[string]string{
+			"cost":     "x-litellm-response-cost",
+			"model_id": "x-litellm-model-id",
+			"call_id":  "x-litellm-call-id",
+		} {
+			if value := c.header.Get(header); value != "" {
+				msg.Metadata[key] = value
+			}
+		}
+	}
+	return res, nil
+}
+
+// NewStream executes a streaming chat completion request through the proxy.
+func (p *ChatProvider) NewStream(ctx context.Context, req *blades.ModelRequest, opts ...blades.ModelOption) (blades.Streamer[*blades.ModelResponse], error) {
+	return p.chat.NewStream(ctx, p.route(req), opts...)
+}
+
+// route returns req with the model prefix applied, if needed.
+func (p *ChatProvider) route(req *blades.ModelRequest) *blades.ModelRequest {
+	if p.prefix == "" || strings.Contains(req.Model, "/") {
+		return req
+	}
+	routed := *req
+	routed.Model = p.prefix + req.Model
+	return &routed
+}
+
+// headerMiddleware records the response headers of the calls made by Generate.
+func headerMiddleware(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+	res, err := next(req)
+	if c, ok := req.Context().Value(callKey{}).(*call); ok && err == nil {
+		c.header = res.Header
+	}
+	return res, err
+}
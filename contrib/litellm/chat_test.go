@@ -0,0 +1,109 @@
+package litellm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/go-kratos/blades"
+	"github.com/openai/openai-go/v2/option"
+)
+
+// fakeProxy serves chat completions like a LiteLLM proxy, reporting the cost
+// and IDs of the calls in response headers, and records the requests.
+type fakeProxy struct {
+	status int
+
+	mu      sync.Mutex
+	headers []http.Header
+	models  []any
+}
+
+func (f *fakeProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]any
+	if r.URL.Path != "/chat/completions" || json.NewDecoder(r.Body).Decode(&body) != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	f.headers = append(f.headers, r.Header.Clone())
+	f.models = append(f.models, body["model"])
+	f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+		io.WriteString(w, `{"error": {"message": "budget exceeded", "code": "400"}}`)
+		return
+	}
+	w.Header().Set("x-litellm-response-cost", "0.0021")
+	w.Header().Set("x-litellm-model-id", "deployment-1")
+	w.Header().Set("x-litellm-call-id", "call-1")
+	io.WriteString(w, `{
+		"id": "chatcmpl-1", "object": "chat.completion", "created": 0, "model": "gpt-4o",
+		"choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "hello"}}]
+	}`)
+}
+
+func newTestProvider(t *testing.T, cfg Config, fake *fakeProxy) blades.ModelProvider {
+	t.Helper()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	cfg.BaseURL = srv.URL
+	return NewChatProvider(cfg, option.WithMaxRetries(0))
+}
+
+func TestChatProvider_Generate(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   Config
+		model string
+		sent  string
+		tags  string
+	}{
+		{name: "model", cfg: Config{APIKey: "sk-1"}, model: "gpt-4o", sent: "gpt-4o"},
+		{name: "prefixed model", cfg: Config{APIKey: "sk-1", ModelPrefix: "azure/"}, model: "gpt-4o", sent: "azure/gpt-4o"},
+		{name: "provider model", cfg: Config{APIKey: "sk-1", ModelPrefix: "azure/"}, model: "bedrock/claude", sent: "bedrock/claude"},
+		{name: "tags", cfg: Config{APIKey: "sk-1", Tags: []string{"team-a", "prod"}}, model: "gpt-4o", sent: "gpt-4o", tags: "team-a,prod"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeProxy{}
+			provider := newTestProvider(t, tt.cfg, fake)
+			res, err := provider.Generate(context.Background(), &blades.ModelRequest{Model: tt.model, Messages: []*blades.Message{blades.UserMessage("hi")}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fake.models[0] != tt.sent {
+				t.Fatalf("expected the model %q, got %v", tt.sent, fake.models[0])
+			}
+			header := fake.headers[0]
+			if header.Get("Authorization") != "Bearer sk-1" || header.Get("x-litellm-tags") != tt.tags {
+				t.Fatalf("expected the key and tags headers, got %v", header)
+			}
+			want := map[string]string{"cost": "0.0021", "model_id": "deployment-1", "call_id": "call-1"}
+			msg := res.Messages[0]
+			for key, value := range want {
+				if msg.Metadata[key] != value {
+					t.Fatalf("expected %s=%q in the metadata, got %v", key, value, msg.Metadata)
+				}
+			}
+			if msg.Text() != "hello" {
+				t.Fatalf("expected the response text, got %q", msg.Text())
+			}
+		})
+	}
+}
+
+func TestChatProvider_Error(t *testing.T) {
+	provider := newTestProvider(t, Config{APIKey: "sk-1"}, &fakeProxy{status: http.StatusBadRequest})
+	_, err := provider.Generate(context.Background(), &blades.ModelRequest{Model: "gpt-4o", Messages: []*blades.Message{blades.UserMessage("hi")}})
+	var perr *blades.ProviderError
+	if !errors.As(err, &perr) || perr.StatusCode != http.StatusBadRequest || perr.Transient() {
+		t.Fatalf("expected a permanent provider error, got %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+
+	"github.com/go-kratos/blades"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+var (
+	// ErrToolFailed is returned when the MCP server reports a tool call error.
+	ErrToolFailed = errors.New("mcp tool call failed")
+)
+
+// Option configures a Client.
+type Option func(*options)
+
+type options struct {
+	name       string
+	version    string
+	httpClient *http.Client
+}
+
+// WithImplementation sets the client name and version reported to the server.
+func WithImplementation(name, version string) Option {
+	return func(o *options) {
+		o.name = name
+		o.version = version
+	}
+}
+
+// WithHTTPClient sets the HTTP client used by the SSE transport.
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = c
+	}
+}
+
+// Client is a session with a Model Context Protocol server whose tools can be
+// used by a blades Agent.
+type Client struct {
+	session *mcp.ClientSession
+}
+
+// NewClient connects to an MCP server over the given transport.
+func NewClient(ctx context.Context, transport mcp.Transport, opts ...Option) (*Client, error) {
+	o := newOptions(opts)
+	client := mcp.NewClient(&mcp.Implementation{Name: o.name, Version: o.version}, nil)
+	session, err := client.Connect(ctx, transport, nil)
+	if err != nil {
+		return nil, fmt.Errorf("mcp: connect: %w", err)
+	}
+	return &Client{session: session}, nil
+}
+
+// NewStdioClient starts the server command and connects to it over stdin/stdout.
+func NewStdioClient(ctx context.Context, command string, args []string, opts ...Option) (*Client, error) {
+	return NewClient(ctx, &mcp.CommandTransport{Command: exec.Command(command, args...)}, opts...)
+}
+
+// NewSSEClient connects to an MCP server over the HTTP SSE transport.
+func NewSSEClient(ctx context.Context, endpoint string, opts ...Option) (*Client, error) {
+	o := newOptions(opts)
+	return NewClient(ctx, &mcp.SSEClientTransport{Endpoint: endpoint, HTTPClient: o.httpClient}, opts...)
+}
+
+func newOptions(opts []Option) options {
+	o := options{name: "blades", version: "v1.0.0"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Tools lists the server tools as blades tools that call back into the server.
+func (c *Client) Tools(ctx context.Context) ([]*blades.Tool, error) {
+	var tools []*blades.Tool
+	for tool, err := range c.session.Tools(ctx, nil) {
+		if err != nil {
+			return nil, fmt.Errorf("mcp: list tools: %w", err)
+		}
+		t, err := c.toTool(tool)
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, t)
+	}
+	return tools, nil
+}
+
+// Close closes the session and, for stdio servers, stops the server process.
+func (c *Client) Close() error {
+	return c.session.Close()
+}
+
+func (c *Client) toTool(tool *mcp.Tool) (*blades.Tool, error) {
+	var schema *jsonschema.Schema
+	if tool.InputSchema != nil {
+		b, err := json.Marshal(tool.InputSchema)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &schema); err != nil {
+			return nil, fmt.Errorf("mcp: tool %s: input schema: %w", tool.Name, err)
+		}
+	}
+	name := tool.Name
+	return &blades.Tool{
+		Name:        name,
+		Description: tool.Description,
+		InputSchema: schema,
+		Handle: func(ctx context.Context, arguments string) (string, error) {
+			return c.call(ctx, name, arguments)
+		},
+	}, nil
+}
+
+// call invokes the named tool and flattens its result into text.
+func (c *Client) call(ctx context.Context, name, arguments string) (string, error) {
+	var args json.RawMessage
+	if strings.TrimSpace(arguments) != "" {
+		args = json.RawMessage(arguments)
+	}
+	res, err := c.session.CallTool(ctx, &mcp.CallToolParams{Name: name, Arguments: args})
+	if err != nil {
+		return "", fmt.Errorf("mcp: call %s: %w", name, err)
+	}
+	text, err := resultText(res)
+	if err != nil {
+		return "", err
+	}
+	if res.IsError {
+		return "", fmt.Errorf("%w: %s: %s", ErrToolFailed, name, text)
+	}
+	return text, nil
+}
+
+func resultText(res *mcp.CallToolResult) (string, error) {
+	var parts []string
+	for _, content := range res.Content {
+		switch v := content.(type) {
+		case *mcp.TextContent:
+			parts = append(parts, v.Text)
+		case *mcp.ImageContent:
+			parts = append(parts, fmt.Sprintf("[image %s]", v.MIMEType))
+		case *mcp.AudioContent:
+			parts = append(parts, fmt.Sprintf("[audio %s]", v.MIMEType))
+		case *mcp.ResourceLink:
+			parts = append(parts, fmt.Sprintf("[resource %s]", v.URI))
+		case *mcp.EmbeddedResource:
+			if v.Resource != nil && v.Resource.Text != "" {
+				parts = append(parts, v.Resource.Text)
+			}
+		}
+	}
+	if len(parts) == 0 && res.StructuredContent != nil {
+		b, err := json.Marshal(res.StructuredContent)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	return strings.Join(parts, "\n"), nil
+}
@@ -0,0 +1,112 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// connect connects a Client to the server over in-memory transports.
+func connect(t *testing.T, server *mcp.Server) *Client {
+	t.Helper()
+	ctx := context.Background()
+	clientTransport, serverTransport := mcp.NewInMemoryTransports()
+	session, err := server.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { session.Close() })
+	client, err := NewClient(ctx, clientTransport, WithImplementation("test", "v0.0.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// newResultServer serves a tool for each of the results, recording the
+// arguments of the calls.
+func newResultServer(results map[string]*mcp.CallToolResult, calls map[string]string) *mcp.Server {
+	server := mcp.NewServer(&mcp.Implementation{Name: "fake", Version: "v0.0.1"}, nil)
+	for name, res := range results {
+		server.AddTool(&mcp.Tool{
+			Name:        name,
+			Description: "returns " + name,
+			InputSchema: &jsonschema.Schema{Type: "object", Properties: map[string]*jsonschema.Schema{"q": {Type: "string"}}},
+		}, func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			calls[name] = string(req.Params.Arguments)
+			return res, nil
+		})
+	}
+	return server
+}
+
+func TestClient_Tools(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *mcp.CallToolResult
+		text   string
+		err    error
+	}{
+		{
+			name:   "text",
+			result: &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "a"}, &mcp.TextContent{Text: "b"}}},
+			text:   "a\nb",
+		},
+		{
+			name: "media",
+			result: &mcp.CallToolResult{Content: []mcp.Content{
+				&mcp.ImageContent{MIMEType: "image/png", Data: []byte("png")},
+				&mcp.ResourceLink{URI: "file:///a.md", Name: "a.md"},
+				&mcp.EmbeddedResource{Resource: &mcp.ResourceContents{URI: "file:///b.md", Text: "b"}},
+			}},
+			text: "[image image/png]\n[resource file:///a.md]\nb",
+		},
+		{
+			name:   "structured",
+			result: &mcp.CallToolResult{Content: []mcp.Content{}, StructuredContent: map[string]any{"n": 1}},
+			text:   `{"n":1}`,
+		},
+		{
+			name:   "failed",
+			result: &mcp.CallToolResult{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: "boom"}}},
+			err:    ErrToolFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := make(map[string]string)
+			client := connect(t, newResultServer(map[string]*mcp.CallToolResult{tt.name: tt.result}, calls))
+			tools, err := client.Tools(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tools) != 1 || tools[0].Name != tt.name || tools[0].Description != "returns "+tt.name {
+				t.Fatalf("expected the server tool, got %+v", tools)
+			}
+			if schema := tools[0].InputSchema; schema == nil || schema.Properties["q"] == nil {
+				t.Fatalf("expected the input schema to be decoded, got %+v", schema)
+			}
+			text, err := tools[0].Handle(context.Background(), `{"q":"x"}`)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+			if text != tt.text {
+				t.Fatalf("expected %q, got %q", tt.text, text)
+			}
+			if calls[tt.name] != `{"q":"x"}` {
+				t.Fatalf("expected the arguments to be sent, got %q", calls[tt.name])
+			}
+		})
+	}
+}
+
+func TestClient_UnknownTool(t *testing.T) {
+	client := connect(t, newResultServer(nil, nil))
+	if _, err := client.call(context.Background(), "missing", ""); err == nil {
+		t.Fatal("expected calling an unknown tool to fail")
+	}
+}
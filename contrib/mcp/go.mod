@@ -0,0 +1,16 @@
+module github.com/go-kratos/blades/contrib/mcp
+
+go 1.24
+
+require (
+	github.com/go-kratos/blades v0.0.0
+	github.com/google/jsonschema-go v0.3.0
+	github.com/modelcontextprotocol/go-sdk v1.0.0
+)
+
+require (
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/yosida95/uritemplate/v3 v3.0.2 // indirect
+)
+
+replace github.com/go-kratos/blades => ../../
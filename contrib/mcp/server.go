@@ -0,0 +1,105 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-kratos/blades"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// agentInput is the input of tools that run an agent.
+type agentInput struct {
+	Prompt string `json:"prompt" jsonschema:"the prompt sent to the agent"`
+}
+
+// Server serves blades tools and agents over the Model Context Protocol so that
+// IDEs and other MCP hosts can call into blades pipelines.
+type Server struct {
+	server *mcp.Server
+}
+
+// NewServer creates a new Server reporting the given name and version.
+func NewServer(name, version string) *Server {
+	return &Server{server: mcp.NewServer(&mcp.Implementation{Name: name, Version: version}, nil)}
+}
+
+// AddTool exposes a blades tool.
+func (s *Server) AddTool(tool *blades.Tool) {
+	schema := tool.InputSchema
+	if schema == nil {
+		schema = &jsonschema.Schema{Type: "object"}
+	}
+	s.server.AddTool(&mcp.Tool{
+		Name:        tool.Name,
+		Description: tool.Description,
+		InputSchema: schema,
+	}, func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		arguments := string(req.Params.Arguments)
+		if arguments == "" {
+			arguments = "{}"
+		}
+		if err := tool.Validate(arguments); err != nil {
+			return toolResult("", err)
+		}
+		return toolResult(tool.Handle(ctx, arguments))
+	})
+}
+
+// AddAgent exposes a runner, typically a blades Agent or Chain, as a tool that
+// takes a prompt and returns the generated text.
+func (s *Server) AddAgent(name, description string, runner blades.Runner) {
+	schema, err := jsonschema.For[agentInput](nil)
+	if err != nil {
+		panic(err) // agentInput is a static type
+	}
+	s.server.AddTool(&mcp.Tool{
+		Name:        name,
+		Description: description,
+		InputSchema: schema,
+	}, func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var in agentInput
+		if err := json.Unmarshal(req.Params.Arguments, &in); err != nil {
+			return toolResult("", err)
+		}
+		res, err := runner.Run(ctx, blades.NewPrompt(blades.UserMessage(in.Prompt)))
+		if err != nil {
+			return toolResult("", err)
+		}
+		return toolResult(res.Text(), nil)
+	})
+}
+
+// Run serves a single client over the transport until it disconnects.
+func (s *Server) Run(ctx context.Context, transport mcp.Transport) error {
+	return s.server.Run(ctx, transport)
+}
+
+// ServeStdio serves a single client over stdin/stdout.
+func (s *Server) ServeStdio(ctx context.Context) error {
+	return s.Run(ctx, &mcp.StdioTransport{})
+}
+
+// SSEHandler returns an HTTP handler serving clients over the SSE transport.
+func (s *Server) SSEHandler() http.Handler {
+	return mcp.NewSSEHandler(func(*http.Request) *mcp.Server { return s.server }, nil)
+}
+
+// StreamableHandler returns an HTTP handler serving clients over the
+// streamable HTTP transport.
+func (s *Server) StreamableHandler() http.Handler {
+	return mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return s.server }, nil)
+}
+
+// toolResult reports errors as tool results so the calling model can react to them.
+func toolResult(text string, err error) (*mcp.CallToolResult, error) {
+	if err != nil {
+		return &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{&mcp.TextContent{Text: err.Error()}},
+		}, nil
+	}
+	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: text}}}, nil
+}
@@ -0,0 +1,126 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/blades"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// echoRunner answers prompts with their text, or fails with err.
+type echoRunner struct {
+	err error
+}
+
+func (r *echoRunner) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &blades.Generation{Messages: []*blades.Message{blades.AssistantMessage("echo: " + prompt.Messages[0].Text())}}, nil
+}
+
+func (r *echoRunner) RunStream(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (blades.Streamer[*blades.Generation], error) {
+	return nil, errors.ErrUnsupported
+}
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	type greetArgs struct {
+		Name string `json:"name"`
+	}
+	greet, err := blades.NewTool("greet", "greets a person", func(ctx context.Context, args greetArgs) (string, error) {
+		if args.Name == "nobody" {
+			return "", errors.New("no one to greet")
+		}
+		return "hello " + args.Name, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer("test", "v0.0.1")
+	s.AddTool(greet)
+	s.AddTool(&blades.Tool{Name: "ping", Handle: func(ctx context.Context, arguments string) (string, error) {
+		return "pong " + arguments, nil
+	}})
+	s.AddAgent("echo", "echoes the prompt", &echoRunner{})
+	s.AddAgent("broken", "always fails", &echoRunner{err: errors.New("model unavailable")})
+	return s
+}
+
+// callText calls the tool and flattens the text of the result.
+func callText(t *testing.T, session *mcp.ClientSession, name string, args any) (string, bool) {
+	t.Helper()
+	res, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: name, Arguments: args})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parts []string
+	for _, content := range res.Content {
+		parts = append(parts, content.(*mcp.TextContent).Text)
+	}
+	return strings.Join(parts, "\n"), res.IsError
+}
+
+func TestServer_Tools(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	clientTransport, serverTransport := mcp.NewInMemoryTransports()
+	go s.Run(ctx, serverTransport)
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "client", Version: "v0.0.1"}, nil).Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	var names []string
+	for tool, err := range session.Tools(ctx, nil) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, tool.Name)
+	}
+	if strings.Join(names, ",") != "broken,echo,greet,ping" {
+		t.Fatalf("expected the tools and agents to be listed, got %v", names)
+	}
+
+	tests := []struct {
+		name    string
+		tool    string
+		args    any
+		text    string
+		isError bool
+	}{
+		{name: "tool", tool: "greet", args: map[string]any{"name": "ada"}, text: "hello ada"},
+		{name: "tool error", tool: "greet", args: map[string]any{"name": "nobody"}, text: "no one to greet", isError: true},
+		{name: "invalid arguments", tool: "greet", args: map[string]any{"name": 1}, text: "invalid tool arguments", isError: true},
+		{name: "no arguments", tool: "ping", text: "pong {}"},
+		{name: "agent", tool: "echo", args: map[string]any{"prompt": "hi"}, text: "echo: hi"},
+		{name: "agent error", tool: "broken", args: map[string]any{"prompt": "hi"}, text: "model unavailable", isError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, isError := callText(t, session, tt.tool, tt.args)
+			if isError != tt.isError || !strings.Contains(text, tt.text) {
+				t.Fatalf("expected %q (error %v), got %q (error %v)", tt.text, tt.isError, text, isError)
+			}
+		})
+	}
+}
+
+func TestServer_StreamableHandler(t *testing.T) {
+	srv := httptest.NewServer(newTestServer(t).StreamableHandler())
+	defer srv.Close()
+	client := mcp.NewClient(&mcp.Implementation{Name: "client", Version: "v0.0.1"}, nil)
+	session, err := client.Connect(context.Background(), &mcp.StreamableClientTransport{Endpoint: srv.URL, MaxRetries: -1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	if text, _ := callText(t, session, "echo", map[string]any{"prompt": "over http"}); text != "echo: over http" {
+		t.Fatalf("expected the agent answer, got %q", text)
+	}
+}
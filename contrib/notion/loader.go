@@ -0,0 +1,300 @@
+package notion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/document"
+)
+
+const (
+	defaultBaseURL = "https://api.notion.com/v1"
+	notionVersion  = "2022-06-28"
+)
+
+var (
+	_ document.SyncLoader = (*Loader)(nil)
+)
+
+// ErrTokenRequired is returned when no integration token is configured.
+var ErrTokenRequired = errors.New("notion: integration token is required")
+
+// Config holds the configuration of a Notion loader.
+type Config struct {
+	// Token is the Notion integration token.
+	Token string
+	// DatabaseID restricts loading to the pages of a database; when empty,
+	// every page shared with the integration is loaded.
+	DatabaseID string
+	// BaseURL overrides the Notion API base URL.
+	BaseURL string
+	// HTTPClient overrides the HTTP client used for API calls.
+	HTTPClient *http.Client
+}
+
+// Loader loads Notion pages as documents, one document per page.
+type Loader struct {
+	cfg    Config
+	client *http.Client
+}
+
+// NewLoader creates a new Notion Loader.
+func NewLoader(cfg Config) (*Loader, error) {
+	if cfg.Token == "" {
+		return nil, ErrTokenRequired
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultBaseURL
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = blades.NewHTTPClient(blades.HTTPTimeout(30 * time.Second))
+	}
+	return &Loader{cfg: cfg, client: client}, nil
+}
+
+// Load loads every accessible page.
+func (l *Loader) Load(ctx context.Context) ([]*document.Document, error) {
+	docs, _, err := l.Sync(ctx, "")
+	return docs, err
+}
+
+// Sync loads the pages edited after the cursor, an RFC 3339 timestamp returned by a
+// previous Sync, and returns the latest edit time seen as the next cursor.
+func (l *Loader) Sync(ctx context.Context, cursor string) ([]*document.Document, string, error) {
+	var since time.Time
+	if cursor != "" {
+		t, err := time.Parse(time.RFC3339, cursor)
+		if err != nil {
+			return nil, "", fmt.Errorf("notion: invalid cursor %q: %w", cursor, err)
+		}
+		since = t
+	}
+	pages, err := l.listPages(ctx, since)
+	if err != nil {
+		return nil, "", err
+	}
+	next := since
+	docs := make([]*document.Document, 0, len(pages))
+	for _, p := range pages {
+		if !p.LastEditedTime.After(since) {
+			continue
+		}
+		text, err := l.pageText(ctx, p.ID, 0)
+		if err != nil {
+			return nil, "", err
+		}
+		doc := document.New(text, map[string]any{
+			"source":           p.URL,
+			"title":            p.title(),
+			"notion_id":        p.ID,
+			"last_edited_time": p.LastEditedTime.Format(time.RFC3339),
+		})
+		doc.ID = p.ID
+		docs = append(docs, doc)
+		if p.LastEditedTime.After(next) {
+			next = p.LastEditedTime
+		}
+	}
+	if next.IsZero() {
+		return docs, cursor, nil
+	}
+	return docs, next.UTC().Format(time.RFC3339), nil
+}
+
+type page struct {
+	ID             string    `json:"id"`
+	URL            string    `json:"url"`
+	LastEditedTime time.Time `json:"last_edited_time"`
+	Properties     map[string]struct {
+		Type  string     `json:"type"`
+		Title []richText `json:"title"`
+	} `json:"properties"`
+}
+
+func (p page) title() string {
+	for _, prop := range p.Properties {
+		if prop.Type == "title" {
+			return plainText(prop.Title)
+		}
+	}
+	return ""
+}
+
+type richText struct {
+	PlainText string `json:"plain_text"`
+}
+
+func plainText(texts []richText) string {
+	var buf strings.Builder
+	for _, t := range texts {
+		buf.WriteString(t.PlainText)
+	}
+	return buf.String()
+}
+
+type pageList struct {
+	Results    []page `json:"results"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// listPages pages through the search or database query endpoint.
+func (l *Loader) listPages(ctx context.Context, since time.Time) ([]page, error) {
+	var pages []page
+	startCursor := ""
+	for {
+		body := map[string]any{"page_size": 100}
+		if startCursor != "" {
+			body["start_cursor"] = startCursor
+		}
+		path := "/search"
+		if l.cfg.DatabaseID != "" {
+			path = "/databases/" + l.cfg.DatabaseID + "/query"
+			body["sorts"] = []map[string]string{{"timestamp": "last_edited_time", "direction": "ascending"}}
+			if !since.IsZero() {
+				body["filter"] = map[string]any{
+					"timestamp":        "last_edited_time",
+					"last_edited_time": map[string]string{"after": since.Format(time.RFC3339)},
+				}
+			}
+		} else {
+			body["filter"] = map[string]string{"property": "object", "value": "page"}
+			body["sort"] = map[string]string{"timestamp": "last_edited_time", "direction": "ascending"}
+		}
+		var res pageList
+		if err := l.do(ctx, http.MethodPost, path, body, &res); err != nil {
+			return nil, err
+		}
+		pages = append(pages, res.Results...)
+		if !res.HasMore || res.NextCursor == "" {
+			return pages, nil
+		}
+		startCursor = res.NextCursor
+	}
+}
+
+type block struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	HasChildren bool   `json:"has_children"`
+}
+
+type blockList struct {
+	Results    []json.RawMessage `json:"results"`
+	HasMore    bool              `json:"has_more"`
+	NextCursor string            `json:"next_cursor"`
+}
+
+// pageText renders the text of a block tree, one line per block.
+func (l *Loader) pageText(ctx context.Context, blockID string, depth int) (string, error) {
+	var buf strings.Builder
+	startCursor := ""
+	for {
+		path := "/blocks/" + blockID + "/children?page_size=100"
+		if startCursor != "" {
+			path += "&start_cursor=" + startCursor
+		}
+		var res blockList
+		if err := l.do(ctx, http.MethodGet, path, nil, &res); err != nil {
+			return "", err
+		}
+		for _, raw := range res.Results {
+			var b block
+			if err := json.Unmarshal(raw, &b); err != nil {
+				return "", err
+			}
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &fields); err != nil {
+				return "", err
+			}
+			var content struct {
+				RichText []richText `json:"rich_text"`
+				Title    string     `json:"title"`
+			}
+			if data, ok := fields[b.Type]; ok {
+				_ = json.Unmarshal(data, &content)
+			}
+			text := plainText(content.RichText)
+			if text == "" {
+				text = content.Title
+			}
+			if text != "" {
+				buf.WriteString(strings.Repeat("  ", depth) + blockPrefix(b.Type) + text + "\n")
+			}
+			if b.HasChildren && b.Type != "child_page" && b.Type != "child_database" {
+				child, err := l.pageText(ctx, b.ID, depth+1)
+				if err != nil {
+					return "", err
+				}
+				buf.WriteString(child)
+			}
+		}
+		if !res.HasMore || res.NextCursor == "" {
+			return buf.String(), nil
+		}
+		startCursor = res.NextCursor
+	}
+}
+
+// blockPrefix returns a markdown-like prefix that preserves the block structure.
+func blockPrefix(typ string) string {
+	switch typ {
+	case "heading_1":
+		return "# "
+	case "heading_2":
+		return "## "
+	case "heading_3":
+		return "### "
+	case "bulleted_list_item", "to_do":
+		return "- "
+	case "numbered_list_item":
+		return "1. "
+	case "quote":
+		return "> "
+	default:
+		return ""
+	}
+}
+
+// do performs an authenticated API call and decodes the JSON response into out.
+func (l *Loader) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("notion: marshal request: %w", err)
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, l.cfg.BaseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+l.cfg.Token)
+	req.Header.Set("Notion-Version", notionVersion)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := l.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notion: %s %s: %w", method, path, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+		return fmt.Errorf("notion: %s %s: status %d: %s", method, path, res.StatusCode, string(b))
+	}
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("notion: decode response: %w", err)
+	}
+	return nil
+}
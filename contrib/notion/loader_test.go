@@ -0,0 +1,159 @@
+package notion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	pageA = `{"id": "a", "url": "https://notion.so/a", "last_edited_time": "2025-01-01T00:00:00Z", "properties": {"Name": {"type": "title", "title": [{"plain_text": "Page "}, {"plain_text": "A"}]}}}`
+	pageB = `{"id": "b", "url": "https://notion.so/b", "last_edited_time": "2025-02-01T00:00:00Z", "properties": {}}`
+)
+
+// fakeNotion serves the search, database query and block endpoints of the
+// Notion API, recording the request bodies by path.
+type fakeNotion struct {
+	mu     sync.Mutex
+	bodies map[string][]map[string]any
+}
+
+func (f *fakeNotion) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("Notion-Version") != notionVersion {
+		http.Error(w, `{"message": "unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	if r.Method == http.MethodPost {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		f.bodies[r.URL.Path] = append(f.bodies[r.URL.Path], body)
+		f.mu.Unlock()
+		switch {
+		case r.URL.Path == "/search" && body["start_cursor"] == nil:
+			io.WriteString(w, `{"results": [`+pageA+`], "has_more": true, "next_cursor": "c1"}`)
+		case r.URL.Path == "/search":
+			io.WriteString(w, `{"results": [`+pageB+`], "has_more": false}`)
+		case r.URL.Path == "/databases/db/query":
+			io.WriteString(w, `{"results": [`+pageB+`], "has_more": false}`)
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
+	switch r.URL.Path {
+	case "/blocks/a/children":
+		if r.URL.Query().Get("start_cursor") == "" {
+			io.WriteString(w, `{"results": [
+				{"id": "h", "type": "heading_1", "has_children": false, "heading_1": {"rich_text": [{"plain_text": "Title"}]}},
+				{"id": "l", "type": "bulleted_list_item", "has_children": true, "bulleted_list_item": {"rich_text": [{"plain_text": "item"}]}},
+				{"id": "p", "type": "child_page", "has_children": true, "child_page": {"title": "Sub page"}}
+			], "has_more": true, "next_cursor": "c2"}`)
+			return
+		}
+		io.WriteString(w, `{"results": [{"id": "q", "type": "quote", "quote": {"rich_text": [{"plain_text": "cited"}]}}], "has_more": false}`)
+	case "/blocks/l/children":
+		io.WriteString(w, `{"results": [{"id": "n", "type": "paragraph", "paragraph": {"rich_text": [{"plain_text": "nested"}]}}], "has_more": false}`)
+	case "/blocks/b/children":
+		io.WriteString(w, `{"results": [{"id": "t", "type": "to_do", "to_do": {"rich_text": [{"plain_text": "task"}]}}], "has_more": false}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTestLoader(t *testing.T, cfg Config) (*Loader, *fakeNotion) {
+	t.Helper()
+	fake := &fakeNotion{bodies: make(map[string][]map[string]any)}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	cfg.BaseURL = srv.URL
+	loader, err := NewLoader(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loader, fake
+}
+
+func TestLoader_Sync(t *testing.T) {
+	tests := []struct {
+		name     string
+		database string
+		cursor   string
+		ids      []string
+		next     string
+	}{
+		{name: "every page", ids: []string{"a", "b"}, next: "2025-02-01T00:00:00Z"},
+		{name: "pages edited after the cursor", cursor: "2025-01-15T00:00:00Z", ids: []string{"b"}, next: "2025-02-01T00:00:00Z"},
+		{name: "nothing new", cursor: "2025-03-01T00:00:00Z", next: "2025-03-01T00:00:00Z"},
+		{name: "database", database: "db", cursor: "2025-01-15T00:00:00Z", ids: []string{"b"}, next: "2025-02-01T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader, fake := newTestLoader(t, Config{Token: "secret", DatabaseID: tt.database})
+			docs, next, err := loader.Sync(context.Background(), tt.cursor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, doc := range docs {
+				ids = append(ids, doc.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.ids, ",") || next != tt.next {
+				t.Fatalf("expected %v and cursor %q, got %v and %q", tt.ids, tt.next, ids, next)
+			}
+			if tt.database != "" {
+				body := fake.bodies["/databases/db/query"][0]
+				filter, _ := json.Marshal(body["filter"])
+				if string(filter) != `{"last_edited_time":{"after":"2025-01-15T00:00:00Z"},"timestamp":"last_edited_time"}` {
+					t.Fatalf("expected the query to filter by edit time, got %s", filter)
+				}
+			}
+		})
+	}
+}
+
+func TestLoader_Load(t *testing.T) {
+	loader, fake := newTestLoader(t, Config{Token: "secret"})
+	docs, err := loader.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("expected the pages of both search pages, got %d", len(docs))
+	}
+	if searches := fake.bodies["/search"]; len(searches) != 2 || searches[1]["start_cursor"] != "c1" {
+		t.Fatalf("expected the search to be paged, got %v", searches)
+	}
+	want := "# Title\n- item\n  nested\nSub page\n> cited\n"
+	if docs[0].Content != want {
+		t.Fatalf("expected the page text\n%q\ngot\n%q", want, docs[0].Content)
+	}
+	if docs[0].Metadata["title"] != "Page A" || docs[0].Metadata["source"] != "https://notion.so/a" {
+		t.Fatalf("unexpected metadata %v", docs[0].Metadata)
+	}
+	if docs[1].Content != "- task\n" {
+		t.Fatalf("expected the to-do as a list item, got %q", docs[1].Content)
+	}
+}
+
+func TestLoader_Errors(t *testing.T) {
+	if _, err := NewLoader(Config{}); !errors.Is(err, ErrTokenRequired) {
+		t.Fatalf("expected ErrTokenRequired, got %v", err)
+	}
+	loader, _ := newTestLoader(t, Config{Token: "wrong"})
+	if _, err := loader.Load(context.Background()); err == nil || !strings.Contains(err.Error(), "notion: POST /search: status 401") {
+		t.Fatalf("expected the status to be reported, got %v", err)
+	}
+	if _, _, err := loader.Sync(context.Background(), "yesterday"); err == nil || !strings.Contains(err.Error(), `notion: invalid cursor "yesterday"`) {
+		t.Fatalf("expected an invalid cursor error, got %v", err)
+	}
+}
@@ -33,7 +33,7 @@ type AudioProvider struct {
 
 // NewAudioProvider creates a new instance of AudioProvider.
 func NewAudioProvider(opts ...option.RequestOption) blades.ModelProvider {
-	return &AudioProvider{client: openai.NewClient(opts...)}
+	return &AudioProvider{client: newClient(opts)}
 }
 
 // Generate generates audio from text input using the configured OpenAI model.
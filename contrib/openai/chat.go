@@ -1,11 +1,13 @@
 package openai
 
 import (
+	"cmp"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"log"
+	"strconv"
 
 	"github.com/go-kratos/blades"
 	"github.com/openai/openai-go/v2"
@@ -18,9 +20,16 @@ var (
 	// ErrEmptyResponse indicates the provider returned no choices.
 	ErrEmptyResponse = errors.New("empty completion response")
 	// ErrToolNotFound indicates a tool call was made to an unknown tool.
-	ErrToolNotFound = errors.New("tool not found")
-	// ErrTooManyIterations indicates the max iterations option is less than 1.
-	ErrTooManyIterations = errors.New("too many iterations requested")
+	//
+	// Deprecated: tool calls are executed by blades.Agent, which reports
+	// blades.ErrToolNotFound, the error this aliases.
+	ErrToolNotFound = blades.ErrToolNotFound
+	// ErrTooManyIterations indicates the tool loop did not finish within the
+	// max iterations option.
+	//
+	// Deprecated: tool calls are executed by blades.Agent, which reports
+	// blades.ErrMaxIterations, the error this aliases.
+	ErrTooManyIterations = blades.ErrMaxIterations
 )
 
 // ChatProvider implements blades.ModelProvider for OpenAI-compatible chat models.
@@ -32,41 +41,27 @@ type ChatProvider struct {
 // the OPENAI_API_KEY environment variable. If OPENAI_BASE_URL is set,
 // it is used as the API base URL; otherwise the library default is used.
 func NewChatProvider(opts ...option.RequestOption) blades.ModelProvider {
-	return &ChatProvider{client: openai.NewClient(opts...)}
+	return &ChatProvider{client: newClient(opts)}
 }
 
 // New executes a non-streaming chat completion request.
-func (p *ChatProvider) New(ctx context.Context,
-	params openai.ChatCompletionNewParams, tools []*blades.Tool, opts blades.ModelOptions) (*blades.ModelResponse, error) {
-	// Ensure we have at least one iteration left.
-	if opts.MaxIterations < 1 {
-		return nil, ErrTooManyIterations
-	}
+func (p *ChatProvider) New(ctx context.Context, params openai.ChatCompletionNewParams) (*blades.ModelResponse, error) {
 	chatResponse, err := p.client.Chat.Completions.New(ctx, params)
 	if err != nil {
-		return nil, err
+		return nil, toProviderError(err)
 	}
-	res, err := choiceToResponse(ctx, &params, tools, chatResponse.Choices)
+	res, err := choiceToResponse(chatResponse.Choices)
 	if err != nil {
 		return nil, err
 	}
-	for _, msg := range res.Messages {
-		switch msg.Role {
-		case blades.RoleTool:
-			if len(msg.ToolCalls) == 0 {
-				continue
-			}
-			// Recursively call Execute to handle multiple tool calls.
-			opts.MaxIterations--
-			return p.New(ctx, params, tools, opts)
-		}
-	}
+	setUsage(res, chatResponse.Usage)
 	return res, nil
 }
 
-// Generate executes a non-streaming chat completion request.
+// Generate executes a non-streaming chat completion request. Tool calls are
+// returned to the caller rather than executed.
 func (p *ChatProvider) Generate(ctx context.Context, req *blades.ModelRequest, opts ...blades.ModelOption) (*blades.ModelResponse, error) {
-	opt := blades.ModelOptions{MaxIterations: 3}
+	var opt blades.ModelOptions
 	for _, apply := range opts {
 		apply(&opt)
 	}
@@ -74,17 +69,19 @@ func (p *ChatProvider) Generate(ctx context.Context, req *blades.ModelRequest, o
 	if err != nil {
 		return nil, err
 	}
-	return p.New(ctx, params, req.Tools, opt)
+	return p.New(ctx, params)
 }
 
-// NewStreaming executes a streaming chat completion request.
-func (p *ChatProvider) NewStreaming(ctx context.Context,
-	params openai.ChatCompletionNewParams, tools []*blades.Tool, opts blades.ModelOptions) (blades.Streamer[*blades.ModelResponse], error) {
-	// Ensure we have at least one iteration left.
-	if opts.MaxIterations < 1 {
-		return nil, ErrTooManyIterations
-	}
+// NewStreaming executes a streaming chat completion request. Each chunk is sent
+// as an incomplete message, followed by the accumulated completed message.
+func (p *ChatProvider) NewStreaming(ctx context.Context, params openai.ChatCompletionNewParams) (blades.Streamer[*blades.ModelResponse], error) {
+	params.StreamOptions.IncludeUsage = param.NewOpt(true)
 	stream := p.client.Chat.Completions.NewStreaming(ctx, params)
+	// The request is sent when the stream is created.
+	if err := stream.Err(); err != nil {
+		stream.Close()
+		return nil, toProviderError(err)
+	}
 	pipe := blades.NewStreamPipe[*blades.ModelResponse]()
 	pipe.Go(func() error {
 		defer stream.Close()
@@ -92,38 +89,20 @@ func (p *ChatProvider) NewStreaming(ctx context.Context,
 		for stream.Next() {
 			chunk := stream.Current()
 			acc.AddChunk(chunk)
-			res, err := chunkChoiceToResponse(ctx, tools, chunk.Choices)
-			if err != nil {
-				return err
+			// The usage chunk has no choices.
+			if len(chunk.Choices) > 0 {
+				pipe.Send(chunkChoiceToResponse(chunk.Choices))
 			}
-			pipe.Send(res)
 		}
-		lastResponse, err := choiceToResponse(ctx, &params, tools, acc.ChatCompletion.Choices)
+		if err := stream.Err(); err != nil {
+			return err
+		}
+		lastResponse, err := choiceToResponse(acc.ChatCompletion.Choices)
 		if err != nil {
 			return err
 		}
+		setUsage(lastResponse, acc.ChatCompletion.Usage)
 		pipe.Send(lastResponse)
-		for _, msg := range lastResponse.Messages {
-			switch msg.Role {
-			case blades.RoleTool:
-				if len(msg.ToolCalls) == 0 {
-					continue
-				}
-				// Recursively call Execute to handle multiple tool calls.
-				opts.MaxIterations--
-				toolStream, err := p.NewStreaming(ctx, params, tools, opts)
-				if err != nil {
-					return err
-				}
-				for toolStream.Next() {
-					res, err := toolStream.Current()
-					if err != nil {
-						return err
-					}
-					pipe.Send(res)
-				}
-			}
-		}
 		return nil
 	})
 	return pipe, nil
@@ -132,20 +111,15 @@ func (p *ChatProvider) NewStreaming(ctx context.Context,
 // NewStream streams chat completion chunks and converts each choice delta
 // into a ModelResponse for incremental consumption.
 func (p *ChatProvider) NewStream(ctx context.Context, req *blades.ModelRequest, opts ...blades.ModelOption) (blades.Streamer[*blades.ModelResponse], error) {
-	opt := blades.ModelOptions{MaxIterations: 3}
+	var opt blades.ModelOptions
 	for _, apply := range opts {
 		apply(&opt)
 	}
-	if opt.MaxIterations > 0 {
-		opts = append(opts, blades.MaxIterations(opt.MaxIterations-1))
-	} else {
-		return nil, ErrTooManyIterations
-	}
 	params, err := toChatCompletionParams(req, opt)
 	if err != nil {
 		return nil, err
 	}
-	return p.NewStreaming(ctx, params, req.Tools, opt)
+	return p.NewStreaming(ctx, params)
 }
 
 // toChatCompletionParams converts a generic model request into OpenAI params.
@@ -162,29 +136,124 @@ func toChatCompletionParams(req *blades.ModelRequest, opt blades.ModelOptions) (
 	if opt.TopP > 0 {
 		params.TopP = param.NewOpt(opt.TopP)
 	}
-	if opt.Temperature > 0 {
-		params.Temperature = param.NewOpt(opt.Temperature)
+	if opt.Temperature != nil {
+		params.Temperature = param.NewOpt(*opt.Temperature)
 	}
 	if opt.MaxOutputTokens > 0 {
 		params.MaxCompletionTokens = param.NewOpt(opt.MaxOutputTokens)
 	}
+	if len(opt.StopSequences) > 0 {
+		params.Stop = openai.ChatCompletionNewParamsStopUnion{OfStringArray: opt.StopSequences}
+	}
 	if opt.ReasoningEffort != "" {
 		params.ReasoningEffort = shared.ReasoningEffort(opt.ReasoningEffort)
 	}
+	// Web search is available on the search models, e.g. gpt-4o-search-preview.
+	if opt.Grounding.WebSearch {
+		// An empty object would be omitted, so the default context size is set.
+		params.WebSearchOptions = openai.ChatCompletionNewParamsWebSearchOptions{SearchContextSize: "medium"}
+	}
+	switch format := opt.ResponseFormat; format.Type {
+	case blades.ResponseFormatJSONObject:
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONObject: &shared.ResponseFormatJSONObjectParam{},
+		}
+	case blades.ResponseFormatJSONSchema:
+		// Structured outputs require an object at the root of the schema.
+		if format.Schema == nil || format.Schema.Type != "object" {
+			return openai.ChatCompletionNewParams{}, &blades.UnsupportedError{Provider: "openai", Option: "response schema without an object root"}
+		}
+		schema := shared.ResponseFormatJSONSchemaJSONSchemaParam{
+			Name:   cmp.Or(format.Name, "response"),
+			Schema: format.Schema,
+		}
+		if format.Strict {
+			schema.Strict = param.NewOpt(true)
+		}
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{JSONSchema: schema},
+		}
+	}
+	// OpenAI caches prompt prefixes automatically; the key improves cache hits
+	// for requests sharing a long prefix.
+	if opt.Cache.Key != "" {
+		params.PromptCacheKey = param.NewOpt(opt.Cache.Key)
+	}
 	for _, msg := range req.Messages {
-		log.Println("Processing message:", msg.Role, msg.Parts)
 		switch msg.Role {
 		case blades.RoleUser:
 			params.Messages = append(params.Messages, openai.UserMessage(toContentParts(msg)))
 		case blades.RoleAssistant:
-			params.Messages = append(params.Messages, openai.UserMessage(toContentParts(msg)))
+			params.Messages = append(params.Messages, toAssistantMessage(msg))
 		case blades.RoleSystem:
 			params.Messages = append(params.Messages, openai.SystemMessage(toTextParts(msg)))
+		case blades.RoleDeveloper:
+			params.Messages = append(params.Messages, openai.DeveloperMessage(toTextParts(msg)))
+		case blades.RoleTool:
+			for _, call := range msg.ToolCalls {
+				params.Messages = append(params.Messages, openai.ToolMessage(call.Result, call.ID))
+			}
 		}
 	}
 	return params, nil
 }
 
+// toProviderError converts API errors to blades.ProviderError, so that
+// transient failures can be retried.
+func toProviderError(err error) error {
+	var apiErr *openai.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	perr := &blades.ProviderError{Provider: "openai", StatusCode: apiErr.StatusCode, Err: err}
+	if apiErr.Response != nil {
+		perr.RetryAfter = blades.ParseRetryAfter(apiErr.Response.Header)
+	}
+	return perr
+}
+
+// setUsage records the token usage and how many prompt tokens were served from
+// the prompt cache.
+func setUsage(res *blades.ModelResponse, usage openai.CompletionUsage) {
+	if usage.TotalTokens > 0 {
+		res.Usage = &blades.Usage{
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			TotalTokens:      usage.TotalTokens,
+		}
+	}
+	if usage.PromptTokensDetails.CachedTokens == 0 {
+		return
+	}
+	for _, msg := range res.Messages {
+		msg.Metadata["cached_tokens"] = strconv.FormatInt(usage.PromptTokensDetails.CachedTokens, 10)
+	}
+}
+
+// toAssistantMessage converts an assistant message, including the tool calls it made.
+func toAssistantMessage(msg *blades.Message) openai.ChatCompletionMessageParamUnion {
+	var text string
+	for _, part := range toTextParts(msg) {
+		text += part.Text
+	}
+	assistant := openai.ChatCompletionAssistantMessageParam{}
+	if text != "" {
+		assistant.Content.OfString = param.NewOpt(text)
+	}
+	for _, call := range msg.ToolCalls {
+		assistant.ToolCalls = append(assistant.ToolCalls, openai.ChatCompletionMessageToolCallUnionParam{
+			OfFunction: &openai.ChatCompletionMessageFunctionToolCallParam{
+				ID: call.ID,
+				Function: openai.ChatCompletionMessageFunctionToolCallFunctionParam{
+					Name:      call.Name,
+					Arguments: call.Arguments,
+				},
+			},
+		})
+	}
+	return openai.ChatCompletionMessageParamUnion{OfAssistant: &assistant}
+}
+
 func toTools(tools []*blades.Tool) ([]openai.ChatCompletionToolUnionParam, error) {
 	if len(tools) == 0 {
 		return nil, nil
@@ -276,45 +345,8 @@ func toContentParts(message *blades.Message) []openai.ChatCompletionContentPartU
 	return parts
 }
 
-// toolCall invokes a tool by name with the given arguments.
-func toolCall(ctx context.Context, tools []*blades.Tool, name, arguments string) (string, error) {
-	for _, tool := range tools {
-		if tool.Name == name {
-			return tool.Handle(ctx, arguments)
-		}
-	}
-	return "", ErrToolNotFound
-}
-
-func choiceToToolCalls(ctx context.Context, tools []*blades.Tool, choices []openai.ChatCompletionChoice) (*blades.ModelResponse, error) {
-	res := &blades.ModelResponse{}
-	for _, choice := range choices {
-		msg := &blades.Message{
-			Role:   blades.RoleTool,
-			Status: blades.StatusCompleted,
-		}
-		if len(choice.Message.ToolCalls) > 0 {
-			for _, call := range choice.Message.ToolCalls {
-				result, err := toolCall(ctx, tools, call.Function.Name, call.Function.Arguments)
-				if err != nil {
-					return nil, err
-				}
-				msg.Role = blades.RoleTool
-				msg.ToolCalls = append(msg.ToolCalls, &blades.ToolCall{
-					ID:        call.ID,
-					Name:      call.Function.Name,
-					Arguments: call.Function.Arguments,
-					Result:    result,
-				})
-			}
-		}
-		res.Messages = append(res.Messages, msg)
-	}
-	return res, nil
-}
-
 // choiceToResponse converts a non-streaming choice to a ModelResponse.
-func choiceToResponse(ctx context.Context, params *openai.ChatCompletionNewParams, tools []*blades.Tool, choices []openai.ChatCompletionChoice) (*blades.ModelResponse, error) {
+func choiceToResponse(choices []openai.ChatCompletionChoice) (*blades.ModelResponse, error) {
 	res := &blades.ModelResponse{}
 	for _, choice := range choices {
 		msg := &blades.Message{
@@ -338,31 +370,52 @@ func choiceToResponse(ctx context.Context, params *openai.ChatCompletionNewParam
 		if choice.FinishReason != "" {
 			msg.Metadata["finish_reason"] = choice.FinishReason
 		}
-		if len(choice.Message.ToolCalls) > 0 {
-			// If there is a was a function call, continue the conversation
-			params.Messages = append(params.Messages, choice.Message.ToParam())
-		}
 		for _, call := range choice.Message.ToolCalls {
-			result, err := toolCall(ctx, tools, call.Function.Name, call.Function.Arguments)
-			if err != nil {
-				return nil, err
-			}
-			msg.Role = blades.RoleTool
 			msg.ToolCalls = append(msg.ToolCalls, &blades.ToolCall{
 				ID:        call.ID,
 				Name:      call.Function.Name,
 				Arguments: call.Function.Arguments,
-				Result:    result,
 			})
-			params.Messages = append(params.Messages, openai.ToolMessage(result, call.ID))
 		}
 		res.Messages = append(res.Messages, msg)
+		if grounding := toGrounding(choice.Message); grounding != nil && res.Grounding == nil {
+			res.Grounding = grounding
+		}
 	}
 	return res, nil
 }
 
+// toGrounding converts the URL citations of web search models.
+func toGrounding(msg openai.ChatCompletionMessage) *blades.Grounding {
+	if len(msg.Annotations) == 0 {
+		return nil
+	}
+	var (
+		grounding = &blades.Grounding{}
+		runes     = []rune(msg.Content)
+	)
+	for i, annotation := range msg.Annotations {
+		citation := annotation.URLCitation
+		grounding.Sources = append(grounding.Sources, blades.GroundingSource{Title: citation.Title, URI: citation.URL})
+		// Citation indexes count characters, supports use byte offsets.
+		start, end := int(citation.StartIndex), int(citation.EndIndex)
+		if start < 0 || start > end || end > len(runes) {
+			continue
+		}
+		offset := len(string(runes[:start]))
+		text := string(runes[start:end])
+		grounding.Supports = append(grounding.Supports, blades.GroundingSupport{
+			Text:    text,
+			Start:   offset,
+			End:     offset + len(text),
+			Sources: []int{i},
+		})
+	}
+	return grounding
+}
+
 // chunkChoiceToResponse converts a streaming chunk choice to a ModelResponse.
-func chunkChoiceToResponse(ctx context.Context, tools []*blades.Tool, choices []openai.ChatCompletionChunkChoice) (*blades.ModelResponse, error) {
+func chunkChoiceToResponse(choices []openai.ChatCompletionChunkChoice) *blades.ModelResponse {
 	res := &blades.ModelResponse{}
 	for _, choice := range choices {
 		msg := &blades.Message{
@@ -380,7 +433,6 @@ func chunkChoiceToResponse(ctx context.Context, tools []*blades.Tool, choices []
 			msg.Metadata["finish_reason"] = choice.FinishReason
 		}
 		for _, call := range choice.Delta.ToolCalls {
-			msg.Role = blades.RoleTool
 			msg.ToolCalls = append(msg.ToolCalls, &blades.ToolCall{
 				ID:        call.ID,
 				Name:      call.Function.Name,
@@ -389,5 +441,5 @@ func chunkChoiceToResponse(ctx context.Context, tools []*blades.Tool, choices []
 		}
 		res.Messages = append(res.Messages, msg)
 	}
-	return res, nil
+	return res
 }
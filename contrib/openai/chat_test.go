@@ -0,0 +1,305 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/blades"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/openai/openai-go/v2"
+)
+
+func TestToChatCompletionParams_ResponseFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      blades.ResponseFormat
+		unsupported bool
+	}{
+		{name: "json object", format: blades.JSONObject()},
+		{name: "object schema", format: blades.JSONSchema(&jsonschema.Schema{Type: "object"})},
+		{name: "array schema", format: blades.JSONSchema(&jsonschema.Schema{Type: "array"}), unsupported: true},
+		{name: "no schema", format: blades.JSONSchema(nil), unsupported: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := toChatCompletionParams(&blades.ModelRequest{}, blades.ModelOptions{ResponseFormat: tt.format})
+			if tt.unsupported {
+				if !errors.Is(err, blades.ErrUnsupported) {
+					t.Fatalf("expected ErrUnsupported, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if params.ResponseFormat.OfJSONObject == nil && params.ResponseFormat.OfJSONSchema == nil {
+				t.Fatal("expected a response format")
+			}
+		})
+	}
+}
+
+func TestToChatCompletionParams_Messages(t *testing.T) {
+	assistant := blades.AssistantMessage("checking")
+	assistant.ToolCalls = []*blades.ToolCall{{ID: "call-1", Name: "weather", Arguments: `{"city":"Paris"}`}}
+	tests := []struct {
+		name     string
+		messages []*blades.Message
+		want     []string
+	}{
+		{
+			name:     "system",
+			messages: []*blades.Message{blades.SystemMessage("be brief")},
+			want:     []string{`{"content":[{"text":"be brief","type":"text"}],"role":"system"}`},
+		},
+		{
+			name:     "developer",
+			messages: []*blades.Message{blades.DeveloperMessage("use metric")},
+			want:     []string{`{"content":[{"text":"use metric","type":"text"}],"role":"developer"}`},
+		},
+		{
+			name:     "user",
+			messages: []*blades.Message{blades.UserMessage("hi")},
+			want:     []string{`{"content":[{"text":"hi","type":"text"}],"role":"user"}`},
+		},
+		{
+			name:     "assistant tool call",
+			messages: []*blades.Message{assistant},
+			want: []string{
+				`{"content":"checking","tool_calls":[{"id":"call-1","function":{"arguments":"{\"city\":\"Paris\"}","name":"weather"},"type":"function"}],"role":"assistant"}`,
+			},
+		},
+		{
+			name: "tool results",
+			messages: []*blades.Message{{Role: blades.RoleTool, ToolCalls: []*blades.ToolCall{
+				{ID: "call-1", Name: "weather", Result: "sunny"},
+				{ID: "call-2", Name: "weather", Result: "rainy"},
+			}}},
+			want: []string{
+				`{"content":"sunny","tool_call_id":"call-1","role":"tool"}`,
+				`{"content":"rainy","tool_call_id":"call-2","role":"tool"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := toChatCompletionParams(&blades.ModelRequest{Messages: tt.messages}, blades.ModelOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(params.Messages) != len(tt.want) {
+				t.Fatalf("expected %d messages, got %d", len(tt.want), len(params.Messages))
+			}
+			for i, msg := range params.Messages {
+				b, err := json.Marshal(msg)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(b) != tt.want[i] {
+					t.Fatalf("expected message %s, got %s", tt.want[i], b)
+				}
+			}
+		})
+	}
+}
+
+func TestToChatCompletionParams_Tools(t *testing.T) {
+	req := &blades.ModelRequest{Tools: []*blades.Tool{{
+		Name:        "weather",
+		Description: "Get the weather",
+		InputSchema: &jsonschema.Schema{Type: "object", Properties: map[string]*jsonschema.Schema{"city": {Type: "string"}}},
+	}}}
+	params, err := toChatCompletionParams(req, blades.ModelOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(params.Tools) != 1 || params.Tools[0].OfFunction == nil {
+		t.Fatalf("expected one function tool, got %+v", params.Tools)
+	}
+	b, err := json.Marshal(params.Tools[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"function":{"name":"weather","description":"Get the weather","parameters":{"properties":{"city":{"type":"string"}},"type":"object"}},"type":"function"}`
+	if string(b) != want {
+		t.Fatalf("expected tool %s, got %s", want, b)
+	}
+}
+
+func TestChoiceToResponse(t *testing.T) {
+	tests := []struct {
+		name   string
+		choice string
+		want   *blades.Message
+	}{
+		{
+			name:   "text",
+			choice: `{"finish_reason":"stop","message":{"role":"assistant","content":"hello"}}`,
+			want: &blades.Message{
+				Role:     blades.RoleAssistant,
+				Status:   blades.StatusCompleted,
+				Parts:    []blades.Part{blades.TextPart{Text: "hello"}},
+				Metadata: map[string]string{"finish_reason": "stop"},
+			},
+		},
+		{
+			name:   "refusal",
+			choice: `{"finish_reason":"stop","message":{"role":"assistant","refusal":"I can't help with that"}}`,
+			want: &blades.Message{
+				Role:     blades.RoleAssistant,
+				Status:   blades.StatusCompleted,
+				Metadata: map[string]string{"finish_reason": "stop", "refusal": "I can't help with that"},
+			},
+		},
+		{
+			name: "tool calls",
+			choice: `{"finish_reason":"tool_calls","message":{"role":"assistant","tool_calls":[` +
+				`{"id":"call-1","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Paris\"}"}}]}}`,
+			want: &blades.Message{
+				Role:      blades.RoleAssistant,
+				Status:    blades.StatusCompleted,
+				Metadata:  map[string]string{"finish_reason": "tool_calls"},
+				ToolCalls: []*blades.ToolCall{{ID: "call-1", Name: "weather", Arguments: `{"city":"Paris"}`}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var choice openai.ChatCompletionChoice
+			if err := json.Unmarshal([]byte(tt.choice), &choice); err != nil {
+				t.Fatal(err)
+			}
+			res, err := choiceToResponse([]openai.ChatCompletionChoice{choice})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Messages) != 1 {
+				t.Fatalf("expected 1 message, got %d", len(res.Messages))
+			}
+			if !reflect.DeepEqual(res.Messages[0], tt.want) {
+				t.Fatalf("expected %+v, got %+v", tt.want, res.Messages[0])
+			}
+			if res.Grounding != nil {
+				t.Fatalf("expected no grounding, got %+v", res.Grounding)
+			}
+		})
+	}
+}
+
+func TestToGrounding(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		start   int
+		end     int
+		want    []blades.GroundingSupport
+	}{
+		{
+			name:    "ascii",
+			content: "Paris is sunny today.",
+			start:   0,
+			end:     5,
+			want:    []blades.GroundingSupport{{Text: "Paris", Start: 0, End: 5, Sources: []int{0}}},
+		},
+		{
+			name:    "non-ascii prefix",
+			content: "Météo: Zürich est ensoleillée.",
+			start:   7,
+			end:     13,
+			want:    []blades.GroundingSupport{{Text: "Zürich", Start: 9, End: 16, Sources: []int{0}}},
+		},
+		{
+			name:    "out of range",
+			content: "café",
+			start:   2,
+			end:     5,
+		},
+		{
+			name:    "reversed",
+			content: "café",
+			start:   3,
+			end:     1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := openai.ChatCompletionMessage{
+				Content: tt.content,
+				Annotations: []openai.ChatCompletionMessageAnnotation{{
+					Type: "url_citation",
+					URLCitation: openai.ChatCompletionMessageAnnotationURLCitation{
+						Title:      "Weather",
+						URL:        "https://example.com/weather",
+						StartIndex: int64(tt.start),
+						EndIndex:   int64(tt.end),
+					},
+				}},
+			}
+			grounding := toGrounding(msg)
+			if grounding == nil {
+				t.Fatal("expected grounding")
+			}
+			sources := []blades.GroundingSource{{Title: "Weather", URI: "https://example.com/weather"}}
+			if !reflect.DeepEqual(grounding.Sources, sources) {
+				t.Fatalf("expected sources %+v, got %+v", sources, grounding.Sources)
+			}
+			if !reflect.DeepEqual(grounding.Supports, tt.want) {
+				t.Fatalf("expected supports %+v, got %+v", tt.want, grounding.Supports)
+			}
+			for _, support := range grounding.Supports {
+				if got := tt.content[support.Start:support.End]; got != support.Text {
+					t.Fatalf("expected offsets to cover %q, got %q", support.Text, got)
+				}
+			}
+		})
+	}
+	if grounding := toGrounding(openai.ChatCompletionMessage{Content: "no citations"}); grounding != nil {
+		t.Fatalf("expected no grounding, got %+v", grounding)
+	}
+}
+
+func TestToProviderError(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		want       time.Duration
+	}{
+		{name: "rate limited", status: http.StatusTooManyRequests, retryAfter: "7", want: 7 * time.Second},
+		{name: "no header", status: http.StatusServiceUnavailable},
+		{name: "bad request", status: http.StatusBadRequest, retryAfter: "soon"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.retryAfter != "" {
+				header.Set("Retry-After", tt.retryAfter)
+			}
+			apiErr := &openai.Error{
+				StatusCode: tt.status,
+				Response:   &http.Response{StatusCode: tt.status, Header: header},
+			}
+			var perr *blades.ProviderError
+			if err := toProviderError(apiErr); !errors.As(err, &perr) {
+				t.Fatalf("expected a ProviderError, got %v", err)
+			}
+			if perr.Provider != "openai" || perr.StatusCode != tt.status {
+				t.Fatalf("expected openai status %d, got %s status %d", tt.status, perr.Provider, perr.StatusCode)
+			}
+			if perr.RetryAfter != tt.want {
+				t.Fatalf("expected RetryAfter %v, got %v", tt.want, perr.RetryAfter)
+			}
+			if !errors.Is(perr, apiErr) {
+				t.Fatal("expected the ProviderError to wrap the API error")
+			}
+		})
+	}
+	plain := errors.New("dial tcp: connection refused")
+	if err := toProviderError(plain); err != plain {
+		t.Fatalf("expected non-API errors unchanged, got %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+package openai
+
+import (
+	"net/http"
+
+	"github.com/go-kratos/blades"
+	"github.com/openai/openai-go/v2"
+	"github.com/openai/openai-go/v2/option"
+)
+
+// WithHTTPHooks applies hooks to the HTTP requests of a provider, e.g. to set
+// gateway routing headers or inspect raw request and response bodies.
+func WithHTTPHooks(hooks *blades.HTTPHooks) option.RequestOption {
+	return option.WithMiddleware(func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		return hooks.Do(req, next)
+	})
+}
+
+// newClient creates a client sending requests over blades.SharedTransport,
+// unless opts set another HTTP client.
+func newClient(opts []option.RequestOption) openai.Client {
+	return openai.NewClient(append([]option.RequestOption{option.WithHTTPClient(blades.NewHTTPClient())}, opts...)...)
+}
@@ -27,7 +27,7 @@ type ImageProvider struct {
 
 // NewImageProvider creates a new instance of ImageProvider.
 func NewImageProvider(opts ...option.RequestOption) blades.ModelProvider {
-	return &ImageProvider{client: openai.NewClient(opts...)}
+	return &ImageProvider{client: newClient(opts)}
 }
 
 // Generate generates images using the configured OpenAI model.
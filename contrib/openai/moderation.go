@@ -0,0 +1,63 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/go-kratos/blades"
+	"github.com/openai/openai-go/v2"
+	"github.com/openai/openai-go/v2/option"
+)
+
+var (
+	_ blades.Moderator = (*Moderator)(nil)
+)
+
+// Moderator implements blades.Moderator with the OpenAI moderation endpoint.
+type Moderator struct {
+	client openai.Client
+	model  string
+}
+
+// NewModerator constructs a Moderator using the model, omni-moderation-latest
+// when empty. The API key is read from the OPENAI_API_KEY environment variable.
+func NewModerator(model string, opts ...option.RequestOption) *Moderator {
+	if model == "" {
+		model = openai.ModerationModelOmniModerationLatest
+	}
+	return &Moderator{client: newClient(opts), model: model}
+}
+
+// Moderate classifies the text, reporting the categories flagged by OpenAI
+// along with the scores of every category.
+func (m *Moderator) Moderate(ctx context.Context, text string) (*blades.ModerationResult, error) {
+	res, err := m.client.Moderations.New(ctx, openai.ModerationNewParams{
+		Model: m.model,
+		Input: openai.ModerationNewParamsInputUnion{OfString: openai.String(text)},
+	})
+	if err != nil {
+		return nil, toProviderError(err)
+	}
+	if len(res.Results) == 0 {
+		return nil, ErrEmptyResponse
+	}
+	var (
+		flags  map[string]bool
+		result = &blades.ModerationResult{}
+	)
+	if err := json.Unmarshal([]byte(res.Results[0].Categories.RawJSON()), &flags); err != nil {
+		return nil, fmt.Errorf("failed to decode moderation categories: %w", err)
+	}
+	if err := json.Unmarshal([]byte(res.Results[0].CategoryScores.RawJSON()), &result.Scores); err != nil {
+		return nil, fmt.Errorf("failed to decode moderation scores: %w", err)
+	}
+	for category, flagged := range flags {
+		if flagged {
+			result.Categories = append(result.Categories, category)
+		}
+	}
+	sort.Strings(result.Categories)
+	return result, nil
+}
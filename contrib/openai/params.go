@@ -13,7 +13,7 @@ func promptFromMessages(messages []*blades.Message) (string, error) {
 	var sections []string
 	for _, msg := range messages {
 		switch msg.Role {
-		case blades.RoleSystem, blades.RoleUser:
+		case blades.RoleSystem, blades.RoleDeveloper, blades.RoleUser:
 			var textParts []string
 			for _, part := range msg.Parts {
 				switch v := part.(type) {
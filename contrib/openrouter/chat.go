@@ -0,0 +1,199 @@
+package openrouter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/contrib/openai"
+	"github.com/openai/openai-go/v2/option"
+)
+
+// DefaultBaseURL is the OpenRouter OpenAI-compatible API endpoint.
+const DefaultBaseURL = "https://openrouter.ai/api/v1"
+
+// Config holds the configuration of an OpenRouter provider.
+type Config struct {
+	// APIKey is the OpenRouter API key.
+	APIKey string
+	// BaseURL overrides DefaultBaseURL.
+	BaseURL string
+	// SiteURL is sent as HTTP-Referer for app attribution on openrouter.ai.
+	SiteURL string
+	// AppName is sent as X-Title for app attribution on openrouter.ai.
+	AppName string
+	// ModelPrefix is prepended to request models without a vendor prefix, e.g.
+	// "openai/" routes "gpt-4o-mini" to "openai/gpt-4o-mini".
+	ModelPrefix string
+}
+
+type routeKey struct{}
+
+// route carries the routing preferences of one call and the routing decision
+// reported by OpenRouter.
+type route struct {
+	options  blades.RoutingOptions
+	model    string
+	provider string
+	cost     string
+}
+
+// ChatProvider implements blades.ModelProvider for OpenRouter. It honors
+// blades.RoutingOptions (fallback models and upstream provider preferences) and
+// reports the model and upstream provider that served each non-streaming response,
+// and the credits it cost, in the "model", "provider" and "cost" message metadata.
+type ChatProvider struct {
+	chat   blades.ModelProvider
+	prefix string
+}
+
+// NewChatProvider constructs an OpenRouter provider. Additional request options
+// are passed through to the underlying OpenAI-compatible client.
+func NewChatProvider(cfg Config, opts ...option.RequestOption) blades.ModelProvider {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	reqOpts := []option.RequestOption{
+		option.WithBaseURL(baseURL),
+		option.WithMiddleware(routingMiddleware),
+	}
+	if cfg.APIKey != "" {
+		reqOpts = append(reqOpts, option.WithAPIKey(cfg.APIKey))
+	}
+	if cfg.SiteURL != "" {
+		reqOpts = append(reqOpts, option.WithHeader("HTTP-Referer", cfg.SiteURL))
+	}
+	if cfg.AppName != "" {
+		reqOpts = append(reqOpts, option.WithHeader("X-Title", cfg.AppName))
+	}
+	return &ChatProvider{chat: openai.NewChatProvider(append(reqOpts, opts...)...), prefix: cfg.ModelPrefix}
+}
+
+// Generate executes a non-streaming chat completion request through OpenRouter.
+func (p *ChatProvider) Generate(ctx context.Context, req *blades.ModelRequest, opts ...blades.ModelOption) (*blades.ModelResponse, error) {
+	r := newRoute(opts)
+	res, err := p.chat.Generate(context.WithValue(ctx, routeKey{}, r), p.route(req), opts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range res.Messages {
+		if msg.Metadata == nil {
+			msg.Metadata = make(map[string]string)
+		}
+		if r.model != "" {
+			msg.Metadata["model"] = r.model
+		}
+		if r.provider != "" {
+			msg.Metadata["provider"] = r.provider
+		}
+		if r.cost != "" {
+			msg.Metadata["cost"] = r.cost
+		}
+	}
+	return res, nil
+}
+
+// NewStream executes a streaming chat completion request through OpenRouter.
+func (p *ChatProvider) NewStream(ctx context.Context, req *blades.ModelRequest, opts ...blades.ModelOption) (blades.Streamer[*blades.ModelResponse], error) {
+	return p.chat.NewStream(context.WithValue(ctx, routeKey{}, newRoute(opts)), p.route(req), opts...)
+}
+
+// route returns req with the model prefix applied, if needed, and developer
+// messages sent as system messages, which every routed provider accepts.
+func (p *ChatProvider) route(req *blades.ModelRequest) *blades.ModelRequest {
+	routed := *req
+	routed.Messages = blades.MapRole(req.Messages, blades.RoleDeveloper, blades.RoleSystem)
+	if p.prefix != "" && !strings.Contains(req.Model, "/") {
+		routed.Model = p.prefix + req.Model
+	}
+	return &routed
+}
+
+func newRoute(opts []blades.ModelOption) *route {
+	var opt blades.ModelOptions
+	for _, apply := range opts {
+		apply(&opt)
+	}
+	return &route{options: opt.Routing}
+}
+
+// routingMiddleware injects the OpenRouter routing fields into the request body
+// and records which model and provider served the response.
+func routingMiddleware(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+	r, ok := req.Context().Value(routeKey{}).(*route)
+	if !ok || req.Body == nil || req.Method != http.MethodPost {
+		return next(req)
+	}
+	if err := injectRouting(req, r.options); err != nil {
+		return nil, err
+	}
+	res, err := next(req)
+	if err != nil || strings.HasPrefix(res.Header.Get("Content-Type"), "text/event-stream") {
+		return res, err
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	var served struct {
+		Model    string `json:"model"`
+		Provider string `json:"provider"`
+		Usage    struct {
+			Cost json.Number `json:"cost"`
+		} `json:"usage"`
+	}
+	if json.Unmarshal(body, &served) == nil {
+		r.model, r.provider, r.cost = served.Model, served.Provider, served.Usage.Cost.String()
+	}
+	return res, nil
+}
+
+// injectRouting adds "models" and "provider" fields to the JSON request body,
+// and asks for usage accounting to learn the cost of the request.
+func injectRouting(req *http.Request, opts blades.RoutingOptions) error {
+	provider := map[string]any{}
+	if len(opts.Providers) > 0 {
+		provider["order"] = opts.Providers
+	}
+	if len(opts.IgnoreProviders) > 0 {
+		provider["ignore"] = opts.IgnoreProviders
+	}
+	if opts.AllowFallbacks != nil {
+		provider["allow_fallbacks"] = *opts.AllowFallbacks
+	}
+	if opts.Sort != "" {
+		provider["sort"] = opts.Sort
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return err
+	}
+	if len(opts.Models) > 0 {
+		fields["models"] = opts.Models
+	}
+	if len(provider) > 0 {
+		fields["provider"] = provider
+	}
+	fields["usage"] = map[string]any{"include": true}
+	if body, err = json.Marshal(fields); err != nil {
+		return err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return nil
+}
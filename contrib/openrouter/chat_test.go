@@ -0,0 +1,181 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/go-kratos/blades"
+	"github.com/openai/openai-go/v2/option"
+)
+
+const completion = `{
+	"id": "gen-1", "object": "chat.completion", "created": 0,
+	"model": "openai/gpt-4o-mini", "provider": "Azure",
+	"choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "hello"}}],
+	"usage": {"prompt_tokens": 3, "completion_tokens": 1, "total_tokens": 4, "cost": 0.00012}
+}`
+
+// fakeOpenRouter serves chat completions, recording the request headers and
+// bodies. Streaming requests are answered with server-sent events.
+type fakeOpenRouter struct {
+	status int
+
+	mu      sync.Mutex
+	headers []http.Header
+	bodies  []map[string]any
+}
+
+func (f *fakeOpenRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]any
+	if r.URL.Path != "/chat/completions" || json.NewDecoder(r.Body).Decode(&body) != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	f.headers = append(f.headers, r.Header.Clone())
+	f.bodies = append(f.bodies, body)
+	f.mu.Unlock()
+	if f.status != 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(f.status)
+		io.WriteString(w, `{"error": {"message": "rate limited", "code": 429}}`)
+		return
+	}
+	if body["stream"] == true {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, `data: {"id": "gen-1", "object": "chat.completion.chunk", "created": 0, "model": "openai/gpt-4o-mini", "choices": [{"index": 0, "delta": {"role": "assistant", "content": "hel"}}]}`+"\n\n")
+		io.WriteString(w, `data: {"id": "gen-1", "object": "chat.completion.chunk", "created": 0, "model": "openai/gpt-4o-mini", "choices": [{"index": 0, "delta": {"content": "lo"}, "finish_reason": "stop"}]}`+"\n\n")
+		io.WriteString(w, "data: [DONE]\n\n")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	io.WriteString(w, completion)
+}
+
+func newTestProvider(t *testing.T, cfg Config, fake *fakeOpenRouter) blades.ModelProvider {
+	t.Helper()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	cfg.BaseURL = srv.URL
+	return NewChatProvider(cfg, option.WithMaxRetries(0))
+}
+
+func TestChatProvider_Generate(t *testing.T) {
+	tests := []struct {
+		name     string
+		model    string
+		opts     []blades.ModelOption
+		sent     string
+		models   any
+		provider any
+	}{
+		{name: "prefixed model", model: "gpt-4o-mini", sent: "openai/gpt-4o-mini"},
+		{name: "vendor model", model: "anthropic/claude-sonnet-4", sent: "anthropic/claude-sonnet-4"},
+		{
+			name:  "routing",
+			model: "gpt-4o-mini",
+			opts: []blades.ModelOption{
+				blades.RoutingModels("openai/gpt-4o", "mistral/large"),
+				blades.RoutingProviders("Azure"),
+				blades.RoutingIgnoreProviders("Groq"),
+				blades.RoutingAllowFallbacks(false),
+				blades.RoutingSort("price"),
+			},
+			sent:     "openai/gpt-4o-mini",
+			models:   []any{"openai/gpt-4o", "mistral/large"},
+			provider: map[string]any{"order": []any{"Azure"}, "ignore": []any{"Groq"}, "allow_fallbacks": false, "sort": "price"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeOpenRouter{}
+			provider := newTestProvider(t, Config{APIKey: "secret", SiteURL: "https://example.com", AppName: "blades", ModelPrefix: "openai/"}, fake)
+			res, err := provider.Generate(context.Background(), &blades.ModelRequest{
+				Model:    tt.model,
+				Messages: []*blades.Message{blades.DeveloperMessage("be brief"), blades.UserMessage("hi")},
+			}, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			header, body := fake.headers[0], fake.bodies[0]
+			if header.Get("Authorization") != "Bearer secret" || header.Get("HTTP-Referer") != "https://example.com" || header.Get("X-Title") != "blades" {
+				t.Fatalf("expected the key and attribution headers, got %v", header)
+			}
+			if body["model"] != tt.sent {
+				t.Fatalf("expected the model %q, got %v", tt.sent, body["model"])
+			}
+			if role := body["messages"].([]any)[0].(map[string]any)["role"]; role != "system" {
+				t.Fatalf("expected developer messages to be sent as system messages, got %v", role)
+			}
+			if usage, _ := json.Marshal(body["usage"]); string(usage) != `{"include":true}` {
+				t.Fatalf("expected usage accounting to be requested, got %s", usage)
+			}
+			if got, _ := json.Marshal(body["models"]); tt.models != nil && string(got) != mustJSON(tt.models) {
+				t.Fatalf("expected the fallback models %v, got %s", tt.models, got)
+			}
+			if got, _ := json.Marshal(body["provider"]); tt.provider != nil && string(got) != mustJSON(tt.provider) {
+				t.Fatalf("expected the provider preferences %v, got %s", tt.provider, got)
+			}
+			msg := res.Messages[0]
+			if msg.Text() != "hello" || msg.Metadata["model"] != "openai/gpt-4o-mini" || msg.Metadata["provider"] != "Azure" || msg.Metadata["cost"] != "0.00012" {
+				t.Fatalf("expected the served model, provider and cost, got %q with %v", msg.Text(), msg.Metadata)
+			}
+		})
+	}
+}
+
+func TestChatProvider_NewStream(t *testing.T) {
+	fake := &fakeOpenRouter{}
+	provider := newTestProvider(t, Config{APIKey: "secret"}, fake)
+	stream, err := provider.NewStream(context.Background(), &blades.ModelRequest{
+		Model:    "openai/gpt-4o-mini",
+		Messages: []*blades.Message{blades.UserMessage("hi")},
+	}, blades.RoutingSort("latency"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var text string
+	for stream.Next() {
+		res, err := stream.Current()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, msg := range res.Messages {
+			if msg.Status == blades.StatusCompleted {
+				text = msg.Text()
+			}
+		}
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if text != "hello" {
+		t.Fatalf("expected the streamed text, got %q", text)
+	}
+	if got, _ := json.Marshal(fake.bodies[0]["provider"]); string(got) != `{"sort":"latency"}` {
+		t.Fatalf("expected the routing preferences in the streaming request, got %s", got)
+	}
+}
+
+func TestChatProvider_Error(t *testing.T) {
+	provider := newTestProvider(t, Config{APIKey: "secret"}, &fakeOpenRouter{status: http.StatusTooManyRequests})
+	_, err := provider.Generate(context.Background(), &blades.ModelRequest{Model: "openai/gpt-4o-mini", Messages: []*blades.Message{blades.UserMessage("hi")}})
+	var perr *blades.ProviderError
+	if !errors.As(err, &perr) || perr.StatusCode != http.StatusTooManyRequests || !perr.Transient() {
+		t.Fatalf("expected a transient provider error, got %v", err)
+	}
+}
+
+func mustJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,211 @@
+package pinecone
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/vectorstore"
+)
+
+var (
+	_ vectorstore.VectorStore = (*Store)(nil)
+)
+
+// APIVersion is the Pinecone API version the store is written against.
+const APIVersion = "2025-01"
+
+// contentKey is the metadata key storing the content of records.
+const contentKey = "content"
+
+// Config configures a Pinecone store.
+type Config struct {
+	// APIKey authenticates the requests. It is required.
+	APIKey string
+	// Host is the host of the index, as shown in the console or returned by
+	// describe_index, e.g. "docs-abc123.svc.aped-1234.pinecone.io". It is required.
+	Host string
+	// Namespace partitions the records of the index, the default namespace when empty.
+	Namespace string
+	// BatchSize is the number of records upserted per request, 100 by default.
+	BatchSize int
+	// HTTPClient sends the requests. When nil a client on blades.SharedTransport
+	// with a 30 seconds timeout is used.
+	HTTPClient *http.Client
+}
+
+// Store implements vectorstore.VectorStore on a Pinecone index, serverless or
+// pod-based. Record content is stored in the "content" metadata field.
+type Store struct {
+	client    *http.Client
+	apiKey    string
+	baseURL   string
+	namespace string
+	batchSize int
+}
+
+// NewStore creates a new Store from the config.
+func NewStore(config Config) (*Store, error) {
+	if config.APIKey == "" {
+		return nil, errors.New("pinecone: API key is required")
+	}
+	if config.Host == "" {
+		return nil, errors.New("pinecone: index host is required")
+	}
+	baseURL := config.Host
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "https://" + baseURL
+	}
+	client := config.HTTPClient
+	if client == nil {
+		client = blades.NewHTTPClient(blades.HTTPTimeout(30 * time.Second))
+	}
+	return &Store{
+		client:    client,
+		apiKey:    config.APIKey,
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		namespace: config.Namespace,
+		batchSize: cmp.Or(config.BatchSize, 100),
+	}, nil
+}
+
+// Namespace returns a copy of the store operating on namespace.
+func (s *Store) Namespace(namespace string) *Store {
+	c := *s
+	c.namespace = namespace
+	return &c
+}
+
+type vector struct {
+	ID       string         `json:"id"`
+	Values   []float32      `json:"values,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// Upsert writes the records in batches.
+func (s *Store) Upsert(ctx context.Context, records ...vectorstore.Record) error {
+	for start := 0; start < len(records); start += s.batchSize {
+		batch := records[start:min(start+s.batchSize, len(records))]
+		vectors := make([]vector, 0, len(batch))
+		for _, record := range batch {
+			metadata := make(map[string]any, len(record.Metadata)+1)
+			for key, value := range record.Metadata {
+				metadata[key] = value
+			}
+			if record.Content != "" {
+				metadata[contentKey] = record.Content
+			}
+			vectors = append(vectors, vector{ID: record.ID, Values: record.Vector, Metadata: metadata})
+		}
+		body := map[string]any{"vectors": vectors, "namespace": s.namespace}
+		if err := s.do(ctx, "/vectors/upsert", body, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Query returns the topK nearest records, translating the filter to Pinecone
+// metadata filters ($eq, or $in for slices).
+func (s *Store) Query(ctx context.Context, values []float32, topK int, opts ...vectorstore.QueryOption) ([]vectorstore.Result, error) {
+	var opt vectorstore.QueryOptions
+	for _, apply := range opts {
+		apply(&opt)
+	}
+	body := map[string]any{
+		"vector":          values,
+		"topK":            cmp.Or(topK, 10),
+		"namespace":       s.namespace,
+		"includeMetadata": true,
+		"includeValues":   true,
+	}
+	if filter := toFilter(opt.Filter); filter != nil {
+		body["filter"] = filter
+	}
+	var res struct {
+		Matches []struct {
+			vector
+			Score float64 `json:"score"`
+		} `json:"matches"`
+	}
+	if err := s.do(ctx, "/query", body, &res); err != nil {
+		return nil, err
+	}
+	results := make([]vectorstore.Result, 0, len(res.Matches))
+	for _, match := range res.Matches {
+		if match.Score < opt.MinScore {
+			continue
+		}
+		record := vectorstore.Record{ID: match.ID, Vector: match.Values, Metadata: match.Metadata}
+		if content, ok := record.Metadata[contentKey].(string); ok {
+			record.Content = content
+			delete(record.Metadata, contentKey)
+		}
+		results = append(results, vectorstore.Result{Record: record, Score: match.Score})
+	}
+	return results, nil
+}
+
+// Delete removes the records with the given IDs.
+func (s *Store) Delete(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return s.do(ctx, "/vectors/delete", map[string]any{"ids": ids, "namespace": s.namespace}, nil)
+}
+
+// DeleteAll removes every record of the namespace.
+func (s *Store) DeleteAll(ctx context.Context) error {
+	return s.do(ctx, "/vectors/delete", map[string]any{"deleteAll": true, "namespace": s.namespace}, nil)
+}
+
+func toFilter(filter vectorstore.Filter) map[string]any {
+	if len(filter) == 0 {
+		return nil
+	}
+	out := make(map[string]any, len(filter))
+	for key, value := range filter {
+		switch value.(type) {
+		case []any, []string:
+			out[key] = map[string]any{"$in": value}
+		default:
+			out[key] = map[string]any{"$eq": value}
+		}
+	}
+	return out
+}
+
+func (s *Store) do(ctx context.Context, path string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Api-Key", s.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Pinecone-API-Version", APIVersion)
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("pinecone: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 4<<10))
+		return fmt.Errorf("pinecone: %s %s: %s", path, res.Status, bytes.TrimSpace(msg))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
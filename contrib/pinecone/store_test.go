@@ -0,0 +1,183 @@
+package pinecone
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-kratos/blades/vectorstore"
+)
+
+// request is a request received by fakePinecone.
+type request struct {
+	path   string
+	header http.Header
+	body   map[string]any
+}
+
+// fakePinecone serves the data plane of an index, recording the requests.
+type fakePinecone struct {
+	status int
+
+	mu       sync.Mutex
+	requests []request
+}
+
+func (f *fakePinecone) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]any
+	if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&body) != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	f.requests = append(f.requests, request{path: r.URL.Path, header: r.Header.Clone(), body: body})
+	f.mu.Unlock()
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+		io.WriteString(w, `{"code": 3, "message": "Vector dimension 2 does not match the dimension of the index 3"}`)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case "/query":
+		io.WriteString(w, `{"matches": [
+			{"id": "a", "score": 0.9, "values": [1, 0], "metadata": {"content": "alpha", "lang": "en"}},
+			{"id": "b", "score": 0.4, "values": [0, 1], "metadata": {"lang": "fr"}}
+		]}`)
+	case "/vectors/upsert":
+		io.WriteString(w, `{"upsertedCount": 1}`)
+	default:
+		io.WriteString(w, `{}`)
+	}
+}
+
+func newTestStore(t *testing.T, fake *fakePinecone, batchSize int) *Store {
+	t.Helper()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	store, err := NewStore(Config{APIKey: "secret", Host: srv.URL + "/", Namespace: "docs", BatchSize: batchSize})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestStore_Upsert(t *testing.T) {
+	fake := &fakePinecone{}
+	store := newTestStore(t, fake, 2)
+	records := []vectorstore.Record{
+		{ID: "a", Content: "alpha", Vector: []float32{1, 0}, Metadata: map[string]any{"lang": "en"}},
+		{ID: "b", Vector: []float32{0, 1}},
+		{ID: "c", Content: "gamma", Vector: []float32{1, 1}},
+	}
+	if err := store.Upsert(context.Background(), records...); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.requests) != 2 {
+		t.Fatalf("expected 2 batches, got %d", len(fake.requests))
+	}
+	req := fake.requests[0]
+	if req.path != "/vectors/upsert" || req.header.Get("Api-Key") != "secret" || req.header.Get("X-Pinecone-API-Version") != APIVersion {
+		t.Fatalf("unexpected request %s with %v", req.path, req.header)
+	}
+	want := `{"namespace":"docs","vectors":[{"id":"a","metadata":{"content":"alpha","lang":"en"},"values":[1,0]},{"id":"b","values":[0,1]}]}`
+	if got, _ := json.Marshal(req.body); string(got) != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestStore_Query(t *testing.T) {
+	tests := []struct {
+		name   string
+		topK   int
+		sent   float64
+		opts   []vectorstore.QueryOption
+		filter string
+		ids    []string
+	}{
+		{name: "default top k", sent: 10, ids: []string{"a", "b"}},
+		{name: "min score", topK: 5, sent: 5, opts: []vectorstore.QueryOption{vectorstore.WithMinScore(0.5)}, ids: []string{"a"}},
+		{
+			name:   "filter",
+			topK:   5,
+			sent:   5,
+			opts:   []vectorstore.QueryOption{vectorstore.WithFilter(vectorstore.Filter{"lang": "en", "tag": []string{"x", "y"}})},
+			filter: `{"lang":{"$eq":"en"},"tag":{"$in":["x","y"]}}`,
+			ids:    []string{"a", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakePinecone{}
+			store := newTestStore(t, fake, 0)
+			results, err := store.Query(context.Background(), []float32{1, 0}, tt.topK, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body := fake.requests[0].body
+			if body["topK"] != tt.sent || body["namespace"] != "docs" || body["includeMetadata"] != true {
+				t.Fatalf("unexpected query %v", body)
+			}
+			if got, _ := json.Marshal(body["filter"]); tt.filter != "" && string(got) != tt.filter {
+				t.Fatalf("expected the filter %s, got %s", tt.filter, got)
+			}
+			var ids []string
+			for _, res := range results {
+				ids = append(ids, res.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.ids, ",") {
+				t.Fatalf("expected %v, got %v", tt.ids, ids)
+			}
+			first := results[0]
+			if first.Content != "alpha" || first.Metadata["lang"] != "en" || first.Metadata[contentKey] != nil || first.Score != 0.9 || len(first.Vector) != 2 {
+				t.Fatalf("unexpected result %+v", first)
+			}
+		})
+	}
+}
+
+func TestStore_Delete(t *testing.T) {
+	fake := &fakePinecone{}
+	store := newTestStore(t, fake, 0)
+	ctx := context.Background()
+	if err := store.Delete(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, "a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Namespace("archive").DeleteAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var bodies []string
+	for _, req := range fake.requests {
+		b, _ := json.Marshal(req.body)
+		bodies = append(bodies, req.path+" "+string(b))
+	}
+	want := []string{
+		`/vectors/delete {"ids":["a","b"],"namespace":"docs"}`,
+		`/vectors/delete {"deleteAll":true,"namespace":"archive"}`,
+	}
+	if strings.Join(bodies, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected %q, got %q", want, bodies)
+	}
+}
+
+func TestStore_Errors(t *testing.T) {
+	if _, err := NewStore(Config{Host: "docs.pinecone.io"}); err == nil {
+		t.Fatal("expected the API key to be required")
+	}
+	if _, err := NewStore(Config{APIKey: "secret"}); err == nil {
+		t.Fatal("expected the host to be required")
+	}
+	store := newTestStore(t, &fakePinecone{status: http.StatusBadRequest}, 0)
+	_, err := store.Query(context.Background(), []float32{1, 0}, 1)
+	if err == nil || !strings.HasPrefix(err.Error(), "pinecone: /query 400 Bad Request:") || !strings.Contains(err.Error(), "does not match the dimension") {
+		t.Fatalf("expected the status and message to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kratos/blades"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	_ blades.StreamMetricsRecorder = (*Metrics)(nil)
+	_ blades.CallMetricsRecorder   = (*Metrics)(nil)
+)
+
+// Metrics exports the metrics of blades.MeteredProvider to Prometheus:
+// requests, errors, latency, tokens and cost per provider and model, and
+// time-to-first-token and throughput of streams.
+type Metrics struct {
+	requests         *prometheus.CounterVec
+	errors           *prometheus.CounterVec
+	latency          *prometheus.HistogramVec
+	tokens           *prometheus.CounterVec
+	cost             *prometheus.CounterVec
+	timeToFirstToken *prometheus.HistogramVec
+	tokensPerSecond  *prometheus.HistogramVec
+}
+
+// NewMetrics creates the metrics under namespace and registers them with reg.
+func NewMetrics(reg prometheus.Registerer, namespace string) (*Metrics, error) {
+	labels := []string{"provider", "model"}
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "model_requests_total", Help: "Model calls by provider, model and mode.",
+		}, append(labels, "mode")),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "model_errors_total", Help: "Failed model calls by provider, model and error kind.",
+		}, append(labels, "kind")),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "model_request_duration_seconds", Help: "Duration of model calls.",
+			Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+		}, labels),
+		tokens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "model_tokens_total", Help: "Tokens by provider, model and direction (in or out).",
+		}, append(labels, "direction")),
+		cost: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "model_cost_total", Help: "Cost of model calls.",
+		}, labels),
+		timeToFirstToken: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "model_time_to_first_token_seconds", Help: "Time to the first token of streams.",
+			Buckets: []float64{0.1, 0.25, 0.5, 1, 2, 5, 10},
+		}, labels),
+		tokensPerSecond: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "model_output_tokens_per_second", Help: "Output throughput of streams.",
+			Buckets: []float64{5, 10, 20, 40, 80, 160, 320},
+		}, labels),
+	}
+	for _, c := range []prometheus.Collector{m.requests, m.errors, m.latency, m.tokens, m.cost, m.timeToFirstToken, m.tokensPerSecond} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// RecordCall records the request, latency, tokens, cost and error of a call.
+func (m *Metrics) RecordCall(ctx context.Context, c blades.CallMetrics) {
+	mode := "generate"
+	if c.Stream {
+		mode = "stream"
+	}
+	m.requests.WithLabelValues(c.Provider, c.Model, mode).Inc()
+	m.latency.WithLabelValues(c.Provider, c.Model).Observe(c.Duration.Seconds())
+	if c.Err != nil {
+		m.errors.WithLabelValues(c.Provider, c.Model, errorKind(c.Err)).Inc()
+	}
+	if c.Usage != nil {
+		m.tokens.WithLabelValues(c.Provider, c.Model, "in").Add(float64(c.Usage.PromptTokens))
+		m.tokens.WithLabelValues(c.Provider, c.Model, "out").Add(float64(c.Usage.CompletionTokens))
+	}
+	if c.Cost > 0 {
+		m.cost.WithLabelValues(c.Provider, c.Model).Add(c.Cost)
+	}
+}
+
+// RecordStream records the time-to-first-token and throughput of a successful stream.
+func (m *Metrics) RecordStream(ctx context.Context, s blades.StreamMetrics) {
+	if s.Err != nil {
+		return
+	}
+	m.timeToFirstToken.WithLabelValues(s.Provider, s.Model).Observe(s.TimeToFirstToken.Seconds())
+	m.tokensPerSecond.WithLabelValues(s.Provider, s.Model).Observe(s.TokensPerSecond)
+}
+
+// errorKind classifies an error with a bounded set of label values.
+func errorKind(err error) string {
+	var perr *blades.ProviderError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, blades.ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, blades.ErrCircuitOpen):
+		return "circuit_open"
+	case errors.As(err, &perr) && perr.Transient():
+		return "transient"
+	case errors.As(err, &perr):
+		return "provider"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,108 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/blades"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// gather returns the samples of the registry as "name{label=value,...}" keys,
+// with the sample count for histograms.
+func gather(t *testing.T, reg *prometheus.Registry) map[string]float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := make(map[string]float64)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			var labels []string
+			for _, label := range metric.GetLabel() {
+				labels = append(labels, label.GetName()+"="+label.GetValue())
+			}
+			sort.Strings(labels)
+			key := fmt.Sprintf("%s{%s}", family.GetName(), strings.Join(labels, ","))
+			switch {
+			case metric.Counter != nil:
+				samples[key] = metric.GetCounter().GetValue()
+			case metric.Histogram != nil:
+				samples[key] = float64(metric.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+	return samples
+}
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := NewMetrics(reg, "blades")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	m.RecordCall(ctx, blades.CallMetrics{
+		Provider: "openai", Model: "gpt-4o", Duration: time.Second,
+		Usage: &blades.Usage{PromptTokens: 10, CompletionTokens: 4}, Cost: 0.5,
+	})
+	m.RecordCall(ctx, blades.CallMetrics{
+		Provider: "openai", Model: "gpt-4o", Stream: true, Duration: time.Second,
+		Err: &blades.ProviderError{Provider: "openai", StatusCode: 503, Err: errors.New("unavailable")},
+	})
+	m.RecordStream(ctx, blades.StreamMetrics{Provider: "openai", Model: "gpt-4o", TimeToFirstToken: time.Second, TokensPerSecond: 20})
+	m.RecordStream(ctx, blades.StreamMetrics{Provider: "openai", Model: "gpt-4o", Err: errors.New("failed")})
+
+	want := map[string]float64{
+		"blades_model_requests_total{mode=generate,model=gpt-4o,provider=openai}": 1,
+		"blades_model_requests_total{mode=stream,model=gpt-4o,provider=openai}":   1,
+		"blades_model_errors_total{kind=transient,model=gpt-4o,provider=openai}":  1,
+		"blades_model_request_duration_seconds{model=gpt-4o,provider=openai}":     2,
+		"blades_model_tokens_total{direction=in,model=gpt-4o,provider=openai}":    10,
+		"blades_model_tokens_total{direction=out,model=gpt-4o,provider=openai}":   4,
+		"blades_model_cost_total{model=gpt-4o,provider=openai}":                   0.5,
+		"blades_model_time_to_first_token_seconds{model=gpt-4o,provider=openai}":  1,
+		"blades_model_output_tokens_per_second{model=gpt-4o,provider=openai}":     1,
+	}
+	got := gather(t, reg)
+	if len(got) != len(want) {
+		t.Fatalf("expected %d samples, got %v", len(want), got)
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Fatalf("expected %s = %v, got %v", key, value, got[key])
+		}
+	}
+
+	if _, err := NewMetrics(reg, "blades"); err == nil {
+		t.Fatal("expected registering the metrics twice to fail")
+	}
+}
+
+func TestErrorKind(t *testing.T) {
+	tests := []struct {
+		err  error
+		kind string
+	}{
+		{err: context.Canceled, kind: "canceled"},
+		{err: fmt.Errorf("call: %w", context.DeadlineExceeded), kind: "timeout"},
+		{err: blades.ErrRateLimited, kind: "rate_limited"},
+		{err: blades.ErrCircuitOpen, kind: "circuit_open"},
+		{err: &blades.ProviderError{StatusCode: 429}, kind: "transient"},
+		{err: &blades.ProviderError{StatusCode: 400}, kind: "provider"},
+		{err: errors.New("boom"), kind: "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			if kind := errorKind(tt.err); kind != tt.kind {
+				t.Fatalf("expected %q, got %q", tt.kind, kind)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-kratos/blades"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	_ blades.ResponseCache = (*Cache)(nil)
+)
+
+// Cache adapts a Redis client to blades.ResponseCache, expiring entries with Redis TTLs.
+type Cache struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewCache creates a new Cache storing entries under keys starting with prefix.
+func NewCache(client redis.UniversalClient, prefix string) *Cache {
+	return &Cache{client: client, prefix: prefix}
+}
+
+// Get returns the entry for key using GET.
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set stores the entry using SET with an expiration of ttl.
+func (c *Cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, c.prefix+key, value, ttl).Err()
+}
@@ -0,0 +1,37 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	client, fake := newTestClient(t)
+	cache := NewCache(client, "cache:")
+	if _, ok, err := cache.Get(ctx, "k"); ok || err != nil {
+		t.Fatalf("expected a miss, got %v, %v", ok, err)
+	}
+	if err := cache.Set(ctx, "k", []byte("v"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	value, ok, err := cache.Get(ctx, "k")
+	if err != nil || !ok || string(value) != "v" {
+		t.Fatalf("expected a hit, got %q, %v, %v", value, ok, err)
+	}
+	fake.mu.Lock()
+	ttl, commands := fake.ttls["cache:k"], fake.commands
+	fake.fail = map[string]bool{"GET": true}
+	fake.mu.Unlock()
+	if ttl != time.Minute {
+		t.Fatalf("expected the entry to expire after a minute, got %v in %q", ttl, commands)
+	}
+	var rerr redis.Error
+	if _, _, err := cache.Get(ctx, "k"); !errors.As(err, &rerr) {
+		t.Fatalf("expected the Redis error, got %v", err)
+	}
+}
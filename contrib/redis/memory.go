@@ -0,0 +1,103 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/blades"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	_ blades.Memory = (*Memory)(nil)
+)
+
+// MemoryOption configures a Memory.
+type MemoryOption func(*Memory)
+
+// WithMaxMessages keeps only the latest n messages of each session.
+func WithMaxMessages(n int) MemoryOption {
+	return func(m *Memory) {
+		m.maxMessages = n
+	}
+}
+
+// WithTTL expires sessions that have not been written to for ttl.
+func WithTTL(ttl time.Duration) MemoryOption {
+	return func(m *Memory) {
+		m.ttl = ttl
+	}
+}
+
+// Memory is a blades.Memory storing the messages of each session in a Redis list.
+type Memory struct {
+	client      redis.UniversalClient
+	prefix      string
+	maxMessages int
+	ttl         time.Duration
+}
+
+// NewMemory creates a new Memory storing sessions under keys starting with prefix.
+func NewMemory(client redis.UniversalClient, prefix string, opts ...MemoryOption) *Memory {
+	m := &Memory{client: client, prefix: prefix}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// AddMessages appends the messages to the session using RPUSH.
+func (m *Memory) AddMessages(ctx context.Context, sessionID string, messages []*blades.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	values := make([]any, 0, len(messages))
+	for _, msg := range messages {
+		data, err := blades.MarshalMessage(msg)
+		if err != nil {
+			return fmt.Errorf("redis memory: %w", err)
+		}
+		values = append(values, data)
+	}
+	key := m.prefix + sessionID
+	_, err := m.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, key, values...)
+		if m.maxMessages > 0 {
+			pipe.LTrim(ctx, key, int64(-m.maxMessages), -1)
+		}
+		if m.ttl > 0 {
+			pipe.Expire(ctx, key, m.ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("redis memory: append: %w", err)
+	}
+	return nil
+}
+
+// ListMessages returns the messages of the session, oldest first, using LRANGE.
+func (m *Memory) ListMessages(ctx context.Context, sessionID string) ([]*blades.Message, error) {
+	values, err := m.client.LRange(ctx, m.prefix+sessionID, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis memory: list: %w", err)
+	}
+	messages := make([]*blades.Message, 0, len(values))
+	for _, value := range values {
+		msg, err := blades.UnmarshalMessage([]byte(value))
+		if err != nil {
+			return nil, fmt.Errorf("redis memory: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+// Clear deletes the session using DEL.
+func (m *Memory) Clear(ctx context.Context, sessionID string) error {
+	if err := m.client.Del(ctx, m.prefix+sessionID).Err(); err != nil {
+		return fmt.Errorf("redis memory: clear: %w", err)
+	}
+	return nil
+}
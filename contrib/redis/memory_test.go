@@ -0,0 +1,257 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/blades"
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeRedis is a Redis server speaking RESP2 that keeps strings and lists in
+// memory. It implements the commands used by Memory and Cache, records them,
+// and fails the commands listed in fail.
+type fakeRedis struct {
+	fail map[string]bool
+
+	mu       sync.Mutex
+	commands []string
+	strings  map[string]string
+	lists    map[string][]string
+	ttls     map[string]time.Duration
+}
+
+// newTestClient starts a fakeRedis and returns a client connected to it.
+func newTestClient(t *testing.T) (*redis.Client, *fakeRedis) {
+	t.Helper()
+	fake := &fakeRedis{strings: make(map[string]string), lists: make(map[string][]string), ttls: make(map[string]time.Duration)}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go fake.serve(conn)
+		}
+	}()
+	client := redis.NewClient(&redis.Options{Addr: ln.Addr().String(), Protocol: 2, DisableIdentity: true, MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	return client, fake
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+	var queued [][]string
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		switch name := strings.ToUpper(args[0]); {
+		case name == "MULTI":
+			queued = [][]string{}
+			w.WriteString("+OK\r\n")
+		case name == "EXEC":
+			fmt.Fprintf(w, "*%d\r\n", len(queued))
+			for _, cmd := range queued {
+				f.do(w, cmd)
+			}
+			queued = nil
+		case queued != nil:
+			queued = append(queued, args)
+			w.WriteString("+QUEUED\r\n")
+		default:
+			f.do(w, args)
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil || line[0] != '*' {
+		return nil, fmt.Errorf("unexpected %q", line)
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func (f *fakeRedis) do(w *bufio.Writer, args []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	name := strings.ToUpper(args[0])
+	f.commands = append(f.commands, strings.Join(append([]string{name}, args[1:]...), " "))
+	if f.fail[name] {
+		w.WriteString("-ERR injected failure\r\n")
+		return
+	}
+	switch name {
+	case "RPUSH":
+		f.lists[args[1]] = append(f.lists[args[1]], args[2:]...)
+		fmt.Fprintf(w, ":%d\r\n", len(f.lists[args[1]]))
+	case "LTRIM":
+		list := f.lists[args[1]]
+		start, stop := index(args[2], len(list)), index(args[3], len(list))
+		f.lists[args[1]] = append([]string(nil), list[start:stop+1]...)
+		w.WriteString("+OK\r\n")
+	case "LRANGE":
+		list := f.lists[args[1]]
+		fmt.Fprintf(w, "*%d\r\n", len(list))
+		for _, value := range list {
+			fmt.Fprintf(w, "$%d\r\n%s\r\n", len(value), value)
+		}
+	case "EXPIRE":
+		seconds, _ := strconv.Atoi(args[2])
+		f.ttls[args[1]] = time.Duration(seconds) * time.Second
+		w.WriteString(":1\r\n")
+	case "DEL":
+		delete(f.lists, args[1])
+		delete(f.strings, args[1])
+		w.WriteString(":1\r\n")
+	case "GET":
+		value, ok := f.strings[args[1]]
+		if !ok {
+			w.WriteString("$-1\r\n")
+			return
+		}
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(value), value)
+	case "SET":
+		f.strings[args[1]] = args[2]
+		if len(args) == 5 && strings.ToUpper(args[3]) == "PX" {
+			ms, _ := strconv.Atoi(args[4])
+			f.ttls[args[1]] = time.Duration(ms) * time.Millisecond
+		} else if len(args) == 5 && strings.ToUpper(args[3]) == "EX" {
+			seconds, _ := strconv.Atoi(args[4])
+			f.ttls[args[1]] = time.Duration(seconds) * time.Second
+		}
+		w.WriteString("+OK\r\n")
+	default:
+		fmt.Fprintf(w, "-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+// index resolves a list index, counting negative indexes from the end.
+func index(s string, n int) int {
+	i, _ := strconv.Atoi(s)
+	if i < 0 {
+		i += n
+	}
+	return max(0, min(i, n-1))
+}
+
+func TestMemory(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []MemoryOption
+		texts []string
+		ttl   time.Duration
+	}{
+		{name: "default", texts: []string{"1", "2", "3"}},
+		{name: "max messages", opts: []MemoryOption{WithMaxMessages(2)}, texts: []string{"2", "3"}},
+		{name: "ttl", opts: []MemoryOption{WithTTL(time.Hour)}, texts: []string{"1", "2", "3"}, ttl: time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client, fake := newTestClient(t)
+			m := NewMemory(client, "chat:", tt.opts...)
+			if err := m.AddMessages(ctx, "s1", []*blades.Message{blades.UserMessage("1"), blades.AssistantMessage("2")}); err != nil {
+				t.Fatal(err)
+			}
+			if err := m.AddMessages(ctx, "s1", []*blades.Message{blades.UserMessage("3")}); err != nil {
+				t.Fatal(err)
+			}
+			if err := m.AddMessages(ctx, "s1", nil); err != nil {
+				t.Fatal(err)
+			}
+			messages, err := m.ListMessages(ctx, "s1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var texts []string
+			for _, msg := range messages {
+				texts = append(texts, msg.Text())
+			}
+			if strings.Join(texts, ",") != strings.Join(tt.texts, ",") {
+				t.Fatalf("expected %v, got %v", tt.texts, texts)
+			}
+			if last := messages[len(messages)-1]; last.Role != blades.RoleUser {
+				t.Fatalf("expected the roles to be decoded, got %q", last.Role)
+			}
+			fake.mu.Lock()
+			_, stored := fake.lists["chat:s1"]
+			ttl := fake.ttls["chat:s1"]
+			fake.mu.Unlock()
+			if !stored {
+				t.Fatal("expected the session to be stored under the prefix")
+			}
+			if ttl != tt.ttl {
+				t.Fatalf("expected a ttl of %v, got %v", tt.ttl, ttl)
+			}
+			if err := m.Clear(ctx, "s1"); err != nil {
+				t.Fatal(err)
+			}
+			if messages, _ := m.ListMessages(ctx, "s1"); len(messages) != 0 {
+				t.Fatalf("expected the session to be cleared, got %d messages", len(messages))
+			}
+		})
+	}
+}
+
+func TestMemory_Errors(t *testing.T) {
+	ctx := context.Background()
+	client, fake := newTestClient(t)
+	m := NewMemory(client, "chat:")
+	fake.mu.Lock()
+	fake.fail = map[string]bool{"RPUSH": true, "LRANGE": true, "DEL": true}
+	fake.mu.Unlock()
+	var rerr redis.Error
+	if err := m.AddMessages(ctx, "s1", []*blades.Message{blades.UserMessage("lost")}); !errors.As(err, &rerr) || !strings.HasPrefix(err.Error(), "redis memory: append:") {
+		t.Fatalf("expected the append error, got %v", err)
+	}
+	if _, err := m.ListMessages(ctx, "s1"); !errors.As(err, &rerr) || !strings.HasPrefix(err.Error(), "redis memory: list:") {
+		t.Fatalf("expected the list error, got %v", err)
+	}
+	if err := m.Clear(ctx, "s1"); !errors.As(err, &rerr) || !strings.HasPrefix(err.Error(), "redis memory: clear:") {
+		t.Fatalf("expected the clear error, got %v", err)
+	}
+	fake.mu.Lock()
+	fake.fail = nil
+	fake.lists["chat:s1"] = []string{"not json"}
+	fake.mu.Unlock()
+	if _, err := m.ListMessages(ctx, "s1"); err == nil || !strings.HasPrefix(err.Error(), "redis memory:") {
+		t.Fatalf("expected the decoding error, got %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package s3
+
+import (
+	"context"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/go-kratos/blades/document"
+)
+
+var (
+	_ document.Bucket = (*Bucket)(nil)
+)
+
+// Bucket adapts an S3 bucket to document.Bucket.
+type Bucket struct {
+	client *s3.Client
+	name   string
+}
+
+// NewBucket creates a new Bucket for the named S3 bucket.
+func NewBucket(client *s3.Client, name string) *Bucket {
+	return &Bucket{client: client, name: name}
+}
+
+// List lists objects under prefix after startAfter using ListObjectsV2.
+func (b *Bucket) List(ctx context.Context, prefix, startAfter string, limit int) ([]document.Object, error) {
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(b.name),
+		Prefix: aws.String(prefix),
+	}
+	if startAfter != "" {
+		input.StartAfter = aws.String(startAfter)
+	}
+	if limit > 0 {
+		input.MaxKeys = aws.Int32(int32(min(limit, 1000)))
+	}
+	out, err := b.client.ListObjectsV2(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]document.Object, 0, len(out.Contents))
+	for _, obj := range out.Contents {
+		objects = append(objects, document.Object{
+			Key:     aws.ToString(obj.Key),
+			Size:    aws.ToInt64(obj.Size),
+			ModTime: aws.ToTime(obj.LastModified),
+		})
+	}
+	return objects, nil
+}
+
+// Open streams the object body.
+func (b *Bucket) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := b.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.name),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
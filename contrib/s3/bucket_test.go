@@ -0,0 +1,116 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const listResult = `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Name>docs</Name>
+  <Prefix>guides/</Prefix>
+  <KeyCount>2</KeyCount>
+  <IsTruncated>false</IsTruncated>
+  <Contents><Key>guides/a.md</Key><Size>5</Size><LastModified>2025-01-01T10:00:00.000Z</LastModified></Contents>
+  <Contents><Key>guides/b.md</Key><Size>7</Size><LastModified>2025-01-02T10:00:00.000Z</LastModified></Contents>
+</ListBucketResult>`
+
+// fakeS3 serves ListObjectsV2 and GetObject for the bucket "docs" in path
+// style, recording the list queries.
+type fakeS3 struct {
+	mu      sync.Mutex
+	queries []url.Values
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/docs" && r.URL.Query().Get("list-type") == "2":
+		f.mu.Lock()
+		f.queries = append(f.queries, r.URL.Query())
+		f.mu.Unlock()
+		w.Header().Set("Content-Type", "application/xml")
+		io.WriteString(w, listResult)
+	case r.URL.Path == "/docs/guides/a.md":
+		io.WriteString(w, "hello")
+	default:
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+	}
+}
+
+func newTestBucket(t *testing.T) (*Bucket, *fakeS3) {
+	t.Helper()
+	fake := &fakeS3{}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(srv.URL),
+		UsePathStyle: true,
+		Credentials:  aws.AnonymousCredentials{},
+	})
+	return NewBucket(client, "docs"), fake
+}
+
+func TestBucket_List(t *testing.T) {
+	tests := []struct {
+		name       string
+		startAfter string
+		limit      int
+		query      url.Values
+	}{
+		{name: "first page", limit: 2, query: url.Values{"prefix": {"guides/"}, "max-keys": {"2"}}},
+		{name: "after a key", startAfter: "guides/0.md", query: url.Values{"prefix": {"guides/"}, "start-after": {"guides/0.md"}}},
+		{name: "capped limit", limit: 5000, query: url.Values{"prefix": {"guides/"}, "max-keys": {"1000"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket, fake := newTestBucket(t)
+			objects, err := bucket.List(context.Background(), "guides/", tt.startAfter, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := fake.queries[0]
+			for _, key := range []string{"prefix", "start-after", "max-keys"} {
+				if got.Get(key) != tt.query.Get(key) {
+					t.Fatalf("expected %s=%q, got %q", key, tt.query.Get(key), got.Get(key))
+				}
+			}
+			if len(objects) != 2 {
+				t.Fatalf("expected 2 objects, got %d", len(objects))
+			}
+			want := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+			if objects[0].Key != "guides/a.md" || objects[0].Size != 5 || !objects[0].ModTime.Equal(want) {
+				t.Fatalf("unexpected object %+v", objects[0])
+			}
+		})
+	}
+}
+
+func TestBucket_Open(t *testing.T) {
+	bucket, _ := newTestBucket(t)
+	r, err := bucket.Open(context.Background(), "guides/a.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if b, _ := io.ReadAll(r); string(b) != "hello" {
+		t.Fatalf("expected the object content, got %q", b)
+	}
+	var missing *types.NoSuchKey
+	if _, err := bucket.Open(context.Background(), "guides/missing.md"); !errors.As(err, &missing) {
+		t.Fatalf("expected the S3 error to be returned, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/go-kratos/blades"
+)
+
+var (
+	_ blades.Memory = (*Memory)(nil)
+)
+
+// Option configures a Memory.
+type Option func(*Memory)
+
+// WithTable sets the table storing the messages, "blades_messages" by default.
+func WithTable(table string) Option {
+	return func(m *Memory) {
+		m.table = table
+	}
+}
+
+// WithMaxMessages keeps only the latest n messages of each session.
+func WithMaxMessages(n int) Option {
+	return func(m *Memory) {
+		m.maxMessages = n
+	}
+}
+
+// Memory is a blades.Memory storing messages in a SQLite table keyed by session ID.
+// The database is opened by the caller with the driver of their choice.
+type Memory struct {
+	db          *sql.DB
+	table       string
+	maxMessages int
+}
+
+// NewMemory creates a new Memory, creating its table if it does not exist.
+func NewMemory(ctx context.Context, db *sql.DB, opts ...Option) (*Memory, error) {
+	m := &Memory{db: db, table: "blades_messages"}
+	for _, opt := range opts {
+		opt(m)
+	}
+	schema := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	session_id TEXT NOT NULL,
+	message TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS %[1]s_session_id ON %[1]s (session_id, id);`, m.table)
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		return nil, fmt.Errorf("sqlite memory: create table: %w", err)
+	}
+	return m, nil
+}
+
+// AddMessages appends the messages to the session in a transaction.
+func (m *Memory) AddMessages(ctx context.Context, sessionID string, messages []*blades.Message) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sqlite memory: begin: %w", err)
+	}
+	defer tx.Rollback()
+	insert := fmt.Sprintf("INSERT INTO %s (session_id, message) VALUES (?, ?)", m.table)
+	for _, msg := range messages {
+		data, err := blades.MarshalMessage(msg)
+		if err != nil {
+			return fmt.Errorf("sqlite memory: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, insert, sessionID, string(data)); err != nil {
+			return fmt.Errorf("sqlite memory: insert: %w", err)
+		}
+	}
+	if m.maxMessages > 0 {
+		trim := fmt.Sprintf(`DELETE FROM %[1]s WHERE session_id = ? AND id NOT IN (
+	SELECT id FROM %[1]s WHERE session_id = ? ORDER BY id DESC LIMIT ?)`, m.table)
+		if _, err := tx.ExecContext(ctx, trim, sessionID, sessionID, m.maxMessages); err != nil {
+			return fmt.Errorf("sqlite memory: trim: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("sqlite memory: commit: %w", err)
+	}
+	return nil
+}
+
+// ListMessages returns the messages of the session, oldest first.
+func (m *Memory) ListMessages(ctx context.Context, sessionID string) ([]*blades.Message, error) {
+	rows, err := m.db.QueryContext(ctx, fmt.Sprintf("SELECT message FROM %s WHERE session_id = ? ORDER BY id", m.table), sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite memory: query: %w", err)
+	}
+	defer rows.Close()
+	var messages []*blades.Message
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("sqlite memory: scan: %w", err)
+		}
+		msg, err := blades.UnmarshalMessage([]byte(data))
+		if err != nil {
+			return nil, fmt.Errorf("sqlite memory: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite memory: query: %w", err)
+	}
+	return messages, nil
+}
+
+// Clear deletes the messages of the session.
+func (m *Memory) Clear(ctx context.Context, sessionID string) error {
+	if _, err := m.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE session_id = ?", m.table), sessionID); err != nil {
+		return fmt.Errorf("sqlite memory: clear: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-kratos/blades"
+)
+
+// fakeDB is a database/sql driver keeping the rows of the messages table in
+// memory. It understands the statements of Memory, records them, and fails the
+// statements starting with a key of fail, and BEGIN and COMMIT when keyed.
+type fakeDB struct {
+	fail map[string]error
+
+	mu         sync.Mutex
+	statements []string
+	rows       []fakeRow
+	nextID     int
+}
+
+type fakeRow struct {
+	id      int
+	session string
+	message string
+	null    bool
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: db}, nil }
+func (db *fakeDB) Driver() driver.Driver                        { return nil }
+
+func (db *fakeDB) exec(query string, args []driver.Value) (*fakeRows, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.statements = append(db.statements, query)
+	if err := db.failure(query); err != nil {
+		return nil, err
+	}
+	switch {
+	case strings.HasPrefix(query, "CREATE TABLE"):
+	case strings.HasPrefix(query, "INSERT INTO"):
+		db.nextID++
+		db.rows = append(db.rows, fakeRow{id: db.nextID, session: args[0].(string), message: args[1].(string)})
+	case strings.HasPrefix(query, "DELETE") && strings.Contains(query, "NOT IN"):
+		keep := int(args[2].(int64))
+		var rows []fakeRow
+		for i := range db.rows {
+			if db.rows[i].session != args[0] || db.count(args[0].(string), i) < keep {
+				rows = append(rows, db.rows[i])
+			}
+		}
+		db.rows = rows
+	case strings.HasPrefix(query, "DELETE"):
+		var rows []fakeRow
+		for _, row := range db.rows {
+			if row.session != args[0] {
+				rows = append(rows, row)
+			}
+		}
+		db.rows = rows
+	case strings.HasPrefix(query, "SELECT message"):
+		res := &fakeRows{}
+		for _, row := range db.rows {
+			switch {
+			case row.session != args[0]:
+			case row.null:
+				res.values = append(res.values, nil)
+			default:
+				res.values = append(res.values, row.message)
+			}
+		}
+		return res, nil
+	default:
+		return nil, fmt.Errorf("unexpected statement %q", query)
+	}
+	return nil, nil
+}
+
+// failure returns the error of the first key of fail prefixing query.
+func (db *fakeDB) failure(query string) error {
+	for prefix, err := range db.fail {
+		if strings.HasPrefix(query, prefix) {
+			return err
+		}
+	}
+	return nil
+}
+
+// count returns the number of rows of the session after row i.
+func (db *fakeDB) count(session string, i int) int {
+	n := 0
+	for _, row := range db.rows[i+1:] {
+		if row.session == session {
+			n++
+		}
+	}
+	return n
+}
+
+type fakeConn struct {
+	db       *fakeDB
+	snapshot []fakeRow
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+func (c *fakeConn) Close() error { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	if err := c.db.failure("BEGIN"); err != nil {
+		return nil, err
+	}
+	c.snapshot = append([]fakeRow(nil), c.db.rows...)
+	return c, nil
+}
+func (c *fakeConn) Commit() error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	return c.db.failure("COMMIT")
+}
+func (c *fakeConn) Rollback() error {
+	c.db.mu.Lock()
+	c.db.rows = c.snapshot
+	c.db.mu.Unlock()
+	return nil
+}
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	_, err := s.db.exec(s.query, args)
+	return driver.RowsAffected(1), err
+}
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.db.exec(s.query, args)
+}
+
+type fakeRows struct {
+	values []driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return []string{"message"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+func newTestMemory(t *testing.T, fake *fakeDB, opts ...Option) *Memory {
+	t.Helper()
+	db := sql.OpenDB(fake)
+	t.Cleanup(func() { db.Close() })
+	m, err := NewMemory(context.Background(), db, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMemory(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []Option
+		table string
+		texts []string
+	}{
+		{name: "default", table: "blades_messages", texts: []string{"1", "2", "3"}},
+		{name: "table", opts: []Option{WithTable("chat_history")}, table: "chat_history", texts: []string{"1", "2", "3"}},
+		{name: "max messages", opts: []Option{WithMaxMessages(2)}, table: "blades_messages", texts: []string{"2", "3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			fake := &fakeDB{}
+			m := newTestMemory(t, fake, tt.opts...)
+			if err := m.AddMessages(ctx, "s1", []*blades.Message{blades.UserMessage("1"), blades.AssistantMessage("2")}); err != nil {
+				t.Fatal(err)
+			}
+			if err := m.AddMessages(ctx, "s1", []*blades.Message{blades.UserMessage("3")}); err != nil {
+				t.Fatal(err)
+			}
+			if err := m.AddMessages(ctx, "s2", []*blades.Message{blades.UserMessage("other")}); err != nil {
+				t.Fatal(err)
+			}
+			messages, err := m.ListMessages(ctx, "s1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var texts []string
+			for _, msg := range messages {
+				texts = append(texts, msg.Text())
+			}
+			if strings.Join(texts, ",") != strings.Join(tt.texts, ",") {
+				t.Fatalf("expected %v, got %v", tt.texts, texts)
+			}
+			if last := messages[len(messages)-1]; last.Role != blades.RoleUser {
+				t.Fatalf("expected the roles to be decoded, got %q", last.Role)
+			}
+			for _, stmt := range fake.statements {
+				if !strings.Contains(stmt, tt.table) || strings.Contains(stmt, "$1") {
+					t.Fatalf("expected statements on %s with ? placeholders, got %q", tt.table, stmt)
+				}
+			}
+			if err := m.Clear(ctx, "s1"); err != nil {
+				t.Fatal(err)
+			}
+			if messages, _ := m.ListMessages(ctx, "s1"); len(messages) != 0 {
+				t.Fatalf("expected the session to be cleared, got %d messages", len(messages))
+			}
+			if messages, _ := m.ListMessages(ctx, "s2"); len(messages) != 1 {
+				t.Fatalf("expected other sessions to be kept, got %d messages", len(messages))
+			}
+		})
+	}
+}
+
+func TestMemory_Errors(t *testing.T) {
+	ctx := context.Background()
+	failure := errors.New("disk I/O error")
+	if _, err := NewMemory(ctx, sql.OpenDB(&fakeDB{fail: map[string]error{"CREATE": failure}})); !errors.Is(err, failure) || !strings.HasPrefix(err.Error(), "sqlite memory: create table:") {
+		t.Fatalf("expected the create table error, got %v", err)
+	}
+
+	fake := &fakeDB{}
+	m := newTestMemory(t, fake)
+	if err := m.AddMessages(ctx, "s1", []*blades.Message{blades.UserMessage("kept")}); err != nil {
+		t.Fatal(err)
+	}
+	fake.fail = map[string]error{"INSERT": failure, "SELECT": failure}
+	if err := m.AddMessages(ctx, "s1", []*blades.Message{blades.UserMessage("lost")}); !errors.Is(err, failure) || !strings.HasPrefix(err.Error(), "sqlite memory: insert:") {
+		t.Fatalf("expected the insert error, got %v", err)
+	}
+	if _, err := m.ListMessages(ctx, "s1"); !errors.Is(err, failure) || !strings.HasPrefix(err.Error(), "sqlite memory: query:") {
+		t.Fatalf("expected the query error, got %v", err)
+	}
+	fake.fail = nil
+	if messages, _ := m.ListMessages(ctx, "s1"); len(messages) != 1 || messages[0].Text() != "kept" {
+		t.Fatalf("expected the failed transaction to be rolled back, got %d messages", len(messages))
+	}
+
+	fake.rows = append(fake.rows, fakeRow{session: "s1", message: "not json"})
+	if _, err := m.ListMessages(ctx, "s1"); err == nil || !strings.HasPrefix(err.Error(), "sqlite memory:") {
+		t.Fatalf("expected the decoding error, got %v", err)
+	}
+	fake.rows = append(fake.rows, fakeRow{session: "s2", null: true})
+	if _, err := m.ListMessages(ctx, "s2"); err == nil || !strings.HasPrefix(err.Error(), "sqlite memory: scan:") {
+		t.Fatalf("expected the scan error, got %v", err)
+	}
+
+	tests := []struct {
+		fail   string
+		prefix string
+		call   func() error
+	}{
+		{"BEGIN", "sqlite memory: begin:", func() error { return m.AddMessages(ctx, "s3", []*blades.Message{blades.UserMessage("hi")}) }},
+		{"COMMIT", "sqlite memory: commit:", func() error { return m.AddMessages(ctx, "s3", []*blades.Message{blades.UserMessage("hi")}) }},
+		{"DELETE", "sqlite memory: clear:", func() error { return m.Clear(ctx, "s3") }},
+	}
+	for _, tt := range tests {
+		fake.fail = map[string]error{tt.fail: failure}
+		if err := tt.call(); !errors.Is(err, failure) || !strings.HasPrefix(err.Error(), tt.prefix) {
+			t.Fatalf("expected the %s error, got %v", tt.fail, err)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package weaviate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/vectorstore"
+	"github.com/google/uuid"
+)
+
+var (
+	_ vectorstore.VectorStore    = (*Store)(nil)
+	_ vectorstore.HybridSearcher = (*Store)(nil)
+)
+
+// idNamespace derives the UUIDs of Weaviate objects from record IDs.
+var idNamespace = uuid.MustParse("6f1c2a3e-8d4b-4b7a-9c1e-2f3a4b5c6d7e")
+
+// Config configures a Weaviate store.
+type Config struct {
+	// Host is the Weaviate endpoint, e.g. "http://localhost:8080". It is required.
+	Host string
+	// APIKey authenticates the requests, if the instance requires it.
+	APIKey string
+	// Class is the collection storing the records, e.g. "Document". It is required.
+	Class string
+	// HTTPClient sends the requests. When nil a client on blades.SharedTransport
+	// with a 30 seconds timeout is used.
+	HTTPClient *http.Client
+}
+
+// Store implements vectorstore.VectorStore on a Weaviate collection, and
+// vectorstore.HybridSearcher with Weaviate hybrid (vector and BM25) search on
+// the content of the records. Record IDs are mapped to UUIDs and kept in the
+// "recordId" property. Metadata is stored as properties, to be filtered on,
+// and as JSON in the "metadataJson" property, to be returned.
+type Store struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+	class   string
+}
+
+// NewStore creates a new Store from the config.
+func NewStore(config Config) (*Store, error) {
+	if config.Host == "" {
+		return nil, errors.New("weaviate: host is required")
+	}
+	if config.Class == "" {
+		return nil, errors.New("weaviate: class is required")
+	}
+	client := config.HTTPClient
+	if client == nil {
+		client = blades.NewHTTPClient(blades.HTTPTimeout(30 * time.Second))
+	}
+	return &Store{
+		client:  client,
+		baseURL: strings.TrimSuffix(config.Host, "/"),
+		apiKey:  config.APIKey,
+		class:   config.Class,
+	}, nil
+}
+
+type object struct {
+	Class      string         `json:"class"`
+	ID         string         `json:"id"`
+	Properties map[string]any `json:"properties"`
+	Vector     []float32      `json:"vector,omitempty"`
+}
+
+// Upsert writes the records with a batch request.
+func (s *Store) Upsert(ctx context.Context, records ...vectorstore.Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	objects := make([]object, 0, len(records))
+	for _, record := range records {
+		metadata, err := json.Marshal(record.Metadata)
+		if err != nil {
+			return fmt.Errorf("weaviate: metadata of %s: %w", record.ID, err)
+		}
+		properties := make(map[string]any, len(record.Metadata)+3)
+		for key, value := range record.Metadata {
+			properties[key] = value
+		}
+		properties["recordId"] = record.ID
+		properties["content"] = record.Content
+		properties["metadataJson"] = string(metadata)
+		objects = append(objects, object{Class: s.class, ID: objectID(record.ID), Properties: properties, Vector: record.Vector})
+	}
+	var res []struct {
+		Result struct {
+			Errors *struct {
+				Error []struct {
+					Message string `json:"message"`
+				} `json:"error"`
+			} `json:"errors"`
+		} `json:"result"`
+	}
+	if err := s.do(ctx, http.MethodPost, "/v1/batch/objects", map[string]any{"objects": objects}, &res); err != nil {
+		return err
+	}
+	var errs []error
+	for i, r := range res {
+		if r.Result.Errors == nil {
+			continue
+		}
+		for _, e := range r.Result.Errors.Error {
+			errs = append(errs, fmt.Errorf("weaviate: record %s: %s", records[i].ID, e.Message))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Query returns the topK records nearest to vector.
+func (s *Store) Query(ctx context.Context, vector []float32, topK int, opts ...vectorstore.QueryOption) ([]vectorstore.Result, error) {
+	return s.get(ctx, "nearVector", map[string]any{"vector": vector}, topK, opts)
+}
+
+// HybridQuery returns the topK records ranked by Weaviate hybrid search,
+// fusing BM25 relevance of the content to query with similarity to vector.
+// WithAlpha weighs them, 0.75 by default. A nil vector lets Weaviate vectorize
+// the query when the collection has a vectorizer.
+func (s *Store) HybridQuery(ctx context.Context, query string, vector []float32, topK int, opts ...vectorstore.QueryOption) ([]vectorstore.Result, error) {
+	args := map[string]any{"query": query}
+	if vector != nil {
+		args["vector"] = vector
+	}
+	var opt vectorstore.QueryOptions
+	for _, apply := range opts {
+		apply(&opt)
+	}
+	if opt.Alpha != nil {
+		args["alpha"] = *opt.Alpha
+	}
+	return s.get(ctx, "hybrid", args, topK, opts)
+}
+
+// Delete removes the records with the given IDs.
+func (s *Store) Delete(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	body := map[string]any{"match": map[string]any{
+		"class": s.class,
+		"where": map[string]any{"path": []string{"recordId"}, "operator": "ContainsAny", "valueTextArray": ids},
+	}}
+	return s.do(ctx, http.MethodDelete, "/v1/batch/objects", body, nil)
+}
+
+// get runs a GraphQL Get query with the search operator and its arguments.
+func (s *Store) get(ctx context.Context, operator string, args map[string]any, topK int, opts []vectorstore.QueryOption) ([]vectorstore.Result, error) {
+	var opt vectorstore.QueryOptions
+	for _, apply := range opts {
+		apply(&opt)
+	}
+	if topK <= 0 {
+		topK = 10
+	}
+	params := fmt.Sprintf("%s: %s, limit: %d", operator, gql(args), topK)
+	if where := toWhere(opt.Filter); where != nil {
+		params += ", where: " + gql(where)
+	}
+	query := fmt.Sprintf("{ Get { %s(%s) { recordId content metadataJson _additional { distance score vector } } } }", s.class, params)
+	var res struct {
+		Data struct {
+			Get map[string][]struct {
+				RecordID     string `json:"recordId"`
+				Content      string `json:"content"`
+				MetadataJSON string `json:"metadataJson"`
+				Additional   struct {
+					Distance *float64  `json:"distance"`
+					Score    string    `json:"score"`
+					Vector   []float32 `json:"vector"`
+				} `json:"_additional"`
+			} `json:"Get"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := s.do(ctx, http.MethodPost, "/v1/graphql", map[string]string{"query": query}, &res); err != nil {
+		return nil, err
+	}
+	if len(res.Errors) > 0 {
+		return nil, fmt.Errorf("weaviate: %s", res.Errors[0].Message)
+	}
+	var results []vectorstore.Result
+	for _, hit := range res.Data.Get[s.class] {
+		result := vectorstore.Result{Record: vectorstore.Record{ID: hit.RecordID, Content: hit.Content, Vector: hit.Additional.Vector}}
+		if hit.MetadataJSON != "" && hit.MetadataJSON != "null" {
+			if err := json.Unmarshal([]byte(hit.MetadataJSON), &result.Metadata); err != nil {
+				return nil, fmt.Errorf("weaviate: metadata of %s: %w", hit.RecordID, err)
+			}
+		}
+		if hit.Additional.Distance != nil {
+			result.Score = 1 - *hit.Additional.Distance
+		} else if score, err := strconv.ParseFloat(hit.Additional.Score, 64); err == nil {
+			result.Score = score
+		}
+		if result.Score < opt.MinScore {
+			continue
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// toWhere translates a filter to a Weaviate where filter.
+func toWhere(filter vectorstore.Filter) map[string]any {
+	if len(filter) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(filter))
+	for key := range filter {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	operands := make([]any, 0, len(keys))
+	for _, key := range keys {
+		operand := map[string]any{"path": []string{key}}
+		switch v := filter[key].(type) {
+		case string:
+			operand["operator"], operand["valueText"] = enum("Equal"), v
+		case bool:
+			operand["operator"], operand["valueBoolean"] = enum("Equal"), v
+		case int, int32, int64:
+			operand["operator"], operand["valueInt"] = enum("Equal"), v
+		case float32, float64:
+			operand["operator"], operand["valueNumber"] = enum("Equal"), v
+		case []string:
+			operand["operator"], operand["valueTextArray"] = enum("ContainsAny"), v
+		case []any:
+			operand["operator"], operand["valueTextArray"] = enum("ContainsAny"), v
+		default:
+			operand["operator"], operand["valueText"] = enum("Equal"), fmt.Sprint(v)
+		}
+		operands = append(operands, operand)
+	}
+	if len(operands) == 1 {
+		return operands[0].(map[string]any)
+	}
+	return map[string]any{"operator": enum("And"), "operands": operands}
+}
+
+// enum is a GraphQL enum value, written unquoted.
+type enum string
+
+// gql encodes a value as a GraphQL input literal.
+func gql(v any) string {
+	switch v := v.(type) {
+	case enum:
+		return string(v)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fields := make([]string, 0, len(keys))
+		for _, key := range keys {
+			fields = append(fields, key+": "+gql(v[key]))
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, gql(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+func objectID(id string) string {
+	return uuid.NewSHA1(idNamespace, []byte(id)).String()
+}
+
+func (s *Store) do(ctx context.Context, method, path string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("weaviate: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 4<<10))
+		return fmt.Errorf("weaviate: %s %s: %s", path, res.Status, bytes.TrimSpace(msg))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
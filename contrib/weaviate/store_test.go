@@ -0,0 +1,208 @@
+package weaviate
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-kratos/blades/vectorstore"
+)
+
+// request is a request received by fakeWeaviate.
+type request struct {
+	method string
+	path   string
+	header http.Header
+	body   map[string]any
+}
+
+// fakeWeaviate serves batch and GraphQL requests with the given responses,
+// recording the requests.
+type fakeWeaviate struct {
+	status int
+	batch  string
+	graph  string
+
+	mu       sync.Mutex
+	requests []request
+}
+
+func (f *fakeWeaviate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]any
+	if json.NewDecoder(r.Body).Decode(&body) != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	f.requests = append(f.requests, request{method: r.Method, path: r.URL.Path, header: r.Header.Clone(), body: body})
+	f.mu.Unlock()
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+		io.WriteString(w, `{"error": [{"message": "class Document not found"}]}`)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/v1/graphql":
+		io.WriteString(w, f.graph)
+	case r.Method == http.MethodPost:
+		io.WriteString(w, f.batch)
+	default:
+		io.WriteString(w, `{}`)
+	}
+}
+
+func newTestStore(t *testing.T, fake *fakeWeaviate) *Store {
+	t.Helper()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	store, err := NewStore(Config{Host: srv.URL + "/", APIKey: "secret", Class: "Document"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestStore_Upsert(t *testing.T) {
+	fake := &fakeWeaviate{batch: `[{"result": {}}, {"result": {"errors": {"error": [{"message": "invalid vector"}]}}}]`}
+	store := newTestStore(t, fake)
+	err := store.Upsert(context.Background(),
+		vectorstore.Record{ID: "a", Content: "alpha", Vector: []float32{1, 0}, Metadata: map[string]any{"lang": "en"}},
+		vectorstore.Record{ID: "b", Content: "beta"},
+	)
+	if err == nil || err.Error() != "weaviate: record b: invalid vector" {
+		t.Fatalf("expected the error of the failed object, got %v", err)
+	}
+	req := fake.requests[0]
+	if req.method != http.MethodPost || req.path != "/v1/batch/objects" || req.header.Get("Authorization") != "Bearer secret" {
+		t.Fatalf("unexpected request %s %s with %v", req.method, req.path, req.header)
+	}
+	want := `{"objects":[` +
+		`{"class":"Document","id":"` + objectID("a") + `","properties":{"content":"alpha","lang":"en","metadataJson":"{\"lang\":\"en\"}","recordId":"a"},"vector":[1,0]},` +
+		`{"class":"Document","id":"` + objectID("b") + `","properties":{"content":"beta","metadataJson":"null","recordId":"b"}}]}`
+	if got, _ := json.Marshal(req.body); string(got) != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestStore_Query(t *testing.T) {
+	const hits = `{"data": {"Get": {"Document": [
+		{"recordId": "a", "content": "alpha", "metadataJson": "{\"lang\": \"en\"}", "_additional": {"distance": 0.1, "score": "", "vector": [1, 0]}},
+		{"recordId": "b", "content": "beta", "metadataJson": "null", "_additional": {"distance": 0.7}}
+	]}}}`
+	const hybridHits = `{"data": {"Get": {"Document": [
+		{"recordId": "b", "content": "beta", "metadataJson": "null", "_additional": {"score": "0.8"}}
+	]}}}`
+	tests := []struct {
+		name   string
+		graph  string
+		search func(s *Store) ([]vectorstore.Result, error)
+		query  string
+		ids    []string
+	}{
+		{
+			name:  "near vector",
+			graph: hits,
+			search: func(s *Store) ([]vectorstore.Result, error) {
+				return s.Query(context.Background(), []float32{1, 0}, 0)
+			},
+			query: `{ Get { Document(nearVector: {vector: [1,0]}, limit: 10) { recordId content metadataJson _additional { distance score vector } } } }`,
+			ids:   []string{"a", "b"},
+		},
+		{
+			name:  "filter and min score",
+			graph: hits,
+			search: func(s *Store) ([]vectorstore.Result, error) {
+				return s.Query(context.Background(), []float32{1, 0}, 3,
+					vectorstore.WithFilter(vectorstore.Filter{"lang": "en", "tags": []string{"x"}}), vectorstore.WithMinScore(0.5))
+			},
+			query: `{ Get { Document(nearVector: {vector: [1,0]}, limit: 3, where: {operands: [` +
+				`{operator: Equal, path: ["lang"], valueText: "en"}, {operator: ContainsAny, path: ["tags"], valueTextArray: ["x"]}` +
+				`], operator: And}) { recordId content metadataJson _additional { distance score vector } } } }`,
+			ids: []string{"a"},
+		},
+		{
+			name:  "hybrid",
+			graph: hybridHits,
+			search: func(s *Store) ([]vectorstore.Result, error) {
+				return s.HybridQuery(context.Background(), "beta", nil, 5, vectorstore.WithAlpha(0.5), vectorstore.WithFilter(vectorstore.Filter{"year": 2024}))
+			},
+			query: `{ Get { Document(hybrid: {alpha: 0.5, query: "beta"}, limit: 5, where: {operator: Equal, path: ["year"], valueInt: 2024}) { recordId content metadataJson _additional { distance score vector } } } }`,
+			ids:   []string{"b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeWeaviate{graph: tt.graph}
+			results, err := tt.search(newTestStore(t, fake))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if query := fake.requests[0].body["query"]; query != tt.query {
+				t.Fatalf("expected the query\n%s\ngot\n%s", tt.query, query)
+			}
+			var ids []string
+			for _, res := range results {
+				ids = append(ids, res.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.ids, ",") {
+				t.Fatalf("expected %v, got %v", tt.ids, ids)
+			}
+		})
+	}
+
+	fake := &fakeWeaviate{graph: hits}
+	results, err := newTestStore(t, fake).Query(context.Background(), []float32{1, 0}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := results[0]
+	if first.Content != "alpha" || first.Metadata["lang"] != "en" || first.Score != 0.9 || len(first.Vector) != 2 || results[1].Metadata != nil {
+		t.Fatalf("unexpected results %+v", results)
+	}
+}
+
+func TestStore_Delete(t *testing.T) {
+	fake := &fakeWeaviate{}
+	store := newTestStore(t, fake)
+	if err := store.Delete(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(context.Background(), "a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.requests) != 1 || fake.requests[0].method != http.MethodDelete {
+		t.Fatalf("expected a single batch delete, got %+v", fake.requests)
+	}
+	want := `{"match":{"class":"Document","where":{"operator":"ContainsAny","path":["recordId"],"valueTextArray":["a","b"]}}}`
+	if got, _ := json.Marshal(fake.requests[0].body); string(got) != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestStore_Errors(t *testing.T) {
+	if _, err := NewStore(Config{Class: "Document"}); err == nil {
+		t.Fatal("expected the host to be required")
+	}
+	if _, err := NewStore(Config{Host: "http://localhost:8080"}); err == nil {
+		t.Fatal("expected the class to be required")
+	}
+	ctx := context.Background()
+	store := newTestStore(t, &fakeWeaviate{status: http.StatusUnprocessableEntity})
+	if err := store.Upsert(ctx, vectorstore.Record{ID: "a"}); err == nil || !strings.HasPrefix(err.Error(), "weaviate: /v1/batch/objects 422 Unprocessable Entity:") || !strings.Contains(err.Error(), "class Document not found") {
+		t.Fatalf("expected the status and message to be reported, got %v", err)
+	}
+	store = newTestStore(t, &fakeWeaviate{graph: `{"errors": [{"message": "Cannot query field \"Document\""}]}`})
+	if _, err := store.Query(ctx, []float32{1}, 1); err == nil || err.Error() != `weaviate: Cannot query field "Document"` {
+		t.Fatalf("expected the GraphQL error, got %v", err)
+	}
+	store = newTestStore(t, &fakeWeaviate{graph: `{"data": {"Get": {"Document": [{"recordId": "a", "metadataJson": "{"}]}}}`})
+	if _, err := store.Query(ctx, []float32{1}, 1); err == nil || !strings.HasPrefix(err.Error(), "weaviate: metadata of a:") {
+		t.Fatalf("expected the metadata error, got %v", err)
+	}
+}
@@ -1,13 +1,17 @@
 package zeus
 
 import (
+	"bufio"
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/go-kratos/blades"
@@ -15,78 +19,212 @@ import (
 
 // ChatProvider implements blades.ModelProvider for Zeus API.
 type ChatProvider struct {
-	client      *http.Client
-	apiKey      string
-	baseURL     string
-	pipelineID  string
+	client     *http.Client
+	apiKey     string
+	baseURL    string
+	pipelineID string
+}
+
+// Config configures a Zeus provider.
+type Config struct {
+	// APIKey authenticates the requests. It is required.
+	APIKey string
+	// BaseURL is the API base URL, https://api.zeusllm.com/v1 by default.
+	BaseURL string
+	// PipelineID selects the Zeus pipeline. It is required.
+	PipelineID string
+	// HTTPClient sends the requests. When nil a client on blades.SharedTransport
+	// with Timeout is used.
+	HTTPClient *http.Client
+	// Timeout bounds each request when HTTPClient is nil, 30 seconds by default.
+	Timeout time.Duration
+	// Hooks set custom headers on the requests and inspect their raw bodies.
+	Hooks *blades.HTTPHooks
 }
 
 // NewChatProvider constructs a Zeus provider. The API key is read from
 // the ZEUS_API_KEY environment variable. The pipeline ID is read from
-// ZEUS_PIPELINE_ID environment variable.
+// ZEUS_PIPELINE_ID environment variable. It panics if either is missing;
+// use NewChatProviderWithConfig to handle configuration errors.
 func NewChatProvider() blades.ModelProvider {
-	apiKey := os.Getenv("ZEUS_API_KEY")
-	if apiKey == "" {
-		panic("ZEUS_API_KEY environment variable is required for Zeus provider")
+	provider, err := NewChatProviderWithConfig(Config{
+		APIKey:     os.Getenv("ZEUS_API_KEY"),
+		BaseURL:    os.Getenv("ZEUS_BASE_URL"),
+		PipelineID: os.Getenv("ZEUS_PIPELINE_ID"),
+	})
+	if err != nil {
+		panic(err)
+	}
+	return provider
+}
+
+// NewChatProviderWithConfig constructs a Zeus provider from the config.
+func NewChatProviderWithConfig(config Config) (blades.ModelProvider, error) {
+	if config.APIKey == "" {
+		return nil, errors.New("zeus: API key is required")
 	}
-	
-	baseURL := os.Getenv("ZEUS_BASE_URL")
-	if baseURL == "" {
-		baseURL = "https://api.zeusllm.com/v1"
+	if config.PipelineID == "" {
+		return nil, errors.New("zeus: pipeline ID is required")
 	}
-	
-	pipelineID := os.Getenv("ZEUS_PIPELINE_ID")
-	if pipelineID == "" {
-		panic("ZEUS_PIPELINE_ID environment variable is required for Zeus provider")
+	client := config.HTTPClient
+	if client == nil {
+		client = blades.NewHTTPClient(blades.HTTPTimeout(cmp.Or(config.Timeout, 30*time.Second)))
 	}
-
-	return &ChatProvider{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		apiKey:     apiKey,
-		baseURL:    baseURL,
-		pipelineID: pipelineID,
+	if config.Hooks != nil {
+		client = config.Hooks.Client(client)
 	}
+	return &ChatProvider{
+		client:     client,
+		apiKey:     config.APIKey,
+		baseURL:    cmp.Or(config.BaseURL, "https://api.zeusllm.com/v1"),
+		pipelineID: config.PipelineID,
+	}, nil
 }
 
 // Generate executes a non-streaming chat completion request.
 func (p *ChatProvider) Generate(ctx context.Context, req *blades.ModelRequest, opts ...blades.ModelOption) (*blades.ModelResponse, error) {
+	var opt blades.ModelOptions
+	for _, apply := range opts {
+		apply(&opt)
+	}
+	// The Zeus API has no structured output setting.
+	if t := opt.ResponseFormat.Type; t != "" && t != blades.ResponseFormatText {
+		return nil, &blades.UnsupportedError{Provider: "zeus", Option: "response format " + string(t)}
+	}
 	// Convert Blades request to Zeus API format
 	zeusReq := p.convertToZeusRequest(req)
-	
+
 	// Make HTTP request
 	resp, err := p.makeRequest(ctx, zeusReq)
 	if err != nil {
 		return nil, err
 	}
-	
+
 	// Convert Zeus response to Blades format
 	return p.convertFromZeusResponse(resp)
 }
 
-// NewStream executes a streaming chat completion request.
+// NewStream executes a streaming chat completion request. The Zeus API streams
+// the deltas as server-sent events; each one is sent as an incomplete message,
+// followed by the accumulated completed message. A stream that ends before its
+// [DONE] event fails with io.ErrUnexpectedEOF.
 func (p *ChatProvider) NewStream(ctx context.Context, req *blades.ModelRequest, opts ...blades.ModelOption) (blades.Streamer[*blades.ModelResponse], error) {
-	// For now, implement as non-streaming since Zeus API doesn't show streaming support
-	// in the provided example. This can be enhanced later if streaming is supported.
-	
+	var opt blades.ModelOptions
+	for _, apply := range opts {
+		apply(&opt)
+	}
+	if t := opt.ResponseFormat.Type; t != "" && t != blades.ResponseFormatText {
+		return nil, &blades.UnsupportedError{Provider: "zeus", Option: "response format " + string(t)}
+	}
+	zeusReq := p.convertToZeusRequest(req)
+	zeusReq["stream"] = true
+
+	body, err := p.openStream(ctx, zeusReq)
+	if err != nil {
+		return nil, err
+	}
 	pipe := blades.NewStreamPipe[*blades.ModelResponse]()
 	pipe.Go(func() error {
-		response, err := p.Generate(ctx, req, opts...)
-		if err != nil {
+		defer body.Close()
+		var (
+			content strings.Builder
+			reason  string
+			usage   *blades.Usage
+			done    bool
+			data    []string
+			scanner = bufio.NewScanner(body)
+		)
+		// dispatch handles the data of an event, joined by newlines.
+		dispatch := func() error {
+			if len(data) == 0 {
+				return nil
+			}
+			event := strings.Join(data, "\n")
+			data = data[:0]
+			if strings.TrimSpace(event) == "[DONE]" {
+				done = true
+				return nil
+			}
+			var chunk ZeusStreamChunk
+			if err := json.Unmarshal([]byte(event), &chunk); err != nil {
+				return fmt.Errorf("failed to decode stream chunk: %w", err)
+			}
+			if chunk.Usage != nil {
+				usage = &blades.Usage{
+					PromptTokens:     int64(chunk.Usage.PromptTokens),
+					CompletionTokens: int64(chunk.Usage.CompletionTokens),
+					TotalTokens:      int64(chunk.Usage.TotalTokens),
+				}
+			}
+			for _, choice := range chunk.Choices {
+				if choice.FinishReason != "" {
+					reason = choice.FinishReason
+				}
+				if choice.Delta.Content == "" {
+					continue
+				}
+				content.WriteString(choice.Delta.Content)
+				pipe.Send(&blades.ModelResponse{
+					Messages: []*blades.Message{
+						{
+							Role:   blades.RoleAssistant,
+							Status: blades.StatusIncomplete,
+							Parts: []blades.Part{
+								blades.TextPart{Text: choice.Delta.Content},
+							},
+						},
+					},
+				})
+			}
+			return nil
+		}
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for !done && scanner.Scan() {
+			line := scanner.Text()
+			if line == "" {
+				// a blank line ends the event
+				if err := dispatch(); err != nil {
+					return err
+				}
+				continue
+			}
+			if value, ok := strings.CutPrefix(line, "data:"); ok {
+				data = append(data, strings.TrimPrefix(value, " "))
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("failed to read stream: %w", err)
+		}
+		// the last event may end with the body instead of a blank line
+		if err := dispatch(); err != nil {
 			return err
 		}
-		pipe.Send(response)
+		if !done {
+			return fmt.Errorf("stream ended before [DONE]: %w", io.ErrUnexpectedEOF)
+		}
+		pipe.Send(&blades.ModelResponse{
+			Messages: []*blades.Message{
+				{
+					Role:   blades.RoleAssistant,
+					Status: blades.StatusCompleted,
+					Parts: []blades.Part{
+						blades.TextPart{Text: content.String()},
+					},
+					Metadata: map[string]string{"finish_reason": reason},
+				},
+			},
+			Usage: usage,
+		})
 		return nil
 	})
-	
+
 	return pipe, nil
 }
 
 // convertToZeusRequest converts Blades ModelRequest to Zeus API format
 func (p *ChatProvider) convertToZeusRequest(req *blades.ModelRequest) map[string]interface{} {
 	messages := make([]map[string]string, 0, len(req.Messages))
-	
+
 	for _, msg := range req.Messages {
 		role := string(msg.Role)
 		// Ensure proper role mapping
@@ -95,12 +233,12 @@ func (p *ChatProvider) convertToZeusRequest(req *blades.ModelRequest) map[string
 			role = "assistant"
 		case "user":
 			role = "user"
-		case "system":
+		case "system", "developer":
 			role = "system"
 		default:
 			role = "user" // Default to user if unknown
 		}
-		
+
 		// Extract text content from parts
 		content := ""
 		for _, part := range msg.Parts {
@@ -108,7 +246,7 @@ func (p *ChatProvider) convertToZeusRequest(req *blades.ModelRequest) map[string
 				content += textPart.Text
 			}
 		}
-		
+
 		// Only add message if it has content
 		if content != "" {
 			messages = append(messages, map[string]string{
@@ -117,10 +255,10 @@ func (p *ChatProvider) convertToZeusRequest(req *blades.ModelRequest) map[string
 			})
 		}
 	}
-	
+
 	return map[string]interface{}{
-		"messages":     messages,
-		"pipeline_id":  p.pipelineID,
+		"messages":    messages,
+		"pipeline_id": p.pipelineID,
 	}
 }
 
@@ -129,10 +267,10 @@ func (p *ChatProvider) convertFromZeusResponse(resp *ZeusResponse) (*blades.Mode
 	if len(resp.Choices) == 0 {
 		return nil, fmt.Errorf("no choices in Zeus response")
 	}
-	
+
 	choice := resp.Choices[0]
 	content := choice.Message.Content
-	
+
 	return &blades.ModelResponse{
 		Messages: []*blades.Message{
 			{
@@ -141,49 +279,71 @@ func (p *ChatProvider) convertFromZeusResponse(resp *ZeusResponse) (*blades.Mode
 				Parts: []blades.Part{
 					blades.TextPart{Text: content},
 				},
+				Metadata: map[string]string{"finish_reason": choice.FinishReason},
 			},
 		},
+		Usage: &blades.Usage{
+			PromptTokens:     int64(resp.Usage.PromptTokens),
+			CompletionTokens: int64(resp.Usage.CompletionTokens),
+			TotalTokens:      int64(resp.Usage.TotalTokens),
+		},
 	}, nil
 }
 
 // makeRequest makes HTTP request to Zeus API
 func (p *ChatProvider) makeRequest(ctx context.Context, req map[string]interface{}) (*ZeusResponse, error) {
+	body, err := p.send(ctx, req, "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var zeusResp ZeusResponse
+	if err := json.NewDecoder(body).Decode(&zeusResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &zeusResp, nil
+}
+
+// openStream makes a streaming HTTP request to Zeus API and returns the event stream.
+func (p *ChatProvider) openStream(ctx context.Context, req map[string]interface{}) (io.ReadCloser, error) {
+	return p.send(ctx, req, "text/event-stream")
+}
+
+// send posts the request to Zeus API and returns the response body.
+func (p *ChatProvider) send(ctx context.Context, req map[string]interface{}, accept string) (io.ReadCloser, error) {
 	jsonData, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
-	
-	// Debug: Print request (remove in production)
-	// fmt.Printf("Zeus Request: %s\n", string(jsonData))
-	
+
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/ai", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	
+
 	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", accept)
 	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
-	
+
 	resp, err := p.client.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
-	defer resp.Body.Close()
-	
+
 	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Zeus API error: %d - %s", resp.StatusCode, string(body))
-	}
-	
-	var zeusResp ZeusResponse
-	if err := json.NewDecoder(resp.Body).Decode(&zeusResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, &blades.ProviderError{
+			Provider:   "zeus",
+			StatusCode: resp.StatusCode,
+			RetryAfter: blades.ParseRetryAfter(resp.Header),
+			Err:        fmt.Errorf("Zeus API error: %d - %s", resp.StatusCode, string(body)),
+		}
 	}
-	
-	// Debug: Print response (remove in production)
-	// fmt.Printf("Zeus Response: %+v\n", zeusResp)
-	
-	return &zeusResp, nil
+
+	return resp.Body, nil
 }
 
 // ZeusResponse represents the response from Zeus API
@@ -197,7 +357,7 @@ type ZeusResponse struct {
 			Role    string `json:"role"`
 		} `json:"message"`
 	} `json:"choices"`
-	Created int64 `json:"created"`
+	Created int64  `json:"created"`
 	Model   string `json:"model"`
 	Object  string `json:"object"`
 	Usage   struct {
@@ -206,3 +366,21 @@ type ZeusResponse struct {
 		TotalTokens      int `json:"total_tokens"`
 	} `json:"usage"`
 }
+
+// ZeusStreamChunk represents a server-sent event of a streaming Zeus API response
+type ZeusStreamChunk struct {
+	ID      string `json:"id"`
+	Choices []struct {
+		FinishReason string `json:"finish_reason"`
+		Index        int    `json:"index"`
+		Delta        struct {
+			Content string `json:"content"`
+			Role    string `json:"role"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
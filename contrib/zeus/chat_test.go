@@ -0,0 +1,243 @@
+package zeus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-kratos/blades"
+)
+
+// fakeZeus serves the Zeus API with the given body, recording the request
+// headers and bodies.
+type fakeZeus struct {
+	status int
+	body   string
+
+	mu      sync.Mutex
+	headers []http.Header
+	bodies  []map[string]any
+}
+
+func (f *fakeZeus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]any
+	if r.Method != http.MethodPost || r.URL.Path != "/v1/ai" || json.NewDecoder(r.Body).Decode(&body) != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	f.headers = append(f.headers, r.Header.Clone())
+	f.bodies = append(f.bodies, body)
+	f.mu.Unlock()
+	if f.status != 0 {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(f.status)
+		io.WriteString(w, `{"error": "slow down"}`)
+		return
+	}
+	if body["stream"] == true {
+		w.Header().Set("Content-Type", "text/event-stream")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	io.WriteString(w, f.body)
+}
+
+func newTestProvider(t *testing.T, fake *fakeZeus) blades.ModelProvider {
+	t.Helper()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	provider, err := NewChatProviderWithConfig(Config{APIKey: "secret", BaseURL: srv.URL + "/v1", PipelineID: "pipe-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+func request() *blades.ModelRequest {
+	return &blades.ModelRequest{Messages: []*blades.Message{blades.DeveloperMessage("be brief"), blades.UserMessage("hi")}}
+}
+
+func TestChatProvider_NewStream(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		deltas []string
+		text   string
+		reason string
+		usage  *blades.Usage
+		err    string
+	}{
+		{
+			name: "chunked deltas",
+			body: "data: {\"choices\": [{\"delta\": {\"role\": \"assistant\", \"content\": \"hel\"}}]}\n\n" +
+				": keep-alive\n\n" +
+				"data: {\"choices\": [{\"delta\": {\"content\": \"lo\"}, \"finish_reason\": \"length\"}]}\n\n" +
+				"data: {\"choices\": [], \"usage\": {\"prompt_tokens\": 3, \"completion_tokens\": 2, \"total_tokens\": 5}}\n\n" +
+				"data: [DONE]\n\n",
+			deltas: []string{"hel", "lo"},
+			text:   "hello",
+			reason: "length",
+			usage:  &blades.Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5},
+		},
+		{
+			name: "multi-line event",
+			body: "data: {\"choices\": [{\"delta\":\n" +
+				"data: {\"content\": \"hi\"}, \"finish_reason\": \"stop\"}]}\n\n" +
+				"data: [DONE]",
+			deltas: []string{"hi"},
+			text:   "hi",
+			reason: "stop",
+		},
+		{
+			name:   "missing done",
+			body:   "data: {\"choices\": [{\"delta\": {\"content\": \"hel\"}}]}\n\n",
+			deltas: []string{"hel"},
+			err:    "stream ended before [DONE]: unexpected EOF",
+		},
+		{
+			name: "malformed chunk",
+			body: "data: {\"choices\": [\n\ndata: [DONE]\n\n",
+			err:  "failed to decode stream chunk: unexpected end of JSON input",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeZeus{body: tt.body}
+			stream, err := newTestProvider(t, fake).NewStream(context.Background(), request())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var (
+				deltas []string
+				final  *blades.ModelResponse
+			)
+			// the error of the stream is also reported with the values
+			// still queued, so it is checked once the stream is closed
+			for stream.Next() {
+				res, _ := stream.Current()
+				msg := res.Messages[0]
+				if msg.Status == blades.StatusIncomplete {
+					deltas = append(deltas, msg.Text())
+				} else {
+					final = res
+				}
+			}
+			if err := stream.Close(); (err == nil) != (tt.err == "") || (err != nil && err.Error() != tt.err) {
+				t.Fatalf("expected error %q, got %v", tt.err, err)
+			}
+			if strings.Join(deltas, ",") != strings.Join(tt.deltas, ",") {
+				t.Fatalf("expected the deltas %q, got %q", tt.deltas, deltas)
+			}
+			if fake.bodies[0]["stream"] != true || fake.headers[0].Get("Accept") != "text/event-stream" {
+				t.Fatalf("expected a streaming request, got %v with %v", fake.bodies[0], fake.headers[0])
+			}
+			if tt.err != "" {
+				if final != nil {
+					t.Fatalf("expected no completed message, got %q", final.Messages[0].Text())
+				}
+				return
+			}
+			if final == nil {
+				t.Fatal("expected a completed message")
+			}
+			msg := final.Messages[0]
+			if msg.Text() != tt.text || msg.Metadata["finish_reason"] != tt.reason {
+				t.Fatalf("expected %q finished by %q, got %q with %v", tt.text, tt.reason, msg.Text(), msg.Metadata)
+			}
+			if (final.Usage == nil) != (tt.usage == nil) || (tt.usage != nil && *final.Usage != *tt.usage) {
+				t.Fatalf("expected the usage %+v, got %+v", tt.usage, final.Usage)
+			}
+		})
+	}
+}
+
+func TestChatProvider_NewStreamMissingDone(t *testing.T) {
+	stream, err := newTestProvider(t, &fakeZeus{body: "data: {\"choices\": []}\n\n"}).NewStream(context.Background(), request())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for stream.Next() {
+	}
+	if err := stream.Close(); !errors.Is(err, io.ErrUnexpectedEOF) || !blades.IsTransient(err) {
+		t.Fatalf("expected a transient io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestNewChatProviderWithConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		err    string
+	}{
+		{name: "valid", config: Config{APIKey: "secret", PipelineID: "pipe-1"}},
+		{name: "missing API key", config: Config{PipelineID: "pipe-1"}, err: "zeus: API key is required"},
+		{name: "missing pipeline", config: Config{APIKey: "secret"}, err: "zeus: pipeline ID is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewChatProviderWithConfig(tt.config)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p := provider.(*ChatProvider); p.baseURL != "https://api.zeusllm.com/v1" || p.client == nil {
+				t.Fatalf("expected the default base URL and client, got %+v", p)
+			}
+		})
+	}
+}
+
+func TestChatProvider_Generate(t *testing.T) {
+	fake := &fakeZeus{body: `{
+		"id": "zeus-1", "object": "chat.completion", "model": "zeus",
+		"choices": [{"index": 0, "finish_reason": "length", "message": {"role": "assistant", "content": "hello"}}],
+		"usage": {"prompt_tokens": 3, "completion_tokens": 1, "total_tokens": 4}
+	}`}
+	res, err := newTestProvider(t, fake).Generate(context.Background(), request())
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, body := fake.headers[0], fake.bodies[0]
+	if header.Get("Authorization") != "Bearer secret" || header.Get("Accept") != "application/json" {
+		t.Fatalf("unexpected headers %v", header)
+	}
+	want := `{"messages":[{"content":"be brief","role":"system"},{"content":"hi","role":"user"}],"pipeline_id":"pipe-1"}`
+	if got, _ := json.Marshal(body); string(got) != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+	msg := res.Messages[0]
+	if msg.Text() != "hello" || msg.Status != blades.StatusCompleted || msg.Metadata["finish_reason"] != "length" {
+		t.Fatalf("expected the completed answer with its finish reason, got %q with %v", msg.Text(), msg.Metadata)
+	}
+	if res.Usage == nil || *res.Usage != (blades.Usage{PromptTokens: 3, CompletionTokens: 1, TotalTokens: 4}) {
+		t.Fatalf("unexpected usage %+v", res.Usage)
+	}
+}
+
+func TestChatProvider_Errors(t *testing.T) {
+	ctx := context.Background()
+	_, err := newTestProvider(t, &fakeZeus{status: http.StatusTooManyRequests}).Generate(ctx, request())
+	var perr *blades.ProviderError
+	if !errors.As(err, &perr) || perr.StatusCode != http.StatusTooManyRequests || perr.RetryAfter.Seconds() != 7 || !perr.Transient() {
+		t.Fatalf("expected a transient provider error, got %v", err)
+	}
+	if _, err := newTestProvider(t, &fakeZeus{body: `{"choices": []}`}).Generate(ctx, request()); err == nil || err.Error() != "no choices in Zeus response" {
+		t.Fatalf("expected an error without choices, got %v", err)
+	}
+	var unsupported *blades.UnsupportedError
+	if _, err := newTestProvider(t, &fakeZeus{}).Generate(ctx, request(), blades.WithResponseFormat(blades.JSONObject())); !errors.As(err, &unsupported) {
+		t.Fatalf("expected structured output to be unsupported, got %v", err)
+	}
+}
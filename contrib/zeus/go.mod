@@ -4,8 +4,11 @@ go 1.24
 
 toolchain go1.24.2
 
+require github.com/go-kratos/blades v0.0.0
+
 require (
-	github.com/go-kratos/blades v0.0.0
+	github.com/google/jsonschema-go v0.2.3 // indirect
+	github.com/google/uuid v1.6.0 // indirect
 )
 
 replace github.com/go-kratos/blades => ../../
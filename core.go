@@ -3,6 +3,7 @@ package blades
 import (
 	"context"
 	"strings"
+	"time"
 )
 
 // Prompt represents a sequence of messages exchanged between a user and an assistant.
@@ -39,6 +40,39 @@ func (p *Prompt) String() string {
 // Generation represents a single generation of a response from the model.
 type Generation struct {
 	Messages []*Message `json:"message"`
+	// ToolTrace lists the tool invocations made while generating, in order.
+	ToolTrace []*ToolInvocation `json:"toolTrace,omitempty"`
+	// Usage sums the tokens of every model call made for the generation. In a
+	// stream it is set on the generations of the final responses of model calls.
+	Usage *Usage `json:"usage,omitempty"`
+	// Grounding describes the sources the final response is grounded in, if any.
+	Grounding *Grounding `json:"grounding,omitempty"`
+	// Finish is set on the terminal generation of a stream, which has no messages.
+	Finish *Finish `json:"finish,omitempty"`
+	// Warnings report non-fatal issues met while generating, such as a truncated
+	// response or a cache hit. In a stream they are set on the generations of
+	// the responses they concern, and all of them on the terminal generation.
+	Warnings []Warning `json:"warnings,omitempty"`
+	// Provenance records how the generation was produced, when enabled.
+	Provenance *Provenance `json:"provenance,omitempty"`
+}
+
+// Finish summarizes how a streamed generation ended, so that consumers learn
+// the outcome without a separate blocking call.
+type Finish struct {
+	// Reason is the finish reason reported by the provider, "stop" by default.
+	Reason string `json:"reason"`
+	// Usage sums the tokens of every model call made for the stream.
+	Usage *Usage `json:"usage,omitempty"`
+	// Cost is the price of Usage, when pricing is configured.
+	Cost float64 `json:"cost,omitempty"`
+	// Elapsed is the time from the start of the stream to its end.
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// Pricing prices the tokens used by a model.
+type Pricing interface {
+	Cost(model string, usage *Usage) float64
 }
 
 // Text extracts the text content from the first text part of the generation.
@@ -0,0 +1,210 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var (
+	_ Pricing = PriceTable(nil)
+)
+
+var (
+	// ErrBudgetExceeded indicates a run was aborted because the spend reached the budget.
+	ErrBudgetExceeded = errors.New("budget exceeded")
+)
+
+// ModelPrice is the price of a model in currency units per million tokens.
+type ModelPrice struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// PriceTable maps model names to prices. Models without an exact entry use the
+// entry of the longest name they start with, so "gpt-4o-2024-08-06" is priced
+// as "gpt-4o". Unknown models cost nothing.
+type PriceTable map[string]ModelPrice
+
+// DefaultPriceTable returns a new table with list prices in USD of common
+// models, which may be overridden or extended.
+func DefaultPriceTable() PriceTable {
+	return PriceTable{
+		"gpt-4o":           {Input: 2.5, Output: 10},
+		"gpt-4o-mini":      {Input: 0.15, Output: 0.6},
+		"gpt-4.1":          {Input: 2, Output: 8},
+		"gpt-4.1-mini":     {Input: 0.4, Output: 1.6},
+		"gpt-4.1-nano":     {Input: 0.1, Output: 0.4},
+		"o3":               {Input: 2, Output: 8},
+		"o4-mini":          {Input: 1.1, Output: 4.4},
+		"gemini-2.5-pro":   {Input: 1.25, Output: 10},
+		"gemini-2.5-flash": {Input: 0.3, Output: 2.5},
+		"gemini-2.0-flash": {Input: 0.1, Output: 0.4},
+	}
+}
+
+// Price returns the price of model and whether the table has one.
+func (t PriceTable) Price(model string) (ModelPrice, bool) {
+	if price, ok := t[model]; ok {
+		return price, true
+	}
+	var (
+		best  string
+		price ModelPrice
+	)
+	for name, p := range t {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best, price = name, p
+		}
+	}
+	return price, best != ""
+}
+
+// Cost returns the price of the prompt and completion tokens of usage.
+func (t PriceTable) Cost(model string, usage *Usage) float64 {
+	price, ok := t.Price(model)
+	if !ok || usage == nil {
+		return 0
+	}
+	return (float64(usage.PromptTokens)*price.Input + float64(usage.CompletionTokens)*price.Output) / 1e6
+}
+
+// ModelSpend is the usage and cost accumulated for a model.
+type ModelSpend struct {
+	Usage Usage   `json:"usage"`
+	Cost  float64 `json:"cost"`
+}
+
+// CostTrackerOption configures a CostTracker.
+type CostTrackerOption func(*CostTracker)
+
+// CostBudget sets the spend at which runs guarded by the tracker are aborted.
+func CostBudget(ceiling float64) CostTrackerOption {
+	return func(t *CostTracker) {
+		t.budget = ceiling
+	}
+}
+
+// CostTracker aggregates the usage of generations, e.g. of every step of a
+// chain, and prices it. Record generations explicitly, or install Middleware
+// on the agents to record every run and enforce the budget.
+type CostTracker struct {
+	pricing Pricing
+	budget  float64
+
+	mu     sync.Mutex
+	total  float64
+	models map[string]*ModelSpend
+}
+
+// NewCostTracker creates a CostTracker pricing usage with pricing.
+func NewCostTracker(pricing Pricing, opts ...CostTrackerOption) *CostTracker {
+	t := &CostTracker{pricing: pricing, models: make(map[string]*ModelSpend)}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Record adds the usage of a generation produced by model.
+func (t *CostTracker) Record(model string, g *Generation) {
+	usage := g.Usage
+	if g.Finish != nil {
+		usage = g.Finish.Usage
+	}
+	t.Add(model, usage)
+}
+
+// Add adds usage of model.
+func (t *CostTracker) Add(model string, usage *Usage) {
+	if usage == nil {
+		return
+	}
+	cost := t.pricing.Cost(model, usage)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	spend, ok := t.models[model]
+	if !ok {
+		spend = &ModelSpend{}
+		t.models[model] = spend
+	}
+	spend.Usage.Add(usage)
+	spend.Cost += cost
+	t.total += cost
+}
+
+// Total returns the total spend.
+func (t *CostTracker) Total() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.total
+}
+
+// Spend returns the usage and cost per model.
+func (t *CostTracker) Spend() map[string]ModelSpend {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	spend := make(map[string]ModelSpend, len(t.models))
+	for model, s := range t.models {
+		spend[model] = *s
+	}
+	return spend
+}
+
+// Check returns ErrBudgetExceeded once the total spend has reached the budget.
+func (t *CostTracker) Check() error {
+	if t.budget <= 0 {
+		return nil
+	}
+	if total := t.Total(); total >= t.budget {
+		return fmt.Errorf("%w: spent %.4f of %.4f", ErrBudgetExceeded, total, t.budget)
+	}
+	return nil
+}
+
+// Middleware records the usage of every run of an agent under its model, and
+// aborts runs with ErrBudgetExceeded once the budget has been reached. A run
+// that crosses the budget completes; the runs after it are aborted.
+func (t *CostTracker) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return Handler{
+			Run: func(ctx context.Context, prompt *Prompt, opts ...ModelOption) (*Generation, error) {
+				if err := t.Check(); err != nil {
+					return nil, err
+				}
+				g, err := next.Run(ctx, prompt, opts...)
+				if err != nil {
+					return nil, err
+				}
+				t.Record(agentModel(ctx), g)
+				return g, nil
+			},
+			Stream: func(ctx context.Context, prompt *Prompt, opts ...ModelOption) (Streamer[*Generation], error) {
+				if err := t.Check(); err != nil {
+					return nil, err
+				}
+				stream, err := next.Stream(ctx, prompt, opts...)
+				if err != nil {
+					return nil, err
+				}
+				model := agentModel(ctx)
+				return NewMappedStream(stream, func(g *Generation) (*Generation, error) {
+					// the terminal generation sums the usage of the stream
+					if g.Finish != nil {
+						t.Record(model, g)
+					}
+					return g, nil
+				}), nil
+			},
+		}
+	}
+}
+
+func agentModel(ctx context.Context) string {
+	if agent, ok := FromContext(ctx); ok {
+		return agent.Model
+	}
+	return ""
+}
@@ -0,0 +1,37 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestPriceTable_Prefix(t *testing.T) {
+	prices := DefaultPriceTable()
+	prices["gpt-4o"] = ModelPrice{Input: 1, Output: 2}
+	usage := &Usage{PromptTokens: 1e6, CompletionTokens: 1e6}
+	if cost := prices.Cost("gpt-4o-2024-08-06", usage); cost != 3 {
+		t.Fatalf("expected the overridden gpt-4o price, got %v", cost)
+	}
+	if cost := prices.Cost("gpt-4o-mini-2024-07-18", usage); math.Abs(cost-0.75) > 1e-9 {
+		t.Fatalf("expected the longest prefix to win, got %v", cost)
+	}
+}
+
+func TestCostTracker_Budget(t *testing.T) {
+	tracker := NewCostTracker(PriceTable{"m": {Input: 1, Output: 1}}, CostBudget(3))
+	agent := NewAgent("a", WithModel("m"), WithProvider(&fakeProvider{replies: []string{"ok"}, usage: &Usage{PromptTokens: 1e6, CompletionTokens: 1e6, TotalTokens: 2e6}}), WithMiddleware(tracker.Middleware()))
+	ctx := context.Background()
+	for range 2 {
+		if _, err := agent.Run(ctx, NewPrompt(UserMessage("hi"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tracker.Total() != 4 || tracker.Spend()["m"].Usage.PromptTokens != 2e6 {
+		t.Fatalf("unexpected spend %v: %+v", tracker.Total(), tracker.Spend())
+	}
+	if _, err := agent.Run(ctx, NewPrompt(UserMessage("hi"))); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected the budget to abort the run, got %v", err)
+	}
+}
@@ -0,0 +1,181 @@
+package blades
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	_ DeadLetterQueue = (*MemoryDeadLetterQueue)(nil)
+)
+
+// DeadLetter is a request that could not be served, with the context needed
+// to investigate and reprocess it.
+type DeadLetter struct {
+	ID       string        `json:"id"`
+	Request  *ModelRequest `json:"request"`
+	Err      string        `json:"error"`
+	Attempts int           `json:"attempts"`
+	FailedAt time.Time     `json:"failed_at"`
+}
+
+// DeadLetterQueue stores dead letters until they are reprocessed.
+type DeadLetterQueue interface {
+	// Put stores the dead letter, replacing one with the same ID.
+	Put(ctx context.Context, letter DeadLetter) error
+	// List returns the dead letters, oldest first.
+	List(ctx context.Context) ([]DeadLetter, error)
+	// Remove deletes the dead letter with the given ID.
+	Remove(ctx context.Context, id string) error
+}
+
+// MemoryDeadLetterQueue is an in-memory DeadLetterQueue.
+type MemoryDeadLetterQueue struct {
+	mu      sync.Mutex
+	letters []DeadLetter
+}
+
+// NewMemoryDeadLetterQueue creates an empty MemoryDeadLetterQueue.
+func NewMemoryDeadLetterQueue() *MemoryDeadLetterQueue {
+	return &MemoryDeadLetterQueue{}
+}
+
+// Put stores the dead letter.
+func (q *MemoryDeadLetterQueue) Put(ctx context.Context, letter DeadLetter) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if i := slices.IndexFunc(q.letters, func(l DeadLetter) bool { return l.ID == letter.ID }); i >= 0 {
+		q.letters[i] = letter
+		return nil
+	}
+	q.letters = append(q.letters, letter)
+	return nil
+}
+
+// List returns the dead letters.
+func (q *MemoryDeadLetterQueue) List(ctx context.Context) ([]DeadLetter, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return slices.Clone(q.letters), nil
+}
+
+// Remove deletes the dead letter.
+func (q *MemoryDeadLetterQueue) Remove(ctx context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.letters = slices.DeleteFunc(q.letters, func(l DeadLetter) bool { return l.ID == id })
+	return nil
+}
+
+// ReplayOption configures Replay and Reprocess.
+type ReplayOption func(*replayOptions)
+
+type replayOptions struct {
+	attempts int
+	handle   func(ctx context.Context, req *ModelRequest, res *ModelResponse) error
+}
+
+// ReplayAttempts sets how many times each request is tried, 3 by default.
+func ReplayAttempts(n int) ReplayOption {
+	return func(o *replayOptions) {
+		o.attempts = n
+	}
+}
+
+// OnReplayed delivers the responses of replayed requests, e.g. to notify the
+// users whose requests were queued. An error counts as a failed attempt.
+func OnReplayed(fn func(ctx context.Context, req *ModelRequest, res *ModelResponse) error) ReplayOption {
+	return func(o *replayOptions) {
+		o.handle = fn
+	}
+}
+
+// ReplayResult counts the outcome of a replay.
+type ReplayResult struct {
+	Succeeded int
+	Failed    int
+}
+
+// Replay executes requests, such as those drained from a MemoryQueue, moving
+// the ones still failing after every attempt to the dead-letter queue instead
+// of dropping them. It returns early only when ctx is done or the dead-letter
+// queue fails.
+func Replay(ctx context.Context, provider ModelProvider, requests []*ModelRequest, dlq DeadLetterQueue, opts ...ReplayOption) (ReplayResult, error) {
+	o := newReplayOptions(opts)
+	var result ReplayResult
+	for _, req := range requests {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		attempts, err := o.replay(ctx, provider, req)
+		if err == nil {
+			result.Succeeded++
+			continue
+		}
+		result.Failed++
+		letter := DeadLetter{ID: NewMessageID(), Request: req, Err: err.Error(), Attempts: attempts, FailedAt: Now()}
+		if err := dlq.Put(ctx, letter); err != nil {
+			return result, fmt.Errorf("dead letter: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// Reprocess replays the dead letters of the queue, removing those that succeed
+// and updating the error and attempt count of those that fail again.
+func Reprocess(ctx context.Context, provider ModelProvider, dlq DeadLetterQueue, opts ...ReplayOption) (ReplayResult, error) {
+	o := newReplayOptions(opts)
+	letters, err := dlq.List(ctx)
+	if err != nil {
+		return ReplayResult{}, fmt.Errorf("dead letter: %w", err)
+	}
+	var result ReplayResult
+	for _, letter := range letters {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		attempts, err := o.replay(ctx, provider, letter.Request)
+		if err == nil {
+			result.Succeeded++
+			if err := dlq.Remove(ctx, letter.ID); err != nil {
+				return result, fmt.Errorf("dead letter: %w", err)
+			}
+			continue
+		}
+		result.Failed++
+		letter.Err = err.Error()
+		letter.Attempts += attempts
+		letter.FailedAt = Now()
+		if err := dlq.Put(ctx, letter); err != nil {
+			return result, fmt.Errorf("dead letter: %w", err)
+		}
+	}
+	return result, nil
+}
+
+func newReplayOptions(opts []ReplayOption) *replayOptions {
+	o := &replayOptions{attempts: 3}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// replay tries the request until it succeeds or the attempts are exhausted,
+// returning the number of attempts made and the last error.
+func (o *replayOptions) replay(ctx context.Context, provider ModelProvider, req *ModelRequest) (int, error) {
+	var err error
+	for attempt := 1; attempt <= max(o.attempts, 1); attempt++ {
+		var res *ModelResponse
+		if res, err = provider.Generate(ctx, req); err == nil && o.handle != nil {
+			err = o.handle(ctx, req, res)
+		}
+		if err == nil || ctx.Err() != nil {
+			return attempt, err
+		}
+	}
+	return max(o.attempts, 1), err
+}
@@ -0,0 +1,37 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestReplayDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	flaky := &fakeProvider{replies: []string{"ok"}, err: errors.New("unavailable"), failures: 3}
+	queue := NewMemoryQueue()
+	_ = queue.Enqueue(ctx, &ModelRequest{Model: "first"})
+	_ = queue.Enqueue(ctx, &ModelRequest{Model: "second"})
+	dlq := NewMemoryDeadLetterQueue()
+
+	result, err := Replay(ctx, flaky, queue.Drain(), dlq, ReplayAttempts(2))
+	if err != nil || result.Succeeded != 1 || result.Failed != 1 {
+		t.Fatalf("unexpected replay result %+v, %v", result, err)
+	}
+	letters, _ := dlq.List(ctx)
+	if len(letters) != 1 || letters[0].Request.Model != "first" || letters[0].Attempts != 2 || letters[0].Err != "unavailable" {
+		t.Fatalf("unexpected dead letters: %+v", letters)
+	}
+
+	var delivered []string
+	result, err = Reprocess(ctx, flaky, dlq, OnReplayed(func(ctx context.Context, req *ModelRequest, res *ModelResponse) error {
+		delivered = append(delivered, req.Model)
+		return nil
+	}))
+	if err != nil || result.Succeeded != 1 || len(delivered) != 1 {
+		t.Fatalf("unexpected reprocess result %+v, %v", result, err)
+	}
+	if letters, _ = dlq.List(ctx); len(letters) != 0 {
+		t.Fatalf("reprocessed letters not removed: %+v", letters)
+	}
+}
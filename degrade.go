@@ -0,0 +1,249 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	_ ModelProvider = (*DegradingProvider)(nil)
+)
+
+// DegradationPolicy produces a fallback response when the provider is unavailable.
+// It returns an error if it cannot serve the request either.
+type DegradationPolicy interface {
+	Degrade(ctx context.Context, req *ModelRequest, cause error) (*ModelResponse, error)
+}
+
+// DegradationFunc adapts a function to a DegradationPolicy.
+type DegradationFunc func(ctx context.Context, req *ModelRequest, cause error) (*ModelResponse, error)
+
+// Degrade calls f(ctx, req, cause).
+func (f DegradationFunc) Degrade(ctx context.Context, req *ModelRequest, cause error) (*ModelResponse, error) {
+	return f(ctx, req, cause)
+}
+
+// CannedResponse degrades to a fixed assistant message.
+func CannedResponse(text string) DegradationPolicy {
+	return DegradationFunc(func(ctx context.Context, req *ModelRequest, cause error) (*ModelResponse, error) {
+		return degradedResponse(text, "canned"), nil
+	})
+}
+
+// DeferredQueue stores requests to be replayed once the provider recovers.
+type DeferredQueue interface {
+	Enqueue(ctx context.Context, req *ModelRequest) error
+}
+
+// QueueForLater enqueues the request and degrades to the acknowledgement text.
+func QueueForLater(queue DeferredQueue, ack string) DegradationPolicy {
+	return DegradationFunc(func(ctx context.Context, req *ModelRequest, cause error) (*ModelResponse, error) {
+		if err := queue.Enqueue(ctx, req); err != nil {
+			return nil, err
+		}
+		return degradedResponse(ack, "queued"), nil
+	})
+}
+
+// FirstOf tries the policies in order and returns the first response.
+func FirstOf(policies ...DegradationPolicy) DegradationPolicy {
+	return DegradationFunc(func(ctx context.Context, req *ModelRequest, cause error) (*ModelResponse, error) {
+		err := cause
+		for _, policy := range policies {
+			var res *ModelResponse
+			if res, err = policy.Degrade(ctx, req, cause); err == nil {
+				return res, nil
+			}
+		}
+		return nil, err
+	})
+}
+
+func degradedResponse(text, mode string) *ModelResponse {
+	return &ModelResponse{Messages: []*Message{{
+		ID:       NewMessageID(),
+		Role:     RoleAssistant,
+		Status:   StatusCompleted,
+		Parts:    Parts(text),
+		Metadata: map[string]string{"degraded": mode},
+	}}}
+}
+
+// DegradeOption configures a DegradingProvider.
+type DegradeOption func(*DegradingProvider)
+
+// DegradeWhen sets which provider errors activate the degradation policy, e.g.
+// only errors reporting an open circuit breaker. By default every error does
+// except context cancellation.
+func DegradeWhen(fn func(error) bool) DegradeOption {
+	return func(p *DegradingProvider) {
+		p.when = fn
+	}
+}
+
+// DegradeFromCache serves the last successful response to an identical request
+// before falling back to the policy. At most size responses are kept.
+func DegradeFromCache(size int) DegradeOption {
+	return func(p *DegradingProvider) {
+		p.cache = &staleCache{size: size, entries: make(map[string]*ModelResponse)}
+	}
+}
+
+// DegradingProvider makes user-facing applications fail soft during provider
+// outages: failed calls are answered by a DegradationPolicy, such as a stale
+// cached response, a canned message or an acknowledgement that the request was
+// queued. Degraded messages carry the mode in the "degraded" metadata, and
+// degraded responses a WarningDegraded.
+type DegradingProvider struct {
+	provider ModelProvider
+	policy   DegradationPolicy
+	when     func(error) bool
+	cache    *staleCache
+}
+
+// NewDegradingProvider wraps provider with the degradation policy.
+func NewDegradingProvider(provider ModelProvider, policy DegradationPolicy, opts ...DegradeOption) *DegradingProvider {
+	p := &DegradingProvider{
+		provider: provider,
+		policy:   policy,
+		when: func(err error) bool {
+			return !errors.Is(err, context.Canceled)
+		},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Generate executes the request, degrading on failure.
+func (p *DegradingProvider) Generate(ctx context.Context, req *ModelRequest, opts ...ModelOption) (*ModelResponse, error) {
+	res, err := p.provider.Generate(ctx, req, opts...)
+	if err != nil {
+		return p.degrade(ctx, req, err)
+	}
+	p.cache.put(req, res)
+	return res, nil
+}
+
+// NewStream executes the streaming request. A stream failing before its first
+// chunk is degraded into a single response; later failures pass through.
+func (p *DegradingProvider) NewStream(ctx context.Context, req *ModelRequest, opts ...ModelOption) (Streamer[*ModelResponse], error) {
+	stream, err := peekStream(p.provider.NewStream(ctx, req, opts...))
+	if err != nil {
+		res, err := p.degrade(ctx, req, err)
+		if err != nil {
+			return nil, err
+		}
+		pipe := NewStreamPipe[*ModelResponse]()
+		pipe.Send(res)
+		pipe.Close()
+		return pipe, nil
+	}
+	if p.cache == nil {
+		return stream, nil
+	}
+	return NewMappedStream(stream, func(res *ModelResponse) (*ModelResponse, error) {
+		if completed := completedMessages(res); len(completed.Messages) > 0 {
+			p.cache.put(req, completed)
+		}
+		return res, nil
+	}), nil
+}
+
+func (p *DegradingProvider) degrade(ctx context.Context, req *ModelRequest, cause error) (*ModelResponse, error) {
+	if !p.when(cause) {
+		return nil, cause
+	}
+	if res, ok := p.cache.get(req); ok {
+		res.Warnings = append(res.Warnings, Warning{Code: WarningDegraded, Message: "stale response: " + cause.Error()})
+		return res, nil
+	}
+	res, err := p.policy.Degrade(ctx, req, cause)
+	if err != nil {
+		return nil, errors.Join(cause, err)
+	}
+	res.Warnings = append(res.Warnings, Warning{Code: WarningDegraded, Message: cause.Error()})
+	return res, nil
+}
+
+// staleCache keeps the last successful response per request.
+type staleCache struct {
+	mu      sync.Mutex
+	size    int
+	order   []string
+	entries map[string]*ModelResponse
+}
+
+func requestKey(req *ModelRequest) string {
+	key := req.Model
+	for _, msg := range req.Messages {
+		key += "/" + messageDigest(msg)
+	}
+	return key
+}
+
+func (c *staleCache) put(req *ModelRequest, res *ModelResponse) {
+	if c == nil {
+		return
+	}
+	key := requestKey(req)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok {
+		c.order = append(c.order, key)
+		if len(c.order) > c.size {
+			delete(c.entries, c.order[0])
+			c.order = c.order[1:]
+		}
+	}
+	c.entries[key] = res
+}
+
+func (c *staleCache) get(req *ModelRequest) (*ModelResponse, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res, ok := c.entries[requestKey(req)]
+	if !ok {
+		return nil, false
+	}
+	stale := &ModelResponse{}
+	for _, msg := range res.Messages {
+		m := *msg
+		m.Metadata = map[string]string{"degraded": "cache"}
+		stale.Messages = append(stale.Messages, &m)
+	}
+	return stale, true
+}
+
+// MemoryQueue is an in-memory DeferredQueue.
+type MemoryQueue struct {
+	mu       sync.Mutex
+	requests []*ModelRequest
+}
+
+// NewMemoryQueue creates an empty MemoryQueue.
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{}
+}
+
+// Enqueue stores the request.
+func (q *MemoryQueue) Enqueue(ctx context.Context, req *ModelRequest) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.requests = append(q.requests, req)
+	return nil
+}
+
+// Drain removes and returns the queued requests for replay.
+func (q *MemoryQueue) Drain() []*ModelRequest {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	requests := q.requests
+	q.requests = nil
+	return requests
+}
@@ -0,0 +1,69 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDegradingProvider(t *testing.T) {
+	var (
+		flaky    = &fakeProvider{replies: []string{"Canberra"}, err: errors.New("503 service unavailable")}
+		queue    = NewMemoryQueue()
+		provider = NewDegradingProvider(flaky, FirstOf(QueueForLater(queue, "queued"), CannedResponse("unavailable")), DegradeFromCache(8))
+		known    = &ModelRequest{Model: "m", Messages: []*Message{UserMessage("capital of Australia?")}}
+		unknown  = &ModelRequest{Model: "m", Messages: []*Message{UserMessage("capital of France?")}}
+	)
+	if _, err := provider.Generate(context.Background(), known); err != nil {
+		t.Fatal(err)
+	}
+	flaky.down.Store(true)
+	res, err := provider.Generate(context.Background(), known)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := res.Messages[0]; msg.Text() != "Canberra" || msg.Metadata["degraded"] != "cache" {
+		t.Fatalf("expected stale response, got %q %v", msg.Text(), msg.Metadata)
+	}
+	stream, err := provider.NewStream(context.Background(), unknown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for stream.Next() {
+		if res, err = stream.Current(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if msg := res.Messages[0]; msg.Text() != "queued" || msg.Metadata["degraded"] != "queued" {
+		t.Fatalf("expected queued response, got %q %v", msg.Text(), msg.Metadata)
+	}
+	if requests := queue.Drain(); len(requests) != 1 || requests[0] != unknown {
+		t.Fatalf("unexpected queued requests %v", requests)
+	}
+}
+
+func TestDegradingProvider_Stream(t *testing.T) {
+	tests := []struct {
+		name string
+		lazy bool
+	}{
+		{"degrades failures to open", false},
+		{"degrades failures on the first read", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			down := &fakeProvider{err: errors.New("503 service unavailable"), failures: 1, lazy: tt.lazy}
+			stream, err := NewDegradingProvider(down, CannedResponse("unavailable")).NewStream(context.Background(), &ModelRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := lastResponse(stream)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if msg := res.Messages[0]; msg.Text() != "unavailable" || msg.Metadata["degraded"] != "canned" {
+				t.Fatalf("expected the canned response, got %q %v", msg.Text(), msg.Metadata)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	_ Loader = (*CodeLoader)(nil)
+)
+
+// languages maps file extensions to language names.
+var languages = map[string]string{
+	".go":    "go",
+	".py":    "python",
+	".js":    "javascript",
+	".jsx":   "javascript",
+	".mjs":   "javascript",
+	".ts":    "typescript",
+	".tsx":   "typescript",
+	".java":  "java",
+	".kt":    "kotlin",
+	".rs":    "rust",
+	".c":     "c",
+	".h":     "c",
+	".cc":    "cpp",
+	".cpp":   "cpp",
+	".hpp":   "cpp",
+	".cs":    "csharp",
+	".rb":    "ruby",
+	".php":   "php",
+	".swift": "swift",
+	".scala": "scala",
+	".md":    "markdown",
+	".proto": "protobuf",
+	".sql":   "sql",
+	".sh":    "shell",
+	".yaml":  "yaml",
+	".yml":   "yaml",
+	".json":  "json",
+	".toml":  "toml",
+}
+
+// declaration is a language-specific pattern for top-level declarations.
+// The first submatch is the declaration kind and the second is the symbol name.
+type declaration struct {
+	pattern *regexp.Regexp
+	comment []string
+}
+
+var declarations = map[string]declaration{
+	"go": {
+		pattern: regexp.MustCompile(`^(func|type|var|const)\s+(?:\([^)]*\)\s*)?([A-Za-z_][A-Za-z0-9_]*)`),
+		comment: []string{"//"},
+	},
+	"python": {
+		pattern: regexp.MustCompile(`^(?:async\s+)?(def|class)\s+([A-Za-z_][A-Za-z0-9_]*)`),
+		comment: []string{"#", "@"},
+	},
+	"javascript": {
+		pattern: regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:async\s+)?(function\*?|class|const|let)\s+([A-Za-z_$][A-Za-z0-9_$]*)`),
+		comment: []string{"//", "/*", "*", "@"},
+	},
+	"typescript": {
+		pattern: regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?(?:async\s+)?(function\*?|class|interface|type|enum|const|let)\s+([A-Za-z_$][A-Za-z0-9_$]*)`),
+		comment: []string{"//", "/*", "*", "@"},
+	},
+	"java": {
+		pattern: regexp.MustCompile(`^\s{0,4}(?:(?:public|private|protected|static|final|abstract|sealed)\s+)*(class|interface|enum|record)\s+([A-Za-z_][A-Za-z0-9_]*)`),
+		comment: []string{"//", "/*", "*", "@"},
+	},
+	"kotlin": {
+		pattern: regexp.MustCompile(`^(?:(?:public|private|internal|data|sealed|open|abstract|suspend)\s+)*(fun|class|object|interface)\s+([A-Za-z_][A-Za-z0-9_]*)`),
+		comment: []string{"//", "/*", "*", "@"},
+	},
+	"rust": {
+		pattern: regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:async\s+)?(?:unsafe\s+)?(fn|struct|enum|trait|impl|mod)\s+([A-Za-z_][A-Za-z0-9_<>]*)`),
+		comment: []string{"//", "#["},
+	},
+	"csharp": {
+		pattern: regexp.MustCompile(`^\s{0,4}(?:(?:public|private|protected|internal|static|sealed|abstract|partial)\s+)*(class|interface|enum|struct|record)\s+([A-Za-z_][A-Za-z0-9_]*)`),
+		comment: []string{"//", "[", "/*", "*"},
+	},
+	"ruby": {
+		pattern: regexp.MustCompile(`^\s{0,2}(def|class|module)\s+([A-Za-z_][A-Za-z0-9_.?!]*)`),
+		comment: []string{"#"},
+	},
+	"php": {
+		pattern: regexp.MustCompile(`^(?:(?:final|abstract)\s+)?(function|class|interface|trait)\s+([A-Za-z_][A-Za-z0-9_]*)`),
+		comment: []string{"//", "#", "/*", "*"},
+	},
+	"markdown": {
+		pattern: regexp.MustCompile(`^(#{1,3})\s+(.+)$`),
+	},
+}
+
+// CodeChunk is a contiguous region of a source file around a declaration.
+type CodeChunk struct {
+	Symbol    string
+	Kind      string
+	StartLine int
+	EndLine   int
+	Content   string
+}
+
+// CodeOption configures a CodeLoader.
+type CodeOption func(*codeOptions)
+
+type codeOptions struct {
+	maxChunkSize int
+	maxFileSize  int64
+	extensions   map[string]bool
+}
+
+// WithMaxChunkSize sets the maximum number of bytes per chunk; larger declarations
+// are split on line boundaries.
+func WithMaxChunkSize(n int) CodeOption {
+	return func(o *codeOptions) {
+		o.maxChunkSize = n
+	}
+}
+
+// WithMaxFileSize skips files larger than n bytes.
+func WithMaxFileSize(n int64) CodeOption {
+	return func(o *codeOptions) {
+		o.maxFileSize = n
+	}
+}
+
+// WithExtensions restricts loading to files with the given extensions (e.g. ".go").
+func WithExtensions(exts ...string) CodeOption {
+	return func(o *codeOptions) {
+		o.extensions = make(map[string]bool, len(exts))
+		for _, ext := range exts {
+			o.extensions[ext] = true
+		}
+	}
+}
+
+// CodeLoader walks a source repository, respecting .gitignore files, and splits
+// every file into declaration-level chunks tagged with path and symbol metadata.
+type CodeLoader struct {
+	root string
+	opts codeOptions
+}
+
+// NewCodeLoader creates a new CodeLoader rooted at the given directory.
+func NewCodeLoader(root string, opts ...CodeOption) *CodeLoader {
+	o := codeOptions{maxChunkSize: 4000, maxFileSize: 1 << 20}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &CodeLoader{root: root, opts: o}
+}
+
+// Load walks the repository and returns one document per chunk.
+func (l *CodeLoader) Load(ctx context.Context) ([]*Document, error) {
+	var (
+		docs    []*Document
+		matcher ignoreMatcher
+	)
+	err := filepath.WalkDir(l.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(l.root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if d.Name() == ".git" || (rel != "." && matcher.ignored(rel, true)) {
+				return filepath.SkipDir
+			}
+			return matcher.load(l.root, rel)
+		}
+		if !d.Type().IsRegular() || matcher.ignored(rel, false) {
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(p))
+		language, ok := languages[ext]
+		if !ok || (l.opts.extensions != nil && !l.opts.extensions[ext]) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if l.opts.maxFileSize > 0 && info.Size() > l.opts.maxFileSize {
+			return nil
+		}
+		src, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if bytes.IndexByte(src[:min(len(src), 8000)], 0) >= 0 {
+			return nil // binary file
+		}
+		for _, chunk := range SplitCode(language, string(src), l.opts.maxChunkSize) {
+			docs = append(docs, New(chunk.Content, map[string]any{
+				"source":     rel,
+				"language":   language,
+				"symbol":     chunk.Symbol,
+				"kind":       chunk.Kind,
+				"start_line": chunk.StartLine,
+				"end_line":   chunk.EndLine,
+			}))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// SplitCode splits source into chunks at top-level declarations of the given language.
+// Leading comments and annotations are kept with the declaration they describe.
+// Languages without a known declaration pattern are split by size only.
+func SplitCode(language, source string, maxChunkSize int) []CodeChunk {
+	lines := strings.SplitAfter(source, "\n")
+	decl, ok := declarations[language]
+	type boundary struct {
+		line       int
+		kind, name string
+	}
+	bounds := []boundary{{line: 0}}
+	if ok {
+		for i, line := range lines {
+			m := decl.pattern.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			start := i
+			for start > 0 && hasAnyPrefix(strings.TrimSpace(lines[start-1]), decl.comment) {
+				start--
+			}
+			if start <= bounds[len(bounds)-1].line {
+				start = i
+			}
+			if start == 0 {
+				bounds[0] = boundary{line: 0, kind: m[1], name: strings.TrimSpace(m[2])}
+				continue
+			}
+			bounds = append(bounds, boundary{line: start, kind: m[1], name: strings.TrimSpace(m[2])})
+		}
+	}
+	var chunks []CodeChunk
+	for i, b := range bounds {
+		end := len(lines)
+		if i+1 < len(bounds) {
+			end = bounds[i+1].line
+		}
+		chunks = append(chunks, splitLines(lines[b.line:end], b.line, b.kind, b.name, maxChunkSize)...)
+	}
+	return chunks
+}
+
+// splitLines emits lines as one chunk, or several when they exceed maxChunkSize bytes.
+func splitLines(lines []string, offset int, kind, symbol string, maxChunkSize int) []CodeChunk {
+	var (
+		chunks []CodeChunk
+		buf    strings.Builder
+		start  = 0
+	)
+	flush := func(end int) {
+		if strings.TrimSpace(buf.String()) != "" {
+			chunks = append(chunks, CodeChunk{
+				Symbol:    symbol,
+				Kind:      kind,
+				StartLine: offset + start + 1,
+				EndLine:   offset + end,
+				Content:   buf.String(),
+			})
+		}
+		buf.Reset()
+		start = end
+	}
+	for i, line := range lines {
+		if maxChunkSize > 0 && buf.Len() > 0 && buf.Len()+len(line) > maxChunkSize {
+			flush(i)
+		}
+		buf.WriteString(line)
+	}
+	flush(len(lines))
+	return chunks
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
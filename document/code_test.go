@@ -0,0 +1,77 @@
+package document
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitCode_Go(t *testing.T) {
+	src := `package demo
+
+import "fmt"
+
+// Hello greets.
+func Hello() {
+	fmt.Println("hello")
+}
+
+type T struct{}
+
+func (t *T) Name() string { return "t" }
+`
+	chunks := SplitCode("go", src, 0)
+	want := []struct{ kind, symbol string }{{"", ""}, {"func", "Hello"}, {"type", "T"}, {"func", "Name"}}
+	if len(chunks) != len(want) {
+		t.Fatalf("expected %d chunks, got %d: %+v", len(want), len(chunks), chunks)
+	}
+	for i, w := range want {
+		if chunks[i].Kind != w.kind || chunks[i].Symbol != w.symbol {
+			t.Fatalf("chunk %d: expected %s %s, got %s %s", i, w.kind, w.symbol, chunks[i].Kind, chunks[i].Symbol)
+		}
+	}
+	if chunks[1].StartLine != 5 || chunks[1].Content[:2] != "//" {
+		t.Fatalf("doc comment should start the Hello chunk: %+v", chunks[1])
+	}
+}
+
+func TestCodeLoader_Gitignore(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":          "build/\n*.gen.go\n!keep.gen.go\n",
+		"main.go":             "package main\n\nfunc main() {}\n",
+		"x.gen.go":            "package main\n",
+		"keep.gen.go":         "package main\n",
+		"build/out.go":        "package build\n",
+		"sub/.gitignore":      "/local.py\n",
+		"sub/local.py":        "def f():\n    pass\n",
+		"sub/nested/local.py": "def g():\n    pass\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	docs, err := NewCodeLoader(root).Load(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]bool{}
+	for _, doc := range docs {
+		got[doc.Metadata["source"].(string)] = true
+	}
+	for _, name := range []string{"main.go", "keep.gen.go", "sub/nested/local.py"} {
+		if !got[name] {
+			t.Fatalf("expected %s to be loaded, got %v", name, got)
+		}
+	}
+	for _, name := range []string{"x.gen.go", "build/out.go", "sub/local.py"} {
+		if got[name] {
+			t.Fatalf("expected %s to be ignored", name)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package document
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	_ Loader = (*CSVLoader)(nil)
+)
+
+// CSVLoader loads a CSV file as row-group documents. The first record is the header.
+type CSVLoader struct {
+	path string
+	opts tableOptions
+}
+
+// NewCSVLoader creates a new CSVLoader for the file at path.
+func NewCSVLoader(path string, opts ...TableOption) *CSVLoader {
+	return &CSVLoader{path: path, opts: newTableOptions(opts)}
+}
+
+// Table reads the whole file into a Table.
+func (l *CSVLoader) Table() (*Table, error) {
+	f, err := os.Open(l.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadCSV(f, l.opts.comma)
+}
+
+// Load reads the file and groups its rows into documents.
+func (l *CSVLoader) Load(ctx context.Context) ([]*Document, error) {
+	table, err := l.Table()
+	if err != nil {
+		return nil, err
+	}
+	return table.Documents(l.path, l.opts.rowsPerDocument, l.opts.markdown), nil
+}
+
+// ReadCSV reads CSV records from r into a Table; the first record is the header.
+func ReadCSV(r io.Reader, comma rune) (*Table, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("document: read csv: %w", err)
+	}
+	if len(records) == 0 {
+		return &Table{}, nil
+	}
+	return NewTable(records[0], records[1:]), nil
+}
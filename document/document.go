@@ -0,0 +1,36 @@
+package document
+
+import (
+	"context"
+
+	"github.com/go-kratos/blades"
+)
+
+// Document is a unit of loaded content with metadata describing its origin.
+type Document struct {
+	ID       string         `json:"id"`
+	Content  string         `json:"content"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// New creates a new Document with an ID from blades.NewID, a random UUID unless
+// blades.SetIDGenerator replaced the generator.
+func New(content string, metadata map[string]any) *Document {
+	if metadata == nil {
+		metadata = make(map[string]any)
+	}
+	return &Document{ID: blades.NewID(), Content: content, Metadata: metadata}
+}
+
+// Loader loads documents from a source such as a file, a bucket or a website.
+type Loader interface {
+	Load(context.Context) ([]*Document, error)
+}
+
+// SyncLoader is a Loader that supports incremental synchronization. The cursor is
+// opaque to callers: pass an empty cursor for a full load, then persist the returned
+// cursor and pass it to the next Sync to load only documents changed since.
+type SyncLoader interface {
+	Loader
+	Sync(ctx context.Context, cursor string) ([]*Document, string, error)
+}
@@ -0,0 +1,115 @@
+package document
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is a single parsed .gitignore pattern scoped to the directory it was found in.
+type ignoreRule struct {
+	dir      string
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// ignoreMatcher evaluates .gitignore rules collected while walking a tree.
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+// load appends the rules of the .gitignore file in dir (relative to the root), if any.
+func (m *ignoreMatcher) load(root, dir string) error {
+	f, err := os.Open(filepath.Join(root, dir, ".gitignore"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		m.add(dir, scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// add parses a single .gitignore line.
+func (m *ignoreMatcher) add(dir, line string) {
+	line = strings.TrimRight(line, " \t")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+	rule := ignoreRule{dir: filepath.ToSlash(dir)}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	rule.pattern = line
+	m.rules = append(m.rules, rule)
+}
+
+// ignored reports whether the slash-separated relative path is ignored.
+// Later rules override earlier ones, as in git.
+func (m *ignoreMatcher) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		p := rel
+		if rule.dir != "" && rule.dir != "." {
+			if !strings.HasPrefix(rel, rule.dir+"/") {
+				continue
+			}
+			p = strings.TrimPrefix(rel, rule.dir+"/")
+		}
+		if rule.matches(p) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+func (r ignoreRule) matches(p string) bool {
+	if !r.anchored {
+		ok, _ := path.Match(r.pattern, path.Base(p))
+		return ok
+	}
+	return matchGlob(strings.Split(r.pattern, "/"), strings.Split(p, "/"))
+}
+
+// matchGlob matches path segments against pattern segments, supporting "**".
+func matchGlob(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchGlob(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchGlob(pattern[1:], segments[1:])
+}
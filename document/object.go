@@ -0,0 +1,288 @@
+package document
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	_ SyncLoader = (*ObjectLoader)(nil)
+)
+
+// Object describes an object stored in a bucket.
+type Object struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Bucket lists and reads objects of an object store such as S3 or GCS.
+type Bucket interface {
+	// List returns up to limit objects under prefix whose keys sort after startAfter,
+	// in lexicographic key order. An empty result means the listing is complete.
+	List(ctx context.Context, prefix, startAfter string, limit int) ([]Object, error)
+	// Open opens the object for reading.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// Parser converts the content of an object into documents.
+type Parser func(ctx context.Context, r io.Reader, obj Object) ([]*Document, error)
+
+// Checkpointer persists the position of an ObjectLoader so that interrupted
+// loads of huge buckets resume where they stopped.
+type Checkpointer interface {
+	Load(ctx context.Context) (string, error)
+	Save(ctx context.Context, cursor string) error
+}
+
+// ObjectOption configures an ObjectLoader.
+type ObjectOption func(*ObjectLoader)
+
+// WithParser registers a parser for a MIME type such as "text/html" or a
+// MIME family such as "text/*".
+func WithParser(mimeType string, parser Parser) ObjectOption {
+	return func(l *ObjectLoader) {
+		l.parsers[mimeType] = parser
+	}
+}
+
+// WithCheckpointer saves the loader position after every listed page.
+func WithCheckpointer(c Checkpointer) ObjectOption {
+	return func(l *ObjectLoader) {
+		l.checkpoint = c
+	}
+}
+
+// WithPageSize sets how many objects are listed per request.
+func WithPageSize(n int) ObjectOption {
+	return func(l *ObjectLoader) {
+		l.pageSize = n
+	}
+}
+
+// WithMaxObjectSize skips objects larger than n bytes.
+func WithMaxObjectSize(n int64) ObjectOption {
+	return func(l *ObjectLoader) {
+		l.maxSize = n
+	}
+}
+
+// ObjectLoader streams objects under a prefix from a Bucket and dispatches each
+// object to a parser chosen by its MIME type. Objects without a matching parser
+// are skipped.
+type ObjectLoader struct {
+	bucket     Bucket
+	prefix     string
+	pageSize   int
+	maxSize    int64
+	parsers    map[string]Parser
+	checkpoint Checkpointer
+}
+
+// NewObjectLoader creates a new ObjectLoader with parsers for text, markdown,
+// HTML, JSON and CSV objects.
+func NewObjectLoader(bucket Bucket, prefix string, opts ...ObjectOption) *ObjectLoader {
+	l := &ObjectLoader{
+		bucket:   bucket,
+		prefix:   prefix,
+		pageSize: 1000,
+		maxSize:  32 << 20,
+		parsers: map[string]Parser{
+			"text/*":           parseText,
+			"application/json": parseText,
+			"text/html":        parseHTML,
+			"text/csv":         parseCSV,
+		},
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Load loads every object, resuming from the checkpoint if one is configured.
+func (l *ObjectLoader) Load(ctx context.Context) ([]*Document, error) {
+	cursor := ""
+	if l.checkpoint != nil {
+		var err error
+		if cursor, err = l.checkpoint.Load(ctx); err != nil {
+			return nil, err
+		}
+	}
+	docs, _, err := l.Sync(ctx, cursor)
+	return docs, err
+}
+
+// Sync loads the objects whose keys sort after cursor and returns the last key seen.
+func (l *ObjectLoader) Sync(ctx context.Context, cursor string) ([]*Document, string, error) {
+	var docs []*Document
+	next, err := l.Walk(ctx, cursor, func(doc *Document) error {
+		docs = append(docs, doc)
+		return nil
+	})
+	return docs, next, err
+}
+
+// Walk streams documents to fn without holding the whole bucket in memory.
+// It returns the key of the last processed object.
+func (l *ObjectLoader) Walk(ctx context.Context, cursor string, fn func(*Document) error) (string, error) {
+	for {
+		objects, err := l.bucket.List(ctx, l.prefix, cursor, l.pageSize)
+		if err != nil {
+			return cursor, err
+		}
+		if len(objects) == 0 {
+			return cursor, nil
+		}
+		for _, obj := range objects {
+			if obj.Key <= cursor {
+				continue
+			}
+			docs, err := l.loadObject(ctx, obj)
+			if err != nil {
+				return cursor, err
+			}
+			for _, doc := range docs {
+				if err := fn(doc); err != nil {
+					return cursor, err
+				}
+			}
+			cursor = obj.Key
+		}
+		if l.checkpoint != nil {
+			if err := l.checkpoint.Save(ctx, cursor); err != nil {
+				return cursor, err
+			}
+		}
+	}
+}
+
+// loadObject reads one object and parses it with the parser for its MIME type.
+func (l *ObjectLoader) loadObject(ctx context.Context, obj Object) ([]*Document, error) {
+	if strings.HasSuffix(obj.Key, "/") || (l.maxSize > 0 && obj.Size > l.maxSize) {
+		return nil, nil
+	}
+	rc, err := l.bucket.Open(ctx, obj.Key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	br := bufio.NewReader(rc)
+	if obj.ContentType == "" || obj.ContentType == "application/octet-stream" {
+		obj.ContentType = DetectMimeType(obj.Key, br)
+	}
+	parser := l.parser(obj.ContentType)
+	if parser == nil {
+		return nil, nil
+	}
+	docs, err := parser(ctx, br, obj)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		doc.Metadata["source"] = obj.Key
+		doc.Metadata["content_type"] = obj.ContentType
+		if !obj.ModTime.IsZero() {
+			doc.Metadata["last_modified"] = obj.ModTime.Format(time.RFC3339)
+		}
+	}
+	return docs, nil
+}
+
+// parser returns the parser registered for the exact MIME type or its family.
+func (l *ObjectLoader) parser(contentType string) Parser {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+	if p, ok := l.parsers[mediaType]; ok {
+		return p
+	}
+	if family, _, ok := strings.Cut(mediaType, "/"); ok {
+		return l.parsers[family+"/*"]
+	}
+	return nil
+}
+
+// DetectMimeType guesses the MIME type of an object from its extension,
+// falling back to sniffing the first bytes of r.
+func DetectMimeType(name string, r *bufio.Reader) string {
+	switch ext := strings.ToLower(path.Ext(name)); ext {
+	case ".md", ".markdown":
+		return "text/markdown"
+	case ".csv":
+		return "text/csv"
+	case "":
+	default:
+		if t := mime.TypeByExtension(ext); t != "" {
+			return t
+		}
+	}
+	head, _ := r.Peek(512)
+	return http.DetectContentType(head)
+}
+
+func parseText(ctx context.Context, r io.Reader, obj Object) ([]*Document, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(b)) == 0 {
+		return nil, nil
+	}
+	return []*Document{New(string(b), nil)}, nil
+}
+
+func parseHTML(ctx context.Context, r io.Reader, obj Object) ([]*Document, error) {
+	text, err := HTMLToText(r)
+	if err != nil || text == "" {
+		return nil, err
+	}
+	return []*Document{New(text, nil)}, nil
+}
+
+func parseCSV(ctx context.Context, r io.Reader, obj Object) ([]*Document, error) {
+	table, err := ReadCSV(r, ',')
+	if err != nil {
+		return nil, err
+	}
+	return table.Documents(obj.Key, 50, false), nil
+}
+
+// FileCheckpointer stores the loader position in a local file.
+type FileCheckpointer struct {
+	path string
+}
+
+// NewFileCheckpointer creates a new FileCheckpointer writing to path.
+func NewFileCheckpointer(path string) *FileCheckpointer {
+	return &FileCheckpointer{path: path}
+}
+
+// Load returns the saved cursor, or an empty cursor if none was saved yet.
+func (c *FileCheckpointer) Load(ctx context.Context) (string, error) {
+	b, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	return strings.TrimSpace(string(b)), err
+}
+
+// Save atomically replaces the saved cursor.
+func (c *FileCheckpointer) Save(ctx context.Context, cursor string) error {
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(cursor), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
@@ -0,0 +1,69 @@
+package document
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type memBucket map[string]string
+
+func (b memBucket) List(ctx context.Context, prefix, startAfter string, limit int) ([]Object, error) {
+	var keys []string
+	for key := range b {
+		if strings.HasPrefix(key, prefix) && key > startAfter {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	objects := make([]Object, len(keys))
+	for i, key := range keys {
+		objects[i] = Object{Key: key, Size: int64(len(b[key]))}
+	}
+	return objects, nil
+}
+
+func (b memBucket) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(b[key])), nil
+}
+
+func TestObjectLoader_Checkpoint(t *testing.T) {
+	bucket := memBucket{
+		"docs/a.txt":  "alpha",
+		"docs/b.html": "<html><body><p>bravo</p></body></html>",
+		"docs/c.png":  "\x89PNG\r\n\x1a\n",
+		"docs/d.md":   "# delta",
+		"other/e.txt": "echo",
+	}
+	cp := NewFileCheckpointer(filepath.Join(t.TempDir(), "cursor"))
+	loader := NewObjectLoader(bucket, "docs/", WithPageSize(2), WithCheckpointer(cp))
+	docs, err := loader.Load(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, doc := range docs {
+		got = append(got, doc.Content)
+	}
+	if strings.Join(got, ",") != "alpha,bravo,# delta" {
+		t.Fatalf("unexpected documents: %q", got)
+	}
+	cursor, err := cp.Load(t.Context())
+	if err != nil || cursor != "docs/d.md" {
+		t.Fatalf("unexpected checkpoint %q: %v", cursor, err)
+	}
+	bucket["docs/f.txt"] = "foxtrot"
+	docs, err = loader.Load(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 || docs[0].Content != "foxtrot" {
+		t.Fatalf("expected only the new object after resume, got %d documents", len(docs))
+	}
+}
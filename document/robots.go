@@ -0,0 +1,98 @@
+package document
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robots holds the rules of a robots.txt file that apply to one user agent.
+type robots struct {
+	allow      []string
+	disallow   []string
+	crawlDelay time.Duration
+}
+
+// parseRobots parses a robots.txt body, keeping the group that best matches userAgent.
+// A group naming the agent explicitly takes precedence over the "*" group.
+func parseRobots(r io.Reader, userAgent string) *robots {
+	var (
+		specific, wildcard *robots
+		current            []*robots
+		inRules            bool
+		agent              = strings.ToLower(userAgent)
+	)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if inRules {
+				current, inRules = nil, false
+			}
+			name := strings.ToLower(value)
+			switch {
+			case name == "*":
+				if wildcard == nil {
+					wildcard = &robots{}
+				}
+				current = append(current, wildcard)
+			case name != "" && strings.Contains(agent, name):
+				if specific == nil {
+					specific = &robots{}
+				}
+				current = append(current, specific)
+			}
+		case "allow", "disallow", "crawl-delay":
+			inRules = true
+			for _, group := range current {
+				switch key {
+				case "allow":
+					group.allow = append(group.allow, value)
+				case "disallow":
+					if value != "" {
+						group.disallow = append(group.disallow, value)
+					}
+				case "crawl-delay":
+					if secs, err := strconv.ParseFloat(value, 64); err == nil {
+						group.crawlDelay = time.Duration(secs * float64(time.Second))
+					}
+				}
+			}
+		}
+	}
+	if specific != nil {
+		return specific
+	}
+	if wildcard != nil {
+		return wildcard
+	}
+	return &robots{}
+}
+
+// allowed reports whether path may be fetched; the longest matching rule wins.
+func (r *robots) allowed(path string) bool {
+	best, allow := -1, true
+	for _, rule := range r.allow {
+		if strings.HasPrefix(path, rule) && len(rule) > best {
+			best, allow = len(rule), true
+		}
+	}
+	for _, rule := range r.disallow {
+		if strings.HasPrefix(path, rule) && len(rule) > best {
+			best, allow = len(rule), false
+		}
+	}
+	return allow
+}
@@ -0,0 +1,296 @@
+package document
+
+import (
+	"maps"
+	"regexp"
+	"strings"
+)
+
+var (
+	_ Splitter = (*RecursiveSplitter)(nil)
+	_ Splitter = (*MarkdownSplitter)(nil)
+)
+
+// Metadata keys set on the chunks produced by splitters.
+const (
+	// ParentIDKey holds the ID of the document a chunk was split from.
+	ParentIDKey = "parent_id"
+	// ChunkKey holds the index of a chunk within its document.
+	ChunkKey = "chunk"
+	// HeadingsKey holds the Markdown headings enclosing a chunk, joined by " > ".
+	HeadingsKey = "headings"
+)
+
+// Splitter splits documents into chunks small enough to embed and retrieve.
+// Chunks keep the metadata of their document, plus ParentIDKey and ChunkKey.
+type Splitter interface {
+	Split(docs []*Document) []*Document
+}
+
+// SplitOption configures a splitter.
+type SplitOption func(*splitOptions)
+
+type splitOptions struct {
+	size       int
+	overlap    int
+	length     func(string) int
+	separators []string
+}
+
+// WithChunkSize sets the maximum length of a chunk, 1000 by default.
+func WithChunkSize(n int) SplitOption {
+	return func(o *splitOptions) {
+		o.size = n
+	}
+}
+
+// WithChunkOverlap sets the length of text shared by adjacent chunks, so that
+// passages spanning a boundary are retrievable from either, 200 by default.
+func WithChunkOverlap(n int) SplitOption {
+	return func(o *splitOptions) {
+		o.overlap = n
+	}
+}
+
+// WithSeparators sets the separators tried in order to split text, from the
+// coarsest to the finest. The default is paragraphs, lines, sentences, words
+// and characters.
+func WithSeparators(separators ...string) SplitOption {
+	return func(o *splitOptions) {
+		o.separators = separators
+	}
+}
+
+// RecursiveSplitter splits text on the coarsest separator that yields pieces
+// within the chunk size, splitting oversized pieces again on finer separators,
+// then merges adjacent pieces into chunks of up to the chunk size with overlap.
+type RecursiveSplitter struct {
+	opts splitOptions
+}
+
+// NewRecursiveSplitter creates a RecursiveSplitter measuring lengths in characters.
+func NewRecursiveSplitter(opts ...SplitOption) *RecursiveSplitter {
+	o := splitOptions{
+		size:       1000,
+		overlap:    200,
+		length:     func(text string) int { return len([]rune(text)) },
+		separators: []string{"\n\n", "\n", ". ", " ", ""},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.overlap >= o.size {
+		o.overlap = 0
+	}
+	return &RecursiveSplitter{opts: o}
+}
+
+// NewTokenSplitter creates a RecursiveSplitter measuring chunk size and overlap
+// in tokens as counted by count, so that chunks fit the input limit of an
+// embedding model. A nil count estimates 4 bytes per token.
+func NewTokenSplitter(count func(text string) int, opts ...SplitOption) *RecursiveSplitter {
+	if count == nil {
+		count = func(text string) int { return (len(text) + 3) / 4 }
+	}
+	s := NewRecursiveSplitter(append([]SplitOption{WithChunkSize(256), WithChunkOverlap(32)}, opts...)...)
+	s.opts.length = count
+	return s
+}
+
+// Split splits each document into chunks.
+func (s *RecursiveSplitter) Split(docs []*Document) []*Document {
+	var chunks []*Document
+	for _, doc := range docs {
+		chunks = appendChunks(chunks, doc, s.SplitText(doc.Content), nil)
+	}
+	return chunks
+}
+
+// SplitText splits text into chunks.
+func (s *RecursiveSplitter) SplitText(text string) []string {
+	return s.split(text, s.opts.separators)
+}
+
+func (s *RecursiveSplitter) split(text string, separators []string) []string {
+	// use the first separator found in the text
+	var sep string
+	for i, candidate := range separators {
+		if candidate == "" || strings.Contains(text, candidate) {
+			sep, separators = candidate, separators[i+1:]
+			break
+		}
+	}
+	var (
+		chunks []string
+		pieces []string
+	)
+	for _, piece := range splitAfter(text, sep) {
+		if s.opts.length(piece) <= s.opts.size {
+			pieces = append(pieces, piece)
+			continue
+		}
+		chunks = append(chunks, s.merge(pieces)...)
+		pieces = nil
+		if len(separators) == 0 {
+			chunks = append(chunks, piece)
+			continue
+		}
+		chunks = append(chunks, s.split(piece, separators)...)
+	}
+	return append(chunks, s.merge(pieces)...)
+}
+
+// merge joins pieces into chunks of up to the chunk size, starting each chunk
+// with the trailing pieces of the previous one up to the overlap.
+func (s *RecursiveSplitter) merge(pieces []string) []string {
+	var (
+		chunks  []string
+		current []string
+		total   int
+	)
+	emit := func() {
+		if chunk := strings.TrimSpace(strings.Join(current, "")); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+	}
+	for _, piece := range pieces {
+		n := s.opts.length(piece)
+		if total+n > s.opts.size && len(current) > 0 {
+			emit()
+			for len(current) > 0 && (total > s.opts.overlap || total+n > s.opts.size) {
+				total -= s.opts.length(current[0])
+				current = current[1:]
+			}
+		}
+		current = append(current, piece)
+		total += n
+	}
+	emit()
+	return chunks
+}
+
+// splitAfter splits text after each separator, or into characters when the
+// separator is empty.
+func splitAfter(text, sep string) []string {
+	if sep != "" {
+		return strings.SplitAfter(text, sep)
+	}
+	pieces := make([]string, 0, len(text))
+	for _, r := range text {
+		pieces = append(pieces, string(r))
+	}
+	return pieces
+}
+
+var markdownHeading = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+
+// MarkdownSplitter splits Markdown documents at their headings, so that chunks
+// follow the structure of the document, and records the enclosing headings of
+// each chunk under HeadingsKey. Sections larger than the chunk size are split
+// further with a RecursiveSplitter. Headings within code blocks are ignored.
+type MarkdownSplitter struct {
+	recursive *RecursiveSplitter
+}
+
+// NewMarkdownSplitter creates a MarkdownSplitter.
+func NewMarkdownSplitter(opts ...SplitOption) *MarkdownSplitter {
+	return &MarkdownSplitter{recursive: NewRecursiveSplitter(opts...)}
+}
+
+// Split splits each document into chunks.
+func (s *MarkdownSplitter) Split(docs []*Document) []*Document {
+	var chunks []*Document
+	for _, doc := range docs {
+		var index int
+		for _, section := range markdownSections(doc.Content) {
+			texts := s.recursive.SplitText(section.content)
+			extra := map[string]any{}
+			if section.headings != "" {
+				extra[HeadingsKey] = section.headings
+			}
+			for _, text := range texts {
+				chunks = appendChunks(chunks, doc, []string{text}, extra)
+				chunks[len(chunks)-1].Metadata[ChunkKey] = index
+				index++
+			}
+		}
+	}
+	return chunks
+}
+
+type markdownSection struct {
+	headings string
+	content  string
+}
+
+// markdownSections splits Markdown text at its headings.
+func markdownSections(text string) []markdownSection {
+	type heading struct {
+		level int
+		title string
+	}
+	var (
+		sections []markdownSection
+		stack    []heading
+		buf      strings.Builder
+		body     bool
+		fence    string
+	)
+	path := func() string {
+		titles := make([]string, len(stack))
+		for i, h := range stack {
+			titles[i] = h.title
+		}
+		return strings.Join(titles, " > ")
+	}
+	// sections holding only their heading are dropped, the heading being
+	// recorded in the path of the subsections
+	flush := func() {
+		if body {
+			sections = append(sections, markdownSection{headings: path(), content: buf.String()})
+		}
+		buf.Reset()
+		body = false
+	}
+	for line := range strings.Lines(text) {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			fence = trimmed[:3]
+		default:
+			if m := markdownHeading.FindStringSubmatch(strings.TrimRight(line, "\r\n")); m != nil {
+				flush()
+				level := len(m[1])
+				for len(stack) > 0 && stack[len(stack)-1].level >= level {
+					stack = stack[:len(stack)-1]
+				}
+				stack = append(stack, heading{level: level, title: m[2]})
+				buf.WriteString(line)
+				continue
+			}
+		}
+		body = body || trimmed != ""
+		buf.WriteString(line)
+	}
+	flush()
+	return sections
+}
+
+// appendChunks appends a document per text, with the metadata of doc and extra.
+func appendChunks(chunks []*Document, doc *Document, texts []string, extra map[string]any) []*Document {
+	for i, text := range texts {
+		metadata := maps.Clone(doc.Metadata)
+		if metadata == nil {
+			metadata = make(map[string]any)
+		}
+		maps.Copy(metadata, extra)
+		metadata[ParentIDKey] = doc.ID
+		metadata[ChunkKey] = i
+		chunks = append(chunks, New(text, metadata))
+	}
+	return chunks
+}
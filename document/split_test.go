@@ -0,0 +1,48 @@
+package document
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecursiveSplitter(t *testing.T) {
+	text := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 10) + "\n\n" + strings.Repeat("word ", 30)
+	doc := New(text, map[string]any{"source": "fox.txt"})
+	chunks := NewRecursiveSplitter(WithChunkSize(100), WithChunkOverlap(20)).Split([]*Document{doc})
+	if len(chunks) < 5 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	for i, c := range chunks {
+		if n := len([]rune(c.Content)); n > 100 {
+			t.Fatalf("chunk %d has %d characters", i, n)
+		}
+		if c.Metadata["source"] != "fox.txt" || c.Metadata[ParentIDKey] != doc.ID || c.Metadata[ChunkKey] != i {
+			t.Fatalf("unexpected metadata %v", c.Metadata)
+		}
+	}
+	// adjacent chunks overlap
+	first, second := chunks[0].Content, chunks[1].Content
+	if !strings.Contains(first, second[:10]) {
+		t.Fatalf("expected overlap between %q and %q", first, second)
+	}
+
+	tokens := NewTokenSplitter(func(text string) int { return len(strings.Fields(text)) }, WithChunkSize(10), WithChunkOverlap(0)).SplitText(strings.Repeat("word ", 30))
+	if len(tokens) != 3 {
+		t.Fatalf("expected 3 chunks of 10 tokens, got %d", len(tokens))
+	}
+}
+
+func TestMarkdownSplitter(t *testing.T) {
+	text := "# Guide\nIntro.\n\n## Install\nRun it.\n```sh\n# not a heading\n```\n## Usage\n### Flags\nUse -v.\n"
+	chunks := NewMarkdownSplitter().Split([]*Document{New(text, nil)})
+	var headings []string
+	for _, c := range chunks {
+		headings = append(headings, c.Metadata[HeadingsKey].(string))
+	}
+	if got := strings.Join(headings, " | "); got != "Guide | Guide > Install | Guide > Usage > Flags" {
+		t.Fatalf("unexpected headings %q", got)
+	}
+	if !strings.Contains(chunks[1].Content, "# not a heading") {
+		t.Fatalf("code block split: %q", chunks[1].Content)
+	}
+}
@@ -0,0 +1,193 @@
+package document
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Column describes a single column of a table.
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Table is a rectangular set of rows with named columns.
+type Table struct {
+	Columns []Column
+	Rows    [][]string
+}
+
+// NewTable creates a Table from a header row and data rows, inferring column types.
+func NewTable(header []string, rows [][]string) *Table {
+	columns := make([]Column, len(header))
+	for i, name := range header {
+		columns[i] = Column{Name: strings.TrimSpace(name), Type: inferType(rows, i)}
+	}
+	return &Table{Columns: columns, Rows: rows}
+}
+
+// Markdown renders the header and up to limit rows as a markdown table.
+// A limit <= 0 renders all rows.
+func (t *Table) Markdown(limit int) string {
+	rows := t.Rows
+	if limit > 0 && len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return t.render(rows, true)
+}
+
+// render formats rows either as a markdown table or as "column: value" records.
+func (t *Table) render(rows [][]string, markdown bool) string {
+	var buf strings.Builder
+	if markdown {
+		buf.WriteString("|")
+		for _, col := range t.Columns {
+			buf.WriteString(" " + escapeCell(col.Name) + " |")
+		}
+		buf.WriteString("\n|")
+		for range t.Columns {
+			buf.WriteString(" --- |")
+		}
+		buf.WriteString("\n")
+		for _, row := range rows {
+			buf.WriteString("|")
+			for i := range t.Columns {
+				buf.WriteString(" " + escapeCell(cell(row, i)) + " |")
+			}
+			buf.WriteString("\n")
+		}
+		return buf.String()
+	}
+	for n, row := range rows {
+		if n > 0 {
+			buf.WriteString("\n")
+		}
+		for i, col := range t.Columns {
+			buf.WriteString(col.Name + ": " + cell(row, i) + "\n")
+		}
+	}
+	return buf.String()
+}
+
+// Documents groups the rows into documents of at most rowsPerDocument rows.
+func (t *Table) Documents(source string, rowsPerDocument int, markdown bool) []*Document {
+	if rowsPerDocument <= 0 {
+		rowsPerDocument = len(t.Rows)
+	}
+	var docs []*Document
+	for start := 0; start < len(t.Rows); start += rowsPerDocument {
+		end := min(start+rowsPerDocument, len(t.Rows))
+		docs = append(docs, New(t.render(t.Rows[start:end], markdown), map[string]any{
+			"source":    source,
+			"columns":   t.Columns,
+			"row_start": start,
+			"row_end":   end,
+		}))
+	}
+	return docs
+}
+
+// TableOption configures tabular loaders.
+type TableOption func(*tableOptions)
+
+type tableOptions struct {
+	rowsPerDocument int
+	markdown        bool
+	sheet           string
+	comma           rune
+}
+
+// WithRowsPerDocument sets how many rows are grouped into each document.
+func WithRowsPerDocument(n int) TableOption {
+	return func(o *tableOptions) {
+		o.rowsPerDocument = n
+	}
+}
+
+// WithMarkdown renders row groups as markdown tables instead of "column: value" records.
+func WithMarkdown(markdown bool) TableOption {
+	return func(o *tableOptions) {
+		o.markdown = markdown
+	}
+}
+
+// WithSheet selects the worksheet to load from a workbook; defaults to the first sheet.
+func WithSheet(name string) TableOption {
+	return func(o *tableOptions) {
+		o.sheet = name
+	}
+}
+
+// WithComma sets the field delimiter for CSV files.
+func WithComma(comma rune) TableOption {
+	return func(o *tableOptions) {
+		o.comma = comma
+	}
+}
+
+func newTableOptions(opts []TableOption) tableOptions {
+	o := tableOptions{rowsPerDocument: 50, comma: ','}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// inferType returns "number", "boolean", "date" or "string" for the i-th column.
+func inferType(rows [][]string, i int) string {
+	kinds := map[string]bool{}
+	for _, row := range rows {
+		v := strings.TrimSpace(cell(row, i))
+		if v == "" {
+			continue
+		}
+		switch {
+		case isNumber(v):
+			kinds["number"] = true
+		case isBool(v):
+			kinds["boolean"] = true
+		case isDate(v):
+			kinds["date"] = true
+		default:
+			return "string"
+		}
+	}
+	if len(kinds) == 1 {
+		for kind := range kinds {
+			return kind
+		}
+	}
+	return "string"
+}
+
+func isNumber(v string) bool {
+	_, err := strconv.ParseFloat(strings.ReplaceAll(v, ",", ""), 64)
+	return err == nil
+}
+
+func isBool(v string) bool {
+	_, err := strconv.ParseBool(v)
+	return err == nil
+}
+
+func isDate(v string) bool {
+	for _, layout := range []string{time.DateOnly, time.DateTime, time.RFC3339} {
+		if _, err := time.Parse(layout, v); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func cell(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
+
+func escapeCell(v string) string {
+	v = strings.ReplaceAll(v, "|", `\|`)
+	return strings.ReplaceAll(v, "\n", " ")
+}
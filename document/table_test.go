@@ -0,0 +1,33 @@
+package document
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	table, err := ReadCSV(strings.NewReader("name,age,active\nalice,30,true\nbob,41,false\ncarol,,true\n"), ',')
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Column{{"name", "string"}, {"age", "number"}, {"active", "boolean"}}
+	for i, col := range table.Columns {
+		if col != want[i] {
+			t.Fatalf("column %d: expected %+v, got %+v", i, want[i], col)
+		}
+	}
+	md := table.Markdown(2)
+	if !strings.HasPrefix(md, "| name | age | active |\n| --- | --- | --- |\n") {
+		t.Fatalf("unexpected markdown header: %q", md)
+	}
+	if strings.Contains(md, "carol") {
+		t.Fatalf("markdown should be limited to 2 rows: %q", md)
+	}
+	docs := table.Documents("people.csv", 2, false)
+	if len(docs) != 2 {
+		t.Fatalf("expected 2 documents, got %d", len(docs))
+	}
+	if docs[1].Metadata["row_start"] != 2 || !strings.Contains(docs[1].Content, "name: carol") {
+		t.Fatalf("unexpected second document: %+v", docs[1])
+	}
+}
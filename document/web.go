@@ -0,0 +1,425 @@
+package document
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/blades"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	_ Loader = (*WebLoader)(nil)
+)
+
+// WebOption configures a WebLoader.
+type WebOption func(*webOptions)
+
+type webOptions struct {
+	client    *http.Client
+	userAgent string
+	maxDepth  int
+	maxPages  int
+	delay     time.Duration
+	sameHost  bool
+}
+
+// WithHTTPClient sets the HTTP client used for fetching pages.
+func WithHTTPClient(client *http.Client) WebOption {
+	return func(o *webOptions) {
+		o.client = client
+	}
+}
+
+// WithUserAgent sets the User-Agent header and the robots.txt agent name.
+func WithUserAgent(userAgent string) WebOption {
+	return func(o *webOptions) {
+		o.userAgent = userAgent
+	}
+}
+
+// WithMaxDepth sets how many links away from the seed URLs the crawler may go.
+// A depth of 0 fetches the seeds only.
+func WithMaxDepth(depth int) WebOption {
+	return func(o *webOptions) {
+		o.maxDepth = depth
+	}
+}
+
+// WithMaxPages limits the total number of pages fetched.
+func WithMaxPages(n int) WebOption {
+	return func(o *webOptions) {
+		o.maxPages = n
+	}
+}
+
+// WithCrawlDelay sets the minimum delay between requests to the same host.
+// A larger Crawl-delay from robots.txt takes precedence.
+func WithCrawlDelay(delay time.Duration) WebOption {
+	return func(o *webOptions) {
+		o.delay = delay
+	}
+}
+
+// WithSameHost restricts link following to the hosts of the seed URLs.
+func WithSameHost(sameHost bool) WebOption {
+	return func(o *webOptions) {
+		o.sameHost = sameHost
+	}
+}
+
+// WebLoader crawls web pages breadth-first from seed URLs or a sitemap, honoring
+// robots.txt and per-host rate limits, and extracts the readable text of each page.
+type WebLoader struct {
+	seeds   []string
+	sitemap string
+	opts    webOptions
+
+	mu      sync.Mutex
+	robots  map[string]*robots
+	lastHit map[string]time.Time
+}
+
+// NewWebLoader creates a new WebLoader that starts crawling from the seed URLs.
+func NewWebLoader(seeds []string, opts ...WebOption) *WebLoader {
+	o := webOptions{
+		client:    http.DefaultClient,
+		userAgent: "blades-crawler",
+		maxDepth:  1,
+		maxPages:  100,
+		delay:     time.Second,
+		sameHost:  true,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &WebLoader{
+		seeds:   seeds,
+		opts:    o,
+		robots:  make(map[string]*robots),
+		lastHit: make(map[string]time.Time),
+	}
+}
+
+// NewSitemapLoader creates a new WebLoader that fetches every page listed in the
+// sitemap (or sitemap index) at sitemapURL. Links are not followed unless a depth is set.
+func NewSitemapLoader(sitemapURL string, opts ...WebOption) *WebLoader {
+	l := NewWebLoader(nil, append([]WebOption{WithMaxDepth(0)}, opts...)...)
+	l.sitemap = sitemapURL
+	return l
+}
+
+type crawlItem struct {
+	url   *url.URL
+	depth int
+}
+
+// Load crawls the configured pages and returns one document per page.
+func (l *WebLoader) Load(ctx context.Context) ([]*Document, error) {
+	seeds := l.seeds
+	if l.sitemap != "" {
+		urls, err := l.readSitemap(ctx, l.sitemap, 0)
+		if err != nil {
+			return nil, err
+		}
+		seeds = append(seeds, urls...)
+	}
+	var (
+		docs  []*Document
+		queue []crawlItem
+		seen  = make(map[string]bool)
+		hosts = make(map[string]bool)
+	)
+	for _, seed := range seeds {
+		u, err := url.Parse(seed)
+		if err != nil {
+			return nil, fmt.Errorf("document: invalid seed url %q: %w", seed, err)
+		}
+		u.Fragment = ""
+		hosts[u.Host] = true
+		if !seen[u.String()] {
+			seen[u.String()] = true
+			queue = append(queue, crawlItem{url: u})
+		}
+	}
+	for len(queue) > 0 && len(docs) < l.opts.maxPages {
+		item := queue[0]
+		queue = queue[1:]
+		ok, err := l.allowed(ctx, item.url)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		page, err := l.fetchPage(ctx, item.url)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			continue // skip unreachable pages
+		}
+		if page == nil {
+			continue
+		}
+		if strings.TrimSpace(page.text) != "" {
+			docs = append(docs, New(page.text, map[string]any{
+				"source": item.url.String(),
+				"title":  page.title,
+				"depth":  item.depth,
+			}))
+		}
+		if item.depth >= l.opts.maxDepth {
+			continue
+		}
+		for _, link := range page.links {
+			next, err := item.url.Parse(link)
+			if err != nil || (next.Scheme != "http" && next.Scheme != "https") {
+				continue
+			}
+			next.Fragment = ""
+			if l.opts.sameHost && !hosts[next.Host] {
+				continue
+			}
+			if !seen[next.String()] {
+				seen[next.String()] = true
+				queue = append(queue, crawlItem{url: next, depth: item.depth + 1})
+			}
+		}
+	}
+	return docs, nil
+}
+
+// allowed checks robots.txt for the URL, fetching and caching it per host.
+func (l *WebLoader) allowed(ctx context.Context, u *url.URL) (bool, error) {
+	l.mu.Lock()
+	rules, ok := l.robots[u.Host]
+	l.mu.Unlock()
+	if !ok {
+		rules = &robots{}
+		robotsURL := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+		res, err := l.get(ctx, robotsURL)
+		if err != nil && ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		if err == nil {
+			if res.StatusCode == http.StatusOK {
+				rules = parseRobots(io.LimitReader(res.Body, 512<<10), l.opts.userAgent)
+			}
+			res.Body.Close()
+		}
+		l.mu.Lock()
+		l.robots[u.Host] = rules
+		l.mu.Unlock()
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return rules.allowed(path), nil
+}
+
+// get performs a rate-limited GET request.
+func (l *WebLoader) get(ctx context.Context, u *url.URL) (*http.Response, error) {
+	if err := l.wait(ctx, u.Host); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", l.opts.userAgent)
+	return l.opts.client.Do(req)
+}
+
+// wait blocks until the per-host crawl delay has elapsed.
+func (l *WebLoader) wait(ctx context.Context, host string) error {
+	l.mu.Lock()
+	delay := l.opts.delay
+	if rules, ok := l.robots[host]; ok && rules.crawlDelay > delay {
+		delay = rules.crawlDelay
+	}
+	next := l.lastHit[host].Add(delay)
+	now := blades.Now()
+	if next.Before(now) {
+		next = now
+	}
+	l.lastHit[host] = next
+	l.mu.Unlock()
+	timer := time.NewTimer(next.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type webPage struct {
+	title string
+	text  string
+	links []string
+}
+
+// fetchPage downloads an HTML page; non-HTML responses yield a nil page.
+func (l *WebLoader) fetchPage(ctx context.Context, u *url.URL) (*webPage, error) {
+	res, err := l.get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("document: fetch %s: status %d", u, res.StatusCode)
+	}
+	if ct := res.Header.Get("Content-Type"); ct != "" && !strings.Contains(ct, "html") {
+		return nil, nil
+	}
+	root, err := html.Parse(io.LimitReader(res.Body, 10<<20))
+	if err != nil {
+		return nil, err
+	}
+	return extractPage(root), nil
+}
+
+// skipped holds elements that never carry readable content.
+var skipped = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Nav: true,
+	atom.Header: true, atom.Footer: true, atom.Aside: true, atom.Form: true,
+	atom.Svg: true, atom.Iframe: true, atom.Template: true, atom.Button: true,
+}
+
+// blocks holds elements rendered on their own lines.
+var blocks = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Li: true, atom.Tr: true, atom.Pre: true, atom.Blockquote: true, atom.Br: true,
+	atom.Table: true, atom.Ul: true, atom.Ol: true, atom.Dd: true, atom.Dt: true,
+}
+
+// extractPage performs a lightweight readability pass: it prefers the <article>
+// or <main> element, drops boilerplate elements and collects links.
+func extractPage(root *html.Node) *webPage {
+	page := &webPage{}
+	var content, body *html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Title:
+				if n.FirstChild != nil && page.title == "" {
+					page.title = strings.TrimSpace(n.FirstChild.Data)
+				}
+			case atom.A:
+				for _, attr := range n.Attr {
+					if attr.Key == "href" {
+						page.links = append(page.links, attr.Val)
+					}
+				}
+			case atom.Article, atom.Main:
+				if content == nil {
+					content = n
+				}
+			case atom.Body:
+				body = n
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+	if content == nil {
+		content = body
+	}
+	if content == nil {
+		content = root
+	}
+	var buf strings.Builder
+	var text func(*html.Node)
+	text = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			if s := strings.Join(strings.Fields(n.Data), " "); s != "" {
+				buf.WriteString(s + " ")
+			}
+			return
+		case html.ElementNode:
+			if skipped[n.DataAtom] {
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			text(c)
+		}
+		if n.Type == html.ElementNode && blocks[n.DataAtom] {
+			buf.WriteString("\n")
+		}
+	}
+	text(content)
+	lines := strings.Split(buf.String(), "\n")
+	out := lines[:0]
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			out = append(out, line)
+		}
+	}
+	page.text = strings.Join(out, "\n")
+	return page
+}
+
+// HTMLToText parses an HTML document or fragment and returns its readable text.
+func HTMLToText(r io.Reader) (string, error) {
+	root, err := html.Parse(r)
+	if err != nil {
+		return "", err
+	}
+	return extractPage(root).text, nil
+}
+
+type sitemapDocument struct {
+	URLs     []string `xml:"url>loc"`
+	Sitemaps []string `xml:"sitemap>loc"`
+}
+
+// readSitemap returns the page URLs of a sitemap, following nested sitemap indexes.
+func (l *WebLoader) readSitemap(ctx context.Context, sitemapURL string, depth int) ([]string, error) {
+	u, err := url.Parse(sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+	res, err := l.get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("document: fetch sitemap %s: status %d", sitemapURL, res.StatusCode)
+	}
+	var sm sitemapDocument
+	if err := xml.NewDecoder(io.LimitReader(res.Body, 50<<20)).Decode(&sm); err != nil {
+		return nil, fmt.Errorf("document: decode sitemap %s: %w", sitemapURL, err)
+	}
+	urls := sm.URLs
+	if depth < 3 {
+		for _, nested := range sm.Sitemaps {
+			more, err := l.readSitemap(ctx, strings.TrimSpace(nested), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			urls = append(urls, more...)
+		}
+	}
+	for i := range urls {
+		urls[i] = strings.TrimSpace(urls[i])
+	}
+	return urls, nil
+}
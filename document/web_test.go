@@ -0,0 +1,97 @@
+package document
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/blades"
+)
+
+func TestWebLoader_Crawl(t *testing.T) {
+	pages := map[string]string{
+		"/":          `<html><head><title>Home</title></head><body><nav>menu</nav><main><h1>Welcome</h1><p>Intro text.</p><a href="/a">A</a><a href="/private/x">X</a></main></body></html>`,
+		"/a":         `<html><head><title>A</title></head><body><p>Page A</p><a href="/b">B</a></body></html>`,
+		"/b":         `<html><body><p>Page B</p></body></html>`,
+		"/private/x": `<html><body><p>secret</p></body></html>`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+			return
+		}
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	docs, err := NewWebLoader([]string{srv.URL + "/"}, WithMaxDepth(1), WithCrawlDelay(0)).Load(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("expected 2 documents, got %d", len(docs))
+	}
+	home := docs[0]
+	if home.Metadata["title"] != "Home" || home.Content != "Welcome\nIntro text.\nA X" {
+		t.Fatalf("unexpected home document: %q %+v", home.Content, home.Metadata)
+	}
+	for _, doc := range docs {
+		if strings.Contains(doc.Content, "secret") || strings.Contains(doc.Content, "Page B") {
+			t.Fatalf("unexpected page crawled: %q", doc.Content)
+		}
+	}
+}
+
+func TestWebLoader_ClockAndIDs(t *testing.T) {
+	started := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	defer blades.SetClock(blades.ClockFunc(func() time.Time { return started }))()
+	defer blades.SetIDGenerator(blades.SequentialIDs("doc"))()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><p>Hello</p></body></html>`))
+	}))
+	defer srv.Close()
+
+	loader := NewWebLoader([]string{srv.URL + "/"}, WithCrawlDelay(0))
+	docs, err := loader.Load(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 || docs[0].ID != "doc-1" {
+		t.Fatalf("expected document doc-1, got %+v", docs)
+	}
+	for host, hit := range loader.lastHit {
+		if !hit.Equal(started) {
+			t.Fatalf("expected the last hit of %s at %v, got %v", host, started, hit)
+		}
+	}
+	if len(loader.lastHit) == 0 {
+		t.Fatal("expected the crawl to record its hits")
+	}
+}
+
+func TestRobots(t *testing.T) {
+	rules := parseRobots(strings.NewReader("User-agent: other\nDisallow: /\n\nUser-agent: *\nDisallow: /docs\nAllow: /docs/public\nCrawl-delay: 2\n"), "blades-crawler")
+	tests := map[string]bool{"/": true, "/docs": false, "/docs/x": false, "/docs/public/y": true}
+	for path, want := range tests {
+		if got := rules.allowed(path); got != want {
+			t.Fatalf("allowed(%q) = %v, want %v", path, got, want)
+		}
+	}
+	if rules.crawlDelay.Seconds() != 2 {
+		t.Fatalf("expected crawl delay 2s, got %v", rules.crawlDelay)
+	}
+}
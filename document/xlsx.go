@@ -0,0 +1,204 @@
+package document
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var (
+	_ Loader = (*XLSXLoader)(nil)
+)
+
+// XLSXLoader loads a worksheet of an Excel workbook as row-group documents.
+// The first row of the sheet is the header.
+type XLSXLoader struct {
+	path string
+	opts tableOptions
+}
+
+// NewXLSXLoader creates a new XLSXLoader for the workbook at path.
+func NewXLSXLoader(path string, opts ...TableOption) *XLSXLoader {
+	return &XLSXLoader{path: path, opts: newTableOptions(opts)}
+}
+
+// Table reads the selected worksheet into a Table.
+func (l *XLSXLoader) Table() (*Table, error) {
+	zr, err := zip.OpenReader(l.path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return ReadXLSX(&zr.Reader, l.opts.sheet)
+}
+
+// Load reads the worksheet and groups its rows into documents.
+func (l *XLSXLoader) Load(ctx context.Context) ([]*Document, error) {
+	table, err := l.Table()
+	if err != nil {
+		return nil, err
+	}
+	docs := table.Documents(l.path, l.opts.rowsPerDocument, l.opts.markdown)
+	if l.opts.sheet != "" {
+		for _, doc := range docs {
+			doc.Metadata["sheet"] = l.opts.sheet
+		}
+	}
+	return docs, nil
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var buf strings.Builder
+	for _, r := range t.Runs {
+		buf.WriteString(r.T)
+	}
+	return buf.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadXLSX reads the named worksheet (or the first one when empty) from an xlsx archive.
+func ReadXLSX(zr *zip.Reader, sheet string) (*Table, error) {
+	var workbook xlsxWorkbook
+	if err := decodeZipXML(zr, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, fmt.Errorf("document: xlsx has no sheets")
+	}
+	rid := workbook.Sheets[0].RID
+	if sheet != "" {
+		rid = ""
+		for _, s := range workbook.Sheets {
+			if s.Name == sheet {
+				rid = s.RID
+			}
+		}
+		if rid == "" {
+			return nil, fmt.Errorf("document: xlsx sheet %q not found", sheet)
+		}
+	}
+	var rels xlsxRelationships
+	if err := decodeZipXML(zr, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	var target string
+	for _, rel := range rels.Relationships {
+		if rel.ID == rid {
+			target = strings.TrimPrefix(rel.Target, "/xl/")
+		}
+	}
+	var shared xlsxSharedStrings
+	if err := decodeZipXML(zr, "xl/sharedStrings.xml", &shared); err != nil && !errors.Is(err, errZipEntryNotFound) {
+		return nil, err
+	}
+	var ws xlsxSheet
+	if err := decodeZipXML(zr, path.Join("xl", target), &ws); err != nil {
+		return nil, err
+	}
+	var records [][]string
+	for _, row := range ws.Rows {
+		var record []string
+		for i, c := range row.Cells {
+			col := columnIndex(c.Ref, i)
+			for len(record) <= col {
+				record = append(record, "")
+			}
+			switch c.Type {
+			case "s":
+				idx, err := strconv.Atoi(c.Value)
+				if err != nil || idx >= len(shared.Items) {
+					return nil, fmt.Errorf("document: xlsx invalid shared string %q", c.Value)
+				}
+				record[col] = shared.Items[idx].String()
+			case "inlineStr":
+				record[col] = c.Inline.String()
+			default:
+				record[col] = c.Value
+			}
+		}
+		records = append(records, record)
+	}
+	if len(records) == 0 {
+		return &Table{}, nil
+	}
+	return NewTable(records[0], records[1:]), nil
+}
+
+var errZipEntryNotFound = errors.New("document: xlsx entry not found")
+
+func decodeZipXML(zr *zip.Reader, name string, v any) error {
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		if err := xml.NewDecoder(io.LimitReader(rc, 512<<20)).Decode(v); err != nil {
+			return fmt.Errorf("document: decode %s: %w", name, err)
+		}
+		return nil
+	}
+	return errZipEntryNotFound
+}
+
+// columnIndex converts a cell reference such as "C7" into a zero-based column index.
+func columnIndex(ref string, fallback int) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+	if col == 0 {
+		return fallback
+	}
+	return col - 1
+}
@@ -0,0 +1,193 @@
+// Package edit lets revision agents return edit operations against a document
+// instead of re-emitting the whole text.
+package edit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrTargetNotFound indicates the target text of an edit does not occur in the document.
+	ErrTargetNotFound = errors.New("edit: target not found")
+	// ErrOverlap indicates two edits change overlapping spans of the document.
+	ErrOverlap = errors.New("edit: overlapping edits")
+	// ErrInvalidEdit indicates an edit is malformed, e.g. has an unknown operation.
+	ErrInvalidEdit = errors.New("edit: invalid edit")
+)
+
+// Op is an edit operation.
+type Op string
+
+const (
+	// OpInsert inserts Text before or after Target.
+	OpInsert Op = "insert"
+	// OpDelete deletes Target.
+	OpDelete Op = "delete"
+	// OpReplace replaces Target with Text.
+	OpReplace Op = "replace"
+)
+
+// Edit changes a span of a document identified by its exact text, which models
+// reproduce far more reliably than offsets.
+type Edit struct {
+	Op Op `json:"op" jsonschema:"insert, delete or replace"`
+	// Target is the exact text of the span, copied verbatim from the document.
+	Target string `json:"target" jsonschema:"the exact text of the span in the document, long enough to be unique"`
+	// Occurrence selects the nth occurrence of Target, starting at 1.
+	Occurrence int `json:"occurrence,omitempty" jsonschema:"which occurrence of target, starting at 1, when it is not unique"`
+	// Text is the inserted or replacement text.
+	Text string `json:"text,omitempty" jsonschema:"the inserted or replacement text"`
+	// After inserts after Target instead of before it.
+	After bool `json:"after,omitempty" jsonschema:"for insert, whether to insert after target instead of before it"`
+	// Reason optionally explains the edit.
+	Reason string `json:"reason,omitempty" jsonschema:"why the edit is made"`
+}
+
+// Change is an edit resolved to the byte span [Start, End) of the document.
+// Inserts have an empty span.
+type Change struct {
+	Edit
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Instructions describe the edit format to a model.
+const Instructions = `Return your revisions as a JSON array of edits instead of the revised document. Each edit is an object with:
+- "op": "insert", "delete" or "replace"
+- "target": the exact text of the span to change, copied verbatim from the document and long enough to be unique
+- "occurrence": which occurrence of target to change, starting at 1, only when target is not unique
+- "text": the inserted or replacement text
+- "after": for insert, true to insert after target instead of before it
+- "reason": a short explanation
+Edits must not overlap. Return [] when no revision is needed.`
+
+// Parse decodes the edits in a model response: a JSON array of edits or an
+// object with an "edits" array, optionally wrapped in a code fence.
+func Parse(text string) ([]Edit, error) {
+	text = strings.TrimSpace(text)
+	if start := strings.IndexAny(text, "[{"); start > 0 {
+		text = text[start:]
+	}
+	if end := strings.LastIndexAny(text, "]}"); end >= 0 {
+		text = text[:end+1]
+	}
+	var edits []Edit
+	if strings.HasPrefix(text, "{") {
+		var wrapped struct {
+			Edits []Edit `json:"edits"`
+		}
+		if err := json.Unmarshal([]byte(text), &wrapped); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidEdit, err)
+		}
+		edits = wrapped.Edits
+	} else if err := json.Unmarshal([]byte(text), &edits); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEdit, err)
+	}
+	return edits, nil
+}
+
+// Resolve locates the edits in doc and returns the changes ordered by position.
+func Resolve(doc string, edits []Edit) ([]Change, error) {
+	changes := make([]Change, 0, len(edits))
+	for i, e := range edits {
+		switch e.Op {
+		case OpInsert, OpDelete, OpReplace:
+		default:
+			return nil, fmt.Errorf("%w: edit %d has operation %q", ErrInvalidEdit, i, e.Op)
+		}
+		if e.Target == "" {
+			return nil, fmt.Errorf("%w: edit %d has no target", ErrInvalidEdit, i)
+		}
+		start := find(doc, e.Target, max(e.Occurrence, 1))
+		if start < 0 {
+			return nil, fmt.Errorf("%w: edit %d: %q", ErrTargetNotFound, i, e.Target)
+		}
+		c := Change{Edit: e, Start: start, End: start + len(e.Target)}
+		if e.Op == OpInsert {
+			if e.After {
+				c.Start = c.End
+			} else {
+				c.End = c.Start
+			}
+		}
+		changes = append(changes, c)
+	}
+	slices.SortStableFunc(changes, func(a, b Change) int {
+		if a.Start != b.Start {
+			return a.Start - b.Start
+		}
+		return a.End - b.End
+	})
+	for i := 1; i < len(changes); i++ {
+		if prev, c := changes[i-1], changes[i]; c.Start < prev.End {
+			return nil, fmt.Errorf("%w: %q and %q", ErrOverlap, prev.Target, c.Target)
+		}
+	}
+	return changes, nil
+}
+
+// find returns the offset of the nth occurrence of target in doc, or -1.
+func find(doc, target string, n int) int {
+	offset := 0
+	for ; n > 0; n-- {
+		i := strings.Index(doc[offset:], target)
+		if i < 0 {
+			return -1
+		}
+		if n == 1 {
+			return offset + i
+		}
+		offset += i + len(target)
+	}
+	return -1
+}
+
+// Apply applies the edits to doc and returns the revised document.
+func Apply(doc string, edits []Edit) (string, error) {
+	changes, err := Resolve(doc, edits)
+	if err != nil {
+		return "", err
+	}
+	return revise(doc, changes), nil
+}
+
+// revise returns doc with the changes applied.
+func revise(doc string, changes []Change) string {
+	return render(doc, changes, func(b *strings.Builder, _, inserted string) {
+		b.WriteString(inserted)
+	})
+}
+
+// Redline renders the changes inline as [-deleted-] and {+inserted+} markup.
+func Redline(doc string, changes []Change) string {
+	return render(doc, changes, func(b *strings.Builder, deleted, inserted string) {
+		if deleted != "" {
+			b.WriteString("[-" + deleted + "-]")
+		}
+		if inserted != "" {
+			b.WriteString("{+" + inserted + "+}")
+		}
+	})
+}
+
+func render(doc string, changes []Change, write func(b *strings.Builder, deleted, inserted string)) string {
+	var (
+		b    strings.Builder
+		last int
+	)
+	for _, c := range changes {
+		b.WriteString(doc[last:c.Start])
+		var inserted string
+		if c.Op != OpDelete {
+			inserted = c.Text
+		}
+		write(&b, doc[c.Start:c.End], inserted)
+		last = c.End
+	}
+	b.WriteString(doc[last:])
+	return b.String()
+}
@@ -0,0 +1,40 @@
+package edit
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	doc := "The cat sat on the mat. The cat slept."
+	edits, err := Parse("```json\n" + `[
+		{"op": "replace", "target": "cat", "occurrence": 2, "text": "dog"},
+		{"op": "delete", "target": " on the mat"},
+		{"op": "insert", "target": "slept", "after": true, "text": " soundly"}
+	]` + "\n```")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Apply(doc, edits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "The cat sat. The dog slept soundly."; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	changes, _ := Resolve(doc, edits)
+	if got, want := Redline(doc, changes), "The cat sat[- on the mat-]. The [-cat-]{+dog+} slept{+ soundly+}."; got != want {
+		t.Fatalf("got redline %q, want %q", got, want)
+	}
+}
+
+func TestResolve_Errors(t *testing.T) {
+	doc := "one two three"
+	if _, err := Resolve(doc, []Edit{{Op: OpDelete, Target: "four"}}); !errors.Is(err, ErrTargetNotFound) {
+		t.Fatalf("expected ErrTargetNotFound, got %v", err)
+	}
+	overlapping := []Edit{{Op: OpDelete, Target: "one two"}, {Op: OpReplace, Target: "two three", Text: "2 3"}}
+	if _, err := Resolve(doc, overlapping); !errors.Is(err, ErrOverlap) {
+		t.Fatalf("expected ErrOverlap, got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package edit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kratos/blades"
+)
+
+// maxRepairAttempts bounds how often edits that do not apply are sent back.
+const maxRepairAttempts = 2
+
+// Result is a revision of a document.
+type Result struct {
+	Edits   []Edit   `json:"edits"`
+	Changes []Change `json:"changes"`
+	// Text is the revised document.
+	Text string `json:"text"`
+}
+
+// Editor asks a runner to revise documents with edit operations. Edits that
+// do not parse or apply are sent back to the model with the error to be fixed.
+type Editor struct {
+	runner blades.Runner
+}
+
+// NewEditor creates an Editor revising documents with runner.
+func NewEditor(runner blades.Runner) *Editor {
+	return &Editor{runner: runner}
+}
+
+// Edit revises doc following instruction.
+func (e *Editor) Edit(ctx context.Context, doc, instruction string, opts ...blades.ModelOption) (*Result, error) {
+	prompt := blades.NewPrompt(blades.UserMessage(fmt.Sprintf("%s\n\n%s\n\nDocument:\n<document>\n%s\n</document>", instruction, Instructions, doc)))
+	for attempt := 0; ; attempt++ {
+		res, err := e.runner.Run(ctx, prompt, opts...)
+		if err != nil {
+			return nil, err
+		}
+		edits, err := Parse(res.Text())
+		var changes []Change
+		if err == nil {
+			changes, err = Resolve(doc, edits)
+		}
+		if err == nil {
+			return &Result{Edits: edits, Changes: changes, Text: revise(doc, changes)}, nil
+		}
+		if attempt == maxRepairAttempts {
+			return nil, err
+		}
+		prompt.Messages = append(prompt.Messages,
+			blades.AssistantMessage(res.Text()),
+			blades.UserMessage(fmt.Sprintf("Your edits cannot be applied: %v\nRespond again with only the corrected JSON array of edits.", err)),
+		)
+	}
+}
@@ -0,0 +1,97 @@
+package blades
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Environment holds runtime facts injected into the agent instructions, such as
+// the current date and time, which models cannot know on their own.
+type Environment struct {
+	location *time.Location
+	locale   string
+	facts    map[string]string
+	now      func() time.Time
+}
+
+// EnvironmentOption configures an Environment.
+type EnvironmentOption func(*Environment)
+
+// EnvTimezone sets the timezone used to report the current time.
+func EnvTimezone(loc *time.Location) EnvironmentOption {
+	return func(e *Environment) {
+		e.location = loc
+	}
+}
+
+// EnvLocale sets the locale reported to the model, e.g. "en-US".
+func EnvLocale(locale string) EnvironmentOption {
+	return func(e *Environment) {
+		e.locale = locale
+	}
+}
+
+// EnvFact adds a custom fact, e.g. EnvFact("Company", "Acme Inc.").
+func EnvFact(key, value string) EnvironmentOption {
+	return func(e *Environment) {
+		e.facts[key] = value
+	}
+}
+
+// EnvClock sets the clock used for the current time.
+func EnvClock(now func() time.Time) EnvironmentOption {
+	return func(e *Environment) {
+		e.now = now
+	}
+}
+
+// NewEnvironment creates an Environment using the local timezone and the locale
+// of the LC_ALL or LANG environment variables.
+func NewEnvironment(opts ...EnvironmentOption) *Environment {
+	e := &Environment{
+		location: time.Local,
+		locale:   systemLocale(),
+		facts:    make(map[string]string),
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// String renders the environment facts as a block of instructions.
+func (e *Environment) String() string {
+	now := e.now().In(e.location)
+	var buf strings.Builder
+	buf.WriteString("# Environment\n")
+	fmt.Fprintf(&buf, "Current date: %s\n", now.Format("Monday, January 2, 2006"))
+	fmt.Fprintf(&buf, "Current time: %s\n", now.Format("15:04 MST"))
+	fmt.Fprintf(&buf, "Timezone: %s (UTC%s)\n", e.location, now.Format("-07:00"))
+	if e.locale != "" {
+		fmt.Fprintf(&buf, "Locale: %s\n", e.locale)
+	}
+	keys := make([]string, 0, len(e.facts))
+	for key := range e.facts {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&buf, "%s: %s\n", key, e.facts[key])
+	}
+	return buf.String()
+}
+
+// systemLocale converts a POSIX locale such as "en_US.UTF-8" to "en-US".
+func systemLocale() string {
+	for _, key := range []string{"LC_ALL", "LANG"} {
+		locale, _, _ := strings.Cut(os.Getenv(key), ".")
+		if locale != "" && locale != "C" && locale != "POSIX" {
+			return strings.ReplaceAll(locale, "_", "-")
+		}
+	}
+	return ""
+}
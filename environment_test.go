@@ -0,0 +1,30 @@
+package blades
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvironment_String(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+	env := NewEnvironment(
+		EnvTimezone(loc),
+		EnvLocale("de-DE"),
+		EnvFact("Company", "Acme"),
+		EnvClock(func() time.Time { return time.Date(2025, 3, 14, 8, 30, 0, 0, time.UTC) }),
+	)
+	want := []string{
+		"Current date: Friday, March 14, 2025",
+		"Current time: 09:30 CET",
+		"Timezone: CET (UTC+01:00)",
+		"Locale: de-DE",
+		"Company: Acme",
+	}
+	got := env.String()
+	for _, line := range want {
+		if !strings.Contains(got, line) {
+			t.Fatalf("missing %q in\n%s", line, got)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package extract
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/document"
+)
+
+var (
+	_ blades.Runner = (*Extractor[any])(nil)
+)
+
+const defaultInstructions = "Extract every item matching the JSON schema from the following text. " +
+	"Return an empty array when the text contains no matching items."
+
+// Option configures an Extractor.
+type Option func(*options)
+
+type options struct {
+	chunkSize    int
+	chunkOverlap int
+	concurrency  int
+	instructions string
+	keyFields    []string
+}
+
+// WithChunkSize sets the maximum number of characters sent to the model per chunk.
+// A size of 0 or less sends the whole document at once.
+func WithChunkSize(n int) Option {
+	return func(o *options) {
+		o.chunkSize = n
+	}
+}
+
+// WithChunkOverlap sets the number of characters shared between adjacent chunks,
+// so that items spanning a chunk boundary are not lost.
+func WithChunkOverlap(n int) Option {
+	return func(o *options) {
+		o.chunkOverlap = n
+	}
+}
+
+// WithConcurrency sets how many chunks are extracted in parallel.
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		o.concurrency = n
+	}
+}
+
+// WithInstructions overrides the extraction instructions prepended to every chunk.
+func WithInstructions(instructions string) Option {
+	return func(o *options) {
+		o.instructions = instructions
+	}
+}
+
+// WithKeyFields deduplicates extracted items by the given JSON fields instead of
+// by their full JSON representation.
+func WithKeyFields(fields ...string) Option {
+	return func(o *options) {
+		o.keyFields = fields
+	}
+}
+
+// Extractor splits a long document into chunks, runs schema-based extraction
+// over every chunk and merges the deduplicated results in document order.
+type Extractor[T any] struct {
+	opts     options
+	splitter *document.RecursiveSplitter
+	output   *blades.OutputConverter[[]T]
+}
+
+// NewExtractor creates a new Extractor that uses the given runner for extraction.
+func NewExtractor[T any](runner blades.Runner, opts ...Option) *Extractor[T] {
+	o := options{
+		chunkSize:    8000,
+		chunkOverlap: 200,
+		concurrency:  4,
+		instructions: defaultInstructions,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	e := &Extractor[T]{
+		opts:   o,
+		output: blades.NewOutputConverter[[]T](runner),
+	}
+	if o.chunkSize > 0 {
+		e.splitter = document.NewRecursiveSplitter(document.WithChunkSize(o.chunkSize), document.WithChunkOverlap(max(o.chunkOverlap, 0)))
+	}
+	return e
+}
+
+// Extract runs extraction over the document and returns the merged items.
+func (e *Extractor[T]) Extract(ctx context.Context, document string, opts ...blades.ModelOption) ([]T, error) {
+	chunks := e.split(document)
+	results := make([][]T, len(chunks))
+	errs := make([]error, len(chunks))
+	concurrency := max(e.opts.concurrency, 1)
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			prompt := blades.NewPrompt(blades.UserMessage(e.opts.instructions + "\n\n" + chunk))
+			results[i], errs[i] = e.output.Run(ctx, prompt, opts...)
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("extract: chunk %d: %w", i, err)
+		}
+	}
+	return e.merge(results)
+}
+
+// split splits the document into the chunks sent to the model, preferring
+// paragraph, line, sentence and word boundaries.
+func (e *Extractor[T]) split(text string) []string {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	if e.splitter == nil {
+		return []string{text}
+	}
+	return e.splitter.SplitText(text)
+}
+
+// merge flattens per-chunk results, dropping items already seen.
+func (e *Extractor[T]) merge(results [][]T) ([]T, error) {
+	var (
+		merged []T
+		seen   = make(map[string]struct{})
+	)
+	for _, items := range results {
+		for _, item := range items {
+			key, err := e.key(item)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			merged = append(merged, item)
+		}
+	}
+	return merged, nil
+}
+
+// key returns the deduplication key of an item.
+func (e *Extractor[T]) key(item T) (string, error) {
+	b, err := json.Marshal(item)
+	if err != nil {
+		return "", err
+	}
+	if len(e.opts.keyFields) == 0 {
+		return string(b), nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return "", err
+	}
+	key := make([]any, 0, len(e.opts.keyFields))
+	for _, name := range e.opts.keyFields {
+		key = append(key, fields[name])
+	}
+	if b, err = json.Marshal(key); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Run treats the prompt text as the document and returns the merged items as a JSON array.
+func (e *Extractor[T]) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	var buf strings.Builder
+	for _, msg := range prompt.Messages {
+		if text := msg.Text(); text != "" {
+			buf.WriteString(text)
+			buf.WriteString("\n")
+		}
+	}
+	items, err := e.Extract(ctx, buf.String(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []T{}
+	}
+	b, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	return &blades.Generation{Messages: []*blades.Message{blades.AssistantMessage(string(b))}}, nil
+}
+
+// RunStream runs the extraction and yields the merged items as a single generation.
+func (e *Extractor[T]) RunStream(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (blades.Streamer[*blades.Generation], error) {
+	pipe := blades.NewStreamPipe[*blades.Generation]()
+	pipe.Go(func() error {
+		res, err := e.Run(ctx, prompt, opts...)
+		if err != nil {
+			return err
+		}
+		pipe.Send(res)
+		return nil
+	})
+	return pipe, nil
+}
@@ -0,0 +1,87 @@
+package extract
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/blades"
+)
+
+type entity struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// echoRunner returns a canned JSON response per chunk based on the words it contains.
+type echoRunner struct{}
+
+func (echoRunner) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	var items []string
+	for _, word := range strings.Fields(prompt.Messages[len(prompt.Messages)-1].Text()) {
+		if strings.HasPrefix(word, "@") {
+			items = append(items, `{"name":"`+strings.TrimPrefix(word, "@")+`","type":"person"}`)
+		}
+	}
+	return &blades.Generation{Messages: []*blades.Message{
+		blades.AssistantMessage("[" + strings.Join(items, ",") + "]"),
+	}}, nil
+}
+
+func (echoRunner) RunStream(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (blades.Streamer[*blades.Generation], error) {
+	return nil, nil
+}
+
+func TestExtractor_Split(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		size    int
+		overlap int
+		want    int
+	}{
+		{"empty", "   ", 10, 0, 0},
+		{"single", "short text", 100, 0, 1},
+		{"words", "aaaa bbbb cccc dddd", 10, 0, 2},
+		{"paragraphs", "aaaa\n\nbbbb\n\ncccc", 8, 0, 3},
+		{"overlap", "aaaa bbbb cccc dddd", 10, 5, 3},
+		{"unsplit", "aaaa bbbb cccc dddd", 0, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := NewExtractor[entity](echoRunner{}, WithChunkSize(tt.size), WithChunkOverlap(tt.overlap)).split(tt.text)
+			if len(chunks) != tt.want {
+				t.Fatalf("expected %d chunks, got %d: %q", tt.want, len(chunks), chunks)
+			}
+			for _, c := range chunks {
+				if tt.size > 0 && len([]rune(c)) > tt.size {
+					t.Fatalf("chunk exceeds size %d: %q", tt.size, c)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractor_MergeDeduplicates(t *testing.T) {
+	doc := "@alice met @bob\n\n@bob called @carol\n\n@alice left"
+	ex := NewExtractor[entity](echoRunner{}, WithChunkSize(24), WithChunkOverlap(0), WithKeyFields("name"))
+	items, err := ex.Extract(context.Background(), doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	if got := strings.Join(names, ","); got != "alice,bob,carol" {
+		t.Fatalf("unexpected merge result: %s", got)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	text := "Contact Ann Lee at ann@example.com or ann@example.com.au."
+	got := Redact(text, []PII{{Type: "email", Value: "ann@example.com"}, {Type: "email", Value: "ann@example.com.au"}, {Type: "name", Value: "Ann Lee"}})
+	if got != "Contact [name] at [email] or [email]." {
+		t.Fatalf("unexpected redaction %q", got)
+	}
+}
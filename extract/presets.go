@@ -0,0 +1,152 @@
+package extract
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-kratos/blades"
+)
+
+var (
+	_ blades.Runner = (*InvoiceExtractor)(nil)
+)
+
+// Entity is a named entity mentioned in a text.
+type Entity struct {
+	Text string `json:"text" jsonschema:"the entity exactly as written in the text"`
+	Type string `json:"type" jsonschema:"one of person, organization, location, date, money, product or other"`
+}
+
+const entityInstructions = "Extract every named entity from the following text: people, organizations, " +
+	"locations, dates, monetary amounts and products. Quote each entity exactly as written, " +
+	"once per distinct entity. Return an empty array when the text contains no entities."
+
+// NewEntityExtractor creates an Extractor of the named entities of a document.
+func NewEntityExtractor(runner blades.Runner, opts ...Option) *Extractor[Entity] {
+	return NewExtractor[Entity](runner, append([]Option{
+		WithInstructions(entityInstructions),
+		WithKeyFields("text", "type"),
+	}, opts...)...)
+}
+
+// PII is a piece of personally identifiable information found in a text.
+type PII struct {
+	Type  string `json:"type" jsonschema:"one of name, email, phone, address, date_of_birth, national_id, passport, credit_card, bank_account, ip_address or other"`
+	Value string `json:"value" jsonschema:"the information exactly as written in the text"`
+}
+
+const piiInstructions = "Find every piece of personally identifiable information in the following text: " +
+	"names of people, email addresses, phone numbers, postal addresses, dates of birth, national " +
+	"identification, passport, credit card and bank account numbers, and IP addresses. Quote each value " +
+	"exactly as written so that it can be located in the text. Do not report company names or public " +
+	"figures mentioned in a public role. Return an empty array when the text contains no such information."
+
+// NewPIIExtractor creates an Extractor of the personally identifiable
+// information of a document, for detection or Redact.
+func NewPIIExtractor(runner blades.Runner, opts ...Option) *Extractor[PII] {
+	return NewExtractor[PII](runner, append([]Option{
+		WithInstructions(piiInstructions),
+		WithKeyFields("type", "value"),
+	}, opts...)...)
+}
+
+// Redact replaces every occurrence of the values of items in text with their
+// type in brackets, e.g. "[email]". Longer values are replaced first.
+func Redact(text string, items []PII) string {
+	items = slices.Clone(items)
+	slices.SortFunc(items, func(a, b PII) int { return len(b.Value) - len(a.Value) })
+	for _, item := range items {
+		if item.Value != "" {
+			text = strings.ReplaceAll(text, item.Value, "["+item.Type+"]")
+		}
+	}
+	return text
+}
+
+// Invoice holds the key fields of an invoice. Amounts are in Currency.
+type Invoice struct {
+	Number    string            `json:"number" jsonschema:"the invoice number"`
+	Date      string            `json:"date" jsonschema:"the issue date as YYYY-MM-DD"`
+	DueDate   string            `json:"due_date,omitempty" jsonschema:"the payment due date as YYYY-MM-DD"`
+	Vendor    string            `json:"vendor" jsonschema:"the name of the issuer"`
+	Customer  string            `json:"customer,omitempty" jsonschema:"the name of the billed party"`
+	Currency  string            `json:"currency" jsonschema:"the ISO 4217 currency code"`
+	Subtotal  float64           `json:"subtotal,omitempty" jsonschema:"the total before tax"`
+	Tax       float64           `json:"tax,omitempty" jsonschema:"the total tax"`
+	Total     float64           `json:"total" jsonschema:"the amount due"`
+	LineItems []InvoiceLineItem `json:"line_items,omitempty"`
+}
+
+// InvoiceLineItem is a line of an invoice.
+type InvoiceLineItem struct {
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity,omitempty"`
+	UnitPrice   float64 `json:"unit_price,omitempty"`
+	Amount      float64 `json:"amount" jsonschema:"the total of the line"`
+}
+
+const invoiceInstructions = "Extract the fields of the following invoice. Write dates as YYYY-MM-DD and " +
+	"amounts as plain numbers without currency symbols or thousands separators. Leave out fields " +
+	"that do not appear on the invoice."
+
+// InvoiceExtractor extracts the key fields of an invoice. Unlike an Extractor,
+// it reads the whole document at once, since invoice fields depend on each other.
+type InvoiceExtractor struct {
+	instructions string
+	output       *blades.OutputConverter[Invoice]
+}
+
+// NewInvoiceExtractor creates an InvoiceExtractor. Only WithInstructions applies.
+func NewInvoiceExtractor(runner blades.Runner, opts ...Option) *InvoiceExtractor {
+	o := options{instructions: invoiceInstructions}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &InvoiceExtractor{instructions: o.instructions, output: blades.NewOutputConverter[Invoice](runner)}
+}
+
+// Extract returns the fields of the invoice in document.
+func (e *InvoiceExtractor) Extract(ctx context.Context, document string, opts ...blades.ModelOption) (Invoice, error) {
+	invoice, err := e.output.Run(ctx, blades.NewPrompt(blades.UserMessage(e.instructions+"\n\n"+document)), opts...)
+	if err != nil {
+		return invoice, fmt.Errorf("extract: invoice: %w", err)
+	}
+	return invoice, nil
+}
+
+// Run treats the prompt text as the invoice and returns its fields as JSON.
+func (e *InvoiceExtractor) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	var buf strings.Builder
+	for _, msg := range prompt.Messages {
+		if text := msg.Text(); text != "" {
+			buf.WriteString(text)
+			buf.WriteString("\n")
+		}
+	}
+	invoice, err := e.Extract(ctx, buf.String(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(invoice)
+	if err != nil {
+		return nil, err
+	}
+	return &blades.Generation{Messages: []*blades.Message{blades.AssistantMessage(string(b))}}, nil
+}
+
+// RunStream extracts the invoice and yields its fields as a single generation.
+func (e *InvoiceExtractor) RunStream(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (blades.Streamer[*blades.Generation], error) {
+	pipe := blades.NewStreamPipe[*blades.Generation]()
+	pipe.Go(func() error {
+		res, err := e.Run(ctx, prompt, opts...)
+		if err != nil {
+			return err
+		}
+		pipe.Send(res)
+		return nil
+	})
+	return pipe, nil
+}
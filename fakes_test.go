@@ -0,0 +1,305 @@
+package blades
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// fakeProvider is the scripted ModelProvider of the tests. It is safe for
+// concurrent use.
+type fakeProvider struct {
+	// replies are the texts of the completed assistant messages returned in
+	// turn, the last one repeating.
+	replies []string
+	// reply builds the responses instead of replies when set.
+	reply func(req *ModelRequest, opts ModelOptions) *ModelResponse
+	// usage is reported on every response when set.
+	usage *Usage
+	// err fails the first failures calls, and every call while down is set.
+	err      error
+	failures int
+	down     atomic.Bool
+	// lazy makes NewStream return at once and report failures inside the
+	// stream, as providers opening their streams on the first read do.
+	lazy bool
+	// noStream makes NewStream unsupported.
+	noStream bool
+	// trailing fails the streams after their last chunk.
+	trailing error
+	// delay delays every call, unless its context is done first.
+	delay time.Duration
+
+	mu       sync.Mutex
+	calls    int
+	canceled int
+	requests []*ModelRequest
+	options  []ModelOptions
+}
+
+// textProvider returns a fakeProvider replying with the texts in turn.
+func textProvider(replies ...string) *fakeProvider {
+	return &fakeProvider{replies: replies}
+}
+
+func (p *fakeProvider) Calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+func (p *fakeProvider) Canceled() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.canceled
+}
+
+// lastRequest returns the last request received, if any.
+func (p *fakeProvider) lastRequest() *ModelRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.requests) == 0 {
+		return nil
+	}
+	return p.requests[len(p.requests)-1]
+}
+
+// lastOptions returns the resolved options of the last call.
+func (p *fakeProvider) lastOptions() ModelOptions {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.options) == 0 {
+		return ModelOptions{}
+	}
+	return p.options[len(p.options)-1]
+}
+
+func (p *fakeProvider) Generate(ctx context.Context, req *ModelRequest, opts ...ModelOption) (*ModelResponse, error) {
+	var opt ModelOptions
+	for _, apply := range opts {
+		apply(&opt)
+	}
+	p.mu.Lock()
+	p.calls++
+	call := p.calls
+	p.requests = append(p.requests, req)
+	p.options = append(p.options, opt)
+	p.mu.Unlock()
+	if p.delay > 0 {
+		select {
+		case <-time.After(p.delay):
+		case <-ctx.Done():
+			p.mu.Lock()
+			p.canceled++
+			p.mu.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+	if call <= p.failures || p.down.Load() {
+		return nil, p.err
+	}
+	var res *ModelResponse
+	if p.reply != nil {
+		res = p.reply(req, opt)
+	} else {
+		text := ""
+		if len(p.replies) > 0 {
+			text = p.replies[min(call, len(p.replies))-1]
+		}
+		res = textResponse(text)
+	}
+	if p.usage != nil {
+		usage := *p.usage
+		res.Usage = &usage
+	}
+	return res, nil
+}
+
+func (p *fakeProvider) NewStream(ctx context.Context, req *ModelRequest, opts ...ModelOption) (Streamer[*ModelResponse], error) {
+	if p.noStream {
+		return nil, &UnsupportedError{Provider: "fake", Option: "streaming"}
+	}
+	var (
+		res *ModelResponse
+		err error
+	)
+	if !p.lazy {
+		if res, err = p.Generate(ctx, req, opts...); err != nil {
+			return nil, err
+		}
+	}
+	pipe := NewStreamPipe[*ModelResponse]()
+	pipe.Go(func() error {
+		if p.lazy {
+			if res, err = p.Generate(ctx, req, opts...); err != nil {
+				return err
+			}
+		}
+		pipe.Send(res)
+		return nil
+	})
+	if p.trailing != nil {
+		return &trailingStream[*ModelResponse]{Streamer: pipe, err: p.trailing}, nil
+	}
+	return pipe, nil
+}
+
+// trailingStream reports err when it is closed, after its last value.
+type trailingStream[T any] struct {
+	Streamer[T]
+	err error
+}
+
+func (s *trailingStream[T]) Close() error {
+	s.Streamer.Close()
+	return s.err
+}
+
+// fakeRunner is the scripted Runner of the tests, answering with the texts of
+// replies in turn, the last one repeating.
+type fakeRunner struct {
+	replies []string
+	usage   *Usage
+	// chunks are streamed as incomplete assistant messages instead when set.
+	chunks []string
+
+	mu      sync.Mutex
+	prompts []*Prompt
+}
+
+func (r *fakeRunner) Run(ctx context.Context, prompt *Prompt, opts ...ModelOption) (*Generation, error) {
+	r.mu.Lock()
+	r.prompts = append(r.prompts, prompt)
+	call := len(r.prompts)
+	r.mu.Unlock()
+	g := &Generation{Messages: textResponse(r.replies[min(call, len(r.replies))-1]).Messages}
+	if r.usage != nil {
+		usage := *r.usage
+		g.Usage = &usage
+	}
+	return g, nil
+}
+
+func (r *fakeRunner) RunStream(ctx context.Context, prompt *Prompt, opts ...ModelOption) (Streamer[*Generation], error) {
+	if r.chunks != nil {
+		pipe := NewStreamPipe[*Generation]()
+		pipe.Go(func() error {
+			for _, chunk := range r.chunks {
+				pipe.Send(&Generation{Messages: []*Message{{Role: RoleAssistant, Status: StatusIncomplete, Parts: Parts(chunk)}}})
+			}
+			return nil
+		})
+		return pipe, nil
+	}
+	g, err := r.Run(ctx, prompt, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return streamOf(g), nil
+}
+
+// fakeMemory is an in-memory Memory, the counterpart of memory.InMemory, which
+// the tests of this package cannot import.
+type fakeMemory struct {
+	mu       sync.Mutex
+	messages map[string][]*Message
+}
+
+func (m *fakeMemory) AddMessages(ctx context.Context, id string, messages []*Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.messages == nil {
+		m.messages = make(map[string][]*Message)
+	}
+	m.messages[id] = append(m.messages[id], messages...)
+	return nil
+}
+
+func (m *fakeMemory) ListMessages(ctx context.Context, id string) ([]*Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*Message(nil), m.messages[id]...), nil
+}
+
+func (m *fakeMemory) Clear(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.messages, id)
+	return nil
+}
+
+// texts returns the texts of the messages of the conversation.
+func (m *fakeMemory) texts(id string) []string {
+	messages, _ := m.ListMessages(context.Background(), id)
+	texts := make([]string, len(messages))
+	for i, msg := range messages {
+		texts[i] = msg.Text()
+	}
+	return texts
+}
+
+// textResponse returns a response with a completed assistant message of text.
+func textResponse(text string) *ModelResponse {
+	return &ModelResponse{Messages: []*Message{{Role: RoleAssistant, Status: StatusCompleted, Parts: Parts(text)}}}
+}
+
+// streamOf returns a stream of the values.
+func streamOf[T any](values ...T) Streamer[T] {
+	pipe := NewStreamPipe[T]()
+	pipe.Go(func() error {
+		for _, v := range values {
+			pipe.Send(v)
+		}
+		return nil
+	})
+	return pipe
+}
+
+// weatherTool answers every call with "sunny".
+func weatherTool() *Tool {
+	return &Tool{
+		Name: "weather",
+		Handle: func(ctx context.Context, args string) (string, error) {
+			return "sunny", nil
+		},
+	}
+}
+
+// weatherReply calls the weather tool until a tool result is present, then
+// answers with it.
+func weatherReply(req *ModelRequest, opts ModelOptions) *ModelResponse {
+	usage := &Usage{PromptTokens: 8, CompletionTokens: 2, TotalTokens: 10}
+	for _, msg := range req.Messages {
+		if msg.Role == RoleTool {
+			res := textResponse("weather is " + msg.ToolCalls[0].Result)
+			res.Usage = usage
+			return res
+		}
+	}
+	return &ModelResponse{Messages: []*Message{{
+		Role:      RoleAssistant,
+		Status:    StatusCompleted,
+		ToolCalls: []*ToolCall{{ID: "call_1", Name: "weather", Arguments: `{"city":"Paris"}`}},
+	}}, Usage: usage}
+}
+
+// weatherProvider returns a fakeProvider replying with weatherReply.
+func weatherProvider() *fakeProvider {
+	return &fakeProvider{reply: weatherReply}
+}
+
+// lastResponse reads the stream to the end and returns its last response and
+// the error it closed with.
+func lastResponse(stream Streamer[*ModelResponse]) (*ModelResponse, error) {
+	var last *ModelResponse
+	for stream.Next() {
+		res, err := stream.Current()
+		if err != nil {
+			drain(stream)
+			return nil, err
+		}
+		last = res
+	}
+	return last, stream.Close()
+}
@@ -0,0 +1,319 @@
+package blades
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	_ ModelProvider = (*FallbackProvider)(nil)
+)
+
+// Backend is a named provider of a FallbackProvider, optionally with its own model.
+type Backend struct {
+	Name     string
+	Provider ModelProvider
+	// Model overrides the model of the request when set.
+	Model string
+}
+
+// FallbackAttempt records a call to one backend of a FallbackProvider.
+type FallbackAttempt struct {
+	Backend  string
+	Err      error
+	Duration time.Duration
+}
+
+// FallbackOption configures a FallbackProvider.
+type FallbackOption func(*FallbackProvider)
+
+// FallbackTimeout fails over to the next backend when a call, or opening a
+// stream, takes longer than timeout.
+func FallbackTimeout(timeout time.Duration) FallbackOption {
+	return func(p *FallbackProvider) {
+		p.timeout = timeout
+	}
+}
+
+// FallbackObserver sets a hook called with the attempts made for each request;
+// when the request succeeded, the last attempt is the backend that served it.
+func FallbackObserver(fn func(ctx context.Context, attempts []FallbackAttempt)) FallbackOption {
+	return func(p *FallbackProvider) {
+		p.observe = fn
+	}
+}
+
+// FallbackAffinity pins each session to the backend that last served it, so
+// that a conversation keeps hitting the same provider and model, preserving
+// its provider-side prompt cache and a consistent style, until that backend
+// fails. A session is the ConversationID of the AgentContext, or else the
+// prompt cache key of the request; requests without either are not pinned.
+// Pins unused for longer than ttl expire, letting sessions return to the
+// primary; a ttl of 0 keeps them until the backend fails. Sessions served by
+// the primary are not pinned, as it is tried first anyway.
+func FallbackAffinity(ttl time.Duration) FallbackOption {
+	return func(p *FallbackProvider) {
+		p.affinity = true
+		p.affinityTTL = ttl
+	}
+}
+
+// FallbackMaxSessions bounds the number of sessions pinned by FallbackAffinity,
+// 1024 by default; the least recently used pins are evicted first.
+func FallbackMaxSessions(n int) FallbackOption {
+	return func(p *FallbackProvider) {
+		p.maxSessions = n
+	}
+}
+
+// FallbackProvider sends requests to a primary backend and transparently fails
+// over to the backups, in order, on error or timeout. Responses carry the name
+// of the backend that served them in the "backend" message metadata, and a
+// WarningFallback when it is not the primary.
+type FallbackProvider struct {
+	backends []Backend
+	timeout  time.Duration
+	observe  func(context.Context, []FallbackAttempt)
+
+	affinity    bool
+	affinityTTL time.Duration
+	maxSessions int
+	mu          sync.Mutex
+	pins        *list.List
+	sessions    map[string]*list.Element
+}
+
+// backendPin is the backend a session is pinned to.
+type backendPin struct {
+	session string
+	backend int
+	used    time.Time
+}
+
+// NewFallbackProvider creates a FallbackProvider trying primary, then backups.
+func NewFallbackProvider(primary Backend, backups []Backend, opts ...FallbackOption) *FallbackProvider {
+	p := &FallbackProvider{
+		backends:    append([]Backend{primary}, backups...),
+		observe:     func(context.Context, []FallbackAttempt) {},
+		maxSessions: 1024,
+		pins:        list.New(),
+		sessions:    make(map[string]*list.Element),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Generate executes the request on the first backend that succeeds.
+func (p *FallbackProvider) Generate(ctx context.Context, req *ModelRequest, opts ...ModelOption) (*ModelResponse, error) {
+	var (
+		errs     []error
+		attempts []FallbackAttempt
+	)
+	defer func() { p.observe(ctx, attempts) }()
+	session := p.session(ctx, opts)
+	for _, i := range p.order(session) {
+		backend := p.backends[i]
+		started := Now()
+		res, err := p.generate(ctx, backend, req, opts)
+		attempts = append(attempts, FallbackAttempt{Backend: backend.Name, Err: err, Duration: Since(started)})
+		if err == nil {
+			p.pin(session, i)
+			markBackend(res, backend.Name)
+			res.Warnings = append(res.Warnings, p.fallbackWarnings(attempts)...)
+			return res, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (p *FallbackProvider) generate(ctx context.Context, backend Backend, req *ModelRequest, opts []ModelOption) (*ModelResponse, error) {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	return backend.Provider.Generate(ctx, backend.request(req), opts...)
+}
+
+// NewStream opens the stream on the first backend that succeeds. Failures after
+// the first chunk of a stream are not failed over.
+func (p *FallbackProvider) NewStream(ctx context.Context, req *ModelRequest, opts ...ModelOption) (Streamer[*ModelResponse], error) {
+	var (
+		errs     []error
+		attempts []FallbackAttempt
+	)
+	defer func() { p.observe(ctx, attempts) }()
+	session := p.session(ctx, opts)
+	for _, i := range p.order(session) {
+		backend := p.backends[i]
+		started := Now()
+		stream, err := p.newStream(ctx, backend, req, opts)
+		attempts = append(attempts, FallbackAttempt{Backend: backend.Name, Err: err, Duration: Since(started)})
+		if err == nil {
+			p.pin(session, i)
+			warnings := p.fallbackWarnings(attempts)
+			return NewMappedStream(stream, func(res *ModelResponse) (*ModelResponse, error) {
+				markBackend(res, backend.Name)
+				// Report the fallback once, on the first response.
+				res.Warnings = append(res.Warnings, warnings...)
+				warnings = nil
+				return res, nil
+			}), nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// newStream opens the stream and reads its first chunk, canceling it if that
+// takes longer than the timeout.
+func (p *FallbackProvider) newStream(ctx context.Context, backend Backend, req *ModelRequest, opts []ModelOption) (Streamer[*ModelResponse], error) {
+	if p.timeout <= 0 {
+		return peekStream(backend.Provider.NewStream(ctx, backend.request(req), opts...))
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	timer := time.AfterFunc(p.timeout, cancel)
+	stream, err := peekStream(backend.Provider.NewStream(ctx, backend.request(req), opts...))
+	if !timer.Stop() {
+		if err == nil {
+			drain(stream)
+		}
+		return nil, context.DeadlineExceeded
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &cancelStream{Streamer: stream, cancel: cancel}, nil
+}
+
+// cancelStream cancels the context of the stream when it is closed.
+type cancelStream struct {
+	Streamer[*ModelResponse]
+	cancel context.CancelFunc
+}
+
+// Close cancels the context of the stream and closes it once its producer has
+// stopped.
+func (s *cancelStream) Close() error {
+	s.cancel()
+	return drain(s.Streamer)
+}
+
+func (b Backend) request(req *ModelRequest) *ModelRequest {
+	if b.Model == "" {
+		return req
+	}
+	r := *req
+	r.Model = b.Model
+	return &r
+}
+
+// session returns the session key of a request for affinity, if any.
+func (p *FallbackProvider) session(ctx context.Context, opts []ModelOption) string {
+	if !p.affinity {
+		return ""
+	}
+	if agent, ok := FromContext(ctx); ok && agent.ConversationID != "" {
+		return agent.ConversationID
+	}
+	var opt ModelOptions
+	for _, apply := range opts {
+		apply(&opt)
+	}
+	return opt.Cache.Key
+}
+
+// order returns the indexes of the backends to try: the backend the session
+// is pinned to first, then the others in order.
+func (p *FallbackProvider) order(session string) []int {
+	order := make([]int, 0, len(p.backends))
+	if session != "" {
+		p.mu.Lock()
+		elem, ok := p.sessions[session]
+		if ok {
+			pin := elem.Value.(*backendPin)
+			if p.affinityTTL > 0 && Since(pin.used) > p.affinityTTL {
+				p.unpin(elem)
+			} else {
+				order = append(order, pin.backend)
+			}
+		}
+		p.mu.Unlock()
+	}
+	for i := range p.backends {
+		if !slices.Contains(order, i) {
+			order = append(order, i)
+		}
+	}
+	return order
+}
+
+// pin pins the session to the backend that served it, or unpins it when that
+// is the primary. The least recently used pins, which are also the first to
+// expire, are evicted beyond maxSessions.
+func (p *FallbackProvider) pin(session string, backend int) {
+	if session == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	elem, ok := p.sessions[session]
+	switch {
+	case backend == 0:
+		if ok {
+			p.unpin(elem)
+		}
+		return
+	case ok:
+		elem.Value = &backendPin{session: session, backend: backend, used: Now()}
+		p.pins.MoveToFront(elem)
+	default:
+		p.sessions[session] = p.pins.PushFront(&backendPin{session: session, backend: backend, used: Now()})
+	}
+	for p.pins.Len() > max(p.maxSessions, 0) {
+		p.unpin(p.pins.Back())
+	}
+}
+
+// unpin removes the pin of elem. p.mu must be held.
+func (p *FallbackProvider) unpin(elem *list.Element) {
+	p.pins.Remove(elem)
+	delete(p.sessions, elem.Value.(*backendPin).session)
+}
+
+// fallbackWarnings returns a WarningFallback when the last attempt was not the
+// primary backend.
+func (p *FallbackProvider) fallbackWarnings(attempts []FallbackAttempt) []Warning {
+	served := attempts[len(attempts)-1].Backend
+	switch {
+	case len(attempts) >= 2:
+		return []Warning{{Code: WarningFallback, Message: fmt.Sprintf("served by %s after %s failed: %v", served, attempts[0].Backend, attempts[0].Err)}}
+	case served != p.backends[0].Name:
+		return []Warning{{Code: WarningFallback, Message: fmt.Sprintf("served by %s, which the session is pinned to", served)}}
+	}
+	return nil
+}
+
+func markBackend(res *ModelResponse, name string) {
+	for _, msg := range res.Messages {
+		if msg.Metadata == nil {
+			msg.Metadata = make(map[string]string)
+		}
+		msg.Metadata["backend"] = name
+	}
+}
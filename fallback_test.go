@@ -0,0 +1,147 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFallbackProvider(t *testing.T) {
+	var served []FallbackAttempt
+	provider := NewFallbackProvider(
+		Backend{Name: "zeus", Provider: &fakeProvider{err: errors.New("down"), failures: 1}},
+		[]Backend{{Name: "gemini", Provider: textProvider("ok")}},
+		FallbackObserver(func(ctx context.Context, attempts []FallbackAttempt) { served = attempts }),
+	)
+	res, err := provider.Generate(context.Background(), &ModelRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Messages[0].Metadata["backend"]; got != "gemini" {
+		t.Fatalf("expected gemini to serve the request, got %q", got)
+	}
+	if len(served) != 2 || served[0].Err == nil || served[1].Backend != "gemini" {
+		t.Fatalf("unexpected attempts %+v", served)
+	}
+}
+
+func TestFallbackProvider_Affinity(t *testing.T) {
+	zeus := &fakeProvider{replies: []string{"ok"}, err: errors.New("down"), failures: 1}
+	provider := NewFallbackProvider(
+		Backend{Name: "zeus", Provider: zeus},
+		[]Backend{{Name: "gemini", Provider: textProvider("ok")}},
+		FallbackAffinity(0),
+	)
+	session := NewContext(context.Background(), &AgentContext{ConversationID: "c1"})
+	for i := 0; i < 2; i++ {
+		res, err := provider.Generate(session, &ModelRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the session stays on gemini even though zeus has recovered
+		if got := res.Messages[0].Metadata["backend"]; got != "gemini" {
+			t.Fatalf("request %d: expected the session to stay on gemini, got %q", i, got)
+		}
+	}
+	res, err := provider.Generate(context.Background(), &ModelRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Messages[0].Metadata["backend"]; got != "zeus" {
+		t.Fatalf("expected other requests to go to the primary, got %q", got)
+	}
+}
+
+func TestFallbackProvider_AffinityEviction(t *testing.T) {
+	zeus := &fakeProvider{replies: []string{"ok"}, err: errors.New("down")}
+	provider := NewFallbackProvider(
+		Backend{Name: "zeus", Provider: zeus},
+		[]Backend{{Name: "gemini", Provider: textProvider("ok")}},
+		FallbackAffinity(0),
+		FallbackMaxSessions(2),
+	)
+	generate := func(session string) string {
+		ctx := NewContext(context.Background(), &AgentContext{ConversationID: session})
+		res, err := provider.Generate(ctx, &ModelRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Messages[0].Metadata["backend"]
+	}
+	generate("primary")
+	zeus.down.Store(true)
+	for _, session := range []string{"c1", "c2", "c3"} {
+		generate(session)
+	}
+	zeus.down.Store(false)
+	tests := []struct {
+		session string
+		backend string
+	}{
+		{"primary", "zeus"},
+		{"c1", "zeus"},
+		{"c2", "gemini"},
+		{"c3", "gemini"},
+	}
+	for _, tt := range tests {
+		if got := generate(tt.session); got != tt.backend {
+			t.Errorf("session %s: expected %s, got %s", tt.session, tt.backend, got)
+		}
+	}
+	if n := len(provider.sessions); n != 2 {
+		t.Fatalf("expected only the sessions on the backup to be pinned, got %d", n)
+	}
+}
+
+func TestFallbackProvider_Stream(t *testing.T) {
+	tests := []struct {
+		name    string
+		lazy    bool
+		timeout time.Duration
+	}{
+		{"fails over failures to open", false, 0},
+		{"fails over failures on the first read", true, 0},
+		{"fails over failures on the first read with a timeout", true, time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewFallbackProvider(
+				Backend{Name: "zeus", Provider: &fakeProvider{err: errors.New("down"), failures: 1, lazy: tt.lazy}},
+				[]Backend{{Name: "gemini", Provider: textProvider("ok")}},
+				FallbackTimeout(tt.timeout),
+			)
+			stream, err := provider.NewStream(context.Background(), &ModelRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := lastResponse(stream)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := res.Messages[0].Metadata["backend"]; got != "gemini" {
+				t.Fatalf("expected gemini to serve the stream, got %q", got)
+			}
+		})
+	}
+}
+
+func TestFallbackProvider_StreamClosedEarly(t *testing.T) {
+	chunked := &chunkedProvider{ModelProvider: textProvider("ok"), chunks: 64, done: make(chan struct{})}
+	provider := NewFallbackProvider(Backend{Name: "zeus", Provider: chunked}, nil, FallbackTimeout(time.Minute))
+	stream, err := provider.NewStream(context.Background(), &ModelRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stream.Next() {
+		t.Fatal("expected the first chunk")
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-chunked.done:
+	default:
+		t.Fatal("expected the producer to have stopped once the stream is closed")
+	}
+}
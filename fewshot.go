@@ -0,0 +1,177 @@
+package blades
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/go-kratos/blades/vectorstore"
+)
+
+// Embedder embeds texts as vectors.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// EmbedderFunc adapts a function to an Embedder.
+type EmbedderFunc func(ctx context.Context, texts []string) ([][]float32, error)
+
+// Embed calls f(ctx, texts).
+func (f EmbedderFunc) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return f(ctx, texts)
+}
+
+// Example is an input and the output expected for it, shown to the model as a
+// user message and the assistant reply.
+type Example struct {
+	Input  string `json:"input"`
+	Output string `json:"output"`
+}
+
+// FewShotOption configures a FewShot.
+type FewShotOption func(*FewShot)
+
+// FewShotSimilar selects the k examples whose inputs are the most similar to
+// the prompt, as embedded by embedder, instead of all of them.
+func FewShotSimilar(embedder Embedder, k int) FewShotOption {
+	return func(f *FewShot) {
+		f.embedder, f.k = embedder, k
+	}
+}
+
+// FewShot stores example input and output pairs and renders them into prompts
+// as a conversation preceding the last user message.
+type FewShot struct {
+	mu       sync.RWMutex
+	examples []Example
+	vectors  [][]float32
+	embedder Embedder
+	k        int
+}
+
+// NewFewShot creates a FewShot. Examples given with FewShotSimilar are
+// embedded on first use; use Add to embed them eagerly.
+func NewFewShot(examples []Example, opts ...FewShotOption) *FewShot {
+	f := &FewShot{}
+	for _, opt := range opts {
+		opt(f)
+	}
+	f.examples = slices.Clone(examples)
+	return f
+}
+
+// Add stores examples, embedding their inputs when selecting similar examples.
+func (f *FewShot) Add(ctx context.Context, examples ...Example) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.examples = append(f.examples, examples...)
+	return f.embed(ctx)
+}
+
+// embed embeds the inputs of the examples not embedded yet.
+func (f *FewShot) embed(ctx context.Context) error {
+	if f.embedder == nil || len(f.vectors) == len(f.examples) {
+		return nil
+	}
+	pending := f.examples[len(f.vectors):]
+	inputs := make([]string, len(pending))
+	for i, example := range pending {
+		inputs[i] = example.Input
+	}
+	vectors, err := f.embedder.Embed(ctx, inputs)
+	if err != nil {
+		return fmt.Errorf("embed examples: %w", err)
+	}
+	if len(vectors) != len(pending) {
+		return fmt.Errorf("embed examples: got %d vectors for %d examples", len(vectors), len(pending))
+	}
+	f.vectors = append(f.vectors, vectors...)
+	return nil
+}
+
+// Select returns the examples to show for input: all of them, or the most
+// similar ones first with FewShotSimilar.
+func (f *FewShot) Select(ctx context.Context, input string) ([]Example, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.embedder == nil || f.k <= 0 || len(f.examples) <= f.k {
+		return slices.Clone(f.examples), nil
+	}
+	if err := f.embed(ctx); err != nil {
+		return nil, err
+	}
+	vectors, err := f.embedder.Embed(ctx, []string{input})
+	if err != nil {
+		return nil, fmt.Errorf("embed input: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, errors.New("embed input: no vector")
+	}
+	type scored struct {
+		example Example
+		score   float64
+	}
+	ranked := make([]scored, len(f.examples))
+	for i, example := range f.examples {
+		ranked[i] = scored{example, vectorstore.Cosine(vectors[0], f.vectors[i])}
+	}
+	slices.SortStableFunc(ranked, func(a, b scored) int { return cmp.Compare(b.score, a.score) })
+	examples := make([]Example, f.k)
+	for i := range examples {
+		examples[i] = ranked[i].example
+	}
+	return examples, nil
+}
+
+// Apply returns a copy of prompt with the examples selected for its last user
+// message inserted before it, as user and assistant messages.
+func (f *FewShot) Apply(ctx context.Context, prompt *Prompt) (*Prompt, error) {
+	last := -1
+	for i, msg := range prompt.Messages {
+		if msg.Role == RoleUser {
+			last = i
+		}
+	}
+	if last < 0 {
+		return prompt, nil
+	}
+	examples, err := f.Select(ctx, prompt.Messages[last].Text())
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]*Message, 0, len(prompt.Messages)+2*len(examples))
+	messages = append(messages, prompt.Messages[:last]...)
+	for _, example := range examples {
+		input, output := UserMessage(example.Input), AssistantMessage(example.Output)
+		input.Metadata = map[string]string{"example": "true"}
+		output.Metadata = map[string]string{"example": "true"}
+		messages = append(messages, input, output)
+	}
+	messages = append(messages, prompt.Messages[last:]...)
+	return &Prompt{ConversationID: prompt.ConversationID, Messages: messages}, nil
+}
+
+// Middleware returns a Middleware applying the examples to every prompt.
+func (f *FewShot) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return Handler{
+			Run: func(ctx context.Context, prompt *Prompt, opts ...ModelOption) (*Generation, error) {
+				p, err := f.Apply(ctx, prompt)
+				if err != nil {
+					return nil, err
+				}
+				return next.Run(ctx, p, opts...)
+			},
+			Stream: func(ctx context.Context, prompt *Prompt, opts ...ModelOption) (Streamer[*Generation], error) {
+				p, err := f.Apply(ctx, prompt)
+				if err != nil {
+					return nil, err
+				}
+				return next.Stream(ctx, p, opts...)
+			},
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package blades
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestFewShot(t *testing.T) {
+	ctx := context.Background()
+	// embed texts on two axes: about weather or about money
+	embed := EmbedderFunc(func(ctx context.Context, texts []string) ([][]float32, error) {
+		vectors := make([][]float32, len(texts))
+		for i, text := range texts {
+			if strings.Contains(text, "rain") || strings.Contains(text, "sun") {
+				vectors[i] = []float32{1, 0}
+			} else {
+				vectors[i] = []float32{0, 1}
+			}
+		}
+		return vectors, nil
+	})
+	examples := []Example{
+		{Input: "Will it rain?", Output: "weather"},
+		{Input: "Refund my order", Output: "billing"},
+		{Input: "Is it sunny?", Output: "weather"},
+	}
+	prompt := NewPrompt(SystemMessage("Classify the request."), UserMessage("Do I need sun cream?"))
+
+	all, err := NewFewShot(examples).Apply(ctx, prompt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all.Messages) != 8 || all.Messages[0].Role != RoleSystem || all.Messages[7].Text() != "Do I need sun cream?" {
+		t.Fatalf("expected every example between the system and user messages, got %d messages", len(all.Messages))
+	}
+
+	similar, err := NewFewShot(examples, FewShotSimilar(embed, 1)).Apply(ctx, prompt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(similar.Messages) != 4 || similar.Messages[2].Text() != "weather" {
+		t.Fatalf("expected the most similar example only, got %d messages", len(similar.Messages))
+	}
+	if len(prompt.Messages) != 2 {
+		t.Fatalf("the original prompt was modified")
+	}
+}
@@ -3,10 +3,12 @@ package flow
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 
 	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/report"
 )
 
 var (
@@ -30,6 +32,8 @@ const (
 type Chain struct {
 	runners []blades.Runner
 	verbose bool
+	logger  *slog.Logger
+	scorer  Scorer
 }
 
 // NewChain creates a new Chain with the given runners.
@@ -53,8 +57,121 @@ func (c *Chain) SetVerbose(verbose bool) {
 	c.verbose = verbose
 }
 
+// SetLogger logs the start, end and errors of every step as structured records
+// carrying the run ID and step index. A run ID is generated per run unless the
+// context already carries one, and is shared with the steps.
+func (c *Chain) SetLogger(logger *slog.Logger) {
+	c.logger = logger
+}
+
+// StepScore is the score given to the output of a step, e.g. by a reviewer.
+type StepScore struct {
+	Score float64 `json:"score"`
+	Notes string  `json:"notes,omitempty"`
+}
+
+// StepReview is a step output to score.
+type StepReview struct {
+	// RunID identifies the chain run, when the context carries one.
+	RunID string
+	// Step is the index of the step, starting at 0.
+	Step   int
+	Name   string
+	Input  *blades.Prompt
+	Output *blades.Generation
+}
+
+// Scorer scores the output of chain steps, typically by collecting labels
+// from human reviewers.
+type Scorer interface {
+	Score(ctx context.Context, review StepReview) (*StepScore, error)
+}
+
+// ScorerFunc adapts a function to a Scorer.
+type ScorerFunc func(ctx context.Context, review StepReview) (*StepScore, error)
+
+// Score calls f(ctx, review).
+func (f ScorerFunc) Score(ctx context.Context, review StepReview) (*StepScore, error) {
+	return f(ctx, review)
+}
+
+// SetScorer scores the output of every step with scorer. Scores are recorded
+// in the StepResult of RunSteps, logged with the step and, whether the chain is
+// run with Run, RunSteps or RunStream, kept in the RunRecord of runs recorded
+// by a report.Runner. A failing scorer does not fail the run: its error is
+// logged and the step is left unscored.
+func (c *Chain) SetScorer(scorer Scorer) {
+	c.scorer = scorer
+}
+
+// StepResult is the output of one step of a chain.
+type StepResult struct {
+	Name       string
+	Generation *blades.Generation
+	// Score is the score of the step, when a Scorer is set.
+	Score *StepScore
+}
+
+// ChainResult holds the output of every step of a chain run, in order.
+type ChainResult struct {
+	Steps []StepResult
+}
+
+// Step returns the output of the step with the given name.
+func (r *ChainResult) Step(name string) (*blades.Generation, bool) {
+	for _, step := range r.Steps {
+		if step.Name == name {
+			return step.Generation, true
+		}
+	}
+	return nil, false
+}
+
+// Last returns the output of the last step, or nil for an empty chain.
+func (r *ChainResult) Last() *blades.Generation {
+	if len(r.Steps) == 0 {
+		return nil
+	}
+	return r.Steps[len(r.Steps)-1].Generation
+}
+
+// Usage returns the usage summed over the steps, or nil when no step reported any.
+func (r *ChainResult) Usage() *blades.Usage {
+	var usage *blades.Usage
+	for _, step := range r.Steps {
+		if step.Generation.Usage == nil {
+			continue
+		}
+		if usage == nil {
+			usage = &blades.Usage{}
+		}
+		usage.Add(step.Generation.Usage)
+	}
+	return usage
+}
+
+// add records the output of a step, suffixing the name of steps sharing the
+// name of an earlier step with their number, e.g. "writer#3".
+func (r *ChainResult) add(name string, stepNum int, g *blades.Generation, score *StepScore) {
+	if _, ok := r.Step(name); ok {
+		name = fmt.Sprintf("%s#%d", name, stepNum)
+	}
+	r.Steps = append(r.Steps, StepResult{Name: name, Generation: g, Score: score})
+}
+
 // Run executes the chain of runners sequentially, passing the output of one as the input to the next.
 func (c *Chain) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	result, err := c.RunSteps(ctx, prompt, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return result.Last(), nil
+}
+
+// RunSteps executes the chain like Run and returns the output of every step,
+// named after the agent or Step, or "Step N" for other runners.
+func (c *Chain) RunSteps(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*ChainResult, error) {
+	ctx = c.runContext(ctx)
 	if !c.verbose {
 		return c.runSilent(ctx, prompt, opts...)
 	}
@@ -63,23 +180,22 @@ func (c *Chain) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.M
 }
 
 // runSilent executes the chain without verbose output.
-func (c *Chain) runSilent(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
-	var (
-		err  error
-		last *blades.Generation
-	)
-	for _, runner := range c.runners {
-		last, err = runner.Run(ctx, prompt, opts...)
+func (c *Chain) runSilent(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*ChainResult, error) {
+	result := &ChainResult{}
+	for i, runner := range c.runners {
+		last, score, err := c.runStep(ctx, i, runner, prompt, opts)
 		if err != nil {
 			return nil, err
 		}
+		name, _ := c.getStepInfo(runner, i+1)
+		result.add(name, i+1, last, score)
 		prompt = blades.NewPrompt(last.Messages...)
 	}
-	return last, nil
+	return result, nil
 }
 
 // runVerbose executes the chain with beautiful visualization.
-func (c *Chain) runVerbose(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+func (c *Chain) runVerbose(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*ChainResult, error) {
 	totalSteps := len(c.runners)
 
 	// Print header
@@ -90,7 +206,7 @@ func (c *Chain) runVerbose(ctx context.Context, prompt *blades.Prompt, opts ...b
 	c.printText(prompt.String(), ColorCyan)
 
 	var currentPrompt = prompt
-	var finalResult *blades.Generation
+	var chainResult = &ChainResult{}
 
 	// Execute each step
 	for i, runner := range c.runners {
@@ -109,20 +225,20 @@ func (c *Chain) runVerbose(ctx context.Context, prompt *blades.Prompt, opts ...b
 		c.printInput(currentPrompt.String())
 
 		// Execute step
-		start := time.Now()
-		result, err := runner.Run(ctx, currentPrompt, opts...)
+		start := blades.Now()
+		result, score, err := c.runStep(ctx, i, runner, currentPrompt, opts)
 		if err != nil {
 			c.printError(err)
 			return nil, err
 		}
-		duration := time.Since(start)
+		duration := blades.Since(start)
 
 		// Print output
 		c.printOutput(result.Text(), duration)
 
 		// Update prompt for next step
 		currentPrompt = blades.NewPrompt(result.Messages...)
-		finalResult = result
+		chainResult.add(stepName, stepNum, result, score)
 
 		// Add separator between steps
 		if i < totalSteps-1 {
@@ -131,30 +247,107 @@ func (c *Chain) runVerbose(ctx context.Context, prompt *blades.Prompt, opts ...b
 	}
 
 	// Print final result
-	c.printFinalResult(finalResult.Text())
+	if final := chainResult.Last(); final != nil {
+		c.printFinalResult(final.Text())
+	}
 
-	return finalResult, nil
+	return chainResult, nil
 }
 
-// RunStream executes the chain of runners sequentially, streaming the output of the last runner.
+// RunStream executes the chain of runners sequentially, streaming the output of
+// each runner followed by a terminal generation summarizing the whole chain.
 func (c *Chain) RunStream(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (blades.Streamer[*blades.Generation], error) {
+	ctx = c.runContext(ctx)
 	pipe := blades.NewStreamPipe[*blades.Generation]()
 	pipe.Go(func() error {
-		for _, runner := range c.runners {
-			last, err := runner.Run(ctx, prompt, opts...)
+		var (
+			started = blades.Now()
+			finish  = &blades.Finish{Reason: "stop"}
+		)
+		for i, runner := range c.runners {
+			last, _, err := c.runStep(ctx, i, runner, prompt, opts)
 			if err != nil {
 				return err
 			}
+			if last.Usage != nil {
+				if finish.Usage == nil {
+					finish.Usage = &blades.Usage{}
+				}
+				finish.Usage.Add(last.Usage)
+			}
 			pipe.Send(last)
 			prompt = blades.NewPrompt(last.Messages...)
 		}
+		finish.Elapsed = blades.Since(started)
+		pipe.Send(&blades.Generation{Finish: finish})
 		return nil
 	})
 	return pipe, nil
 }
 
+// runContext returns ctx with a run ID when logging.
+func (c *Chain) runContext(ctx context.Context) context.Context {
+	if c.logger == nil {
+		return ctx
+	}
+	if _, ok := blades.RunIDFromContext(ctx); ok {
+		return ctx
+	}
+	return blades.ContextWithRunID(ctx, blades.NewRunID())
+}
+
+// runStep runs the runner at index i, logging its start, end and error, and
+// scores its output when a scorer is set.
+func (c *Chain) runStep(ctx context.Context, i int, runner blades.Runner, prompt *blades.Prompt, opts []blades.ModelOption) (*blades.Generation, *StepScore, error) {
+	if c.logger == nil && c.scorer == nil {
+		res, err := runner.Run(ctx, prompt, opts...)
+		return res, nil, err
+	}
+	runID, _ := blades.RunIDFromContext(ctx)
+	name, _ := c.getStepInfo(runner, i+1)
+	attrs := []slog.Attr{slog.String("run_id", runID), slog.Int("step", i), slog.String("name", name)}
+	c.log(ctx, slog.LevelInfo, "step started", attrs...)
+	start := blades.Now()
+	res, err := runner.Run(ctx, prompt, opts...)
+	attrs = append(attrs, slog.Duration("duration", blades.Since(start)))
+	if err != nil {
+		c.log(ctx, slog.LevelError, "step failed", append(attrs, slog.Any("error", err))...)
+		return nil, nil, err
+	}
+	if res.Usage != nil {
+		attrs = append(attrs, slog.Int64("prompt_tokens", res.Usage.PromptTokens), slog.Int64("completion_tokens", res.Usage.CompletionTokens))
+	}
+	c.log(ctx, slog.LevelInfo, "step finished", attrs...)
+	if c.scorer == nil {
+		return res, nil, nil
+	}
+	score, err := c.scorer.Score(ctx, StepReview{RunID: runID, Step: i, Name: name, Input: prompt, Output: res})
+	switch {
+	case err != nil:
+		c.log(ctx, slog.LevelWarn, "step scoring failed", append(attrs, slog.Any("error", err))...)
+	case score != nil:
+		c.log(ctx, slog.LevelInfo, "step scored", append(attrs, slog.Float64("score", score.Score), slog.String("notes", score.Notes))...)
+		report.AddStepScore(ctx, report.StepScore{Step: i, Name: name, Score: score.Score, Notes: score.Notes})
+	}
+	return res, score, nil
+}
+
+// log logs the record when a logger is set.
+func (c *Chain) log(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	if c.logger != nil {
+		c.logger.LogAttrs(ctx, level, msg, attrs...)
+	}
+}
+
 // getStepInfo extracts step name and instructions from a runner (Agent)
 func (c *Chain) getStepInfo(runner blades.Runner, stepNum int) (string, string) {
+	if step, ok := runner.(*Step); ok {
+		if step.name != "" {
+			_, instructions := c.getStepInfo(step.runner, stepNum)
+			return step.name, instructions
+		}
+		runner = step.runner
+	}
 	// Try to get info from Agent if it's an Agent type
 	if agent, ok := runner.(*blades.Agent); ok {
 		name := agent.Name()
@@ -178,7 +371,7 @@ func (c *Chain) getStepInfo(runner blades.Runner, stepNum int) (string, string)
 // printHeader prints the chain execution header
 func (c *Chain) printHeader(totalSteps int) {
 	fmt.Printf("\n%s%s╔════════════════════════════════════════════════════════════════════════════════╗%s\n", ColorBold, ColorBlue, ColorReset)
-	fmt.Printf("%s%s║%s %sCHAIN EXECUTION STARTED%s %s│ Steps: %d%s %s║%s\n", ColorBold, ColorBlue, ColorReset, ColorBold, ColorWhite, ColorReset, ColorYellow, totalSteps, ColorBold, ColorBlue, ColorReset)
+	fmt.Printf("%s%s║%s %s%sCHAIN EXECUTION STARTED%s %s│ Steps: %d %s%s║%s\n", ColorBold, ColorBlue, ColorReset, ColorBold, ColorWhite, ColorReset, ColorYellow, totalSteps, ColorBold, ColorBlue, ColorReset)
 	fmt.Printf("%s%s╚════════════════════════════════════════════════════════════════════════════════╝%s\n\n", ColorBold, ColorBlue, ColorReset)
 }
 
@@ -240,7 +433,7 @@ func (c *Chain) printError(err error) {
 // printFinalResult prints the final result
 func (c *Chain) printFinalResult(result string) {
 	fmt.Printf("\n%s%s╔════════════════════════════════════════════════════════════════════════════════╗%s\n", ColorBold, ColorGreen, ColorReset)
-	fmt.Printf("%s%s║%s %s🎉 CHAIN EXECUTION COMPLETE! 🎉%s %s║%s\n", ColorBold, ColorGreen, ColorReset, ColorBold, ColorWhite, ColorBold, ColorGreen, ColorReset)
+	fmt.Printf("%s%s║%s %s%s🎉 CHAIN EXECUTION COMPLETE! 🎉 %s%s║%s\n", ColorBold, ColorGreen, ColorReset, ColorBold, ColorWhite, ColorBold, ColorGreen, ColorReset)
 	fmt.Printf("%s%s╚════════════════════════════════════════════════════════════════════════════════╝%s\n", ColorBold, ColorGreen, ColorReset)
 
 	fmt.Printf("\n%s%s📋 FINAL RESULT:%s\n", ColorBold, ColorCyan, ColorReset)
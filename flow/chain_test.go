@@ -0,0 +1,166 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/report"
+)
+
+// inputScorer scores a step with the length of its output, noting its input,
+// and fails to score "Step 2".
+var inputScorer = ScorerFunc(func(ctx context.Context, review StepReview) (*StepScore, error) {
+	if review.Name == "Step 2" {
+		return nil, errors.New("no reviewer")
+	}
+	return &StepScore{Score: float64(len(review.Output.Text())), Notes: review.Input.Messages[0].Text()}, nil
+})
+
+func TestChain_Scores(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(ctx context.Context, runner blades.Runner) error
+	}{
+		{"Run", func(ctx context.Context, runner blades.Runner) error {
+			_, err := runner.Run(ctx, userPrompt("hi"))
+			return err
+		}},
+		{"RunStream", func(ctx context.Context, runner blades.Runner) error {
+			stream, err := runner.RunStream(ctx, userPrompt("hi"))
+			if err != nil {
+				return err
+			}
+			_, err = collect(stream)
+			return err
+		}},
+	}
+	want := []report.StepScore{
+		{Step: 0, Name: "Step 1", Score: 3, Notes: "hi"},
+		{Step: 2, Name: "Step 3", Score: 5, Notes: "two"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := NewChainSilent(textRunner("one"), textRunner("two"), textRunner("three"))
+			chain.SetScorer(inputScorer)
+			store := report.NewMemoryStore()
+			if err := tt.run(context.Background(), report.NewRunner("chain", chain, store)); err != nil {
+				t.Fatal(err)
+			}
+			records, _ := store.Records(context.Background(), time.Time{}, time.Now().Add(time.Hour))
+			if len(records) != 1 {
+				t.Fatalf("expected one record, got %d", len(records))
+			}
+			if got := records[0].Scores; !slices.Equal(got, want) {
+				t.Fatalf("expected the scores %+v to be recorded, got %+v", want, got)
+			}
+		})
+	}
+}
+
+func TestChain_RunStepsScores(t *testing.T) {
+	chain := NewChainSilent(textRunner("one"), textRunner("two"), textRunner("three"))
+	chain.SetScorer(inputScorer)
+	result, err := chain.RunSteps(context.Background(), userPrompt("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		step  int
+		score *StepScore
+	}{
+		{0, &StepScore{Score: 3, Notes: "hi"}},
+		{1, nil},
+		{2, &StepScore{Score: 5, Notes: "two"}},
+	}
+	for _, tt := range tests {
+		got := result.Steps[tt.step].Score
+		if (got == nil) != (tt.score == nil) || (got != nil && *got != *tt.score) {
+			t.Errorf("step %d: expected score %+v, got %+v", tt.step, tt.score, got)
+		}
+	}
+}
+
+func TestChain_RunSteps(t *testing.T) {
+	usage := &blades.Usage{TotalTokens: 2}
+	writer := blades.NewAgent("writer", blades.WithProvider(&fakeProvider{reply: "draft"}))
+	chain := NewChainSilent(
+		writer,
+		&fakeRunner{replies: []string{"reviewed"}, usage: usage},
+		NewStep(writer, StepName("final")),
+		&fakeRunner{replies: []string{"polished"}, usage: usage},
+		writer,
+	)
+	result, err := chain.RunSteps(context.Background(), userPrompt("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"writer", "draft"},
+		{"Step 2", "reviewed"},
+		{"final", "draft"},
+		{"Step 4", "polished"},
+		{"writer#5", "draft"},
+	}
+	for i, tt := range tests {
+		if got := result.Steps[i].Name; got != tt.name {
+			t.Errorf("step %d: expected the name %q, got %q", i, tt.name, got)
+		}
+		if g, ok := result.Step(tt.name); !ok || g.Text() != tt.want {
+			t.Errorf("step %q: expected %q", tt.name, tt.want)
+		}
+	}
+	if _, ok := result.Step("missing"); ok {
+		t.Error("expected no step named missing")
+	}
+	if last := result.Last(); last.Text() != "draft" {
+		t.Errorf("expected the last step output, got %q", last.Text())
+	}
+	if usage := result.Usage(); usage == nil || usage.TotalTokens != 4 {
+		t.Errorf("expected the usage summed over the steps, got %+v", usage)
+	}
+}
+
+func TestChain_Run(t *testing.T) {
+	boom := errors.New("boom")
+	tests := []struct {
+		name    string
+		runners []blades.Runner
+		want    string
+		err     error
+	}{
+		{"passes outputs on", []blades.Runner{textRunner("one"), &fakeRunner{reply: func(p *blades.Prompt) (string, error) {
+			return p.Messages[0].Text() + " two", nil
+		}}}, "one two", nil},
+		{"failing step", []blades.Runner{textRunner("one"), &fakeRunner{err: boom}, textRunner("three")}, "", boom},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := NewChainSilent(tt.runners...)
+			g, err := chain.Run(context.Background(), userPrompt("hi"))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+			if err == nil && g.Text() != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, g.Text())
+			}
+			stream, err := chain.RunStream(context.Background(), userPrompt("hi"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			gens, err := collect(stream)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected the stream to fail with %v, got %v", tt.err, err)
+			}
+			if err == nil && (len(gens) != len(tt.runners)+1 || gens[len(gens)-2].Text() != tt.want || gens[len(gens)-1].Finish == nil) {
+				t.Fatalf("expected every step output and a finish, got %d generations", len(gens))
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package flow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-kratos/blades"
+)
+
+var (
+	_ blades.Runner = (*Classifier[string])(nil)
+	_ Selector      = (*Classifier[string])(nil)
+)
+
+// ErrUnknownLabel is returned when the model answers with a label outside the label set.
+var ErrUnknownLabel = errors.New("flow: unknown label")
+
+// Label is a class of a Classifier, described to the model with optional
+// example inputs used as few-shots.
+type Label[L ~string] struct {
+	Name        L
+	Description string
+	Examples    []string
+}
+
+// Classification is the label chosen for an input with the model confidence.
+type Classification[L ~string] struct {
+	Label      L       `json:"label" jsonschema:"the name of the label matching the input"`
+	Confidence float64 `json:"confidence" jsonschema:"the confidence in the label, from 0 to 1"`
+}
+
+// ClassifierOption configures a Classifier.
+type ClassifierOption[L ~string] func(*Classifier[L])
+
+// ClassifierInstructions sets the instructions preceding the label set.
+func ClassifierInstructions[L ~string](instructions string) ClassifierOption[L] {
+	return func(c *Classifier[L]) {
+		c.instructions = instructions
+	}
+}
+
+// ClassifierFallback sets the label returned instead of failing when the model
+// answers with an unknown label or with a confidence below threshold.
+func ClassifierFallback[L ~string](label L, threshold float64) ClassifierOption[L] {
+	return func(c *Classifier[L]) {
+		c.fallback = &label
+		c.threshold = threshold
+	}
+}
+
+// Classifier classifies prompts into one of a fixed set of labels, such as
+// user intents. The model output is constrained to a JSON classification and
+// its label checked against the label set. A Classifier is a Selector, so it
+// can pick the route of a Router.
+type Classifier[L ~string] struct {
+	runner       blades.Runner
+	labels       []Label[L]
+	instructions string
+	fallback     *L
+	threshold    float64
+}
+
+// NewClassifier creates a Classifier asking runner, typically a small model,
+// to choose among labels.
+func NewClassifier[L ~string](runner blades.Runner, labels []Label[L], opts ...ClassifierOption[L]) *Classifier[L] {
+	c := &Classifier[L]{
+		runner:       runner,
+		labels:       labels,
+		instructions: "Classify the input into exactly one of the following labels.",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Classify returns the label of the prompt.
+func (c *Classifier[L]) Classify(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (Classification[L], error) {
+	p := &blades.Prompt{ConversationID: prompt.ConversationID}
+	p.Messages = append(p.Messages, blades.SystemMessage(c.systemPrompt()))
+	p.Messages = append(p.Messages, prompt.Messages...)
+	result, err := blades.GenerateObject[Classification[L]](ctx, c.runner, p, opts...)
+	if err != nil {
+		return result, fmt.Errorf("flow: classify: %w", err)
+	}
+	label, ok := c.label(result.Label)
+	switch {
+	case !ok && c.fallback == nil:
+		return result, fmt.Errorf("%w %q", ErrUnknownLabel, result.Label)
+	case !ok || result.Confidence < c.threshold:
+		return Classification[L]{Label: *c.fallback, Confidence: result.Confidence}, nil
+	}
+	result.Label = label
+	return result, nil
+}
+
+// Select returns the label of the prompt as a route name.
+func (c *Classifier[L]) Select(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (string, error) {
+	result, err := c.Classify(ctx, prompt, opts...)
+	if err != nil {
+		return "", err
+	}
+	return string(result.Label), nil
+}
+
+// Run classifies the prompt and returns the classification as JSON.
+func (c *Classifier[L]) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	result, err := c.Classify(ctx, prompt, opts...)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	msg := blades.AssistantMessage(string(b))
+	msg.Status = blades.StatusCompleted
+	return &blades.Generation{Messages: []*blades.Message{msg}}, nil
+}
+
+// RunStream classifies the prompt and yields the classification as a single generation.
+func (c *Classifier[L]) RunStream(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (blades.Streamer[*blades.Generation], error) {
+	pipe := blades.NewStreamPipe[*blades.Generation]()
+	pipe.Go(func() error {
+		res, err := c.Run(ctx, prompt, opts...)
+		if err != nil {
+			return err
+		}
+		pipe.Send(res)
+		return nil
+	})
+	return pipe, nil
+}
+
+// label returns the label of the set matching name, ignoring case.
+func (c *Classifier[L]) label(name L) (L, bool) {
+	for _, l := range c.labels {
+		if strings.EqualFold(string(l.Name), strings.TrimSpace(string(name))) {
+			return l.Name, true
+		}
+	}
+	return name, false
+}
+
+func (c *Classifier[L]) systemPrompt() string {
+	var buf strings.Builder
+	buf.WriteString(c.instructions)
+	buf.WriteString("\n\nLabels:\n")
+	for _, l := range c.labels {
+		fmt.Fprintf(&buf, "- %s", l.Name)
+		if l.Description != "" {
+			fmt.Fprintf(&buf, ": %s", l.Description)
+		}
+		buf.WriteString("\n")
+	}
+	var examples bool
+	for _, l := range c.labels {
+		for _, example := range l.Examples {
+			if !examples {
+				buf.WriteString("\nExamples:\n")
+				examples = true
+			}
+			fmt.Fprintf(&buf, "Input: %s\nLabel: %s\n", example, l.Name)
+		}
+	}
+	buf.WriteString("\nAnswer with the label name exactly as listed.")
+	return buf.String()
+}
@@ -0,0 +1,142 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/blades"
+)
+
+type intent string
+
+var intents = []Label[intent]{
+	{Name: "billing", Description: "payments and invoices", Examples: []string{"where is my invoice?"}},
+	{Name: "support", Description: "technical problems"},
+}
+
+func TestClassifier(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer string
+		opts   []ClassifierOption[intent]
+		want   Classification[intent]
+		err    error
+	}{
+		{
+			name:   "label",
+			answer: `{"label": "billing", "confidence": 0.9}`,
+			want:   Classification[intent]{Label: "billing", Confidence: 0.9},
+		},
+		{
+			name:   "label in another case",
+			answer: `{"label": " Support ", "confidence": 0.8}`,
+			want:   Classification[intent]{Label: "support", Confidence: 0.8},
+		},
+		{
+			name:   "unknown label",
+			answer: `{"label": "sales", "confidence": 0.9}`,
+			err:    ErrUnknownLabel,
+		},
+		{
+			name:   "unknown label with fallback",
+			answer: `{"label": "sales", "confidence": 0.9}`,
+			opts:   []ClassifierOption[intent]{ClassifierFallback[intent]("support", 0.5)},
+			want:   Classification[intent]{Label: "support", Confidence: 0.9},
+		},
+		{
+			name:   "low confidence with fallback",
+			answer: `{"label": "billing", "confidence": 0.2}`,
+			opts:   []ClassifierOption[intent]{ClassifierFallback[intent]("support", 0.5)},
+			want:   Classification[intent]{Label: "support", Confidence: 0.2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := textRunner(tt.answer)
+			got, err := NewClassifier(runner, intents, tt.opts...).Classify(context.Background(), userPrompt("I was charged twice"))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected %v, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+			var system []string
+			for _, msg := range runner.Prompts()[0].Messages {
+				if msg.Role == blades.RoleSystem {
+					system = append(system, msg.Text())
+				}
+			}
+			for _, want := range []string{"- billing: payments and invoices", "Input: where is my invoice?\nLabel: billing"} {
+				if !strings.Contains(strings.Join(system, "\n"), want) {
+					t.Fatalf("expected the label set in the system prompt, got %q", system)
+				}
+			}
+		})
+	}
+}
+
+func TestRouter(t *testing.T) {
+	classifier := NewClassifier(textRunner(`{"label": "billing", "confidence": 0.9}`), intents)
+	tests := []struct {
+		name     string
+		selector Selector
+		opts     []RouterOption
+		want     string
+		route    string
+		err      string
+	}{
+		{name: "classifier", selector: classifier, want: "invoices", route: "billing"},
+		{name: "missing route", selector: SelectorFunc(func(context.Context, *blades.Prompt, ...blades.ModelOption) (string, error) {
+			return "sales", nil
+		}), err: `flow: no route "sales"`},
+		{name: "default route", selector: SelectorFunc(func(context.Context, *blades.Prompt, ...blades.ModelOption) (string, error) {
+			return "sales", nil
+		}), opts: []RouterOption{RouterDefault(textRunner("default"))}, want: "default", route: "sales"},
+		{name: "failing selector", selector: SelectorFunc(func(context.Context, *blades.Prompt, ...blades.ModelOption) (string, error) {
+			return "", errors.New("boom")
+		}), err: "boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewRouter(tt.selector, map[string]blades.Runner{
+				"billing": textRunner("invoices"),
+				"support": textRunner("tickets"),
+			}, tt.opts...)
+			g, err := router.Run(context.Background(), userPrompt("I was charged twice"))
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				if _, err := router.RunStream(context.Background(), userPrompt("I was charged twice")); err == nil || err.Error() != tt.err {
+					t.Fatalf("expected the stream to fail with %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g.Text() != tt.want || g.Messages[0].Metadata[RouteKey] != tt.route {
+				t.Fatalf("expected %q from route %q, got %q from %q", tt.want, tt.route, g.Text(), g.Messages[0].Metadata[RouteKey])
+			}
+			stream, err := router.RunStream(context.Background(), userPrompt("I was charged twice"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			gens, err := collect(stream)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gens[0].Text() != tt.want || gens[0].Messages[0].Metadata[RouteKey] != tt.route {
+				t.Fatalf("expected the stream of route %q, got %+v", tt.route, gens[0].Messages[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-kratos/blades"
+)
+
+var (
+	_ blades.Runner = (*Debate)(nil)
+)
+
+// Debater is a named participant of a debate.
+type Debater struct {
+	Name   string
+	Runner blades.Runner
+}
+
+// DebateTurn is an argument made by a debater in a round.
+type DebateTurn struct {
+	Round    int    `json:"round"`
+	Debater  string `json:"debater"`
+	Argument string `json:"argument"`
+}
+
+// Verdict is the judge's final decision.
+type Verdict struct {
+	Winner    string `json:"winner" jsonschema:"the name of the debater with the strongest case, or empty for a draw"`
+	Decision  string `json:"decision" jsonschema:"the answer to the question"`
+	Reasoning string `json:"reasoning" jsonschema:"why the decision was reached, citing the arguments"`
+}
+
+// DebateResult is the outcome of a debate.
+type DebateResult struct {
+	Question   string       `json:"question"`
+	Transcript []DebateTurn `json:"transcript"`
+	Verdict    Verdict      `json:"verdict"`
+}
+
+// DebateOption configures a Debate.
+type DebateOption func(*Debate)
+
+// DebateRounds sets the number of rounds in which every debater argues once.
+func DebateRounds(n int) DebateOption {
+	return func(d *Debate) {
+		d.rounds = n
+	}
+}
+
+// Debate lets two or more debaters argue over a question in alternating rounds,
+// each seeing the transcript so far, after which a judge issues a verdict with
+// its reasoning.
+type Debate struct {
+	debaters []Debater
+	judge    blades.Runner
+	rounds   int
+}
+
+// NewDebate creates a Debate of 3 rounds between the debaters, decided by judge.
+func NewDebate(judge blades.Runner, debaters []Debater, opts ...DebateOption) *Debate {
+	d := &Debate{judge: judge, debaters: debaters, rounds: 3}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Debate runs the debate over the question in prompt and returns its full transcript and verdict.
+func (d *Debate) Debate(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*DebateResult, error) {
+	return d.run(ctx, prompt, func(*blades.Generation) {}, opts)
+}
+
+// Run runs the debate and returns the verdict as a generation, with the usage
+// of the arguments.
+func (d *Debate) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	var usage *blades.Usage
+	result, err := d.run(ctx, prompt, func(turn *blades.Generation) {
+		usage = addUsage(usage, turn.Usage)
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	g := verdictGeneration(result.Verdict)
+	g.Usage = usage
+	return g, nil
+}
+
+// RunStream streams each argument as it is made, then the verdict, followed by
+// a terminal generation summarizing the debate.
+func (d *Debate) RunStream(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (blades.Streamer[*blades.Generation], error) {
+	pipe := blades.NewStreamPipe[*blades.Generation]()
+	pipe.Go(func() error {
+		var (
+			started = blades.Now()
+			finish  = &blades.Finish{Reason: "stop"}
+		)
+		result, err := d.run(ctx, prompt, func(turn *blades.Generation) {
+			finish.Usage = addUsage(finish.Usage, turn.Usage)
+			pipe.Send(turn)
+		}, opts)
+		if err != nil {
+			return err
+		}
+		pipe.Send(verdictGeneration(result.Verdict))
+		finish.Elapsed = blades.Since(started)
+		pipe.Send(&blades.Generation{Finish: finish})
+		return nil
+	})
+	return pipe, nil
+}
+
+// run holds the rounds, passing each argument to onTurn, and asks the judge.
+func (d *Debate) run(ctx context.Context, prompt *blades.Prompt, onTurn func(*blades.Generation), opts []blades.ModelOption) (*DebateResult, error) {
+	if len(d.debaters) < 2 {
+		return nil, fmt.Errorf("flow: a debate needs at least 2 debaters, got %d", len(d.debaters))
+	}
+	result := &DebateResult{Question: promptText(prompt)}
+	for round := 1; round <= d.rounds; round++ {
+		for _, debater := range d.debaters {
+			turn, err := debater.Runner.Run(ctx, blades.NewPrompt(blades.UserMessage(d.turnPrompt(result, debater.Name, round))), opts...)
+			if err != nil {
+				return nil, fmt.Errorf("flow: debater %s in round %d: %w", debater.Name, round, err)
+			}
+			for _, msg := range turn.Messages {
+				if msg.Metadata == nil {
+					msg.Metadata = make(map[string]string)
+				}
+				msg.Metadata["debater"] = debater.Name
+			}
+			result.Transcript = append(result.Transcript, DebateTurn{Round: round, Debater: debater.Name, Argument: turn.Text()})
+			onTurn(turn)
+		}
+	}
+	verdict, err := blades.GenerateObject[Verdict](ctx, d.judge, blades.NewPrompt(blades.UserMessage(d.judgePrompt(result))), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("flow: judge: %w", err)
+	}
+	result.Verdict = verdict
+	return result, nil
+}
+
+func (d *Debate) turnPrompt(result *DebateResult, name string, round int) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "You are %s in a debate of %d rounds between %s.\n\nQuestion:\n%s\n", name, d.rounds, d.names(), result.Question)
+	writeTranscript(&buf, result.Transcript)
+	fmt.Fprintf(&buf, "\nGive your argument for round %d. Rebut the other debaters where you disagree.", round)
+	return buf.String()
+}
+
+func (d *Debate) judgePrompt(result *DebateResult) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "You are the judge of a debate between %s.\n\nQuestion:\n%s\n", d.names(), result.Question)
+	writeTranscript(&buf, result.Transcript)
+	buf.WriteString("\nWeigh the arguments and issue your final verdict with your reasoning.")
+	return buf.String()
+}
+
+func (d *Debate) names() string {
+	names := make([]string, len(d.debaters))
+	for i, debater := range d.debaters {
+		names[i] = debater.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+func writeTranscript(buf *strings.Builder, transcript []DebateTurn) {
+	if len(transcript) == 0 {
+		return
+	}
+	buf.WriteString("\nDebate so far:\n")
+	for _, turn := range transcript {
+		fmt.Fprintf(buf, "\n[Round %d] %s:\n%s\n", turn.Round, turn.Debater, turn.Argument)
+	}
+}
+
+func verdictGeneration(verdict Verdict) *blades.Generation {
+	var buf strings.Builder
+	if verdict.Winner != "" {
+		fmt.Fprintf(&buf, "Winner: %s\n\n", verdict.Winner)
+	}
+	fmt.Fprintf(&buf, "Decision: %s\n\nReasoning: %s", verdict.Decision, verdict.Reasoning)
+	msg := blades.AssistantMessage(buf.String())
+	msg.Status = blades.StatusCompleted
+	return &blades.Generation{Messages: []*blades.Message{msg}}
+}
+
+// promptText joins the text of the prompt messages, one message per line.
+func promptText(prompt *blades.Prompt) string {
+	texts := make([]string, 0, len(prompt.Messages))
+	for _, msg := range prompt.Messages {
+		texts = append(texts, msg.Text())
+	}
+	return strings.Join(texts, "\n")
+}
+
+// addUsage adds u to total, allocating total on the first usage.
+func addUsage(total, u *blades.Usage) *blades.Usage {
+	if u == nil {
+		return total
+	}
+	if total == nil {
+		total = &blades.Usage{}
+	}
+	total.Add(u)
+	return total
+}
@@ -0,0 +1,131 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/blades"
+)
+
+const verdictJSON = `{"winner": "pro", "decision": "yes", "reasoning": "stronger case"}`
+
+func TestDebate(t *testing.T) {
+	boom := errors.New("boom")
+	tests := []struct {
+		name     string
+		debaters []Debater
+		judge    *fakeRunner
+		err      string
+	}{
+		{
+			name:     "verdict",
+			debaters: []Debater{{Name: "pro", Runner: textRunner("pro 1", "pro 2")}, {Name: "con", Runner: textRunner("con 1", "con 2")}},
+			judge:    textRunner(verdictJSON),
+		},
+		{
+			name:     "single debater",
+			debaters: []Debater{{Name: "pro", Runner: textRunner("pro")}},
+			judge:    textRunner(verdictJSON),
+			err:      "flow: a debate needs at least 2 debaters, got 1",
+		},
+		{
+			name:     "failing debater",
+			debaters: []Debater{{Name: "pro", Runner: textRunner("pro")}, {Name: "con", Runner: &fakeRunner{err: boom}}},
+			judge:    textRunner(verdictJSON),
+			err:      "flow: debater con in round 1: boom",
+		},
+		{
+			name:     "failing judge",
+			debaters: []Debater{{Name: "pro", Runner: textRunner("pro")}, {Name: "con", Runner: textRunner("con")}},
+			judge:    &fakeRunner{err: boom},
+			err:      "flow: judge: boom",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewDebate(tt.judge, tt.debaters, DebateRounds(2)).Debate(context.Background(), userPrompt("Is Go fun?"))
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var turns []string
+			for _, turn := range result.Transcript {
+				turns = append(turns, turn.Debater+":"+turn.Argument)
+			}
+			if got := strings.Join(turns, ","); got != "pro:pro 1,con:con 1,pro:pro 2,con:con 2" {
+				t.Fatalf("expected alternating rounds, got %q", got)
+			}
+			if result.Verdict != (Verdict{Winner: "pro", Decision: "yes", Reasoning: "stronger case"}) {
+				t.Fatalf("unexpected verdict %+v", result.Verdict)
+			}
+			if result.Question != "Is Go fun?" {
+				t.Fatalf("expected the question text, got %q", result.Question)
+			}
+			prompts := tt.debaters[0].Runner.(*fakeRunner).Prompts()
+			if first := prompts[0].Messages[0].Text(); !strings.Contains(first, "Question:\nIs Go fun?\n") {
+				t.Fatalf("expected the debaters to see the question text, got %q", first)
+			}
+			if second := prompts[1].Messages[0].Text(); !strings.Contains(second, "[Round 1] con:\ncon 1") || !strings.Contains(second, "round 2") {
+				t.Fatalf("expected the second turn to see the transcript, got %q", second)
+			}
+			if judged := promptText(tt.judge.Prompts()[0]); !strings.Contains(judged, "Question:\nIs Go fun?\n") || !strings.Contains(judged, "[Round 2] con:\ncon 2") {
+				t.Fatalf("expected the judge to see the whole transcript, got %q", judged)
+			}
+		})
+	}
+}
+
+func TestDebate_Run(t *testing.T) {
+	usage := &blades.Usage{PromptTokens: 1, CompletionTokens: 1, TotalTokens: 2}
+	debate := NewDebate(textRunner(verdictJSON), []Debater{
+		{Name: "pro", Runner: &fakeRunner{replies: []string{"pro 1"}, usage: usage}},
+		{Name: "con", Runner: &fakeRunner{replies: []string{"con 1"}, usage: usage}},
+	}, DebateRounds(2))
+	g, err := debate.Run(context.Background(), userPrompt("Is Go fun?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(g.Text(), "Winner: pro") {
+		t.Fatalf("expected the verdict, got %q", g.Text())
+	}
+	if g.Usage == nil || *g.Usage != (blades.Usage{PromptTokens: 4, CompletionTokens: 4, TotalTokens: 8}) {
+		t.Fatalf("expected the usage of the 4 turns, got %+v", g.Usage)
+	}
+}
+
+func TestDebate_RunStream(t *testing.T) {
+	usage := &blades.Usage{TotalTokens: 2}
+	debate := NewDebate(textRunner(verdictJSON), []Debater{
+		{Name: "pro", Runner: &fakeRunner{replies: []string{"pro 1"}, usage: usage}},
+		{Name: "con", Runner: &fakeRunner{replies: []string{"con 1"}, usage: usage}},
+	}, DebateRounds(1))
+	stream, err := debate.RunStream(context.Background(), userPrompt("Is Go fun?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gens, err := collect(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gens) != 4 {
+		t.Fatalf("expected 2 turns, the verdict and the finish, got %d generations", len(gens))
+	}
+	for i, debater := range []string{"pro", "con"} {
+		if got := gens[i].Messages[0].Metadata["debater"]; got != debater {
+			t.Fatalf("expected turn %d by %s, got %q", i, debater, got)
+		}
+	}
+	if verdict := gens[2].Text(); !strings.HasPrefix(verdict, "Winner: pro") {
+		t.Fatalf("expected the verdict, got %q", verdict)
+	}
+	if finish := gens[3].Finish; finish == nil || finish.Usage == nil || finish.Usage.TotalTokens != 4 {
+		t.Fatalf("expected a finish with the usage of the turns, got %+v", finish)
+	}
+}
@@ -0,0 +1,221 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-kratos/blades"
+)
+
+var (
+	_ blades.Runner = (*Ensemble)(nil)
+	_ Judge         = (*LLMJudge)(nil)
+)
+
+// Candidate is the output of a branch of an Ensemble.
+type Candidate struct {
+	Name       string             `json:"name"`
+	Generation *blades.Generation `json:"generation,omitempty"`
+	// Score is the score given by the judge, if any.
+	Score float64 `json:"score,omitempty"`
+	// Err is the error of a failed branch.
+	Err error `json:"-"`
+}
+
+// Judgment is the decision of a judge over the candidates of an Ensemble.
+type Judgment struct {
+	// Winner is the index of the best candidate.
+	Winner    int    `json:"winner"`
+	Reasoning string `json:"reasoning,omitempty"`
+	// Scores are the scores of the candidates by index, if the judge scores them.
+	Scores []float64 `json:"scores,omitempty"`
+}
+
+// Judge picks the best of the candidates answering prompt. Failed candidates,
+// with an Err, must not win.
+type Judge interface {
+	Judge(ctx context.Context, prompt *blades.Prompt, candidates []Candidate) (Judgment, error)
+}
+
+// JudgeFunc adapts a function to a Judge.
+type JudgeFunc func(ctx context.Context, prompt *blades.Prompt, candidates []Candidate) (Judgment, error)
+
+// Judge calls f(ctx, prompt, candidates).
+func (f JudgeFunc) Judge(ctx context.Context, prompt *blades.Prompt, candidates []Candidate) (Judgment, error) {
+	return f(ctx, prompt, candidates)
+}
+
+// ScoreJudge returns a Judge scoring every successful candidate with score and
+// picking the highest, the first one on ties.
+func ScoreJudge(score func(ctx context.Context, prompt *blades.Prompt, g *blades.Generation) (float64, error)) Judge {
+	return JudgeFunc(func(ctx context.Context, prompt *blades.Prompt, candidates []Candidate) (Judgment, error) {
+		judgment := Judgment{Winner: -1, Scores: make([]float64, len(candidates))}
+		for i, c := range candidates {
+			if c.Err != nil {
+				continue
+			}
+			s, err := score(ctx, prompt, c.Generation)
+			if err != nil {
+				return Judgment{}, fmt.Errorf("flow: score candidate %s: %w", c.Name, err)
+			}
+			judgment.Scores[i] = s
+			if judgment.Winner < 0 || s > judgment.Scores[judgment.Winner] {
+				judgment.Winner = i
+			}
+		}
+		return judgment, nil
+	})
+}
+
+// LLMJudge asks a model which candidate answers the prompt best.
+type LLMJudge struct {
+	runner blades.Runner
+}
+
+// NewLLMJudge creates an LLMJudge asking runner.
+func NewLLMJudge(runner blades.Runner) *LLMJudge {
+	return &LLMJudge{runner: runner}
+}
+
+type pick struct {
+	Winner    int    `json:"winner" jsonschema:"the number of the best candidate"`
+	Reasoning string `json:"reasoning" jsonschema:"why the candidate is the best, compared to the others"`
+}
+
+// Judge returns the candidate chosen by the model.
+func (j *LLMJudge) Judge(ctx context.Context, prompt *blades.Prompt, candidates []Candidate) (Judgment, error) {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Several candidates answered the following request.\n\nRequest:\n%s\n", promptText(prompt))
+	for i, c := range candidates {
+		if c.Err == nil {
+			fmt.Fprintf(&buf, "\n[Candidate %d]\n%s\n", i+1, c.Generation.Text())
+		}
+	}
+	buf.WriteString("\nCompare the candidates for correctness, completeness and clarity, and choose the best one.")
+	p, err := blades.GenerateObject[pick](ctx, j.runner, blades.NewPrompt(blades.UserMessage(buf.String())))
+	if err != nil {
+		return Judgment{}, fmt.Errorf("flow: judge: %w", err)
+	}
+	return Judgment{Winner: p.Winner - 1, Reasoning: p.Reasoning}, nil
+}
+
+// EnsembleResult is the outcome of an Ensemble, with every candidate for inspection.
+type EnsembleResult struct {
+	Winner     *Candidate  `json:"winner"`
+	Candidates []Candidate `json:"candidates"`
+	Reasoning  string      `json:"reasoning,omitempty"`
+}
+
+// Samples returns n branches running the same runner, named "sample-1" to
+// "sample-n", to select the best of n samples of one agent.
+func Samples(runner blades.Runner, n int) []Branch {
+	branches := make([]Branch, n)
+	for i := range branches {
+		branches[i] = Branch{Name: fmt.Sprintf("sample-%d", i+1), Runner: runner}
+	}
+	return branches
+}
+
+// Ensemble runs the same prompt through several branches concurrently, such as
+// different agents or Samples of one agent, and returns the best output
+// according to a judge. Failed branches are left out, unless all of them fail.
+type Ensemble struct {
+	judge    Judge
+	branches []Branch
+}
+
+// NewEnsemble creates an Ensemble of the branches, decided by judge.
+func NewEnsemble(judge Judge, branches ...Branch) *Ensemble {
+	return &Ensemble{judge: judge, branches: branches}
+}
+
+// BestOf runs every branch and returns the winner along with all the candidates.
+func (e *Ensemble) BestOf(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*EnsembleResult, error) {
+	var (
+		wg         sync.WaitGroup
+		candidates = make([]Candidate, len(e.branches))
+	)
+	for i, branch := range e.branches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			candidates[i].Name = branch.Name
+			g, err := branch.Runner.Run(ctx, prompt, opts...)
+			if err != nil {
+				candidates[i].Err = err
+				return
+			}
+			tagBranch(g, branch.Name)
+			candidates[i].Generation = g
+		}()
+	}
+	wg.Wait()
+	var errs []error
+	for _, c := range candidates {
+		if c.Err != nil {
+			errs = append(errs, fmt.Errorf("flow: branch %s: %w", c.Name, c.Err))
+		}
+	}
+	if len(errs) == len(candidates) {
+		return nil, errors.Join(append([]error{errors.New("flow: every candidate failed")}, errs...)...)
+	}
+	judgment, err := e.judge.Judge(ctx, prompt, candidates)
+	if err != nil {
+		return nil, err
+	}
+	if judgment.Winner < 0 || judgment.Winner >= len(candidates) || candidates[judgment.Winner].Err != nil {
+		return nil, fmt.Errorf("flow: judge chose invalid candidate %d", judgment.Winner+1)
+	}
+	for i, s := range judgment.Scores {
+		if i < len(candidates) {
+			candidates[i].Score = s
+		}
+	}
+	return &EnsembleResult{Winner: &candidates[judgment.Winner], Candidates: candidates, Reasoning: judgment.Reasoning}, nil
+}
+
+// Run returns the generation of the winner, with the usage of every candidate.
+func (e *Ensemble) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	result, err := e.BestOf(ctx, prompt, opts...)
+	if err != nil {
+		return nil, err
+	}
+	winner := *result.Winner.Generation
+	winner.Usage = result.usage()
+	return &winner, nil
+}
+
+// RunStream selects the winner like Run and yields it as a single generation,
+// followed by a terminal generation.
+func (e *Ensemble) RunStream(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (blades.Streamer[*blades.Generation], error) {
+	pipe := blades.NewStreamPipe[*blades.Generation]()
+	pipe.Go(func() error {
+		started := blades.Now()
+		result, err := e.BestOf(ctx, prompt, opts...)
+		if err != nil {
+			return err
+		}
+		pipe.Send(result.Winner.Generation)
+		pipe.Send(&blades.Generation{Finish: &blades.Finish{Reason: "stop", Usage: result.usage(), Elapsed: blades.Since(started)}})
+		return nil
+	})
+	return pipe, nil
+}
+
+// usage returns the usage summed over the candidates.
+func (r *EnsembleResult) usage() *blades.Usage {
+	var usage *blades.Usage
+	for _, c := range r.Candidates {
+		if c.Generation == nil || c.Generation.Usage == nil {
+			continue
+		}
+		if usage == nil {
+			usage = &blades.Usage{}
+		}
+		usage.Add(c.Generation.Usage)
+	}
+	return usage
+}
@@ -0,0 +1,164 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/blades"
+)
+
+// lengthJudge scores the candidates by the length of their text.
+var lengthJudge = ScoreJudge(func(ctx context.Context, prompt *blades.Prompt, g *blades.Generation) (float64, error) {
+	return float64(len(g.Text())), nil
+})
+
+func TestEnsemble(t *testing.T) {
+	boom := errors.New("boom")
+	usage := &blades.Usage{TotalTokens: 2}
+	tests := []struct {
+		name     string
+		judge    Judge
+		branches []Branch
+		winner   string
+		want     string
+		err      string
+	}{
+		{
+			name:  "score judge",
+			judge: lengthJudge,
+			branches: []Branch{
+				{Name: "short", Runner: &fakeRunner{replies: []string{"ok"}, usage: usage}},
+				{Name: "long", Runner: &fakeRunner{replies: []string{"a longer answer"}, usage: usage}},
+				{Name: "failed", Runner: &fakeRunner{err: boom}},
+			},
+			winner: "long",
+			want:   "a longer answer",
+		},
+		{
+			name:  "score judge ties",
+			judge: lengthJudge,
+			branches: []Branch{
+				{Name: "first", Runner: textRunner("same")},
+				{Name: "second", Runner: textRunner("same")},
+			},
+			winner: "first",
+			want:   "same",
+		},
+		{
+			name:  "llm judge",
+			judge: NewLLMJudge(textRunner(`{"winner": 2, "reasoning": "clearer"}`)),
+			branches: []Branch{
+				{Name: "a", Runner: textRunner("answer a")},
+				{Name: "b", Runner: textRunner("answer b")},
+			},
+			winner: "b",
+			want:   "answer b",
+		},
+		{
+			name: "every candidate failed",
+			branches: []Branch{
+				{Name: "a", Runner: &fakeRunner{err: boom}},
+				{Name: "b", Runner: &fakeRunner{err: boom}},
+			},
+			err: "flow: every candidate failed\nflow: branch a: boom\nflow: branch b: boom",
+		},
+		{
+			name: "judge choosing a failed candidate",
+			judge: JudgeFunc(func(ctx context.Context, prompt *blades.Prompt, candidates []Candidate) (Judgment, error) {
+				return Judgment{Winner: 1}, nil
+			}),
+			branches: []Branch{
+				{Name: "a", Runner: textRunner("answer a")},
+				{Name: "b", Runner: &fakeRunner{err: boom}},
+			},
+			err: "flow: judge chose invalid candidate 2",
+		},
+		{
+			name: "failing judge",
+			judge: JudgeFunc(func(ctx context.Context, prompt *blades.Prompt, candidates []Candidate) (Judgment, error) {
+				return Judgment{}, boom
+			}),
+			branches: []Branch{{Name: "a", Runner: textRunner("answer a")}},
+			err:      "boom",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ensemble := NewEnsemble(tt.judge, tt.branches...)
+			result, err := ensemble.BestOf(context.Background(), userPrompt("hi"))
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Winner.Name != tt.winner || BranchOf(result.Winner.Generation) != tt.winner {
+				t.Fatalf("expected %s to win, got %s", tt.winner, result.Winner.Name)
+			}
+			if len(result.Candidates) != len(tt.branches) {
+				t.Fatalf("expected every candidate in the result, got %d", len(result.Candidates))
+			}
+			g, err := ensemble.Run(context.Background(), userPrompt("hi"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g.Text() != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, g.Text())
+			}
+		})
+	}
+}
+
+func TestEnsemble_Usage(t *testing.T) {
+	usage := &blades.Usage{TotalTokens: 2}
+	ensemble := NewEnsemble(lengthJudge, Samples(&fakeRunner{replies: []string{"x", "xxx", "xx"}, usage: usage}, 3)...)
+	result, err := ensemble.BestOf(context.Background(), userPrompt("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, c := range result.Candidates {
+		names = append(names, c.Name)
+	}
+	if got := strings.Join(names, ","); got != "sample-1,sample-2,sample-3" {
+		t.Fatalf("expected the samples to be named in order, got %q", got)
+	}
+	if result.Winner.Score != 3 {
+		t.Fatalf("expected the winner to carry its score, got %v", result.Winner.Score)
+	}
+	stream, err := ensemble.RunStream(context.Background(), userPrompt("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gens, err := collect(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gens) != 2 || gens[1].Finish == nil || gens[1].Finish.Usage == nil || gens[1].Finish.Usage.TotalTokens != 6 {
+		t.Fatalf("expected the winner and a finish with the usage of every sample, got %d generations", len(gens))
+	}
+}
+
+func TestLLMJudge_Prompt(t *testing.T) {
+	runner := textRunner(`{"winner": 1, "reasoning": "only one"}`)
+	candidates := []Candidate{
+		{Name: "a", Generation: &blades.Generation{Messages: []*blades.Message{assistantText("answer a")}}},
+		{Name: "b", Err: errors.New("boom")},
+	}
+	judgment, err := NewLLMJudge(runner).Judge(context.Background(), userPrompt("Is Go fun?"), candidates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if judgment.Winner != 0 || judgment.Reasoning != "only one" {
+		t.Fatalf("unexpected judgment %+v", judgment)
+	}
+	judged := promptText(runner.Prompts()[0])
+	if !strings.Contains(judged, "Request:\nIs Go fun?\n") || !strings.Contains(judged, "[Candidate 1]\nanswer a\n") || strings.Contains(judged, "[Candidate 2]") {
+		t.Fatalf("expected the request text and the successful candidates, got %q", judged)
+	}
+}
@@ -0,0 +1,182 @@
+package flow
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/go-kratos/blades"
+)
+
+// fakeRunner is the scripted Runner of the tests, answering with the texts of
+// replies in turn, the last one repeating. It is safe for concurrent use.
+type fakeRunner struct {
+	replies []string
+	// reply builds the answers instead of replies when set.
+	reply func(prompt *blades.Prompt) (string, error)
+	// usage is reported on every generation when set.
+	usage *blades.Usage
+	// err fails every run, and every stream after its chunks.
+	err error
+	// chunks are streamed as incomplete assistant messages before the answer.
+	chunks []string
+
+	mu      sync.Mutex
+	prompts []*blades.Prompt
+	options []blades.ModelOptions
+}
+
+// textRunner returns a fakeRunner answering with the texts in turn.
+func textRunner(replies ...string) *fakeRunner {
+	return &fakeRunner{replies: replies}
+}
+
+// Prompts returns the prompts received so far.
+func (r *fakeRunner) Prompts() []*blades.Prompt {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*blades.Prompt(nil), r.prompts...)
+}
+
+// lastOptions returns the resolved options of the last run.
+func (r *fakeRunner) lastOptions() blades.ModelOptions {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.options) == 0 {
+		return blades.ModelOptions{}
+	}
+	return r.options[len(r.options)-1]
+}
+
+func (r *fakeRunner) answer(prompt *blades.Prompt, opts []blades.ModelOption) (string, error) {
+	var opt blades.ModelOptions
+	for _, apply := range opts {
+		apply(&opt)
+	}
+	r.mu.Lock()
+	r.prompts = append(r.prompts, prompt)
+	r.options = append(r.options, opt)
+	call := len(r.prompts)
+	r.mu.Unlock()
+	if r.reply != nil {
+		return r.reply(prompt)
+	}
+	if len(r.replies) == 0 {
+		return "", nil
+	}
+	return r.replies[min(call, len(r.replies))-1], nil
+}
+
+func (r *fakeRunner) generation(text string) *blades.Generation {
+	g := &blades.Generation{Messages: []*blades.Message{assistantText(text)}}
+	if r.usage != nil {
+		usage := *r.usage
+		g.Usage = &usage
+	}
+	return g
+}
+
+func (r *fakeRunner) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	text, err := r.answer(prompt, opts)
+	if err != nil {
+		return nil, err
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.generation(text), nil
+}
+
+// RunStream streams the chunks, then the answer and a terminal generation
+// carrying the Finish summary, as agents do.
+func (r *fakeRunner) RunStream(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (blades.Streamer[*blades.Generation], error) {
+	text, err := r.answer(prompt, opts)
+	if err != nil {
+		return nil, err
+	}
+	pipe := blades.NewStreamPipe[*blades.Generation]()
+	pipe.Go(func() error {
+		for _, chunk := range r.chunks {
+			pipe.Send(&blades.Generation{Messages: []*blades.Message{{Role: blades.RoleAssistant, Status: blades.StatusIncomplete, Parts: blades.Parts(chunk)}}})
+		}
+		if r.err != nil {
+			return r.err
+		}
+		g := r.generation(text)
+		pipe.Send(g)
+		pipe.Send(&blades.Generation{Finish: &blades.Finish{Reason: "stop", Usage: g.Usage}})
+		return nil
+	})
+	return pipe, nil
+}
+
+// assistantText returns a completed assistant message of the text.
+func assistantText(text string) *blades.Message {
+	return &blades.Message{Role: blades.RoleAssistant, Status: blades.StatusCompleted, Parts: blades.Parts(text)}
+}
+
+// collect reads the stream to its end and closes it.
+func collect(stream blades.Streamer[*blades.Generation]) ([]*blades.Generation, error) {
+	var (
+		gens []*blades.Generation
+		err  error
+	)
+	for stream.Next() {
+		var g *blades.Generation
+		if g, err = stream.Current(); err != nil {
+			break
+		}
+		gens = append(gens, g)
+	}
+	if cerr := stream.Close(); err == nil {
+		err = cerr
+	}
+	return gens, err
+}
+
+// userPrompt returns a prompt of a single user message.
+func userPrompt(text string) *blades.Prompt {
+	return blades.NewPrompt(blades.UserMessage(text))
+}
+
+// fakeProvider is a ModelProvider answering every request with reply,
+// recording the models and options of the calls.
+type fakeProvider struct {
+	reply string
+
+	mu      sync.Mutex
+	models  []string
+	options []blades.ModelOptions
+}
+
+func (p *fakeProvider) Generate(ctx context.Context, req *blades.ModelRequest, opts ...blades.ModelOption) (*blades.ModelResponse, error) {
+	var opt blades.ModelOptions
+	for _, apply := range opts {
+		apply(&opt)
+	}
+	p.mu.Lock()
+	p.models = append(p.models, req.Model)
+	p.options = append(p.options, opt)
+	p.mu.Unlock()
+	return &blades.ModelResponse{Messages: []*blades.Message{assistantText(p.reply)}}, nil
+}
+
+func (p *fakeProvider) NewStream(ctx context.Context, req *blades.ModelRequest, opts ...blades.ModelOption) (blades.Streamer[*blades.ModelResponse], error) {
+	res, err := p.Generate(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	pipe := blades.NewStreamPipe[*blades.ModelResponse]()
+	pipe.Go(func() error {
+		pipe.Send(res)
+		return nil
+	})
+	return pipe, nil
+}
+
+// calls returns the models and options of the calls made so far.
+func (p *fakeProvider) calls() ([]string, []blades.ModelOptions) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.models), slices.Clone(p.options)
+}
@@ -0,0 +1,290 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-kratos/blades"
+)
+
+var (
+	_ blades.Runner = (*GroupChat)(nil)
+	_ Moderator     = (*LLMModerator)(nil)
+)
+
+// SpeakerKey is the message metadata key holding the name of the participant
+// who wrote a message in a group chat.
+const SpeakerKey = "speaker"
+
+// Participant is a named member of a group chat: an agent replying with its
+// Runner, or a human answering through Human.
+type Participant struct {
+	Name string
+	// Description tells the moderator and the other participants what the participant is for.
+	Description string
+	Runner      blades.Runner
+	Human       Asker
+}
+
+// Moderator decides who speaks next in a group chat. It returns the name of a
+// participant, or an empty name to end the chat.
+type Moderator interface {
+	Next(ctx context.Context, participants []Participant, transcript []*blades.Message) (string, error)
+}
+
+// ModeratorFunc adapts a function to a Moderator.
+type ModeratorFunc func(ctx context.Context, participants []Participant, transcript []*blades.Message) (string, error)
+
+// Next calls f(ctx, participants, transcript).
+func (f ModeratorFunc) Next(ctx context.Context, participants []Participant, transcript []*blades.Message) (string, error) {
+	return f(ctx, participants, transcript)
+}
+
+// RoundRobin lets the participants speak in turn, starting after the last speaker.
+func RoundRobin() Moderator {
+	return ModeratorFunc(func(ctx context.Context, participants []Participant, transcript []*blades.Message) (string, error) {
+		if len(participants) == 0 {
+			return "", nil
+		}
+		last := -1
+		if len(transcript) > 0 {
+			speaker := transcript[len(transcript)-1].Metadata[SpeakerKey]
+			last = slices.IndexFunc(participants, func(p Participant) bool { return p.Name == speaker })
+		}
+		return participants[(last+1)%len(participants)].Name, nil
+	})
+}
+
+// LLMModerator asks a model who should speak next given the transcript, or
+// whether the conversation is over.
+type LLMModerator struct {
+	runner blades.Runner
+}
+
+// NewLLMModerator creates an LLMModerator asking runner.
+func NewLLMModerator(runner blades.Runner) *LLMModerator {
+	return &LLMModerator{runner: runner}
+}
+
+type moderation struct {
+	Next string `json:"next" jsonschema:"the name of the participant who should speak next, or empty when the conversation is over"`
+}
+
+// Next returns the speaker chosen by the model.
+func (m *LLMModerator) Next(ctx context.Context, participants []Participant, transcript []*blades.Message) (string, error) {
+	var buf strings.Builder
+	buf.WriteString("You moderate a group chat between the following participants:\n")
+	writeParticipants(&buf, participants)
+	writeGroupTranscript(&buf, transcript)
+	buf.WriteString("\nChoose who should speak next to move the conversation forward, or nobody when the request is settled or the human has to answer.")
+	decision, err := blades.GenerateObject[moderation](ctx, m.runner, blades.NewPrompt(blades.UserMessage(buf.String())))
+	if err != nil {
+		return "", fmt.Errorf("flow: moderate: %w", err)
+	}
+	return strings.TrimSpace(decision.Next), nil
+}
+
+// GroupOption configures a GroupChat.
+type GroupOption func(*GroupChat)
+
+// GroupMaxTurns sets the maximum number of turns of a chat, 10 by default.
+func GroupMaxTurns(n int) GroupOption {
+	return func(g *GroupChat) {
+		g.maxTurns = n
+	}
+}
+
+// GroupMemory persists the transcript of conversations in memory, keyed by the
+// conversation ID of the prompt, so that chats can be resumed.
+func GroupMemory(memory blades.Memory) GroupOption {
+	return func(g *GroupChat) {
+		g.memory = memory
+	}
+}
+
+// GroupChat is a conversation shared by several named agents and humans, in
+// which a moderator picks each speaker in turn. Every message of the
+// transcript is attributed to its speaker in the SpeakerKey metadata.
+type GroupChat struct {
+	moderator    Moderator
+	participants []Participant
+	maxTurns     int
+	memory       blades.Memory
+}
+
+// NewGroupChat creates a GroupChat between participants, moderated by moderator.
+func NewGroupChat(moderator Moderator, participants []Participant, opts ...GroupOption) *GroupChat {
+	g := &GroupChat{moderator: moderator, participants: participants, maxTurns: 10}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Chat continues the conversation of prompt until the moderator ends it or the
+// maximum number of turns is reached, and returns the full transcript,
+// including the messages persisted earlier when a memory is set. The prompt
+// messages are attributed to the first human participant.
+func (g *GroupChat) Chat(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) ([]*blades.Message, error) {
+	return g.run(ctx, prompt, func(*blades.Message) {}, opts)
+}
+
+// Run runs the chat and returns the messages written by the participants.
+func (g *GroupChat) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	res := &blades.Generation{}
+	_, err := g.run(ctx, prompt, func(msg *blades.Message) {
+		res.Messages = append(res.Messages, msg)
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// RunStream streams each message as it is written, followed by a terminal generation.
+func (g *GroupChat) RunStream(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (blades.Streamer[*blades.Generation], error) {
+	pipe := blades.NewStreamPipe[*blades.Generation]()
+	pipe.Go(func() error {
+		started := blades.Now()
+		_, err := g.run(ctx, prompt, func(msg *blades.Message) {
+			pipe.Send(&blades.Generation{Messages: []*blades.Message{msg}})
+		}, opts)
+		if err != nil {
+			return err
+		}
+		pipe.Send(&blades.Generation{Finish: &blades.Finish{Reason: "stop", Elapsed: blades.Since(started)}})
+		return nil
+	})
+	return pipe, nil
+}
+
+// run loads the transcript, attributes the prompt and holds the turns, passing
+// each message written to onTurn. It returns the full transcript.
+func (g *GroupChat) run(ctx context.Context, prompt *blades.Prompt, onTurn func(*blades.Message), opts []blades.ModelOption) ([]*blades.Message, error) {
+	var transcript []*blades.Message
+	if g.memory != nil && prompt.ConversationID != "" {
+		history, err := g.memory.ListMessages(ctx, prompt.ConversationID)
+		if err != nil {
+			return nil, fmt.Errorf("flow: load transcript: %w", err)
+		}
+		transcript = history
+	}
+	for _, msg := range prompt.Messages {
+		attribute(msg, g.humanName())
+	}
+	if err := g.append(ctx, prompt.ConversationID, &transcript, prompt.Messages...); err != nil {
+		return transcript, err
+	}
+	for turn := 0; turn < g.maxTurns; turn++ {
+		name, err := g.moderator.Next(ctx, g.participants, transcript)
+		if err != nil {
+			return transcript, err
+		}
+		if name == "" {
+			break
+		}
+		i := slices.IndexFunc(g.participants, func(p Participant) bool { return strings.EqualFold(p.Name, name) })
+		if i < 0 {
+			return transcript, fmt.Errorf("flow: moderator chose unknown participant %q", name)
+		}
+		msg, err := g.speak(ctx, g.participants[i], transcript, opts)
+		if err != nil {
+			return transcript, err
+		}
+		if err := g.append(ctx, prompt.ConversationID, &transcript, msg); err != nil {
+			return transcript, err
+		}
+		onTurn(msg)
+	}
+	return transcript, nil
+}
+
+// speak has participant write its next message.
+func (g *GroupChat) speak(ctx context.Context, participant Participant, transcript []*blades.Message, opts []blades.ModelOption) (*blades.Message, error) {
+	var msg *blades.Message
+	switch {
+	case participant.Human != nil:
+		var question string
+		if len(transcript) > 0 {
+			last := transcript[len(transcript)-1]
+			question = fmt.Sprintf("%s: %s", last.Metadata[SpeakerKey], last.Text())
+		}
+		answer, err := participant.Human.Ask(ctx, question)
+		if err != nil {
+			return nil, err
+		}
+		msg = blades.UserMessage(answer)
+	case participant.Runner != nil:
+		var buf strings.Builder
+		fmt.Fprintf(&buf, "You are %s in a group chat between the following participants:\n", participant.Name)
+		writeParticipants(&buf, g.participants)
+		writeGroupTranscript(&buf, transcript)
+		fmt.Fprintf(&buf, "\nWrite your next message as %s. Address the others by name when needed.", participant.Name)
+		res, err := participant.Runner.Run(ctx, blades.NewPrompt(blades.UserMessage(buf.String())), opts...)
+		if err != nil {
+			return nil, fmt.Errorf("flow: participant %s: %w", participant.Name, err)
+		}
+		msg = blades.AssistantMessage(res.Text())
+		msg.Status = blades.StatusCompleted
+	default:
+		return nil, errors.New("flow: participant " + participant.Name + " has neither a runner nor a human")
+	}
+	attribute(msg, participant.Name)
+	return msg, nil
+}
+
+// append adds msgs to the transcript and persists them.
+func (g *GroupChat) append(ctx context.Context, id string, transcript *[]*blades.Message, msgs ...*blades.Message) error {
+	if g.memory != nil && id != "" {
+		if err := g.memory.AddMessages(ctx, id, msgs); err != nil {
+			return fmt.Errorf("flow: persist transcript: %w", err)
+		}
+	}
+	*transcript = append(*transcript, msgs...)
+	return nil
+}
+
+// humanName returns the name of the first human participant, or "user".
+func (g *GroupChat) humanName() string {
+	for _, p := range g.participants {
+		if p.Human != nil {
+			return p.Name
+		}
+	}
+	return "user"
+}
+
+func attribute(msg *blades.Message, name string) {
+	if msg.Metadata == nil {
+		msg.Metadata = make(map[string]string)
+	}
+	if msg.Metadata[SpeakerKey] == "" {
+		msg.Metadata[SpeakerKey] = name
+	}
+}
+
+func writeParticipants(buf *strings.Builder, participants []Participant) {
+	for _, p := range participants {
+		fmt.Fprintf(buf, "- %s", p.Name)
+		if p.Human != nil {
+			buf.WriteString(" (human)")
+		}
+		if p.Description != "" {
+			fmt.Fprintf(buf, ": %s", p.Description)
+		}
+		buf.WriteString("\n")
+	}
+}
+
+func writeGroupTranscript(buf *strings.Builder, transcript []*blades.Message) {
+	if len(transcript) == 0 {
+		return
+	}
+	buf.WriteString("\nConversation so far:\n")
+	for _, msg := range transcript {
+		fmt.Fprintf(buf, "\n%s: %s\n", msg.Metadata[SpeakerKey], msg.Text())
+	}
+}
@@ -0,0 +1,139 @@
+package flow
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/memory"
+)
+
+// speakers returns the "speaker: text" lines of the messages.
+func speakers(msgs []*blades.Message) string {
+	lines := make([]string, len(msgs))
+	for i, msg := range msgs {
+		lines[i] = msg.Metadata[SpeakerKey] + ": " + msg.Text()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// script returns a Moderator choosing the speakers in turn, then nobody.
+func script(names ...string) Moderator {
+	var turn int
+	return ModeratorFunc(func(ctx context.Context, participants []Participant, transcript []*blades.Message) (string, error) {
+		if turn == len(names) {
+			return "", nil
+		}
+		turn++
+		return names[turn-1], nil
+	})
+}
+
+func TestGroupChat(t *testing.T) {
+	tests := []struct {
+		name      string
+		moderator Moderator
+		opts      []GroupOption
+		want      string
+		err       string
+	}{
+		{
+			name:      "round robin",
+			moderator: RoundRobin(),
+			opts:      []GroupOption{GroupMaxTurns(3)},
+			want:      "ann: plan trip\nalice: from alice\nbob: from bob\nann: ok",
+		},
+		{
+			name:      "scripted",
+			moderator: script("bob", "ANN"),
+			want:      "ann: plan trip\nbob: from bob\nann: ok",
+		},
+		{
+			name:      "llm moderator",
+			moderator: NewLLMModerator(textRunner(`{"next": "alice"}`, `{"next": ""}`)),
+			want:      "ann: plan trip\nalice: from alice",
+		},
+		{
+			name:      "unknown participant",
+			moderator: script("carol"),
+			err:       `flow: moderator chose unknown participant "carol"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var questions []string
+			human := AskerFunc(func(ctx context.Context, question string) (string, error) {
+				questions = append(questions, question)
+				return "ok", nil
+			})
+			chat := NewGroupChat(tt.moderator, []Participant{
+				{Name: "ann", Human: human},
+				{Name: "alice", Runner: textRunner("from alice")},
+				{Name: "bob", Runner: textRunner("from bob")},
+			}, tt.opts...)
+			transcript, err := chat.Chat(context.Background(), userPrompt("plan trip"))
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := speakers(transcript); got != tt.want {
+				t.Fatalf("expected the transcript\n%s\ngot\n%s", tt.want, got)
+			}
+			for _, question := range questions {
+				if question != "bob: from bob" {
+					t.Fatalf("expected the human to be asked after the last message, got %q", question)
+				}
+			}
+		})
+	}
+}
+
+func TestGroupChat_Memory(t *testing.T) {
+	mem := memory.NewInMemory(0)
+	alice := textRunner("from alice")
+	chat := NewGroupChat(RoundRobin(), []Participant{{Name: "alice", Runner: alice}}, GroupMaxTurns(1), GroupMemory(mem))
+	if _, err := chat.Run(context.Background(), blades.NewConversation("trip", blades.UserMessage("first"))); err != nil {
+		t.Fatal(err)
+	}
+	transcript, err := chat.Chat(context.Background(), blades.NewConversation("trip", blades.UserMessage("second")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := speakers(transcript), "user: first\nalice: from alice\nuser: second\nalice: from alice"; got != want {
+		t.Fatalf("expected the resumed transcript\n%s\ngot\n%s", want, got)
+	}
+	if prompt := alice.Prompts()[1].Messages[0].Text(); !strings.Contains(prompt, "user: first") {
+		t.Fatalf("expected the persisted messages to be shown to the participants, got %q", prompt)
+	}
+	stored, _ := mem.ListMessages(context.Background(), "trip")
+	if len(stored) != 4 {
+		t.Fatalf("expected the transcript to be persisted, got %d messages", len(stored))
+	}
+}
+
+func TestGroupChat_RunStream(t *testing.T) {
+	chat := NewGroupChat(script("alice", "bob"), []Participant{
+		{Name: "alice", Runner: textRunner("from alice")},
+		{Name: "bob", Runner: textRunner("from bob")},
+	})
+	stream, err := chat.RunStream(context.Background(), userPrompt("plan trip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gens, err := collect(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gens) != 3 || gens[2].Finish == nil {
+		t.Fatalf("expected 2 messages and a finish, got %d generations", len(gens))
+	}
+	if got := speakers(append(gens[0].Messages, gens[1].Messages...)); got != "alice: from alice\nbob: from bob" {
+		t.Fatalf("expected the messages of the participants, got\n%s", got)
+	}
+}
@@ -0,0 +1,219 @@
+package flow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-kratos/blades"
+)
+
+var (
+	_ blades.Runner = (*Interview[struct{}])(nil)
+)
+
+var (
+	// ErrInterviewIncomplete indicates the slots were still incomplete after the maximum number of questions.
+	ErrInterviewIncomplete = errors.New("flow: interview incomplete")
+)
+
+// Asker asks the user a question and returns the answer.
+type Asker interface {
+	Ask(ctx context.Context, question string) (string, error)
+}
+
+// AskerFunc adapts a function to an Asker.
+type AskerFunc func(ctx context.Context, question string) (string, error)
+
+// Ask calls f(ctx, question).
+func (f AskerFunc) Ask(ctx context.Context, question string) (string, error) {
+	return f(ctx, question)
+}
+
+// SlotIssue describes a slot that is missing or invalid.
+type SlotIssue struct {
+	Slot    string
+	Problem string
+}
+
+// InterviewOption configures an Interview.
+type InterviewOption func(*interviewOptions)
+
+type interviewOptions struct {
+	maxQuestions int
+	optional     map[string]bool
+	validators   map[string]func(any) error
+}
+
+// InterviewMaxQuestions sets the maximum number of questions asked.
+func InterviewMaxQuestions(n int) InterviewOption {
+	return func(o *interviewOptions) {
+		o.maxQuestions = n
+	}
+}
+
+// OptionalSlots marks slots, by JSON name, that may be left empty.
+func OptionalSlots(names ...string) InterviewOption {
+	return func(o *interviewOptions) {
+		for _, name := range names {
+			o.optional[name] = true
+		}
+	}
+}
+
+// ValidateSlot sets a validator for the slot with the given JSON name. It
+// receives the value of the field and returns why it is invalid, if it is.
+func ValidateSlot(name string, fn func(value any) error) InterviewOption {
+	return func(o *interviewOptions) {
+		o.validators[name] = fn
+	}
+}
+
+// Interview elicits the slots of the struct T from the user: the extractor
+// fills the slots from the conversation and, while any required slot is empty
+// or fails its validator, phrases a clarifying question that is put to the user
+// through the asker. The completed slots are then handed as JSON to next.
+type Interview[T any] struct {
+	extractor blades.Runner
+	asker     Asker
+	next      blades.Runner
+	opts      interviewOptions
+}
+
+// NewInterview creates an Interview asking at most 10 questions. Every exported
+// field of T is a required slot unless marked optional.
+func NewInterview[T any](extractor blades.Runner, asker Asker, next blades.Runner, opts ...InterviewOption) *Interview[T] {
+	o := interviewOptions{
+		maxQuestions: 10,
+		optional:     make(map[string]bool),
+		validators:   make(map[string]func(any) error),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Interview[T]{extractor: extractor, asker: asker, next: next, opts: o}
+}
+
+// Elicit interviews the user, starting from the request in prompt, until the
+// slots are complete and valid.
+func (iv *Interview[T]) Elicit(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (T, error) {
+	var (
+		slots      T
+		transcript strings.Builder
+	)
+	fmt.Fprintf(&transcript, "User: %s\n", promptText(prompt))
+	for questions := 0; ; questions++ {
+		var err error
+		slots, err = blades.GenerateObject[T](ctx, iv.extractor, blades.NewPrompt(blades.UserMessage(
+			"Extract the details the user has given in this conversation. Leave details the user has not given empty.\n\n"+transcript.String(),
+		)), opts...)
+		if err != nil {
+			return slots, fmt.Errorf("flow: extract slots: %w", err)
+		}
+		issues := iv.Issues(slots)
+		if len(issues) == 0 {
+			return slots, nil
+		}
+		if questions == iv.opts.maxQuestions {
+			return slots, fmt.Errorf("%w: %s", ErrInterviewIncomplete, formatIssues(issues))
+		}
+		question, err := iv.extractor.Run(ctx, blades.NewPrompt(blades.UserMessage(fmt.Sprintf(
+			"%s\nThe following details are missing or invalid:\n%s\nAsk the user one short, friendly question to obtain them. Reply with the question only.",
+			transcript.String(), formatIssues(issues),
+		))), opts...)
+		if err != nil {
+			return slots, fmt.Errorf("flow: phrase question: %w", err)
+		}
+		answer, err := iv.asker.Ask(ctx, question.Text())
+		if err != nil {
+			return slots, err
+		}
+		fmt.Fprintf(&transcript, "Assistant: %s\nUser: %s\n", question.Text(), answer)
+	}
+}
+
+// Issues returns the required slots of v that are empty or invalid.
+func (iv *Interview[T]) Issues(v T) []SlotIssue {
+	var issues []SlotIssue
+	rv := reflect.Indirect(reflect.ValueOf(&v).Elem())
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		name, ok := slotName(field)
+		if !ok {
+			continue
+		}
+		value := rv.Field(i)
+		if value.IsZero() {
+			if !iv.opts.optional[name] {
+				issues = append(issues, SlotIssue{Slot: name, Problem: "missing"})
+			}
+			continue
+		}
+		if validate, ok := iv.opts.validators[name]; ok {
+			if err := validate(value.Interface()); err != nil {
+				issues = append(issues, SlotIssue{Slot: name, Problem: err.Error()})
+			}
+		}
+	}
+	return issues
+}
+
+// Run elicits the slots and runs next with them.
+func (iv *Interview[T]) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	p, err := iv.handoff(ctx, prompt, opts)
+	if err != nil {
+		return nil, err
+	}
+	return iv.next.Run(ctx, p, opts...)
+}
+
+// RunStream elicits the slots and streams next with them.
+func (iv *Interview[T]) RunStream(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (blades.Streamer[*blades.Generation], error) {
+	p, err := iv.handoff(ctx, prompt, opts)
+	if err != nil {
+		return nil, err
+	}
+	return iv.next.RunStream(ctx, p, opts...)
+}
+
+// handoff builds the prompt for next from the completed slots.
+func (iv *Interview[T]) handoff(ctx context.Context, prompt *blades.Prompt, opts []blades.ModelOption) (*blades.Prompt, error) {
+	slots, err := iv.Elicit(ctx, prompt, opts...)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(slots)
+	if err != nil {
+		return nil, err
+	}
+	return blades.NewConversation(prompt.ConversationID, blades.UserMessage(string(data))), nil
+}
+
+// slotName returns the JSON name of an exported field.
+func slotName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return "", false
+	case "":
+		return field.Name, true
+	}
+	return name, true
+}
+
+func formatIssues(issues []SlotIssue) string {
+	var buf strings.Builder
+	for _, issue := range issues {
+		fmt.Fprintf(&buf, "- %s: %s\n", issue.Slot, issue.Problem)
+	}
+	return buf.String()
+}
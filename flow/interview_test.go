@@ -0,0 +1,150 @@
+package flow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/blades"
+)
+
+type trip struct {
+	City  string `json:"city"`
+	Date  string `json:"date"`
+	Notes string `json:"notes"`
+}
+
+// tripExtractor extracts the city and, once the user gave it, the date of a
+// trip, and asks for the date. It fails unless the transcript holds the
+// plain text of the request.
+func tripExtractor(city string) *fakeRunner {
+	return &fakeRunner{reply: func(prompt *blades.Prompt) (string, error) {
+		text := promptText(prompt)
+		if !strings.Contains(text, "User: Book me a trip\n") {
+			return "", fmt.Errorf("unexpected transcript %q", text)
+		}
+		switch {
+		case strings.Contains(text, "Ask the user"):
+			return "When do you leave?", nil
+		case strings.Contains(text, "tomorrow"):
+			return `{"city": "` + city + `", "date": "tomorrow", "notes": ""}`, nil
+		}
+		return `{"city": "` + city + `", "date": "", "notes": ""}`, nil
+	}}
+}
+
+func TestInterview(t *testing.T) {
+	boom := errors.New("boom")
+	tests := []struct {
+		name      string
+		city      string
+		answers   []string
+		askErr    error
+		opts      []InterviewOption
+		questions int
+		want      trip
+		err       error
+	}{
+		{
+			name:      "one question",
+			city:      "Paris",
+			answers:   []string{"tomorrow"},
+			questions: 1,
+			want:      trip{City: "Paris", Date: "tomorrow"},
+		},
+		{
+			name:      "optional slot left empty",
+			city:      "Paris",
+			answers:   []string{"tomorrow"},
+			opts:      []InterviewOption{OptionalSlots("date")},
+			questions: 0,
+			want:      trip{City: "Paris"},
+		},
+		{
+			name:      "incomplete",
+			city:      "Paris",
+			answers:   []string{"soon", "later"},
+			opts:      []InterviewOption{InterviewMaxQuestions(2)},
+			questions: 2,
+			err:       ErrInterviewIncomplete,
+		},
+		{
+			name:      "invalid slot",
+			city:      "Atlantis",
+			answers:   []string{"tomorrow"},
+			opts:      []InterviewOption{InterviewMaxQuestions(1), ValidateSlot("city", func(v any) error { return errors.New("no such city") })},
+			questions: 1,
+			err:       ErrInterviewIncomplete,
+		},
+		{
+			name:      "failing asker",
+			city:      "Paris",
+			askErr:    boom,
+			questions: 1,
+			err:       boom,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var questions []string
+			asker := AskerFunc(func(ctx context.Context, question string) (string, error) {
+				questions = append(questions, question)
+				if tt.askErr != nil {
+					return "", tt.askErr
+				}
+				return tt.answers[len(questions)-1], nil
+			})
+			next := textRunner("booked")
+			opts := append([]InterviewOption{OptionalSlots("notes")}, tt.opts...)
+			iv := NewInterview[trip](tripExtractor(tt.city), asker, next, opts...)
+			g, err := iv.Run(context.Background(), userPrompt("Book me a trip"))
+			if len(questions) != tt.questions {
+				t.Fatalf("expected %d questions, got %q", tt.questions, questions)
+			}
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected %v, got %v", tt.err, err)
+				}
+				if len(next.Prompts()) != 0 {
+					t.Fatal("expected no handoff")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g.Text() != "booked" {
+				t.Fatalf("expected the output of next, got %q", g.Text())
+			}
+			var got trip
+			if err := json.Unmarshal([]byte(next.Prompts()[0].Messages[0].Text()), &got); err != nil || got != tt.want {
+				t.Fatalf("expected the slots %+v to be handed off, got %+v (%v)", tt.want, got, err)
+			}
+		})
+	}
+}
+
+func TestInterview_Issues(t *testing.T) {
+	iv := NewInterview[trip](nil, nil, nil, OptionalSlots("notes"), ValidateSlot("date", func(v any) error {
+		if v != "tomorrow" {
+			return errors.New("only tomorrow")
+		}
+		return nil
+	}))
+	tests := []struct {
+		slots trip
+		want  string
+	}{
+		{trip{City: "Paris", Date: "tomorrow"}, ""},
+		{trip{}, "- city: missing\n- date: missing\n"},
+		{trip{City: "Paris", Date: "today"}, "- date: only tomorrow\n"},
+	}
+	for _, tt := range tests {
+		if got := formatIssues(iv.Issues(tt.slots)); got != tt.want {
+			t.Errorf("%+v: expected issues %q, got %q", tt.slots, tt.want, got)
+		}
+	}
+}
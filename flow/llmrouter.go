@@ -0,0 +1,129 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/go-kratos/blades"
+)
+
+var (
+	_ blades.Runner = (*LLMRouter)(nil)
+	_ Selector      = (*LLMRouter)(nil)
+)
+
+// RouteConfidenceKey is the message metadata key holding the confidence of the
+// model in the route chosen by an LLMRouter.
+const RouteConfidenceKey = "route_confidence"
+
+// LLMRoute is a named route of an LLMRouter, described to the model with
+// optional example inputs.
+type LLMRoute struct {
+	Name        string
+	Description string
+	Examples    []string
+	Runner      blades.Runner
+}
+
+// LLMRouterOption configures an LLMRouter.
+type LLMRouterOption func(*LLMRouter)
+
+// LLMRouterFallback dispatches to the named route the prompts the model assigns
+// to an unknown route, or to a route with a confidence below threshold.
+func LLMRouterFallback(route string, threshold float64) LLMRouterOption {
+	return func(r *LLMRouter) {
+		r.classifierOpts = append(r.classifierOpts, ClassifierFallback(route, threshold))
+	}
+}
+
+// LLMRouterInstructions sets the instructions preceding the routes.
+func LLMRouterInstructions(instructions string) LLMRouterOption {
+	return func(r *LLMRouter) {
+		r.classifierOpts = append(r.classifierOpts, ClassifierInstructions[string](instructions))
+	}
+}
+
+// LLMRouter asks a model, typically a cheap one, which of several named routes
+// a prompt belongs to and dispatches it to the runner of that route. Messages
+// are tagged with the route in the RouteKey metadata and the confidence of the
+// model in the RouteConfidenceKey metadata.
+type LLMRouter struct {
+	classifier     *Classifier[string]
+	classifierOpts []ClassifierOption[string]
+	routes         map[string]blades.Runner
+}
+
+// NewLLMRouter creates an LLMRouter asking runner to choose among routes.
+func NewLLMRouter(runner blades.Runner, routes []LLMRoute, opts ...LLMRouterOption) *LLMRouter {
+	r := &LLMRouter{routes: make(map[string]blades.Runner, len(routes))}
+	for _, opt := range opts {
+		opt(r)
+	}
+	labels := make([]Label[string], 0, len(routes))
+	for _, route := range routes {
+		labels = append(labels, Label[string]{Name: route.Name, Description: route.Description, Examples: route.Examples})
+		r.routes[route.Name] = route.Runner
+	}
+	r.classifier = NewClassifier(runner, labels, r.classifierOpts...)
+	return r
+}
+
+// route classifies the prompt and returns its route.
+func (r *LLMRouter) route(ctx context.Context, prompt *blades.Prompt, opts []blades.ModelOption) (Classification[string], blades.Runner, error) {
+	result, err := r.classifier.Classify(ctx, prompt, opts...)
+	if err != nil {
+		return result, nil, err
+	}
+	runner, ok := r.routes[result.Label]
+	if !ok {
+		return result, nil, fmt.Errorf("flow: no route %q", result.Label)
+	}
+	return result, runner, nil
+}
+
+// Select returns the name of the route of the prompt.
+func (r *LLMRouter) Select(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (string, error) {
+	result, _, err := r.route(ctx, prompt, opts)
+	if err != nil {
+		return "", err
+	}
+	return result.Label, nil
+}
+
+// Run runs the prompt with the chosen route.
+func (r *LLMRouter) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	result, runner, err := r.route(ctx, prompt, opts)
+	if err != nil {
+		return nil, err
+	}
+	res, err := runner.Run(ctx, prompt, opts...)
+	if err != nil {
+		return nil, err
+	}
+	tagClassification(res, result)
+	return res, nil
+}
+
+// RunStream streams the prompt with the chosen route.
+func (r *LLMRouter) RunStream(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (blades.Streamer[*blades.Generation], error) {
+	result, runner, err := r.route(ctx, prompt, opts)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := runner.RunStream(ctx, prompt, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return blades.NewMappedStream(stream, func(g *blades.Generation) (*blades.Generation, error) {
+		tagClassification(g, result)
+		return g, nil
+	}), nil
+}
+
+func tagClassification(g *blades.Generation, result Classification[string]) {
+	tagRoute(g, result.Label)
+	for _, msg := range g.Messages {
+		msg.Metadata[RouteConfidenceKey] = strconv.FormatFloat(result.Confidence, 'f', -1, 64)
+	}
+}
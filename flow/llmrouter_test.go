@@ -0,0 +1,93 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLLMRouter(t *testing.T) {
+	tests := []struct {
+		name       string
+		answer     string
+		opts       []LLMRouterOption
+		want       string
+		route      string
+		confidence string
+		err        error
+	}{
+		{
+			name:       "route",
+			answer:     `{"label": "refunds", "confidence": 0.9}`,
+			want:       "refunded",
+			route:      "refunds",
+			confidence: "0.9",
+		},
+		{
+			name:   "unknown route",
+			answer: `{"label": "sales", "confidence": 0.9}`,
+			err:    ErrUnknownLabel,
+		},
+		{
+			name:       "fallback on low confidence",
+			answer:     `{"label": "refunds", "confidence": 0.3}`,
+			opts:       []LLMRouterOption{LLMRouterFallback("general", 0.5)},
+			want:       "answered",
+			route:      "general",
+			confidence: "0.3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classifier := textRunner(tt.answer)
+			router := NewLLMRouter(classifier, []LLMRoute{
+				{Name: "refunds", Description: "money back", Examples: []string{"refund my order"}, Runner: textRunner("refunded")},
+				{Name: "general", Description: "anything else", Runner: textRunner("answered")},
+			}, append([]LLMRouterOption{LLMRouterInstructions("Pick the team.")}, tt.opts...)...)
+			g, err := router.Run(context.Background(), userPrompt("I want my money back"))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected %v, got %v", tt.err, err)
+				}
+				if _, err := router.RunStream(context.Background(), userPrompt("I want my money back")); !errors.Is(err, tt.err) {
+					t.Fatalf("expected the stream to fail with %v, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			metadata := g.Messages[0].Metadata
+			if g.Text() != tt.want || metadata[RouteKey] != tt.route || metadata[RouteConfidenceKey] != tt.confidence {
+				t.Fatalf("expected %q from route %q at %s, got %q with %v", tt.want, tt.route, tt.confidence, g.Text(), metadata)
+			}
+			if system := classifier.Prompts()[0].String(); !strings.Contains(system, "Pick the team.") || !strings.Contains(system, "- refunds: money back") {
+				t.Fatalf("expected the instructions and routes in the prompt, got %q", system)
+			}
+			if route, err := router.Select(context.Background(), userPrompt("I want my money back")); err != nil || route != tt.route {
+				t.Fatalf("expected to select %q, got %q (%v)", tt.route, route, err)
+			}
+			stream, err := router.RunStream(context.Background(), userPrompt("I want my money back"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			gens, err := collect(stream)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if metadata := gens[0].Messages[0].Metadata; metadata[RouteKey] != tt.route || metadata[RouteConfidenceKey] != tt.confidence {
+				t.Fatalf("expected the stream to be tagged with route %q, got %v", tt.route, metadata)
+			}
+		})
+	}
+}
+
+func TestLLMRouter_MissingRunner(t *testing.T) {
+	router := NewLLMRouter(textRunner(`{"label": "sales", "confidence": 0.9}`), []LLMRoute{
+		{Name: "refunds", Runner: textRunner("refunded")},
+	}, LLMRouterFallback("general", 0.5))
+	if _, err := router.Run(context.Background(), userPrompt("hi")); err == nil || err.Error() != `flow: no route "general"` {
+		t.Fatalf("expected a missing fallback route to fail, got %v", err)
+	}
+}
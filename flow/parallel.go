@@ -0,0 +1,175 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-kratos/blades"
+)
+
+var (
+	_ blades.Runner = (*Parallel)(nil)
+)
+
+// BranchKey is the message metadata key holding the name of the branch that
+// produced the message.
+const BranchKey = "branch"
+
+// Branch is a named runner of a Parallel flow.
+type Branch struct {
+	Name   string
+	Runner blades.Runner
+}
+
+// BranchOf returns the name of the branch that produced a generation of a
+// Parallel flow, or "" for generations of the flow itself such as its Finish.
+func BranchOf(g *blades.Generation) string {
+	for _, msg := range g.Messages {
+		if name, ok := msg.Metadata[BranchKey]; ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// Parallel runs several runners on the same prompt concurrently. The first
+// failing branch cancels the others and fails the run.
+type Parallel struct {
+	branches []Branch
+}
+
+// NewParallel creates a new Parallel flow of the given branches.
+func NewParallel(branches ...Branch) *Parallel {
+	return &Parallel{branches: branches}
+}
+
+// Run runs every branch and merges their generations in branch order, tagging
+// each message with its branch name, see blades.Generation.Merge.
+func (p *Parallel) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	var (
+		wg  sync.WaitGroup
+		acc = blades.NewGenerationAccumulator()
+	)
+	for i, branch := range p.branches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g, err := branch.Runner.Run(ctx, prompt, opts...)
+			if err != nil {
+				cancel(fmt.Errorf("flow: branch %s: %w", branch.Name, err))
+				return
+			}
+			tagBranch(g, branch.Name)
+			acc.Add(i, g)
+		}()
+	}
+	wg.Wait()
+	if err := context.Cause(ctx); err != nil {
+		return nil, err
+	}
+	return acc.Generation(), nil
+}
+
+// RunStream streams every branch and interleaves their generations on a single
+// stream as they arrive, so that several agents can be rendered generating side
+// by side. Each generation is tagged with its branch name, see BranchOf. The
+// terminal generations of the branches are folded into a single one ending the
+// stream.
+func (p *Parallel) RunStream(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (blades.Streamer[*blades.Generation], error) {
+	pipe := blades.NewStreamPipe[*blades.Generation]()
+	pipe.Go(func() error {
+		ctx, cancel := context.WithCancelCause(ctx)
+		defer cancel(nil)
+		var (
+			wg       sync.WaitGroup
+			mu       sync.Mutex
+			started  = blades.Now()
+			finish   = &blades.Finish{Reason: "stop"}
+			warnings []blades.Warning
+		)
+		addResult := func(usage *blades.Usage, branchWarnings []blades.Warning) {
+			mu.Lock()
+			defer mu.Unlock()
+			if finish.Usage == nil {
+				finish.Usage = &blades.Usage{}
+			}
+			finish.Usage.Add(usage)
+			warnings = append(warnings, branchWarnings...)
+		}
+		for _, branch := range p.branches {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := p.streamBranch(ctx, branch, prompt, opts, pipe, addResult); err != nil {
+					cancel(fmt.Errorf("flow: branch %s: %w", branch.Name, err))
+				}
+			}()
+		}
+		wg.Wait()
+		if err := context.Cause(ctx); err != nil {
+			return err
+		}
+		finish.Elapsed = blades.Since(started)
+		pipe.Send(&blades.Generation{Finish: finish, Warnings: warnings})
+		return nil
+	})
+	return pipe, nil
+}
+
+// streamBranch forwards the generations of a branch to pipe, reporting the
+// usage and warnings of the branch to addResult once it ends.
+func (p *Parallel) streamBranch(ctx context.Context, branch Branch, prompt *blades.Prompt, opts []blades.ModelOption, pipe *blades.StreamPipe[*blades.Generation], addResult func(*blades.Usage, []blades.Warning)) error {
+	stream, err := branch.Runner.RunStream(ctx, prompt, opts...)
+	if err != nil {
+		return err
+	}
+	var (
+		usage    blades.Usage
+		finish   *blades.Finish
+		warnings []blades.Warning
+	)
+	for stream.Next() {
+		var g *blades.Generation
+		if g, err = stream.Current(); err != nil {
+			// let the producer of the branch finish before closing its stream
+			for stream.Next() {
+			}
+			break
+		}
+		if g.Finish != nil {
+			finish = g.Finish
+			warnings = g.Warnings
+			continue
+		}
+		if g.Usage != nil {
+			usage.Add(g.Usage)
+		}
+		tagBranch(g, branch.Name)
+		pipe.Send(g)
+	}
+	if cerr := stream.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if finish != nil && finish.Usage != nil {
+		addResult(finish.Usage, warnings)
+	} else {
+		addResult(&usage, warnings)
+	}
+	return nil
+}
+
+// tagBranch records the branch name in the metadata of the generation messages.
+func tagBranch(g *blades.Generation, name string) {
+	for _, msg := range g.Messages {
+		if msg.Metadata == nil {
+			msg.Metadata = make(map[string]string)
+		}
+		msg.Metadata[BranchKey] = name
+	}
+}
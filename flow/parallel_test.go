@@ -0,0 +1,132 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/blades"
+)
+
+func TestParallel_Run(t *testing.T) {
+	usage := &blades.Usage{PromptTokens: 1, CompletionTokens: 2, TotalTokens: 3}
+	tests := []struct {
+		name     string
+		branches []Branch
+		want     []string
+		err      string
+	}{
+		{
+			name: "merged in branch order",
+			branches: []Branch{
+				{Name: "a", Runner: &fakeRunner{replies: []string{"from a"}, usage: usage}},
+				{Name: "b", Runner: &fakeRunner{replies: []string{"from b"}, usage: usage}},
+			},
+			want: []string{"a:from a", "b:from b"},
+		},
+		{
+			name: "failing branch",
+			branches: []Branch{
+				{Name: "a", Runner: textRunner("from a")},
+				{Name: "b", Runner: &fakeRunner{err: errors.New("boom")}},
+			},
+			err: "flow: branch b: boom",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewParallel(tt.branches...).Run(context.Background(), userPrompt("hi"))
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, msg := range g.Messages {
+				got = append(got, msg.Metadata[BranchKey]+":"+msg.Text())
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			if g.Usage == nil || g.Usage.TotalTokens != 6 {
+				t.Fatalf("expected the usage of both branches, got %+v", g.Usage)
+			}
+		})
+	}
+}
+
+func TestParallel_RunStream(t *testing.T) {
+	usage := &blades.Usage{PromptTokens: 1, CompletionTokens: 2, TotalTokens: 3}
+	stream, err := NewParallel(
+		Branch{Name: "a", Runner: &fakeRunner{replies: []string{"from a"}, chunks: []string{"fr", "om"}, usage: usage}},
+		Branch{Name: "b", Runner: &fakeRunner{replies: []string{"from b"}, usage: usage}},
+	).RunStream(context.Background(), userPrompt("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gens, err := collect(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	perBranch := map[string][]string{}
+	for _, g := range gens[:len(gens)-1] {
+		if g.Finish != nil {
+			t.Fatalf("unexpected branch finish %+v", g.Finish)
+		}
+		perBranch[BranchOf(g)] = append(perBranch[BranchOf(g)], g.Text())
+	}
+	if got := strings.Join(perBranch["a"], ","); got != "fr,om,from a" {
+		t.Fatalf("expected the generations of a in order, got %q", got)
+	}
+	if got := strings.Join(perBranch["b"], ","); got != "from b" {
+		t.Fatalf("expected the generations of b, got %q", got)
+	}
+	last := gens[len(gens)-1]
+	if last.Finish == nil || BranchOf(last) != "" {
+		t.Fatalf("expected the stream to end with the finish of the flow, got %+v", last)
+	}
+	if last.Finish.Usage == nil || last.Finish.Usage.TotalTokens != 6 {
+		t.Fatalf("expected the usage of both branches, got %+v", last.Finish.Usage)
+	}
+}
+
+func TestParallel_RunStreamBranchError(t *testing.T) {
+	// more chunks than the stream buffers, so that the producers are still
+	// sending when the failure is read
+	chunks := make([]string, 64)
+	for i := range chunks {
+		chunks[i] = "x"
+	}
+	tests := []struct {
+		name   string
+		failer *fakeRunner
+	}{
+		{"fails after its chunks", &fakeRunner{chunks: chunks, err: errors.New("boom")}},
+		{"fails to start", &fakeRunner{reply: func(*blades.Prompt) (string, error) { return "", errors.New("boom") }}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := NewParallel(
+				Branch{Name: "ok", Runner: &fakeRunner{replies: []string{"done"}, chunks: chunks}},
+				Branch{Name: "bad", Runner: tt.failer},
+			).RunStream(context.Background(), userPrompt("hi"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			gens, err := collect(stream)
+			if err == nil || err.Error() != "flow: branch bad: boom" {
+				t.Fatalf("expected the branch failure, got %v", err)
+			}
+			for _, g := range gens {
+				if g.Finish != nil {
+					t.Fatalf("expected no finish on a failed run, got %+v", g.Finish)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package flow
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/tokens"
+)
+
+// maxInstructionSummary bounds the length of the instructions summarized in a plan.
+const maxInstructionSummary = 120
+
+// PlannedStep is a step of a chain as it would run.
+type PlannedStep struct {
+	Number int    `json:"number"`
+	Name   string `json:"name"`
+	// Model is the model of the step agent, empty for other runners or agents
+	// using the default model of their provider.
+	Model string `json:"model,omitempty"`
+	// Instructions summarizes the instructions of the step agent.
+	Instructions string `json:"instructions"`
+	// EstimatedTokens estimates the prompt tokens of the step from its
+	// instructions, plus the prompt for the first step, as the inputs of the
+	// other steps are only known once the previous steps ran.
+	EstimatedTokens int `json:"estimatedTokens"`
+}
+
+// Plan walks the chain without calling any provider and returns its planned
+// steps, for reviewing a pipeline before running it.
+func (c *Chain) Plan(prompt *blades.Prompt) []PlannedStep {
+	steps := make([]PlannedStep, 0, len(c.runners))
+	for i, runner := range c.runners {
+		name, instructions := c.getStepInfo(runner, i+1)
+		step := PlannedStep{Number: i + 1, Name: name, Instructions: summarizeInstructions(instructions)}
+		var full string
+		if agent := stepAgent(runner); agent != nil {
+			step.Model = agent.Model()
+			full = agent.Instructions()
+		}
+		tokenizer := tokens.ForModel(step.Model)
+		step.EstimatedTokens = tokenizer.Count(full)
+		if i == 0 && prompt != nil {
+			for _, msg := range prompt.Messages {
+				step.EstimatedTokens += tokenizer.Count(msg.Text())
+			}
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// DryRun plans the chain like Plan and, unless the chain is silent, renders the
+// planned steps like a run, without calling any provider.
+func (c *Chain) DryRun(prompt *blades.Prompt) []PlannedStep {
+	steps := c.Plan(prompt)
+	if !c.verbose {
+		return steps
+	}
+	fmt.Printf("\n%s%s╔════════════════════════════════════════════════════════════════════════════════╗%s\n", ColorBold, ColorBlue, ColorReset)
+	fmt.Printf("%s%s║%s %s%sCHAIN DRY RUN%s %s│ Steps: %d %s%s║%s\n", ColorBold, ColorBlue, ColorReset, ColorBold, ColorWhite, ColorReset, ColorYellow, len(steps), ColorBold, ColorBlue, ColorReset)
+	fmt.Printf("%s%s╚════════════════════════════════════════════════════════════════════════════════╝%s\n", ColorBold, ColorBlue, ColorReset)
+	if prompt != nil {
+		fmt.Printf("\n%s%sINITIAL PROMPT%s\n", ColorBold, ColorCyan, ColorReset)
+		c.printText(prompt.String(), ColorCyan)
+	}
+	total := 0
+	for _, step := range steps {
+		c.printStepHeader(step.Number, step.Name, step.Instructions)
+		model := step.Model
+		if model == "" {
+			model = "default"
+		}
+		fmt.Printf("%s   Model: %s │ Estimated prompt tokens: ~%d%s\n", ColorYellow, model, step.EstimatedTokens, ColorReset)
+		total += step.EstimatedTokens
+	}
+	fmt.Printf("\n%s%s📋 PLANNED: %d steps, ~%d prompt tokens before step outputs%s\n", ColorBold, ColorCyan, len(steps), total, ColorReset)
+	return steps
+}
+
+// stepAgent returns the agent of a runner, unwrapping steps.
+func stepAgent(runner blades.Runner) *blades.Agent {
+	if step, ok := runner.(*Step); ok {
+		runner = step.runner
+	}
+	agent, _ := runner.(*blades.Agent)
+	return agent
+}
+
+// summarizeInstructions returns the first line of the instructions, shortened
+// to maxInstructionSummary runes.
+func summarizeInstructions(instructions string) string {
+	line, _, more := strings.Cut(strings.TrimSpace(instructions), "\n")
+	if runes := []rune(line); len(runes) > maxInstructionSummary {
+		return string(runes[:maxInstructionSummary]) + "…"
+	}
+	if more {
+		return line + " …"
+	}
+	return line
+}
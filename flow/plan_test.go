@@ -0,0 +1,65 @@
+package flow
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/tokens"
+)
+
+func TestChain_Plan(t *testing.T) {
+	provider := &fakeProvider{reply: "ok"}
+	writer := blades.NewAgent("writer", blades.WithModel("gpt-4o"), blades.WithProvider(provider), blades.WithInstructions("Write a draft.\nKeep it short."))
+	chain := NewChainSilent(
+		writer,
+		NewStep(writer, StepName("cheap"), StepModel("gpt-4o-mini")),
+		textRunner("ok"),
+	)
+	prompt := userPrompt("about Go")
+	want := []PlannedStep{
+		{
+			Number:          1,
+			Name:            "writer",
+			Model:           "gpt-4o",
+			Instructions:    "Write a draft. …",
+			EstimatedTokens: tokens.Count("gpt-4o", "Write a draft.\nKeep it short.") + tokens.Count("gpt-4o", "about Go"),
+		},
+		{
+			Number:          2,
+			Name:            "cheap",
+			Model:           "gpt-4o-mini",
+			Instructions:    "Write a draft. …",
+			EstimatedTokens: tokens.Count("gpt-4o-mini", "Write a draft.\nKeep it short."),
+		},
+		{Number: 3, Name: "Step 3", Instructions: "Executing task..."},
+	}
+	if got := chain.Plan(prompt); !slices.Equal(got, want) {
+		t.Fatalf("expected the plan\n%+v\ngot\n%+v", want, got)
+	}
+	if got := chain.DryRun(prompt); !slices.Equal(got, want) {
+		t.Fatalf("expected the dry run to return the plan, got %+v", got)
+	}
+	if models, _ := provider.calls(); len(models) != 0 {
+		t.Fatalf("expected no provider call, got %d", len(models))
+	}
+}
+
+func TestSummarizeInstructions(t *testing.T) {
+	long := strings.Repeat("a", maxInstructionSummary+1)
+	tests := []struct {
+		instructions string
+		want         string
+	}{
+		{"", ""},
+		{"  Write a draft.  ", "Write a draft."},
+		{"Write a draft.\nKeep it short.", "Write a draft. …"},
+		{long, long[:maxInstructionSummary] + "…"},
+	}
+	for _, tt := range tests {
+		if got := summarizeInstructions(tt.instructions); got != tt.want {
+			t.Errorf("summarizeInstructions(%q) = %q, want %q", tt.instructions, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kratos/blades"
+)
+
+var (
+	_ blades.Runner = (*Router)(nil)
+)
+
+// RouteKey is the message metadata key holding the route that produced a message.
+const RouteKey = "route"
+
+// Selector chooses the route of a prompt by name.
+type Selector interface {
+	Select(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (string, error)
+}
+
+// SelectorFunc adapts a function to a Selector.
+type SelectorFunc func(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (string, error)
+
+// Select calls f.
+func (f SelectorFunc) Select(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (string, error) {
+	return f(ctx, prompt, opts...)
+}
+
+// RouterOption configures a Router.
+type RouterOption func(*Router)
+
+// RouterDefault sets the runner of prompts whose selected route does not exist.
+func RouterDefault(runner blades.Runner) RouterOption {
+	return func(r *Router) {
+		r.fallback = runner
+	}
+}
+
+// Router runs each prompt with the runner of the route chosen by a selector,
+// such as a Classifier.
+type Router struct {
+	selector Selector
+	routes   map[string]blades.Runner
+	fallback blades.Runner
+}
+
+// NewRouter creates a Router dispatching prompts to routes by the name selector returns.
+func NewRouter(selector Selector, routes map[string]blades.Runner, opts ...RouterOption) *Router {
+	r := &Router{selector: selector, routes: routes}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// route selects the route of the prompt.
+func (r *Router) route(ctx context.Context, prompt *blades.Prompt, opts []blades.ModelOption) (string, blades.Runner, error) {
+	name, err := r.selector.Select(ctx, prompt, opts...)
+	if err != nil {
+		return "", nil, err
+	}
+	if runner, ok := r.routes[name]; ok {
+		return name, runner, nil
+	}
+	if r.fallback == nil {
+		return "", nil, fmt.Errorf("flow: no route %q", name)
+	}
+	return name, r.fallback, nil
+}
+
+// Run runs the prompt with the selected route.
+func (r *Router) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	name, runner, err := r.route(ctx, prompt, opts)
+	if err != nil {
+		return nil, err
+	}
+	res, err := runner.Run(ctx, prompt, opts...)
+	if err != nil {
+		return nil, err
+	}
+	tagRoute(res, name)
+	return res, nil
+}
+
+// RunStream streams the prompt with the selected route.
+func (r *Router) RunStream(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (blades.Streamer[*blades.Generation], error) {
+	name, runner, err := r.route(ctx, prompt, opts)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := runner.RunStream(ctx, prompt, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return blades.NewMappedStream(stream, func(g *blades.Generation) (*blades.Generation, error) {
+		tagRoute(g, name)
+		return g, nil
+	}), nil
+}
+
+func tagRoute(g *blades.Generation, name string) {
+	for _, msg := range g.Messages {
+		if msg.Metadata == nil {
+			msg.Metadata = make(map[string]string)
+		}
+		msg.Metadata[RouteKey] = name
+	}
+}
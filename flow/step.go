@@ -0,0 +1,81 @@
+package flow
+
+import (
+	"context"
+	"slices"
+
+	"github.com/go-kratos/blades"
+)
+
+var (
+	_ blades.Runner = (*Step)(nil)
+)
+
+// StepOption configures a Step.
+type StepOption func(*Step)
+
+// StepName names the step in chain results and logs, instead of the name of
+// its agent.
+func StepName(name string) StepOption {
+	return func(s *Step) {
+		s.name = name
+	}
+}
+
+// StepModel overrides the model of the step agent.
+func StepModel(model string) StepOption {
+	return func(s *Step) {
+		s.agentOpts = append(s.agentOpts, blades.WithModel(model))
+	}
+}
+
+// StepProvider overrides the model provider of the step agent.
+func StepProvider(provider blades.ModelProvider) StepOption {
+	return func(s *Step) {
+		s.agentOpts = append(s.agentOpts, blades.WithProvider(provider))
+	}
+}
+
+// StepModelOptions sets model options for the step. They are applied after the
+// options passed to the chain, so they take precedence.
+func StepModelOptions(opts ...blades.ModelOption) StepOption {
+	return func(s *Step) {
+		s.modelOpts = append(s.modelOpts, opts...)
+	}
+}
+
+// Step wraps a runner with step-level overrides, so a chain can use a cheap model
+// for one step and an expensive one for another without defining an agent per
+// combination. Model and provider overrides apply when the runner is a *blades.Agent.
+type Step struct {
+	name      string
+	runner    blades.Runner
+	agentOpts []blades.Option
+	modelOpts []blades.ModelOption
+}
+
+// NewStep creates a new Step for runner with the given overrides.
+func NewStep(runner blades.Runner, opts ...StepOption) *Step {
+	s := &Step{runner: runner}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if agent, ok := runner.(*blades.Agent); ok && len(s.agentOpts) > 0 {
+		s.runner = agent.Clone(s.agentOpts...)
+	}
+	return s
+}
+
+// Run runs the step with the chain options followed by the step options.
+func (s *Step) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	return s.runner.Run(ctx, prompt, s.options(opts)...)
+}
+
+// RunStream streams the step with the chain options followed by the step options.
+func (s *Step) RunStream(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (blades.Streamer[*blades.Generation], error) {
+	return s.runner.RunStream(ctx, prompt, s.options(opts)...)
+}
+
+func (s *Step) options(opts []blades.ModelOption) []blades.ModelOption {
+	return append(slices.Clip(opts), s.modelOpts...)
+}
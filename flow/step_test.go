@@ -0,0 +1,105 @@
+package flow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/blades"
+)
+
+func TestStep_Overrides(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      func(other *fakeProvider) []StepOption
+		stepName  string
+		model     string
+		other     bool
+		maxTokens int64
+	}{
+		{
+			name:      "no overrides",
+			opts:      func(*fakeProvider) []StepOption { return nil },
+			stepName:  "writer",
+			model:     "base",
+			maxTokens: 100,
+		},
+		{
+			name:      "model",
+			opts:      func(*fakeProvider) []StepOption { return []StepOption{StepModel("cheap")} },
+			stepName:  "writer",
+			model:     "cheap",
+			maxTokens: 100,
+		},
+		{
+			name:      "provider",
+			opts:      func(other *fakeProvider) []StepOption { return []StepOption{StepProvider(other)} },
+			stepName:  "writer",
+			model:     "base",
+			other:     true,
+			maxTokens: 100,
+		},
+		{
+			name:      "model options over chain options",
+			opts:      func(*fakeProvider) []StepOption { return []StepOption{StepModelOptions(blades.MaxOutputTokens(10))} },
+			stepName:  "writer",
+			model:     "base",
+			maxTokens: 10,
+		},
+		{
+			name:      "name",
+			opts:      func(*fakeProvider) []StepOption { return []StepOption{StepName("draft")} },
+			stepName:  "draft",
+			model:     "base",
+			maxTokens: 100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, other := &fakeProvider{reply: "base"}, &fakeProvider{reply: "other"}
+			agent := blades.NewAgent("writer", blades.WithModel("base"), blades.WithProvider(base))
+			chain := NewChainSilent(NewStep(agent, tt.opts(other)...))
+			result, err := chain.RunSteps(context.Background(), userPrompt("hi"), blades.MaxOutputTokens(100), blades.TopK(5))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Steps[0].Name != tt.stepName {
+				t.Fatalf("expected the step to be named %q, got %q", tt.stepName, result.Steps[0].Name)
+			}
+			called := base
+			if tt.other {
+				called = other
+			}
+			models, options := called.calls()
+			if len(models) != 1 || models[0] != tt.model {
+				t.Fatalf("expected one call of %q, got %v", tt.model, models)
+			}
+			if options[0].MaxOutputTokens != tt.maxTokens || options[0].TopK != 5 {
+				t.Fatalf("expected %d max output tokens and the chain options, got %+v", tt.maxTokens, options[0])
+			}
+			if _, err := agent.Run(context.Background(), userPrompt("hi")); err != nil {
+				t.Fatal(err)
+			}
+			if models, _ := base.calls(); models[len(models)-1] != "base" {
+				t.Fatalf("expected the agent to be left unchanged, got model %q", models[len(models)-1])
+			}
+		})
+	}
+}
+
+func TestStep_RunnerOptions(t *testing.T) {
+	runner := textRunner("ok")
+	step := NewStep(runner, StepModel("ignored"), StepModelOptions(blades.MaxOutputTokens(10)))
+	stream, err := step.RunStream(context.Background(), userPrompt("hi"), blades.MaxOutputTokens(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := collect(stream); err != nil {
+		t.Fatal(err)
+	}
+	if step.runner != runner {
+		t.Fatal("expected runners other than agents to be used as is")
+	}
+	if got := runner.lastOptions().MaxOutputTokens; got != 10 {
+		t.Fatalf("expected the step options to take precedence, got %d", got)
+	}
+}
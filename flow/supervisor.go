@@ -0,0 +1,270 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-kratos/blades"
+)
+
+var (
+	_ blades.Runner = (*Supervisor)(nil)
+)
+
+// WorkerKey is the message metadata key holding the name of the worker that
+// produced a message under a Supervisor.
+const WorkerKey = "worker"
+
+// Worker is a named agent a Supervisor may delegate tasks to.
+type Worker struct {
+	Name string
+	// Description tells the coordinator what the worker is good at.
+	Description string
+	Runner      blades.Runner
+}
+
+// Assignment is a task the coordinator delegates to a worker.
+type Assignment struct {
+	Worker string `json:"worker" jsonschema:"the name of the worker"`
+	Task   string `json:"task" jsonschema:"the task for the worker, with all the context it needs"`
+}
+
+// SupervisorDecision is what the coordinator decided at a turn.
+type SupervisorDecision struct {
+	Turn        int          `json:"turn"`
+	Reasoning   string       `json:"reasoning"`
+	Assignments []Assignment `json:"assignments,omitempty"`
+	Done        bool         `json:"done"`
+	Answer      string       `json:"answer,omitempty"`
+}
+
+// decision is the structured output asked from the coordinator.
+type decision struct {
+	Reasoning   string       `json:"reasoning" jsonschema:"why these tasks are delegated, or why the task is complete"`
+	Assignments []Assignment `json:"assignments" jsonschema:"the tasks to delegate in this turn, which run in parallel; empty when the task is complete"`
+	Done        bool         `json:"done" jsonschema:"whether the task is complete"`
+	Answer      string       `json:"answer" jsonschema:"the final answer to the task, when it is complete"`
+}
+
+// Delegation is an assignment carried out by a worker.
+type Delegation struct {
+	Turn   int    `json:"turn"`
+	Worker string `json:"worker"`
+	Task   string `json:"task"`
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// SupervisorResult is the outcome of a supervised task, with the full trace of
+// the decisions of the coordinator and the delegations they led to.
+type SupervisorResult struct {
+	Task        string               `json:"task"`
+	Decisions   []SupervisorDecision `json:"decisions"`
+	Delegations []Delegation         `json:"delegations"`
+	Answer      string               `json:"answer"`
+}
+
+// SupervisorOption configures a Supervisor.
+type SupervisorOption func(*Supervisor)
+
+// SupervisorMaxTurns sets the maximum number of decisions of the coordinator,
+// 5 by default. The coordinator is told to answer at the last turn.
+func SupervisorMaxTurns(n int) SupervisorOption {
+	return func(s *Supervisor) {
+		s.maxTurns = n
+	}
+}
+
+// Supervisor lets a coordinator work on a task with a team of workers: at each
+// turn it decides which workers to delegate tasks to, which run in parallel,
+// reads their outputs and iterates until it decides the task is complete. A
+// failing worker does not stop the run; its error is reported to the
+// coordinator instead.
+type Supervisor struct {
+	coordinator blades.Runner
+	workers     []Worker
+	maxTurns    int
+}
+
+// NewSupervisor creates a Supervisor of the workers, coordinated by coordinator.
+func NewSupervisor(coordinator blades.Runner, workers []Worker, opts ...SupervisorOption) *Supervisor {
+	s := &Supervisor{coordinator: coordinator, workers: workers, maxTurns: 5}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Supervise works on the task in prompt and returns the answer with the trace
+// of the run.
+func (s *Supervisor) Supervise(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*SupervisorResult, error) {
+	result, _, err := s.run(ctx, prompt, func(*blades.Generation) {}, opts)
+	return result, err
+}
+
+// Run works on the task and returns the answer as a generation, with the
+// usage of the coordinator and the workers.
+func (s *Supervisor) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	result, usage, err := s.run(ctx, prompt, func(*blades.Generation) {}, opts)
+	if err != nil {
+		return nil, err
+	}
+	g := answerGeneration(result.Answer)
+	g.Usage = usage
+	return g, nil
+}
+
+// RunStream streams the output of each delegation as it completes, tagged with
+// its worker in the WorkerKey metadata, then the answer, followed by a terminal
+// generation.
+func (s *Supervisor) RunStream(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (blades.Streamer[*blades.Generation], error) {
+	pipe := blades.NewStreamPipe[*blades.Generation]()
+	pipe.Go(func() error {
+		started := blades.Now()
+		result, usage, err := s.run(ctx, prompt, func(output *blades.Generation) {
+			pipe.Send(output)
+		}, opts)
+		if err != nil {
+			return err
+		}
+		pipe.Send(answerGeneration(result.Answer))
+		pipe.Send(&blades.Generation{Finish: &blades.Finish{Reason: "stop", Usage: usage, Elapsed: blades.Since(started)}})
+		return nil
+	})
+	return pipe, nil
+}
+
+// run asks the coordinator for decisions and carries out their assignments,
+// passing the output of each delegation to onOutput, until the task is complete.
+// It returns the usage of the coordinator and the workers.
+func (s *Supervisor) run(ctx context.Context, prompt *blades.Prompt, onOutput func(*blades.Generation), opts []blades.ModelOption) (*SupervisorResult, *blades.Usage, error) {
+	if len(s.workers) == 0 {
+		return nil, nil, errors.New("flow: a supervisor needs at least 1 worker")
+	}
+	var (
+		result      = &SupervisorResult{Task: promptText(prompt)}
+		coordinator = &usageRunner{Runner: s.coordinator}
+		usage       *blades.Usage
+	)
+	// outputs are passed one at a time
+	emit := func(output *blades.Generation) {
+		usage = addUsage(usage, output.Usage)
+		onOutput(output)
+	}
+	for turn := 1; turn <= s.maxTurns; turn++ {
+		d, err := blades.GenerateObject[decision](ctx, coordinator, blades.NewPrompt(blades.UserMessage(s.coordinatorPrompt(result, turn))), opts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("flow: coordinator in turn %d: %w", turn, err)
+		}
+		result.Decisions = append(result.Decisions, SupervisorDecision{
+			Turn:        turn,
+			Reasoning:   d.Reasoning,
+			Assignments: d.Assignments,
+			Done:        d.Done,
+			Answer:      d.Answer,
+		})
+		if d.Done || len(d.Assignments) == 0 {
+			result.Answer = d.Answer
+			return result, addUsage(usage, coordinator.usage), nil
+		}
+		result.Delegations = append(result.Delegations, s.delegate(ctx, turn, d.Assignments, emit, opts)...)
+	}
+	return nil, nil, fmt.Errorf("flow: supervisor did not complete the task within %d turns", s.maxTurns)
+}
+
+// delegate runs the assignments in parallel and returns their delegations in
+// assignment order.
+func (s *Supervisor) delegate(ctx context.Context, turn int, assignments []Assignment, onOutput func(*blades.Generation), opts []blades.ModelOption) []Delegation {
+	var (
+		wg          sync.WaitGroup
+		mu          sync.Mutex
+		delegations = make([]Delegation, len(assignments))
+	)
+	for i, assignment := range assignments {
+		delegations[i] = Delegation{Turn: turn, Worker: assignment.Worker, Task: assignment.Task}
+		j := slices.IndexFunc(s.workers, func(w Worker) bool { return strings.EqualFold(w.Name, assignment.Worker) })
+		if j < 0 {
+			delegations[i].Error = fmt.Sprintf("unknown worker %q", assignment.Worker)
+			continue
+		}
+		worker := s.workers[j]
+		delegations[i].Worker = worker.Name
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			output, err := worker.Runner.Run(ctx, blades.NewPrompt(blades.UserMessage(assignment.Task)), opts...)
+			if err != nil {
+				delegations[i].Error = err.Error()
+				return
+			}
+			delegations[i].Output = output.Text()
+			for _, msg := range output.Messages {
+				if msg.Metadata == nil {
+					msg.Metadata = make(map[string]string)
+				}
+				msg.Metadata[WorkerKey] = worker.Name
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			onOutput(output)
+		}()
+	}
+	wg.Wait()
+	return delegations
+}
+
+func (s *Supervisor) coordinatorPrompt(result *SupervisorResult, turn int) string {
+	var buf strings.Builder
+	buf.WriteString("You coordinate a team of workers to complete a task. The workers are:\n")
+	for _, w := range s.workers {
+		fmt.Fprintf(&buf, "- %s", w.Name)
+		if w.Description != "" {
+			fmt.Fprintf(&buf, ": %s", w.Description)
+		}
+		buf.WriteString("\n")
+	}
+	fmt.Fprintf(&buf, "\nTask:\n%s\n", result.Task)
+	if len(result.Delegations) > 0 {
+		buf.WriteString("\nWork so far:\n")
+		for _, d := range result.Delegations {
+			fmt.Fprintf(&buf, "\n[Turn %d] %s was asked: %s\n", d.Turn, d.Worker, d.Task)
+			if d.Error != "" {
+				fmt.Fprintf(&buf, "It failed: %s\n", d.Error)
+			} else {
+				fmt.Fprintf(&buf, "It answered:\n%s\n", d.Output)
+			}
+		}
+	}
+	if turn == s.maxTurns {
+		buf.WriteString("\nThis is your last turn: give the final answer to the task now.")
+	} else {
+		buf.WriteString("\nDelegate the next tasks to the workers, several at once when they are independent, or give the final answer when the task is complete.")
+	}
+	return buf.String()
+}
+
+// usageRunner adds up the usage of the runs of a runner. It is not safe for
+// concurrent use.
+type usageRunner struct {
+	blades.Runner
+	usage *blades.Usage
+}
+
+func (r *usageRunner) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	res, err := r.Runner.Run(ctx, prompt, opts...)
+	if err != nil {
+		return nil, err
+	}
+	r.usage = addUsage(r.usage, res.Usage)
+	return res, nil
+}
+
+func answerGeneration(answer string) *blades.Generation {
+	msg := blades.AssistantMessage(answer)
+	msg.Status = blades.StatusCompleted
+	return &blades.Generation{Messages: []*blades.Message{msg}}
+}
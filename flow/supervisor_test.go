@@ -0,0 +1,173 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/blades"
+)
+
+const (
+	delegateJSON = `{"reasoning": "split", "assignments": [{"worker": "Researcher", "task": "find"}, {"worker": "writer", "task": "write"}, {"worker": "critic", "task": "judge"}], "done": false, "answer": ""}`
+	answerJSON   = `{"reasoning": "complete", "assignments": [], "done": true, "answer": "report"}`
+)
+
+func TestSupervisor(t *testing.T) {
+	tests := []struct {
+		name        string
+		coordinator *fakeRunner
+		workers     bool
+		opts        []SupervisorOption
+		answer      string
+		delegations []Delegation
+		err         string
+	}{
+		{
+			name:        "delegates then answers",
+			coordinator: textRunner(delegateJSON, answerJSON),
+			workers:     true,
+			answer:      "report",
+			delegations: []Delegation{
+				{Turn: 1, Worker: "researcher", Task: "find", Output: "facts"},
+				{Turn: 1, Worker: "writer", Task: "write", Error: "boom"},
+				{Turn: 1, Worker: "critic", Task: "judge", Error: `unknown worker "critic"`},
+			},
+		},
+		{
+			name:        "answers at once",
+			coordinator: textRunner(answerJSON),
+			workers:     true,
+			answer:      "report",
+		},
+		{
+			name:        "too many turns",
+			coordinator: textRunner(delegateJSON),
+			workers:     true,
+			opts:        []SupervisorOption{SupervisorMaxTurns(2)},
+			err:         "flow: supervisor did not complete the task within 2 turns",
+		},
+		{
+			name:        "failing coordinator",
+			coordinator: &fakeRunner{err: errors.New("boom")},
+			workers:     true,
+			err:         "flow: coordinator in turn 1: boom",
+		},
+		{
+			name:        "no workers",
+			coordinator: textRunner(answerJSON),
+			err:         "flow: a supervisor needs at least 1 worker",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var workers []Worker
+			if tt.workers {
+				workers = []Worker{
+					{Name: "researcher", Description: "finds facts", Runner: textRunner("facts")},
+					{Name: "writer", Runner: &fakeRunner{err: errors.New("boom")}},
+				}
+			}
+			result, err := NewSupervisor(tt.coordinator, workers, tt.opts...).Supervise(context.Background(), userPrompt("write a report"))
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Task != "write a report" {
+				t.Fatalf("expected the task text, got %q", result.Task)
+			}
+			if first := promptText(tt.coordinator.Prompts()[0]); !strings.Contains(first, "Task:\nwrite a report\n") {
+				t.Fatalf("expected the coordinator to see the task text, got %q", first)
+			}
+			if result.Answer != tt.answer {
+				t.Fatalf("expected the answer %q, got %q", tt.answer, result.Answer)
+			}
+			if !slices.Equal(result.Delegations, tt.delegations) {
+				t.Fatalf("expected the delegations %+v, got %+v", tt.delegations, result.Delegations)
+			}
+			if last := result.Decisions[len(result.Decisions)-1]; !last.Done {
+				t.Fatalf("expected the last decision to complete the task, got %+v", last)
+			}
+			if len(tt.delegations) > 0 {
+				second := promptText(tt.coordinator.Prompts()[1])
+				for _, want := range []string{"- researcher: finds facts", "researcher was asked: find", "It answered:\nfacts", "It failed: boom"} {
+					if !strings.Contains(second, want) {
+						t.Fatalf("expected the coordinator to see %q, got %q", want, second)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSupervisor_LastTurn(t *testing.T) {
+	coordinator := textRunner(delegateJSON, answerJSON)
+	workers := []Worker{{Name: "researcher", Runner: textRunner("facts")}, {Name: "writer", Runner: textRunner("text")}}
+	if _, err := NewSupervisor(coordinator, workers, SupervisorMaxTurns(2)).Supervise(context.Background(), userPrompt("write a report")); err != nil {
+		t.Fatal(err)
+	}
+	prompts := coordinator.Prompts()
+	if strings.Contains(promptText(prompts[0]), "last turn") || !strings.Contains(promptText(prompts[1]), "This is your last turn") {
+		t.Fatal("expected the coordinator to be told to answer at the last turn only")
+	}
+}
+
+func TestSupervisor_Run(t *testing.T) {
+	usage := &blades.Usage{TotalTokens: 3}
+	coordinator := &fakeRunner{replies: []string{delegateJSON, answerJSON}, usage: &blades.Usage{TotalTokens: 1}}
+	workers := []Worker{
+		{Name: "researcher", Runner: &fakeRunner{replies: []string{"facts"}, usage: usage}},
+		{Name: "writer", Runner: &fakeRunner{replies: []string{"text"}, usage: usage}},
+	}
+	g, err := NewSupervisor(coordinator, workers).Run(context.Background(), userPrompt("write a report"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Text() != "report" {
+		t.Fatalf("expected the answer, got %q", g.Text())
+	}
+	if g.Usage == nil || g.Usage.TotalTokens != 8 {
+		t.Fatalf("expected the usage of the coordinator and the workers, got %+v", g.Usage)
+	}
+}
+
+func TestSupervisor_RunStream(t *testing.T) {
+	usage := &blades.Usage{TotalTokens: 3}
+	coordinator := &fakeRunner{replies: []string{delegateJSON, answerJSON}, usage: &blades.Usage{TotalTokens: 1}}
+	workers := []Worker{
+		{Name: "researcher", Runner: &fakeRunner{replies: []string{"facts"}, usage: usage}},
+		{Name: "writer", Runner: &fakeRunner{replies: []string{"text"}, usage: usage}},
+	}
+	stream, err := NewSupervisor(coordinator, workers).RunStream(context.Background(), userPrompt("write a report"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gens, err := collect(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gens) != 4 {
+		t.Fatalf("expected 2 outputs, the answer and the finish, got %d generations", len(gens))
+	}
+	var outputs []string
+	for _, g := range gens[:2] {
+		outputs = append(outputs, g.Messages[0].Metadata[WorkerKey]+":"+g.Text())
+	}
+	slices.Sort(outputs)
+	if got := strings.Join(outputs, ","); got != "researcher:facts,writer:text" {
+		t.Fatalf("expected the outputs tagged by worker, got %q", got)
+	}
+	if gens[2].Text() != "report" {
+		t.Fatalf("expected the answer, got %q", gens[2].Text())
+	}
+	if finish := gens[3].Finish; finish == nil || finish.Usage == nil || finish.Usage.TotalTokens != 8 {
+		t.Fatalf("expected a finish with the usage of the coordinator and the workers, got %+v", finish)
+	}
+}
@@ -0,0 +1,113 @@
+package blades
+
+import (
+	"context"
+	"html"
+	"regexp"
+)
+
+// TextFormat is a format the text of responses must follow whatever the
+// provider, such as plain text for SMS or HTML-safe text for web pages.
+type TextFormat struct {
+	// Name identifies the format in warnings.
+	Name string
+	// Instruction tells the model how to format its responses.
+	Instruction string
+	// Sanitize rewrites text into the format.
+	Sanitize func(text string) string
+}
+
+var (
+	// PlainText is text without Markdown, e.g. for SMS or speech.
+	PlainText = TextFormat{
+		Name:        "plain",
+		Instruction: "Respond in plain text only: no Markdown, headings, bold, italics, code blocks or tables.",
+		Sanitize:    StripMarkdown,
+	}
+	// SafeHTML is text safe to embed in HTML pages, with HTML special characters escaped.
+	SafeHTML = TextFormat{
+		Name:        "html",
+		Instruction: "Respond in plain text only: no HTML tags, scripts or Markdown.",
+		Sanitize:    html.EscapeString,
+	}
+)
+
+var (
+	mdFence      = regexp.MustCompile("(?m)^[ \t]*(```|~~~).*$\n?")
+	mdRule       = regexp.MustCompile(`(?m)^[ \t]*([-*_][ \t]*){3,}$`)
+	mdHeading    = regexp.MustCompile(`(?m)^[ \t]{0,3}#{1,6}[ \t]+`)
+	mdQuote      = regexp.MustCompile(`(?m)^[ \t]{0,3}>[ \t]?`)
+	mdBullet     = regexp.MustCompile(`(?m)^([ \t]*)[*+][ \t]+`)
+	mdImage      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink       = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)[^)]*\)`)
+	mdStrong     = regexp.MustCompile(`\*\*|__|~~`)
+	mdEmphasis   = regexp.MustCompile(`(^|[^\w*])[*_]([^*_\n]+)[*_]([^\w*]|$)`)
+	mdInlineCode = regexp.MustCompile("`+")
+)
+
+// StripMarkdown removes the Markdown syntax of text, keeping its content: link
+// targets follow their text in parentheses and list items start with "- ".
+func StripMarkdown(text string) string {
+	text = mdFence.ReplaceAllString(text, "")
+	text = mdRule.ReplaceAllString(text, "")
+	text = mdHeading.ReplaceAllString(text, "")
+	text = mdQuote.ReplaceAllString(text, "")
+	text = mdBullet.ReplaceAllString(text, "$1- ")
+	text = mdImage.ReplaceAllString(text, "$1")
+	text = mdLink.ReplaceAllString(text, "$1 ($2)")
+	text = mdStrong.ReplaceAllString(text, "")
+	text = mdEmphasis.ReplaceAllString(text, "$1$2$3")
+	return mdInlineCode.ReplaceAllString(text, "")
+}
+
+// FormatOutput returns a middleware that instructs the model to answer in the
+// format and sanitizes the text of the answers into it, so that responses fit
+// their channel whatever the provider. Streamed chunks are sanitized one by
+// one, then completed messages as a whole. Run reports a WarningReformatted
+// when the answer did not follow the format.
+func FormatOutput(format TextFormat) Middleware {
+	return func(next Handler) Handler {
+		return Handler{
+			Run: func(ctx context.Context, prompt *Prompt, opts ...ModelOption) (*Generation, error) {
+				res, err := next.Run(ctx, withInstruction(prompt, format.Instruction), opts...)
+				if err != nil {
+					return nil, err
+				}
+				if sanitizeMessages(res.Messages, format) {
+					res.Warnings = append(res.Warnings, Warning{Code: WarningReformatted, Message: format.Name})
+				}
+				return res, nil
+			},
+			Stream: func(ctx context.Context, prompt *Prompt, opts ...ModelOption) (Streamer[*Generation], error) {
+				stream, err := next.Stream(ctx, withInstruction(prompt, format.Instruction), opts...)
+				if err != nil {
+					return nil, err
+				}
+				return NewMappedStream(stream, func(g *Generation) (*Generation, error) {
+					sanitizeMessages(g.Messages, format)
+					return g, nil
+				}), nil
+			},
+		}
+	}
+}
+
+// sanitizeMessages sanitizes the text parts of the assistant messages and
+// reports whether any changed.
+func sanitizeMessages(messages []*Message, format TextFormat) bool {
+	var changed bool
+	for _, msg := range messages {
+		if msg.Role != RoleAssistant {
+			continue
+		}
+		for i, part := range msg.Parts {
+			if text, ok := part.(TextPart); ok {
+				if sanitized := format.Sanitize(text.Text); sanitized != text.Text {
+					msg.Parts[i] = TextPart{Text: sanitized}
+					changed = true
+				}
+			}
+		}
+	}
+	return changed
+}
@@ -0,0 +1,40 @@
+package blades
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStripMarkdown(t *testing.T) {
+	in := "# Title\n\nSome **bold** and _italic_ text with `code`, a snake_case word and a [link](https://go.dev).\n\n* one\n* two\n\n```go\nfmt.Println(1)\n```\n"
+	want := "Title\n\nSome bold and italic text with code, a snake_case word and a link (https://go.dev).\n\n- one\n- two\n\nfmt.Println(1)\n"
+	if got := StripMarkdown(in); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestFormatOutput(t *testing.T) {
+	agent := NewAgent("test", WithProvider(textProvider("<b>**hi**</b>")), WithMiddleware(FormatOutput(SafeHTML)))
+	res, err := agent.Run(context.Background(), NewPrompt(UserMessage("hello")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Text(); got != "&lt;b&gt;**hi**&lt;/b&gt;" || !res.HasWarning(WarningReformatted) {
+		t.Fatalf("unexpected result %q with warnings %v", got, res.Warnings)
+	}
+
+	stream, err := NewAgent("test", WithProvider(textProvider("**hi**")), WithMiddleware(FormatOutput(PlainText))).
+		RunStream(context.Background(), NewPrompt(UserMessage("hello")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for stream.Next() {
+		g, err := stream.Current()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text := g.Text(); text != "" && text != "hi" {
+			t.Fatalf("unexpected streamed text %q", text)
+		}
+	}
+}
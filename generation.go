@@ -0,0 +1,83 @@
+package blades
+
+import (
+	"slices"
+	"sync"
+)
+
+// Merge appends the messages, tool trace, warnings and grounding of other to g and adds
+// its usage. Grounding support indexes of other are shifted past the sources of
+// g. Finish is not merged, as it describes a whole stream. Merge is not safe
+// for concurrent use; see GenerationAccumulator.
+func (g *Generation) Merge(other *Generation) {
+	if other == nil {
+		return
+	}
+	g.Messages = append(g.Messages, other.Messages...)
+	g.ToolTrace = append(g.ToolTrace, other.ToolTrace...)
+	g.Warnings = append(g.Warnings, other.Warnings...)
+	if other.Usage != nil {
+		if g.Usage == nil {
+			g.Usage = &Usage{}
+		}
+		g.Usage.Add(other.Usage)
+	}
+	if other.Grounding != nil {
+		if g.Grounding == nil {
+			g.Grounding = &Grounding{}
+		}
+		offset := len(g.Grounding.Sources)
+		g.Grounding.Queries = append(g.Grounding.Queries, other.Grounding.Queries...)
+		g.Grounding.Sources = append(g.Grounding.Sources, other.Grounding.Sources...)
+		for _, support := range other.Grounding.Supports {
+			sources := make([]int, len(support.Sources))
+			for i, source := range support.Sources {
+				sources[i] = source + offset
+			}
+			support.Sources = sources
+			g.Grounding.Supports = append(g.Grounding.Supports, support)
+		}
+	}
+}
+
+// GenerationAccumulator merges generations written from several goroutines,
+// e.g. by the branches of a parallel flow. Generations are merged in slot
+// order, and in the order they were added within a slot, so the result does
+// not depend on which goroutine finishes first.
+type GenerationAccumulator struct {
+	mu    sync.Mutex
+	slots map[int][]*Generation
+}
+
+// NewGenerationAccumulator creates a new GenerationAccumulator.
+func NewGenerationAccumulator() *GenerationAccumulator {
+	return &GenerationAccumulator{slots: make(map[int][]*Generation)}
+}
+
+// Add records a generation in the given slot. It is safe for concurrent use.
+func (a *GenerationAccumulator) Add(slot int, g *Generation) {
+	if g == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.slots[slot] = append(a.slots[slot], g)
+}
+
+// Generation returns the merge of the generations added so far, by slot.
+func (a *GenerationAccumulator) Generation() *Generation {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	keys := make([]int, 0, len(a.slots))
+	for slot := range a.slots {
+		keys = append(keys, slot)
+	}
+	slices.Sort(keys)
+	merged := &Generation{}
+	for _, slot := range keys {
+		for _, g := range a.slots[slot] {
+			merged.Merge(g)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,37 @@
+package blades
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestGenerationAccumulator(t *testing.T) {
+	acc := NewGenerationAccumulator()
+	var wg sync.WaitGroup
+	for i := 3; i >= 0; i-- {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			acc.Add(i, &Generation{
+				Messages:  []*Message{AssistantMessage(string(rune('a' + i)))},
+				Usage:     &Usage{PromptTokens: 1, CompletionTokens: 2, TotalTokens: 3},
+				Grounding: &Grounding{Sources: []GroundingSource{{URI: "u"}}, Supports: []GroundingSupport{{Sources: []int{0}}}},
+			})
+		}()
+	}
+	wg.Wait()
+	g := acc.Generation()
+	var text string
+	for _, msg := range g.Messages {
+		text += msg.Text()
+	}
+	if text != "abcd" {
+		t.Fatalf("messages out of slot order: %q", text)
+	}
+	if g.Usage.TotalTokens != 12 || g.Usage.PromptTokens != 4 {
+		t.Fatalf("unexpected usage: %+v", g.Usage)
+	}
+	if len(g.Grounding.Sources) != 4 || g.Grounding.Supports[3].Sources[0] != 3 {
+		t.Fatalf("unexpected grounding: %+v", g.Grounding)
+	}
+}
@@ -5,4 +5,9 @@ go 1.24
 require (
 	github.com/google/jsonschema-go v0.2.3
 	github.com/google/uuid v1.6.0
+	github.com/pkoukk/tiktoken-go v0.1.8
+	github.com/pkoukk/tiktoken-go-loader v0.0.2
+	golang.org/x/net v0.34.0
 )
+
+require github.com/dlclark/regexp2 v1.10.0 // indirect
@@ -0,0 +1,313 @@
+package blades
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+var (
+	// ErrGuardrailViolation indicates a guardrail rejected a prompt.
+	ErrGuardrailViolation = errors.New("guardrail violation")
+	// ErrOutputViolation indicates the response of a run failed validation.
+	ErrOutputViolation = errors.New("output violation")
+)
+
+// GuardrailViolation reports the guardrail that rejected a prompt and why.
+// It matches ErrGuardrailViolation with errors.Is.
+type GuardrailViolation struct {
+	// Guardrail names the guardrail, e.g. "max_input_length".
+	Guardrail string
+	// Reason describes the violation.
+	Reason string
+}
+
+// Error returns the error message.
+func (e *GuardrailViolation) Error() string {
+	return fmt.Sprintf("guardrail %s: %s", e.Guardrail, e.Reason)
+}
+
+// Is reports whether target is ErrGuardrailViolation.
+func (e *GuardrailViolation) Is(target error) bool {
+	return target == ErrGuardrailViolation
+}
+
+// Guardrail checks the prompt of a run before any provider call. It returns
+// the prompt to run, which may be a rewritten copy, or a *GuardrailViolation to
+// reject it. Guardrails must not modify the prompt they receive.
+type Guardrail interface {
+	CheckInput(ctx context.Context, prompt *Prompt) (*Prompt, error)
+}
+
+// GuardrailFunc adapts a function to a Guardrail.
+type GuardrailFunc func(ctx context.Context, prompt *Prompt) (*Prompt, error)
+
+// CheckInput calls f(ctx, prompt).
+func (f GuardrailFunc) CheckInput(ctx context.Context, prompt *Prompt) (*Prompt, error) {
+	return f(ctx, prompt)
+}
+
+// WithGuardrails checks the prompt of every run of the Agent with the
+// guardrails, in order, before the middleware and the provider see it.
+func WithGuardrails(guardrails ...Guardrail) Option {
+	return func(a *Agent) {
+		a.guardrails = guardrails
+	}
+}
+
+// checkInput runs the prompt through the guardrails of the Agent.
+func (a *Agent) checkInput(ctx context.Context, prompt *Prompt) (*Prompt, error) {
+	for _, g := range a.guardrails {
+		checked, err := g.CheckInput(ctx, prompt)
+		if err != nil {
+			return nil, err
+		}
+		if checked != nil {
+			prompt = checked
+		}
+	}
+	return prompt, nil
+}
+
+// userText returns the text of the user messages of the prompt.
+func userText(prompt *Prompt) string {
+	var texts []string
+	for _, msg := range prompt.Messages {
+		if msg.Role != RoleUser {
+			continue
+		}
+		for _, part := range msg.Parts {
+			if text, ok := part.(TextPart); ok {
+				texts = append(texts, text.Text)
+			}
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// MaxInputLength rejects prompts whose user messages exceed n characters.
+func MaxInputLength(n int) Guardrail {
+	return GuardrailFunc(func(ctx context.Context, prompt *Prompt) (*Prompt, error) {
+		if length := utf8.RuneCountInString(userText(prompt)); length > n {
+			return nil, &GuardrailViolation{
+				Guardrail: "max_input_length",
+				Reason:    fmt.Sprintf("input is %d characters, the limit is %d", length, n),
+			}
+		}
+		return prompt, nil
+	})
+}
+
+// BannedTopics rejects prompts whose user messages mention any of the topics,
+// matched as case-insensitive whole words or phrases.
+func BannedTopics(topics ...string) Guardrail {
+	patterns := make([]*regexp.Regexp, 0, len(topics))
+	for _, topic := range topics {
+		words := strings.Fields(regexp.QuoteMeta(topic))
+		if len(words) == 0 {
+			continue
+		}
+		patterns = append(patterns, regexp.MustCompile(`(?i)\b`+strings.Join(words, `\s+`)+`\b`))
+	}
+	return GuardrailFunc(func(ctx context.Context, prompt *Prompt) (*Prompt, error) {
+		text := userText(prompt)
+		for i, pattern := range patterns {
+			if pattern.MatchString(text) {
+				return nil, &GuardrailViolation{
+					Guardrail: "banned_topics",
+					Reason:    fmt.Sprintf("input mentions banned topic %q", topics[i]),
+				}
+			}
+		}
+		return prompt, nil
+	})
+}
+
+// injectionPatterns are phrases typical of prompt injection attempts.
+var injectionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(ignore|disregard|forget)\s+(all\s+|any\s+)?(the\s+)?(previous|prior|above|earlier|preceding)\s+(instructions|prompts|rules|messages|directions)`),
+	regexp.MustCompile(`(?i)\b(reveal|show|print|repeat|output)\s+(me\s+)?(your|the)\s+(system\s+prompt|instructions|initial\s+prompt|hidden\s+prompt)`),
+	regexp.MustCompile(`(?i)\byou\s+are\s+now\s+(in\s+)?(developer|dan|jailbreak|unrestricted)\b`),
+	regexp.MustCompile(`(?i)\b(new|updated)\s+system\s+(prompt|instructions)\s*:`),
+	regexp.MustCompile(`(?i)<\s*/?\s*(system|im_start|im_end)\s*>|\[/?INST\]`),
+}
+
+// PromptInjection rejects prompts whose user messages match common prompt
+// injection heuristics, such as asking to ignore previous instructions, to
+// reveal the system prompt or smuggling chat template markers. The heuristics
+// catch naive attempts only and are no substitute for least-privilege tools.
+func PromptInjection() Guardrail {
+	return GuardrailFunc(func(ctx context.Context, prompt *Prompt) (*Prompt, error) {
+		text := userText(prompt)
+		for _, pattern := range injectionPatterns {
+			if match := pattern.FindString(text); match != "" {
+				return nil, &GuardrailViolation{
+					Guardrail: "prompt_injection",
+					Reason:    fmt.Sprintf("input looks like a prompt injection: %q", match),
+				}
+			}
+		}
+		return prompt, nil
+	})
+}
+
+// OutputViolation reports the validation failure of the last response of a run
+// once the repair attempts are exhausted. It matches ErrOutputViolation with
+// errors.Is and unwraps to the error of the output guardrail.
+type OutputViolation struct {
+	// Attempts is the number of responses generated, repairs included.
+	Attempts int
+	// Output is the text of the last, invalid response.
+	Output string
+	Err    error
+}
+
+// Error returns the error message.
+func (e *OutputViolation) Error() string {
+	return fmt.Sprintf("invalid output after %d attempts: %v", e.Attempts, e.Err)
+}
+
+// Unwrap returns the error of the output guardrail.
+func (e *OutputViolation) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrOutputViolation.
+func (e *OutputViolation) Is(target error) bool {
+	return target == ErrOutputViolation
+}
+
+// OutputGuardrail validates the generation of a run. The error it returns is
+// sent back to the model to repair its response, so it should say what is wrong.
+type OutputGuardrail interface {
+	CheckOutput(ctx context.Context, generation *Generation) error
+}
+
+// OutputGuardrailFunc adapts a function to an OutputGuardrail.
+type OutputGuardrailFunc func(ctx context.Context, generation *Generation) error
+
+// CheckOutput calls f(ctx, generation).
+func (f OutputGuardrailFunc) CheckOutput(ctx context.Context, generation *Generation) error {
+	return f(ctx, generation)
+}
+
+// WithOutputGuardrails validates the generation of every run of the Agent with
+// the guardrails, in order. An invalid response of Run is sent back to the
+// model along with the validation error, up to retries times, before failing
+// with an *OutputViolation. Streamed runs are validated once complete and fail
+// without repair, as their output has already been delivered.
+func WithOutputGuardrails(retries int, guardrails ...OutputGuardrail) Option {
+	return func(a *Agent) {
+		a.repairs = retries
+		a.outputGuardrails = guardrails
+	}
+}
+
+// checkOutput runs the generation through the output guardrails of the Agent.
+func (a *Agent) checkOutput(ctx context.Context, generation *Generation) error {
+	for _, g := range a.outputGuardrails {
+		if err := g.CheckOutput(ctx, generation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runValidated runs the prompt with handler, repairing invalid responses. The
+// memory of the repairs is deferred, so that only the prompt and the valid
+// response are recorded.
+func (a *Agent) runValidated(ctx context.Context, handler Handler, prompt *Prompt, opts ...ModelOption) (*Generation, error) {
+	if len(a.outputGuardrails) == 0 {
+		return handler.Run(ctx, prompt, opts...)
+	}
+	ctx, memory := deferMemory(ctx, prompt)
+	p := prompt
+	for attempt := 0; ; attempt++ {
+		res, err := handler.Run(ctx, p, opts...)
+		if err != nil {
+			return nil, err
+		}
+		err = a.checkOutput(ctx, res)
+		if err == nil {
+			if err := memory.commit(); err != nil {
+				return nil, err
+			}
+			return res, nil
+		}
+		if attempt == a.repairs {
+			return nil, &OutputViolation{Attempts: attempt + 1, Output: res.Text(), Err: err}
+		}
+		messages := make([]*Message, 0, len(p.Messages)+2)
+		messages = append(messages, p.Messages...)
+		messages = append(messages,
+			AssistantMessage(res.Text()),
+			UserMessage(fmt.Sprintf("Your previous response is invalid: %v\nRespond again with the corrected response only.", err)),
+		)
+		p = &Prompt{ConversationID: prompt.ConversationID, Messages: messages}
+	}
+}
+
+// streamValidated validates the last completed generation of stream once it ends.
+func (a *Agent) streamValidated(ctx context.Context, stream Streamer[*Generation]) Streamer[*Generation] {
+	if len(a.outputGuardrails) == 0 {
+		return stream
+	}
+	pipe := NewStreamPipe[*Generation]()
+	pipe.Go(func() error {
+		var last *Generation
+		for stream.Next() {
+			g, err := stream.Current()
+			if err != nil {
+				drain(stream)
+				return err
+			}
+			if len(g.Messages) > 0 && g.Messages[len(g.Messages)-1].Status == StatusCompleted {
+				last = g
+			}
+			pipe.Send(g)
+		}
+		if err := stream.Close(); err != nil {
+			return err
+		}
+		if last == nil {
+			return nil
+		}
+		if err := a.checkOutput(ctx, last); err != nil {
+			return &OutputViolation{Attempts: 1, Output: last.Text(), Err: err}
+		}
+		return nil
+	})
+	return pipe
+}
+
+// MatchJSONSchema validates that the generation text is JSON, optionally in a
+// markdown code fence, conforming to schema.
+func MatchJSONSchema(schema *jsonschema.Schema) OutputGuardrail {
+	resolved, err := schema.Resolve(nil)
+	return OutputGuardrailFunc(func(ctx context.Context, generation *Generation) error {
+		if err != nil {
+			return err
+		}
+		var instance any
+		if err := json.Unmarshal([]byte(extractJSON(generation.Text())), &instance); err != nil {
+			return fmt.Errorf("response is not valid JSON: %w", err)
+		}
+		return resolved.Validate(instance)
+	})
+}
+
+// MatchRegexp validates that the generation text matches pattern.
+func MatchRegexp(pattern *regexp.Regexp) OutputGuardrail {
+	return OutputGuardrailFunc(func(ctx context.Context, generation *Generation) error {
+		if !pattern.MatchString(generation.Text()) {
+			return fmt.Errorf("response does not match the pattern %s", pattern)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,162 @@
+package blades
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+var (
+	// ErrMaxHandoffs indicates a conversation was handed off more than maxHandoffs times in a run.
+	ErrMaxHandoffs = errors.New("max handoffs exceeded")
+)
+
+// maxHandoffs bounds the handoffs of a run, so that peers handing the
+// conversation back and forth do not loop forever.
+const maxHandoffs = 8
+
+// handoffPrefix prefixes the names of the tools transferring to peer agents.
+const handoffPrefix = "transfer_to_"
+
+// WithDescription describes what the Agent does, for the agents delegating to
+// it with AgentTool or handing conversations off to it.
+func WithDescription(description string) Option {
+	return func(a *Agent) {
+		a.description = description
+	}
+}
+
+// WithHandoffs lets the Agent transfer the conversation to the peer agents. The
+// model is offered a transfer_to_<name> tool per peer; when it calls one, the
+// run of the Agent ends and the peer runs the prompt instead, with the options
+// of the run, and its generation is returned.
+func WithHandoffs(peers ...*Agent) Option {
+	return func(a *Agent) {
+		a.handoffs = peers
+	}
+}
+
+// AgentTool returns a tool delegating tasks to agent: the model writes the task
+// as the input of the tool, the agent runs it as a new conversation and its
+// answer is the result of the tool.
+func AgentTool(agent *Agent) *Tool {
+	description := agent.description
+	if description == "" {
+		description = fmt.Sprintf("Delegate a task to the %s agent.", agent.name)
+	}
+	return &Tool{
+		Name:        toolName(agent.name),
+		Description: description,
+		InputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"input": {Type: "string", Description: "The task for the agent, with all the context it needs."},
+			},
+			Required: []string{"input"},
+		},
+		Handle: func(ctx context.Context, arguments string) (string, error) {
+			var args struct {
+				Input string `json:"input"`
+			}
+			if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+				return "", fmt.Errorf("%w: %v", ErrInvalidArguments, err)
+			}
+			res, err := agent.Run(ctx, NewPrompt(UserMessage(args.Input)))
+			if err != nil {
+				return "", err
+			}
+			return res.Text(), nil
+		},
+	}
+}
+
+var invalidToolChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// toolName turns an agent name into a valid tool name.
+func toolName(name string) string {
+	return strings.Trim(invalidToolChars.ReplaceAllString(name, "_"), "_")
+}
+
+// handoffTools returns the tools transferring to the peers of the Agent.
+func (a *Agent) handoffTools() []*Tool {
+	tools := make([]*Tool, 0, len(a.handoffs))
+	for _, peer := range a.handoffs {
+		description := fmt.Sprintf("Transfer the conversation to the %s agent.", peer.name)
+		if peer.description != "" {
+			description += " " + peer.description
+		}
+		tools = append(tools, &Tool{
+			Name:        handoffPrefix + toolName(peer.name),
+			Description: description,
+			InputSchema: &jsonschema.Schema{Type: "object"},
+		})
+	}
+	return tools
+}
+
+// handoffTarget returns the peer the first handoff call transfers to, if any.
+func (a *Agent) handoffTarget(calls []*ToolCall) *Agent {
+	for _, call := range calls {
+		name, ok := strings.CutPrefix(call.Name, handoffPrefix)
+		if !ok {
+			continue
+		}
+		for _, peer := range a.handoffs {
+			if toolName(peer.name) == name {
+				return peer
+			}
+		}
+	}
+	return nil
+}
+
+type ctxHandoffsKey struct{}
+
+// handoffContext returns ctx counting one more handoff, or ErrMaxHandoffs.
+func handoffContext(ctx context.Context, to *Agent) (context.Context, error) {
+	n, _ := ctx.Value(ctxHandoffsKey{}).(int)
+	if n >= maxHandoffs {
+		return nil, fmt.Errorf("%w: to %s", ErrMaxHandoffs, to.name)
+	}
+	return context.WithValue(ctx, ctxHandoffsKey{}, n+1), nil
+}
+
+// handOff runs the prompt with the peer.
+func handOff(ctx context.Context, peer *Agent, prompt *Prompt, opts []ModelOption) (*Generation, error) {
+	ctx, err := handoffContext(ctx, peer)
+	if err != nil {
+		return nil, err
+	}
+	return peer.Run(ctx, prompt, opts...)
+}
+
+// handOffStream streams the prompt with the peer.
+func handOffStream(ctx context.Context, peer *Agent, prompt *Prompt, opts []ModelOption) (Streamer[*Generation], error) {
+	ctx, err := handoffContext(ctx, peer)
+	if err != nil {
+		return nil, err
+	}
+	return peer.RunStream(ctx, prompt, opts...)
+}
+
+// forwardHandoff forwards the stream of the peer to pipe.
+func forwardHandoff(pipe *StreamPipe[*Generation], peer *Agent, handoff func(*Agent) (Streamer[*Generation], error)) error {
+	stream, err := handoff(peer)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	for stream.Next() {
+		g, err := stream.Current()
+		if err != nil {
+			return err
+		}
+		pipe.Send(g)
+	}
+	return stream.Close()
+}
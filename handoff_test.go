@@ -0,0 +1,99 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// handoffProvider transfers to the first peer it is offered, or answers with
+// its name when it has no peers.
+func handoffProvider(name string) *fakeProvider {
+	return &fakeProvider{reply: func(req *ModelRequest, opts ModelOptions) *ModelResponse {
+		for _, tool := range req.Tools {
+			if strings.HasPrefix(tool.Name, handoffPrefix) {
+				return &ModelResponse{Messages: []*Message{{
+					Role:      RoleAssistant,
+					Status:    StatusCompleted,
+					ToolCalls: []*ToolCall{{ID: "call_1", Name: tool.Name, Arguments: `{}`}},
+				}}, Usage: &Usage{TotalTokens: 5}}
+			}
+		}
+		res := textResponse("answered by " + name)
+		res.Usage = &Usage{TotalTokens: 10}
+		return res
+	}}
+}
+
+func TestAgent_Handoff(t *testing.T) {
+	billing := NewAgent("billing", WithDescription("Handles invoices."), WithProvider(handoffProvider("billing")))
+	triage := NewAgent("triage", WithProvider(handoffProvider("triage")), WithHandoffs(billing))
+
+	res, err := triage.Run(context.Background(), NewPrompt(UserMessage("where is my invoice?")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Text(); got != "answered by billing" {
+		t.Fatalf("unexpected result %q", got)
+	}
+	if res.Usage == nil || res.Usage.TotalTokens != 15 {
+		t.Fatalf("expected usage of both agents, got %+v", res.Usage)
+	}
+
+	stream, err := triage.RunStream(context.Background(), NewPrompt(UserMessage("where is my invoice?")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var text string
+	for stream.Next() {
+		g, err := stream.Current()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.Finish == nil && len(g.Messages) > 0 {
+			text = g.Text()
+		}
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if text != "answered by billing" {
+		t.Fatalf("unexpected streamed result %q", text)
+	}
+
+	// The peer reports no usage, so the handoff carries the triage usage alone.
+	silent := &fakeProvider{reply: func(req *ModelRequest, opts ModelOptions) *ModelResponse {
+		return textResponse("answered by support")
+	}}
+	support := NewAgent("support", WithDescription("Handles accounts."), WithProvider(silent))
+	desk := NewAgent("desk", WithProvider(handoffProvider("desk")), WithHandoffs(support))
+	res, err = desk.Run(context.Background(), NewPrompt(UserMessage("reset my password")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Usage == nil || res.Usage.TotalTokens != 5 {
+		t.Fatalf("expected the usage of the handing off agent, got %+v", res.Usage)
+	}
+
+	loop := NewAgent("loop", WithProvider(handoffProvider("")))
+	loop.handoffs = []*Agent{NewAgent("billing", WithProvider(handoffProvider("")), WithHandoffs(loop))}
+	if _, err := loop.Run(context.Background(), NewPrompt(UserMessage("hi"))); !errors.Is(err, ErrMaxHandoffs) {
+		t.Fatalf("expected ErrMaxHandoffs, got %v", err)
+	}
+}
+
+func TestAgentTool(t *testing.T) {
+	weather := NewAgent("weather agent", WithProvider(weatherProvider()), WithTools(weatherTool()))
+	tool := AgentTool(weather)
+	if tool.Name != "weather_agent" {
+		t.Fatalf("unexpected tool name %q", tool.Name)
+	}
+	got, err := tool.Handle(context.Background(), `{"input":"weather in Paris?"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "weather is sunny" {
+		t.Fatalf("unexpected result %q", got)
+	}
+}
@@ -0,0 +1,188 @@
+package blades
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	_ ModelProvider = (*HedgeProvider)(nil)
+)
+
+// HedgeOption configures a HedgeProvider.
+type HedgeOption func(*HedgeProvider)
+
+// HedgeWith sends the duplicate request to contender instead of the primary.
+func HedgeWith(contender Contender) HedgeOption {
+	return func(p *HedgeProvider) {
+		p.hedge = contender
+	}
+}
+
+// HedgeProvider reduces tail latency by sending a duplicate request, to the
+// primary itself or to a secondary contender, when the primary has not answered
+// within a delay. Whichever completes first wins and the other is cancelled.
+// Streams are hedged on their first chunk. Responses carry the winner, "primary"
+// or "hedge", in the "hedge" message metadata.
+//
+// Set the delay around the p95 latency of the primary, so that only the slowest
+// requests are duplicated.
+type HedgeProvider struct {
+	primary Contender
+	hedge   Contender
+	delay   time.Duration
+}
+
+// NewHedgeProvider creates a HedgeProvider duplicating requests to primary that
+// take longer than delay.
+func NewHedgeProvider(primary Contender, delay time.Duration, opts ...HedgeOption) *HedgeProvider {
+	p := &HedgeProvider{primary: primary, hedge: primary, delay: delay}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+type hedgeResult struct {
+	name   string
+	res    *ModelResponse
+	stream Streamer[*ModelResponse]
+	err    error
+	cancel context.CancelFunc
+}
+
+// hedgeAttempt runs one attempt and returns its result.
+type hedgeAttempt func(ctx context.Context, c Contender) hedgeResult
+
+// race launches the primary attempt, then the hedged one after the delay, and
+// returns the first successful result. The attempts still pending are cancelled
+// and released in the background. When every attempt fails, the last error is
+// returned.
+func (p *HedgeProvider) race(ctx context.Context, attempt hedgeAttempt) (hedgeResult, error) {
+	var (
+		results = make(chan hedgeResult, 2)
+		cancels = make(map[string]context.CancelFunc, 2)
+	)
+	launch := func(name string, c Contender) {
+		actx, cancel := context.WithCancel(ctx)
+		cancels[name] = cancel
+		go func() {
+			r := attempt(actx, c)
+			r.name, r.cancel = name, cancel
+			results <- r
+		}()
+	}
+	release := func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+		go discardHedged(results, len(cancels))
+	}
+	launch("primary", p.primary)
+	timer := time.NewTimer(p.delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			launch("hedge", p.hedge)
+		case r := <-results:
+			delete(cancels, r.name)
+			if r.err == nil {
+				release()
+				return r, nil
+			}
+			r.cancel()
+			if len(cancels) == 0 {
+				return hedgeResult{}, r.err
+			}
+		case <-ctx.Done():
+			release()
+			return hedgeResult{}, ctx.Err()
+		}
+	}
+}
+
+// discardHedged releases the n attempts that lost the race.
+func discardHedged(results <-chan hedgeResult, n int) {
+	for range n {
+		r := <-results
+		r.cancel()
+		if r.stream != nil {
+			for r.stream.Next() {
+			}
+			r.stream.Close()
+		}
+	}
+}
+
+// Generate sends the request to the primary, and to the hedge once the delay
+// elapses, returning the first successful response.
+func (p *HedgeProvider) Generate(ctx context.Context, req *ModelRequest, opts ...ModelOption) (*ModelResponse, error) {
+	winner, err := p.race(ctx, func(ctx context.Context, c Contender) hedgeResult {
+		res, err := c.Provider.Generate(ctx, c.request(req), opts...)
+		return hedgeResult{res: res, err: err}
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer winner.cancel()
+	markHedge(winner.res, winner.name)
+	return winner.res, nil
+}
+
+// NewStream opens a stream on the primary, and on the hedge once the delay
+// elapses without a first chunk, forwarding the stream that produces its first
+// chunk first.
+func (p *HedgeProvider) NewStream(ctx context.Context, req *ModelRequest, opts ...ModelOption) (Streamer[*ModelResponse], error) {
+	pipe := NewStreamPipe[*ModelResponse]()
+	pipe.Go(func() error {
+		winner, err := p.race(ctx, func(ctx context.Context, c Contender) hedgeResult {
+			stream, err := c.Provider.NewStream(ctx, c.request(req), opts...)
+			if err != nil {
+				return hedgeResult{err: err}
+			}
+			if !stream.Next() {
+				if err := stream.Close(); err != nil {
+					return hedgeResult{err: err}
+				}
+				return hedgeResult{}
+			}
+			res, err := stream.Current()
+			if err != nil {
+				stream.Close()
+				return hedgeResult{err: err}
+			}
+			return hedgeResult{stream: stream, res: res}
+		})
+		if err != nil {
+			return err
+		}
+		defer winner.cancel()
+		stream := winner.stream
+		if stream == nil {
+			return nil
+		}
+		defer stream.Close()
+		markHedge(winner.res, winner.name)
+		pipe.Send(winner.res)
+		for stream.Next() {
+			res, err := stream.Current()
+			if err != nil {
+				return err
+			}
+			markHedge(res, winner.name)
+			pipe.Send(res)
+		}
+		return stream.Close()
+	})
+	return pipe, nil
+}
+
+func markHedge(res *ModelResponse, winner string) {
+	for _, msg := range res.Messages {
+		if msg.Metadata == nil {
+			msg.Metadata = make(map[string]string)
+		}
+		msg.Metadata["hedge"] = winner
+	}
+}
@@ -0,0 +1,53 @@
+package blades
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHedgeProvider(t *testing.T) {
+	req := &ModelRequest{Messages: []*Message{UserMessage("hi")}}
+	fast := &fakeProvider{replies: []string{"fast"}, lazy: true}
+	slow := &fakeProvider{replies: []string{"slow"}, lazy: true, delay: time.Second}
+	hedged := NewHedgeProvider(Contender{Provider: slow}, 10*time.Millisecond, HedgeWith(Contender{Provider: fast}))
+	res, err := hedged.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Messages[0].Text() != "fast" || res.Messages[0].Metadata["hedge"] != "hedge" {
+		t.Fatalf("expected the hedged response, got %q from %q", res.Messages[0].Text(), res.Messages[0].Metadata["hedge"])
+	}
+	stream, err := hedged.NewStream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var text string
+	for stream.Next() {
+		res, err := stream.Current()
+		if err != nil {
+			t.Fatal(err)
+		}
+		text += res.Messages[0].Text()
+	}
+	if text != "fast" {
+		t.Fatalf("expected the hedged stream, got %q", text)
+	}
+	deadline := time.Now().Add(time.Second / 2)
+	for slow.Canceled() != 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := slow.Canceled(); got != 2 {
+		t.Fatalf("expected both slow calls to be cancelled, got %d", got)
+	}
+
+	primary := &fakeProvider{replies: []string{"primary"}, lazy: true}
+	backup := &fakeProvider{replies: []string{"backup"}, lazy: true}
+	res, err = NewHedgeProvider(Contender{Provider: primary}, time.Second, HedgeWith(Contender{Provider: backup})).Generate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Messages[0].Metadata["hedge"] != "primary" || backup.Calls() != 0 {
+		t.Fatalf("expected no hedged request for a fast primary, got %d", backup.Calls())
+	}
+}
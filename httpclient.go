@@ -0,0 +1,70 @@
+package blades
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HTTPClientOption configures a client created by NewHTTPClient.
+type HTTPClientOption func(*httpClientOptions)
+
+type httpClientOptions struct {
+	timeout         time.Duration
+	maxConnsPerHost int
+}
+
+// HTTPTimeout bounds each request, including reading the response body, so it
+// also bounds streams. There is no timeout by default.
+func HTTPTimeout(timeout time.Duration) HTTPClientOption {
+	return func(o *httpClientOptions) {
+		o.timeout = timeout
+	}
+}
+
+// HTTPMaxConnsPerHost limits the connections opened to each host, e.g. to stay
+// within the concurrency limit of an API. Clients with a limit use their own
+// connection pool instead of the shared one.
+func HTTPMaxConnsPerHost(n int) HTTPClientOption {
+	return func(o *httpClientOptions) {
+		o.maxConnsPerHost = n
+	}
+}
+
+// SharedTransport returns the transport shared by the providers of blades.
+// Unlike http.DefaultTransport, which keeps 2 idle connections per host, it
+// keeps enough idle connections to serve concurrent requests to a model API
+// without reconnecting, as servers running many agents do.
+var SharedTransport = sync.OnceValue(func() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          256,
+		MaxIdleConnsPerHost:   64,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+})
+
+// NewHTTPClient returns a client sending requests over SharedTransport. Clients
+// are safe for concurrent use and should be shared rather than created per
+// request.
+func NewHTTPClient(opts ...HTTPClientOption) *http.Client {
+	var o httpClientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	transport := SharedTransport()
+	if o.maxConnsPerHost > 0 {
+		transport = transport.Clone()
+		transport.MaxConnsPerHost = o.maxConnsPerHost
+		transport.MaxIdleConnsPerHost = min(transport.MaxIdleConnsPerHost, o.maxConnsPerHost)
+	}
+	return &http.Client{Transport: transport, Timeout: o.timeout}
+}
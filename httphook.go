@@ -0,0 +1,129 @@
+package blades
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+type ctxHeadersKey struct{}
+
+// ContextWithHeaders returns a new context carrying HTTP headers that
+// HTTPHooks set on the provider requests made with it, e.g. tracing headers
+// or per-tenant routing keys.
+func ContextWithHeaders(ctx context.Context, header http.Header) context.Context {
+	if prev, ok := ctx.Value(ctxHeadersKey{}).(http.Header); ok {
+		merged := prev.Clone()
+		for key, values := range header {
+			merged[key] = values
+		}
+		header = merged
+	}
+	return context.WithValue(ctx, ctxHeadersKey{}, header)
+}
+
+// HTTPHooks customize the HTTP requests of providers, e.g. to go through an
+// enterprise API gateway such as LiteLLM, Portkey or Cloudflare AI Gateway.
+// Install them with Transport or Client, or with the hook option of a provider.
+type HTTPHooks struct {
+	// Headers are set on every request, before the headers of the context.
+	Headers http.Header
+	// Request is called with each request and its raw body before it is sent.
+	// It may set headers and returns the body to send, which may be mutated.
+	Request func(req *http.Request, body []byte) ([]byte, error)
+	// Response is called with each response and its raw body once the body has
+	// been read, so streamed responses are inspected after the stream ends.
+	Response func(res *http.Response, body []byte)
+}
+
+// Do applies the hooks to req, sending it with next.
+func (h *HTTPHooks) Do(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for key, values := range h.Headers {
+		req.Header[key] = values
+	}
+	if header, ok := req.Context().Value(ctxHeadersKey{}).(http.Header); ok {
+		for key, values := range header {
+			req.Header[key] = values
+		}
+	}
+	if h.Request != nil {
+		var body []byte
+		if req.Body != nil {
+			var err error
+			body, err = io.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+		body, err := h.Request(req, body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+	res, err := next(req)
+	if err != nil || h.Response == nil {
+		return res, err
+	}
+	res.Body = &hookedBody{body: res.Body, done: func(body []byte) { h.Response(res, body) }}
+	return res, nil
+}
+
+// Transport returns a RoundTripper applying the hooks to the requests of base,
+// or of http.DefaultTransport when base is nil.
+func (h *HTTPHooks) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return h.Do(req, base.RoundTrip)
+	})
+}
+
+// Client returns a copy of client, or of a default client when nil, applying
+// the hooks to its requests.
+func (h *HTTPHooks) Client(client *http.Client) *http.Client {
+	hooked := &http.Client{}
+	if client != nil {
+		*hooked = *client
+	}
+	hooked.Transport = h.Transport(hooked.Transport)
+	return hooked
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// hookedBody records a response body as it is read, passing it to done at EOF
+// or on Close.
+type hookedBody struct {
+	body io.ReadCloser
+	buf  bytes.Buffer
+	once sync.Once
+	done func([]byte)
+}
+
+func (b *hookedBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.buf.Write(p[:n])
+	if err == io.EOF {
+		b.once.Do(func() { b.done(b.buf.Bytes()) })
+	}
+	return n, err
+}
+
+func (b *hookedBody) Close() error {
+	b.once.Do(func() { b.done(b.buf.Bytes()) })
+	return b.body.Close()
+}
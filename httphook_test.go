@@ -0,0 +1,44 @@
+package blades
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPHooks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(r.Header.Get("X-Org") + "," + r.Header.Get("X-Trace") + "," + string(body)))
+	}))
+	defer server.Close()
+
+	var inspected string
+	hooks := &HTTPHooks{
+		Headers: http.Header{"X-Org": {"acme"}},
+		Request: func(req *http.Request, body []byte) ([]byte, error) {
+			return []byte(strings.ToUpper(string(body))), nil
+		},
+		Response: func(res *http.Response, body []byte) {
+			inspected = string(body)
+		},
+	}
+	client := hooks.Client(nil)
+	ctx := ContextWithHeaders(context.Background(), http.Header{"X-Trace": {"t1"}})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, strings.NewReader("hello"))
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if string(body) != "acme,t1,HELLO" {
+		t.Fatalf("unexpected body: %q", body)
+	}
+	if inspected != string(body) {
+		t.Fatalf("response hook saw %q", inspected)
+	}
+}
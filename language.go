@@ -0,0 +1,213 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+var (
+	// ErrWrongLanguage indicates the model kept answering in another language.
+	ErrWrongLanguage = errors.New("response is not in the expected language")
+)
+
+// languageNames maps the languages recognized by DetectLanguage to their names.
+var languageNames = map[string]string{
+	"en": "English", "es": "Spanish", "fr": "French", "de": "German",
+	"it": "Italian", "pt": "Portuguese", "nl": "Dutch", "zh": "Chinese",
+	"ja": "Japanese", "ko": "Korean", "ru": "Russian", "uk": "Ukrainian",
+	"ar": "Arabic", "he": "Hebrew", "el": "Greek", "hi": "Hindi", "th": "Thai",
+}
+
+// stopwords are frequent words that identify Latin-script languages.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "of", "to", "in", "that", "it", "you", "what", "with", "for", "this", "how"},
+	"es": {"el", "la", "los", "las", "de", "que", "y", "es", "en", "por", "para", "con", "una", "qué", "cómo"},
+	"fr": {"le", "la", "les", "de", "des", "et", "est", "que", "une", "pour", "dans", "vous", "pas", "je", "quel", "il", "à", "au", "du", "ce", "qui", "sur", "fait", "comment"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ich", "sie", "mit", "ein", "eine", "zu", "wie", "auf", "was"},
+	"it": {"il", "la", "di", "che", "e", "è", "non", "per", "una", "sono", "gli", "come", "con", "del", "cosa"},
+	"pt": {"o", "a", "os", "de", "que", "e", "é", "não", "para", "com", "uma", "do", "da", "em", "você"},
+	"nl": {"de", "het", "een", "en", "is", "van", "niet", "ik", "dat", "je", "op", "met", "voor", "zijn", "wat"},
+}
+
+// DetectLanguage returns the ISO 639-1 code of the language of text, or an empty
+// string if it cannot be determined. Non-Latin languages are recognized by their
+// script and Latin-script languages by their most frequent words.
+func DetectLanguage(text string) string {
+	scripts := make(map[string]int)
+	var latin int
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			scripts["ja"]++
+		case unicode.Is(unicode.Han, r):
+			scripts["zh"]++
+		case unicode.Is(unicode.Hangul, r):
+			scripts["ko"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			if strings.ContainsRune("іїєґІЇЄҐ", r) {
+				scripts["uk"] += 10
+			}
+			scripts["ru"]++
+		case unicode.Is(unicode.Arabic, r):
+			scripts["ar"]++
+		case unicode.Is(unicode.Hebrew, r):
+			scripts["he"]++
+		case unicode.Is(unicode.Greek, r):
+			scripts["el"]++
+		case unicode.Is(unicode.Devanagari, r):
+			scripts["hi"]++
+		case unicode.Is(unicode.Thai, r):
+			scripts["th"]++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+	// Kana mixed with Han is Japanese.
+	if scripts["ja"] > 0 {
+		scripts["ja"] += scripts["zh"]
+	}
+	if scripts["uk"] > 0 {
+		scripts["uk"] += scripts["ru"]
+	}
+	best, count := "", 0
+	for lang, n := range scripts {
+		if n > count || (n == count && lang < best) {
+			best, count = lang, n
+		}
+	}
+	if count > latin {
+		return best
+	}
+	return detectLatin(text)
+}
+
+func detectLatin(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	scores := make(map[string]int)
+	for _, word := range words {
+		for lang, list := range stopwords {
+			for _, stop := range list {
+				if word == stop {
+					scores[lang]++
+					break
+				}
+			}
+		}
+	}
+	best, score := "", 0
+	for lang, n := range scores {
+		if n > score || (n == score && lang < best) {
+			best, score = lang, n
+		}
+	}
+	// a single common word is not enough evidence
+	if score < 2 && len(words) > 2 {
+		return ""
+	}
+	return best
+}
+
+// LanguageOption configures LanguageGuard.
+type LanguageOption func(*languageGuard)
+
+// LanguageTarget enforces a fixed language instead of the language of the user.
+func LanguageTarget(code string) LanguageOption {
+	return func(g *languageGuard) {
+		g.target = code
+	}
+}
+
+// LanguageRetries sets how many times a response in the wrong language is retried.
+func LanguageRetries(n int) LanguageOption {
+	return func(g *languageGuard) {
+		g.retries = n
+	}
+}
+
+type languageGuard struct {
+	target  string
+	retries int
+}
+
+// LanguageGuard returns a middleware that instructs the model to answer in the
+// language of the user, or a configured one. Responses of Run detected to be in
+// another language are retried with a correction, and ErrWrongLanguage is
+// returned once the retries are exhausted. Streamed responses are instructed only.
+func LanguageGuard(opts ...LanguageOption) Middleware {
+	g := &languageGuard{retries: 1}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return func(next Handler) Handler {
+		return Handler{
+			Run: func(ctx context.Context, prompt *Prompt, opts ...ModelOption) (*Generation, error) {
+				lang := g.language(prompt)
+				if lang == "" {
+					return next.Run(ctx, prompt, opts...)
+				}
+				// Only the prompt and the final answer are recorded to memory.
+				ctx, memory := deferMemory(ctx, prompt)
+				p := withInstruction(prompt, fmt.Sprintf("Always respond in %s.", languageName(lang)))
+				for attempt := 0; ; attempt++ {
+					res, err := next.Run(ctx, p, opts...)
+					if err != nil {
+						return nil, err
+					}
+					got := DetectLanguage(res.Text())
+					if got == "" || got == lang {
+						if err := memory.commit(); err != nil {
+							return nil, err
+						}
+						return res, nil
+					}
+					if attempt == g.retries {
+						return nil, fmt.Errorf("%w: expected %s, got %s", ErrWrongLanguage, lang, got)
+					}
+					p = withInstruction(p, fmt.Sprintf("Your previous answer was in %s. Answer again in %s only.", languageName(got), languageName(lang)))
+				}
+			},
+			Stream: func(ctx context.Context, prompt *Prompt, opts ...ModelOption) (Streamer[*Generation], error) {
+				if lang := g.language(prompt); lang != "" {
+					prompt = withInstruction(prompt, fmt.Sprintf("Always respond in %s.", languageName(lang)))
+				}
+				return next.Stream(ctx, prompt, opts...)
+			},
+		}
+	}
+}
+
+// language returns the configured language or the language of the last user message.
+func (g *languageGuard) language(prompt *Prompt) string {
+	if g.target != "" {
+		return g.target
+	}
+	for i := len(prompt.Messages) - 1; i >= 0; i-- {
+		if msg := prompt.Messages[i]; msg.Role == RoleUser {
+			if lang := DetectLanguage(msg.Text()); languageNames[lang] != "" {
+				return lang
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+func languageName(code string) string {
+	if name, ok := languageNames[code]; ok {
+		return name
+	}
+	return code
+}
+
+// withInstruction returns a copy of prompt with a trailing system instruction.
+func withInstruction(prompt *Prompt, instruction string) *Prompt {
+	messages := make([]*Message, 0, len(prompt.Messages)+1)
+	messages = append(messages, prompt.Messages...)
+	messages = append(messages, SystemMessage(instruction))
+	return &Prompt{ConversationID: prompt.ConversationID, Messages: messages}
+}
@@ -0,0 +1,108 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := map[string]string{
+		"What is the weather like in Paris today?":      "en",
+		"¿Qué tiempo hace hoy en la ciudad de Madrid?":  "es",
+		"Quel temps fait-il aujourd'hui dans la ville?": "fr",
+		"Wie ist das Wetter heute in der Stadt?":        "de",
+		"今天北京的天气怎么样？":                                   "zh",
+		"今日の東京の天気はどうですか？":                               "ja",
+		"Какая сегодня погода в Москве?":                "ru",
+		"42": "",
+	}
+	for text, want := range tests {
+		if got := DetectLanguage(text); got != want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestLanguageGuard(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []LanguageOption
+		question string
+		replies  []string
+		wantErr  error
+		wantRuns int
+	}{
+		{
+			name:     "retries in the language of the question",
+			question: "Quel temps fait-il aujourd'hui à Paris?",
+			replies:  []string{"The weather is sunny and warm.", "Il fait beau et chaud dans la ville."},
+			wantRuns: 2,
+		},
+		{
+			name:     "fails when the target language is never used",
+			opts:     []LanguageOption{LanguageTarget("de")},
+			question: "How is the weather?",
+			replies:  []string{"The weather is sunny.", "The weather is still sunny."},
+			wantErr:  ErrWrongLanguage,
+			wantRuns: 2,
+		},
+		{
+			name:     "retries up to the configured times",
+			opts:     []LanguageOption{LanguageRetries(2)},
+			question: "Quel temps fait-il aujourd'hui à Paris?",
+			replies:  []string{"The weather is sunny.", "The weather is still sunny.", "Il fait beau et chaud dans la ville."},
+			wantRuns: 3,
+		},
+		{
+			name:     "passes through without a detected language",
+			question: "42?",
+			replies:  []string{"The answer is 42."},
+			wantRuns: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{replies: tt.replies}
+			handler := LanguageGuard(tt.opts...)(Handler{Run: runner.Run})
+			res, err := handler.Run(context.Background(), NewPrompt(UserMessage(tt.question)))
+			if !errors.Is(err, tt.wantErr) || len(runner.prompts) != tt.wantRuns {
+				t.Fatalf("got %v after %d runs, want %v after %d", err, len(runner.prompts), tt.wantErr, tt.wantRuns)
+			}
+			if lang := DetectLanguage(tt.question); err == nil && lang != "" && DetectLanguage(res.Text()) != lang {
+				t.Fatalf("unexpected answer language %q", res.Text())
+			}
+		})
+	}
+}
+
+func TestLanguageGuard_Memory(t *testing.T) {
+	mem := &fakeMemory{}
+	provider := textProvider("The weather is sunny.", "Il fait beau et chaud dans la ville.")
+	agent := NewAgent("test", WithProvider(provider), WithMemory(mem), WithMiddleware(LanguageGuard()))
+	if _, err := agent.Run(context.Background(), NewPrompt(UserMessage("Quel temps fait-il aujourd'hui à Paris?"))); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Quel temps fait-il aujourd'hui à Paris?", "Il fait beau et chaud dans la ville."}
+	if got := mem.texts(""); !slices.Equal(got, want) {
+		t.Fatalf("memory = %q, want %q", got, want)
+	}
+}
+
+func TestLanguageGuard_Stream(t *testing.T) {
+	provider := textProvider("Il fait beau.")
+	agent := NewAgent("test", WithProvider(provider), WithMiddleware(LanguageGuard()))
+	stream, err := agent.RunStream(context.Background(), NewPrompt(UserMessage("Quel temps fait-il aujourd'hui à Paris?")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := drain(stream); err != nil {
+		t.Fatal(err)
+	}
+	messages := provider.lastRequest().Messages
+	if last := messages[len(messages)-1]; last.Role != RoleSystem || !strings.Contains(last.Text(), "French") {
+		t.Fatalf("expected the language instruction, got %v", last)
+	}
+}
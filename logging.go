@@ -0,0 +1,154 @@
+package blades
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+var (
+	_ ModelProvider = (*LoggingProvider)(nil)
+)
+
+type ctxRunIDKey struct{}
+
+// NewRunID generates a new run identifier, a random UUID unless another
+// generator is set with SetIDGenerator.
+func NewRunID() string {
+	return NewID()
+}
+
+// ContextWithRunID returns a new context carrying the run ID, so that the log
+// records of every step and call of a run can be correlated.
+func ContextWithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, ctxRunIDKey{}, runID)
+}
+
+// RunIDFromContext returns the run ID of the context, if any.
+func RunIDFromContext(ctx context.Context) (string, bool) {
+	runID, ok := ctx.Value(ctxRunIDKey{}).(string)
+	return runID, ok
+}
+
+// ensureRunID returns ctx with a new run ID unless it already carries one.
+func ensureRunID(ctx context.Context) (context.Context, string) {
+	if runID, ok := RunIDFromContext(ctx); ok {
+		return ctx, runID
+	}
+	runID := NewRunID()
+	return ContextWithRunID(ctx, runID), runID
+}
+
+// WithLogger logs the provider calls and tool calls of the Agent as structured
+// records carrying the run ID, which is generated per run unless the context
+// already carries one.
+func WithLogger(logger *slog.Logger) Option {
+	return func(a *Agent) {
+		a.logger = logger
+	}
+}
+
+// LoggingProvider logs every call of a provider with its model, duration,
+// usage and error.
+type LoggingProvider struct {
+	provider ModelProvider
+	logger   *slog.Logger
+}
+
+// NewLoggingProvider wraps provider, logging its calls to logger.
+func NewLoggingProvider(provider ModelProvider, logger *slog.Logger) *LoggingProvider {
+	return &LoggingProvider{provider: provider, logger: logger}
+}
+
+// Generate executes the request and logs it.
+func (p *LoggingProvider) Generate(ctx context.Context, req *ModelRequest, opts ...ModelOption) (*ModelResponse, error) {
+	start := Now()
+	res, err := p.provider.Generate(ctx, req, opts...)
+	var usage *Usage
+	if res != nil {
+		usage = res.Usage
+	}
+	p.log(ctx, req, false, start, usage, err)
+	return res, err
+}
+
+// NewStream executes the streaming request and logs it once the stream ends.
+func (p *LoggingProvider) NewStream(ctx context.Context, req *ModelRequest, opts ...ModelOption) (Streamer[*ModelResponse], error) {
+	start := Now()
+	stream, err := p.provider.NewStream(ctx, req, opts...)
+	if err != nil {
+		p.log(ctx, req, true, start, nil, err)
+		return nil, err
+	}
+	pipe := NewStreamPipe[*ModelResponse]()
+	pipe.Go(func() error {
+		var (
+			err   error
+			usage *Usage
+		)
+		for stream.Next() {
+			var res *ModelResponse
+			if res, err = stream.Current(); err != nil {
+				break
+			}
+			if res.Usage != nil {
+				usage = res.Usage
+			}
+			pipe.Send(res)
+		}
+		if cerr := stream.Close(); err == nil {
+			err = cerr
+		}
+		p.log(ctx, req, true, start, usage, err)
+		return err
+	})
+	return pipe, nil
+}
+
+func (p *LoggingProvider) log(ctx context.Context, req *ModelRequest, stream bool, start time.Time, usage *Usage, err error) {
+	attrs := append(runAttrs(ctx),
+		slog.String("model", req.Model),
+		slog.Bool("stream", stream),
+		slog.Int("messages", len(req.Messages)),
+		slog.Duration("duration", Since(start)),
+	)
+	if usage != nil {
+		attrs = append(attrs, slog.Int64("prompt_tokens", usage.PromptTokens), slog.Int64("completion_tokens", usage.CompletionTokens))
+	}
+	if err != nil {
+		p.logger.LogAttrs(ctx, slog.LevelError, "provider call failed", append(attrs, slog.Any("error", err))...)
+		return
+	}
+	p.logger.LogAttrs(ctx, slog.LevelInfo, "provider call", attrs...)
+}
+
+// logToolCalls logs the tool invocations of a run.
+func logToolCalls(ctx context.Context, logger *slog.Logger, trace []*ToolInvocation) {
+	if logger == nil {
+		return
+	}
+	for _, inv := range trace {
+		attrs := append(runAttrs(ctx),
+			slog.String("tool", inv.Name),
+			slog.String("call_id", inv.ID),
+			slog.Duration("duration", inv.Duration),
+		)
+		if inv.Error != "" {
+			logger.LogAttrs(ctx, slog.LevelWarn, "tool call failed", append(attrs, slog.String("error", inv.Error))...)
+			continue
+		}
+		logger.LogAttrs(ctx, slog.LevelInfo, "tool call", attrs...)
+	}
+}
+
+// runAttrs returns the run ID and agent of the context as log attributes.
+func runAttrs(ctx context.Context) []slog.Attr {
+	var attrs []slog.Attr
+	if runID, ok := RunIDFromContext(ctx); ok {
+		attrs = append(attrs, slog.String("run_id", runID))
+	}
+	if agent, ok := FromContext(ctx); ok && agent.Name != "" {
+		attrs = append(attrs, slog.String("agent", agent.Name))
+	}
+	return attrs
+}
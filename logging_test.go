@@ -0,0 +1,33 @@
+package blades
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestAgent_Logger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	agent := NewAgent("weather", WithProvider(weatherProvider()), WithTools(weatherTool()), WithLogger(logger))
+	ctx := ContextWithRunID(context.Background(), "run-1")
+	if _, err := agent.Run(ctx, NewPrompt(UserMessage("weather in Paris?"))); err != nil {
+		t.Fatal(err)
+	}
+	var messages []string
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var record map[string]any
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatal(err)
+		}
+		if record["run_id"] != "run-1" || record["agent"] != "weather" {
+			t.Fatalf("record without run attributes: %s", line)
+		}
+		messages = append(messages, record["msg"].(string))
+	}
+	if len(messages) != 3 || messages[0] != "provider call" || messages[1] != "tool call" || messages[2] != "provider call" {
+		t.Fatalf("unexpected records %v", messages)
+	}
+}
@@ -8,3 +8,59 @@ type Memory interface {
 	ListMessages(context.Context, string) ([]*Message, error)
 	Clear(context.Context, string) error
 }
+
+type ctxMemoryKey struct{}
+
+// deferredMemory holds back what the runs of an agent add to memory while they
+// may be retried with corrections, so that the turn is recorded once, with the
+// original prompt and the messages of the final run.
+type deferredMemory struct {
+	agent  *AgentContext
+	prompt *Prompt
+	record func(prompt *Prompt) error
+	// runs defers the memory of the agent runs made by caller instead, each
+	// of them claiming the deferral when it starts.
+	runs   bool
+	caller *AgentContext
+}
+
+// deferMemory returns ctx deferring the memory of the runs of the current
+// agent until commit is called. It returns a nil deferredMemory, whose commit
+// does nothing, when an enclosing run already defers it.
+func deferMemory(ctx context.Context, prompt *Prompt) (context.Context, *deferredMemory) {
+	agent, _ := FromContext(ctx)
+	if d, ok := ctx.Value(ctxMemoryKey{}).(*deferredMemory); ok && d.agent == agent {
+		return ctx, nil
+	}
+	d := &deferredMemory{agent: agent, prompt: prompt}
+	return context.WithValue(ctx, ctxMemoryKey{}, d), d
+}
+
+// deferRunMemory returns ctx deferring the memory of the agent runs made with it
+// until commit is called, so that a caller running an agent several times for
+// one turn records it once.
+func deferRunMemory(ctx context.Context, prompt *Prompt) (context.Context, *deferredMemory) {
+	caller, _ := FromContext(ctx)
+	d := &deferredMemory{prompt: prompt, runs: true, caller: caller}
+	return context.WithValue(ctx, ctxMemoryKey{}, d), d
+}
+
+// claimMemory makes the run of agent, started with ctx, the one whose memory is
+// deferred when ctx defers the memory of the runs made by its caller.
+func claimMemory(ctx context.Context, agent *AgentContext) {
+	d, ok := ctx.Value(ctxMemoryKey{}).(*deferredMemory)
+	if !ok || !d.runs {
+		return
+	}
+	if caller, _ := FromContext(ctx); caller == d.caller {
+		d.agent = agent
+	}
+}
+
+// commit records the last deferred run with the original prompt.
+func (d *deferredMemory) commit() error {
+	if d == nil || d.record == nil {
+		return nil
+	}
+	return d.record(d.prompt)
+}
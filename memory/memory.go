@@ -2,6 +2,7 @@ package memory
 
 import (
 	"context"
+	"maps"
 	"sync"
 
 	"github.com/go-kratos/blades"
@@ -14,6 +15,7 @@ type InMemory struct {
 	mu          sync.RWMutex
 	maxMessages int
 	store       map[string][]*blades.Message
+	metadata    map[string]map[string]string
 }
 
 // NewInMemory creates a new in-memory storage. If maxMessages > 0,
@@ -21,6 +23,7 @@ type InMemory struct {
 func NewInMemory(maxMessages int) *InMemory {
 	return &InMemory{
 		store:       make(map[string][]*blades.Message),
+		metadata:    make(map[string]map[string]string),
 		maxMessages: maxMessages,
 	}
 }
@@ -54,5 +57,24 @@ func (m *InMemory) Clear(ctx context.Context, id string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	delete(m.store, id)
+	delete(m.metadata, id)
 	return nil
 }
+
+// SetMetadata merges md into the metadata of the conversation.
+func (m *InMemory) SetMetadata(ctx context.Context, id string, md map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.metadata[id] == nil {
+		m.metadata[id] = make(map[string]string, len(md))
+	}
+	maps.Copy(m.metadata[id], md)
+	return nil
+}
+
+// Metadata returns a copy of the metadata of the conversation.
+func (m *InMemory) Metadata(ctx context.Context, id string) (map[string]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return maps.Clone(m.metadata[id]), nil
+}
@@ -1,6 +1,7 @@
 package memory
 
 import (
+	"cmp"
 	"context"
 	"testing"
 
@@ -50,3 +51,59 @@ func TestInMemory_Unlimited(t *testing.T) {
 		t.Fatalf("expected 4, got %d", len(msgs))
 	}
 }
+
+type summarizer struct {
+	calls int
+	reply string
+}
+
+func (s *summarizer) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	s.calls++
+	return &blades.Generation{Messages: []*blades.Message{blades.AssistantMessage(cmp.Or(s.reply, "short"))}}, nil
+}
+
+func (s *summarizer) RunStream(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (blades.Streamer[*blades.Generation], error) {
+	return nil, nil
+}
+
+func TestSummarizing(t *testing.T) {
+	ctx := context.Background()
+	s := &summarizer{}
+	mem := NewSummarizing(NewInMemory(0), s, 10, WithKeepRecent(2))
+	_ = mem.AddMessages(ctx, "A", []*blades.Message{blades.UserMessage("hello there")})
+	if s.calls != 0 {
+		t.Fatalf("summarized within budget")
+	}
+	for _, text := range []string{"first long message", "second long message", "third long message"} {
+		if err := mem.AddMessages(ctx, "A", []*blades.Message{blades.UserMessage(text)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	msgs, _ := mem.ListMessages(ctx, "A")
+	if !isSummary(msgs[0]) || msgs[0].Role != blades.RoleSystem {
+		t.Fatalf("expected a summary first, got %v", msgs[0])
+	}
+	if got := msgs[len(msgs)-1].Text(); got != "third long message" {
+		t.Fatalf("latest message not kept: %q", got)
+	}
+	if len(msgs) != 3 {
+		t.Fatalf("expected summary and 2 recent messages, got %d", len(msgs))
+	}
+}
+
+func TestTitler(t *testing.T) {
+	ctx := context.Background()
+	mem := NewInMemory(0)
+	titler := NewTitler(&summarizer{reply: "**Title:** Paris trip planning\nSummary: The user plans a weekend in Paris."})
+	if err := titler.Update(ctx, mem, mem, "A"); err != nil {
+		t.Fatal(err)
+	}
+	_ = mem.AddMessages(ctx, "A", []*blades.Message{blades.UserMessage("help me plan a weekend in Paris")})
+	if err := titler.Update(ctx, mem, mem, "A"); err != nil {
+		t.Fatal(err)
+	}
+	md, _ := mem.Metadata(ctx, "A")
+	if md[TitleKey] != "Paris trip planning" || md[SummaryKey] != "The user plans a weekend in Paris." {
+		t.Fatalf("unexpected metadata %v", md)
+	}
+}
@@ -0,0 +1,156 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-kratos/blades"
+)
+
+var (
+	_ blades.Memory = (*Summarizing)(nil)
+)
+
+// SummaryKey is the message metadata key marking summary messages.
+const SummaryKey = "summary"
+
+// SummaryOption configures a Summarizing memory.
+type SummaryOption func(*Summarizing)
+
+// WithTokenCounter sets the function counting the tokens of a message text,
+// which estimates 4 bytes per token by default.
+func WithTokenCounter(count func(text string) int) SummaryOption {
+	return func(m *Summarizing) {
+		m.count = count
+	}
+}
+
+// WithKeepRecent sets how many of the latest messages are kept verbatim when
+// summarizing, 4 by default.
+func WithKeepRecent(n int) SummaryOption {
+	return func(m *Summarizing) {
+		m.keepRecent = n
+	}
+}
+
+// WithSummaryPrompt sets the instruction given to the summarizer before the
+// transcript of the messages to summarize.
+func WithSummaryPrompt(prompt string) SummaryOption {
+	return func(m *Summarizing) {
+		m.prompt = prompt
+	}
+}
+
+// Summarizing is a Memory keeping long-running conversations within a token
+// budget. When the history of a conversation exceeds the budget after adding
+// messages, the older messages are summarized by the summarizer runner and
+// replaced with a single system message holding the summary, followed by the
+// latest messages verbatim. Earlier summaries are summarized again with the
+// messages that follow them.
+type Summarizing struct {
+	mu         sync.Mutex
+	store      blades.Memory
+	summarizer blades.Runner
+	budget     int
+	keepRecent int
+	prompt     string
+	count      func(text string) int
+}
+
+// NewSummarizing wraps store, summarizing conversations longer than budget
+// tokens with summarizer.
+func NewSummarizing(store blades.Memory, summarizer blades.Runner, budget int, opts ...SummaryOption) *Summarizing {
+	m := &Summarizing{
+		store:      store,
+		summarizer: summarizer,
+		budget:     budget,
+		keepRecent: 4,
+		prompt:     "Summarize the following conversation concisely, keeping the facts, decisions and open questions needed to continue it.",
+		count:      func(text string) int { return (len(text) + 3) / 4 },
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// AddMessages appends messages to the conversation and summarizes it when it
+// exceeds the token budget. A summarizer error is returned after the messages
+// are stored, leaving the history unsummarized.
+func (m *Summarizing) AddMessages(ctx context.Context, id string, msgs []*blades.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.store.AddMessages(ctx, id, msgs); err != nil {
+		return err
+	}
+	history, err := m.store.ListMessages(ctx, id)
+	if err != nil {
+		return err
+	}
+	if m.tokens(history) <= m.budget {
+		return nil
+	}
+	cut := len(history) - m.keepRecent
+	// Keep tool results together with the assistant message calling the tool.
+	for cut > 0 && cut < len(history) && history[cut].Role == blades.RoleTool {
+		cut--
+	}
+	if cut <= 1 && (cut <= 0 || isSummary(history[0])) {
+		return nil
+	}
+	summary, err := m.summarize(ctx, history[:cut])
+	if err != nil {
+		return fmt.Errorf("memory: summarize conversation %s: %w", id, err)
+	}
+	if err := m.store.Clear(ctx, id); err != nil {
+		return err
+	}
+	return m.store.AddMessages(ctx, id, append([]*blades.Message{summary}, history[cut:]...))
+}
+
+// ListMessages returns the messages of the conversation, starting with its
+// summary when it has been summarized.
+func (m *Summarizing) ListMessages(ctx context.Context, id string) ([]*blades.Message, error) {
+	return m.store.ListMessages(ctx, id)
+}
+
+// Clear removes all messages of the conversation.
+func (m *Summarizing) Clear(ctx context.Context, id string) error {
+	return m.store.Clear(ctx, id)
+}
+
+func (m *Summarizing) tokens(msgs []*blades.Message) int {
+	var n int
+	for _, msg := range msgs {
+		n += m.count(msg.Text())
+	}
+	return n
+}
+
+func (m *Summarizing) summarize(ctx context.Context, msgs []*blades.Message) (*blades.Message, error) {
+	var buf strings.Builder
+	buf.WriteString(m.prompt)
+	buf.WriteString("\n\n")
+	for _, msg := range msgs {
+		if isSummary(msg) {
+			fmt.Fprintf(&buf, "Summary of the earlier conversation: %s\n", msg.Text())
+			continue
+		}
+		if text := msg.Text(); text != "" {
+			fmt.Fprintf(&buf, "%s: %s\n", msg.Role, text)
+		}
+	}
+	res, err := m.summarizer.Run(ctx, blades.NewPrompt(blades.UserMessage(buf.String())))
+	if err != nil {
+		return nil, err
+	}
+	summary := blades.SystemMessage("Summary of the earlier conversation:\n" + res.Text())
+	summary.Metadata = map[string]string{SummaryKey: "true"}
+	return summary, nil
+}
+
+func isSummary(msg *blades.Message) bool {
+	return msg.Metadata[SummaryKey] == "true"
+}
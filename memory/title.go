@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-kratos/blades"
+)
+
+var (
+	_ MetadataStore = (*InMemory)(nil)
+)
+
+// TitleKey is the conversation metadata key holding its title; the summary is
+// held under SummaryKey.
+const TitleKey = "title"
+
+// MetadataStore stores metadata of conversations alongside their messages.
+type MetadataStore interface {
+	// SetMetadata merges md into the metadata of the conversation.
+	SetMetadata(ctx context.Context, id string, md map[string]string) error
+	// Metadata returns the metadata of the conversation.
+	Metadata(ctx context.Context, id string) (map[string]string, error)
+}
+
+// TitleOption configures a Titler.
+type TitleOption func(*Titler)
+
+// WithTitlePrompt sets the instruction given to the model before the
+// transcript. The answer must hold a "Title:" line and a "Summary:" line.
+func WithTitlePrompt(prompt string) TitleOption {
+	return func(t *Titler) {
+		t.prompt = prompt
+	}
+}
+
+// WithMaxTitleLength truncates titles to n characters, 60 by default.
+func WithMaxTitleLength(n int) TitleOption {
+	return func(t *Titler) {
+		t.maxTitle = n
+	}
+}
+
+// Titler generates the short title and summary of a conversation shown in
+// chat UI lists, typically with a cheap model.
+type Titler struct {
+	runner   blades.Runner
+	prompt   string
+	maxTitle int
+}
+
+// NewTitler creates a Titler asking runner for titles and summaries.
+func NewTitler(runner blades.Runner, opts ...TitleOption) *Titler {
+	t := &Titler{
+		runner:   runner,
+		prompt:   "Give the following conversation a short title of at most six words and a one or two sentence summary. Answer with exactly two lines:\nTitle: <title>\nSummary: <summary>",
+		maxTitle: 60,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Describe returns the title and summary of the messages.
+func (t *Titler) Describe(ctx context.Context, msgs []*blades.Message) (title, summary string, err error) {
+	var buf strings.Builder
+	buf.WriteString(t.prompt)
+	buf.WriteString("\n\n")
+	for _, msg := range msgs {
+		if msg.Role == blades.RoleTool || isSummary(msg) {
+			continue
+		}
+		if text := msg.Text(); text != "" {
+			fmt.Fprintf(&buf, "%s: %s\n", msg.Role, text)
+		}
+	}
+	res, err := t.runner.Run(ctx, blades.NewPrompt(blades.UserMessage(buf.String())))
+	if err != nil {
+		return "", "", err
+	}
+	for line := range strings.Lines(res.Text()) {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, "\"* \t\r\n")
+		switch strings.ToLower(strings.Trim(strings.TrimSpace(key), "*#")) {
+		case "title":
+			title = value
+		case "summary":
+			summary = value
+		}
+	}
+	if title == "" {
+		return "", "", fmt.Errorf("memory: no title in %q", res.Text())
+	}
+	if runes := []rune(title); len(runes) > t.maxTitle {
+		title = strings.TrimSpace(string(runes[:t.maxTitle])) + "…"
+	}
+	return title, summary, nil
+}
+
+// Update describes the conversation stored in store and saves its title and
+// summary in the metadata of the conversation under TitleKey and SummaryKey.
+func (t *Titler) Update(ctx context.Context, store blades.Memory, metadata MetadataStore, id string) error {
+	msgs, err := store.ListMessages(ctx, id)
+	if err != nil {
+		return err
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	title, summary, err := t.Describe(ctx, msgs)
+	if err != nil {
+		return fmt.Errorf("memory: describe conversation %s: %w", id, err)
+	}
+	return metadata.SetMetadata(ctx, id, map[string]string{TitleKey: title, SummaryKey: summary})
+}
@@ -2,9 +2,8 @@ package blades
 
 import (
 	"fmt"
+	"slices"
 	"strings"
-
-	"github.com/google/uuid"
 )
 
 // Role indicates the author of a message in a conversation.
@@ -15,12 +14,43 @@ const (
 	RoleUser Role = "user"
 	// RoleSystem provides system-level instructions.
 	RoleSystem Role = "system"
+	// RoleDeveloper provides instructions from the application developer, which
+	// reasoning models follow in place of system messages. Providers without a
+	// developer role send these messages as system messages.
+	RoleDeveloper Role = "developer"
 	// RoleAssistant is the model output.
 	RoleAssistant Role = "assistant"
 	// RoleTool indicates a message generated by a tool.
 	RoleTool Role = "tool"
 )
 
+// IsInstruction reports whether the role is the system or the developer role,
+// whose messages instruct the model rather than take part in the conversation.
+func (r Role) IsInstruction() bool {
+	return r == RoleSystem || r == RoleDeveloper
+}
+
+// MapRole returns the messages with the role from replaced by to, for providers
+// that lack a role. The messages with the role are copied, not modified.
+func MapRole(messages []*Message, from, to Role) []*Message {
+	var mapped []*Message
+	for i, msg := range messages {
+		if msg.Role != from {
+			continue
+		}
+		if mapped == nil {
+			mapped = slices.Clone(messages)
+		}
+		c := *msg
+		c.Role = to
+		mapped[i] = &c
+	}
+	if mapped == nil {
+		return messages
+	}
+	return mapped
+}
+
 // Status indicates the state of a message.
 type Status string
 
@@ -139,6 +169,11 @@ func SystemMessage[T contentPart](parts ...T) *Message {
 	return &Message{ID: NewMessageID(), Role: RoleSystem, Parts: Parts(parts...)}
 }
 
+// DeveloperMessage creates a developer-authored message from parts.
+func DeveloperMessage[T contentPart](parts ...T) *Message {
+	return &Message{ID: NewMessageID(), Role: RoleDeveloper, Parts: Parts(parts...)}
+}
+
 // AssistantMessage creates an assistant-authored message from parts.
 func AssistantMessage[T contentPart](parts ...T) *Message {
 	return &Message{ID: NewMessageID(), Role: RoleAssistant, Parts: Parts(parts...)}
@@ -163,7 +198,8 @@ func Parts[T contentPart](inputs ...T) []Part {
 	return parts
 }
 
-// NewMessageID generates a new random message identifier.
+// NewMessageID generates a new message identifier, a random UUID unless
+// another generator is set with SetIDGenerator.
 func NewMessageID() string {
-	return uuid.NewString()
+	return NewID()
 }
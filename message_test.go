@@ -0,0 +1,28 @@
+package blades
+
+import "testing"
+
+func TestMapRole(t *testing.T) {
+	prompt, err := NewPromptTemplate().
+		System("be brief").
+		Developer("answer in {{.lang}}", map[string]any{"lang": "French"}).
+		User("hello").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev := prompt.Messages[1]
+	if dev.Role != RoleDeveloper || dev.Text() != "answer in French" || !dev.Role.IsInstruction() {
+		t.Fatalf("unexpected developer message %+v", dev)
+	}
+	mapped := MapRole(prompt.Messages, RoleDeveloper, RoleSystem)
+	if mapped[1].Role != RoleSystem || mapped[1].Text() != "answer in French" {
+		t.Fatalf("expected the developer message mapped to system, got %+v", mapped[1])
+	}
+	if dev.Role != RoleDeveloper || mapped[0] != prompt.Messages[0] {
+		t.Fatal("expected only the mapped message to be copied")
+	}
+	if same := MapRole(prompt.Messages, RoleTool, RoleUser); &same[0] != &prompt.Messages[0] {
+		t.Fatal("expected messages without the role to be returned as is")
+	}
+}
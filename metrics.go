@@ -0,0 +1,267 @@
+package blades
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	_ ModelProvider         = (*MeteredProvider)(nil)
+	_ StreamMetricsRecorder = (*StreamStats)(nil)
+)
+
+// StreamMetrics holds the latency and throughput of one streamed response.
+type StreamMetrics struct {
+	Provider         string
+	Model            string
+	TimeToFirstToken time.Duration
+	Duration         time.Duration
+	OutputTokens     int
+	TokensPerSecond  float64
+	Err              error
+}
+
+// StreamMetricsRecorder receives the metrics of every finished stream.
+type StreamMetricsRecorder interface {
+	RecordStream(ctx context.Context, m StreamMetrics)
+}
+
+// CallMetrics describes one model call, streamed or not.
+type CallMetrics struct {
+	Provider string
+	Model    string
+	Stream   bool
+	Duration time.Duration
+	// Usage is the token usage reported by the provider, if any.
+	Usage *Usage
+	// Cost is the price of Usage, when pricing is configured.
+	Cost float64
+	Err  error
+}
+
+// CallMetricsRecorder receives the metrics of every finished model call. A
+// recorder passed to NewMeteredProvider that implements it receives them in
+// addition to the stream metrics.
+type CallMetricsRecorder interface {
+	RecordCall(ctx context.Context, m CallMetrics)
+}
+
+// MeterOption configures a MeteredProvider.
+type MeterOption func(*MeteredProvider)
+
+// MeterPricing prices the usage reported in call metrics.
+func MeterPricing(pricing Pricing) MeterOption {
+	return func(p *MeteredProvider) {
+		p.pricing = pricing
+	}
+}
+
+// MeteredProvider measures time-to-first-token and output tokens per second of
+// streaming responses. The metrics are passed to the recorder and added to the
+// metadata of completed messages as "ttft_ms" and "tokens_per_second".
+type MeteredProvider struct {
+	name     string
+	provider ModelProvider
+	recorder StreamMetricsRecorder
+	pricing  Pricing
+}
+
+// NewMeteredProvider wraps provider, reporting its metrics under name.
+// The recorder may be nil when only the message metadata is needed.
+func NewMeteredProvider(name string, provider ModelProvider, recorder StreamMetricsRecorder, opts ...MeterOption) *MeteredProvider {
+	p := &MeteredProvider{name: name, provider: provider, recorder: recorder}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Generate executes the request and records its call metrics.
+func (p *MeteredProvider) Generate(ctx context.Context, req *ModelRequest, opts ...ModelOption) (*ModelResponse, error) {
+	start := Now()
+	res, err := p.provider.Generate(ctx, req, opts...)
+	var usage *Usage
+	if res != nil {
+		usage = res.Usage
+	}
+	p.recordCall(ctx, CallMetrics{Model: req.Model, Usage: usage, Err: err}, start)
+	return res, err
+}
+
+// NewStream executes the streaming request and measures it.
+func (p *MeteredProvider) NewStream(ctx context.Context, req *ModelRequest, opts ...ModelOption) (Streamer[*ModelResponse], error) {
+	start := Now()
+	stream, err := p.provider.NewStream(ctx, req, opts...)
+	if err != nil {
+		p.recordCall(ctx, CallMetrics{Model: req.Model, Stream: true, Err: err}, start)
+		return nil, err
+	}
+	pipe := NewStreamPipe[*ModelResponse]()
+	pipe.Go(func() error {
+		m := StreamMetrics{Provider: p.name, Model: req.Model}
+		var (
+			first     time.Time
+			generated int
+			usage     *Usage
+		)
+		fail := func(err error) error {
+			m.Err = err
+			p.record(ctx, m, start)
+			p.recordCall(ctx, CallMetrics{Model: req.Model, Stream: true, Usage: usage, Err: err}, start)
+			return err
+		}
+		for stream.Next() {
+			res, err := stream.Current()
+			if err != nil {
+				stream.Close()
+				return fail(err)
+			}
+			if res.Usage != nil {
+				usage = res.Usage
+			}
+			var completed []*Message
+			for _, msg := range res.Messages {
+				if msg.Status == StatusCompleted {
+					completed = append(completed, msg)
+					continue
+				}
+				if text := msg.Text(); text != "" {
+					if first.IsZero() {
+						first = Now()
+						m.TimeToFirstToken = first.Sub(start)
+					}
+					generated += len(text)
+				}
+			}
+			if len(completed) > 0 {
+				if first.IsZero() {
+					// the provider does not stream deltas
+					first = Now()
+					m.TimeToFirstToken = first.Sub(start)
+				}
+				for _, msg := range completed {
+					generated = max(generated, len(msg.Text()))
+				}
+				m.OutputTokens = estimateTokens(generated)
+				m.TokensPerSecond = tokensPerSecond(m.OutputTokens, first, start)
+				for _, msg := range completed {
+					if msg.Metadata == nil {
+						msg.Metadata = make(map[string]string)
+					}
+					msg.Metadata["ttft_ms"] = strconv.FormatInt(m.TimeToFirstToken.Milliseconds(), 10)
+					msg.Metadata["tokens_per_second"] = strconv.FormatFloat(m.TokensPerSecond, 'f', 1, 64)
+				}
+			}
+			pipe.Send(res)
+		}
+		// Failures after the last chunk are reported when the stream is closed.
+		if err := stream.Close(); err != nil {
+			return fail(err)
+		}
+		if m.OutputTokens == 0 {
+			m.OutputTokens = estimateTokens(generated)
+		}
+		p.record(ctx, m, start)
+		p.recordCall(ctx, CallMetrics{Model: req.Model, Stream: true, Usage: usage}, start)
+		return nil
+	})
+	return pipe, nil
+}
+
+func (p *MeteredProvider) record(ctx context.Context, m StreamMetrics, start time.Time) {
+	if p.recorder == nil {
+		return
+	}
+	m.Duration = Since(start)
+	p.recorder.RecordStream(ctx, m)
+}
+
+func (p *MeteredProvider) recordCall(ctx context.Context, m CallMetrics, start time.Time) {
+	recorder, ok := p.recorder.(CallMetricsRecorder)
+	if !ok {
+		return
+	}
+	m.Provider = p.name
+	m.Duration = Since(start)
+	if p.pricing != nil && m.Usage != nil {
+		m.Cost = p.pricing.Cost(m.Model, m.Usage)
+	}
+	recorder.RecordCall(ctx, m)
+}
+
+// minRateWindow is the shortest time throughput is measured over, below which
+// the rate would be dominated by the resolution of the clock.
+const minRateWindow = 10 * time.Millisecond
+
+// tokensPerSecond returns the rate of tokens generated since first, or since
+// start when the response arrived at once, as from providers that do not
+// stream deltas. It is 0 when too little time has passed to measure it.
+func tokensPerSecond(tokens int, first, start time.Time) float64 {
+	elapsed := Since(first)
+	if elapsed < minRateWindow {
+		elapsed = Since(start)
+	}
+	if elapsed < minRateWindow {
+		return 0
+	}
+	return float64(tokens) / elapsed.Seconds()
+}
+
+// estimateTokens approximates the number of tokens of n bytes of text.
+func estimateTokens(n int) int {
+	return (n + 3) / 4
+}
+
+// StreamSummary aggregates the stream metrics of a provider.
+type StreamSummary struct {
+	Streams              int
+	Errors               int
+	MeanTimeToFirstToken time.Duration
+	MaxTimeToFirstToken  time.Duration
+	MeanTokensPerSecond  float64
+}
+
+// StreamStats is an in-memory StreamMetricsRecorder aggregating metrics per
+// provider for comparisons and capacity planning.
+type StreamStats struct {
+	mu        sync.Mutex
+	summaries map[string]*StreamSummary
+}
+
+// NewStreamStats creates an empty StreamStats.
+func NewStreamStats() *StreamStats {
+	return &StreamStats{summaries: make(map[string]*StreamSummary)}
+}
+
+// RecordStream adds m to the summary of its provider.
+func (s *StreamStats) RecordStream(ctx context.Context, m StreamMetrics) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sum, ok := s.summaries[m.Provider]
+	if !ok {
+		sum = &StreamSummary{}
+		s.summaries[m.Provider] = sum
+	}
+	if m.Err != nil {
+		sum.Errors++
+		return
+	}
+	n := time.Duration(sum.Streams)
+	sum.MeanTimeToFirstToken = (sum.MeanTimeToFirstToken*n + m.TimeToFirstToken) / (n + 1)
+	sum.MeanTokensPerSecond = (sum.MeanTokensPerSecond*float64(sum.Streams) + m.TokensPerSecond) / float64(sum.Streams+1)
+	sum.MaxTimeToFirstToken = max(sum.MaxTimeToFirstToken, m.TimeToFirstToken)
+	sum.Streams++
+}
+
+// Summaries returns a copy of the summaries keyed by provider name.
+func (s *StreamStats) Summaries() map[string]StreamSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]StreamSummary, len(s.summaries))
+	for name, sum := range s.summaries {
+		out[name] = *sum
+	}
+	return out
+}
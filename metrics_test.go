@@ -0,0 +1,77 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// callRecorder is a StreamStats also recording the call metrics.
+type callRecorder struct {
+	*StreamStats
+	mu    sync.Mutex
+	calls []CallMetrics
+}
+
+func (r *callRecorder) RecordCall(ctx context.Context, m CallMetrics) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, m)
+}
+
+func TestMeteredProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		trailing error
+	}{
+		{"completes", nil},
+		{"fails after the last chunk", errors.New("connection reset")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &callRecorder{StreamStats: NewStreamStats()}
+			backend := &fakeProvider{replies: []string{"Canberra is the capital."}, trailing: tt.trailing}
+			stream, err := NewMeteredProvider("static", backend, recorder).NewStream(context.Background(), &ModelRequest{Model: "m"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var last *ModelResponse
+			for stream.Next() {
+				res, err := stream.Current()
+				if err != nil {
+					break
+				}
+				last = res
+			}
+			if err := stream.Close(); !errors.Is(err, tt.trailing) {
+				t.Fatalf("Close() = %v, want %v", err, tt.trailing)
+			}
+			if tt.trailing == nil {
+				if _, ok := last.Messages[0].Metadata["ttft_ms"]; !ok {
+					t.Fatalf("expected ttft_ms metadata, got %v", last.Messages[0].Metadata)
+				}
+			}
+			wantErrors := 0
+			if tt.trailing != nil {
+				wantErrors = 1
+			}
+			if sum := recorder.Summaries()["static"]; sum.Streams != 1-wantErrors || sum.Errors != wantErrors {
+				t.Fatalf("unexpected summary %+v", sum)
+			}
+			if len(recorder.calls) != 1 || !errors.Is(recorder.calls[0].Err, tt.trailing) {
+				t.Fatalf("unexpected call metrics %+v", recorder.calls)
+			}
+		})
+	}
+}
+
+func TestTokensPerSecond(t *testing.T) {
+	start := Now()
+	if got := tokensPerSecond(100, Now(), start); got != 0 {
+		t.Fatalf("expected no rate for an instant response, got %v", got)
+	}
+	if got := tokensPerSecond(100, start.Add(-minRateWindow*10), start.Add(-minRateWindow*20)); got <= 0 || got > 1000 {
+		t.Fatalf("unexpected rate %v", got)
+	}
+}
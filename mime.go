@@ -1,6 +1,11 @@
 package blades
 
-import "strings"
+import (
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
 
 // MimeType represents the media type of content.
 type MimeType string
@@ -49,3 +54,20 @@ func (m MimeType) Format() string {
 	}
 	return "octet-stream"
 }
+
+// DetectMimeType returns the media type of a file from its name extension or,
+// failing that, by sniffing its content. It returns application/octet-stream
+// when neither is conclusive.
+func DetectMimeType(name string, data []byte) MimeType {
+	if ext := path.Ext(name); ext != "" {
+		if t := mime.TypeByExtension(ext); t != "" {
+			media, _, _ := strings.Cut(t, ";")
+			return MimeType(media)
+		}
+	}
+	if len(data) == 0 {
+		return "application/octet-stream"
+	}
+	media, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	return MimeType(media)
+}
@@ -2,8 +2,59 @@ package blades
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+var (
+	// ErrUnsupported indicates a provider cannot honor a model option.
+	ErrUnsupported = errors.New("unsupported model option")
 )
 
+// UnsupportedError reports the model option a provider cannot honor.
+// It matches ErrUnsupported with errors.Is.
+type UnsupportedError struct {
+	Provider string
+	Option   string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s: %s is not supported", e.Provider, e.Option)
+}
+
+// Is reports whether target is ErrUnsupported.
+func (e *UnsupportedError) Is(target error) bool {
+	return target == ErrUnsupported
+}
+
+// ProviderError reports a failed provider call with its HTTP status code and
+// the delay requested by a Retry-After header, if any.
+type ProviderError struct {
+	Provider   string
+	StatusCode int
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s: status %d: %v", e.Provider, e.StatusCode, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// Transient reports whether the call may succeed when retried, i.e. the
+// provider was rate limited or failed with a server error.
+func (e *ProviderError) Transient() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusRequestTimeout || e.StatusCode >= 500
+}
+
 // ModelOption configures a single request. Providers may ignore options
 // they do not support but should prefer best-effort behavior.
 type ModelOption func(*ModelOptions)
@@ -12,11 +63,34 @@ type ModelOption func(*ModelOptions)
 type ModelOptions struct {
 	MaxIterations   int
 	MaxOutputTokens int64
-	Temperature     float64
+	// Temperature is nil unless set, so that a temperature of 0 can be sent.
+	Temperature     *float64
 	TopP            float64
+	TopK            int
+	StopSequences   []string
+	SafetySettings  []SafetySetting
 	ReasoningEffort string
 	Image           ImageOptions
 	Audio           AudioOptions
+	Routing         RoutingOptions
+	Cache           CacheOptions
+	ResponseFormat  ResponseFormat
+	Grounding       GroundingOptions
+}
+
+// GroundingOptions enables provider-native grounding tools.
+type GroundingOptions struct {
+	// WebSearch grounds responses in web search results, e.g. Google Search.
+	WebSearch bool
+	// URLContext lets the model read the pages linked in the prompt.
+	URLContext bool
+}
+
+// SafetySetting sets the threshold at which a provider blocks content of a harm
+// category, e.g. {Category: "HARM_CATEGORY_HARASSMENT", Threshold: "BLOCK_ONLY_HIGH"}.
+type SafetySetting struct {
+	Category  string
+	Threshold string
 }
 
 // ImageOptions holds configuration for image generation requests.
@@ -43,6 +117,66 @@ type AudioOptions struct {
 	Speed          float64
 }
 
+// RoutingOptions holds preferences for gateways that route requests across
+// upstream vendors (e.g. OpenRouter). Providers without routing ignore them.
+type RoutingOptions struct {
+	// Models lists fallback models tried in order when the requested model fails.
+	Models []string
+	// Providers lists upstream vendors to try, in order of preference.
+	Providers []string
+	// IgnoreProviders lists upstream vendors that must not serve the request.
+	IgnoreProviders []string
+	// AllowFallbacks permits vendors outside Providers when all of them fail.
+	AllowFallbacks *bool
+	// Sort ranks candidate vendors, e.g. by "price", "throughput" or "latency".
+	Sort string
+}
+
+// CacheOptions holds hints for provider-side prompt (prefix) caching.
+// Providers without prompt caching ignore them.
+type CacheOptions struct {
+	// Key groups requests that share a prompt prefix so they hit the same cache.
+	Key string
+	// Prefix is the number of leading request messages unchanged since the
+	// previous request with the same Key; providers with explicit cache
+	// breakpoints mark the end of this prefix as cacheable.
+	Prefix int
+}
+
+// ResponseFormatType identifies the format of the model output.
+type ResponseFormatType string
+
+const (
+	// ResponseFormatText is free-form text, the default.
+	ResponseFormatText ResponseFormatType = "text"
+	// ResponseFormatJSONObject is any valid JSON object.
+	ResponseFormatJSONObject ResponseFormatType = "json_object"
+	// ResponseFormatJSONSchema is JSON conforming to a schema.
+	ResponseFormatJSONSchema ResponseFormatType = "json_schema"
+)
+
+// ResponseFormat requests a format for the model output, which providers
+// translate to their native response format settings.
+type ResponseFormat struct {
+	Type ResponseFormatType
+	// Name identifies the schema for providers that require one.
+	Name string
+	// Schema is the JSON schema of ResponseFormatJSONSchema.
+	Schema *jsonschema.Schema
+	// Strict requests exact schema adherence where supported.
+	Strict bool
+}
+
+// JSONObject requests the output to be a JSON object.
+func JSONObject() ResponseFormat {
+	return ResponseFormat{Type: ResponseFormatJSONObject}
+}
+
+// JSONSchema requests the output to be JSON conforming to schema.
+func JSONSchema(schema *jsonschema.Schema) ResponseFormat {
+	return ResponseFormat{Type: ResponseFormatJSONSchema, Name: "response", Schema: schema}
+}
+
 // ModelRequest is a multimodal chat-style request to the provider.
 type ModelRequest struct {
 	Model    string     `json:"model"`
@@ -53,6 +187,56 @@ type ModelRequest struct {
 // ModelResponse is a single assistant message as a result of generation.
 type ModelResponse struct {
 	Messages []*Message `json:"message"`
+	// Usage reports the tokens consumed by the call, when the provider returns it.
+	// In a stream it is set on the final response.
+	Usage *Usage `json:"usage,omitempty"`
+	// Grounding describes the sources the response is grounded in, if any.
+	Grounding *Grounding `json:"grounding,omitempty"`
+	// Warnings report non-fatal issues, e.g. that a fallback backend served it.
+	Warnings []Warning `json:"warnings,omitempty"`
+}
+
+// Grounding describes the sources a response is grounded in, for rendering citations.
+type Grounding struct {
+	// Queries are the search queries issued by the model.
+	Queries []string `json:"queries,omitempty"`
+	// Sources are the documents the response is grounded in.
+	Sources []GroundingSource `json:"sources,omitempty"`
+	// Supports link segments of the response text to their sources.
+	Supports []GroundingSupport `json:"supports,omitempty"`
+}
+
+// GroundingSource is a document a response is grounded in.
+type GroundingSource struct {
+	Title string `json:"title,omitempty"`
+	URI   string `json:"uri"`
+}
+
+// GroundingSupport links the text between the Start and End byte offsets of the
+// response to the indexes of its sources, with a confidence score per source.
+type GroundingSupport struct {
+	Text    string    `json:"text"`
+	Start   int       `json:"start"`
+	End     int       `json:"end"`
+	Sources []int     `json:"sources"`
+	Scores  []float64 `json:"scores,omitempty"`
+}
+
+// Usage reports the tokens consumed by one or more model calls.
+type Usage struct {
+	PromptTokens     int64 `json:"promptTokens"`
+	CompletionTokens int64 `json:"completionTokens"`
+	TotalTokens      int64 `json:"totalTokens"`
+}
+
+// Add adds the tokens of other to u. A nil other is ignored.
+func (u *Usage) Add(other *Usage) {
+	if other == nil {
+		return
+	}
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
 }
 
 // ModelProvider is an interface for multimodal chat-style models.
@@ -0,0 +1,241 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrModerationBlocked indicates content was blocked by the moderation policy.
+	ErrModerationBlocked = errors.New("content blocked by moderation")
+)
+
+// ModerationResult is the assessment of a text by a Moderator.
+type ModerationResult struct {
+	// Categories lists the categories the text is flagged for.
+	Categories []string
+	// Scores maps the assessed categories to their scores, from 0 to 1.
+	Scores map[string]float64
+}
+
+// Moderator classifies text into harm categories, e.g. the OpenAI moderation
+// endpoint or a local classifier.
+type Moderator interface {
+	Moderate(ctx context.Context, text string) (*ModerationResult, error)
+}
+
+// ModeratorFunc adapts a function to a Moderator.
+type ModeratorFunc func(ctx context.Context, text string) (*ModerationResult, error)
+
+// Moderate calls f(ctx, text).
+func (f ModeratorFunc) Moderate(ctx context.Context, text string) (*ModerationResult, error) {
+	return f(ctx, text)
+}
+
+// ModerationError reports content blocked by the moderation policy. It matches
+// ErrModerationBlocked with errors.Is.
+type ModerationError struct {
+	// Phase is "input" or "output".
+	Phase string
+	// Categories lists the blocked categories the content is flagged for.
+	Categories []string
+}
+
+// Error returns the error message.
+func (e *ModerationError) Error() string {
+	return fmt.Sprintf("%s blocked by moderation: %s", e.Phase, strings.Join(e.Categories, ", "))
+}
+
+// Is reports whether target is ErrModerationBlocked.
+func (e *ModerationError) Is(target error) bool {
+	return target == ErrModerationBlocked
+}
+
+// ModerationOption configures the Moderation middleware.
+type ModerationOption func(*moderation)
+
+// BlockCategories blocks content flagged for any of the categories. By default
+// content flagged for any category not set to be flagged only is blocked.
+func BlockCategories(categories ...string) ModerationOption {
+	return func(m *moderation) {
+		m.block = categories
+	}
+}
+
+// FlagCategories lets content flagged for the categories through with a
+// WarningModerationFlagged warning instead of blocking it.
+func FlagCategories(categories ...string) ModerationOption {
+	return func(m *moderation) {
+		m.flag = categories
+	}
+}
+
+// ModerationThreshold flags content whose score for category reaches threshold,
+// whether or not the moderator flags it.
+func ModerationThreshold(category string, threshold float64) ModerationOption {
+	return func(m *moderation) {
+		if m.thresholds == nil {
+			m.thresholds = make(map[string]float64)
+		}
+		m.thresholds[category] = threshold
+	}
+}
+
+// ModerateOutputOnly skips the moderation of prompts.
+func ModerateOutputOnly() ModerationOption {
+	return func(m *moderation) {
+		m.input = false
+	}
+}
+
+// ModerateInputOnly skips the moderation of generations.
+func ModerateInputOnly() ModerationOption {
+	return func(m *moderation) {
+		m.output = false
+	}
+}
+
+type moderation struct {
+	moderator  Moderator
+	block      []string
+	flag       []string
+	thresholds map[string]float64
+	input      bool
+	output     bool
+}
+
+// Moderation returns a middleware that runs the user messages of prompts and the
+// generations through the moderator. Content flagged for a blocked category
+// fails with a *ModerationError; content flagged for a category set with
+// FlagCategories gets a WarningModerationFlagged warning. Streamed generations
+// are moderated once their messages are completed.
+func Moderation(moderator Moderator, opts ...ModerationOption) Middleware {
+	m := &moderation{moderator: moderator, input: true, output: true}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return func(next Handler) Handler {
+		return Handler{
+			Run: func(ctx context.Context, prompt *Prompt, opts ...ModelOption) (*Generation, error) {
+				flagged, err := m.checkInput(ctx, prompt)
+				if err != nil {
+					return nil, err
+				}
+				res, err := next.Run(ctx, prompt, opts...)
+				if err != nil {
+					return nil, err
+				}
+				if err := m.checkOutput(ctx, res); err != nil {
+					return nil, err
+				}
+				res.Warnings = append(res.Warnings, flagged...)
+				return res, nil
+			},
+			Stream: func(ctx context.Context, prompt *Prompt, opts ...ModelOption) (Streamer[*Generation], error) {
+				flagged, err := m.checkInput(ctx, prompt)
+				if err != nil {
+					return nil, err
+				}
+				stream, err := next.Stream(ctx, prompt, opts...)
+				if err != nil {
+					return nil, err
+				}
+				first := true
+				return NewMappedStream(stream, func(g *Generation) (*Generation, error) {
+					if first {
+						first = false
+						g.Warnings = append(g.Warnings, flagged...)
+					}
+					if len(g.Messages) > 0 && g.Messages[len(g.Messages)-1].Status == StatusCompleted {
+						if err := m.checkOutput(ctx, g); err != nil {
+							return nil, err
+						}
+					}
+					return g, nil
+				}), nil
+			},
+		}
+	}
+}
+
+// checkInput moderates the user messages of the prompt and returns the warnings
+// for the flagged categories.
+func (m *moderation) checkInput(ctx context.Context, prompt *Prompt) ([]Warning, error) {
+	if !m.input {
+		return nil, nil
+	}
+	return m.check(ctx, "input", userText(prompt))
+}
+
+// checkOutput moderates the generation and adds the warnings to it.
+func (m *moderation) checkOutput(ctx context.Context, g *Generation) error {
+	if !m.output {
+		return nil
+	}
+	var texts []string
+	for _, msg := range g.Messages {
+		if text := msg.Text(); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	flagged, err := m.check(ctx, "output", strings.Join(texts, "\n"))
+	if err != nil {
+		return err
+	}
+	g.Warnings = append(g.Warnings, flagged...)
+	return nil
+}
+
+// check moderates the text and applies the policy.
+func (m *moderation) check(ctx context.Context, phase, text string) ([]Warning, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	res, err := m.moderator.Moderate(ctx, text)
+	if err != nil {
+		return nil, fmt.Errorf("moderation: %w", err)
+	}
+	var blocked, flagged []string
+	for _, category := range m.categories(res) {
+		switch {
+		case slices.Contains(m.flag, category):
+			flagged = append(flagged, category)
+		case len(m.block) == 0 || slices.Contains(m.block, category):
+			blocked = append(blocked, category)
+		}
+	}
+	if len(blocked) > 0 {
+		return nil, &ModerationError{Phase: phase, Categories: blocked}
+	}
+	if len(flagged) == 0 {
+		return nil, nil
+	}
+	return []Warning{{
+		Code:    WarningModerationFlagged,
+		Message: fmt.Sprintf("the %s was flagged for %s", phase, strings.Join(flagged, ", ")),
+	}}, nil
+}
+
+// categories returns the sorted categories the result is flagged for, including
+// those whose score reaches their threshold.
+func (m *moderation) categories(res *ModerationResult) []string {
+	set := make(map[string]bool)
+	for _, category := range res.Categories {
+		set[category] = true
+	}
+	for category, threshold := range m.thresholds {
+		if score, ok := res.Scores[category]; ok && score >= threshold {
+			set[category] = true
+		}
+	}
+	categories := make([]string, 0, len(set))
+	for category := range set {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	return categories
+}
@@ -0,0 +1,51 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestModeration(t *testing.T) {
+	// flag "violence" when the text mentions a fight, and score "harassment"
+	moderator := ModeratorFunc(func(ctx context.Context, text string) (*ModerationResult, error) {
+		res := &ModerationResult{Scores: map[string]float64{"harassment": 0.1}}
+		if strings.Contains(text, "fight") {
+			res.Categories = append(res.Categories, "violence")
+		}
+		if strings.Contains(text, "idiot") {
+			res.Scores["harassment"] = 0.7
+		}
+		return res, nil
+	})
+	run := func(reply, input string, opts ...ModerationOption) (*Generation, error) {
+		agent := NewAgent("test", WithProvider(textProvider(reply)), WithMiddleware(Moderation(moderator, opts...)))
+		return agent.Run(context.Background(), NewPrompt(UserMessage(input)))
+	}
+	if _, err := run("ok", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := run("ok", "how to win a fight")
+	var blocked *ModerationError
+	if !errors.As(err, &blocked) || !errors.Is(err, ErrModerationBlocked) || blocked.Phase != "input" || blocked.Categories[0] != "violence" {
+		t.Fatalf("expected the input to be blocked, got %v", err)
+	}
+	res, err := run("a fight scene", "write a story", FlagCategories("violence"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Warnings) != 1 || res.Warnings[0].Code != WarningModerationFlagged {
+		t.Fatalf("expected the output to be flagged, got %+v", res.Warnings)
+	}
+	if _, err := run("you idiot", "hello"); err != nil {
+		t.Fatalf("expected scores below the moderator flags to pass, got %v", err)
+	}
+	_, err = run("you idiot", "hello", ModerationThreshold("harassment", 0.5))
+	if !errors.As(err, &blocked) || blocked.Phase != "output" {
+		t.Fatalf("expected the output to be blocked by threshold, got %v", err)
+	}
+	if _, err := run("ok", "how to win a fight", ModerateOutputOnly()); err != nil {
+		t.Fatalf("expected the input to be skipped, got %v", err)
+	}
+}
@@ -24,7 +24,28 @@ func TopP(p float64) ModelOption {
 // Temperature sets the sampling temperature to use, between 0.0 and 1.0.
 func Temperature(t float64) ModelOption {
 	return func(o *ModelOptions) {
-		o.Temperature = t
+		o.Temperature = &t
+	}
+}
+
+// TopK limits sampling to the k most likely tokens.
+func TopK(k int) ModelOption {
+	return func(o *ModelOptions) {
+		o.TopK = k
+	}
+}
+
+// StopSequences sets sequences that stop generation when produced.
+func StopSequences(stop ...string) ModelOption {
+	return func(o *ModelOptions) {
+		o.StopSequences = stop
+	}
+}
+
+// SafetySettings sets the content blocking thresholds of providers with safety filters.
+func SafetySettings(settings ...SafetySetting) ModelOption {
+	return func(o *ModelOptions) {
+		o.SafetySettings = settings
 	}
 }
 
@@ -146,3 +167,76 @@ func AudioSpeed(speed float64) ModelOption {
 		o.Audio.Speed = speed
 	}
 }
+
+// RoutingModels sets the fallback models tried in order by routing gateways.
+func RoutingModels(models ...string) ModelOption {
+	return func(o *ModelOptions) {
+		o.Routing.Models = models
+	}
+}
+
+// RoutingProviders sets the upstream vendors to prefer, in order.
+func RoutingProviders(providers ...string) ModelOption {
+	return func(o *ModelOptions) {
+		o.Routing.Providers = providers
+	}
+}
+
+// RoutingIgnoreProviders excludes upstream vendors from serving the request.
+func RoutingIgnoreProviders(providers ...string) ModelOption {
+	return func(o *ModelOptions) {
+		o.Routing.IgnoreProviders = providers
+	}
+}
+
+// RoutingAllowFallbacks controls whether vendors outside the preferred list may be used.
+func RoutingAllowFallbacks(allow bool) ModelOption {
+	return func(o *ModelOptions) {
+		o.Routing.AllowFallbacks = &allow
+	}
+}
+
+// RoutingSort ranks candidate vendors by "price", "throughput" or "latency".
+func RoutingSort(sort string) ModelOption {
+	return func(o *ModelOptions) {
+		o.Routing.Sort = sort
+	}
+}
+
+// PromptCacheKey groups requests sharing a prompt prefix for provider-side caching.
+func PromptCacheKey(key string) ModelOption {
+	return func(o *ModelOptions) {
+		o.Cache.Key = key
+	}
+}
+
+// PromptCachePrefix marks the first n request messages as a cacheable prefix.
+func PromptCachePrefix(n int) ModelOption {
+	return func(o *ModelOptions) {
+		o.Cache.Prefix = n
+	}
+}
+
+// WithResponseFormat sets the format of the model output, e.g. JSONObject() or JSONSchema(schema).
+func WithResponseFormat(format ResponseFormat) ModelOption {
+	return func(o *ModelOptions) {
+		o.ResponseFormat = format
+	}
+}
+
+// WebSearch grounds the response in web search results where the provider
+// supports it, surfacing the sources in the response grounding.
+func WebSearch() ModelOption {
+	return func(o *ModelOptions) {
+		o.Grounding.WebSearch = true
+	}
+}
+
+// URLContext lets the model read the pages linked in the prompt where the
+// provider supports it natively, e.g. Gemini url_context; wrap other providers
+// with urlcontext.NewProvider to fetch the pages client-side.
+func URLContext() ModelOption {
+	return func(o *ModelOptions) {
+		o.Grounding.URLContext = true
+	}
+}
@@ -3,11 +3,21 @@ package blades
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/google/jsonschema-go/jsonschema"
 )
 
+var (
+	// ErrInvalidObject indicates the model output is not valid JSON for the requested type.
+	ErrInvalidObject = errors.New("invalid structured output")
+)
+
+// maxRepairAttempts is how many times GenerateObject asks the model to fix malformed output.
+const maxRepairAttempts = 2
+
 // OutputConverter is a wrapper around a Runnable runner that ensures the output conforms to a specified type T using JSON schema validation.
 type OutputConverter[T any] struct {
 	runner Runner
@@ -20,47 +30,105 @@ func NewOutputConverter[T any](runner Runner) *OutputConverter[T] {
 
 // Run processes the given prompt using the wrapped runner and ensures the output conforms to type T.
 func (o *OutputConverter[T]) Run(ctx context.Context, prompt *Prompt, opts ...ModelOption) (T, error) {
-	var result T
-	schema, err := jsonschema.For[T](nil)
+	return GenerateObject[T](ctx, o.runner, prompt, opts...)
+}
+
+// RunStream processes the given prompt using the wrapped runner and returns a Streamable that yields a single output of type T.
+func (o *OutputConverter[T]) RunStream(ctx context.Context, prompt *Prompt, opts ...ModelOption) (Streamer[T], error) {
+	result, err := o.Run(ctx, prompt, opts...)
 	if err != nil {
-		return result, err
+		return nil, err
 	}
-	// Convert the schema to JSON Schema format
-	b, err := schema.MarshalJSON()
+	stream := NewStreamPipe[T]()
+	stream.Send(result)
+	stream.Close()
+	return stream, nil
+}
+
+// GenerateObject runs the prompt and decodes the response into T. The JSON schema
+// of T is injected into the prompt and requested from the provider as response
+// format where supported; responses that are not valid JSON for the schema are sent back to the
+// model with repair instructions before giving up with ErrInvalidObject. Agents
+// with memory record only the prompt and the accepted response.
+func GenerateObject[T any](ctx context.Context, runner Runner, prompt *Prompt, opts ...ModelOption) (T, error) {
+	var result T
+	p, schema, err := objectPrompt[T](prompt)
 	if err != nil {
 		return result, err
 	}
-	buf := strings.Builder{}
-	buf.WriteString(`Your response should be in JSON format.
-				Do not include any explanations, only provide a RFC8259 compliant JSON response following this format without deviation.
-				Do not include markdown code blocks in your response.
-				Here is the JSON Schema instance your output must adhere to:
-				`)
-	buf.WriteString(string(b))
-	p := NewPrompt(SystemMessage(buf.String()))
-	p.Messages = append(p.Messages, prompt.Messages...)
-	res, err := o.runner.Run(ctx, p, opts...)
+	resolved, err := schema.Resolve(nil)
 	if err != nil {
 		return result, err
 	}
-	text := res.Text()
-	text = strings.TrimSpace(text)
-	text = strings.Trim(text, "```json")
-	text = strings.Trim(text, "```")
-	if err := json.Unmarshal([]byte(text), &result); err != nil {
-		return result, err
+	formatted := append(opts[:len(opts):len(opts)], WithResponseFormat(JSONSchema(schema)))
+	ctx, memory := deferRunMemory(ctx, prompt)
+	for attempt := 0; ; attempt++ {
+		res, err := runner.Run(ctx, p, formatted...)
+		if errors.Is(err, ErrUnsupported) {
+			// the schema in the prompt still applies
+			formatted = opts
+			res, err = runner.Run(ctx, p, formatted...)
+		}
+		if err != nil {
+			return result, err
+		}
+		text := extractJSON(res.Text())
+		if err = decodeObject(text, resolved, &result); err == nil {
+			return result, memory.commit()
+		}
+		if attempt == maxRepairAttempts {
+			return result, fmt.Errorf("%w: %v", ErrInvalidObject, err)
+		}
+		p.Messages = append(p.Messages,
+			AssistantMessage(text),
+			UserMessage(fmt.Sprintf("Your previous response is invalid: %v\nRespond again with only the corrected JSON that adheres to the schema.", err)),
+		)
 	}
-	return result, nil
 }
 
-// RunStream processes the given prompt using the wrapped runner and returns a Streamable that yields a single output of type T.
-func (o *OutputConverter[T]) RunStream(ctx context.Context, prompt *Prompt, opts ...ModelOption) (Streamer[T], error) {
-	result, err := o.Run(ctx, prompt, opts...)
+// objectPrompt prepends the JSON schema of T to the prompt.
+func objectPrompt[T any](prompt *Prompt) (*Prompt, *jsonschema.Schema, error) {
+	schema, err := jsonschema.For[T](nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	stream := NewStreamPipe[T]()
-	stream.Send(result)
-	stream.Close()
-	return stream, nil
+	b, err := schema.MarshalJSON()
+	if err != nil {
+		return nil, nil, err
+	}
+	var buf strings.Builder
+	buf.WriteString("Your response should be in JSON format.\n")
+	buf.WriteString("Do not include any explanations, only provide a RFC8259 compliant JSON response following this format without deviation.\n")
+	buf.WriteString("Do not include markdown code blocks in your response.\n")
+	buf.WriteString("Here is the JSON Schema instance your output must adhere to:\n")
+	buf.Write(b)
+	p := &Prompt{ConversationID: prompt.ConversationID}
+	p.Messages = append(p.Messages, SystemMessage(buf.String()))
+	p.Messages = append(p.Messages, prompt.Messages...)
+	return p, schema, nil
+}
+
+// decodeObject validates text against the schema and unmarshals it into v.
+func decodeObject(text string, resolved *jsonschema.Resolved, v any) error {
+	var instance any
+	if err := json.Unmarshal([]byte(text), &instance); err != nil {
+		return err
+	}
+	if err := resolved.Validate(instance); err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(text), v)
+}
+
+// extractJSON strips surrounding whitespace and markdown code fences.
+func extractJSON(text string) string {
+	text = strings.TrimSpace(text)
+	if rest, ok := strings.CutPrefix(text, "```"); ok {
+		// drop the language tag on the opening fence
+		if i := strings.IndexByte(rest, '\n'); i >= 0 {
+			rest = rest[i+1:]
+		}
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest), "```"))
+	}
+	return text
 }
@@ -0,0 +1,50 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestGenerateObject_Repair(t *testing.T) {
+	type city struct {
+		Name       string `json:"name"`
+		Population int    `json:"population"`
+	}
+	runner := &fakeRunner{replies: []string{
+		`{"name": "Paris"`,
+		`{"name": "Paris", "population": "many"}`,
+		"```json\n{\"name\": \"Paris\", \"population\": 2100000}\n```",
+	}}
+	got, err := GenerateObject[city](context.Background(), runner, NewPrompt(UserMessage("Describe Paris")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "Paris" || got.Population != 2100000 {
+		t.Fatalf("unexpected object %+v", got)
+	}
+	if n := len(runner.prompts[2].Messages); n != 6 {
+		t.Fatalf("expected repair messages to accumulate, got %d messages", n)
+	}
+
+	runner = &fakeRunner{replies: []string{"no", "still no", "never"}}
+	if _, err := GenerateObject[city](context.Background(), runner, NewPrompt(UserMessage("Describe Paris"))); !errors.Is(err, ErrInvalidObject) {
+		t.Fatalf("expected ErrInvalidObject, got %v", err)
+	}
+}
+
+func TestGenerateObject_Memory(t *testing.T) {
+	type city struct {
+		Name string `json:"name"`
+	}
+	mem := &fakeMemory{}
+	agent := NewAgent("test", WithProvider(textProvider("not json", `{"name": "Paris"}`)), WithMemory(mem))
+	if _, err := GenerateObject[city](context.Background(), agent, NewPrompt(UserMessage("Describe Paris"))); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Describe Paris", `{"name": "Paris"}`}
+	if got := mem.texts(""); !slices.Equal(got, want) {
+		t.Fatalf("memory = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,160 @@
+package blades
+
+import (
+	"context"
+	"time"
+)
+
+type ctxNoPacingKey struct{}
+
+// ContextWithoutPacing returns a new context whose streams are not paced by
+// PaceStream, for programmatic consumers that want deltas as soon as possible.
+func ContextWithoutPacing(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxNoPacingKey{}, true)
+}
+
+// PaceOption configures stream pacing.
+type PaceOption func(*pacer)
+
+// PaceInterval sets the interval at which chars characters may be emitted,
+// 50ms by default.
+func PaceInterval(interval time.Duration) PaceOption {
+	return func(p *pacer) {
+		p.interval = interval
+	}
+}
+
+// PaceMaxLag bounds how far the paced output may fall behind the provider:
+// when the buffered text would take longer than lag to emit, it is emitted
+// faster. The default is 2 seconds.
+func PaceMaxLag(lag time.Duration) PaceOption {
+	return func(p *pacer) {
+		p.maxLag = lag
+	}
+}
+
+type pacer struct {
+	chars    int
+	interval time.Duration
+	maxLag   time.Duration
+}
+
+// PaceStream returns a Middleware smoothing the streams of an agent for UI
+// rendering: text deltas are split and emitted at a steady rate of at most
+// chars characters per interval, instead of in the bursts providers send them.
+// Other generations pass through in order. Runs with a context from
+// ContextWithoutPacing are not paced.
+func PaceStream(chars int, opts ...PaceOption) Middleware {
+	return Streaming(func(next StreamHandler) StreamHandler {
+		return func(ctx context.Context, prompt *Prompt, modelOpts ...ModelOption) (Streamer[*Generation], error) {
+			stream, err := next(ctx, prompt, modelOpts...)
+			if err != nil {
+				return nil, err
+			}
+			return NewPacedStream(ctx, stream, chars, opts...), nil
+		}
+	})
+}
+
+// NewPacedStream paces the text deltas of stream at chars characters per
+// interval, see PaceStream. It returns stream unchanged when chars is not
+// positive or ctx disables pacing.
+func NewPacedStream(ctx context.Context, stream Streamer[*Generation], chars int, opts ...PaceOption) Streamer[*Generation] {
+	if disabled, _ := ctx.Value(ctxNoPacingKey{}).(bool); disabled || chars <= 0 {
+		return stream
+	}
+	p := &pacer{chars: chars, interval: 50 * time.Millisecond, maxLag: 2 * time.Second}
+	for _, opt := range opts {
+		opt(p)
+	}
+	// Generations are read ahead so that the lag can be measured.
+	source := make(chan *Generation, 64)
+	var readErr error
+	go func() {
+		defer close(source)
+		for stream.Next() {
+			g, err := stream.Current()
+			if err != nil {
+				readErr = err
+				return
+			}
+			source <- g
+		}
+	}()
+	pipe := NewStreamPipe[*Generation]()
+	pipe.Go(func() error {
+		var (
+			tokens = float64(p.chars)
+			last   = time.Now()
+		)
+		for g := range source {
+			pieces := splitDelta(g, p.chars)
+			if len(pieces) == 1 {
+				pipe.Send(g)
+				continue
+			}
+			for _, piece := range pieces {
+				n := float64(len([]rune(piece.Messages[0].Text())))
+				// Speed up when the text buffered ahead would exceed the lag.
+				rate := float64(p.chars) / float64(p.interval)
+				if backlog := float64(len(source)*p.chars) + n; p.maxLag > 0 && backlog/rate > float64(p.maxLag) {
+					rate = backlog / float64(p.maxLag)
+				}
+				now := time.Now()
+				tokens = min(float64(p.chars), tokens+float64(now.Sub(last))*rate)
+				last = now
+				if tokens < n {
+					wait := time.Duration((n - tokens) / rate)
+					select {
+					case <-ctx.Done():
+						stream.Close()
+						return ctx.Err()
+					case <-time.After(wait):
+					}
+					last = time.Now()
+					tokens = n
+				}
+				tokens -= n
+				pipe.Send(piece)
+			}
+		}
+		if cerr := stream.Close(); readErr == nil {
+			readErr = cerr
+		}
+		return readErr
+	})
+	return pipe
+}
+
+// splitDelta splits a generation holding a single text delta into generations
+// of at most chars characters. The other fields of the generation are kept on
+// the last piece. Other generations are returned as is.
+func splitDelta(g *Generation, chars int) []*Generation {
+	if len(g.Messages) != 1 || g.Finish != nil || len(g.ToolTrace) > 0 {
+		return []*Generation{g}
+	}
+	msg := g.Messages[0]
+	if msg.Status == StatusCompleted || len(msg.ToolCalls) > 0 || len(msg.Parts) != 1 {
+		return []*Generation{g}
+	}
+	text, ok := msg.Parts[0].(TextPart)
+	runes := []rune(text.Text)
+	if !ok || len(runes) <= chars {
+		return []*Generation{g}
+	}
+	var pieces []*Generation
+	for start := 0; start < len(runes); start += chars {
+		end := min(start+chars, len(runes))
+		m := *msg
+		m.Parts = []Part{TextPart{Text: string(runes[start:end])}}
+		if end < len(runes) {
+			m.Metadata = nil
+			pieces = append(pieces, &Generation{Messages: []*Message{&m}})
+			continue
+		}
+		last := *g
+		last.Messages = []*Message{&m}
+		pieces = append(pieces, &last)
+	}
+	return pieces
+}
@@ -0,0 +1,45 @@
+package blades
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPacedStream(t *testing.T) {
+	source := NewStreamPipe[*Generation]()
+	source.Go(func() error {
+		source.Send(&Generation{Messages: []*Message{{Role: RoleAssistant, Status: StatusIncomplete, Parts: Parts("Hello, paced world!!")}}})
+		source.Send(&Generation{Finish: &Finish{Reason: "stop"}})
+		return nil
+	})
+	started := time.Now()
+	stream := NewPacedStream(context.Background(), source, 5, PaceInterval(10*time.Millisecond))
+	var (
+		deltas []string
+		finish bool
+	)
+	for stream.Next() {
+		g, err := stream.Current()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.Finish != nil {
+			finish = true
+			continue
+		}
+		deltas = append(deltas, g.Text())
+	}
+	if len(deltas) != 4 || strings.Join(deltas, "") != "Hello, paced world!!" || !finish {
+		t.Fatalf("unexpected deltas %q, finish %v", deltas, finish)
+	}
+	if elapsed := time.Since(started); elapsed < 25*time.Millisecond {
+		t.Fatalf("deltas were not paced: %v", elapsed)
+	}
+
+	passthrough := NewStreamPipe[*Generation]()
+	if NewPacedStream(ContextWithoutPacing(context.Background()), passthrough, 5) != Streamer[*Generation](passthrough) {
+		t.Fatalf("expected the stream to pass through")
+	}
+}
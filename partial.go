@@ -0,0 +1,196 @@
+package blades
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// PartialJSON returns the longest prefix of a truncated JSON document that ends
+// with a complete value, closed with the brackets still open so that it parses.
+// Incomplete trailing strings, numbers and literals are dropped, so every value
+// in the result is final. It reports false if no value has started yet.
+func PartialJSON(text string) (string, bool) {
+	start := strings.IndexAny(text, "{[")
+	if start < 0 {
+		return "", false
+	}
+	type frame struct {
+		object bool
+		key    bool // the next string of an object is a key
+	}
+	var (
+		stack    []frame
+		cut      = -1
+		closers  string
+		inString bool
+		escaped  bool
+		isKey    bool
+		scalar   = -1 // start of the current number or literal
+	)
+	safe := func(pos int) {
+		cut = pos
+		var b strings.Builder
+		for i := len(stack) - 1; i >= 0; i-- {
+			if stack[i].object {
+				b.WriteByte('}')
+			} else {
+				b.WriteByte(']')
+			}
+		}
+		closers = b.String()
+	}
+	for i := start; i < len(text) && (len(stack) > 0 || i == start); i++ {
+		c := text[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+				if !isKey {
+					safe(i + 1)
+				}
+			}
+			continue
+		}
+		if scalar >= 0 && strings.IndexByte(",}] \t\r\n", c) >= 0 {
+			scalar = -1
+			safe(i)
+		}
+		switch c {
+		case '{':
+			stack = append(stack, frame{object: true, key: true})
+			safe(i + 1)
+		case '[':
+			stack = append(stack, frame{})
+			safe(i + 1)
+		case '}', ']':
+			stack = stack[:len(stack)-1]
+			safe(i + 1)
+		case '"':
+			inString = true
+			isKey = stack[len(stack)-1].object && stack[len(stack)-1].key
+		case ':':
+			stack[len(stack)-1].key = false
+		case ',':
+			if stack[len(stack)-1].object {
+				stack[len(stack)-1].key = true
+			}
+		case ' ', '\t', '\r', '\n':
+		default:
+			if scalar < 0 {
+				scalar = i
+			}
+		}
+	}
+	if cut < 0 {
+		return "", false
+	}
+	return text[start:cut] + closers, true
+}
+
+// PartialParser incrementally decodes a streamed JSON document into T.
+type PartialParser[T any] struct {
+	buf  strings.Builder
+	last string
+}
+
+// NewPartialParser creates a new PartialParser.
+func NewPartialParser[T any]() *PartialParser[T] {
+	return &PartialParser[T]{}
+}
+
+// Write appends a delta of the document. It returns a partially populated value
+// and true whenever more values have completed since the previous call.
+func (p *PartialParser[T]) Write(delta string) (T, bool, error) {
+	p.buf.WriteString(delta)
+	return p.parse()
+}
+
+// Reset replaces the buffered document, e.g. with the final complete text.
+func (p *PartialParser[T]) Reset(text string) (T, bool, error) {
+	p.buf.Reset()
+	p.buf.WriteString(text)
+	return p.parse()
+}
+
+func (p *PartialParser[T]) parse() (T, bool, error) {
+	var v T
+	text, ok := PartialJSON(p.buf.String())
+	if !ok || text == p.last {
+		return v, false, nil
+	}
+	if err := json.Unmarshal([]byte(text), &v); err != nil {
+		return v, false, err
+	}
+	p.last = text
+	return v, true, nil
+}
+
+// StreamObject streams the response to the prompt as progressively populated
+// values of T, so that UIs can render structured results during generation.
+// The JSON schema of T is injected into the prompt as with GenerateObject.
+func StreamObject[T any](ctx context.Context, runner Runner, prompt *Prompt, opts ...ModelOption) (Streamer[T], error) {
+	p, schema, err := objectPrompt[T](prompt)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := runner.RunStream(ctx, p, append(opts[:len(opts):len(opts)], WithResponseFormat(JSONSchema(schema)))...)
+	if errors.Is(err, ErrUnsupported) {
+		stream, err = runner.RunStream(ctx, p, opts...)
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	pipe := NewStreamPipe[T]()
+	pipe.Go(func() error {
+		defer cancel()
+		if err := sendObjects(stream, pipe); err != nil {
+			// Stop the upstream and let its producer finish before closing it.
+			cancel()
+			drain(stream)
+			return err
+		}
+		return stream.Close()
+	})
+	return pipe, nil
+}
+
+// sendObjects parses the assistant messages of stream into values of T and
+// sends them to pipe as they complete.
+func sendObjects[T any](stream Streamer[*Generation], pipe *StreamPipe[T]) error {
+	parser := NewPartialParser[T]()
+	for stream.Next() {
+		res, err := stream.Current()
+		if err != nil {
+			return err
+		}
+		for _, msg := range res.Messages {
+			if msg.Role != RoleAssistant {
+				continue
+			}
+			var (
+				v       T
+				updated bool
+			)
+			if msg.Status == StatusCompleted {
+				v, updated, err = parser.Reset(msg.Text())
+			} else {
+				v, updated, err = parser.Write(msg.Text())
+			}
+			if err != nil {
+				return err
+			}
+			if updated {
+				pipe.Send(v)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package blades
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPartialJSON(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{`Sure: {"name": "Par`, `{}`},
+		{`{"name": "Paris", "pop`, `{"name": "Paris"}`},
+		{`{"name": "Paris", "population": 21`, `{"name": "Paris"}`},
+		{`{"name": "Paris", "population": 21, "tags": ["a", "b\"`, `{"name": "Paris", "population": 21, "tags": ["a"]}`},
+		{`{"rows": [{"a": true}, {"a": fal`, `{"rows": [{"a": true}, {}]}`},
+		{"```json\n{\"a\": 1}\n```", `{"a": 1}`},
+	}
+	for _, tt := range tests {
+		got, ok := PartialJSON(tt.text)
+		if !ok || got != tt.want {
+			t.Errorf("PartialJSON(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+	if _, ok := PartialJSON("Thinking..."); ok {
+		t.Error("expected no JSON before the document starts")
+	}
+}
+
+func TestPartialParser(t *testing.T) {
+	type city struct {
+		Name       string `json:"name"`
+		Population int    `json:"population"`
+	}
+	parser := NewPartialParser[city]()
+	var updates []city
+	for _, delta := range []string{`{"na`, `me": "Pa`, `ris", `, `"population": 21`, `00000}`} {
+		v, ok, err := parser.Write(delta)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			updates = append(updates, v)
+		}
+	}
+	want := []city{{}, {Name: "Paris"}, {Name: "Paris", Population: 2100000}}
+	if len(updates) != len(want) {
+		t.Fatalf("unexpected updates %+v", updates)
+	}
+	for i := range want {
+		if updates[i] != want[i] {
+			t.Fatalf("update %d = %+v, want %+v", i, updates[i], want[i])
+		}
+	}
+}
+
+func TestStreamObject(t *testing.T) {
+	type counter struct {
+		N int `json:"n"`
+	}
+	tests := []struct {
+		name    string
+		chunks  []string
+		want    []counter
+		wantErr bool
+	}{
+		{"populates progressively", []string{`{"n": 1`, `, "m": 2}`}, []counter{{}, {N: 1}}, false},
+		// The producer is still sending when the mismatch is met.
+		{"type mismatch", append([]string{`{"n": "x", `}, strings.Split(strings.Repeat(`"pad": 1, `, 64), " ")...), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := StreamObject[counter](context.Background(), &fakeRunner{chunks: tt.chunks}, NewPrompt(UserMessage("count")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []counter
+			for stream.Next() {
+				v, err := stream.Current()
+				if err != nil {
+					break
+				}
+				got = append(got, v)
+			}
+			if err := stream.Close(); (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected close error %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %+v, want %+v", got, tt.want)
+				}
+			}
+		})
+	}
+}
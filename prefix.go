@@ -0,0 +1,98 @@
+package blades
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+)
+
+var (
+	_ ModelProvider = (*PrefixCachingProvider)(nil)
+)
+
+// PrefixCachingProvider is a ModelProvider decorator for iterative flows such as
+// reflection and revision loops, where each request repeats most of the previous
+// one. It remembers the messages of the last request per cache key and passes the
+// stable key and the length of the unchanged prefix to the wrapped provider, which
+// maps them onto its native prompt caching.
+type PrefixCachingProvider struct {
+	provider    ModelProvider
+	maxSessions int
+	mu          sync.Mutex
+	sessions    map[string][]string
+}
+
+// NewPrefixCachingProvider wraps provider with prefix caching hints.
+func NewPrefixCachingProvider(provider ModelProvider) *PrefixCachingProvider {
+	return &PrefixCachingProvider{
+		provider:    provider,
+		maxSessions: 1024,
+		sessions:    make(map[string][]string),
+	}
+}
+
+// Generate executes the request with prompt caching hints.
+func (p *PrefixCachingProvider) Generate(ctx context.Context, req *ModelRequest, opts ...ModelOption) (*ModelResponse, error) {
+	return p.provider.Generate(ctx, req, p.options(req, opts)...)
+}
+
+// NewStream executes the streaming request with prompt caching hints.
+func (p *PrefixCachingProvider) NewStream(ctx context.Context, req *ModelRequest, opts ...ModelOption) (Streamer[*ModelResponse], error) {
+	return p.provider.NewStream(ctx, req, p.options(req, opts)...)
+}
+
+// options appends the cache key and the unchanged prefix length of req.
+func (p *PrefixCachingProvider) options(req *ModelRequest, opts []ModelOption) []ModelOption {
+	var opt ModelOptions
+	for _, apply := range opts {
+		apply(&opt)
+	}
+	digests := make([]string, len(req.Messages))
+	for i, msg := range req.Messages {
+		digests[i] = messageDigest(msg)
+	}
+	key := opt.Cache.Key
+	if key == "" {
+		key = defaultCacheKey(req, digests)
+	}
+	p.mu.Lock()
+	prev := p.sessions[key]
+	if len(p.sessions) >= p.maxSessions && prev == nil {
+		clear(p.sessions)
+	}
+	p.sessions[key] = digests
+	p.mu.Unlock()
+	prefix := 0
+	for prefix < len(prev) && prefix < len(digests) && prev[prefix] == digests[prefix] {
+		prefix++
+	}
+	return append(opts[:len(opts):len(opts)], PromptCacheKey(key), PromptCachePrefix(prefix))
+}
+
+// defaultCacheKey derives a key from the model and the leading instructions,
+// which stay the same across the iterations of a flow.
+func defaultCacheKey(req *ModelRequest, digests []string) string {
+	h := sha256.New()
+	h.Write([]byte(req.Model))
+	for i, msg := range req.Messages {
+		if !msg.Role.IsInstruction() {
+			break
+		}
+		h.Write([]byte(digests[i]))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// messageDigest hashes the content of a message, ignoring its ID and metadata
+// which change between otherwise identical messages.
+func messageDigest(msg *Message) string {
+	b, _ := json.Marshal(struct {
+		Role      Role        `json:"role"`
+		Parts     []Part      `json:"parts"`
+		ToolCalls []*ToolCall `json:"toolCalls"`
+	}{msg.Role, msg.Parts, msg.ToolCalls})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
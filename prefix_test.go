@@ -0,0 +1,26 @@
+package blades
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPrefixCachingProvider(t *testing.T) {
+	recorder := textProvider()
+	provider := NewPrefixCachingProvider(recorder)
+	draft := []*Message{SystemMessage("You are an editor."), UserMessage("Draft v1")}
+	if _, err := provider.Generate(context.Background(), &ModelRequest{Model: "m", Messages: draft}); err != nil {
+		t.Fatal(err)
+	}
+	key := recorder.lastOptions().Cache.Key
+	if key == "" || recorder.lastOptions().Cache.Prefix != 0 {
+		t.Fatalf("unexpected first cache options %+v", recorder.lastOptions().Cache)
+	}
+	revision := []*Message{SystemMessage("You are an editor."), UserMessage("Draft v1"), UserMessage("Revise it")}
+	if _, err := provider.Generate(context.Background(), &ModelRequest{Model: "m", Messages: revision}); err != nil {
+		t.Fatal(err)
+	}
+	if recorder.lastOptions().Cache.Key != key || recorder.lastOptions().Cache.Prefix != 2 {
+		t.Fatalf("expected 2 cached messages under %s, got %+v", key, recorder.lastOptions().Cache)
+	}
+}
@@ -0,0 +1,119 @@
+// Package prompttest renders the requests agents send to their providers and
+// compares them with snapshots, so that refactors of prompt assembly can be
+// verified without calling a model.
+package prompttest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kratos/blades"
+)
+
+// UpdateEnv is the environment variable that, when set to a non-empty value,
+// makes Match write the snapshots instead of comparing with them.
+const UpdateEnv = "BLADES_UPDATE_SNAPSHOTS"
+
+var errCaptured = errors.New("prompttest: request captured")
+
+// Request is the rendered request of an agent. Message IDs are omitted so
+// that renderings are deterministic.
+type Request struct {
+	Model    string              `json:"model"`
+	Messages []json.RawMessage   `json:"messages"`
+	Tools    []*blades.Tool      `json:"tools,omitempty"`
+	Options  blades.ModelOptions `json:"options"`
+}
+
+// JSON returns the indented JSON encoding of the request.
+func (r *Request) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// capture is a provider recording the first request and failing, so that the
+// agent neither calls a model nor updates its memory.
+type capture struct {
+	req  *blades.ModelRequest
+	opts blades.ModelOptions
+}
+
+func (c *capture) Generate(ctx context.Context, req *blades.ModelRequest, opts ...blades.ModelOption) (*blades.ModelResponse, error) {
+	c.req = req
+	for _, apply := range opts {
+		apply(&c.opts)
+	}
+	return nil, errCaptured
+}
+
+func (c *capture) NewStream(ctx context.Context, req *blades.ModelRequest, opts ...blades.ModelOption) (blades.Streamer[*blades.ModelResponse], error) {
+	_, err := c.Generate(ctx, req, opts...)
+	return nil, err
+}
+
+// Render returns the request the agent would send to its provider for prompt,
+// after its instructions, memory, middlewares and model options are applied.
+// The provider of the agent is not called.
+func Render(ctx context.Context, agent *blades.Agent, prompt *blades.Prompt, opts ...blades.ModelOption) (*Request, error) {
+	c := &capture{}
+	if _, err := agent.Clone(blades.WithProvider(c)).Run(ctx, prompt, opts...); c.req == nil {
+		if err == nil {
+			err = errors.New("prompttest: the agent sent no request")
+		}
+		return nil, err
+	}
+	r := &Request{Model: c.req.Model, Tools: c.req.Tools, Options: c.opts}
+	for _, msg := range c.req.Messages {
+		m := *msg
+		m.ID = ""
+		data, err := blades.MarshalMessage(&m)
+		if err != nil {
+			return nil, fmt.Errorf("prompttest: %w", err)
+		}
+		r.Messages = append(r.Messages, data)
+	}
+	return r, nil
+}
+
+// Match compares data with the snapshot testdata/snapshots/<name>.json, or
+// writes the snapshot when it does not exist or UpdateEnv is set.
+func Match(t testing.TB, name string, data []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", "snapshots", name+".json")
+	want, err := os.ReadFile(path)
+	if os.Getenv(UpdateEnv) != "" || errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("prompttest: %v", err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatalf("prompttest: %v", err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("prompttest: %v", err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(want), bytes.TrimSpace(data)) {
+		t.Errorf("prompttest: %s does not match the snapshot; set %s=1 to update it\n--- want\n%s\n--- got\n%s", path, UpdateEnv, want, data)
+	}
+}
+
+// AssertSnapshot renders the request of the agent for prompt and matches it
+// with the snapshot name.
+func AssertSnapshot(t testing.TB, name string, agent *blades.Agent, prompt *blades.Prompt, opts ...blades.ModelOption) {
+	t.Helper()
+	r, err := Render(context.Background(), agent, prompt, opts...)
+	if err != nil {
+		t.Fatalf("prompttest: render: %v", err)
+	}
+	data, err := r.JSON()
+	if err != nil {
+		t.Fatalf("prompttest: %v", err)
+	}
+	Match(t, name, data)
+}
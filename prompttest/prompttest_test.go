@@ -0,0 +1,29 @@
+package prompttest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/blades"
+)
+
+func TestRender(t *testing.T) {
+	agent := blades.NewAgent("assistant",
+		blades.WithModel("gpt-4o-mini"),
+		blades.WithInstructions("Answer briefly."),
+		blades.WithModelOptions(blades.Temperature(0.2)),
+	)
+	r, err := Render(context.Background(), agent, blades.NewPrompt(blades.UserMessage("Hi")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Model != "gpt-4o-mini" || len(r.Messages) != 2 || r.Options.Temperature == nil || *r.Options.Temperature != 0.2 {
+		t.Fatalf("unexpected request: %+v", r)
+	}
+	data, _ := r.JSON()
+	if !strings.Contains(string(data), `"Answer briefly."`) || !strings.Contains(string(data), `"id": ""`) {
+		t.Fatalf("unexpected rendering: %s", data)
+	}
+	AssertSnapshot(t, "assistant", agent, blades.NewPrompt(blades.UserMessage("Hi")))
+}
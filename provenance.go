@@ -0,0 +1,181 @@
+package blades
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var (
+	// ErrInvalidSignature indicates a provenance record does not match its signature.
+	ErrInvalidSignature = errors.New("invalid provenance signature")
+)
+
+// Provenance records how content was generated, for AI-content disclosure.
+type Provenance struct {
+	// Agent is the name of the agent that generated the content.
+	Agent string `json:"agent"`
+	// Model is the model requested by the agent, if set.
+	Model string `json:"model,omitempty"`
+	// RunID identifies the run that generated the content.
+	RunID string `json:"runId"`
+	// Timestamp is when the content was generated.
+	Timestamp time.Time `json:"timestamp"`
+	// ContentHash is the SHA-256 of the content, as "sha256:<hex>".
+	ContentHash string `json:"contentHash"`
+	// Signature is the base64 HMAC-SHA256 of the record without the signature,
+	// set when a signing key is configured.
+	Signature string `json:"signature,omitempty"`
+}
+
+// Sign sets the signature of the record with key.
+func (p *Provenance) Sign(key []byte) error {
+	sig, err := p.signature(key)
+	if err != nil {
+		return err
+	}
+	p.Signature = sig
+	return nil
+}
+
+// Verify reports ErrInvalidSignature unless the record is signed with key.
+func (p *Provenance) Verify(key []byte) error {
+	sig, err := p.signature(key)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(sig), []byte(p.Signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// signature returns the HMAC-SHA256 of the JSON encoding of the unsigned record.
+func (p *Provenance) signature(key []byte) (string, error) {
+	unsigned := *p
+	unsigned.Signature = ""
+	b, err := json.Marshal(unsigned)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(b)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// ProvenanceOption configures the provenance of an Agent.
+type ProvenanceOption func(*provenance)
+
+// ProvenanceSigningKey signs the provenance records with key.
+func ProvenanceSigningKey(key []byte) ProvenanceOption {
+	return func(p *provenance) {
+		p.key = key
+	}
+}
+
+type provenance struct {
+	key []byte
+}
+
+// WithProvenance attaches a Provenance record to the generations of the Agent.
+// Streamed runs attach it to the generations of completed messages. Runs get a
+// run ID unless their context already carries one.
+func WithProvenance(opts ...ProvenanceOption) Option {
+	return func(a *Agent) {
+		a.provenance = &provenance{}
+		for _, opt := range opts {
+			opt(a.provenance)
+		}
+	}
+}
+
+// stampProvenance sets the provenance of the generation.
+func (a *Agent) stampProvenance(ctx context.Context, g *Generation) error {
+	if a.provenance == nil || g == nil || len(g.Messages) == 0 {
+		return nil
+	}
+	runID, _ := RunIDFromContext(ctx)
+	g.Provenance = &Provenance{
+		Agent:       a.name,
+		Model:       a.model,
+		RunID:       runID,
+		Timestamp:   Now().UTC(),
+		ContentHash: hashMessages(g.Messages),
+	}
+	if a.provenance.key != nil {
+		return g.Provenance.Sign(a.provenance.key)
+	}
+	return nil
+}
+
+// streamProvenance sets the provenance of the generations of completed messages.
+func (a *Agent) streamProvenance(ctx context.Context, stream Streamer[*Generation]) Streamer[*Generation] {
+	if a.provenance == nil {
+		return stream
+	}
+	return NewMappedStream(stream, func(g *Generation) (*Generation, error) {
+		if len(g.Messages) > 0 && g.Messages[len(g.Messages)-1].Status == StatusCompleted {
+			if err := a.stampProvenance(ctx, g); err != nil {
+				return nil, err
+			}
+		}
+		return g, nil
+	})
+}
+
+// hashMessages returns the SHA-256 of the text and data parts of the messages.
+func hashMessages(messages []*Message) string {
+	h := sha256.New()
+	for _, msg := range messages {
+		for _, part := range msg.Parts {
+			switch v := part.(type) {
+			case TextPart:
+				h.Write([]byte(v.Text))
+			case DataPart:
+				h.Write(v.Bytes)
+			case FilePart:
+				h.Write([]byte(v.URI))
+			}
+		}
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// SaveWithProvenance saves the data like Save and writes the provenance record,
+// with the content hash of the data and signed with key when set, next to it
+// as "<file>.provenance.json".
+func (s *FileArtifactStore) SaveWithProvenance(ctx context.Context, data DataPart, record Provenance, key []byte) (FilePart, error) {
+	file, err := s.Save(ctx, data)
+	if err != nil {
+		return FilePart{}, err
+	}
+	sum := sha256.Sum256(data.Bytes)
+	record.ContentHash = "sha256:" + hex.EncodeToString(sum[:])
+	record.Signature = ""
+	if key != nil {
+		if err := record.Sign(key); err != nil {
+			return FilePart{}, fmt.Errorf("artifact store: %w", err)
+		}
+	}
+	b, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return FilePart{}, fmt.Errorf("artifact store: %w", err)
+	}
+	u, err := url.Parse(file.URI)
+	if err != nil {
+		return FilePart{}, fmt.Errorf("artifact store: %w", err)
+	}
+	if err := os.WriteFile(filepath.FromSlash(u.Path)+".provenance.json", b, 0o644); err != nil {
+		return FilePart{}, fmt.Errorf("artifact store: %w", err)
+	}
+	return file, nil
+}
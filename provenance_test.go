@@ -0,0 +1,77 @@
+package blades
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func TestProvenance(t *testing.T) {
+	key := []byte("secret")
+	agent := NewAgent("writer", WithModel("gpt-test"), WithProvider(textProvider("hello")), WithProvenance(ProvenanceSigningKey(key)))
+	ctx := ContextWithRunID(context.Background(), "run-1")
+	res, err := agent.Run(ctx, NewPrompt(UserMessage("hi")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := res.Provenance
+	if p == nil || p.Agent != "writer" || p.Model != "gpt-test" || p.RunID != "run-1" || p.Timestamp.IsZero() {
+		t.Fatalf("unexpected provenance %+v", p)
+	}
+	if p.ContentHash != hashMessages([]*Message{AssistantMessage("hello")}) {
+		t.Fatalf("unexpected content hash %s", p.ContentHash)
+	}
+	if err := p.Verify(key); err != nil {
+		t.Fatal(err)
+	}
+	tampered := *p
+	tampered.Model = "other"
+	if err := tampered.Verify(key); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature, got %v", err)
+	}
+
+	stream, err := agent.RunStream(context.Background(), NewPrompt(UserMessage("hi")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stamped int
+	for stream.Next() {
+		g, err := stream.Current()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.Provenance != nil && g.Provenance.RunID != "" {
+			stamped++
+		}
+	}
+	if stamped != 1 {
+		t.Fatalf("expected the completed generation to carry provenance, got %d", stamped)
+	}
+}
+
+func TestFileArtifactStore_SaveWithProvenance(t *testing.T) {
+	store, err := NewFileArtifactStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := []byte("secret")
+	file, err := store.SaveWithProvenance(context.Background(), DataPart{Bytes: []byte("image"), MimeType: "image/png"}, Provenance{Agent: "painter", RunID: "run-1"}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(file.URI)
+	b, err := os.ReadFile(u.Path + ".provenance.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var record Provenance
+	if err := json.Unmarshal(b, &record); err != nil {
+		t.Fatal(err)
+	}
+	if record.Agent != "painter" || record.ContentHash == "" || record.Verify(key) != nil {
+		t.Fatalf("unexpected provenance record %+v", record)
+	}
+}
@@ -0,0 +1,130 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	_ ModelProvider = (*PseudoStreamingProvider)(nil)
+)
+
+// PseudoStreamOption configures a PseudoStreamingProvider.
+type PseudoStreamOption func(*PseudoStreamingProvider)
+
+// PseudoChunkSize sets the number of characters per delta, 20 by default.
+func PseudoChunkSize(n int) PseudoStreamOption {
+	return func(p *PseudoStreamingProvider) {
+		p.chunkSize = n
+	}
+}
+
+// PseudoInterval sets the delay between deltas, 20ms by default.
+func PseudoInterval(interval time.Duration) PseudoStreamOption {
+	return func(p *PseudoStreamingProvider) {
+		p.interval = interval
+	}
+}
+
+// PseudoStreamAlways pseudo-streams every request instead of only those the
+// provider cannot stream.
+func PseudoStreamAlways() PseudoStreamOption {
+	return func(p *PseudoStreamingProvider) {
+		p.always = true
+	}
+}
+
+// PseudoStreamingProvider exposes streaming for providers without it: when
+// the provider cannot stream a request, reporting ErrUnsupported, the response
+// is generated whole and its text is streamed in timed deltas, followed by the
+// completed response, so that streaming UIs behave the same across providers.
+type PseudoStreamingProvider struct {
+	provider  ModelProvider
+	chunkSize int
+	interval  time.Duration
+	always    bool
+}
+
+// NewPseudoStreamingProvider wraps provider with pseudo-streaming.
+func NewPseudoStreamingProvider(provider ModelProvider, opts ...PseudoStreamOption) *PseudoStreamingProvider {
+	p := &PseudoStreamingProvider{provider: provider, chunkSize: 20, interval: 20 * time.Millisecond}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Generate executes the request on the provider.
+func (p *PseudoStreamingProvider) Generate(ctx context.Context, req *ModelRequest, opts ...ModelOption) (*ModelResponse, error) {
+	return p.provider.Generate(ctx, req, opts...)
+}
+
+// NewStream opens a stream on the provider, or pseudo-streams the generated
+// response when the provider cannot stream.
+func (p *PseudoStreamingProvider) NewStream(ctx context.Context, req *ModelRequest, opts ...ModelOption) (Streamer[*ModelResponse], error) {
+	if !p.always {
+		stream, err := p.provider.NewStream(ctx, req, opts...)
+		if !errors.Is(err, ErrUnsupported) {
+			return stream, err
+		}
+	}
+	pipe := NewStreamPipe[*ModelResponse]()
+	pipe.Go(func() error {
+		res, err := p.provider.Generate(ctx, req, opts...)
+		if err != nil {
+			return err
+		}
+		for i, msg := range res.Messages {
+			if msg.Role != RoleAssistant {
+				continue
+			}
+			if i > 0 {
+				if err := p.wait(ctx); err != nil {
+					return err
+				}
+			}
+			if err := p.stream(ctx, pipe, msg); err != nil {
+				return err
+			}
+		}
+		pipe.Send(res)
+		return nil
+	})
+	return pipe, nil
+}
+
+// stream sends the text of msg in deltas of the chunk size.
+func (p *PseudoStreamingProvider) stream(ctx context.Context, pipe *StreamPipe[*ModelResponse], msg *Message) error {
+	runes := []rune(msg.Text())
+	size := max(p.chunkSize, 1)
+	for start := 0; start < len(runes); start += size {
+		if start > 0 {
+			if err := p.wait(ctx); err != nil {
+				return err
+			}
+		}
+		end := min(start+size, len(runes))
+		pipe.Send(&ModelResponse{Messages: []*Message{{
+			ID:     msg.ID,
+			Role:   RoleAssistant,
+			Status: StatusIncomplete,
+			Parts:  Parts(string(runes[start:end])),
+		}}})
+	}
+	return nil
+}
+
+func (p *PseudoStreamingProvider) wait(ctx context.Context) error {
+	if p.interval <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(p.interval)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,34 @@
+package blades
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPseudoStreamingProvider(t *testing.T) {
+	provider := NewPseudoStreamingProvider(&fakeProvider{replies: []string{"Hello from a batch-only model"}, noStream: true}, PseudoChunkSize(10), PseudoInterval(time.Millisecond))
+	stream, err := provider.NewStream(context.Background(), &ModelRequest{Messages: []*Message{UserMessage("hi")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		deltas    []string
+		completed string
+	)
+	for stream.Next() {
+		res, err := stream.Current()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg := res.Messages[0]; msg.Status == StatusIncomplete {
+			deltas = append(deltas, msg.Text())
+		} else {
+			completed = msg.Text()
+		}
+	}
+	if len(deltas) != 3 || strings.Join(deltas, "") != completed || completed != "Hello from a batch-only model" {
+		t.Fatalf("unexpected deltas %q and completed %q", deltas, completed)
+	}
+}
@@ -0,0 +1,232 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+)
+
+var (
+	_ ModelProvider = (*RaceProvider)(nil)
+)
+
+// Contender is a provider taking part in a race, optionally with its own model.
+type Contender struct {
+	Provider ModelProvider
+	// Model overrides the model of the request when set.
+	Model string
+}
+
+func (c Contender) request(req *ModelRequest) *ModelRequest {
+	if c.Model == "" {
+		return req
+	}
+	r := *req
+	r.Model = c.Model
+	return &r
+}
+
+// SwitchPolicy decides whether the strong response replaces the fast one.
+type SwitchPolicy interface {
+	ShouldSwitch(fast, strong *ModelResponse) bool
+}
+
+// SwitchPolicyFunc adapts a function to a SwitchPolicy.
+type SwitchPolicyFunc func(fast, strong *ModelResponse) bool
+
+// ShouldSwitch calls f(fast, strong).
+func (f SwitchPolicyFunc) ShouldSwitch(fast, strong *ModelResponse) bool {
+	return f(fast, strong)
+}
+
+// DifferencePolicy switches when the word overlap of both responses is below
+// 1-threshold, i.e. when their Jaccard distance exceeds threshold.
+func DifferencePolicy(threshold float64) SwitchPolicy {
+	return SwitchPolicyFunc(func(fast, strong *ModelResponse) bool {
+		return jaccardDistance(responseText(fast), responseText(strong)) > threshold
+	})
+}
+
+// RaceOption configures a RaceProvider.
+type RaceOption func(*RaceProvider)
+
+// WithSwitchPolicy sets the policy deciding whether to switch to the strong response.
+func WithSwitchPolicy(policy SwitchPolicy) RaceOption {
+	return func(p *RaceProvider) {
+		p.policy = policy
+	}
+}
+
+// RaceProvider speculatively sends each request to a fast, cheap contender and a
+// slow, strong one. Streams forward the fast response immediately; once both are
+// done the strong response is sent as well if the switch policy decides it
+// differs materially. Responses carry the winning contender, "fast" or "strong",
+// in the "race" message metadata.
+type RaceProvider struct {
+	fast   Contender
+	strong Contender
+	policy SwitchPolicy
+}
+
+// NewRaceProvider creates a RaceProvider switching when responses differ by more
+// than 50% of their words, unless another policy is set.
+func NewRaceProvider(fast, strong Contender, opts ...RaceOption) *RaceProvider {
+	p := &RaceProvider{fast: fast, strong: strong, policy: DifferencePolicy(0.5)}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+type raceResult struct {
+	res *ModelResponse
+	err error
+}
+
+// generateStrong runs the strong contender in the background.
+func (p *RaceProvider) generateStrong(ctx context.Context, req *ModelRequest, opts []ModelOption) <-chan raceResult {
+	ch := make(chan raceResult, 1)
+	go func() {
+		res, err := p.strong.Provider.Generate(ctx, p.strong.request(req), opts...)
+		ch <- raceResult{res, err}
+	}()
+	return ch
+}
+
+// decide returns the strong response if the policy prefers it over fast.
+func (p *RaceProvider) decide(fast *ModelResponse, strong raceResult) (*ModelResponse, bool) {
+	if strong.err != nil {
+		return fast, false
+	}
+	if fast == nil || p.policy.ShouldSwitch(fast, strong.res) {
+		return strong.res, true
+	}
+	return fast, false
+}
+
+// Generate runs both contenders concurrently and returns the fast response
+// unless the policy switches to the strong one. Either one failing falls back
+// to the other.
+func (p *RaceProvider) Generate(ctx context.Context, req *ModelRequest, opts ...ModelOption) (*ModelResponse, error) {
+	strong := p.generateStrong(ctx, req, opts)
+	fast, err := p.fast.Provider.Generate(ctx, p.fast.request(req), opts...)
+	result := <-strong
+	if err != nil {
+		if result.err != nil {
+			return nil, result.err
+		}
+		fast = nil
+	}
+	res, switched := p.decide(fast, result)
+	markRace(res, switched)
+	return res, nil
+}
+
+// NewStream streams the fast contender and appends the strong response when the
+// policy switches to it. Closing the stream early cancels the strong contender.
+func (p *RaceProvider) NewStream(ctx context.Context, req *ModelRequest, opts ...ModelOption) (Streamer[*ModelResponse], error) {
+	ctx, cancel := context.WithCancel(ctx)
+	strong := p.generateStrong(ctx, req, opts)
+	stream, err := p.fast.Provider.NewStream(ctx, p.fast.request(req), opts...)
+	pipe := NewStreamPipe[*ModelResponse]()
+	pipe.Go(func() error {
+		defer cancel()
+		var fast *ModelResponse
+		if err == nil {
+			fast, err = p.streamFast(stream, pipe)
+		}
+		result := <-strong
+		if err != nil {
+			if result.err != nil {
+				return errors.Join(err, result.err)
+			}
+			fast = nil
+		}
+		if fast == nil && result.err != nil {
+			return result.err
+		}
+		if res, switched := p.decide(fast, result); switched {
+			markRace(res, true)
+			pipe.Send(res)
+		}
+		return nil
+	})
+	return &cancelStream{Streamer: pipe, cancel: cancel}, nil
+}
+
+// streamFast forwards the fast stream and returns its completed messages.
+func (p *RaceProvider) streamFast(stream Streamer[*ModelResponse], pipe *StreamPipe[*ModelResponse]) (*ModelResponse, error) {
+	var fast *ModelResponse
+	for stream.Next() {
+		res, err := stream.Current()
+		if err != nil {
+			drain(stream)
+			return nil, err
+		}
+		if completed := completedMessages(res); len(completed.Messages) > 0 {
+			fast = completed
+			markRace(fast, false)
+		}
+		pipe.Send(res)
+	}
+	if err := stream.Close(); err != nil {
+		return nil, err
+	}
+	return fast, nil
+}
+
+func completedMessages(res *ModelResponse) *ModelResponse {
+	completed := &ModelResponse{Usage: res.Usage, Grounding: res.Grounding}
+	for _, msg := range res.Messages {
+		if msg.Status == StatusCompleted {
+			completed.Messages = append(completed.Messages, msg)
+		}
+	}
+	return completed
+}
+
+func markRace(res *ModelResponse, switched bool) {
+	winner := "fast"
+	if switched {
+		winner = "strong"
+	}
+	for _, msg := range res.Messages {
+		if msg.Metadata == nil {
+			msg.Metadata = make(map[string]string)
+		}
+		msg.Metadata["race"] = winner
+	}
+}
+
+func responseText(res *ModelResponse) string {
+	var buf strings.Builder
+	for _, msg := range res.Messages {
+		buf.WriteString(msg.Text())
+	}
+	return buf.String()
+}
+
+// jaccardDistance returns 1 minus the Jaccard similarity of the word sets of a and b.
+func jaccardDistance(a, b string) float64 {
+	words := func(s string) map[string]bool {
+		set := make(map[string]bool)
+		for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		}) {
+			set[w] = true
+		}
+		return set
+	}
+	wa, wb := words(a), words(b)
+	if len(wa) == 0 && len(wb) == 0 {
+		return 0
+	}
+	var common int
+	for w := range wa {
+		if wb[w] {
+			common++
+		}
+	}
+	return 1 - float64(common)/float64(len(wa)+len(wb)-common)
+}
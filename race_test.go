@@ -0,0 +1,168 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRaceProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		fast     string
+		strong   string
+		want     string
+		wantRace string
+	}{
+		{"keeps a similar fast response", "The capital is Canberra.", "The capital is Canberra, in the ACT.", "The capital is Canberra.", "fast"},
+		{"switches to a different strong response", "Sydney.", "The capital is Canberra.", "The capital is Canberra.", "strong"},
+	}
+	req := &ModelRequest{Messages: []*Message{UserMessage("capital of Australia?")}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			race := NewRaceProvider(Contender{Provider: textProvider(tt.fast)}, Contender{Provider: textProvider(tt.strong)})
+			res, err := race.Generate(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if msg := res.Messages[0]; msg.Text() != tt.want || msg.Metadata["race"] != tt.wantRace {
+				t.Fatalf("Generate: got %q from %q", msg.Text(), msg.Metadata["race"])
+			}
+			stream, err := race.NewStream(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var last *ModelResponse
+			for stream.Next() {
+				if last, err = stream.Current(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := stream.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if msg := last.Messages[0]; msg.Text() != tt.want || msg.Metadata["race"] != tt.wantRace {
+				t.Fatalf("NewStream: got %q from %q", msg.Text(), msg.Metadata["race"])
+			}
+		})
+	}
+}
+
+func TestRaceProvider_StreamErrors(t *testing.T) {
+	var (
+		errFast   = errors.New("fast")
+		errStrong = errors.New("strong")
+	)
+	tests := []struct {
+		name   string
+		fast   *fakeProvider
+		strong *fakeProvider
+		want   string
+		errs   []error
+	}{
+		{
+			name:   "falls back to strong when fast fails to close",
+			fast:   &fakeProvider{replies: []string{"Sydney."}, trailing: errFast},
+			strong: textProvider("Canberra."),
+			want:   "Canberra.",
+		},
+		{
+			name:   "reports both failures",
+			fast:   &fakeProvider{replies: []string{"Sydney."}, trailing: errFast},
+			strong: &fakeProvider{err: errStrong, failures: 1},
+			errs:   []error{errFast, errStrong},
+		},
+		{
+			name:   "falls back to strong when fast fails lazily",
+			fast:   &fakeProvider{err: errFast, failures: 1, lazy: true},
+			strong: textProvider("Canberra."),
+			want:   "Canberra.",
+		},
+	}
+	req := &ModelRequest{Messages: []*Message{UserMessage("capital of Australia?")}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			race := NewRaceProvider(Contender{Provider: tt.fast}, Contender{Provider: tt.strong})
+			stream, err := race.NewStream(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var last *ModelResponse
+			for stream.Next() {
+				last, _ = stream.Current()
+			}
+			err = stream.Close()
+			for _, want := range tt.errs {
+				if !errors.Is(err, want) {
+					t.Fatalf("expected %v, got %v", want, err)
+				}
+			}
+			if tt.errs != nil {
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if msg := last.Messages[0]; msg.Text() != tt.want || msg.Metadata["race"] != "strong" {
+				t.Fatalf("got %q from %q", msg.Text(), msg.Metadata["race"])
+			}
+		})
+	}
+}
+
+func TestRaceProvider_StreamClosedEarly(t *testing.T) {
+	strong := &fakeProvider{replies: []string{"Canberra."}, delay: time.Minute}
+	race := NewRaceProvider(Contender{Provider: textProvider("Canberra.")}, Contender{Provider: strong})
+	stream, err := race.NewStream(context.Background(), &ModelRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stream.Next() {
+		t.Fatal("expected the fast response")
+	}
+	stream.Close()
+	deadline := time.Now().Add(time.Second)
+	for strong.Canceled() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the strong call to be canceled")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRaceProvider_StreamClosedAfterFirstChunk(t *testing.T) {
+	chunked := &chunkedProvider{ModelProvider: textProvider("Canberra."), chunks: 64, done: make(chan struct{})}
+	race := NewRaceProvider(Contender{Provider: chunked}, Contender{Provider: textProvider("Canberra.")})
+	stream, err := race.NewStream(context.Background(), &ModelRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stream.Next() {
+		t.Fatal("expected the first chunk")
+	}
+	stream.Close()
+	// the producer keeps forwarding the fast stream after the close
+	<-chunked.done
+}
+
+// chunkedProvider streams chunks incomplete messages before the completed one,
+// closing done once its producer returns.
+type chunkedProvider struct {
+	ModelProvider
+	chunks int
+	done   chan struct{}
+}
+
+func (p *chunkedProvider) NewStream(ctx context.Context, req *ModelRequest, opts ...ModelOption) (Streamer[*ModelResponse], error) {
+	pipe := NewStreamPipe[*ModelResponse]()
+	pipe.Go(func() error {
+		defer close(p.done)
+		for range p.chunks {
+			pipe.Send(&ModelResponse{Messages: []*Message{{Role: RoleAssistant, Status: StatusIncomplete, Parts: Parts("Canberra")}}})
+		}
+		pipe.Send(textResponse("Canberra."))
+		return nil
+	})
+	return pipe, nil
+}
@@ -0,0 +1,44 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kratos/blades/document"
+	"github.com/go-kratos/blades/vectorstore"
+)
+
+// ingestBatchSize is the number of chunks embedded per call.
+const ingestBatchSize = 64
+
+// Ingest splits docs with splitter, embeds the chunks and upserts them into
+// store, returning the number of chunks stored. A nil splitter stores the
+// documents whole.
+func Ingest(ctx context.Context, embedder Embedder, store vectorstore.VectorStore, splitter document.Splitter, docs []*document.Document) (int, error) {
+	chunks := docs
+	if splitter != nil {
+		chunks = splitter.Split(docs)
+	}
+	for start := 0; start < len(chunks); start += ingestBatchSize {
+		batch := chunks[start:min(start+ingestBatchSize, len(chunks))]
+		texts := make([]string, len(batch))
+		for i, chunk := range batch {
+			texts[i] = chunk.Content
+		}
+		vectors, err := embedder.Embed(ctx, texts)
+		if err != nil {
+			return start, fmt.Errorf("rag: embed chunks: %w", err)
+		}
+		if len(vectors) != len(batch) {
+			return start, fmt.Errorf("rag: embed chunks: got %d vectors for %d chunks", len(vectors), len(batch))
+		}
+		records := make([]vectorstore.Record, len(batch))
+		for i, chunk := range batch {
+			records[i] = vectorstore.Record{ID: chunk.ID, Vector: vectors[i], Content: chunk.Content, Metadata: chunk.Metadata}
+		}
+		if err := store.Upsert(ctx, records...); err != nil {
+			return start, fmt.Errorf("rag: upsert chunks: %w", err)
+		}
+	}
+	return len(chunks), nil
+}
@@ -0,0 +1,229 @@
+// Package rag assembles retrieved documents into model context.
+package rag
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/go-kratos/blades/document"
+)
+
+// Chunk is a retrieved document with its relevance score, higher being more relevant.
+type Chunk struct {
+	Document *document.Document
+	Score    float64
+}
+
+// Source returns the "source" metadata of the document, or its ID.
+func (c Chunk) Source() string {
+	if source, ok := c.Document.Metadata["source"].(string); ok && source != "" {
+		return source
+	}
+	return c.Document.ID
+}
+
+// PackOption configures a Packer.
+type PackOption func(*Packer)
+
+// WithTokenCounter sets the function counting the tokens of a chunk.
+func WithTokenCounter(count func(text string) int) PackOption {
+	return func(p *Packer) {
+		p.count = count
+	}
+}
+
+// WithDuplicateThreshold sets the word overlap above which a chunk is dropped
+// as a near duplicate of a chunk already packed.
+func WithDuplicateThreshold(threshold float64) PackOption {
+	return func(p *Packer) {
+		p.duplicate = threshold
+	}
+}
+
+// WithDiversity sets how much similarity to packed chunks lowers the value of
+// a chunk, from 0 (pure relevance) to 1 (pure novelty).
+func WithDiversity(diversity float64) PackOption {
+	return func(p *Packer) {
+		p.diversity = diversity
+	}
+}
+
+// WithSourcePenalty sets how much each chunk already packed from the same
+// source lowers the value of a chunk, balancing sources.
+func WithSourcePenalty(penalty float64) PackOption {
+	return func(p *Packer) {
+		p.sourcePenalty = penalty
+	}
+}
+
+// WithMaxPerSource caps the number of chunks packed from one source.
+func WithMaxPerSource(n int) PackOption {
+	return func(p *Packer) {
+		p.maxPerSource = n
+	}
+}
+
+// WithEdgeOrdering places the most relevant chunks at the start and end of the
+// context and the least relevant in the middle, where models attend least.
+func WithEdgeOrdering() PackOption {
+	return func(p *Packer) {
+		p.edges = true
+	}
+}
+
+// Packer selects and orders retrieved chunks to fit a token budget. Instead of
+// taking the top-k, it greedily packs the chunk of highest marginal value:
+// relevance, lowered by its similarity to chunks already packed (maximal
+// marginal relevance) and by the number of chunks packed from its source.
+// Near duplicates and chunks over the remaining budget are skipped.
+type Packer struct {
+	count         func(string) int
+	duplicate     float64
+	diversity     float64
+	sourcePenalty float64
+	maxPerSource  int
+	edges         bool
+}
+
+// NewPacker creates a Packer with a diversity of 0.3, a source penalty of
+// 0.05, a duplicate threshold of 0.8 and tokens estimated from text length.
+func NewPacker(opts ...PackOption) *Packer {
+	p := &Packer{
+		count:         func(text string) int { return (len(text) + 3) / 4 },
+		duplicate:     0.8,
+		diversity:     0.3,
+		sourcePenalty: 0.05,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+type candidate struct {
+	chunk  Chunk
+	tokens int
+	words  map[string]bool
+	score  float64 // normalized relevance
+}
+
+// Pack returns the chunks to include within budget tokens, in context order.
+func (p *Packer) Pack(chunks []Chunk, budget int) []Chunk {
+	if len(chunks) == 0 {
+		return nil
+	}
+	lo, hi := chunks[0].Score, chunks[0].Score
+	for _, c := range chunks {
+		lo, hi = min(lo, c.Score), max(hi, c.Score)
+	}
+	candidates := make([]*candidate, 0, len(chunks))
+	for _, c := range chunks {
+		norm := 1.0
+		if hi > lo {
+			norm = (c.Score - lo) / (hi - lo)
+		}
+		candidates = append(candidates, &candidate{
+			chunk:  c,
+			tokens: p.count(c.Document.Content),
+			words:  wordSet(c.Document.Content),
+			score:  norm,
+		})
+	}
+	var (
+		packed  []*candidate
+		sources = make(map[string]int)
+	)
+	for len(candidates) > 0 {
+		best, bestValue := -1, 0.0
+		for i := 0; i < len(candidates); i++ {
+			c := candidates[i]
+			similarity := 0.0
+			for _, s := range packed {
+				similarity = max(similarity, jaccard(c.words, s.words))
+			}
+			source := c.chunk.Source()
+			if c.tokens > budget || similarity >= p.duplicate || (p.maxPerSource > 0 && sources[source] >= p.maxPerSource) {
+				candidates = slices.Delete(candidates, i, i+1)
+				i--
+				continue
+			}
+			value := (1-p.diversity)*c.score - p.diversity*similarity - p.sourcePenalty*float64(sources[source])
+			if best < 0 || value > bestValue {
+				best, bestValue = i, value
+			}
+		}
+		if best < 0 {
+			break
+		}
+		c := candidates[best]
+		candidates = slices.Delete(candidates, best, best+1)
+		packed = append(packed, c)
+		sources[c.chunk.Source()]++
+		budget -= c.tokens
+	}
+	slices.SortStableFunc(packed, func(a, b *candidate) int {
+		switch {
+		case a.chunk.Score > b.chunk.Score:
+			return -1
+		case a.chunk.Score < b.chunk.Score:
+			return 1
+		}
+		return 0
+	})
+	result := make([]Chunk, len(packed))
+	if !p.edges {
+		for i, c := range packed {
+			result[i] = c.chunk
+		}
+		return result
+	}
+	// alternate the ranked chunks between the front and the back
+	front, back := 0, len(packed)-1
+	for i, c := range packed {
+		if i%2 == 0 {
+			result[front] = c.chunk
+			front++
+		} else {
+			result[back] = c.chunk
+			back--
+		}
+	}
+	return result
+}
+
+// Format renders chunks as numbered context entries citing their sources.
+func Format(chunks []Chunk) string {
+	var buf strings.Builder
+	for i, c := range chunks {
+		if i > 0 {
+			buf.WriteString("\n\n")
+		}
+		fmt.Fprintf(&buf, "[%d] %s\n%s", i+1, c.Source(), c.Document.Content)
+	}
+	return buf.String()
+}
+
+func wordSet(text string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		set[w] = true
+	}
+	return set
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	var common int
+	for w := range a {
+		if b[w] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
@@ -0,0 +1,35 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/blades/document"
+)
+
+func chunk(source, content string, score float64) Chunk {
+	return Chunk{Document: document.New(content, map[string]any{"source": source}), Score: score}
+}
+
+func TestPacker_Pack(t *testing.T) {
+	chunks := []Chunk{
+		chunk("a", "the eiffel tower is 330 metres tall", 0.9),
+		chunk("a", "the eiffel tower is 330 metres tall!", 0.89), // near duplicate
+		chunk("b", "gustave eiffel's company built the tower in 1889", 0.8),
+		chunk("c", strings.Repeat("filler text about paris ", 40), 0.7), // over budget
+		chunk("d", "it was the tallest structure until 1930", 0.5),
+	}
+	packed := NewPacker().Pack(chunks, 40)
+	var got []string
+	for _, c := range packed {
+		got = append(got, c.Source())
+	}
+	if strings.Join(got, ",") != "a,b,d" {
+		t.Fatalf("unexpected packed sources %v", got)
+	}
+
+	edges := NewPacker(WithEdgeOrdering()).Pack(chunks, 40)
+	if edges[0].Source() != "a" || edges[len(edges)-1].Source() != "b" {
+		t.Fatalf("expected the best chunks at both edges, got %v", Format(edges))
+	}
+}
@@ -0,0 +1,80 @@
+package rag
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-kratos/blades"
+)
+
+var (
+	_ Reranker = (*LLMReranker)(nil)
+)
+
+// Reranker reorders retrieved chunks by their relevance to a query, typically
+// with a cross-encoder more accurate than vector similarity.
+type Reranker interface {
+	// Rerank returns the topN chunks most relevant to query, most relevant
+	// first, with their scores replaced by the reranker relevance.
+	Rerank(ctx context.Context, query string, chunks []Chunk, topN int) ([]Chunk, error)
+}
+
+// RerankerFunc adapts a function to a Reranker.
+type RerankerFunc func(ctx context.Context, query string, chunks []Chunk, topN int) ([]Chunk, error)
+
+// Rerank calls f(ctx, query, chunks, topN).
+func (f RerankerFunc) Rerank(ctx context.Context, query string, chunks []Chunk, topN int) ([]Chunk, error) {
+	return f(ctx, query, chunks, topN)
+}
+
+// LLMReranker is a Reranker asking a model to grade the relevance of each
+// chunk, for deployments without a dedicated reranking model.
+type LLMReranker struct {
+	runner blades.Runner
+}
+
+// NewLLMReranker creates an LLMReranker grading chunks with runner.
+func NewLLMReranker(runner blades.Runner) *LLMReranker {
+	return &LLMReranker{runner: runner}
+}
+
+type relevance struct {
+	Scores []float64 `json:"scores" jsonschema:"the relevance of each passage to the query from 0 to 10, in the order of the passages"`
+}
+
+// Rerank grades all chunks in a single request.
+func (r *LLMReranker) Rerank(ctx context.Context, query string, chunks []Chunk, topN int) ([]Chunk, error) {
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Grade how relevant each passage is to answering the query, from 0 (irrelevant) to 10 (answers it fully).\n\nQuery: %s\n", query)
+	for i, c := range chunks {
+		fmt.Fprintf(&buf, "\nPassage %d:\n%s\n", i+1, c.Document.Content)
+	}
+	res, err := blades.GenerateObject[relevance](ctx, r.runner, blades.NewPrompt(blades.UserMessage(buf.String())))
+	if err != nil {
+		return nil, fmt.Errorf("rag: rerank: %w", err)
+	}
+	if len(res.Scores) != len(chunks) {
+		return nil, fmt.Errorf("rag: rerank: got %d scores for %d passages", len(res.Scores), len(chunks))
+	}
+	ranked := make([]Chunk, len(chunks))
+	for i, c := range chunks {
+		ranked[i] = Chunk{Document: c.Document, Score: res.Scores[i] / 10}
+	}
+	return topChunks(ranked, topN), nil
+}
+
+// topChunks sorts chunks by decreasing score and keeps the topN, all when topN
+// is not positive.
+func topChunks(chunks []Chunk, topN int) []Chunk {
+	slices.SortStableFunc(chunks, func(a, b Chunk) int { return cmp.Compare(b.Score, a.Score) })
+	if topN > 0 && len(chunks) > topN {
+		chunks = chunks[:topN]
+	}
+	return chunks
+}
@@ -0,0 +1,187 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/document"
+	"github.com/go-kratos/blades/vectorstore"
+)
+
+var (
+	_ blades.Runner = (*Retriever)(nil)
+)
+
+// Embedder embeds texts as vectors.
+type Embedder = blades.Embedder
+
+// EmbedderFunc adapts a function to an Embedder.
+type EmbedderFunc = blades.EmbedderFunc
+
+// DefaultTemplate is the template of prompts augmented with retrieved context.
+// It is executed with the Context, formatted by Format, and the Question.
+const DefaultTemplate = `Answer the question using the context below. Cite the sources you use by their number, and say so if the context does not contain the answer.
+
+Context:
+{{.Context}}
+
+Question: {{.Question}}`
+
+// RetrieverOption configures a Retriever.
+type RetrieverOption func(*Retriever)
+
+// WithTopK sets the number of records retrieved, 4 by default.
+func WithTopK(k int) RetrieverOption {
+	return func(r *Retriever) {
+		r.topK = k
+	}
+}
+
+// WithTemplate sets the text/template of augmented prompts, see DefaultTemplate.
+func WithTemplate(tmpl string) RetrieverOption {
+	return func(r *Retriever) {
+		r.template = tmpl
+	}
+}
+
+// WithQueryOptions sets the options of the vector store queries, e.g. a filter.
+func WithQueryOptions(opts ...vectorstore.QueryOption) RetrieverOption {
+	return func(r *Retriever) {
+		r.queryOpts = append(r.queryOpts, opts...)
+	}
+}
+
+// WithPacker packs the retrieved chunks within budget tokens with packer
+// instead of injecting all of them. Retrieve more chunks with WithTopK for
+// the packer to choose from.
+func WithPacker(packer *Packer, budget int) RetrieverOption {
+	return func(r *Retriever) {
+		r.packer, r.budget = packer, budget
+	}
+}
+
+// WithReranker reranks the retrieved chunks with reranker, keeping the topN
+// most relevant, before they are packed or injected. Retrieve more chunks with
+// WithTopK for the reranker to choose from.
+func WithReranker(reranker Reranker, topN int) RetrieverOption {
+	return func(r *Retriever) {
+		r.reranker, r.rerankTopN = reranker, topN
+	}
+}
+
+// Retriever is a Runner augmenting prompts with retrieved context: it embeds
+// the last user message, queries a vector store, renders the top records into
+// the message with a template and delegates to the wrapped runner.
+type Retriever struct {
+	embedder  Embedder
+	store     vectorstore.VectorStore
+	runner    blades.Runner
+	topK      int
+	template  string
+	queryOpts []vectorstore.QueryOption
+	packer    *Packer
+	budget    int
+
+	reranker   Reranker
+	rerankTopN int
+}
+
+// NewRetriever creates a Retriever querying store and delegating to runner.
+func NewRetriever(embedder Embedder, store vectorstore.VectorStore, runner blades.Runner, opts ...RetrieverOption) *Retriever {
+	r := &Retriever{embedder: embedder, store: store, runner: runner, topK: 4, template: DefaultTemplate}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Retrieve returns the chunks retrieved for query, most relevant first, or
+// packed when a Packer is set. Chunks are reranked when a Reranker is set.
+func (r *Retriever) Retrieve(ctx context.Context, query string) ([]Chunk, error) {
+	vectors, err := r.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("rag: embed query: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("rag: embed query: got %d vectors", len(vectors))
+	}
+	results, err := r.store.Query(ctx, vectors[0], r.topK, r.queryOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("rag: query: %w", err)
+	}
+	chunks := make([]Chunk, 0, len(results))
+	for _, result := range results {
+		doc := &document.Document{ID: result.ID, Content: result.Content, Metadata: result.Metadata}
+		chunks = append(chunks, Chunk{Document: doc, Score: result.Score})
+	}
+	if r.reranker != nil {
+		if chunks, err = r.reranker.Rerank(ctx, query, chunks, r.rerankTopN); err != nil {
+			return nil, err
+		}
+	}
+	if r.packer != nil {
+		chunks = r.packer.Pack(chunks, r.budget)
+	}
+	return chunks, nil
+}
+
+// Run augments the prompt and runs the wrapped runner.
+func (r *Retriever) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	augmented, err := r.augment(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	return r.runner.Run(ctx, augmented, opts...)
+}
+
+// RunStream augments the prompt and streams the wrapped runner.
+func (r *Retriever) RunStream(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (blades.Streamer[*blades.Generation], error) {
+	augmented, err := r.augment(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	return r.runner.RunStream(ctx, augmented, opts...)
+}
+
+// augment returns a copy of the prompt whose last user message is rendered
+// with the context retrieved for its text. Other parts of the message, such as
+// files, are kept.
+func (r *Retriever) augment(ctx context.Context, prompt *blades.Prompt) (*blades.Prompt, error) {
+	tmpl, err := template.New("rag").Parse(r.template)
+	if err != nil {
+		return nil, fmt.Errorf("rag: template: %w", err)
+	}
+	last := -1
+	for i, msg := range prompt.Messages {
+		if msg.Role == blades.RoleUser {
+			last = i
+		}
+	}
+	if last < 0 || prompt.Messages[last].Text() == "" {
+		return prompt, nil
+	}
+	question := prompt.Messages[last].Text()
+	chunks, err := r.Retrieve(ctx, question)
+	if err != nil {
+		return nil, err
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, struct{ Context, Question string }{Format(chunks), question}); err != nil {
+		return nil, fmt.Errorf("rag: template: %w", err)
+	}
+	msg := *prompt.Messages[last]
+	msg.Parts = []blades.Part{blades.TextPart{Text: buf.String()}}
+	for _, part := range prompt.Messages[last].Parts {
+		if _, ok := part.(blades.TextPart); !ok {
+			msg.Parts = append(msg.Parts, part)
+		}
+	}
+	augmented := *prompt
+	augmented.Messages = slices.Clone(prompt.Messages)
+	augmented.Messages[last] = &msg
+	return &augmented, nil
+}
@@ -0,0 +1,84 @@
+package rag
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/document"
+	"github.com/go-kratos/blades/vectorstore"
+)
+
+// echoRunner replies with the text of the last message it receives.
+type echoRunner struct{}
+
+func (echoRunner) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	text := prompt.Messages[len(prompt.Messages)-1].Text()
+	return &blades.Generation{Messages: []*blades.Message{blades.AssistantMessage(text)}}, nil
+}
+
+func (echoRunner) RunStream(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (blades.Streamer[*blades.Generation], error) {
+	return nil, nil
+}
+
+func TestRetriever(t *testing.T) {
+	ctx := context.Background()
+	embed := EmbedderFunc(func(ctx context.Context, texts []string) ([][]float32, error) {
+		vectors := make([][]float32, len(texts))
+		for i, text := range texts {
+			if strings.Contains(strings.ToLower(text), "eiffel") {
+				vectors[i] = []float32{1, 0}
+			} else {
+				vectors[i] = []float32{0, 1}
+			}
+		}
+		return vectors, nil
+	})
+	store := vectorstore.NewMemoryStore()
+	n, err := Ingest(ctx, embed, store, document.NewRecursiveSplitter(document.WithChunkSize(40), document.WithChunkOverlap(0)), []*document.Document{
+		document.New("The Eiffel Tower is 330 metres tall.\n\nRome was not built in a day.", map[string]any{"source": "wiki/eiffel"}),
+	})
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 chunks ingested, got %d: %v", n, err)
+	}
+	retriever := NewRetriever(embed, store, echoRunner{}, WithTopK(1))
+	prompt := blades.NewPrompt(blades.UserMessage("How tall is the Eiffel Tower?"))
+	g, err := retriever.Run(ctx, prompt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := g.Text()
+	if !strings.Contains(text, "330 metres") || strings.Contains(text, "Rome") || !strings.Contains(text, "Question: How tall is the Eiffel Tower?") {
+		t.Fatalf("unexpected augmented prompt: %s", text)
+	}
+	if prompt.Messages[0].Text() != "How tall is the Eiffel Tower?" {
+		t.Fatalf("the original prompt was modified")
+	}
+}
+
+func TestRetriever_Rerank(t *testing.T) {
+	ctx := context.Background()
+	embed := EmbedderFunc(func(ctx context.Context, texts []string) ([][]float32, error) {
+		return [][]float32{{1, 0}}, nil
+	})
+	store := vectorstore.NewMemoryStore()
+	_ = store.Upsert(ctx,
+		vectorstore.Record{ID: "close", Vector: []float32{1, 0}, Content: "similar but useless"},
+		vectorstore.Record{ID: "far", Vector: []float32{1, 1}, Content: "the actual answer"},
+	)
+	// rank the chunks in reverse order of similarity
+	reverse := RerankerFunc(func(ctx context.Context, query string, chunks []Chunk, topN int) ([]Chunk, error) {
+		for i := range chunks {
+			chunks[i].Score = float64(i)
+		}
+		return topChunks(chunks, topN), nil
+	})
+	chunks, err := NewRetriever(embed, store, echoRunner{}, WithReranker(reverse, 1)).Retrieve(ctx, "question")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 1 || chunks[0].Document.ID != "far" {
+		t.Fatalf("expected the reranked chunk, got %v", Format(chunks))
+	}
+}
@@ -0,0 +1,214 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	_ ModelProvider = (*RateLimitedProvider)(nil)
+)
+
+var (
+	// ErrRateLimited indicates a call was rejected because it would exceed a rate limit.
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// RateLimitOption configures a RateLimitedProvider.
+type RateLimitOption func(*RateLimitedProvider)
+
+// RequestsPerMinute limits the number of calls per minute.
+func RequestsPerMinute(n int) RateLimitOption {
+	return func(p *RateLimitedProvider) {
+		p.requests = newTokenBucket(float64(n))
+	}
+}
+
+// TokensPerMinute limits the number of prompt and completion tokens per minute.
+// Tokens are estimated before a call and corrected with the usage reported by
+// the provider once it completes.
+func TokensPerMinute(n int) RateLimitOption {
+	return func(p *RateLimitedProvider) {
+		p.tokens = newTokenBucket(float64(n))
+	}
+}
+
+// RejectOverLimit rejects calls over the limits with ErrRateLimited instead of
+// queuing them until capacity is available.
+func RejectOverLimit() RateLimitOption {
+	return func(p *RateLimitedProvider) {
+		p.reject = true
+	}
+}
+
+// RateLimitedProvider enforces requests-per-minute and tokens-per-minute limits
+// on a provider with token buckets, so that chains with many steps stay within
+// vendor quotas. Calls over the limits wait for capacity unless RejectOverLimit is set.
+type RateLimitedProvider struct {
+	provider ModelProvider
+	requests *tokenBucket
+	tokens   *tokenBucket
+	reject   bool
+}
+
+// NewRateLimitedProvider wraps provider with rate limits.
+func NewRateLimitedProvider(provider ModelProvider, opts ...RateLimitOption) *RateLimitedProvider {
+	p := &RateLimitedProvider{provider: provider}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Generate waits for capacity and executes the request.
+func (p *RateLimitedProvider) Generate(ctx context.Context, req *ModelRequest, opts ...ModelOption) (*ModelResponse, error) {
+	estimate, err := p.acquire(ctx, req, opts)
+	if err != nil {
+		return nil, err
+	}
+	res, err := p.provider.Generate(ctx, req, opts...)
+	if err == nil {
+		p.settle(estimate, res.Usage)
+	}
+	return res, err
+}
+
+// NewStream waits for capacity and executes the streaming request.
+func (p *RateLimitedProvider) NewStream(ctx context.Context, req *ModelRequest, opts ...ModelOption) (Streamer[*ModelResponse], error) {
+	estimate, err := p.acquire(ctx, req, opts)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := p.provider.NewStream(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewMappedStream(stream, func(res *ModelResponse) (*ModelResponse, error) {
+		if res.Usage != nil {
+			p.settle(estimate, res.Usage)
+		}
+		return res, nil
+	}), nil
+}
+
+// acquire takes a request and the estimated tokens of req from the buckets,
+// waiting for capacity or rejecting the call. It returns the estimate.
+func (p *RateLimitedProvider) acquire(ctx context.Context, req *ModelRequest, opts []ModelOption) (float64, error) {
+	estimate := estimateRequestTokens(req, opts)
+	if p.requests != nil {
+		if err := p.take(ctx, p.requests, 1); err != nil {
+			return 0, err
+		}
+	}
+	if p.tokens != nil {
+		if err := p.take(ctx, p.tokens, estimate); err != nil {
+			if p.requests != nil {
+				p.requests.refund(1)
+			}
+			return 0, err
+		}
+	}
+	return estimate, nil
+}
+
+func (p *RateLimitedProvider) take(ctx context.Context, bucket *tokenBucket, n float64) error {
+	if p.reject {
+		if !bucket.tryTake(n) {
+			return ErrRateLimited
+		}
+		return nil
+	}
+	wait := bucket.take(n)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		bucket.refund(n)
+		return ctx.Err()
+	}
+}
+
+// settle corrects the token bucket with the usage reported by the provider.
+func (p *RateLimitedProvider) settle(estimate float64, usage *Usage) {
+	if p.tokens == nil || usage == nil {
+		return
+	}
+	p.tokens.refund(estimate - float64(usage.TotalTokens))
+}
+
+// estimateRequestTokens approximates the prompt tokens of req plus the
+// maximum number of output tokens, when set.
+func estimateRequestTokens(req *ModelRequest, opts []ModelOption) float64 {
+	var opt ModelOptions
+	for _, apply := range opts {
+		apply(&opt)
+	}
+	var n int
+	for _, msg := range req.Messages {
+		for _, part := range msg.Parts {
+			if text, ok := part.(TextPart); ok {
+				n += len(text.Text)
+			}
+		}
+	}
+	return float64(estimateTokens(n)) + float64(opt.MaxOutputTokens)
+}
+
+// tokenBucket is a token bucket refilled at capacity tokens per minute.
+type tokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+func newTokenBucket(perMinute float64) *tokenBucket {
+	return &tokenBucket{capacity: perMinute, tokens: perMinute, last: time.Now()}
+}
+
+func (b *tokenBucket) refill() {
+	now := time.Now()
+	b.tokens = min(b.capacity, b.tokens+now.Sub(b.last).Minutes()*b.capacity)
+	b.last = now
+}
+
+// take removes n tokens, going into debt if needed, and returns how long to
+// wait until the debt is repaid. Requests larger than the bucket take it whole.
+func (b *tokenBucket) take(n float64) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	b.tokens -= min(n, b.capacity)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.capacity * float64(time.Minute))
+}
+
+// tryTake removes n tokens if available.
+func (b *tokenBucket) tryTake(n float64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	n = min(n, b.capacity)
+	if b.tokens < n {
+		return false
+	}
+	b.tokens -= n
+	return true
+}
+
+// refund returns n tokens to the bucket; a negative n takes them.
+func (b *tokenBucket) refund(n float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	b.tokens = min(b.capacity, b.tokens+n)
+}
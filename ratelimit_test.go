@@ -0,0 +1,29 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRateLimitedProvider(t *testing.T) {
+	provider := NewRateLimitedProvider(textProvider("ok"), RequestsPerMinute(2), RejectOverLimit())
+	for range 2 {
+		if _, err := provider.Generate(context.Background(), &ModelRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := provider.Generate(context.Background(), &ModelRequest{}); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	provider = NewRateLimitedProvider(textProvider("ok"), RequestsPerMinute(1))
+	if _, err := provider.Generate(context.Background(), &ModelRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Generate(ctx, &ModelRequest{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the queued call to be canceled, got %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package blades
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+var (
+	// ErrPromptNotFound is returned when a prompt name or version is not registered.
+	ErrPromptNotFound = errors.New("prompt not found")
+	// ErrNoPreviousVersion is returned when rolling back from the first version.
+	ErrNoPreviousVersion = errors.New("no previous prompt version")
+)
+
+// PromptVersion is an immutable revision of a named instruction template.
+type PromptVersion struct {
+	Name      string    `json:"name"`
+	Version   int       `json:"version"`
+	Template  string    `json:"template"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Render executes the version's template with the given variables.
+func (v *PromptVersion) Render(vars map[string]any) (string, error) {
+	t, err := template.New(fmt.Sprintf("%s@v%d", v.Name, v.Version)).Parse(v.Template)
+	if err != nil {
+		return "", err
+	}
+	var buf strings.Builder
+	if err := t.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// PromptRegistry versions instruction templates by name. Registering changed
+// content creates a new version; the active version is the latest one unless a
+// version is pinned, which makes pinning and rollback behave like code deploys.
+type PromptRegistry struct {
+	mu       sync.RWMutex
+	versions map[string][]*PromptVersion
+	pinned   map[string]int
+}
+
+// NewPromptRegistry creates an empty PromptRegistry.
+func NewPromptRegistry() *PromptRegistry {
+	return &PromptRegistry{
+		versions: make(map[string][]*PromptVersion),
+		pinned:   make(map[string]int),
+	}
+}
+
+// Register stores the template under name and returns its version. Registering
+// content identical to an existing version returns that version unchanged.
+func (r *PromptRegistry) Register(name, tmpl string) *PromptVersion {
+	sum := sha256.Sum256([]byte(tmpl))
+	hash := hex.EncodeToString(sum[:])
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, v := range r.versions[name] {
+		if v.Hash == hash {
+			return v
+		}
+	}
+	v := &PromptVersion{
+		Name:      name,
+		Version:   len(r.versions[name]) + 1,
+		Template:  tmpl,
+		Hash:      hash,
+		CreatedAt: Now(),
+	}
+	r.versions[name] = append(r.versions[name], v)
+	return v
+}
+
+// Active returns the pinned version of name, or the latest one if none is pinned.
+func (r *PromptRegistry) Active(name string) (*PromptVersion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	versions := r.versions[name]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrPromptNotFound, name)
+	}
+	if n, ok := r.pinned[name]; ok {
+		return versions[n-1], nil
+	}
+	return versions[len(versions)-1], nil
+}
+
+// Version returns a specific version of name.
+func (r *PromptRegistry) Version(name string, version int) (*PromptVersion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	versions := r.versions[name]
+	if version < 1 || version > len(versions) {
+		return nil, fmt.Errorf("%w: %s@v%d", ErrPromptNotFound, name, version)
+	}
+	return versions[version-1], nil
+}
+
+// Versions returns every version of name, oldest first.
+func (r *PromptRegistry) Versions(name string) []*PromptVersion {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]*PromptVersion(nil), r.versions[name]...)
+}
+
+// Pin makes version the active version of name until Unpin is called.
+func (r *PromptRegistry) Pin(name string, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if version < 1 || version > len(r.versions[name]) {
+		return fmt.Errorf("%w: %s@v%d", ErrPromptNotFound, name, version)
+	}
+	r.pinned[name] = version
+	return nil
+}
+
+// Unpin makes the latest version of name active again.
+func (r *PromptRegistry) Unpin(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pinned, name)
+}
+
+// Rollback pins the version preceding the active version of name and returns it.
+func (r *PromptRegistry) Rollback(name string) (*PromptVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	versions := r.versions[name]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrPromptNotFound, name)
+	}
+	active := len(versions)
+	if n, ok := r.pinned[name]; ok {
+		active = n
+	}
+	if active <= 1 {
+		return nil, fmt.Errorf("%w: %s", ErrNoPreviousVersion, name)
+	}
+	r.pinned[name] = active - 1
+	return versions[active-2], nil
+}
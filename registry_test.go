@@ -0,0 +1,33 @@
+package blades
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPromptRegistry_PinAndRollback(t *testing.T) {
+	reg := NewPromptRegistry()
+	v1 := reg.Register("support", "You are helpful.")
+	v2 := reg.Register("support", "You are helpful and concise.")
+	if again := reg.Register("support", "You are helpful."); again != v1 {
+		t.Fatalf("re-registering identical content should return v1, got v%d", again.Version)
+	}
+	active, err := reg.Active("support")
+	if err != nil || active != v2 {
+		t.Fatalf("expected v2 to be active, got %+v (%v)", active, err)
+	}
+	prev, err := reg.Rollback("support")
+	if err != nil || prev != v1 {
+		t.Fatalf("expected rollback to v1, got %+v (%v)", prev, err)
+	}
+	if _, err := reg.Rollback("support"); !errors.Is(err, ErrNoPreviousVersion) {
+		t.Fatalf("expected ErrNoPreviousVersion, got %v", err)
+	}
+	reg.Unpin("support")
+	if active, _ := reg.Active("support"); active != v2 {
+		t.Fatalf("expected v2 after unpin, got v%d", active.Version)
+	}
+	if _, err := reg.Active("missing"); !errors.Is(err, ErrPromptNotFound) {
+		t.Fatalf("expected ErrPromptNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-kratos/blades"
+)
+
+var (
+	_ FeedbackStore = (*MemoryStore)(nil)
+)
+
+var (
+	// ErrInvalidFeedback indicates feedback without a run ID or with invalid values.
+	ErrInvalidFeedback = errors.New("report: invalid feedback")
+)
+
+// Thumb is a thumbs-up or thumbs-down vote.
+type Thumb string
+
+const (
+	// ThumbUp approves a run.
+	ThumbUp Thumb = "up"
+	// ThumbDown rejects a run.
+	ThumbDown Thumb = "down"
+)
+
+// Feedback is a user's judgement of a run. Pass the run ID with
+// blades.ContextWithRunID when running to know which run to attach it to.
+type Feedback struct {
+	RunID     string    `json:"run_id"`
+	Thumb     Thumb     `json:"thumb,omitempty"`
+	Rating    int       `json:"rating,omitempty"` // from 1 to 5
+	Comment   string    `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate checks that the feedback refers to a run and judges it.
+func (f Feedback) Validate() error {
+	switch {
+	case f.RunID == "":
+		return fmt.Errorf("%w: missing run_id", ErrInvalidFeedback)
+	case f.Thumb != "" && f.Thumb != ThumbUp && f.Thumb != ThumbDown:
+		return fmt.Errorf("%w: thumb must be %q or %q", ErrInvalidFeedback, ThumbUp, ThumbDown)
+	case f.Rating < 0 || f.Rating > 5:
+		return fmt.Errorf("%w: rating must be between 1 and 5", ErrInvalidFeedback)
+	case f.Thumb == "" && f.Rating == 0 && f.Comment == "":
+		return fmt.Errorf("%w: empty feedback", ErrInvalidFeedback)
+	}
+	return nil
+}
+
+// Label returns "positive" for a thumbs-up or a rating of 4 or more, "negative"
+// for a thumbs-down or a rating of 2 or less, and "" otherwise.
+func (f Feedback) Label() string {
+	switch {
+	case f.Thumb == ThumbUp || f.Rating >= 4:
+		return "positive"
+	case f.Thumb == ThumbDown || (f.Rating > 0 && f.Rating <= 2):
+		return "negative"
+	}
+	return ""
+}
+
+// FeedbackStore persists feedback by run ID.
+type FeedbackStore interface {
+	AddFeedback(ctx context.Context, feedback Feedback) error
+	// Feedback returns the feedback of a run, oldest first.
+	Feedback(ctx context.Context, runID string) ([]Feedback, error)
+}
+
+// AddFeedback validates and stores the feedback.
+func (s *MemoryStore) AddFeedback(ctx context.Context, feedback Feedback) error {
+	if err := feedback.Validate(); err != nil {
+		return err
+	}
+	if feedback.CreatedAt.IsZero() {
+		feedback.CreatedAt = blades.Now()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.feedback == nil {
+		s.feedback = make(map[string][]Feedback)
+	}
+	s.feedback[feedback.RunID] = append(s.feedback[feedback.RunID], feedback)
+	return nil
+}
+
+// Feedback returns the feedback of a run.
+func (s *MemoryStore) Feedback(ctx context.Context, runID string) ([]Feedback, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Feedback(nil), s.feedback[runID]...), nil
+}
+
+// FeedbackHandler serves feedback over HTTP: POST a Feedback JSON object to
+// store it, or GET with a run_id query parameter to list the feedback of a run.
+func FeedbackHandler(store FeedbackStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			var feedback Feedback
+			if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&feedback); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := feedback.Validate(); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := store.AddFeedback(r.Context(), feedback); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodGet:
+			feedback, err := store.Feedback(r.Context(), r.URL.Query().Get("run_id"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(feedback)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// DatasetFormat selects the layout of exported datasets.
+type DatasetFormat int
+
+const (
+	// DatasetEval writes every run with feedback, with its label, rating and comments.
+	DatasetEval DatasetFormat = iota
+	// DatasetFineTune writes positively labeled runs as chat fine-tuning examples.
+	DatasetFineTune
+)
+
+type evalExample struct {
+	RunID    string     `json:"run_id"`
+	Runner   string     `json:"runner"`
+	Model    string     `json:"model,omitempty"`
+	Input    string     `json:"input"`
+	Output   string     `json:"output"`
+	Label    string     `json:"label,omitempty"`
+	Feedback []Feedback `json:"feedback"`
+}
+
+type chatExample struct {
+	Messages []chatMessage `json:"messages"`
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ExportDataset writes the records that have feedback as JSON lines. Records
+// need their content, see RecordContent. The label of a run is that of its
+// latest labeled feedback.
+func ExportDataset(ctx context.Context, w io.Writer, records []RunRecord, store FeedbackStore, format DatasetFormat) error {
+	enc := json.NewEncoder(w)
+	for _, record := range records {
+		if record.Failed() || record.Input == "" {
+			continue
+		}
+		feedback, err := store.Feedback(ctx, record.RunID)
+		if err != nil {
+			return err
+		}
+		if len(feedback) == 0 {
+			continue
+		}
+		var label string
+		for _, f := range feedback {
+			if l := f.Label(); l != "" {
+				label = l
+			}
+		}
+		var example any
+		switch format {
+		case DatasetFineTune:
+			if label != "positive" {
+				continue
+			}
+			example = chatExample{Messages: []chatMessage{
+				{Role: "user", Content: record.Input},
+				{Role: "assistant", Content: record.Output},
+			}}
+		default:
+			example = evalExample{
+				RunID:    record.RunID,
+				Runner:   record.Runner,
+				Model:    record.Model,
+				Input:    record.Input,
+				Output:   record.Output,
+				Label:    label,
+				Feedback: feedback,
+			}
+		}
+		if err := enc.Encode(example); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/blades"
+)
+
+type echoRunner struct{}
+
+func (echoRunner) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	return &blades.Generation{Messages: []*blades.Message{blades.AssistantMessage("echo: " + prompt.Messages[0].Text())}}, nil
+}
+
+func (echoRunner) RunStream(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (blades.Streamer[*blades.Generation], error) {
+	return nil, blades.ErrUnsupported
+}
+
+func TestFeedback_ExportDataset(t *testing.T) {
+	store := NewMemoryStore()
+	runner := NewRunner("echo", echoRunner{}, store, RecordContent())
+	ctx := blades.ContextWithRunID(context.Background(), "run-1")
+	if _, err := runner.Run(ctx, blades.NewPrompt(blades.UserMessage("hello"))); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := FeedbackHandler(store)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/feedback", strings.NewReader(`{"run_id":"run-1","thumb":"up","comment":"great"}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body)
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/feedback", strings.NewReader(`{"rating":9}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid feedback to be rejected, got %d", rec.Code)
+	}
+
+	records := store.records
+	var buf bytes.Buffer
+	if err := ExportDataset(context.Background(), &buf, records, store, DatasetFineTune); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"messages":[{"role":"user","content":"hello"},{"role":"assistant","content":"echo: hello"}]}` + "\n"
+	if buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+}
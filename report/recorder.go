@@ -0,0 +1,262 @@
+package report
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/blades"
+)
+
+var (
+	_ blades.Runner = (*Runner)(nil)
+	_ Store         = (*MemoryStore)(nil)
+)
+
+// RunRecord is the outcome of a single run of a chain or agent.
+type RunRecord struct {
+	RunID    string        `json:"run_id"`
+	Runner   string        `json:"runner"`
+	Model    string        `json:"model,omitempty"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Usage    blades.Usage  `json:"usage"`
+	Cost     float64       `json:"cost"`
+	Err      string        `json:"error,omitempty"`
+	// Input and Output are the prompt and response texts, kept when content recording is enabled.
+	Input  string `json:"input,omitempty"`
+	Output string `json:"output,omitempty"`
+	// Scores are the scores given to the steps of the run, see AddStepScore.
+	Scores []StepScore `json:"scores,omitempty"`
+}
+
+// StepScore is the score given to the output of a step of a run, e.g. by the
+// scorer of a flow.Chain.
+type StepScore struct {
+	Step  int     `json:"step"`
+	Name  string  `json:"name"`
+	Score float64 `json:"score"`
+	Notes string  `json:"notes,omitempty"`
+}
+
+type scoresKey struct{}
+
+// runScores collects the step scores of a recorded run.
+type runScores struct {
+	mu     sync.Mutex
+	scores []StepScore
+}
+
+// AddStepScore records the score of a step with the run of the Runner whose
+// context ctx derives from. It does nothing outside of a recorded run.
+func AddStepScore(ctx context.Context, score StepScore) {
+	if s, ok := ctx.Value(scoresKey{}).(*runScores); ok {
+		s.mu.Lock()
+		s.scores = append(s.scores, score)
+		s.mu.Unlock()
+	}
+}
+
+// Failed reports whether the run ended with an error.
+func (r RunRecord) Failed() bool {
+	return r.Err != ""
+}
+
+// Store persists run records.
+type Store interface {
+	Add(ctx context.Context, record RunRecord) error
+	// Records returns the records of runs started in [from, to).
+	Records(ctx context.Context, from, to time.Time) ([]RunRecord, error)
+}
+
+// MemoryStore is an in-memory Store and FeedbackStore.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	records  []RunRecord
+	feedback map[string][]Feedback
+}
+
+// NewMemoryStore creates a new MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Add appends the record.
+func (s *MemoryStore) Add(ctx context.Context, record RunRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
+
+// Records returns the records of runs started in [from, to).
+func (s *MemoryStore) Records(ctx context.Context, from, to time.Time) ([]RunRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.DeleteFunc(slices.Clone(s.records), func(r RunRecord) bool {
+		return r.Start.Before(from) || !r.Start.Before(to)
+	}), nil
+}
+
+// Option configures a Runner.
+type Option func(*Runner)
+
+// WithModel sets the model recorded for the runs and used for pricing.
+func WithModel(model string) Option {
+	return func(r *Runner) {
+		r.model = model
+	}
+}
+
+// WithPricing prices the usage of runs. Streams ending with a priced Finish keep its cost.
+func WithPricing(pricing blades.Pricing) Option {
+	return func(r *Runner) {
+		r.pricing = pricing
+	}
+}
+
+// RecordContent keeps the prompt and response texts in the records, e.g. to
+// export datasets from runs with feedback.
+func RecordContent() Option {
+	return func(r *Runner) {
+		r.content = true
+	}
+}
+
+// WithSampler records only the runs sampler keeps, looking up per-agent rates
+// by the name of the Runner.
+func WithSampler(sampler *blades.Sampler) Option {
+	return func(r *Runner) {
+		r.sampler = sampler
+	}
+}
+
+// Runner records a RunRecord for every run of the wrapped chain or agent. Runs
+// get a run ID unless the context already carries one, which is passed on to
+// the wrapped runner. Store errors do not fail the runs.
+type Runner struct {
+	name    string
+	runner  blades.Runner
+	store   Store
+	model   string
+	pricing blades.Pricing
+	content bool
+	sampler *blades.Sampler
+}
+
+// NewRunner wraps runner, recording its runs under name in store.
+func NewRunner(name string, runner blades.Runner, store Store, opts ...Option) *Runner {
+	r := &Runner{name: name, runner: runner, store: store}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run runs the wrapped runner and records the run.
+func (r *Runner) Run(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (*blades.Generation, error) {
+	ctx, record := r.start(ctx, prompt)
+	g, err := r.runner.Run(ctx, prompt, opts...)
+	if g != nil {
+		r.addUsage(&record, g.Usage)
+		if r.content {
+			record.Output = g.Text()
+		}
+	}
+	r.finish(ctx, record, err)
+	return g, err
+}
+
+// RunStream streams the wrapped runner and records the run once the stream ends.
+func (r *Runner) RunStream(ctx context.Context, prompt *blades.Prompt, opts ...blades.ModelOption) (blades.Streamer[*blades.Generation], error) {
+	ctx, record := r.start(ctx, prompt)
+	stream, err := r.runner.RunStream(ctx, prompt, opts...)
+	if err != nil {
+		r.finish(ctx, record, err)
+		return nil, err
+	}
+	pipe := blades.NewStreamPipe[*blades.Generation]()
+	pipe.Go(func() error {
+		var (
+			err    error
+			finish *blades.Finish
+			output string
+		)
+		for stream.Next() {
+			var g *blades.Generation
+			if g, err = stream.Current(); err != nil {
+				break
+			}
+			if g.Finish != nil {
+				finish = g.Finish
+			}
+			for _, msg := range g.Messages {
+				if msg.Role == blades.RoleAssistant && msg.Status == blades.StatusCompleted {
+					output = msg.Text()
+				}
+			}
+			pipe.Send(g)
+		}
+		if r.content {
+			record.Output = output
+		}
+		if cerr := stream.Close(); err == nil {
+			err = cerr
+		}
+		if finish != nil {
+			r.addUsage(&record, finish.Usage)
+			if finish.Cost > 0 {
+				record.Cost = finish.Cost
+			}
+		}
+		r.finish(ctx, record, err)
+		return err
+	})
+	return pipe, nil
+}
+
+func (r *Runner) start(ctx context.Context, prompt *blades.Prompt) (context.Context, RunRecord) {
+	runID, ok := blades.RunIDFromContext(ctx)
+	if !ok {
+		runID = blades.NewRunID()
+		ctx = blades.ContextWithRunID(ctx, runID)
+	}
+	ctx = context.WithValue(ctx, scoresKey{}, &runScores{})
+	record := RunRecord{RunID: runID, Runner: r.name, Model: r.model, Start: blades.Now()}
+	if r.content {
+		texts := make([]string, 0, len(prompt.Messages))
+		for _, msg := range prompt.Messages {
+			texts = append(texts, msg.Text())
+		}
+		record.Input = strings.Join(texts, "\n\n")
+	}
+	return ctx, record
+}
+
+func (r *Runner) addUsage(record *RunRecord, usage *blades.Usage) {
+	if usage == nil {
+		return
+	}
+	record.Usage.Add(usage)
+	if r.pricing != nil {
+		record.Cost = r.pricing.Cost(r.model, &record.Usage)
+	}
+}
+
+func (r *Runner) finish(ctx context.Context, record RunRecord, err error) {
+	record.Duration = blades.Since(record.Start)
+	if err != nil {
+		record.Err = err.Error()
+	}
+	if s, ok := ctx.Value(scoresKey{}).(*runScores); ok {
+		s.mu.Lock()
+		record.Scores = slices.Clone(s.scores)
+		s.mu.Unlock()
+	}
+	if r.sampler != nil && !r.sampler.Keep(record.RunID, r.name, err) {
+		return
+	}
+	_ = r.store.Add(context.WithoutCancel(ctx), record)
+}
@@ -0,0 +1,117 @@
+// Package report records the runs of chains and agents and aggregates them into
+// cost and latency reports for dashboards and chargeback.
+package report
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Row aggregates the runs of a runner on a day.
+type Row struct {
+	Runner           string  `json:"runner"`
+	Day              string  `json:"day"`
+	Runs             int     `json:"runs"`
+	Failures         int     `json:"failures"`
+	FailureRate      float64 `json:"failure_rate"`
+	Cost             float64 `json:"cost"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	P50LatencyMs     int64   `json:"p50_latency_ms"`
+	P95LatencyMs     int64   `json:"p95_latency_ms"`
+}
+
+// Aggregate groups records by runner and day in loc, or UTC when loc is nil.
+// Rows are sorted by day, then runner.
+func Aggregate(records []RunRecord, loc *time.Location) []Row {
+	if loc == nil {
+		loc = time.UTC
+	}
+	type key struct{ runner, day string }
+	var (
+		rows      []*Row
+		index     = make(map[key]*Row)
+		latencies = make(map[*Row][]time.Duration)
+	)
+	for _, record := range records {
+		k := key{record.Runner, record.Start.In(loc).Format(time.DateOnly)}
+		row, ok := index[k]
+		if !ok {
+			row = &Row{Runner: k.runner, Day: k.day}
+			index[k] = row
+			rows = append(rows, row)
+		}
+		row.Runs++
+		if record.Failed() {
+			row.Failures++
+		}
+		row.Cost += record.Cost
+		row.PromptTokens += record.Usage.PromptTokens
+		row.CompletionTokens += record.Usage.CompletionTokens
+		latencies[row] = append(latencies[row], record.Duration)
+	}
+	result := make([]Row, 0, len(rows))
+	for _, row := range rows {
+		row.FailureRate = float64(row.Failures) / float64(row.Runs)
+		durations := latencies[row]
+		slices.Sort(durations)
+		row.P50LatencyMs = percentile(durations, 50).Milliseconds()
+		row.P95LatencyMs = percentile(durations, 95).Milliseconds()
+		result = append(result, *row)
+	}
+	slices.SortFunc(result, func(a, b Row) int {
+		return cmp.Or(cmp.Compare(a.Day, b.Day), cmp.Compare(a.Runner, b.Runner))
+	})
+	return result
+}
+
+// percentile returns the nearest-rank percentile p of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// WriteJSON writes the rows as a JSON array.
+func WriteJSON(w io.Writer, rows []Row) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rows)
+}
+
+// WriteCSV writes the rows as CSV with a header line.
+func WriteCSV(w io.Writer, rows []Row) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{
+		"runner", "day", "runs", "failures", "failure_rate", "cost",
+		"prompt_tokens", "completion_tokens", "p50_latency_ms", "p95_latency_ms",
+	}); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := cw.Write([]string{
+			row.Runner,
+			row.Day,
+			strconv.Itoa(row.Runs),
+			strconv.Itoa(row.Failures),
+			strconv.FormatFloat(row.FailureRate, 'f', 4, 64),
+			strconv.FormatFloat(row.Cost, 'f', 6, 64),
+			strconv.FormatInt(row.PromptTokens, 10),
+			strconv.FormatInt(row.CompletionTokens, 10),
+			strconv.FormatInt(row.P50LatencyMs, 10),
+			strconv.FormatInt(row.P95LatencyMs, 10),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,46 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/blades"
+)
+
+func TestAggregate(t *testing.T) {
+	day := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	var records []RunRecord
+	for i := 1; i <= 20; i++ {
+		records = append(records, RunRecord{
+			Runner:   "summarize",
+			Start:    day.Add(time.Duration(i) * time.Minute),
+			Duration: time.Duration(i) * 100 * time.Millisecond,
+			Usage:    blades.Usage{PromptTokens: 10, CompletionTokens: 5},
+			Cost:     0.01,
+		})
+	}
+	records[0].Err = "timeout"
+	records = append(records, RunRecord{Runner: "summarize", Start: day.AddDate(0, 0, 1), Duration: time.Second})
+
+	rows := Aggregate(records, nil)
+	if len(rows) != 2 || rows[0].Day != "2025-03-01" || rows[1].Day != "2025-03-02" {
+		t.Fatalf("unexpected rows %+v", rows)
+	}
+	first := rows[0]
+	if first.Runs != 20 || first.Failures != 1 || first.FailureRate != 0.05 || first.PromptTokens != 200 {
+		t.Fatalf("unexpected totals %+v", first)
+	}
+	if first.P50LatencyMs != 1000 || first.P95LatencyMs != 1900 {
+		t.Fatalf("unexpected latency percentiles %+v", first)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, rows); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 3 || !strings.HasPrefix(lines[1], "summarize,2025-03-01,20,1,") {
+		t.Fatalf("unexpected csv %q", buf.String())
+	}
+}
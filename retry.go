@@ -0,0 +1,148 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+var (
+	_ ModelProvider = (*RetryProvider)(nil)
+)
+
+// RetryPolicy configures retries of transient provider failures with
+// exponential backoff and jitter. Zero fields take their defaults.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, 3 by default.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry, 500ms by default.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts, 30s by default.
+	MaxBackoff time.Duration
+	// Multiplier grows the delay after each retry, 2 by default.
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction, 0.2 by default.
+	Jitter float64
+	// Retryable reports whether an error is transient, IsTransient by default.
+	Retryable func(error) bool
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = 500 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 30 * time.Second
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = 2
+	}
+	if p.Jitter <= 0 {
+		p.Jitter = 0.2
+	}
+	if p.Retryable == nil {
+		p.Retryable = IsTransient
+	}
+	return p
+}
+
+// backoff returns the delay before the given retry, starting at 1. A
+// Retry-After delay requested by the provider takes precedence.
+func (p RetryPolicy) backoff(retry int, err error) time.Duration {
+	var perr *ProviderError
+	if errors.As(err, &perr) && perr.RetryAfter > 0 {
+		return perr.RetryAfter
+	}
+	delay := float64(p.InitialBackoff)
+	for range retry - 1 {
+		delay *= p.Multiplier
+	}
+	delay = min(delay, float64(p.MaxBackoff))
+	delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	return time.Duration(delay)
+}
+
+// IsTransient reports whether err is a transient failure worth retrying: a
+// rate limit or server error, a timeout or a reset connection.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var perr *ProviderError
+	if errors.As(err, &perr) {
+		return perr.Transient()
+	}
+	var nerr net.Error
+	if errors.As(err, &nerr) && nerr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+// ParseRetryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func ParseRetryAfter(header http.Header) time.Duration {
+	v := header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+// RetryProvider retries transient failures of a provider. Streams are retried
+// only while they are being opened; failures after the first chunk pass through.
+type RetryProvider struct {
+	provider ModelProvider
+	policy   RetryPolicy
+}
+
+// NewRetryProvider wraps provider with the retry policy.
+func NewRetryProvider(provider ModelProvider, policy RetryPolicy) *RetryProvider {
+	return &RetryProvider{provider: provider, policy: policy.withDefaults()}
+}
+
+// Generate executes the request, retrying transient failures.
+func (p *RetryProvider) Generate(ctx context.Context, req *ModelRequest, opts ...ModelOption) (*ModelResponse, error) {
+	return retry(ctx, p.policy, func() (*ModelResponse, error) {
+		return p.provider.Generate(ctx, req, opts...)
+	})
+}
+
+// NewStream opens the stream, retrying transient failures until the first
+// chunk is received.
+func (p *RetryProvider) NewStream(ctx context.Context, req *ModelRequest, opts ...ModelOption) (Streamer[*ModelResponse], error) {
+	return retry(ctx, p.policy, func() (Streamer[*ModelResponse], error) {
+		return peekStream(p.provider.NewStream(ctx, req, opts...))
+	})
+}
+
+func retry[T any](ctx context.Context, policy RetryPolicy, fn func() (T, error)) (T, error) {
+	for attempt := 1; ; attempt++ {
+		v, err := fn()
+		if err == nil || attempt >= policy.MaxAttempts || !policy.Retryable(err) {
+			return v, err
+		}
+		select {
+		case <-time.After(policy.backoff(attempt, err)):
+		case <-ctx.Done():
+			return v, errors.Join(err, ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryProvider(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	tests := []struct {
+		name      string
+		status    int
+		failures  int
+		wantErr   bool
+		wantCalls int
+	}{
+		{"retries rate limits", http.StatusTooManyRequests, 2, false, 3},
+		{"gives up after the attempts", http.StatusServiceUnavailable, 3, true, 3},
+		{"does not retry client errors", http.StatusBadRequest, 1, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &fakeProvider{
+				replies:  []string{"ok"},
+				err:      &ProviderError{Provider: "test", StatusCode: tt.status, Err: errors.New(http.StatusText(tt.status))},
+				failures: tt.failures,
+			}
+			res, err := NewRetryProvider(provider, policy).Generate(context.Background(), &ModelRequest{})
+			if (err != nil) != tt.wantErr || provider.Calls() != tt.wantCalls {
+				t.Fatalf("got %v after %d calls, want error %v after %d", err, provider.Calls(), tt.wantErr, tt.wantCalls)
+			}
+			if err == nil && res.Messages[0].Text() != "ok" {
+				t.Fatalf("unexpected response %q", res.Messages[0].Text())
+			}
+		})
+	}
+}
+
+func TestRetryProvider_Stream(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	unavailable := &ProviderError{Provider: "test", StatusCode: http.StatusServiceUnavailable, Err: errors.New("unavailable")}
+	tests := []struct {
+		name string
+		lazy bool
+	}{
+		{"retries failures to open", false},
+		{"retries failures on the first read", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &fakeProvider{replies: []string{"ok"}, err: unavailable, failures: 2, lazy: tt.lazy}
+			stream, err := NewRetryProvider(provider, policy).NewStream(context.Background(), &ModelRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := lastResponse(stream)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Messages[0].Text() != "ok" || provider.Calls() != 3 {
+				t.Fatalf("got %q after %d calls", res.Messages[0].Text(), provider.Calls())
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package blades
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+)
+
+var (
+	_ slog.Handler = (*SamplingHandler)(nil)
+)
+
+// SamplerOption configures a Sampler.
+type SamplerOption func(*Sampler)
+
+// SampleAgent overrides the sampling rate of the runs of the named agent, or of
+// the runner recorded under that name.
+func SampleAgent(name string, rate float64) SamplerOption {
+	return func(s *Sampler) {
+		s.agents[name] = rate
+	}
+}
+
+// SampleErrors keeps the failed runs and the error records of every run,
+// whatever the rate.
+func SampleErrors() SamplerOption {
+	return func(s *Sampler) {
+		s.errors = true
+	}
+}
+
+// Sampler decides which runs are observed, to bound the storage of traces and
+// run records of high-traffic deployments. The decision is derived from the run
+// ID, so that every record of a run, across agents, chain steps and recorders,
+// is kept or dropped together.
+type Sampler struct {
+	rate   float64
+	agents map[string]float64
+	errors bool
+}
+
+// NewSampler creates a Sampler keeping the given fraction of runs, from 0 to 1.
+func NewSampler(rate float64, opts ...SamplerOption) *Sampler {
+	s := &Sampler{rate: rate, agents: make(map[string]float64)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Sampled reports whether the run of the named agent is sampled.
+func (s *Sampler) Sampled(runID, agent string) bool {
+	rate, ok := s.agents[agent]
+	if !ok {
+		rate = s.rate
+	}
+	switch {
+	case rate >= 1:
+		return true
+	case rate <= 0:
+		return false
+	}
+	h := fnv.New64a()
+	h.Write([]byte(runID))
+	return float64(h.Sum64()>>11)/(1<<53) < rate
+}
+
+// Keep reports whether the run of the named agent, which ended with err, is
+// recorded: when it is sampled, or when it failed and errors are always kept.
+func (s *Sampler) Keep(runID, agent string, err error) bool {
+	return (s.errors && err != nil) || s.Sampled(runID, agent)
+}
+
+// SamplingHandler is a slog.Handler dropping the records of the runs a Sampler
+// does not sample. Runs are identified by the run ID and agent of the context,
+// or of the run_id and agent attributes of the records; records outside runs
+// are always handled. With SampleErrors, records at error level are kept.
+type SamplingHandler struct {
+	handler slog.Handler
+	sampler *Sampler
+	runID   string
+	agent   string
+}
+
+// NewSamplingHandler wraps handler, sampling its records with sampler.
+func NewSamplingHandler(handler slog.Handler, sampler *Sampler) *SamplingHandler {
+	return &SamplingHandler{handler: handler, sampler: sampler}
+}
+
+// Enabled reports whether the wrapped handler handles records at level.
+func (h *SamplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// Handle passes the record to the wrapped handler when its run is sampled.
+func (h *SamplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	runID, agent := h.runID, h.agent
+	if id, ok := RunIDFromContext(ctx); ok {
+		runID = id
+	}
+	if ac, ok := FromContext(ctx); ok && ac.Name != "" {
+		agent = ac.Name
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		switch a.Key {
+		case "run_id":
+			runID = a.Value.String()
+		case "agent":
+			agent = a.Value.String()
+		}
+		return true
+	})
+	if runID == "" || (h.sampler.errors && r.Level >= slog.LevelError) || h.sampler.Sampled(runID, agent) {
+		return h.handler.Handle(ctx, r)
+	}
+	return nil
+}
+
+// WithAttrs returns a SamplingHandler wrapping the handler with attrs.
+func (h *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.handler = h.handler.WithAttrs(attrs)
+	for _, a := range attrs {
+		switch a.Key {
+		case "run_id":
+			c.runID = a.Value.String()
+		case "agent":
+			c.agent = a.Value.String()
+		}
+	}
+	return &c
+}
+
+// WithGroup returns a SamplingHandler wrapping the handler with the group.
+func (h *SamplingHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.handler = h.handler.WithGroup(name)
+	return &c
+}
@@ -0,0 +1,41 @@
+package blades
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSampler(t *testing.T) {
+	s := NewSampler(0.25, SampleAgent("critical", 1), SampleErrors())
+	var sampled int
+	for i := range 1000 {
+		runID := fmt.Sprintf("run-%d", i)
+		if s.Sampled(runID, "chat") {
+			sampled++
+		}
+		if s.Sampled(runID, "chat") != s.Sampled(runID, "chat") {
+			t.Fatal("expected the decision to be stable for a run")
+		}
+		if !s.Sampled(runID, "critical") || !s.Keep(runID, "chat", errors.New("boom")) {
+			t.Fatal("expected overridden agents and failed runs to be kept")
+		}
+	}
+	if sampled < 200 || sampled > 300 {
+		t.Fatalf("expected about a quarter of the runs sampled, got %d", sampled)
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(NewSamplingHandler(slog.NewTextHandler(&buf, nil), NewSampler(0, SampleErrors())))
+	ctx := ContextWithRunID(context.Background(), "run-1")
+	logger.InfoContext(ctx, "provider call")
+	logger.ErrorContext(ctx, "provider call failed")
+	logger.Info("startup")
+	if out := buf.String(); strings.Contains(out, "msg=\"provider call\"") || !strings.Contains(out, "provider call failed") || !strings.Contains(out, "startup") {
+		t.Fatalf("unexpected records %q", out)
+	}
+}
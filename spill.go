@@ -0,0 +1,97 @@
+package blades
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// WithSpillover moves content larger than limit bytes out of the messages of the
+// Agent into store, so that runs over very large documents do not hold them in
+// memory, nor in the memory of the conversation. Tool results are saved and
+// replaced by a reference to the artifact followed by a preview, which is what
+// the model reads; the text and data parts of answers are replaced by FileParts.
+// FileArtifactStore.Load reads spilled content back.
+func WithSpillover(store ArtifactStore, limit int) Option {
+	return func(a *Agent) {
+		a.spillover = &spillover{store: store, limit: limit}
+	}
+}
+
+type spillover struct {
+	store ArtifactStore
+	limit int
+}
+
+// spillResults replaces the oversized results of the tool message by references.
+func (s *spillover) spillResults(ctx context.Context, msg *Message) error {
+	if s == nil {
+		return nil
+	}
+	for _, call := range msg.ToolCalls {
+		if len(call.Result) <= s.limit {
+			continue
+		}
+		file, err := s.store.Save(ctx, DataPart{
+			Name:     call.Name + "-" + call.ID,
+			Bytes:    []byte(call.Result),
+			MimeType: DetectMimeType("", []byte(call.Result)),
+		})
+		if err != nil {
+			return fmt.Errorf("spillover: %w", err)
+		}
+		call.Result = fmt.Sprintf("[%d bytes saved to %s, beginning with:]\n%s", len(call.Result), file.URI, summarize(call.Result))
+	}
+	return nil
+}
+
+// spillAnswers replaces the oversized parts of the answers, the assistant
+// messages without tool calls, by FileParts.
+func (s *spillover) spillAnswers(ctx context.Context, messages []*Message) error {
+	if s == nil {
+		return nil
+	}
+	for _, msg := range messages {
+		if msg.Role != RoleAssistant || len(msg.ToolCalls) > 0 {
+			continue
+		}
+		for i, part := range msg.Parts {
+			var data DataPart
+			switch v := part.(type) {
+			case TextPart:
+				data = DataPart{Bytes: []byte(v.Text), MimeType: MimeText}
+			case DataPart:
+				data = v
+			default:
+				continue
+			}
+			if len(data.Bytes) <= s.limit {
+				continue
+			}
+			file, err := s.store.Save(ctx, data)
+			if err != nil {
+				return fmt.Errorf("spillover: %w", err)
+			}
+			msg.Parts[i] = file
+		}
+	}
+	return nil
+}
+
+// Load reads back an artifact saved by the store.
+func (s *FileArtifactStore) Load(ctx context.Context, file FilePart) (DataPart, error) {
+	u, err := url.Parse(file.URI)
+	if err != nil {
+		return DataPart{}, fmt.Errorf("artifact store: %w", err)
+	}
+	if u.Scheme != "file" {
+		return DataPart{}, fmt.Errorf("artifact store: unsupported uri %q", file.URI)
+	}
+	b, err := os.ReadFile(filepath.FromSlash(u.Path))
+	if err != nil {
+		return DataPart{}, fmt.Errorf("artifact store: %w", err)
+	}
+	return DataPart{Name: file.Name, Bytes: b, MimeType: file.MimeType}, nil
+}
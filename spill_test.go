@@ -0,0 +1,47 @@
+package blades
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestAgent_Spillover(t *testing.T) {
+	store, err := NewFileArtifactStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	large := strings.Repeat("sunny ", 100)
+	tool := &Tool{
+		Name: "weather",
+		Handle: func(ctx context.Context, args string) (string, error) {
+			return large, nil
+		},
+	}
+	agent := NewAgent("test", WithProvider(weatherProvider()), WithTools(tool), WithSpillover(store, 64))
+	res, err := agent.Run(context.Background(), NewPrompt(UserMessage("weather in Paris?")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The scripted model echoes the tool result it read, a reference to the artifact.
+	file := res.File()
+	if file == nil || file.MimeType != MimeText {
+		t.Fatalf("expected the answer to be spilled, got %+v", res.Messages)
+	}
+	data, err := store.Load(context.Background(), *file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	answer := string(data.Bytes)
+	if !strings.HasPrefix(answer, "weather is [600 bytes saved to file://") || len(answer) >= len(large) {
+		t.Fatalf("expected the tool result to be replaced by a reference, got %q", answer)
+	}
+	uri := strings.Fields(strings.TrimPrefix(answer, "weather is [600 bytes saved to "))[0]
+	result, err := store.Load(context.Background(), FilePart{URI: strings.TrimSuffix(uri, ",")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(result.Bytes) != large {
+		t.Fatalf("unexpected spilled tool result %q", result.Bytes)
+	}
+}
@@ -1,5 +1,7 @@
 package blades
 
+import "sync"
+
 // MappedStream maps the output of one Streamer to another type.
 type MappedStream[M any, T any] struct {
 	stream   Streamer[M]
@@ -32,47 +34,133 @@ func (ws *MappedStream[M, T]) Close() error {
 
 // StreamPipe directs the yielding of values.
 type StreamPipe[T any] struct {
+	mu    sync.Mutex
 	err   error
 	queue chan T
+	done  chan struct{}
 	next  T
+	once  sync.Once
 }
 
 // NewStreamPipe creates a new StreamPipe director.
 func NewStreamPipe[T any]() *StreamPipe[T] {
 	return &StreamPipe[T]{
 		queue: make(chan T, 8),
+		done:  make(chan struct{}),
 	}
 }
 
+// Send yields v to the consumer of the StreamPipe. Values sent once the pipe is
+// closed are dropped, so a consumer closing the stream early neither blocks nor
+// crashes the producer.
 func (d *StreamPipe[T]) Send(v T) {
-	d.queue <- v
+	select {
+	case d.queue <- v:
+	case <-d.done:
+	}
 }
 
 // Next returns true if there is a value to yield.
 func (d *StreamPipe[T]) Next() bool {
-	v, ok := <-d.queue
-	if !ok {
+	select {
+	case v := <-d.queue:
+		d.next = v
+		return true
+	case <-d.done:
+	}
+	// yield the values sent before the pipe was closed
+	select {
+	case v := <-d.queue:
+		d.next = v
+		return true
+	default:
 		return false
 	}
-	d.next = v
-	return true
 }
 
 // Current returns the value and marks it as yielded.
 func (d *StreamPipe[T]) Current() (T, error) {
-	return d.next, d.err
+	return d.next, d.error()
 }
 
 // Go runs the provided function in a goroutine, closing the StreamPipe when done.
 func (d *StreamPipe[T]) Go(fn func() error) {
 	go func() {
 		defer d.Close()
-		d.err = fn()
+		err := fn()
+		d.mu.Lock()
+		d.err = err
+		d.mu.Unlock()
 	}()
 }
 
-// Close closes the StreamPipe.
+func (d *StreamPipe[T]) error() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.err
+}
+
+// Close closes the StreamPipe and returns the error of the function run by Go,
+// so failures after the last value are not lost. It is safe to call Close more
+// than once.
 func (d *StreamPipe[T]) Close() error {
-	close(d.queue)
-	return nil
+	d.once.Do(func() { close(d.done) })
+	return d.error()
+}
+
+// drain consumes the rest of stream, so that its producer is not left blocked
+// or sending into a closed pipe, and closes it.
+func drain[T any](stream Streamer[T]) error {
+	for stream.Next() {
+	}
+	return stream.Close()
+}
+
+// peekStream reads the first value of the stream being opened, so that the
+// failures of providers opening their streams lazily, on the first read, are
+// reported when the stream is opened and can be retried or failed over. The
+// returned stream yields that value again first.
+func peekStream[T any](stream Streamer[T], err error) (Streamer[T], error) {
+	if err != nil {
+		return nil, err
+	}
+	peeked := &peekedStream[T]{Streamer: stream}
+	if !stream.Next() {
+		if err := stream.Close(); err != nil {
+			return nil, err
+		}
+		return peeked, nil
+	}
+	if peeked.first, err = stream.Current(); err != nil {
+		drain(stream)
+		return nil, err
+	}
+	peeked.pending = true
+	return peeked, nil
+}
+
+// peekedStream yields the value read by peekStream before the rest of the stream.
+type peekedStream[T any] struct {
+	Streamer[T]
+	first   T
+	pending bool
+	replay  bool
+}
+
+// Next advances the stream, yielding the peeked value first.
+func (s *peekedStream[T]) Next() bool {
+	if s.pending {
+		s.pending, s.replay = false, true
+		return true
+	}
+	s.replay = false
+	return s.Streamer.Next()
+}
+
+// Current returns the current value of the stream.
+func (s *peekedStream[T]) Current() (T, error) {
+	if s.replay {
+		return s.first, nil
+	}
+	return s.Streamer.Current()
 }
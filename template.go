@@ -70,6 +70,30 @@ func (p *PromptTemplate) System(tmpl string, params ...map[string]any) *PromptTe
 	return p
 }
 
+// Developer appends a developer message rendered from the provided template and
+// params, for instructions that reasoning models follow in place of system ones.
+func (p *PromptTemplate) Developer(tmpl string, params ...map[string]any) *PromptTemplate {
+	p.tmpls = append(p.tmpls, &templateText{
+		role:     RoleDeveloper,
+		template: tmpl,
+		vars:     p.mergeParams(params...),
+		name:     fmt.Sprintf("developer-%d", len(p.tmpls)),
+	})
+	return p
+}
+
+// Assistant appends an assistant message rendered from the provided template and
+// params, e.g. an example answer.
+func (p *PromptTemplate) Assistant(tmpl string, params ...map[string]any) *PromptTemplate {
+	p.tmpls = append(p.tmpls, &templateText{
+		role:     RoleAssistant,
+		template: tmpl,
+		vars:     p.mergeParams(params...),
+		name:     fmt.Sprintf("assistant-%d", len(p.tmpls)),
+	})
+	return p
+}
+
 // Build finalizes and returns the constructed Prompt.
 func (p *PromptTemplate) Build() (*Prompt, error) {
 	messages := make([]*Message, 0, len(p.tmpls))
@@ -87,6 +111,8 @@ func (p *PromptTemplate) Build() (*Prompt, error) {
 			messages = append(messages, UserMessage(buf.String()))
 		case RoleSystem:
 			messages = append(messages, SystemMessage(buf.String()))
+		case RoleDeveloper:
+			messages = append(messages, DeveloperMessage(buf.String()))
 		case RoleAssistant:
 			messages = append(messages, AssistantMessage(buf.String()))
 		default:
@@ -0,0 +1,70 @@
+package tokens
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+// bpe is a tiktoken byte pair encoding. Its ranks are embedded in the binary
+// and loaded on first use, so counting never downloads them.
+type bpe struct {
+	// name is the name of the encoding and of its rank file.
+	name string
+	// pattern is the regular expression of the pre-tokenizer.
+	pattern string
+	// special are the special tokens of the encoding.
+	special map[string]int
+
+	load func() (*tiktoken.Tiktoken, error)
+}
+
+func newBPE(name, pattern string, special map[string]int) *bpe {
+	e := &bpe{name: name, pattern: pattern, special: special}
+	e.load = sync.OnceValues(e.encoder)
+	return e
+}
+
+// Count returns the tokens of text. Special tokens such as <|endoftext|> are
+// counted as plain text.
+func (e *bpe) Count(text string) int {
+	enc, err := e.load()
+	if err != nil {
+		// the embedded ranks are malformed, which the tests rule out
+		return Heuristic(4).Count(text)
+	}
+	return len(enc.EncodeOrdinary(text))
+}
+
+// encoder builds the encoder from the embedded ranks.
+func (e *bpe) encoder() (*tiktoken.Tiktoken, error) {
+	ranks, err := loader.NewOfflineLoader().LoadTiktokenBpe(e.name + ".tiktoken")
+	if err != nil {
+		return nil, err
+	}
+	core, err := tiktoken.NewCoreBPE(ranks, e.special, e.pattern)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]any, len(e.special))
+	for token := range e.special {
+		set[token] = true
+	}
+	encoding := &tiktoken.Encoding{Name: e.name, PatStr: e.pattern, MergeableRanks: ranks, SpecialTokens: e.special}
+	return tiktoken.NewTiktoken(core, encoding, set), nil
+}
+
+var (
+	cl100kPattern = `(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+`
+	o200kPattern  = strings.Join([]string{
+		`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?`,
+		`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?`,
+		`\p{N}{1,3}`,
+		` ?[^\s\p{L}\p{N}]+[\r\n/]*`,
+		`\s*[\r\n]+`,
+		`\s+(?!\S)`,
+		`\s+`,
+	}, "|")
+)
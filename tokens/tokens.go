@@ -0,0 +1,154 @@
+// Package tokens counts the tokens of text and messages for a model, for
+// truncation, budgeting and cost estimation before a request is sent. OpenAI
+// models are counted exactly with their tiktoken encodings, other models are
+// estimated: the usage reported by providers is authoritative.
+//
+// The counters plug into the token options of other packages:
+//
+//	blades.NewContextManager(blades.ContextTokenCounter(tokens.CountTokens))
+//	memory.NewSummarizing(store, summarizer, 4000, memory.WithTokenCounter(tokens.Counter("gpt-4o")))
+//	rag.NewPacker(rag.WithTokenCounter(tokens.Counter("gpt-4o")))
+package tokens
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/go-kratos/blades"
+)
+
+// Tokenizer counts the tokens of text.
+type Tokenizer interface {
+	Count(text string) int
+}
+
+// TokenizerFunc adapts a function to a Tokenizer.
+type TokenizerFunc func(text string) int
+
+// Count calls f(text).
+func (f TokenizerFunc) Count(text string) int {
+	return f(text)
+}
+
+var (
+	// CL100K is the cl100k_base encoding of GPT-4, GPT-3.5 and the
+	// text-embedding-3 models.
+	CL100K Tokenizer = newBPE("cl100k_base", cl100kPattern, map[string]int{
+		"<|endoftext|>":   100257,
+		"<|fim_prefix|>":  100258,
+		"<|fim_middle|>":  100259,
+		"<|fim_suffix|>":  100260,
+		"<|endofprompt|>": 100276,
+	})
+	// O200K is the o200k_base encoding of GPT-4o, GPT-4.1 and the o-series
+	// models.
+	O200K Tokenizer = newBPE("o200k_base", o200kPattern, map[string]int{
+		"<|endoftext|>":   199999,
+		"<|endofprompt|>": 200018,
+	})
+	// Gemini approximates the tokenizer of Gemini models, about 4 characters per token.
+	Gemini Tokenizer = Heuristic(4)
+	// Claude approximates the tokenizer of Claude models, about 3.5 characters per token.
+	Claude Tokenizer = Heuristic(3.5)
+)
+
+// ForModel returns the tokenizer of the family of model, falling back to a
+// heuristic of 4 characters per token for unknown models.
+func ForModel(model string) Tokenizer {
+	model = strings.ToLower(model)
+	// drop the vendor of gateway model names, e.g. "openai/gpt-4o"
+	if i := strings.LastIndexByte(model, '/'); i >= 0 {
+		model = model[i+1:]
+	}
+	switch {
+	case strings.HasPrefix(model, "gpt-4o"), strings.HasPrefix(model, "gpt-4.1"), strings.HasPrefix(model, "gpt-5"),
+		strings.HasPrefix(model, "chatgpt-4o"), strings.HasPrefix(model, "o1"), strings.HasPrefix(model, "o3"), strings.HasPrefix(model, "o4"):
+		return O200K
+	case strings.HasPrefix(model, "gpt-"), strings.HasPrefix(model, "text-embedding-"):
+		return CL100K
+	case strings.HasPrefix(model, "gemini"), strings.HasPrefix(model, "gemma"):
+		return Gemini
+	case strings.HasPrefix(model, "claude"):
+		return Claude
+	}
+	return Heuristic(4)
+}
+
+// Count returns the tokens of text for model.
+func Count(model, text string) int {
+	return ForModel(model).Count(text)
+}
+
+// Counter returns a function counting the tokens of text for model, as taken
+// by token counter options such as memory.WithTokenCounter.
+func Counter(model string) func(text string) int {
+	return ForModel(model).Count
+}
+
+// CountTokens returns the tokens of msgs sent to model, including the text and
+// tool calls of each message and the overhead of the chat format. It can be
+// passed to blades.ContextTokenCounter.
+func CountTokens(model string, msgs []*blades.Message) int {
+	tokenizer := ForModel(model)
+	// the chat format adds a few tokens per message and primes the reply
+	n := 3
+	for _, msg := range msgs {
+		n += 4
+		for _, part := range msg.Parts {
+			if text, ok := part.(blades.TextPart); ok {
+				n += tokenizer.Count(text.Text)
+			}
+		}
+		for _, call := range msg.ToolCalls {
+			n += 3 + tokenizer.Count(call.Name) + tokenizer.Count(call.Arguments) + tokenizer.Count(call.Result)
+		}
+	}
+	return n
+}
+
+// EstimateUsage returns the usage of req with the given number of output
+// tokens, counting the tokens of its messages and tool definitions.
+func EstimateUsage(req *blades.ModelRequest, outputTokens int) *blades.Usage {
+	prompt := CountTokens(req.Model, req.Messages)
+	tokenizer := ForModel(req.Model)
+	for _, tool := range req.Tools {
+		prompt += tokenizer.Count(tool.Name) + tokenizer.Count(tool.Description)
+		if tool.InputSchema != nil {
+			if b, err := tool.InputSchema.MarshalJSON(); err == nil {
+				prompt += tokenizer.Count(string(b))
+			}
+		}
+	}
+	return &blades.Usage{
+		PromptTokens:     int64(prompt),
+		CompletionTokens: int64(outputTokens),
+		TotalTokens:      int64(prompt + outputTokens),
+	}
+}
+
+// EstimateCost returns the cost of req with the given number of output tokens
+// under pricing, before sending it.
+func EstimateCost(pricing blades.Pricing, req *blades.ModelRequest, outputTokens int) float64 {
+	return pricing.Cost(req.Model, EstimateUsage(req, outputTokens))
+}
+
+// Heuristic returns a Tokenizer estimating charsPerToken characters per token
+// for alphabetic scripts and about one token per CJK character.
+func Heuristic(charsPerToken float64) Tokenizer {
+	return TokenizerFunc(func(text string) int {
+		var chars, cjk float64
+		for _, r := range text {
+			if isCJK(r) {
+				cjk++
+			} else {
+				chars++
+			}
+		}
+		return int(math.Ceil(chars/charsPerToken + cjk))
+	})
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
@@ -0,0 +1,71 @@
+package tokens
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/go-kratos/blades"
+)
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		model string
+		text  string
+		want  int // tokens of the reference tokenizer
+	}{
+		{"gpt-4", "", 0},
+		{"gpt-4", "Hello, world!", 4},
+		{"gpt-4", "The quick brown fox jumps over the lazy dog.", 10},
+		{"gpt-4", "1234567", 3},
+		{"gpt-4", "I don't think we'll make it.", 9},
+		{"gpt-4", "tiktoken is great!", 6},
+		{"gpt-4", "你好，世界", 6},
+		{"gpt-4", "<|endoftext|>", 7},
+		{"text-embedding-3-small", "hello world", 2},
+		{"gpt-4o", "Tokenization of internationalization is hard.", 8},
+		{"gpt-4o", "def main():\n    print('hi')\n", 8},
+		{"gpt-4o", "tiktoken is great!", 6},
+		{"gpt-4o", "你好，世界", 3},
+		{"openai/gpt-4o-mini", "Hello, world!", 4},
+	}
+	for _, test := range tests {
+		if got := Count(test.model, test.text); got != test.want {
+			t.Errorf("Count(%q, %q) = %d, want %d", test.model, test.text, got, test.want)
+		}
+	}
+	if Count("gemini-2.5-flash", "12345678") != 2 || Count("unknown", "") != 0 {
+		t.Errorf("unexpected heuristic counts")
+	}
+}
+
+func TestBPE_Ranks(t *testing.T) {
+	tests := []struct {
+		encoding *bpe
+		text     string
+		want     []int
+	}{
+		{CL100K.(*bpe), "Hello, world!", []int{9906, 11, 1917, 0}},
+		{CL100K.(*bpe), "tiktoken is great!", []int{83, 1609, 5963, 374, 2294, 0}},
+		{O200K.(*bpe), "tiktoken is great!", []int{83, 8251, 2488, 382, 2212, 0}},
+	}
+	for _, test := range tests {
+		enc, err := test.encoding.load()
+		if err != nil {
+			t.Fatalf("load %s: %v", test.encoding.name, err)
+		}
+		if got := enc.EncodeOrdinary(test.text); !slices.Equal(got, test.want) {
+			t.Errorf("%s encodes %q as %v, want %v", test.encoding.name, test.text, got, test.want)
+		}
+	}
+}
+
+func TestCountTokens(t *testing.T) {
+	msgs := []*blades.Message{blades.SystemMessage("You are helpful."), blades.UserMessage("Hello, world!")}
+	if got := CountTokens("gpt-4o", msgs); got != 3+4+Count("gpt-4o", "You are helpful.")+4+4 {
+		t.Fatalf("unexpected message tokens %d", got)
+	}
+	cost := EstimateCost(blades.DefaultPriceTable(), &blades.ModelRequest{Model: "gpt-4o", Messages: msgs}, 1000)
+	if cost <= 0.01 || cost >= 0.0102 {
+		t.Fatalf("unexpected cost %f", cost)
+	}
+}
@@ -2,14 +2,289 @@ package blades
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/jsonschema-go/jsonschema"
 )
 
+var (
+	// ErrToolNotFound indicates the model called a tool that is not registered.
+	ErrToolNotFound = errors.New("tool not found")
+	// ErrMaxIterations indicates the tool loop did not finish within MaxIterations.
+	ErrMaxIterations = errors.New("max iterations exceeded")
+	// ErrInvalidArguments indicates tool arguments do not match the tool input schema.
+	ErrInvalidArguments = errors.New("invalid tool arguments")
+)
+
 // Tool represents a tool with a name, description, input schema, and a callable function.
 type Tool struct {
 	Name        string                                        `json:"name"`
 	Description string                                        `json:"description"`
 	InputSchema *jsonschema.Schema                            `json:"inputSchema"`
 	Handle      func(context.Context, string) (string, error) `json:"-"`
+	// Destructive tags tools whose calls need approval in supervised runs.
+	Destructive bool `json:"-"`
+
+	resolved atomic.Pointer[resolvedSchema]
+}
+
+// resolvedSchema caches the resolution of an input schema.
+type resolvedSchema struct {
+	schema   *jsonschema.Schema
+	resolved *jsonschema.Resolved
+	err      error
+}
+
+// Validate checks the JSON arguments against the input schema, including types,
+// enums, ranges and required properties. The schema is resolved once, and again
+// only if InputSchema is replaced.
+func (t *Tool) Validate(arguments string) error {
+	r := t.resolved.Load()
+	if r == nil || r.schema != t.InputSchema {
+		r = &resolvedSchema{schema: t.InputSchema}
+		if t.InputSchema != nil {
+			r.resolved, r.err = t.InputSchema.Resolve(nil)
+		}
+		t.resolved.Store(r)
+	}
+	if r.err != nil {
+		return fmt.Errorf("tool %s: %w", t.Name, r.err)
+	}
+	return validateArguments(r.resolved, arguments)
+}
+
+func validateArguments(resolved *jsonschema.Resolved, arguments string) error {
+	if strings.TrimSpace(arguments) == "" {
+		arguments = "{}"
+	}
+	var instance any
+	if err := json.Unmarshal([]byte(arguments), &instance); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidArguments, err)
+	}
+	if resolved == nil {
+		return nil
+	}
+	if err := resolved.Validate(instance); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidArguments, err)
+	}
+	return nil
+}
+
+// NewTool creates a Tool whose input schema is reflected from the Args struct.
+// Field descriptions come from the `jsonschema` tag, and fields are required
+// unless their `json` tag has omitempty or omitzero; a `required:"true"` or
+// `required:"false"` tag overrides this. Model arguments are validated against
+// the schema by Validate and decoded into Args before handle is called.
+func NewTool[Args any](name, description string, handle func(context.Context, Args) (string, error)) (*Tool, error) {
+	schema, err := jsonschema.For[Args](nil)
+	if err != nil {
+		return nil, fmt.Errorf("tool %s: %w", name, err)
+	}
+	applyRequiredTags(schema, reflect.TypeFor[Args]())
+	resolved, err := schema.Resolve(nil)
+	if err != nil {
+		return nil, fmt.Errorf("tool %s: %w", name, err)
+	}
+	tool := &Tool{
+		Name:        name,
+		Description: description,
+		InputSchema: schema,
+		Handle: func(ctx context.Context, arguments string) (string, error) {
+			if strings.TrimSpace(arguments) == "" {
+				arguments = "{}"
+			}
+			var args Args
+			if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+				return "", fmt.Errorf("%w: %v", ErrInvalidArguments, err)
+			}
+			return handle(ctx, args)
+		},
+	}
+	tool.resolved.Store(&resolvedSchema{schema: schema, resolved: resolved})
+	return tool, nil
+}
+
+// applyRequiredTags adjusts the required properties of schema with the
+// `required` tags of the fields of struct type t.
+func applyRequiredTags(schema *jsonschema.Schema, t reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("required")
+		if !ok || !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		if name == "-" {
+			continue
+		}
+		schema.Required = slices.DeleteFunc(schema.Required, func(s string) bool { return s == name })
+		if tag == "true" {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// pendingToolCalls returns the tool calls requested by assistant messages.
+func pendingToolCalls(messages []*Message) []*ToolCall {
+	var calls []*ToolCall
+	for _, msg := range messages {
+		if msg.Role == RoleAssistant {
+			calls = append(calls, msg.ToolCalls...)
+		}
+	}
+	return calls
+}
+
+// ToolInvocation records one tool call made by an agent for audit trails.
+type ToolInvocation struct {
+	ID        string        `json:"id"`
+	Name      string        `json:"name"`
+	Arguments string        `json:"arguments"`
+	Result    string        `json:"result,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	StartedAt time.Time     `json:"startedAt"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// maxResultSummary bounds the length of results recorded in a ToolInvocation.
+const maxResultSummary = 256
+
+// callTools executes the tool calls and returns a tool message carrying their
+// results together with a trace of the invocations. Arguments are validated
+// before a tool runs, and calls are approved by guard in supervised runs or when
+// they require approval, which may edit their arguments. Once every call is
+// approved, up to limit calls run concurrently; they run one at a time when
+// limit is below 2. A failing, declined or unknown tool does not stop the
+// loop: the error is reported to the model as a JSON tool result.
+func callTools(ctx context.Context, tools []*Tool, calls []*ToolCall, guard *autonomyGuard, limit int) (*Message, []*ToolInvocation, error) {
+	var (
+		trace   = make([]*ToolInvocation, len(calls))
+		results = make([]*ToolCall, len(calls))
+		pending []func()
+	)
+	for i, call := range calls {
+		invocation := &ToolInvocation{ID: call.ID, Name: call.Name, Arguments: call.Arguments}
+		result := &ToolCall{ID: call.ID, Name: call.Name, Arguments: call.Arguments}
+		trace[i], results[i] = invocation, result
+		tool, err := findTool(tools, call.Name)
+		if err != nil {
+			invocation.StartedAt = Now()
+			recordResult(invocation, result, "", err)
+			continue
+		}
+		arguments, err := guard.approve(ctx, tool, call)
+		switch {
+		case err == nil:
+			invocation.Arguments, result.Arguments = arguments, arguments
+			pending = append(pending, func() {
+				invocation.StartedAt = Now()
+				out, err := invokeTool(ctx, tool, arguments)
+				invocation.Duration = Since(invocation.StartedAt)
+				recordResult(invocation, result, out, err)
+			})
+		case errors.Is(err, ErrActionDeclined):
+			invocation.StartedAt = Now()
+			recordResult(invocation, result, "", err)
+		default:
+			return nil, nil, err
+		}
+	}
+	if limit < 2 {
+		for _, run := range pending {
+			run()
+		}
+	} else {
+		var (
+			wg  sync.WaitGroup
+			sem = make(chan struct{}, limit)
+		)
+		for _, run := range pending {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer func() { <-sem; wg.Done() }()
+				run()
+			}()
+		}
+		wg.Wait()
+	}
+	return &Message{ID: NewMessageID(), Role: RoleTool, Status: StatusCompleted, ToolCalls: results}, trace, nil
+}
+
+// recordResult records the outcome of a tool call in its invocation and result.
+func recordResult(invocation *ToolInvocation, result *ToolCall, out string, err error) {
+	if err != nil {
+		invocation.Error = err.Error()
+		result.Result = toolFailure(err)
+		return
+	}
+	invocation.Result = summarize(out)
+	result.Result = out
+}
+
+// invokeTool validates the arguments and runs the tool, turning panics into errors.
+func invokeTool(ctx context.Context, tool *Tool, arguments string) (result string, err error) {
+	if err := tool.Validate(arguments); err != nil {
+		return "", err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("tool %s panicked: %v", tool.Name, r)
+		}
+	}()
+	return tool.Handle(ctx, arguments)
+}
+
+// toolFailure encodes a tool error as a structured result the model can act on.
+func toolFailure(err error) string {
+	failure := struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}{Error: "tool_failed", Message: err.Error()}
+	switch {
+	case errors.Is(err, ErrInvalidArguments):
+		failure.Error = "invalid_arguments"
+	case errors.Is(err, ErrToolNotFound):
+		failure.Error = "tool_not_found"
+	}
+	b, _ := json.Marshal(failure)
+	return string(b)
+}
+
+func summarize(result string) string {
+	if len(result) <= maxResultSummary {
+		return result
+	}
+	cut := maxResultSummary
+	for cut > 0 && !utf8.RuneStart(result[cut]) {
+		cut--
+	}
+	return result[:cut] + "…"
+}
+
+func findTool(tools []*Tool, name string) (*Tool, error) {
+	for _, tool := range tools {
+		if tool.Name == name {
+			return tool, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrToolNotFound, name)
 }
@@ -0,0 +1,96 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+func TestNewTool(t *testing.T) {
+	type weatherArgs struct {
+		City  string `json:"city" jsonschema:"the city name"`
+		Units string `json:"units,omitempty" required:"true"`
+		Days  int    `json:"days" required:"false"`
+	}
+	tool, err := NewTool("weather", "Get the weather", func(ctx context.Context, args weatherArgs) (string, error) {
+		return args.City + " " + args.Units, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tool.InputSchema.Properties["city"].Description; got != "the city name" {
+		t.Fatalf("unexpected description %q", got)
+	}
+	if !slices.Equal(tool.InputSchema.Required, []string{"city", "units"}) {
+		t.Fatalf("unexpected required fields %v", tool.InputSchema.Required)
+	}
+	res, err := tool.Handle(context.Background(), `{"city":"Paris","units":"metric"}`)
+	if err != nil || res != "Paris metric" {
+		t.Fatalf("unexpected result %q (%v)", res, err)
+	}
+	if err := tool.Validate(`{"city":"Paris"}`); !errors.Is(err, ErrInvalidArguments) {
+		t.Fatalf("expected missing units to be rejected, got %v", err)
+	}
+	if err := tool.Validate(`{"city":1,"units":"metric"}`); !errors.Is(err, ErrInvalidArguments) {
+		t.Fatalf("expected wrong type to be rejected, got %v", err)
+	}
+	tool.InputSchema = &jsonschema.Schema{Type: "object", Required: []string{"country"}}
+	if err := tool.Validate(`{"city":"Paris","units":"metric"}`); !errors.Is(err, ErrInvalidArguments) {
+		t.Fatalf("expected the replaced schema to be used, got %v", err)
+	}
+}
+
+func TestCallTools_Parallel(t *testing.T) {
+	var (
+		running, peak atomic.Int32
+		started       = make(chan struct{}, 4)
+		release       = make(chan struct{})
+	)
+	tool := &Tool{
+		Name: "slow",
+		Handle: func(ctx context.Context, args string) (string, error) {
+			n := running.Add(1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			defer running.Add(-1)
+			started <- struct{}{}
+			<-release
+			return args, nil
+		},
+	}
+	calls := make([]*ToolCall, 4)
+	for i := range calls {
+		calls[i] = &ToolCall{ID: fmt.Sprintf("call_%d", i), Name: "slow", Arguments: fmt.Sprintf(`{"n":%d}`, i)}
+	}
+	go func() {
+		// both slots are taken before any call completes
+		for range 2 {
+			select {
+			case <-started:
+			case <-time.After(time.Second):
+				t.Error("expected 2 calls to run concurrently")
+			}
+		}
+		for range calls {
+			release <- struct{}{}
+		}
+	}()
+	msg, trace, err := callTools(context.Background(), []*Tool{tool}, calls, nil, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peak.Load() != 2 {
+		t.Fatalf("expected 2 concurrent calls at most, got %d", peak.Load())
+	}
+	for i, call := range msg.ToolCalls {
+		if call.ID != calls[i].ID || call.Result != calls[i].Arguments || trace[i].ID != calls[i].ID {
+			t.Fatalf("expected results in call order, got %+v", call)
+		}
+	}
+}
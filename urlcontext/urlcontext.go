@@ -0,0 +1,192 @@
+// Package urlcontext fetches the pages linked in prompts client-side, for
+// providers without a native URL context feature.
+package urlcontext
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/document"
+)
+
+var (
+	_ blades.ModelProvider = (*Provider)(nil)
+)
+
+// Fetcher returns the readable text of a web page.
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) (title, text string, err error)
+}
+
+// FetcherFunc adapts a function to a Fetcher.
+type FetcherFunc func(ctx context.Context, url string) (string, string, error)
+
+// Fetch calls f(ctx, url).
+func (f FetcherFunc) Fetch(ctx context.Context, url string) (string, string, error) {
+	return f(ctx, url)
+}
+
+// WebFetcher fetches pages with a document.WebLoader, honoring robots.txt.
+func WebFetcher(opts ...document.WebOption) Fetcher {
+	return FetcherFunc(func(ctx context.Context, url string) (string, string, error) {
+		loader := document.NewWebLoader([]string{url}, append(opts, document.WithMaxDepth(0), document.WithMaxPages(1))...)
+		docs, err := loader.Load(ctx)
+		if err != nil {
+			return "", "", err
+		}
+		if len(docs) == 0 {
+			return "", "", fmt.Errorf("urlcontext: no readable content at %s", url)
+		}
+		title, _ := docs[0].Metadata["title"].(string)
+		return title, docs[0].Content, nil
+	})
+}
+
+// Option configures a Provider.
+type Option func(*Provider)
+
+// WithFetcher sets the fetcher used to read pages.
+func WithFetcher(fetcher Fetcher) Option {
+	return func(p *Provider) {
+		p.fetcher = fetcher
+	}
+}
+
+// WithMaxURLs limits how many links of a request are fetched.
+func WithMaxURLs(n int) Option {
+	return func(p *Provider) {
+		p.maxURLs = n
+	}
+}
+
+// WithMaxBytes truncates the text of each page to n bytes.
+func WithMaxBytes(n int) Option {
+	return func(p *Provider) {
+		p.maxBytes = n
+	}
+}
+
+// Provider emulates URL context for providers that lack it: when a request
+// enables blades.URLContext, the pages linked in its user messages are fetched
+// and appended to the messages, and the option is cleared for the wrapped
+// provider. Fetched pages are reported as grounding sources. Pages that cannot
+// be fetched are skipped.
+type Provider struct {
+	provider blades.ModelProvider
+	fetcher  Fetcher
+	maxURLs  int
+	maxBytes int
+}
+
+// NewProvider wraps provider, fetching up to 5 pages of 20 KB each per request.
+func NewProvider(provider blades.ModelProvider, opts ...Option) *Provider {
+	p := &Provider{
+		provider: provider,
+		fetcher:  WebFetcher(),
+		maxURLs:  5,
+		maxBytes: 20 << 10,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Generate fetches the linked pages and generates a response grounded in them.
+func (p *Provider) Generate(ctx context.Context, req *blades.ModelRequest, opts ...blades.ModelOption) (*blades.ModelResponse, error) {
+	req, sources, opts := p.prepare(ctx, req, opts)
+	res, err := p.provider.Generate(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return withSources(res, sources), nil
+}
+
+// NewStream fetches the linked pages and streams a response grounded in them.
+func (p *Provider) NewStream(ctx context.Context, req *blades.ModelRequest, opts ...blades.ModelOption) (blades.Streamer[*blades.ModelResponse], error) {
+	req, sources, opts := p.prepare(ctx, req, opts)
+	stream, err := p.provider.NewStream(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return stream, nil
+	}
+	return blades.NewMappedStream(stream, func(res *blades.ModelResponse) (*blades.ModelResponse, error) {
+		for _, msg := range res.Messages {
+			if msg.Status == blades.StatusCompleted {
+				return withSources(res, sources), nil
+			}
+		}
+		return res, nil
+	}), nil
+}
+
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"'()\[\]]+`)
+
+// prepare appends the linked pages to a copy of the request.
+func (p *Provider) prepare(ctx context.Context, req *blades.ModelRequest, opts []blades.ModelOption) (*blades.ModelRequest, []blades.GroundingSource, []blades.ModelOption) {
+	var opt blades.ModelOptions
+	for _, o := range opts {
+		o(&opt)
+	}
+	if !opt.Grounding.URLContext {
+		return req, nil, opts
+	}
+	opts = append(opts[:len(opts):len(opts)], func(o *blades.ModelOptions) {
+		o.Grounding.URLContext = false
+	})
+	var (
+		sources []blades.GroundingSource
+		seen    = make(map[string]bool)
+		r       = *req
+	)
+	r.Messages = make([]*blades.Message, len(req.Messages))
+	for i, msg := range req.Messages {
+		r.Messages[i] = msg
+		if msg.Role != blades.RoleUser {
+			continue
+		}
+		var pages []blades.Part
+		for _, u := range urlPattern.FindAllString(msg.Text(), -1) {
+			u = strings.TrimRight(u, ".,;:!?")
+			if seen[u] || len(seen) >= p.maxURLs {
+				continue
+			}
+			seen[u] = true
+			title, text, err := p.fetcher.Fetch(ctx, u)
+			if err != nil {
+				continue
+			}
+			if len(text) > p.maxBytes {
+				text = strings.ToValidUTF8(text[:p.maxBytes], "")
+			}
+			sources = append(sources, blades.GroundingSource{Title: title, URI: u})
+			pages = append(pages, blades.TextPart{Text: fmt.Sprintf("Content of %s:\n%s", u, text)})
+		}
+		if len(pages) > 0 {
+			m := *msg
+			m.Parts = append(msg.Parts[:len(msg.Parts):len(msg.Parts)], pages...)
+			r.Messages[i] = &m
+		}
+	}
+	return &r, sources, opts
+}
+
+// withSources adds the fetched pages to the grounding of res.
+func withSources(res *blades.ModelResponse, sources []blades.GroundingSource) *blades.ModelResponse {
+	if len(sources) == 0 {
+		return res
+	}
+	grounding := &blades.Grounding{}
+	if res.Grounding != nil {
+		*grounding = *res.Grounding
+	}
+	grounding.Sources = append(grounding.Sources[:len(grounding.Sources):len(grounding.Sources)], sources...)
+	r := *res
+	r.Grounding = grounding
+	return &r
+}
@@ -0,0 +1,51 @@
+package urlcontext
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/blades"
+)
+
+type recordingProvider struct {
+	req *blades.ModelRequest
+	opt blades.ModelOptions
+}
+
+func (p *recordingProvider) Generate(ctx context.Context, req *blades.ModelRequest, opts ...blades.ModelOption) (*blades.ModelResponse, error) {
+	p.req = req
+	for _, o := range opts {
+		o(&p.opt)
+	}
+	return &blades.ModelResponse{Messages: []*blades.Message{blades.AssistantMessage("ok")}}, nil
+}
+
+func (p *recordingProvider) NewStream(ctx context.Context, req *blades.ModelRequest, opts ...blades.ModelOption) (blades.Streamer[*blades.ModelResponse], error) {
+	return nil, blades.ErrUnsupported
+}
+
+func TestProvider_FetchesLinkedPages(t *testing.T) {
+	rec := &recordingProvider{}
+	p := NewProvider(rec, WithFetcher(FetcherFunc(func(ctx context.Context, url string) (string, string, error) {
+		return "Example", "page body", nil
+	})))
+	msg := blades.UserMessage("Summarize https://example.com/post.")
+	res, err := p.Generate(context.Background(), &blades.ModelRequest{Messages: []*blades.Message{msg}}, blades.URLContext())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.opt.Grounding.URLContext {
+		t.Fatal("URL context should be cleared for the wrapped provider")
+	}
+	parts := rec.req.Messages[0].Parts
+	if len(parts) != 2 || !strings.Contains(parts[1].(blades.TextPart).Text, "page body") {
+		t.Fatalf("unexpected parts: %+v", parts)
+	}
+	if len(msg.Parts) != 1 {
+		t.Fatal("original message was modified")
+	}
+	if res.Grounding == nil || len(res.Grounding.Sources) != 1 || res.Grounding.Sources[0].URI != "https://example.com/post" {
+		t.Fatalf("unexpected grounding: %+v", res.Grounding)
+	}
+}
@@ -0,0 +1,111 @@
+package vectorstore
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	_ VectorStore = (*MemoryStore)(nil)
+)
+
+// MemoryStore is an in-memory VectorStore ranking records by cosine
+// similarity with an exhaustive scan, for tests, examples and small corpora.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	dim     int
+	records map[string]Record
+}
+
+// NewMemoryStore creates a new MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]Record)}
+}
+
+// Upsert stores copies of the records. All vectors must have the same length;
+// otherwise no record is stored.
+func (s *MemoryStore) Upsert(ctx context.Context, records ...Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dim := s.dim
+	for _, record := range records {
+		if dim == 0 {
+			dim = len(record.Vector)
+		}
+		if len(record.Vector) != dim {
+			return fmt.Errorf("%w: record %s has %d dimensions, want %d", ErrDimensionMismatch, record.ID, len(record.Vector), dim)
+		}
+	}
+	s.dim = dim
+	for _, record := range records {
+		record.Vector = slices.Clone(record.Vector)
+		record.Metadata = maps.Clone(record.Metadata)
+		s.records[record.ID] = record
+	}
+	return nil
+}
+
+// Query scans every record matching the filter and returns the topK closest.
+func (s *MemoryStore) Query(ctx context.Context, vector []float32, topK int, opts ...QueryOption) ([]Result, error) {
+	var opt QueryOptions
+	for _, apply := range opts {
+		apply(&opt)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.dim != 0 && len(vector) != s.dim {
+		return nil, fmt.Errorf("%w: query has %d dimensions, want %d", ErrDimensionMismatch, len(vector), s.dim)
+	}
+	var results []Result
+	for _, record := range s.records {
+		if !opt.Filter.Match(record.Metadata) {
+			continue
+		}
+		score := Cosine(vector, record.Vector)
+		if score < opt.MinScore {
+			continue
+		}
+		results = append(results, Result{Record: record, Score: score})
+	}
+	slices.SortFunc(results, func(a, b Result) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), strings.Compare(a.ID, b.ID))
+	})
+	if topK > 0 && len(results) > topK {
+		results = results[:topK]
+	}
+	return results, nil
+}
+
+// Delete removes the records with the given IDs.
+func (s *MemoryStore) Delete(ctx context.Context, ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		delete(s.records, id)
+	}
+	if len(s.records) == 0 {
+		s.dim = 0
+	}
+	return nil
+}
+
+// Cosine returns the cosine similarity of two vectors of the same length, or
+// 0 when either is zero.
+func Cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
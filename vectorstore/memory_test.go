@@ -0,0 +1,36 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	err := store.Upsert(ctx,
+		Record{ID: "a", Vector: []float32{1, 0}, Metadata: map[string]any{"lang": "en"}},
+		Record{ID: "b", Vector: []float32{0.9, 0.1}, Metadata: map[string]any{"lang": "fr"}},
+		Record{ID: "c", Vector: []float32{0, 1}, Metadata: map[string]any{"lang": "en"}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, _ := store.Query(ctx, []float32{1, 0}, 2)
+	if len(results) != 2 || results[0].ID != "a" || results[1].ID != "b" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	results, _ = store.Query(ctx, []float32{1, 0}, 5, WithFilter(Filter{"lang": "en"}))
+	if len(results) != 2 || results[1].ID != "c" {
+		t.Fatalf("unexpected filtered results: %+v", results)
+	}
+	_ = store.Delete(ctx, "a")
+	results, _ = store.Query(ctx, []float32{1, 0}, 1, WithFilter(Filter{"lang": []string{"en", "de"}}))
+	if len(results) != 1 || results[0].ID != "c" {
+		t.Fatalf("unexpected results after delete: %+v", results)
+	}
+	if err := store.Upsert(ctx, Record{ID: "d", Vector: []float32{1}}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("expected dimension mismatch, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrDimensionMismatch indicates a vector whose length differs from the vectors of the store.
+	ErrDimensionMismatch = errors.New("vectorstore: dimension mismatch")
+)
+
+// Record is an embedded piece of content.
+type Record struct {
+	ID       string         `json:"id"`
+	Vector   []float32      `json:"vector"`
+	Content  string         `json:"content,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// Result is a record matching a query with its similarity score, higher is closer.
+type Result struct {
+	Record
+	Score float64 `json:"score"`
+}
+
+// Filter restricts a query to the records whose metadata has every key of the
+// filter with an equal value. A slice value matches any of its elements.
+type Filter map[string]any
+
+// QueryOptions holds the options of a query.
+type QueryOptions struct {
+	Filter   Filter
+	MinScore float64
+	// Alpha weighs hybrid queries, nil for the default of the store.
+	Alpha *float64
+}
+
+// QueryOption configures a query.
+type QueryOption func(*QueryOptions)
+
+// WithFilter restricts a query to the records matching filter.
+func WithFilter(filter Filter) QueryOption {
+	return func(o *QueryOptions) {
+		o.Filter = filter
+	}
+}
+
+// WithMinScore drops results scoring below score.
+func WithMinScore(score float64) QueryOption {
+	return func(o *QueryOptions) {
+		o.MinScore = score
+	}
+}
+
+// WithAlpha weighs vector similarity against keyword relevance in hybrid
+// queries, from 0 (keywords only) to 1 (vector only).
+func WithAlpha(alpha float64) QueryOption {
+	return func(o *QueryOptions) {
+		o.Alpha = &alpha
+	}
+}
+
+// VectorStore stores embedded records and finds the nearest ones to a vector.
+type VectorStore interface {
+	// Upsert inserts the records, replacing those with the same IDs.
+	Upsert(ctx context.Context, records ...Record) error
+	// Query returns the topK records closest to vector, closest first.
+	Query(ctx context.Context, vector []float32, topK int, opts ...QueryOption) ([]Result, error)
+	// Delete removes the records with the given IDs, ignoring unknown ones.
+	Delete(ctx context.Context, ids ...string) error
+}
+
+// HybridSearcher is an optional capability of stores that rank records by a
+// combination of vector similarity and keyword (e.g. BM25) relevance.
+// Check for it with a type assertion on a VectorStore.
+type HybridSearcher interface {
+	// HybridQuery returns the topK records most relevant to the query text and
+	// vector, most relevant first.
+	HybridQuery(ctx context.Context, query string, vector []float32, topK int, opts ...QueryOption) ([]Result, error)
+}
+
+// Match reports whether metadata matches the filter.
+func (f Filter) Match(metadata map[string]any) bool {
+	for key, want := range f {
+		got, ok := metadata[key]
+		if !ok {
+			return false
+		}
+		if values, ok := want.([]any); ok {
+			if !containsValue(values, got) {
+				return false
+			}
+			continue
+		}
+		if values, ok := want.([]string); ok {
+			s, isString := got.(string)
+			if !isString || !containsValue(toAny(values), s) {
+				return false
+			}
+			continue
+		}
+		if got != want {
+			return false
+		}
+	}
+	return true
+}
+
+func containsValue(values []any, v any) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func toAny(values []string) []any {
+	out := make([]any, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
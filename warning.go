@@ -0,0 +1,61 @@
+package blades
+
+import "fmt"
+
+// Warning codes reported by the providers and agents of this package.
+const (
+	// WarningTruncated reports a response cut off by the output token limit.
+	WarningTruncated = "truncated"
+	// WarningContentFiltered reports a response stopped by the provider's safety filters.
+	WarningContentFiltered = "content_filtered"
+	// WarningFallback reports a response served by a backup backend.
+	WarningFallback = "fallback"
+	// WarningCacheHit reports a response served from a ResponseCache.
+	WarningCacheHit = "cache_hit"
+	// WarningDegraded reports a response produced by a DegradationPolicy.
+	WarningDegraded = "degraded"
+	// WarningModerationFlagged reports content flagged by the Moderation middleware.
+	WarningModerationFlagged = "moderation_flagged"
+	// WarningReformatted reports a response rewritten into the format required by FormatOutput.
+	WarningReformatted = "reformatted"
+)
+
+// Warning is a non-fatal issue met while generating, such as a fallback
+// provider being used, so that callers can observe degraded behavior
+// programmatically. Other packages may report their own codes.
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+func (w Warning) String() string {
+	if w.Message == "" {
+		return w.Code
+	}
+	return fmt.Sprintf("%s: %s", w.Code, w.Message)
+}
+
+// HasWarning reports whether the generation carries a warning with code.
+func (g *Generation) HasWarning(code string) bool {
+	for _, w := range g.Warnings {
+		if w.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// responseWarnings returns the warnings of a response, including those implied
+// by the finish reasons of its messages.
+func responseWarnings(res *ModelResponse) []Warning {
+	warnings := res.Warnings
+	for _, msg := range res.Messages {
+		switch msg.Metadata["finish_reason"] {
+		case "length":
+			warnings = append(warnings, Warning{Code: WarningTruncated, Message: "the response reached the output token limit"})
+		case "content_filter":
+			warnings = append(warnings, Warning{Code: WarningContentFiltered, Message: "the response was stopped by the provider's safety filters"})
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,39 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestGenerationWarnings(t *testing.T) {
+	ctx := context.Background()
+	primary := &fakeProvider{err: errors.New("unavailable"), failures: 10}
+	fallback := NewFallbackProvider(Backend{Name: "primary", Provider: primary}, []Backend{{Name: "backup", Provider: textProvider("ok")}})
+	cached := NewCachingProvider(fallback, NewLRUCache(10))
+	agent := NewAgent("assistant", WithProvider(cached))
+
+	g, err := agent.Run(ctx, NewPrompt(UserMessage("hi")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.HasWarning(WarningFallback) || g.HasWarning(WarningCacheHit) {
+		t.Fatalf("expected a fallback warning, got %v", g.Warnings)
+	}
+	stream, err := agent.RunStream(ctx, NewPrompt(UserMessage("hi")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last *Generation
+	for stream.Next() {
+		last, _ = stream.Current()
+	}
+	if last.Finish == nil || !last.HasWarning(WarningCacheHit) {
+		t.Fatalf("expected a cache hit warning on the terminal generation, got %+v", last)
+	}
+
+	truncated := &ModelResponse{Messages: []*Message{{Role: RoleAssistant, Metadata: map[string]string{"finish_reason": "length"}}}}
+	if w := responseWarnings(truncated); len(w) != 1 || w[0].Code != WarningTruncated {
+		t.Fatalf("expected a truncation warning, got %v", w)
+	}
+}
@@ -0,0 +1,335 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	_ ModelProvider      = (*TruncatingProvider)(nil)
+	_ TruncationStrategy = TruncationFunc(nil)
+)
+
+var (
+	// ErrContextLength indicates a request does not fit the context window of its model.
+	ErrContextLength = errors.New("context length exceeded")
+)
+
+// WarningContextTrimmed reports a response to a request whose messages were
+// trimmed to fit the context window of the model.
+const WarningContextTrimmed = "context_trimmed"
+
+// ContextSizes maps model names to context window sizes in tokens. Models
+// without an exact entry use the entry of the longest name they start with.
+type ContextSizes map[string]int
+
+// DefaultContextSizes returns a new table with the context sizes of common
+// models, which may be overridden or extended.
+func DefaultContextSizes() ContextSizes {
+	return ContextSizes{
+		"gpt-4o":           128_000,
+		"gpt-4o-mini":      128_000,
+		"gpt-4.1":          1_047_576,
+		"gpt-4.1-mini":     1_047_576,
+		"gpt-4.1-nano":     1_047_576,
+		"o3":               200_000,
+		"o4-mini":          200_000,
+		"gemini-2.5-pro":   1_048_576,
+		"gemini-2.5-flash": 1_048_576,
+		"gemini-2.0-flash": 1_048_576,
+	}
+}
+
+// Size returns the context size of model and whether the table has one.
+func (s ContextSizes) Size(model string) (int, bool) {
+	if size, ok := s[model]; ok {
+		return size, true
+	}
+	var (
+		best string
+		size int
+	)
+	for name, n := range s {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best, size = name, n
+		}
+	}
+	return size, best != ""
+}
+
+// TruncationStrategy trims the messages of a request to fit budget tokens, as
+// measured by count.
+type TruncationStrategy interface {
+	Truncate(ctx context.Context, msgs []*Message, budget int, count func([]*Message) int) ([]*Message, error)
+}
+
+// TruncationFunc adapts a function to a TruncationStrategy.
+type TruncationFunc func(ctx context.Context, msgs []*Message, budget int, count func([]*Message) int) ([]*Message, error)
+
+// Truncate calls f(ctx, msgs, budget, count).
+func (f TruncationFunc) Truncate(ctx context.Context, msgs []*Message, budget int, count func([]*Message) int) ([]*Message, error) {
+	return f(ctx, msgs, budget, count)
+}
+
+// DropOldest drops the oldest messages until the rest fit, keeping system
+// messages and the latest message. Tool results are dropped with the message
+// calling the tool.
+func DropOldest() TruncationStrategy {
+	return TruncationFunc(func(ctx context.Context, msgs []*Message, budget int, count func([]*Message) int) ([]*Message, error) {
+		system, turns := splitTurns(msgs)
+		for len(turns) > 1 && count(joinTurns(system, turns)) > budget {
+			turns = turns[1:]
+		}
+		return joinTurns(system, turns), nil
+	})
+}
+
+// KeepSystemAndLast keeps the system messages and the last n other messages,
+// then drops the oldest of those until they fit.
+func KeepSystemAndLast(n int) TruncationStrategy {
+	return TruncationFunc(func(ctx context.Context, msgs []*Message, budget int, count func([]*Message) int) ([]*Message, error) {
+		system, turns := splitTurns(msgs)
+		kept := len(msgs) - len(system)
+		for len(turns) > 1 && kept > n {
+			kept -= len(turns[0])
+			turns = turns[1:]
+		}
+		return DropOldest().Truncate(ctx, joinTurns(system, turns), budget, count)
+	})
+}
+
+// Summarize replaces the messages DropOldest would drop with a system message
+// summarizing them, written by summarizer.
+func Summarize(summarizer Runner) TruncationStrategy {
+	return TruncationFunc(func(ctx context.Context, msgs []*Message, budget int, count func([]*Message) int) ([]*Message, error) {
+		system, turns := splitTurns(msgs)
+		// leave room for the summary, estimated at a tenth of the budget
+		reserve := budget / 10
+		var dropped []*Message
+		for len(turns) > 1 && count(joinTurns(system, turns)) > budget-reserve {
+			dropped = append(dropped, turns[0]...)
+			turns = turns[1:]
+		}
+		if len(dropped) == 0 {
+			return msgs, nil
+		}
+		var buf strings.Builder
+		buf.WriteString("Summarize the following conversation concisely, keeping the facts, decisions and open questions needed to continue it.\n\n")
+		for _, msg := range dropped {
+			if text := msg.Text(); text != "" {
+				fmt.Fprintf(&buf, "%s: %s\n", msg.Role, text)
+			}
+		}
+		res, err := summarizer.Run(ctx, NewPrompt(UserMessage(buf.String())))
+		if err != nil {
+			return nil, fmt.Errorf("summarize context: %w", err)
+		}
+		summary := SystemMessage("Summary of the earlier conversation:\n" + res.Text())
+		return joinTurns(append(system, summary), turns), nil
+	})
+}
+
+// splitTurns separates the system and developer messages from the others, grouped so that
+// tool results stay with the message calling the tool.
+func splitTurns(msgs []*Message) (system []*Message, turns [][]*Message) {
+	for _, msg := range msgs {
+		switch {
+		case msg.Role.IsInstruction():
+			system = append(system, msg)
+		case msg.Role == RoleTool && len(turns) > 0:
+			turns[len(turns)-1] = append(turns[len(turns)-1], msg)
+		default:
+			turns = append(turns, []*Message{msg})
+		}
+	}
+	return system, turns
+}
+
+func joinTurns(system []*Message, turns [][]*Message) []*Message {
+	msgs := append([]*Message{}, system...)
+	for _, turn := range turns {
+		msgs = append(msgs, turn...)
+	}
+	return msgs
+}
+
+// ContextOption configures a ContextManager.
+type ContextOption func(*ContextManager)
+
+// ContextWindow sets the context sizes of the models, DefaultContextSizes by default.
+func ContextWindow(sizes ContextSizes) ContextOption {
+	return func(m *ContextManager) {
+		m.sizes = sizes
+	}
+}
+
+// ContextStrategy sets how messages are trimmed, DropOldest by default.
+func ContextStrategy(strategy TruncationStrategy) ContextOption {
+	return func(m *ContextManager) {
+		m.strategy = strategy
+	}
+}
+
+// ContextReserve sets the tokens left free for the output when the request
+// does not set MaxOutputTokens, 4096 by default.
+func ContextReserve(tokens int) ContextOption {
+	return func(m *ContextManager) {
+		m.reserve = tokens
+	}
+}
+
+// ContextTokenCounter sets the function counting the tokens of messages sent
+// to a model, such as tokens.CountTokens. It estimates 4 bytes per token by
+// default.
+func ContextTokenCounter(count func(model string, msgs []*Message) int) ContextOption {
+	return func(m *ContextManager) {
+		m.count = count
+	}
+}
+
+// ContextManager fits requests to the context window of their model, trimming
+// their messages with a TruncationStrategy instead of letting the provider
+// fail with a context length error.
+type ContextManager struct {
+	sizes    ContextSizes
+	strategy TruncationStrategy
+	reserve  int
+	count    func(model string, msgs []*Message) int
+}
+
+// NewContextManager creates a ContextManager.
+func NewContextManager(opts ...ContextOption) *ContextManager {
+	m := &ContextManager{
+		sizes:    DefaultContextSizes(),
+		strategy: DropOldest(),
+		reserve:  4096,
+		count:    estimateMessageTokens,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Budget returns the tokens available to the messages of req, and false when
+// the context size of its model is unknown.
+func (m *ContextManager) Budget(req *ModelRequest, opts ...ModelOption) (int, bool) {
+	size, ok := m.sizes.Size(req.Model)
+	if !ok {
+		return 0, false
+	}
+	var mo ModelOptions
+	for _, opt := range opts {
+		opt(&mo)
+	}
+	reserve := m.reserve
+	if mo.MaxOutputTokens > 0 {
+		reserve = int(mo.MaxOutputTokens)
+	}
+	for _, tool := range req.Tools {
+		reserve += estimateTokens(len(tool.Name) + len(tool.Description))
+		if tool.InputSchema != nil {
+			if b, err := tool.InputSchema.MarshalJSON(); err == nil {
+				reserve += estimateTokens(len(b))
+			}
+		}
+	}
+	return size - reserve, true
+}
+
+// Fit returns req, or a copy of it with its messages trimmed when they exceed
+// the budget, and whether they were trimmed. Requests for models of unknown
+// context size are returned as is. It fails with ErrContextLength when the
+// trimmed messages still exceed the budget.
+func (m *ContextManager) Fit(ctx context.Context, req *ModelRequest, opts ...ModelOption) (*ModelRequest, bool, error) {
+	budget, ok := m.Budget(req, opts...)
+	if !ok {
+		return req, false, nil
+	}
+	count := func(msgs []*Message) int { return m.count(req.Model, msgs) }
+	if count(req.Messages) <= budget {
+		return req, false, nil
+	}
+	msgs, err := m.strategy.Truncate(ctx, req.Messages, budget, count)
+	if err != nil {
+		return nil, false, err
+	}
+	if n := count(msgs); n > budget {
+		return nil, false, fmt.Errorf("%w: %d tokens for a budget of %d with %s", ErrContextLength, n, budget, req.Model)
+	}
+	r := *req
+	r.Messages = msgs
+	return &r, true, nil
+}
+
+// estimateMessageTokens approximates the tokens of messages from their text and
+// tool calls, with a small overhead per message.
+func estimateMessageTokens(model string, msgs []*Message) int {
+	var n int
+	for _, msg := range msgs {
+		n += 4
+		for _, part := range msg.Parts {
+			if text, ok := part.(TextPart); ok {
+				n += estimateTokens(len(text.Text))
+			}
+		}
+		for _, call := range msg.ToolCalls {
+			n += estimateTokens(len(call.Name) + len(call.Arguments) + len(call.Result))
+		}
+	}
+	return n
+}
+
+// TruncatingProvider fits the requests to the context window of their model
+// with a ContextManager before sending them. Responses to trimmed requests
+// carry a WarningContextTrimmed.
+type TruncatingProvider struct {
+	provider ModelProvider
+	manager  *ContextManager
+}
+
+// NewTruncatingProvider wraps provider, fitting requests with manager.
+func NewTruncatingProvider(provider ModelProvider, manager *ContextManager) *TruncatingProvider {
+	return &TruncatingProvider{provider: provider, manager: manager}
+}
+
+// Generate fits the request and executes it.
+func (p *TruncatingProvider) Generate(ctx context.Context, req *ModelRequest, opts ...ModelOption) (*ModelResponse, error) {
+	fitted, trimmed, err := p.manager.Fit(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	res, err := p.provider.Generate(ctx, fitted, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed {
+		res.Warnings = append(res.Warnings, trimmedWarning(req, fitted))
+	}
+	return res, nil
+}
+
+// NewStream fits the request and opens the stream, reporting the trimming on
+// the first response.
+func (p *TruncatingProvider) NewStream(ctx context.Context, req *ModelRequest, opts ...ModelOption) (Streamer[*ModelResponse], error) {
+	fitted, trimmed, err := p.manager.Fit(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := p.provider.NewStream(ctx, fitted, opts...)
+	if err != nil || !trimmed {
+		return stream, err
+	}
+	warnings := []Warning{trimmedWarning(req, fitted)}
+	return NewMappedStream(stream, func(res *ModelResponse) (*ModelResponse, error) {
+		res.Warnings = append(res.Warnings, warnings...)
+		warnings = nil
+		return res, nil
+	}), nil
+}
+
+func trimmedWarning(req, fitted *ModelRequest) Warning {
+	return Warning{Code: WarningContextTrimmed, Message: fmt.Sprintf("%d of %d messages sent to fit the context window of %s", len(fitted.Messages), len(req.Messages), req.Model)}
+}
@@ -0,0 +1,56 @@
+package blades
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestContextManager(t *testing.T) {
+	ctx := context.Background()
+	long := strings.Repeat("x", 400) // about 100 tokens
+	call := AssistantMessage("")
+	call.ToolCalls = []*ToolCall{{ID: "1", Name: "weather"}}
+	result := &Message{Role: RoleTool, ToolCalls: []*ToolCall{{ID: "1", Name: "weather", Result: "sunny"}}}
+	req := &ModelRequest{Model: "tiny-1", Messages: []*Message{
+		SystemMessage("be brief"),
+		UserMessage(long), call, result,
+		UserMessage(long), AssistantMessage(long),
+		UserMessage("and tomorrow?"),
+	}}
+	sizes := ContextWindow(ContextSizes{"tiny": 300})
+
+	fitted, trimmed, err := NewContextManager(sizes, ContextReserve(50)).Fit(ctx, req)
+	if err != nil || !trimmed {
+		t.Fatalf("expected the request to be trimmed: %v", err)
+	}
+	if n := len(fitted.Messages); n != 6 || fitted.Messages[0].Role != RoleSystem || fitted.Messages[1] != call || fitted.Messages[n-1].Text() != "and tomorrow?" {
+		t.Fatalf("unexpected messages after dropping the oldest: %d", n)
+	}
+	// the tool result is dropped with its call
+	fitted, _, _ = NewContextManager(sizes, ContextReserve(100)).Fit(ctx, req)
+	if n := len(fitted.Messages); n != 3 || fitted.Messages[1].Role != RoleAssistant {
+		t.Fatalf("unexpected messages after dropping the oldest: %d", n)
+	}
+	if len(req.Messages) != 7 {
+		t.Fatalf("the original request was modified")
+	}
+
+	fitted, _, _ = NewContextManager(sizes, ContextReserve(50), ContextStrategy(KeepSystemAndLast(1))).Fit(ctx, req)
+	if len(fitted.Messages) != 2 {
+		t.Fatalf("expected the system and last message, got %d", len(fitted.Messages))
+	}
+
+	fitted, _, _ = NewContextManager(sizes, ContextReserve(50), ContextStrategy(Summarize(NewAgent("summarizer", WithProvider(textProvider("weather talk")))))).Fit(ctx, req)
+	if fitted.Messages[1].Role != RoleSystem || !strings.Contains(fitted.Messages[1].Text(), "weather talk") {
+		t.Fatalf("expected a summary after the system message")
+	}
+
+	if _, _, err := NewContextManager(sizes, ContextReserve(290)).Fit(ctx, req); !errors.Is(err, ErrContextLength) {
+		t.Fatalf("expected ErrContextLength, got %v", err)
+	}
+	if _, trimmed, _ := NewContextManager().Fit(ctx, req); trimmed {
+		t.Fatalf("unknown models must not be trimmed")
+	}
+}